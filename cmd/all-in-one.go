@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/daemon"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	inletkafka "akvorado/inlet/kafka"
+	outletkafka "akvorado/outlet/kafka"
+)
+
+type allInOneOptions struct {
+	ConfigRelatedOptions
+	CheckMode bool
+	Kafka     bool
+}
+
+// AllInOneOptions stores the command-line option values for the all-in-one
+// command.
+var AllInOneOptions allInOneOptions
+
+var allInOneCmd = &cobra.Command{
+	Use:   "all-in-one",
+	Short: "Start Akvorado's orchestrator, inlet, outlet and console in a single process",
+	Long: `Akvorado is a NetFlow/IPFIX collector. The all-in-one mode starts the orchestrator,
+inlet, outlet and console services in a single process, using the configuration
+file of the orchestrator. By default, the inlet sends flows to the outlet
+through an in-memory queue instead of Kafka. This is intended for demos, labs
+and small deployments.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := OrchestratorConfiguration{}
+		AllInOneOptions.Path = args[0]
+		AllInOneOptions.BeforeDump = orchestratorBeforeDump(&config)
+		if _, err := AllInOneOptions.Parse(cmd.OutOrStdout(), "orchestrator", &config); err != nil {
+			return err
+		}
+		return allInOneStart(config, AllInOneOptions.Kafka, AllInOneOptions.CheckMode)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(allInOneCmd)
+	allInOneCmd.Flags().BoolVarP(&AllInOneOptions.ConfigRelatedOptions.Dump, "dump", "D", false,
+		"Dump configuration before starting")
+	allInOneCmd.Flags().BoolVarP(&AllInOneOptions.CheckMode, "check", "C", false,
+		"Check configuration, but does not start")
+	allInOneCmd.Flags().BoolVarP(&AllInOneOptions.Kafka, "kafka", "", false,
+		"Use Kafka between the inlet and the outlet instead of an in-memory queue")
+}
+
+func allInOneStart(config OrchestratorConfiguration, withKafka bool, checkOnly bool) error {
+	if len(config.Inlet) != 1 || len(config.Outlet) != 1 || len(config.Console) != 1 {
+		return errors.New("all-in-one mode requires exactly one inlet, one outlet and one console configuration")
+	}
+	inletConfig, outletConfig, consoleConfig := config.Inlet[0], config.Outlet[0], config.Console[0]
+
+	// Each service gets its own reporter to keep metrics and healthchecks
+	// separate, but they share the daemon component and the HTTP server of
+	// the orchestrator. Log levels are global to the process: only the ones
+	// from the orchestrator configuration are used.
+	r, err := reporter.New(config.Reporting)
+	if err != nil {
+		return fmt.Errorf("unable to initialize reporter: %w", err)
+	}
+	serviceReporter := func(service string, reporting reporter.Configuration) (*reporter.Reporter, error) {
+		if len(reporting.Logging.Levels) > 0 {
+			r.Warn().Str("service", service).
+				Msg("log levels from the service configuration are ignored in all-in-one mode")
+		}
+		reporting.Logging = config.Reporting.Logging
+		sr, err := reporter.New(reporting)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize %s reporter: %w", service, err)
+		}
+		return sr, nil
+	}
+	inletReporter, err := serviceReporter("inlet", inletConfig.Reporting)
+	if err != nil {
+		return err
+	}
+	outletReporter, err := serviceReporter("outlet", outletConfig.Reporting)
+	if err != nil {
+		return err
+	}
+	consoleReporter, err := serviceReporter("console", consoleConfig.Reporting)
+	if err != nil {
+		return err
+	}
+	daemonComponent, err := daemon.New(r)
+	if err != nil {
+		return fmt.Errorf("unable to initialize daemon component: %w", err)
+	}
+	httpComponent, err := httpserver.New(r, config.HTTP, httpserver.Dependencies{
+		Daemon: daemonComponent,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to initialize HTTP component: %w", err)
+	}
+
+	// Link the inlet and the outlet
+	var sender inletkafka.Sender
+	var consumer outletkafka.Component
+	if withKafka {
+		sender, err = inletkafka.New(inletReporter, inletConfig.Kafka, inletkafka.Dependencies{
+			Daemon: daemonComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize inlet Kafka component: %w", err)
+		}
+		consumer, err = outletkafka.New(outletReporter, outletConfig.Kafka, outletkafka.Dependencies{
+			Daemon: daemonComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize outlet Kafka component: %w", err)
+		}
+	} else {
+		queue := make(chan []byte, inletConfig.Kafka.QueueSize)
+		sender = inletkafka.NewMemory(inletReporter, queue)
+		consumer = outletkafka.NewMemory(outletReporter, outletConfig.Kafka, queue)
+	}
+
+	// Initialize the components of each service. The inlet comes last to be
+	// stopped first. This way, the outlet can process the remaining flows
+	// before stopping. The HTTP server is started with the components of the
+	// orchestrator.
+	components := []any{inletReporter, outletReporter, consoleReporter}
+	more, err := orchestratorComponents(r, config, daemonComponent, httpComponent, withKafka)
+	if err != nil {
+		return err
+	}
+	components = append(components, more...)
+	more, err = outletComponents(outletReporter, outletConfig, daemonComponent, httpComponent, consumer, nil)
+	if err != nil {
+		return err
+	}
+	components = append(components, more...)
+	more, err = consoleComponents(consoleReporter, consoleConfig, daemonComponent, httpComponent)
+	if err != nil {
+		return err
+	}
+	components = append(components, more...)
+	more, err = inletComponents(inletReporter, inletConfig, daemonComponent, httpComponent, sender)
+	if err != nil {
+		return err
+	}
+	components = append(components, more...)
+
+	// Expose some information and metrics
+	addCommonHTTPHandlers(r, "orchestrator", httpComponent)
+	addServiceHTTPHandlers(inletReporter, "inlet", httpComponent)
+	addServiceHTTPHandlers(outletReporter, "outlet", httpComponent)
+	addServiceHTTPHandlers(consoleReporter, "console", httpComponent)
+	moreMetrics(r)
+	moreMetrics(inletReporter)
+	moreMetrics(outletReporter)
+	moreMetrics(consoleReporter)
+	reloader := newConfigReloader(r, config,
+		orchestratorParse(AllInOneOptions.ConfigRelatedOptions),
+		orchestratorReloaders(components)...)
+	addReloadHTTPHandlers("orchestrator", httpComponent, reloader.HTTPHandler)
+
+	// If we only asked for a check, stop here.
+	if checkOnly {
+		return nil
+	}
+
+	// Start all the components.
+	reloader.Watch(daemonComponent)
+	return StartStopComponents(r, daemonComponent, components,
+		inletConfig.ShutdownTimeout+outletConfig.ShutdownTimeout)
+}
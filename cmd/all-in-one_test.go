@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter/logger"
+)
+
+func TestAllInOneStart(t *testing.T) {
+	for _, withKafka := range []bool{false, true} {
+		t.Run(map[bool]string{false: "memory", true: "kafka"}[withKafka], func(t *testing.T) {
+			config := OrchestratorConfiguration{}
+			config.Reset()
+			if err := allInOneStart(config, withKafka, true); err != nil {
+				t.Fatalf("allInOneStart() error:\n%+v", err)
+			}
+		})
+	}
+
+	t.Run("log levels", func(t *testing.T) {
+		t.Cleanup(func() { logger.SetLevels(nil) })
+		config := OrchestratorConfiguration{}
+		config.Reset()
+		config.Reporting.Logging.Levels = map[string]zerolog.Level{"outlet/clickhouse": zerolog.WarnLevel}
+		config.Console[0].Reporting.Logging.Levels = map[string]zerolog.Level{"console": zerolog.DebugLevel}
+		if err := allInOneStart(config, false, true); err != nil {
+			t.Fatalf("allInOneStart() error:\n%+v", err)
+		}
+		_, got := logger.Levels()
+		expected := map[string]zerolog.Level{"outlet/clickhouse": zerolog.WarnLevel}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("Levels() (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("several inlets", func(t *testing.T) {
+		config := OrchestratorConfiguration{}
+		config.Reset()
+		config.Inlet = append(config.Inlet, config.Inlet[0])
+		if err := allInOneStart(config, false, true); err == nil {
+			t.Fatal("allInOneStart() did not error")
+		}
+	})
+}
+
+func TestAllInOne(t *testing.T) {
+	root := RootCmd
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetArgs([]string{"all-in-one", "--check", "/dev/null"})
+	err := root.Execute()
+	if err != nil {
+		t.Errorf("`all-in-one` error:\n%+v", err)
+	}
+}
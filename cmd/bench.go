@@ -0,0 +1,511 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
+	"akvorado/common/schema"
+	"akvorado/demoexporter/flows"
+	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/kafka"
+	"akvorado/outlet/metadata"
+	"akvorado/outlet/metadata/provider"
+	"akvorado/outlet/metadata/provider/static"
+)
+
+// benchExporter is the address of the synthetic exporter used for benchmarks.
+var benchExporter = netip.MustParseAddr("::ffff:192.0.2.1")
+
+// benchFlows are the flows generated for benchmarks. The rate does not matter
+// as packets are replayed at the requested rate.
+var benchFlows = []flows.FlowConfiguration{
+	{
+		PerSecond:  300,
+		InIfIndex:  []int{10, 11},
+		OutIfIndex: []int{20, 21, 22},
+		Multiplier: 1,
+		Profile:    "flat",
+		SrcNet:     netip.MustParsePrefix("192.0.2.0/24"),
+		DstNet:     netip.MustParsePrefix("198.51.100.0/24"),
+		SrcAS:      []uint32{65201, 65202},
+		DstAS:      []uint32{64501, 64502, 64503},
+		SrcPort:    []uint16{443, 80},
+		Protocol:   []string{"tcp"},
+		Size:       1300,
+
+		ReverseDirectionRatio: 0.1,
+	}, {
+		PerSecond:  200,
+		InIfIndex:  []int{10, 11},
+		OutIfIndex: []int{20, 21, 22},
+		Multiplier: 1,
+		Profile:    "flat",
+		SrcNet:     netip.MustParsePrefix("2001:db8:1::/48"),
+		DstNet:     netip.MustParsePrefix("2001:db8:2::/48"),
+		SrcAS:      []uint32{65201, 65202},
+		DstAS:      []uint32{64501, 64502, 64503},
+		DstPort:    []uint16{443, 53},
+		Protocol:   []string{"udp", "tcp"},
+	},
+}
+
+// benchPoolSeconds is the number of seconds of flows to generate. Packets are
+// replayed in a loop.
+const benchPoolSeconds = 10
+
+// benchQueueSize is the size of the in-memory queue between the generator and
+// the outlet workers.
+const benchQueueSize = 1000
+
+type benchOptions struct {
+	ConfigRelatedOptions
+	Rate     uint
+	Duration time.Duration
+	Discard  bool
+	JSON     bool
+}
+
+// BenchOptions stores the command-line option values for the bench
+// subcommands.
+var BenchOptions benchOptions
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark Akvorado's services",
+	Long: `Generate synthetic load for a service and report throughput, latencies and
+allocations. This is useful for capacity planning and to detect performance
+regressions.`,
+}
+
+var benchOutletCmd = &cobra.Command{
+	Use:   "outlet CONFIG",
+	Short: "Benchmark the outlet service",
+	Long: `Generate NetFlow packets from a synthetic exporter at the requested rate and
+send them through the decoding, enrichment and ClickHouse export steps of the
+outlet service. The configuration file is the one of the outlet. Kafka is
+replaced by an in-memory queue and metadata are provided statically. Unless
+--discard is used, flows are sent to the configured ClickHouse database, which
+should be a test one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Only warnings and errors are logged to keep the report readable.
+		if !debug {
+			logger.SetDefaultLevel(zerolog.WarnLevel)
+		}
+		config := OutletConfiguration{}
+		BenchOptions.Path = args[0]
+		if _, err := BenchOptions.Parse(cmd.OutOrStdout(), "outlet", &config); err != nil {
+			return err
+		}
+		r, err := reporter.New(config.Reporting)
+		if err != nil {
+			return fmt.Errorf("unable to initialize reporter: %w", err)
+		}
+		result, err := benchOutlet(r, config, BenchOptions)
+		if err != nil {
+			return err
+		}
+		return result.write(cmd.OutOrStdout(), BenchOptions.JSON)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(benchCmd)
+	benchCmd.AddCommand(benchOutletCmd)
+	benchOutletCmd.Flags().BoolVarP(&BenchOptions.ConfigRelatedOptions.Dump, "dump", "D", false,
+		"Dump configuration before starting")
+	benchOutletCmd.Flags().UintVarP(&BenchOptions.Rate, "rate", "r", 100_000,
+		"Target rate in flows per second (0 for as fast as possible)")
+	benchOutletCmd.Flags().DurationVarP(&BenchOptions.Duration, "duration", "", 30*time.Second,
+		"Duration of the benchmark")
+	benchOutletCmd.Flags().BoolVarP(&BenchOptions.Discard, "discard", "", false,
+		"Discard flows instead of sending them to ClickHouse")
+	benchOutletCmd.Flags().BoolVarP(&BenchOptions.JSON, "json", "", false,
+		"Output results as JSON")
+}
+
+// benchResult is the result of a benchmark. Durations are in seconds.
+type benchResult struct {
+	Duration         float64 `json:"duration"`
+	Packets          uint64  `json:"packets"`
+	Flows            uint64  `json:"flows"`
+	ForwardedFlows   uint64  `json:"forwarded-flows"`
+	PacketsPerSecond float64 `json:"packets-per-second"`
+	FlowsPerSecond   float64 `json:"flows-per-second"`
+	Latency          struct {
+		P50 float64 `json:"p50"`
+		P90 float64 `json:"p90"`
+		P99 float64 `json:"p99"`
+		Max float64 `json:"max"`
+	} `json:"latency"`
+	BytesPerFlow       float64 `json:"bytes-per-flow"`
+	AllocationsPerFlow float64 `json:"allocations-per-flow"`
+	GCCycles           uint32  `json:"gc-cycles"`
+}
+
+// write writes the result of the benchmark in a human-readable form or as
+// JSON.
+func (br benchResult) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(br)
+	}
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second)).Round(time.Microsecond)
+	}
+	fmt.Fprintf(w, "duration:        %s\n", seconds(br.Duration).Round(time.Millisecond))
+	fmt.Fprintf(w, "packets:         %d (%.0f/s)\n", br.Packets, br.PacketsPerSecond)
+	fmt.Fprintf(w, "flows:           %d (%.0f/s)\n", br.Flows, br.FlowsPerSecond)
+	fmt.Fprintf(w, "forwarded flows: %d\n", br.ForwardedFlows)
+	fmt.Fprintf(w, "packet latency:  p50=%s p90=%s p99=%s max=%s\n",
+		seconds(br.Latency.P50), seconds(br.Latency.P90),
+		seconds(br.Latency.P99), seconds(br.Latency.Max))
+	fmt.Fprintf(w, "allocations:     %.0f B/flow, %.1f allocs/flow, %d GC cycles\n",
+		br.BytesPerFlow, br.AllocationsPerFlow, br.GCCycles)
+	return nil
+}
+
+// benchStats collects statistics from the outlet workers.
+type benchStats struct {
+	packets   atomic.Uint64
+	flows     atomic.Uint64
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+// reset resets the collected statistics.
+func (bs *benchStats) reset() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.packets.Store(0)
+	bs.flows.Store(0)
+	bs.latencies = bs.latencies[:0]
+}
+
+// percentile returns the requested percentile of the collected latencies.
+// They should be sorted.
+func (bs *benchStats) percentile(p float64) float64 {
+	if len(bs.latencies) == 0 {
+		return 0
+	}
+	i := min(int(p*float64(len(bs.latencies))), len(bs.latencies)-1)
+	return bs.latencies[i].Seconds()
+}
+
+// benchConsumer wraps a consumer to measure the time spent to hand each message
+// to the outlet. Decoding happens asynchronously and the time includes waiting
+// for the decoders when they are busy.
+type benchConsumer struct {
+	kafka.Component
+	stats *benchStats
+}
+
+// StartWorkers starts the workers with an instrumented callback.
+func (c benchConsumer) StartWorkers(workerBuilder kafka.WorkerBuilderFunc) error {
+	return c.Component.StartWorkers(func(i int, scaleRequestChan chan<- kafka.ScaleRequest) (kafka.ReceiveFunc, kafka.ShutdownFunc) {
+		callback, shutdown := workerBuilder(i, scaleRequestChan)
+		return func(ctx context.Context, message []byte) error {
+			start := time.Now()
+			err := callback(ctx, message)
+			latency := time.Since(start)
+			c.stats.mu.Lock()
+			c.stats.latencies = append(c.stats.latencies, latency)
+			c.stats.mu.Unlock()
+			c.stats.packets.Add(1)
+			return err
+		}, shutdown
+	})
+}
+
+// benchClickHouse wraps the ClickHouse exporter to count forwarded flows. When
+// the wrapped component is nil, flows are batched and discarded.
+type benchClickHouse struct {
+	clickhouse.Component
+	batchSize int
+	stats     *benchStats
+}
+
+// NewWorker creates a new worker, discarding flows if there is no wrapped
+// component.
+func (c benchClickHouse) NewWorker(i int, bf *schema.FlowMessage) clickhouse.Worker {
+	if c.Component != nil {
+		return c.Component.NewWorker(i, bf)
+	}
+	return &benchClickHouseWorker{
+		bf:        bf,
+		batchSize: c.batchSize,
+	}
+}
+
+// Finalize counts the current flow and finalizes it.
+func (c benchClickHouse) Finalize(bf *schema.FlowMessage) {
+	c.stats.flows.Add(1)
+	if c.Component != nil {
+		c.Component.Finalize(bf)
+		return
+	}
+	bf.Finalize()
+}
+
+// SendAccounting sends the ingest accounting records, discarding them if there
+// is no wrapped component.
+func (c benchClickHouse) SendAccounting(ctx context.Context, t time.Time, records []clickhouse.ExporterAccounting) error {
+	if c.Component != nil {
+		return c.Component.SendAccounting(ctx, t, records)
+	}
+	return nil
+}
+
+// SendQuarantine sends the quarantined flows, discarding them if there is no
+// wrapped component.
+func (c benchClickHouse) SendQuarantine(ctx context.Context, flows []clickhouse.QuarantinedFlow) error {
+	if c.Component != nil {
+		return c.Component.SendQuarantine(ctx, flows)
+	}
+	return nil
+}
+
+// SendInterfaceCounters sends the counter samples, discarding them if there is
+// no wrapped component.
+func (c benchClickHouse) SendInterfaceCounters(ctx context.Context, samples []clickhouse.InterfaceCounters) error {
+	if c.Component != nil {
+		return c.Component.SendInterfaceCounters(ctx, samples)
+	}
+	return nil
+}
+
+// ReduceBatchSize forwards the request to the wrapped component, if any.
+func (c benchClickHouse) ReduceBatchSize(reduce bool) {
+	if c.Component != nil {
+		c.Component.ReduceBatchSize(reduce)
+	}
+}
+
+// benchClickHouseWorker discards the flows.
+type benchClickHouseWorker struct {
+	bf        *schema.FlowMessage
+	batchSize int
+}
+
+// Send clears the batch when full.
+func (w *benchClickHouseWorker) Send(context.Context) clickhouse.WorkerStatus {
+	if w.bf.FlowCount() >= w.batchSize {
+		w.bf.Clear()
+	}
+	return clickhouse.WorkerStatusIdle
+}
+
+// Flush clears the current batch.
+func (w *benchClickHouseWorker) Flush(context.Context) {
+	w.bf.Clear()
+}
+
+// Deadline returns the zero time as there is no need to send a partial batch.
+func (w *benchClickHouseWorker) Deadline() time.Time {
+	return time.Time{}
+}
+
+// benchMessages generates the raw flows to send to the outlet. The first one
+// defines the templates.
+func benchMessages() ([][]byte, []int, error) {
+	config := flows.DefaultConfiguration()
+	config.Flows = benchFlows
+	now := time.Now().Truncate(time.Second)
+	start := now.Add(-time.Hour)
+	rawFlow := pb.RawFlow{
+		TimeReceived:  uint64(now.Unix()),
+		SourceAddress: benchExporter.AsSlice(),
+		Decoder:       pb.RawFlow_DECODER_NETFLOW,
+		Version:       uint32(pb.Version),
+	}
+	messages := [][]byte{}
+	flowCounts := []int{}
+	for i := range benchPoolSeconds {
+		packets := flows.GeneratePackets(config, start, now.Add(time.Duration(i)*time.Second))
+		if i > 0 {
+			packets = packets[1:]
+		}
+		for _, packet := range packets {
+			rawFlow.Payload = packet.Payload
+			message, err := rawFlow.MarshalVT()
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot encode raw flow: %w", err)
+			}
+			messages = append(messages, message)
+			flowCounts = append(flowCounts, packet.Flows)
+		}
+	}
+	return messages, flowCounts, nil
+}
+
+// waitFor waits for the provided condition to be true. It returns false on
+// timeout.
+func waitFor(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+// benchOutlet runs the outlet components against an in-memory queue fed with
+// synthetic flows and returns the observed performance.
+func benchOutlet(r *reporter.Reporter, config OutletConfiguration, options benchOptions) (result benchResult, err error) {
+	// Flows come from a synthetic exporter: we don't need the HTTP server and
+	// the metadata are static. We don't want to touch the persisted state of
+	// a running outlet either.
+	config.HTTP.Listen = ""
+	config.Flow.StatePersistFile = ""
+	config.Metadata.CachePersistFile = ""
+	config.Metadata.Providers = []metadata.ProviderConfiguration{{
+		Config: static.Configuration{
+			Exporters: helpers.MustNewSubnetMap(map[string]static.ExporterConfiguration{
+				benchExporter.String() + "/128": {
+					Exporter: provider.Exporter{Name: "bench"},
+					Default: provider.Interface{
+						Name:        "Gi0/0/0",
+						Description: "bench interface",
+						Speed:       10000,
+					},
+				},
+			}),
+		},
+	}}
+
+	messages, flowCounts, err := benchMessages()
+	if err != nil {
+		return result, err
+	}
+
+	daemonComponent, err := daemon.New(r)
+	if err != nil {
+		return result, fmt.Errorf("unable to initialize daemon component: %w", err)
+	}
+	httpComponent, err := httpserver.New(r, config.HTTP, httpserver.Dependencies{
+		Daemon: daemonComponent,
+	})
+	if err != nil {
+		return result, fmt.Errorf("unable to initialize HTTP component: %w", err)
+	}
+	stats := &benchStats{}
+	queue := make(chan []byte, benchQueueSize)
+	consumer := benchConsumer{
+		Component: kafka.NewMemory(r, config.Kafka, queue),
+		stats:     stats,
+	}
+	wrapClickHouse := func(c clickhouse.Component) clickhouse.Component {
+		if options.Discard {
+			c = nil
+		}
+		return benchClickHouse{
+			Component: c,
+			batchSize: int(config.ClickHouse.MaximumBatchSize),
+			stats:     stats,
+		}
+	}
+	components, err := outletComponents(r, config, daemonComponent, httpComponent, consumer, wrapClickHouse)
+	if err != nil {
+		return result, err
+	}
+
+	// Start the components and stop them in reverse order when done.
+	components = append([]any{r, daemonComponent, httpComponent}, components...)
+	startedComponents := []any{}
+	defer func() {
+		if stopErr := stopComponents(r, startedComponents, config.ShutdownTimeout); err == nil {
+			err = stopErr
+		}
+	}()
+	for _, cmp := range components {
+		if starterC, ok := cmp.(starter); ok {
+			if err := starterC.Start(); err != nil {
+				return result, fmt.Errorf("unable to start component: %w", err)
+			}
+		}
+		startedComponents = append([]any{cmp}, startedComponents...)
+	}
+
+	// Send the templates first. As decoding is asynchronous, send a data
+	// packet until we get flows to know the templates were processed and let
+	// the last ones drain.
+	queue <- messages[0]
+	if !waitFor(config.ShutdownTimeout, func() bool {
+		if stats.flows.Load() > 0 {
+			return true
+		}
+		if len(queue) == 0 {
+			queue <- messages[1]
+		}
+		return false
+	}) {
+		return result, errors.New("timeout while waiting for templates to be processed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	messages, flowCounts = messages[1:], flowCounts[1:]
+	stats.reset()
+
+	// Send packets at the requested rate.
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; time.Since(start) < options.Duration; {
+		if options.Rate > 0 && result.Flows >= uint64(time.Since(start).Seconds()*float64(options.Rate)) {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		queue <- messages[i]
+		result.Packets++
+		result.Flows += uint64(flowCounts[i])
+		i = (i + 1) % len(messages)
+	}
+	if !waitFor(config.ShutdownTimeout, func() bool { return stats.packets.Load() == result.Packets }) {
+		return result, errors.New("timeout while waiting for packets to be processed")
+	}
+	// Stopping the workers waits for the queued packets to be decoded.
+	consumer.StopWorkers()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	slices.Sort(stats.latencies)
+	result.Duration = elapsed.Seconds()
+	result.ForwardedFlows = stats.flows.Load()
+	result.PacketsPerSecond = float64(result.Packets) / elapsed.Seconds()
+	result.FlowsPerSecond = float64(result.ForwardedFlows) / elapsed.Seconds()
+	result.Latency.P50 = stats.percentile(0.50)
+	result.Latency.P90 = stats.percentile(0.90)
+	result.Latency.P99 = stats.percentile(0.99)
+	result.Latency.Max = stats.percentile(1)
+	if result.ForwardedFlows > 0 {
+		result.BytesPerFlow = float64(after.TotalAlloc-before.TotalAlloc) / float64(result.ForwardedFlows)
+		result.AllocationsPerFlow = float64(after.Mallocs-before.Mallocs) / float64(result.ForwardedFlows)
+	}
+	result.GCCycles = after.NumGC - before.NumGC
+	return result, nil
+}
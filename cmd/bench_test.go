@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/outlet/routing/provider/bmp"
+)
+
+func TestBenchOutlet(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := OutletConfiguration{}
+	config.Reset()
+	bmpConfig := config.Routing.Provider.Config.(bmp.Configuration)
+	bmpConfig.Listen = "127.0.0.1:0"
+	config.Routing.Provider.Config = bmpConfig
+
+	result, err := benchOutlet(r, config, benchOptions{
+		Rate:     20_000,
+		Duration: 500 * time.Millisecond,
+		Discard:  true,
+	})
+	if err != nil {
+		t.Fatalf("benchOutlet() error:\n%+v", err)
+	}
+	if result.Flows == 0 || result.Packets == 0 {
+		t.Fatalf("benchOutlet() did not send anything (%d packets, %d flows)",
+			result.Packets, result.Flows)
+	}
+	if result.ForwardedFlows != result.Flows {
+		t.Errorf("benchOutlet() forwarded %d flows, expected %d", result.ForwardedFlows, result.Flows)
+	}
+	if result.Flows > 20_000 {
+		t.Errorf("benchOutlet() sent %d flows, expected at most 20000", result.Flows)
+	}
+	if result.Latency.P50 <= 0 || result.Latency.Max < result.Latency.P99 {
+		t.Errorf("benchOutlet() latencies are incorrect: %+v", result.Latency)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := result.write(buf, true); err != nil {
+		t.Fatalf("write() error:\n%+v", err)
+	}
+	var got benchResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error:\n%+v", err)
+	}
+	if got != result {
+		t.Errorf("write() JSON output does not match: %+v", got)
+	}
+}
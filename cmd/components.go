@@ -4,24 +4,33 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"akvorado/common/daemon"
 	"akvorado/common/helpers"
 	"akvorado/common/reporter"
 )
 
-// StartStopComponents activate/deactivate components in order.
-func StartStopComponents(r *reporter.Reporter, daemonComponent daemon.Component, otherComponents []any) error {
+// defaultShutdownTimeout is the total time allowed to stop all components for
+// services without a configurable deadline.
+const defaultShutdownTimeout = 30 * time.Second
+
+// errShutdownTimeout is returned when components were not stopped in time.
+var errShutdownTimeout = errors.New("shutdown deadline exceeded")
+
+// StartStopComponents activate/deactivate components in order. Components are
+// stopped in the reverse order: the ones receiving data are stopped first, the
+// HTTP server is stopped last. The provided timeout bounds the total time to
+// stop all components (0 means no limit).
+func StartStopComponents(r *reporter.Reporter, daemonComponent daemon.Component, otherComponents []any, shutdownTimeout time.Duration) (err error) {
 	components := append([]any{r, daemonComponent}, otherComponents...)
 	startedComponents := []any{}
 	defer func() {
-		for _, cmp := range startedComponents {
-			if stopperC, ok := cmp.(stopper); ok {
-				if err := stopperC.Stop(); err != nil {
-					r.Err(err).Msg("unable to stop component, ignoring")
-				}
-			}
+		if stopErr := stopComponents(r, startedComponents, shutdownTimeout); err == nil {
+			err = stopErr
 		}
 	}()
 	for _, cmp := range components {
@@ -42,6 +51,57 @@ func StartStopComponents(r *reporter.Reporter, daemonComponent daemon.Component,
 	return nil
 }
 
+// stopComponents stops the provided components in order, logging the progress
+// of each stage. It gives up when the timeout is exceeded.
+func stopComponents(r *reporter.Reporter, components []any, timeout time.Duration) error {
+	var current atomic.Pointer[string]
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		for _, cmp := range components {
+			stopperC, ok := cmp.(stopper)
+			if !ok {
+				continue
+			}
+			name := fmt.Sprintf("%T", cmp)
+			current.Store(&name)
+			stageStart := time.Now()
+			r.Info().Str("component", name).Msg("stopping component")
+			if err := stopperC.Stop(); err != nil {
+				r.Err(err).Str("component", name).Msg("unable to stop component, ignoring")
+				continue
+			}
+			r.Info().
+				Str("component", name).
+				Dur("duration", time.Since(stageStart)).
+				Msg("component stopped")
+		}
+	}()
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case <-done:
+		r.Info().Dur("duration", time.Since(start)).Msg("all components stopped")
+		return nil
+	case <-deadline:
+		var name string
+		if current := current.Load(); current != nil {
+			name = *current
+		}
+		r.Error().
+			Str("component", name).
+			Dur("timeout", timeout).
+			Msg("shutdown deadline exceeded, giving up")
+		return errShutdownTimeout
+	}
+}
+
 type starter interface {
 	Start() error
 }
@@ -63,7 +63,7 @@ func TestStartStopError(t *testing.T) {
 		&ComponentStartError{},
 		&ComponentStartStop{},
 	}
-	if err := cmd.StartStopComponents(r, daemonComponent, otherComponents); err == nil {
+	if err := cmd.StartStopComponents(r, daemonComponent, otherComponents, 0); err == nil {
 		t.Error("StartStopComponents() did not trigger an error")
 	}
 
@@ -100,7 +100,7 @@ func TestStartStop(t *testing.T) {
 		time.Sleep(10 * time.Millisecond)
 		daemonComponent.Terminate()
 	}()
-	if err := cmd.StartStopComponents(r, daemonComponent, otherComponents); err != nil {
+	if err := cmd.StartStopComponents(r, daemonComponent, otherComponents, 0); err != nil {
 		t.Errorf("StartStopComponents() error:\n%+v", err)
 	}
 
@@ -121,3 +121,33 @@ func TestStartStop(t *testing.T) {
 		t.Errorf("StartStopComponents() (-got, +want):\n%s", diff)
 	}
 }
+
+type ComponentStopSlow struct {
+	Stopable
+}
+
+func (c *ComponentStopSlow) Stop() error {
+	time.Sleep(time.Second)
+	return c.Stopable.Stop()
+}
+
+func TestStartStopTimeout(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	otherComponents := []any{
+		&ComponentStop{},
+		&ComponentStopSlow{},
+		&ComponentStartStop{},
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		daemonComponent.Terminate()
+	}()
+	start := time.Now()
+	if err := cmd.StartStopComponents(r, daemonComponent, otherComponents, 100*time.Millisecond); err == nil {
+		t.Error("StartStopComponents() did not trigger an error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("StartStopComponents() took %s, expected about 100ms", elapsed)
+	}
+}
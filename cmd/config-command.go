@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/helpers/yaml"
+)
+
+// maskedSecret replaces secrets when rendering a configuration.
+const maskedSecret = "********"
+
+type configCommandOptions struct {
+	Service string
+	NoMask  bool
+}
+
+// ConfigCommandOptions stores the command-line option values for the config
+// subcommands.
+var ConfigCommandOptions configCommandOptions
+
+// configServices maps each service to a function returning its configuration
+// and the associated options.
+var configServices = map[string]func() (ConfigRelatedOptions, any){
+	"orchestrator": func() (ConfigRelatedOptions, any) {
+		config := OrchestratorConfiguration{}
+		return ConfigRelatedOptions{BeforeDump: orchestratorBeforeDump(&config)}, &config
+	},
+	"inlet": func() (ConfigRelatedOptions, any) {
+		return ConfigRelatedOptions{}, &InletConfiguration{}
+	},
+	"outlet": func() (ConfigRelatedOptions, any) {
+		return ConfigRelatedOptions{}, &OutletConfiguration{}
+	},
+	"console": func() (ConfigRelatedOptions, any) {
+		return ConfigRelatedOptions{}, &ConsoleConfiguration{}
+	},
+	"demo-exporter": func() (ConfigRelatedOptions, any) {
+		return ConfigRelatedOptions{}, &DemoExporterConfiguration{}
+	},
+}
+
+// parse parses and validates the provided configuration file for the
+// configured service.
+func (o configCommandOptions) parse(path string) (any, error) {
+	newConfig, ok := configServices[o.Service]
+	if !ok {
+		services := make([]string, 0, len(configServices))
+		for service := range configServices {
+			services = append(services, service)
+		}
+		slices.Sort(services)
+		return nil, fmt.Errorf("unknown service %q (valid: %s)", o.Service, strings.Join(services, ", "))
+	}
+	options, config := newConfig()
+	options.Path = path
+	if _, err := options.Parse(io.Discard, o.Service, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Handle configuration files",
+	Long: `Validate or render configuration files without starting a service. This is
+useful in deployment pipelines or to debug a configuration.`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate CONFIG",
+	Short: "Validate a configuration file",
+	Long: `Validate a configuration file against the configuration of all the components
+of a service. For the orchestrator, this includes the configuration of the other
+services.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := ConfigCommandOptions.parse(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "configuration is valid")
+		return nil
+	},
+}
+
+var configRenderCmd = &cobra.Command{
+	Use:   "render CONFIG",
+	Short: "Render a configuration file",
+	Long: `Render a configuration file with all the default values. Included files and
+environment variables are resolved. Secrets are masked, unless requested
+otherwise.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := ConfigCommandOptions.parse(args[0])
+		if err != nil {
+			return err
+		}
+		output, err := yaml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("unable to render configuration: %w", err)
+		}
+		if !ConfigCommandOptions.NoMask {
+			var raw any
+			if err := yaml.Unmarshal(output, &raw); err != nil {
+				return fmt.Errorf("unable to render configuration: %w", err)
+			}
+			output, err = yaml.Marshal(maskSecrets(raw))
+			if err != nil {
+				return fmt.Errorf("unable to render configuration: %w", err)
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "---\n%s", output)
+		return nil
+	},
+}
+
+// isSecretKey tells if the provided configuration key is a secret.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "")
+	key = strings.ReplaceAll(key, "_", "")
+	if key == "key" {
+		return true
+	}
+	if strings.HasSuffix(key, "url") || strings.HasSuffix(key, "file") {
+		return false
+	}
+	for _, word := range []string{"password", "secret", "token", "authorization"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecrets replaces non-empty secrets in a raw configuration.
+func maskSecrets(raw any) any {
+	switch raw := raw.(type) {
+	case map[string]any:
+		for key, value := range raw {
+			if s, ok := value.(string); ok && s != "" && isSecretKey(key) {
+				raw[key] = maskedSecret
+			} else {
+				raw[key] = maskSecrets(value)
+			}
+		}
+	case []any:
+		for i, value := range raw {
+			raw[i] = maskSecrets(value)
+		}
+	}
+	return raw
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configRenderCmd)
+	configCmd.PersistentFlags().StringVarP(&ConfigCommandOptions.Service, "service", "s", "orchestrator",
+		"Service the configuration is for")
+	configRenderCmd.Flags().BoolVar(&ConfigCommandOptions.NoMask, "no-mask", false,
+		"Do not mask secrets")
+}
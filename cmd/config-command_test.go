@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"akvorado/cmd"
+)
+
+func TestConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte("kafka:\n  brokers: [kafka:9092]\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("kafka:\n  brokers: [kafka:9092]\n  unknown: 1\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+
+	root := cmd.RootCmd
+	t.Run("valid", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		root.SetOut(buf)
+		root.SetArgs([]string{"config", "validate", "--service", "orchestrator", valid})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("`config validate` error:\n%+v", err)
+		}
+		if got := buf.String(); got != "configuration is valid\n" {
+			t.Errorf("`config validate` output:\n%s", got)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		root.SetOut(new(bytes.Buffer))
+		root.SetArgs([]string{"config", "validate", "--service", "orchestrator", invalid})
+		err := root.Execute()
+		if err == nil {
+			t.Fatal("`config validate` did not error")
+		}
+		if !strings.Contains(err.Error(), "at line 3 of invalid.yaml") {
+			t.Errorf("`config validate` error:\n%+v", err)
+		}
+	})
+	t.Run("unknown service", func(t *testing.T) {
+		root.SetOut(new(bytes.Buffer))
+		root.SetArgs([]string{"config", "validate", "--service", "unknown", valid})
+		err := root.Execute()
+		if err == nil || !strings.Contains(err.Error(), `unknown service "unknown"`) {
+			t.Errorf("`config validate` error:\n%+v", err)
+		}
+	})
+}
+
+func TestConfigRender(t *testing.T) {
+	config := filepath.Join("testdata", "configurations", "kafka-sasl-tls", "in.yaml")
+	root := cmd.RootCmd
+
+	t.Run("masked", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		root.SetOut(buf)
+		root.SetArgs([]string{"config", "render", "--service", "orchestrator", "--no-mask=false", config})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("`config render` error:\n%+v", err)
+		}
+		got := buf.String()
+		if !strings.HasPrefix(got, "---\n") {
+			t.Errorf("`config render` output does not start with a document marker:\n%s", got)
+		}
+		if !strings.Contains(got, "password: '********'") {
+			t.Errorf("`config render` output does not contain a masked password:\n%s", got)
+		}
+		if !strings.Contains(got, "username: hello") {
+			t.Errorf("`config render` output does not contain SASL username:\n%s", got)
+		}
+		if strings.Contains(got, "bye") {
+			t.Errorf("`config render` output contains the password:\n%s", got)
+		}
+	})
+	t.Run("unmasked", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		root.SetOut(buf)
+		root.SetArgs([]string{"config", "render", "--service", "orchestrator", "--no-mask", config})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("`config render` error:\n%+v", err)
+		}
+		if !strings.Contains(buf.String(), "password: bye") {
+			t.Errorf("`config render` output does not contain the password:\n%s", buf.String())
+		}
+	})
+}
@@ -4,6 +4,9 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -12,9 +15,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"testing/fstest"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -22,15 +29,55 @@ import (
 
 	"akvorado/common/helpers/yaml"
 
+	"akvorado/common/daemon"
 	"akvorado/common/helpers"
+	"akvorado/common/reporter"
 )
 
 // ConfigRelatedOptions are command-line options related to handling a
 // configuration file.
 type ConfigRelatedOptions struct {
-	Path       string
-	Dump       bool
-	BeforeDump func(mapstructure.Metadata)
+	Path           string
+	Dump           bool
+	BeforeDump     func(mapstructure.Metadata)
+	ReloadInterval time.Duration
+}
+
+// errConfigurationModified is returned when a service stopped because its
+// configuration was modified.
+var errConfigurationModified = errors.New("configuration modified")
+
+// configurationURL returns the URL to fetch the configuration from or nil if
+// the configuration is not fetched through HTTP.
+func (c ConfigRelatedOptions) configurationURL(component string) (*url.URL, error) {
+	if !strings.HasPrefix(c.Path, "http://") && !strings.HasPrefix(c.Path, "https://") {
+		return nil, nil
+	}
+	u, err := url.Parse(c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse configuration URL: %w", err)
+	}
+	if u.Path == "" {
+		u.Path = fmt.Sprintf("/api/v0/orchestrator/configuration/%s", component)
+	}
+	if u.Fragment != "" {
+		u.Path = fmt.Sprintf("%s/%s", u.Path, u.Fragment)
+		u.Fragment = ""
+	}
+	return u, nil
+}
+
+// fetchConfiguration fetches the configuration from the provided URL. The
+// version and the features supported by the component are advertised to the
+// orchestrator.
+func fetchConfiguration(u *url.URL, component string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Akvorado-Version", helpers.AkvoradoVersion)
+	req.Header.Set("X-Akvorado-Features", featuresHeader(component))
+	return http.DefaultClient.Do(req)
 }
 
 // Parse parses the configuration file (if present) and the environment
@@ -39,23 +86,23 @@ type ConfigRelatedOptions struct {
 func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any) ([]string, error) {
 	var rawConfig gin.H
 	var paths []string
+	var positions yaml.Positions
 	if cfgFile := c.Path; cfgFile != "" {
-		if strings.HasPrefix(cfgFile, "http://") || strings.HasPrefix(cfgFile, "https://") {
-			u, err := url.Parse(cfgFile)
-			if err != nil {
-				return nil, fmt.Errorf("cannot parse configuration URL: %w", err)
-			}
-			if u.Path == "" {
-				u.Path = fmt.Sprintf("/api/v0/orchestrator/configuration/%s", component)
-			}
-			if u.Fragment != "" {
-				u.Path = fmt.Sprintf("%s/%s", u.Path, u.Fragment)
-			}
-			resp, err := http.Get(u.String())
+		u, err := c.configurationURL(component)
+		if err != nil {
+			return nil, err
+		}
+		if u != nil {
+			resp, err := fetchConfiguration(u, component)
 			if err != nil {
 				return nil, fmt.Errorf("unable to fetch configuration file: %w", err)
 			}
 			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusConflict {
+				var body struct{ Message string }
+				json.NewDecoder(resp.Body).Decode(&body)
+				return nil, fmt.Errorf("configuration refused by orchestrator: %s", body.Message)
+			}
 			contentType := resp.Header.Get("Content-Type")
 			mediaType, _, err := mime.ParseMediaType(contentType)
 			if (mediaType != "application/x-yaml" && mediaType != "application/yaml") || err != nil {
@@ -77,7 +124,8 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 			if dirname == "" {
 				dirname = "."
 			}
-			paths, err = yaml.UnmarshalWithInclude(os.DirFS(dirname), filename, &rawConfig)
+			paths, positions, err = yaml.UnmarshalWithOptions(os.DirFS(dirname), filename, &rawConfig,
+				yaml.Options{LookupEnv: os.LookupEnv})
 			for i := range paths {
 				paths[i] = filepath.Clean(filepath.Join(dirname, paths[i]))
 			}
@@ -87,6 +135,86 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 		}
 	}
 
+	if err := c.decode(out, component, rawConfig, positions, config); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// WatchURL polls the configuration URL, if any, at the configured interval and
+// terminates the daemon when the received configuration changes. The returned
+// flag is set when this happens.
+func (c ConfigRelatedOptions) WatchURL(r *reporter.Reporter, component string, daemonComponent daemon.Component) *atomic.Bool {
+	modified := &atomic.Bool{}
+	u, err := c.configurationURL(component)
+	if err != nil || u == nil || c.ReloadInterval == 0 {
+		return modified
+	}
+	fetch := func() ([32]byte, string, error) {
+		resp, err := fetchConfiguration(u, component)
+		if err != nil {
+			return [32]byte{}, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return [32]byte{}, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return [32]byte{}, "", err
+		}
+		return sha256.Sum256(body), resp.Header.Get("X-Akvorado-Configuration-Version"), nil
+	}
+	current, version, err := fetch()
+	if err != nil {
+		r.Err(err).Msg("cannot fetch configuration, not watching for changes")
+		return modified
+	}
+	r.Info().Str("url", u.String()).Str("version", version).Msg("watching configuration for changes")
+	go func() {
+		ticker := time.NewTicker(c.ReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-daemonComponent.Terminated():
+				return
+			case <-ticker.C:
+				sum, version, err := fetch()
+				if err != nil {
+					r.Err(err).Msg("cannot fetch configuration")
+					continue
+				}
+				if sum == current {
+					continue
+				}
+				r.Info().Str("version", version).Msg("restart on configuration change")
+				modified.Store(true)
+				daemonComponent.Terminate()
+				return
+			}
+		}
+	}()
+	return modified
+}
+
+// ParseBytes parses the provided configuration file and the environment
+// variables into the provided configuration. The "!include" tag is not
+// supported and "${VAR}" is not interpolated.
+func (c ConfigRelatedOptions) ParseBytes(out io.Writer, component string, input []byte, config any) error {
+	var rawConfig gin.H
+	_, positions, err := yaml.UnmarshalWithOptions(fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: input},
+	}, "config.yaml", &rawConfig, yaml.Options{})
+	if err != nil {
+		return fmt.Errorf("unable to parse YAML configuration file: %w", err)
+	}
+	return c.decode(out, component, rawConfig, positions, config)
+}
+
+// decode decodes a raw configuration and the environment variables into the
+// provided configuration. The positions are used to locate errors in the
+// original files.
+func (c ConfigRelatedOptions) decode(out io.Writer, component string, rawConfig gin.H, positions yaml.Positions, config any) error {
 	// Parse provided configuration
 	defaultHook, disableDefaultHook := DefaultHook()
 	zeroSliceHook, disableZeroSliceHook := ZeroSliceHook()
@@ -96,10 +224,10 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 	decoderConfig.Metadata = &metadata
 	decoder, err := mapstructure.NewDecoder(decoderConfig)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create configuration decoder: %w", err)
+		return fmt.Errorf("unable to create configuration decoder: %w", err)
 	}
 	if err := decoder.Decode(rawConfig); err != nil {
-		return nil, fmt.Errorf("unable to parse configuration: %w", err)
+		return fmt.Errorf("unable to parse configuration: %w", err)
 	}
 	disableDefaultHook()
 	disableZeroSliceHook()
@@ -132,20 +260,23 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 			}
 		}
 		if err := decoder.Decode(rawConfig); err != nil {
-			return nil, fmt.Errorf("unable to parse override %q: %w", kv[0], err)
+			return fmt.Errorf("unable to parse override %q: %w", kv[0], err)
 		}
 	}
 
 	// Check for unused keys
+	locator := newConfigLocator(positions)
+	unusedKeys := slices.Clone(metadata.Unused)
+	sort.Strings(unusedKeys)
 	invalidKeys := []string{}
-	for _, key := range metadata.Unused {
+	for _, key := range unusedKeys {
 		if !strings.HasPrefix(key, ".") && !strings.Contains(key, "..") {
-			invalidKeys = append(invalidKeys, fmt.Sprintf("invalid key %q", key))
+			invalidKeys = append(invalidKeys,
+				locator.annotate(strings.Split(key, "."), fmt.Sprintf("invalid key %q", key)))
 		}
 	}
-	sort.Strings(invalidKeys)
 	if len(invalidKeys) > 0 {
-		return nil, fmt.Errorf("invalid configuration:\n%s", strings.Join(invalidKeys, "\n"))
+		return fmt.Errorf("invalid configuration:\n%s", strings.Join(invalidKeys, "\n"))
 	}
 
 	// Validate and dump configuration if requested
@@ -155,22 +286,28 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 	if err := helpers.Validate.Struct(config); err != nil {
 		switch verr := err.(type) {
 		case validator.ValidationErrors:
-			return nil, fmt.Errorf("invalid configuration:\n%w", verr)
+			errs := make([]string, 0, len(verr))
+			for _, fe := range verr {
+				// Skip the name of the root structure
+				_, namespace, _ := strings.Cut(fe.Namespace(), ".")
+				errs = append(errs, locator.annotate(strings.Split(namespace, "."), fe.Error()))
+			}
+			return fmt.Errorf("invalid configuration:\n%s", strings.Join(errs, "\n"))
 		default:
-			return nil, fmt.Errorf("unexpected internal error: %w", verr)
+			return fmt.Errorf("unexpected internal error: %w", verr)
 		}
 	}
 	if c.Dump {
 		output, err := yaml.Marshal(config)
 		if err != nil {
-			return nil, fmt.Errorf("unable to dump configuration: %w", err)
+			return fmt.Errorf("unable to dump configuration: %w", err)
 		}
 		out.Write([]byte("---\n"))
 		out.Write(output)
 		out.Write([]byte("\n"))
 	}
 
-	return paths, nil
+	return nil
 }
 
 // DefaultHook will reset the destination value to its default using
@@ -243,3 +380,66 @@ func ZeroSliceHook() (mapstructure.DecodeHookFunc, func()) {
 	}
 	return hook, disable
 }
+
+// configLocator finds the position in the original files of a configuration
+// key.
+type configLocator map[string]yaml.Position
+
+// newConfigLocator creates a new locator from the positions of the raw
+// configuration. Paths are normalized to match the way mapstructure matches
+// keys with structure fields.
+func newConfigLocator(positions yaml.Positions) configLocator {
+	locator := make(configLocator, len(positions))
+	for path, position := range positions {
+		locator[normalizeConfigPath(strings.Split(path, "."))] = position
+	}
+	return locator
+}
+
+// normalizeConfigPath normalizes the provided path segments.
+func normalizeConfigPath(segments []string) string {
+	normalized := make([]string, len(segments))
+	for i, segment := range segments {
+		segment = strings.ToLower(segment)
+		segment = strings.ReplaceAll(segment, "-", "")
+		segment = strings.ReplaceAll(segment, "_", "")
+		normalized[i] = segment
+	}
+	return strings.Join(normalized, ".")
+}
+
+// locate returns the position of the deepest node matching the provided path.
+// Segments without a match are skipped as they may be squashed structures or
+// wrappers for parametrized configurations.
+func (l configLocator) locate(segments []string) (yaml.Position, bool) {
+	var (
+		position yaml.Position
+		found    bool
+		current  []string
+	)
+	for _, segment := range segments {
+		candidates := []string{segment}
+		if name, _, ok := strings.Cut(segment, "["); ok {
+			// A single value may have been used instead of a list
+			candidates = append(candidates, name)
+		}
+		for _, candidate := range candidates {
+			if p, ok := l[normalizeConfigPath(append(slices.Clone(current), candidate))]; ok {
+				current = append(current, candidate)
+				position = p
+				found = true
+				break
+			}
+		}
+	}
+	return position, found
+}
+
+// annotate prefixes the provided message with the position of the provided
+// path, if known.
+func (l configLocator) annotate(segments []string, message string) string {
+	if position, ok := l.locate(segments); ok {
+		return fmt.Sprintf("at line %d of %s: %s", position.Line, position.File, message)
+	}
+	return message
+}
@@ -6,12 +6,15 @@ package cmd_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -20,7 +23,9 @@ import (
 	"akvorado/common/helpers/yaml"
 
 	"akvorado/cmd"
+	"akvorado/common/daemon"
 	"akvorado/common/helpers"
+	"akvorado/common/reporter"
 )
 
 type dummyConfiguration struct {
@@ -95,12 +100,47 @@ module1:
 	if _, err := c.Parse(out, "dummy", &parsed); err == nil {
 		t.Fatal("Parse() didn't error")
 	} else if diff := helpers.Diff(err.Error(), `invalid configuration:
-Key: 'dummyConfiguration.Module1.Topic' Error:Field validation for 'Topic' failed on the 'gte' tag
-Key: 'dummyConfiguration.Module1.Workers' Error:Field validation for 'Workers' failed on the 'gte' tag`); diff != "" {
+at line 3 of config.yaml: Key: 'dummyConfiguration.Module1.Topic' Error:Field validation for 'Topic' failed on the 'gte' tag
+at line 4 of config.yaml: Key: 'dummyConfiguration.Module1.Workers' Error:Field validation for 'Workers' failed on the 'gte' tag`); diff != "" {
 		t.Fatalf("Parse() (-got, +want):\n%s", diff)
 	}
 }
 
+func TestValidationWithInclude(t *testing.T) {
+	config := `---
+module1: !include module1.yaml
+module2:
+ details:
+  workers: ${DUMMY_WORKERS}
+`
+	module1 := `---
+topic: ${DUMMY_TOPIC:-flows}
+workers: -5
+`
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o644)
+	os.WriteFile(filepath.Join(dir, "module1.yaml"), []byte(module1), 0o644)
+	t.Setenv("DUMMY_WORKERS", "12")
+
+	c := cmd.ConfigRelatedOptions{
+		Path: filepath.Join(dir, "config.yaml"),
+	}
+	parsed := dummyConfiguration{}
+	out := bytes.NewBuffer([]byte{})
+	if _, err := c.Parse(out, "dummy", &parsed); err == nil {
+		t.Fatal("Parse() didn't error")
+	} else if diff := helpers.Diff(err.Error(), `invalid configuration:
+at line 3 of module1.yaml: Key: 'dummyConfiguration.Module1.Workers' Error:Field validation for 'Workers' failed on the 'gte' tag`); diff != "" {
+		t.Fatalf("Parse() (-got, +want):\n%s", diff)
+	}
+	if parsed.Module1.Topic != "flows" {
+		t.Errorf("Parse() topic: got %q, expected %q", parsed.Module1.Topic, "flows")
+	}
+	if parsed.Module2.Details.Workers != 12 {
+		t.Errorf("Parse() workers: got %d, expected %d", parsed.Module2.Details.Workers, 12)
+	}
+}
+
 func TestDump(t *testing.T) {
 	// Configuration file
 	config := `---
@@ -309,6 +349,68 @@ module2:
 	}
 }
 
+func TestHTTPConfigurationRefused(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"message": "Configuration uses features not supported by this version: something."}`)
+	}))
+	defer ts.Close()
+
+	c := cmd.ConfigRelatedOptions{
+		Path: ts.URL,
+	}
+	parsed := dummyConfiguration{}
+	_, err := c.Parse(io.Discard, "outlet", &parsed)
+	if diff := helpers.Diff(err.Error(),
+		"configuration refused by orchestrator: Configuration uses features not supported by this version: something."); diff != "" {
+		t.Errorf("Parse() error (-got, +want):\n%s", diff)
+	}
+	if got.Get("X-Akvorado-Version") == "" {
+		t.Error("Parse() did not advertise version")
+	}
+	if features := strings.Split(got.Get("X-Akvorado-Features"), ","); !slices.Contains(features, "clickhouse-aggregation") {
+		t.Errorf("Parse() advertised features %v", features)
+	}
+}
+
+func TestWatchURL(t *testing.T) {
+	var content atomic.Value
+	content.Store("module1:\n topic: flows\n")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		fmt.Fprint(w, content.Load().(string))
+	}))
+	defer ts.Close()
+
+	c := cmd.ConfigRelatedOptions{
+		Path:           ts.URL,
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	modified := c.WatchURL(r, "dummy", daemonComponent)
+
+	// No change
+	time.Sleep(50 * time.Millisecond)
+	if modified.Load() {
+		t.Fatal("WatchURL() detected a change that should not be")
+	}
+
+	// Change
+	content.Store("module1:\n topic: flows2\n")
+	select {
+	case <-daemonComponent.Terminated():
+	case <-time.After(time.Second):
+		t.Fatal("WatchURL() did not terminate the daemon")
+	}
+	if !modified.Load() {
+		t.Fatal("WatchURL() did not register a change")
+	}
+}
+
 func TestUnused(t *testing.T) {
 	t.Run("ignored fields", func(t *testing.T) {
 		config := `---
@@ -348,8 +450,8 @@ module1:
 		if _, err := c.Parse(out, "dummy", &parsed); err == nil {
 			t.Fatal("Parse() didn't error")
 		} else if diff := helpers.Diff(err.Error(), `invalid configuration:
-invalid key "Module1.extra"
-invalid key "unused"`); diff != "" {
+at line 4 of config.yaml: invalid key "Module1.extra"
+at line 2 of config.yaml: invalid key "unused"`); diff != "" {
 			t.Fatalf("Parse() (-got, +want):\n%s", diff)
 		}
 	})
@@ -55,7 +55,7 @@ containers started with the label "akvorado.conntrack.fix=1".`,
 			httpComponent,
 			conntrackFixerComponent,
 		}
-		return StartStopComponents(r, daemonComponent, components)
+		return StartStopComponents(r, daemonComponent, components, defaultShutdownTimeout)
 	},
 }
 
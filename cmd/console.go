@@ -4,7 +4,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -58,6 +60,7 @@ var consoleCmd = &cobra.Command{
 manage collected flows.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+	restart:
 		config := ConsoleConfiguration{}
 		ConsoleOptions.Path = args[0]
 		if _, err := ConsoleOptions.Parse(cmd.OutOrStdout(), "console", &config); err != nil {
@@ -68,7 +71,11 @@ manage collected flows.`,
 		if err != nil {
 			return fmt.Errorf("unable to initialize reporter: %w", err)
 		}
-		return consoleStart(r, config, ConsoleOptions.CheckMode)
+		err = consoleStart(r, config, ConsoleOptions.CheckMode)
+		if errors.Is(err, errConfigurationModified) {
+			goto restart
+		}
+		return err
 	},
 }
 
@@ -78,6 +85,8 @@ func init() {
 		"Dump configuration before starting")
 	consoleCmd.Flags().BoolVarP(&ConsoleOptions.CheckMode, "check", "C", false,
 		"Check configuration, but does not start")
+	consoleCmd.Flags().DurationVarP(&ConsoleOptions.ReloadInterval, "reload-interval", "", time.Minute,
+		"Interval to check for configuration changes when fetched from the orchestrator (0 to disable)")
 }
 
 func consoleStart(r *reporter.Reporter, config ConsoleConfiguration, checkOnly bool) error {
@@ -91,23 +100,58 @@ func consoleStart(r *reporter.Reporter, config ConsoleConfiguration, checkOnly b
 	if err != nil {
 		return fmt.Errorf("unable to initialize HTTP component: %w", err)
 	}
-	clickhouseComponent, err := clickhousedb.New(r, config.ClickHouse, clickhousedb.Dependencies{
+	components, err := consoleComponents(r, config, daemonComponent, httpComponent)
+	if err != nil {
+		return err
+	}
+
+	// Expose some information and metrics
+	addCommonHTTPHandlers(r, "console", httpComponent)
+	moreMetrics(r)
+	reloader := newConfigReloader(r, config,
+		parseConfiguration[ConsoleConfiguration](ConsoleOptions.ConfigRelatedOptions, "console"),
+		logLevelsReloader(func(c ConsoleConfiguration) reporter.Configuration { return c.Reporting }))
+	addReloadHTTPHandlers("console", httpComponent, reloader.HTTPHandler)
+
+	// If we only asked for a check, stop here.
+	if checkOnly {
+		return nil
+	}
+
+	// Start all the components.
+	components = append([]any{httpComponent}, components...)
+	modified := ConsoleOptions.WatchURL(r, "console", daemonComponent)
+	reloader.Watch(daemonComponent)
+	if err := StartStopComponents(r, daemonComponent, components, defaultShutdownTimeout); err != nil {
+		return err
+	}
+	if modified.Load() {
+		return errConfigurationModified
+	}
+	return nil
+}
+
+// consoleComponents initializes the components of the console service using
+// the provided HTTP server. It returns the components to start, in order,
+// except the HTTP server.
+func consoleComponents(r *reporter.Reporter, config ConsoleConfiguration, daemonComponent daemon.Component, httpComponent *httpserver.Component) ([]any, error) {
+	clickhouseComponent, err := clickhousedb.New(r, config.ClickHouse.ReadOnly(), clickhousedb.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
+		return nil, fmt.Errorf("unable to initialize ClickHouse component: %w", err)
 	}
 	authenticationComponent, err := authentication.New(r, config.Auth)
 	if err != nil {
-		return fmt.Errorf("unable to initialize authentication component: %w", err)
+		return nil, fmt.Errorf("unable to initialize authentication component: %w", err)
 	}
 	databaseComponent, err := database.New(r, config.Database)
 	if err != nil {
-		return fmt.Errorf("unable to initialize database component: %w", err)
+		return nil, fmt.Errorf("unable to initialize database component: %w", err)
 	}
 	schemaComponent, err := schema.New(config.Schema)
 	if err != nil {
-		return fmt.Errorf("unable to initialize schema component: %w", err)
+		return nil, fmt.Errorf("unable to initialize schema component: %w", err)
 	}
 	consoleComponent, err := console.New(r, config.Console, console.Dependencies{
 		Daemon:       daemonComponent,
@@ -118,25 +162,13 @@ func consoleStart(r *reporter.Reporter, config ConsoleConfiguration, checkOnly b
 		Schema:       schemaComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize console component: %w", err)
-	}
-
-	// Expose some information and metrics
-	addCommonHTTPHandlers(r, "console", httpComponent)
-	moreMetrics(r)
-
-	// If we only asked for a check, stop here.
-	if checkOnly {
-		return nil
+		return nil, fmt.Errorf("unable to initialize console component: %w", err)
 	}
 
-	// Start all the components.
-	components := []any{
-		httpComponent,
+	return []any{
 		clickhouseComponent,
 		authenticationComponent,
 		databaseComponent,
 		consoleComponent,
-	}
-	return StartStopComponents(r, daemonComponent, components)
+	}, nil
 }
@@ -131,5 +131,5 @@ func demoExporterStart(r *reporter.Reporter, config DemoExporterConfiguration, c
 		flowsComponent,
 		demoExporterComponent,
 	}
-	return StartStopComponents(r, daemonComponent, components)
+	return StartStopComponents(r, daemonComponent, components, defaultShutdownTimeout)
 }
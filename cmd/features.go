@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"strings"
+
+	"akvorado/orchestrator"
+)
+
+// serviceFeatures lists the configuration features of each service which are
+// not understood by older versions. When fetching its configuration from the
+// orchestrator, a service advertises the features it supports. The
+// orchestrator removes the keys of the other features or refuses to serve the
+// configuration if they are enabled.
+var serviceFeatures = map[orchestrator.ServiceType][]orchestrator.Feature{
+	orchestrator.OutletService: {
+		{Name: "clickhouse-aggregation", Key: "clickhouse.aggregation"},
+		{Name: "clickhouse-sharding", Key: "clickhouse.shardingkey"},
+		{Name: "bmp-peer-groups", Key: "routing.provider.peergroups"},
+		{Name: "reexport", Key: "reexport"},
+		{Name: "watchdog", Key: "watchdog"},
+	},
+}
+
+// serviceDefaultConfigurations returns the default configuration of each
+// service. They tell if a feature is enabled.
+func serviceDefaultConfigurations() map[orchestrator.ServiceType]any {
+	inlet := InletConfiguration{}
+	inlet.Reset()
+	outlet := OutletConfiguration{}
+	outlet.Reset()
+	console := ConsoleConfiguration{}
+	console.Reset()
+	demoExporter := DemoExporterConfiguration{}
+	demoExporter.Reset()
+	return map[orchestrator.ServiceType]any{
+		orchestrator.InletService:        inlet,
+		orchestrator.OutletService:       outlet,
+		orchestrator.ConsoleService:      console,
+		orchestrator.DemoExporterService: demoExporter,
+	}
+}
+
+// featuresHeader returns the value of the header advertising the features
+// supported by a service.
+func featuresHeader(component string) string {
+	names := []string{}
+	for _, feature := range serviceFeatures[orchestrator.ServiceType(component)] {
+		names = append(names, feature.Name)
+	}
+	return strings.Join(names, ",")
+}
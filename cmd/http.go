@@ -6,6 +6,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/gin-gonic/gin"
+
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
 )
@@ -14,10 +16,33 @@ import (
 // services. Each endpoint is registered under `/api/v0` and
 // `/api/v0/SERVICE` namespaces.
 func addCommonHTTPHandlers(r *reporter.Reporter, service string, httpComponent *httpserver.Component) {
-	httpComponent.AddHandler(fmt.Sprintf("/api/v0/%s/metrics", service), r.MetricsHTTPHandler())
+	addServiceHTTPHandlers(r, service, httpComponent)
 	httpComponent.AddHandler("/api/v0/metrics", r.MetricsHTTPHandler())
-	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/healthcheck", service), r.HealthcheckHTTPHandler)
 	httpComponent.GinRouter.GET("/api/v0/healthcheck", r.HealthcheckHTTPHandler)
-	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/version", service), versionHandler)
+	httpComponent.GinRouter.GET("/api/v0/healthcheck/live", r.LivenessHTTPHandler)
+	httpComponent.GinRouter.GET("/api/v0/healthcheck/ready", r.ReadinessHTTPHandler)
 	httpComponent.GinRouter.GET("/api/v0/version", versionHandler)
+	httpComponent.GinRouter.GET("/api/v0/diagnostics", httpComponent.AdminOnly, r.DiagnosticsHTTPHandler)
+}
+
+// addServiceHTTPHandlers configures the endpoints common to all services under
+// the `/api/v0/SERVICE` namespace only. This is used when several services
+// share the same HTTP server.
+func addServiceHTTPHandlers(r *reporter.Reporter, service string, httpComponent *httpserver.Component) {
+	httpComponent.AddHandler(fmt.Sprintf("/api/v0/%s/metrics", service), r.MetricsHTTPHandler())
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/healthcheck", service), r.HealthcheckHTTPHandler)
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/healthcheck/live", service), r.LivenessHTTPHandler)
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/healthcheck/ready", service), r.ReadinessHTTPHandler)
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/version", service), versionHandler)
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/log-levels", service), r.LogLevelsHTTPHandler)
+	httpComponent.GinRouter.POST(fmt.Sprintf("/api/v0/%s/log-levels", service), httpComponent.AdminOnly, r.SetLogLevelHTTPHandler)
+	httpComponent.GinRouter.GET(fmt.Sprintf("/api/v0/%s/diagnostics", service), httpComponent.AdminOnly, r.DiagnosticsHTTPHandler)
+}
+
+// addReloadHTTPHandlers configures the endpoint to reload the configuration of
+// a service. It is registered under `/api/v0` and `/api/v0/SERVICE`
+// namespaces.
+func addReloadHTTPHandlers(service string, httpComponent *httpserver.Component, handler gin.HandlerFunc) {
+	httpComponent.GinRouter.POST("/api/v0/reload", httpComponent.AdminOnly, handler)
+	httpComponent.GinRouter.POST(fmt.Sprintf("/api/v0/%s/reload", service), httpComponent.AdminOnly, handler)
 }
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
+)
+
+func TestLogLevelsHTTPHandlers(t *testing.T) {
+	base, _ := logger.Levels()
+	t.Cleanup(func() {
+		logger.ResetLevel("inlet/flow")
+		logger.SetDefaultLevel(base)
+	})
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	addCommonHTTPHandlers(r, "inlet", h)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "set level without token",
+			URL:         "/api/v0/inlet/log-levels",
+			JSONInput:   gin.H{"module": "inlet/flow", "level": "debug"},
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "get levels without token",
+			URL:         "/api/v0/inlet/log-levels",
+			JSONOutput:  gin.H{"default": base.String(), "modules": gin.H{}},
+		}, {
+			Description: "set level with token",
+			URL:         "/api/v0/inlet/log-levels",
+			Header:      httpserver.MockAdminHeader(),
+			JSONInput:   gin.H{"module": "inlet/flow", "level": "debug"},
+			JSONOutput:  gin.H{"default": base.String(), "modules": gin.H{"inlet/flow": "debug"}},
+		},
+	})
+}
@@ -4,7 +4,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -21,6 +23,9 @@ type InletConfiguration struct {
 	HTTP      httpserver.Configuration
 	Flow      flow.Configuration
 	Kafka     kafka.Configuration
+	// ShutdownTimeout is the time allowed to flush buffers and stop all
+	// components.
+	ShutdownTimeout time.Duration `validate:"min=1s"`
 }
 
 // Reset resets the configuration for the inlet command to its default value.
@@ -30,6 +35,8 @@ func (c *InletConfiguration) Reset() {
 		Reporting: reporter.DefaultConfiguration(),
 		Flow:      flow.DefaultConfiguration(),
 		Kafka:     kafka.DefaultConfiguration(),
+
+		ShutdownTimeout: defaultShutdownTimeout,
 	}
 }
 
@@ -49,6 +56,7 @@ var inletCmd = &cobra.Command{
 and export to Kafka.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+	restart:
 		config := InletConfiguration{}
 		InletOptions.Path = args[0]
 		if _, err := InletOptions.Parse(cmd.OutOrStdout(), "inlet", &config); err != nil {
@@ -59,7 +67,11 @@ and export to Kafka.`,
 		if err != nil {
 			return fmt.Errorf("unable to initialize reporter: %w", err)
 		}
-		return inletStart(r, config, InletOptions.CheckMode)
+		err = inletStart(r, config, InletOptions.CheckMode)
+		if errors.Is(err, errConfigurationModified) {
+			goto restart
+		}
+		return err
 	},
 }
 
@@ -69,6 +81,8 @@ func init() {
 		"Dump configuration before starting")
 	inletCmd.Flags().BoolVarP(&InletOptions.CheckMode, "check", "C", false,
 		"Check configuration, but does not start")
+	inletCmd.Flags().DurationVarP(&InletOptions.ReloadInterval, "reload-interval", "", time.Minute,
+		"Interval to check for configuration changes when fetched from the orchestrator (0 to disable)")
 }
 
 func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool) error {
@@ -89,18 +103,18 @@ func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool)
 	if err != nil {
 		return fmt.Errorf("unable to initialize Kafka component: %w", err)
 	}
-	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
-		Daemon: daemonComponent,
-		HTTP:   httpComponent,
-		Kafka:  kafkaComponent,
-	})
+	components, err := inletComponents(r, config, daemonComponent, httpComponent, kafkaComponent)
 	if err != nil {
-		return fmt.Errorf("unable to initialize flow component: %w", err)
+		return err
 	}
 
 	// Expose some information and metrics
 	addCommonHTTPHandlers(r, "inlet", httpComponent)
 	moreMetrics(r)
+	reloader := newConfigReloader(r, config,
+		parseConfiguration[InletConfiguration](InletOptions.ConfigRelatedOptions, "inlet"),
+		logLevelsReloader(func(c InletConfiguration) reporter.Configuration { return c.Reporting }))
+	addReloadHTTPHandlers("inlet", httpComponent, reloader.HTTPHandler)
 
 	// If we only asked for a check, stop here.
 	if checkOnly {
@@ -108,10 +122,30 @@ func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool)
 	}
 
 	// Start all the components.
-	components := []any{
-		httpComponent,
-		kafkaComponent,
-		flowComponent,
+	components = append([]any{httpComponent}, components...)
+	modified := InletOptions.WatchURL(r, "inlet", daemonComponent)
+	reloader.Watch(daemonComponent)
+	if err := StartStopComponents(r, daemonComponent, components, config.ShutdownTimeout); err != nil {
+		return err
+	}
+	if modified.Load() {
+		return errConfigurationModified
+	}
+	return nil
+}
+
+// inletComponents initializes the components of the inlet service using the
+// provided HTTP server and flow sender. It returns the components to start, in
+// order, except the HTTP server.
+func inletComponents(r *reporter.Reporter, config InletConfiguration, daemonComponent daemon.Component, httpComponent *httpserver.Component, sender kafka.Sender) ([]any, error) {
+	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
+		Daemon: daemonComponent,
+		HTTP:   httpComponent,
+		Kafka:  sender,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize flow component: %w", err)
 	}
-	return StartStopComponents(r, daemonComponent, components)
+
+	return []any{sender, flowComponent}, nil
 }
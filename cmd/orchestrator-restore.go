@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/reporter"
+	"akvorado/orchestrator/clickhouse"
+)
+
+var orchestratorRestoreCmd = &cobra.Command{
+	Use:   "restore CONFIG BACKUP",
+	Short: "Restore a ClickHouse table from a backup",
+	Long: `Restore a ClickHouse table from a backup made by the orchestrator before a
+destructive migration step. The existing table is replaced by the content of the
+backup. The orchestrator should be stopped while restoring a backup.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := OrchestratorConfiguration{}
+		options := ConfigRelatedOptions{Path: args[0]}
+		if _, err := options.Parse(cmd.OutOrStdout(), "orchestrator", &config); err != nil {
+			return err
+		}
+		r, err := reporter.New(config.Reporting)
+		if err != nil {
+			return fmt.Errorf("unable to initialize reporter: %w", err)
+		}
+		daemonComponent, err := daemon.New(r)
+		if err != nil {
+			return fmt.Errorf("unable to initialize daemon component: %w", err)
+		}
+		clickhouseDBComponent, err := clickhousedb.New(r, config.ClickHouseDB, clickhousedb.Dependencies{
+			Daemon: daemonComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
+		}
+		defer clickhouseDBComponent.Close()
+
+		if err := clickhouse.RestoreBackup(context.Background(),
+			clickhouseDBComponent, config.ClickHouse.Backup, args[1]); err != nil {
+			return err
+		}
+		cmd.Printf("backup %s restored\n", args[1])
+		return nil
+	},
+}
+
+func init() {
+	orchestratorCmd.AddCommand(orchestratorRestoreCmd)
+}
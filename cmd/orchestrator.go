@@ -92,29 +92,8 @@ components and centralizes configuration of the various other components.`,
 	restart:
 		config := OrchestratorConfiguration{}
 		OrchestratorOptions.Path = args[0]
-		OrchestratorOptions.BeforeDump = func(metadata mapstructure.Metadata) {
-			// Override some parts of the configuration
-			for idx := range config.Inlet {
-				if !slices.Contains(metadata.Keys, fmt.Sprintf("Inlet[%d].Kafka.Brokers[0]", idx)) {
-					config.Inlet[idx].Kafka.Configuration = config.Kafka.Configuration
-				}
-			}
-			for idx := range config.Outlet {
-				if !slices.Contains(metadata.Keys, fmt.Sprintf("Outlet[%d].ClickHouse.Servers[0]", idx)) {
-					config.Outlet[idx].ClickHouseDB = config.ClickHouseDB
-				}
-				if !slices.Contains(metadata.Keys, fmt.Sprintf("Outlet[%d].Kafka.Brokers[0]", idx)) {
-					config.Outlet[idx].Kafka.Configuration = config.Kafka.Configuration
-				}
-				config.Outlet[idx].Schema = config.Schema
-			}
-			for idx := range config.Console {
-				if !slices.Contains(metadata.Keys, fmt.Sprintf("Console[%d].ClickHouse.Servers[0]", idx)) {
-					config.Console[idx].ClickHouse = config.ClickHouseDB
-				}
-				config.Console[idx].Schema = config.Schema
-			}
-		}
+		OrchestratorOptions.BeforeDump = orchestratorBeforeDump(&config)
+
 		// Parse and check the configuration a first time to start monitoring
 		// file changes if automatic restart is enabled.
 		paths, err := OrchestratorOptions.Parse(cmd.OutOrStdout(), "orchestrator", &config)
@@ -169,25 +148,49 @@ func orchestratorStart(r *reporter.Reporter, config OrchestratorConfiguration, d
 	if err != nil {
 		return fmt.Errorf("unable to initialize HTTP component: %w", err)
 	}
-	schemaComponent, err := schema.New(config.Schema)
+	components, err := orchestratorComponents(r, config, daemonComponent, httpComponent, true)
 	if err != nil {
-		return fmt.Errorf("unable to initialize schema component: %w", err)
+		return err
+	}
+
+	// Expose some information and metrics
+	addCommonHTTPHandlers(r, "orchestrator", httpComponent)
+	moreMetrics(r)
+	reloader := newConfigReloader(r, config,
+		orchestratorParse(OrchestratorOptions.ConfigRelatedOptions),
+		orchestratorReloaders(components)...)
+	addReloadHTTPHandlers("orchestrator", httpComponent, reloader.HTTPHandler)
+
+	// If we only asked for a check, stop here.
+	if checkOnly {
+		return nil
 	}
-	kafkaComponent, err := kafka.New(r, config.Kafka, kafka.Dependencies{Schema: schemaComponent})
+
+	// Start all the components.
+	reloader.Watch(daemonComponent)
+	return StartStopComponents(r, daemonComponent, components, defaultShutdownTimeout)
+}
+
+// orchestratorComponents initializes the components of the orchestrator
+// service using the provided HTTP server. The Kafka component, managing the
+// topic, is only included when requested. It returns the components to start,
+// in order, including the HTTP server.
+func orchestratorComponents(r *reporter.Reporter, config OrchestratorConfiguration, daemonComponent daemon.Component, httpComponent *httpserver.Component, withKafka bool) ([]any, error) {
+	schemaComponent, err := schema.New(config.Schema)
 	if err != nil {
-		return fmt.Errorf("unable to initialize kafka component: %w", err)
+		return nil, fmt.Errorf("unable to initialize schema component: %w", err)
 	}
 	clickhouseDBComponent, err := clickhousedb.New(r, config.ClickHouseDB, clickhousedb.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
+		return nil, fmt.Errorf("unable to initialize ClickHouse component: %w", err)
 	}
 	geoipComponent, err := geoip.New(r, config.GeoIP, geoip.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize GeoIP component: %w", err)
+		return nil, fmt.Errorf("unable to initialize GeoIP component: %w", err)
 	}
 	clickhouseComponent, err := clickhouse.New(r, config.ClickHouse, clickhouse.Dependencies{
 		Daemon:     daemonComponent,
@@ -197,45 +200,135 @@ func orchestratorStart(r *reporter.Reporter, config OrchestratorConfiguration, d
 		GeoIP:      geoipComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize clickhouse component: %w", err)
+		return nil, fmt.Errorf("unable to initialize clickhouse component: %w", err)
 	}
 	orchestratorComponent, err := orchestrator.New(r, config.Orchestrator, orchestrator.Dependencies{
 		HTTP: httpComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize orchestrator component: %w", err)
+		return nil, fmt.Errorf("unable to initialize orchestrator component: %w", err)
+	}
+	for service, configurations := range orchestratorServiceConfigurations(config) {
+		for _, configuration := range configurations {
+			orchestratorComponent.RegisterConfiguration(service, configuration)
+		}
+	}
+	orchestratorComponent.RegisterValidator(orchestratorValidateConfiguration)
+	defaultConfigurations := serviceDefaultConfigurations()
+	for service, features := range serviceFeatures {
+		orchestratorComponent.RegisterFeatures(service, defaultConfigurations[service], features)
+	}
+
+	components := []any{
+		orchestratorComponent,
+		geoipComponent,
+		httpComponent,
+		clickhouseDBComponent,
+		clickhouseComponent,
+	}
+	if withKafka {
+		kafkaComponent, err := kafka.New(r, config.Kafka, kafka.Dependencies{Schema: schemaComponent})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize kafka component: %w", err)
+		}
+		components = append(components, kafkaComponent)
 	}
+	return components, nil
+}
+
+// orchestratorBeforeDump returns a function to override some parts of the
+// configuration of the other services from the orchestrator configuration.
+func orchestratorBeforeDump(config *OrchestratorConfiguration) func(mapstructure.Metadata) {
+	return func(metadata mapstructure.Metadata) {
+		// Override some parts of the configuration
+		for idx := range config.Inlet {
+			if !slices.Contains(metadata.Keys, fmt.Sprintf("Inlet[%d].Kafka.Brokers[0]", idx)) {
+				config.Inlet[idx].Kafka.Configuration = config.Kafka.Configuration
+			}
+		}
+		for idx := range config.Outlet {
+			if !slices.Contains(metadata.Keys, fmt.Sprintf("Outlet[%d].ClickHouse.Servers[0]", idx)) {
+				config.Outlet[idx].ClickHouseDB = config.ClickHouseDB
+			}
+			if !slices.Contains(metadata.Keys, fmt.Sprintf("Outlet[%d].Kafka.Brokers[0]", idx)) {
+				config.Outlet[idx].Kafka.Configuration = config.Kafka.Configuration
+			}
+			config.Outlet[idx].Schema = config.Schema
+		}
+		for idx := range config.Console {
+			if !slices.Contains(metadata.Keys, fmt.Sprintf("Console[%d].ClickHouse.Servers[0]", idx)) {
+				config.Console[idx].ClickHouse = config.ClickHouseDB
+			}
+			config.Console[idx].Schema = config.Schema
+		}
+	}
+}
+
+// orchestratorParse returns a function to parse again the configuration of the
+// orchestrator using the provided options.
+func orchestratorParse(options ConfigRelatedOptions) func() (OrchestratorConfiguration, error) {
+	options.Dump = false
+	return func() (OrchestratorConfiguration, error) {
+		config := OrchestratorConfiguration{}
+		options := options
+		options.BeforeDump = orchestratorBeforeDump(&config)
+		_, err := options.Parse(io.Discard, "orchestrator", &config)
+		return config, err
+	}
+}
+
+// orchestratorReloaders returns the hot reloaders for the orchestrator: log
+// levels and configurations served to the other services.
+func orchestratorReloaders(components []any) []hotReloader[OrchestratorConfiguration] {
+	reloaders := []hotReloader[OrchestratorConfiguration]{
+		logLevelsReloader(func(c OrchestratorConfiguration) reporter.Configuration { return c.Reporting }),
+	}
+	for _, component := range components {
+		orchestratorComponent, ok := component.(*orchestrator.Component)
+		if !ok {
+			continue
+		}
+		reloaders = append(reloaders, hotReloader[OrchestratorConfiguration]{
+			Keys: []string{"inlet", "outlet", "console", "demoexporter"},
+			Apply: func(config OrchestratorConfiguration) error {
+				_, err := orchestratorComponent.ReplaceConfigurations(
+					orchestratorServiceConfigurations(config), "reload")
+				return err
+			},
+		})
+	}
+	return reloaders
+}
+
+// orchestratorServiceConfigurations returns the configurations to serve to each
+// service.
+func orchestratorServiceConfigurations(config OrchestratorConfiguration) map[orchestrator.ServiceType][]any {
+	configurations := map[orchestrator.ServiceType][]any{}
 	for idx := range config.Inlet {
-		orchestratorComponent.RegisterConfiguration(orchestrator.InletService, config.Inlet[idx])
+		configurations[orchestrator.InletService] = append(configurations[orchestrator.InletService], config.Inlet[idx])
 	}
 	for idx := range config.Outlet {
-		orchestratorComponent.RegisterConfiguration(orchestrator.OutletService, config.Outlet[idx])
+		configurations[orchestrator.OutletService] = append(configurations[orchestrator.OutletService], config.Outlet[idx])
 	}
 	for idx := range config.Console {
-		orchestratorComponent.RegisterConfiguration(orchestrator.ConsoleService, config.Console[idx])
+		configurations[orchestrator.ConsoleService] = append(configurations[orchestrator.ConsoleService], config.Console[idx])
 	}
 	for idx := range config.DemoExporter {
-		orchestratorComponent.RegisterConfiguration(orchestrator.DemoExporterService, config.DemoExporter[idx])
-	}
-
-	// Expose some information and metrics
-	addCommonHTTPHandlers(r, "orchestrator", httpComponent)
-	moreMetrics(r)
-
-	// If we only asked for a check, stop here.
-	if checkOnly {
-		return nil
+		configurations[orchestrator.DemoExporterService] = append(configurations[orchestrator.DemoExporterService], config.DemoExporter[idx])
 	}
+	return configurations
+}
 
-	// Start all the components.
-	components := []any{
-		geoipComponent,
-		httpComponent,
-		clickhouseDBComponent,
-		clickhouseComponent,
-		kafkaComponent,
+// orchestratorValidateConfiguration parses and validates a proposed
+// configuration for the orchestrator. It returns the configurations that would
+// be served to each service.
+func orchestratorValidateConfiguration(input []byte) (map[orchestrator.ServiceType][]any, error) {
+	config := OrchestratorConfiguration{}
+	options := ConfigRelatedOptions{BeforeDump: orchestratorBeforeDump(&config)}
+	if err := options.ParseBytes(io.Discard, "orchestrator", input, &config); err != nil {
+		return nil, err
 	}
-	return StartStopComponents(r, daemonComponent, components)
+	return orchestratorServiceConfigurations(config), nil
 }
 
 // orchestratorWatch will listen to changes to the given path and trigger a
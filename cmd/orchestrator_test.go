@@ -19,6 +19,7 @@ import (
 	"akvorado/common/helpers"
 	"akvorado/common/helpers/yaml"
 	"akvorado/common/reporter"
+	"akvorado/orchestrator"
 )
 
 func TestOrchestratorStart(t *testing.T) {
@@ -30,6 +31,47 @@ func TestOrchestratorStart(t *testing.T) {
 	}
 }
 
+func TestOrchestratorValidateConfiguration(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		configurations, err := orchestratorValidateConfiguration([]byte(`
+kafka:
+  topic: flows-test
+inlet:
+  - {}
+  - {}
+console:
+  - http:
+      listen: :8081
+`))
+		if err != nil {
+			t.Fatalf("orchestratorValidateConfiguration() error:\n%+v", err)
+		}
+		if len(configurations[orchestrator.InletService]) != 2 {
+			t.Fatalf("orchestratorValidateConfiguration() got %d inlet configurations",
+				len(configurations[orchestrator.InletService]))
+		}
+		inlet := configurations[orchestrator.InletService][1].(InletConfiguration)
+		if diff := helpers.Diff(inlet.Kafka.Topic, "flows-test"); diff != "" {
+			t.Fatalf("orchestratorValidateConfiguration() inlet topic (-got, +want):\n%s", diff)
+		}
+		console := configurations[orchestrator.ConsoleService][0].(ConsoleConfiguration)
+		if diff := helpers.Diff(console.HTTP.Listen, ":8081"); diff != "" {
+			t.Fatalf("orchestratorValidateConfiguration() console listen (-got, +want):\n%s", diff)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{
+			"inlet:\n  - flows: 767643\n",
+			"inlet: !include inlet.yaml\n",
+			"inlet: [",
+		} {
+			if _, err := orchestratorValidateConfiguration([]byte(input)); err == nil {
+				t.Errorf("orchestratorValidateConfiguration(%q) did not error", input)
+			}
+		}
+	})
+}
+
 func TestOrchestratorConfig(t *testing.T) {
 	tests, err := os.ReadDir("testdata/configurations")
 	if err != nil {
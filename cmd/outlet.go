@@ -4,8 +4,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-viper/mapstructure/v2"
@@ -23,8 +25,10 @@ import (
 	"akvorado/outlet/kafka"
 	"akvorado/outlet/metadata"
 	"akvorado/outlet/metadata/provider/snmp"
+	"akvorado/outlet/reexport"
 	"akvorado/outlet/routing"
 	"akvorado/outlet/routing/provider/bmp"
+	"akvorado/outlet/watchdog"
 )
 
 // OutletConfiguration represents the configuration file for the outlet command.
@@ -39,6 +43,11 @@ type OutletConfiguration struct {
 	Flow         flow.Configuration
 	Core         core.Configuration
 	Schema       schema.Configuration
+	Reexport     reexport.Configuration
+	Watchdog     watchdog.Configuration
+	// ShutdownTimeout is the time allowed to flush workers and stop all
+	// components.
+	ShutdownTimeout time.Duration `validate:"min=1s"`
 }
 
 // Reset resets the configuration for the outlet command to its default value.
@@ -54,6 +63,10 @@ func (c *OutletConfiguration) Reset() {
 		Flow:         flow.DefaultConfiguration(),
 		Core:         core.DefaultConfiguration(),
 		Schema:       schema.DefaultConfiguration(),
+		Reexport:     reexport.DefaultConfiguration(),
+		Watchdog:     watchdog.DefaultConfiguration(),
+
+		ShutdownTimeout: defaultShutdownTimeout,
 	}
 	c.Metadata.Providers = []metadata.ProviderConfiguration{{Config: snmp.DefaultConfiguration()}}
 	c.Routing.Provider.Config = bmp.DefaultConfiguration()
@@ -75,6 +88,7 @@ var outletCmd = &cobra.Command{
 enrichment and export to Kafka.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+	restart:
 		config := OutletConfiguration{}
 		OutletOptions.Path = args[0]
 		if _, err := OutletOptions.Parse(cmd.OutOrStdout(), "outlet", &config); err != nil {
@@ -85,7 +99,11 @@ enrichment and export to Kafka.`,
 		if err != nil {
 			return fmt.Errorf("unable to initialize reporter: %w", err)
 		}
-		return outletStart(r, config, OutletOptions.CheckMode)
+		err = outletStart(r, config, OutletOptions.CheckMode)
+		if errors.Is(err, errConfigurationModified) {
+			goto restart
+		}
+		return err
 	},
 }
 
@@ -95,6 +113,8 @@ func init() {
 		"Dump configuration before starting")
 	outletCmd.Flags().BoolVarP(&OutletOptions.CheckMode, "check", "C", false,
 		"Check configuration, but does not start")
+	outletCmd.Flags().DurationVarP(&OutletOptions.ReloadInterval, "reload-interval", "", time.Minute,
+		"Interval to check for configuration changes when fetched from the orchestrator (0 to disable)")
 }
 
 func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly bool) error {
@@ -109,46 +129,93 @@ func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly boo
 	if err != nil {
 		return fmt.Errorf("unable to initialize http component: %w", err)
 	}
-	schemaComponent, err := schema.New(config.Schema)
+	kafkaComponent, err := kafka.New(r, config.Kafka, kafka.Dependencies{
+		Daemon: daemonComponent,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to initialize Kafka component: %w", err)
+	}
+	components, err := outletComponents(r, config, daemonComponent, httpComponent, kafkaComponent, nil)
 	if err != nil {
-		return fmt.Errorf("unable to initialize schema component: %w", err)
+		return err
 	}
-	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
-		Schema: schemaComponent,
-	})
+
+	// Expose some information and metrics
+	addCommonHTTPHandlers(r, "outlet", httpComponent)
+	moreMetrics(r)
+	reloader := newConfigReloader(r, config,
+		parseConfiguration[OutletConfiguration](OutletOptions.ConfigRelatedOptions, "outlet"),
+		logLevelsReloader(func(c OutletConfiguration) reporter.Configuration { return c.Reporting }))
+	addReloadHTTPHandlers("outlet", httpComponent, reloader.HTTPHandler)
+
+	// If we only asked for a check, stop here.
+	if checkOnly {
+		return nil
+	}
+
+	// Start all the components.
+	components = append([]any{httpComponent}, components...)
+	modified := OutletOptions.WatchURL(r, "outlet", daemonComponent)
+	reloader.Watch(daemonComponent)
+	if err := StartStopComponents(r, daemonComponent, components, config.ShutdownTimeout); err != nil {
+		return err
+	}
+	if modified.Load() {
+		return errConfigurationModified
+	}
+	return nil
+}
+
+// outletComponents initializes the components of the outlet service using the
+// provided HTTP server and Kafka consumer. When not nil, wrapClickHouse is
+// applied to the ClickHouse exporter before handing it to the core component.
+// It returns the components to start, in order, except the HTTP server.
+func outletComponents(r *reporter.Reporter, config OutletConfiguration, daemonComponent daemon.Component, httpComponent *httpserver.Component, kafkaComponent kafka.Component, wrapClickHouse func(clickhouse.Component) clickhouse.Component) ([]any, error) {
+	schemaComponent, err := schema.New(config.Schema)
 	if err != nil {
-		return fmt.Errorf("unable to initialize flow component: %w", err)
+		return nil, fmt.Errorf("unable to initialize schema component: %w", err)
 	}
 	metadataComponent, err := metadata.New(r, config.Metadata, metadata.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize metadata component: %w", err)
+		return nil, fmt.Errorf("unable to initialize metadata component: %w", err)
 	}
-	routingComponent, err := routing.New(r, config.Routing, routing.Dependencies{
-		Daemon: daemonComponent,
+	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
+		Schema:     schemaComponent,
+		Interfaces: metadataComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize routing component: %w", err)
+		return nil, fmt.Errorf("unable to initialize flow component: %w", err)
 	}
-	kafkaComponent, err := kafka.New(r, config.Kafka, kafka.Dependencies{
+	routingComponent, err := routing.New(r, config.Routing, routing.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize Kafka component: %w", err)
+		return nil, fmt.Errorf("unable to initialize routing component: %w", err)
 	}
 	clickhouseDBComponent, err := clickhousedb.New(r, config.ClickHouseDB, clickhousedb.Dependencies{
 		Daemon: daemonComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
+		return nil, fmt.Errorf("unable to initialize ClickHouse component: %w", err)
 	}
 	clickhouseComponent, err := clickhouse.New(r, config.ClickHouse, clickhouse.Dependencies{
 		ClickHouse: clickhouseDBComponent,
 		Schema:     schemaComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize outlet ClickHouse component: %w", err)
+		return nil, fmt.Errorf("unable to initialize outlet ClickHouse component: %w", err)
+	}
+	if wrapClickHouse != nil {
+		clickhouseComponent = wrapClickHouse(clickhouseComponent)
+	}
+	reexportComponent, err := reexport.New(r, config.Reexport, reexport.Dependencies{
+		Daemon: daemonComponent,
+		Schema: schemaComponent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize re-export component: %w", err)
 	}
 	coreComponent, err := core.New(r, config.Core, core.Dependencies{
 		Daemon:     daemonComponent,
@@ -159,32 +226,32 @@ func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly boo
 		ClickHouse: clickhouseComponent,
 		HTTP:       httpComponent,
 		Schema:     schemaComponent,
+		Reexport:   reexportComponent,
 	})
 	if err != nil {
-		return fmt.Errorf("unable to initialize core component: %w", err)
+		return nil, fmt.Errorf("unable to initialize core component: %w", err)
 	}
-
-	// Expose some information and metrics
-	addCommonHTTPHandlers(r, "outlet", httpComponent)
-	moreMetrics(r)
-
-	// If we only asked for a check, stop here.
-	if checkOnly {
-		return nil
+	watchdogComponent, err := watchdog.New(r, config.Watchdog, watchdog.Dependencies{
+		Daemon:     daemonComponent,
+		ClickHouse: clickhouseComponent,
+		Reexport:   reexportComponent,
+		Metadata:   metadataComponent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize watchdog component: %w", err)
 	}
 
-	// Start all the components.
-	components := []any{
-		httpComponent,
+	return []any{
 		clickhouseDBComponent,
 		clickhouseComponent,
 		flowComponent,
 		metadataComponent,
 		routingComponent,
+		reexportComponent,
 		kafkaComponent,
 		coreComponent,
-	}
-	return StartStopComponents(r, daemonComponent, components)
+		watchdogComponent,
+	}, nil
 }
 
 // OutletConfigurationUnmarshallerHook renames SNMP configuration to metadata and
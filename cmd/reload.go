@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
+	"akvorado/orchestrator"
+)
+
+// ReloadResult is the outcome of a configuration reload.
+type ReloadResult struct {
+	// Applied lists the modified keys applied without a restart.
+	Applied []string `json:"applied"`
+	// Restart lists the modified keys needing a restart to be applied.
+	Restart []string `json:"restart"`
+}
+
+// hotReloader applies the changes made to a part of the configuration of a
+// service without restarting it.
+type hotReloader[T any] struct {
+	// Keys are the paths of the parts of the configuration handled by the
+	// reloader, as in a configuration dump (for example,
+	// "reporting.logging.levels").
+	Keys []string
+	// Apply applies the provided configuration.
+	Apply func(T) error
+}
+
+// handles tells if the reloader handles the provided path.
+func (h hotReloader[T]) handles(path string) bool {
+	return slices.ContainsFunc(h.Keys, func(key string) bool {
+		return path == key || strings.HasPrefix(path, key+".")
+	})
+}
+
+// logLevelsReloader returns a reloader for the log levels.
+func logLevelsReloader[T any](reporting func(T) reporter.Configuration) hotReloader[T] {
+	return hotReloader[T]{
+		Keys: []string{"reporting.logging.levels"},
+		Apply: func(config T) error {
+			logger.SetLevels(reporting(config).Logging.Levels)
+			return nil
+		},
+	}
+}
+
+// configReloader reloads the configuration of a service on request. Changes
+// handled by one of the hot reloaders are applied, the other ones are only
+// reported.
+type configReloader[T any] struct {
+	r         *reporter.Reporter
+	parse     func() (T, error)
+	reloaders []hotReloader[T]
+
+	lock    sync.Mutex
+	started T // configuration at startup
+	current T // configuration at the last reload
+}
+
+// newConfigReloader creates a new configuration reloader. The provided
+// configuration is the one used to start the service and parse should return
+// the configuration as currently found in the configuration file.
+func newConfigReloader[T any](r *reporter.Reporter, config T, parse func() (T, error), reloaders ...hotReloader[T]) *configReloader[T] {
+	return &configReloader[T]{
+		r:         r,
+		parse:     parse,
+		reloaders: reloaders,
+		started:   config,
+		current:   config,
+	}
+}
+
+// parseConfiguration returns a function to parse again the configuration of a
+// service using the provided options.
+func parseConfiguration[T any](options ConfigRelatedOptions, component string) func() (T, error) {
+	options.Dump = false
+	return func() (T, error) {
+		var config T
+		_, err := options.Parse(io.Discard, component, &config)
+		return config, err
+	}
+}
+
+// Reload parses the configuration again, applies the changes that can be
+// applied without a restart and reports the other ones. Changes needing a
+// restart are reported until the service is restarted.
+func (c *configReloader[T]) Reload() (ReloadResult, error) {
+	result := ReloadResult{Applied: []string{}, Restart: []string{}}
+	config, err := c.parse()
+	if err != nil {
+		return result, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	changes, err := orchestrator.DiffConfigurations(c.current, config)
+	if err != nil {
+		return result, err
+	}
+	sinceStart, err := orchestrator.DiffConfigurations(c.started, config)
+	if err != nil {
+		return result, err
+	}
+	hot := func(path string) bool {
+		return slices.ContainsFunc(c.reloaders, func(reloader hotReloader[T]) bool {
+			return reloader.handles(path)
+		})
+	}
+	for _, reloader := range c.reloaders {
+		if !slices.ContainsFunc(changes, func(change orchestrator.ConfigurationChange) bool {
+			return reloader.handles(change.Path)
+		}) {
+			continue
+		}
+		if err := reloader.Apply(config); err != nil {
+			return result, fmt.Errorf("cannot apply %s: %w", strings.Join(reloader.Keys, ", "), err)
+		}
+	}
+	for _, change := range changes {
+		if hot(change.Path) {
+			result.Applied = append(result.Applied, change.Path)
+		}
+	}
+	for _, change := range sinceStart {
+		if !hot(change.Path) {
+			result.Restart = append(result.Restart, change.Path)
+		}
+	}
+	c.current = config
+	return result, nil
+}
+
+// reload reloads the configuration and logs the outcome.
+func (c *configReloader[T]) reload() (ReloadResult, error) {
+	result, err := c.Reload()
+	if err != nil {
+		c.r.Err(err).Msg("cannot reload configuration")
+		return result, err
+	}
+	if len(result.Applied) == 0 && len(result.Restart) == 0 {
+		c.r.Info().Msg("configuration reloaded, no change")
+	}
+	if len(result.Applied) > 0 {
+		c.r.Info().Strs("keys", result.Applied).Msg("configuration changes applied")
+	}
+	if len(result.Restart) > 0 {
+		c.r.Warn().Strs("keys", result.Restart).Msg("configuration changes need a restart")
+	}
+	return result, nil
+}
+
+// Watch reloads the configuration each time a reload is requested to the
+// daemon component (on SIGHUP), until it terminates.
+func (c *configReloader[T]) Watch(daemonComponent daemon.Component) {
+	go func() {
+		for {
+			select {
+			case <-daemonComponent.Terminated():
+				return
+			case <-daemonComponent.ReloadRequested():
+				c.reload()
+			}
+		}
+	}()
+}
+
+// HTTPHandler reloads the configuration and returns the outcome as JSON.
+func (c *configReloader[T]) HTTPHandler(gc *gin.Context) {
+	result, err := c.reload()
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	gc.JSON(http.StatusOK, result)
+}
+
+type reloadOptions struct {
+	URL   string
+	Token string
+}
+
+// ReloadOptions stores the command-line option values for the reload command.
+var ReloadOptions reloadOptions
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload configuration",
+	Long: `Ask a running service to reload its configuration using the builtin HTTP
+endpoint, like on SIGHUP. Changes that cannot be applied without a restart are
+reported. This requires the admin token of the service.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost,
+			fmt.Sprintf("%s/api/v0/reload", strings.TrimSuffix(ReloadOptions.URL, "/")), nil)
+		if err != nil {
+			return err
+		}
+		if ReloadOptions.Token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ReloadOptions.Token))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			var body struct {
+				Message string `json:"message"`
+			}
+			json.NewDecoder(resp.Body).Decode(&body)
+			return fmt.Errorf("cannot reload configuration (status %d): %s", resp.StatusCode, body.Message)
+		}
+		var result ReloadResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("cannot decode answer: %w", err)
+		}
+		if len(result.Applied) == 0 && len(result.Restart) == 0 {
+			cmd.Println("no change")
+		}
+		for _, key := range result.Applied {
+			cmd.Printf("applied: %s\n", key)
+		}
+		for _, key := range result.Restart {
+			cmd.Printf("restart needed: %s\n", key)
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(reloadCmd)
+	reloadCmd.Flags().StringVarP(&ReloadOptions.URL, "url", "", "http://localhost:8080",
+		"URL of the service to reload")
+	reloadCmd.Flags().StringVarP(&ReloadOptions.Token, "token", "", "",
+		"Admin token of the service")
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
+	"akvorado/orchestrator"
+)
+
+func TestConfigReloader(t *testing.T) {
+	t.Cleanup(func() { logger.SetLevels(nil) })
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error:\n%+v", err)
+		}
+	}
+	write(`---
+kafka:
+  topic: flows
+`)
+	options := ConfigRelatedOptions{Path: configFile}
+	parse := parseConfiguration[InletConfiguration](options, "inlet")
+	config, err := parse()
+	if err != nil {
+		t.Fatalf("parse() error:\n%+v", err)
+	}
+	r := reporter.NewMock(t)
+	reloader := newConfigReloader(r, config, parse,
+		logLevelsReloader(func(c InletConfiguration) reporter.Configuration { return c.Reporting }))
+
+	// No change
+	got, err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, ReloadResult{Applied: []string{}, Restart: []string{}}); diff != "" {
+		t.Fatalf("Reload() (-got, +want):\n%s", diff)
+	}
+
+	// Hot-reloadable and other changes
+	write(`---
+reporting:
+  logging:
+    levels:
+      inlet/flow: warn
+kafka:
+  topic: flows2
+`)
+	got, err = reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, ReloadResult{
+		Applied: []string{"reporting.logging.levels.inlet/flow"},
+		Restart: []string{"kafka.topic"},
+	}); diff != "" {
+		t.Fatalf("Reload() (-got, +want):\n%s", diff)
+	}
+	if _, levels := logger.Levels(); levels["inlet/flow"] != zerolog.WarnLevel {
+		t.Fatalf("Reload() did not apply log levels: %v", levels)
+	}
+
+	// Changes needing a restart are still reported
+	got, err = reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, ReloadResult{Applied: []string{}, Restart: []string{"kafka.topic"}}); diff != "" {
+		t.Fatalf("Reload() (-got, +want):\n%s", diff)
+	}
+
+	// Invalid configuration
+	write(`---
+kafka:
+  unknown: 1
+`)
+	if _, err := reloader.Reload(); err == nil {
+		t.Fatal("Reload() did not error")
+	}
+}
+
+func TestOrchestratorReloaders(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error:\n%+v", err)
+		}
+	}
+	write(`---
+inlet:
+  - kafka:
+      brokers: [kafka:9092]
+      topic: flows
+`)
+	parse := orchestratorParse(ConfigRelatedOptions{Path: configFile})
+	config, err := parse()
+	if err != nil {
+		t.Fatalf("parse() error:\n%+v", err)
+	}
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	orchestratorComponent, err := orchestrator.New(r, orchestrator.DefaultConfiguration(),
+		orchestrator.Dependencies{HTTP: h})
+	if err != nil {
+		t.Fatalf("orchestrator.New() error:\n%+v", err)
+	}
+	for service, configurations := range orchestratorServiceConfigurations(config) {
+		for _, configuration := range configurations {
+			orchestratorComponent.RegisterConfiguration(service, configuration)
+		}
+	}
+	helpers.StartStop(t, orchestratorComponent)
+	reloader := newConfigReloader(r, config, parse,
+		orchestratorReloaders([]any{orchestratorComponent})...)
+
+	write(`---
+inlet:
+  - kafka:
+      brokers: [kafka:9092]
+      topic: flows2
+`)
+	got, err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, ReloadResult{
+		Applied: []string{"inlet.0.kafka.topic"},
+		Restart: []string{},
+	}); diff != "" {
+		t.Fatalf("Reload() (-got, +want):\n%s", diff)
+	}
+	resp, err := http.Get("http://" + h.LocalAddr().String() + "/api/v0/orchestrator/configuration/inlet")
+	if err != nil {
+		t.Fatalf("GET error:\n%+v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if diff := helpers.Diff(resp.Header.Get("X-Akvorado-Configuration-Version"), "2"); diff != "" {
+		t.Fatalf("GET version header (-got, +want):\n%s", diff)
+	}
+	if !bytes.Contains(body, []byte("topic: flows2")) {
+		t.Fatalf("GET did not return the new configuration:\n%s", body)
+	}
+}
+
+func TestReloadCommand(t *testing.T) {
+	var authorization string
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v0/reload", func(gc *gin.Context) {
+		authorization = gc.GetHeader("Authorization")
+		gc.JSON(http.StatusOK, ReloadResult{
+			Applied: []string{"reporting.logging.levels.inlet"},
+			Restart: []string{"kafka.topic"},
+		})
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	root := RootCmd
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetArgs([]string{"reload", "--url", ts.URL, "--token", "secret"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("`reload` error:\n%+v", err)
+	}
+	if diff := helpers.Diff(buf.String(),
+		"applied: reporting.logging.levels.inlet\nrestart needed: kafka.topic\n"); diff != "" {
+		t.Errorf("`reload` (-got, +want):\n%s", diff)
+	}
+	if diff := helpers.Diff(authorization, "Bearer secret"); diff != "" {
+		t.Errorf("`reload` authorization (-got, +want):\n%s", diff)
+	}
+}
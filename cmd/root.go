@@ -12,6 +12,7 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
 
 	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
@@ -39,9 +40,9 @@ var RootCmd = &cobra.Command{
 			})
 			log.Logger = zerolog.New(w).With().Timestamp().Logger()
 		}
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		logger.SetDefaultLevel(zerolog.InfoLevel)
 		if debug || helpers.Testing() {
-			zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			logger.SetDefaultLevel(zerolog.DebugLevel)
 		}
 	},
 	SilenceErrors: true,
@@ -31,6 +31,17 @@ type Configuration struct {
 	DialTimeout time.Duration `validate:"min=100ms"`
 	// TLS defines TLS connection parameters, if empty, plain TCP will be used.
 	TLS helpers.TLSConfiguration
+	// Proxy is the URL of a proxy to use to connect to ClickHouse
+	// (http://, https://, or socks5://). If empty, no proxy is used.
+	Proxy string `validate:"omitempty,url"`
+	// ReadServers define the list of ClickHouse servers to use for read-only
+	// queries, like the ones from the console. When empty, Servers is used.
+	ReadServers []string `validate:"dive,listen"`
+	// ReadUsername defines the username to use for read-only queries. When
+	// empty, Username and Password are used.
+	ReadUsername string
+	// ReadPassword defines the password to use for read-only queries.
+	ReadPassword string
 }
 
 // DefaultConfiguration represents the default configuration for connecting to ClickHouse
@@ -44,6 +55,22 @@ func DefaultConfiguration() Configuration {
 	}
 }
 
+// ReadOnly returns the configuration to use for read-only queries. Read
+// servers and credentials replace the regular ones when they are set.
+func (config Configuration) ReadOnly() Configuration {
+	if len(config.ReadServers) > 0 {
+		config.Servers = config.ReadServers
+	}
+	if config.ReadUsername != "" {
+		config.Username = config.ReadUsername
+		config.Password = config.ReadPassword
+	}
+	config.ReadServers = nil
+	config.ReadUsername = ""
+	config.ReadPassword = ""
+	return config
+}
+
 // ClusterName returns the cluster we operate on.
 func (c *Component) ClusterName() string {
 	return c.config.Cluster
@@ -58,7 +85,7 @@ func (c *Component) DatabaseName() string {
 // available servers.
 func (c *Component) ChGoOptions() (ch.Options, []string) {
 	tlsConfig, _ := c.config.TLS.MakeTLSConfig()
-	return ch.Options{
+	options := ch.Options{
 		Address:     c.config.Servers[0],
 		Database:    c.config.Database,
 		User:        c.config.Username,
@@ -67,5 +94,11 @@ func (c *Component) ChGoOptions() (ch.Options, []string) {
 		ClientName:  "akvorado",
 		DialTimeout: c.config.DialTimeout,
 		TLS:         tlsConfig,
-	}, c.config.Servers
+	}
+	if c.dialer != nil {
+		// TLS is handled by the dialer
+		options.Dialer = c.dialer
+		options.TLS = nil
+	}
+	return options, c.config.Servers
 }
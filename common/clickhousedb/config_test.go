@@ -14,3 +14,60 @@ func TestDefaultConfiguration(t *testing.T) {
 		t.Fatalf("validate.Struct() error:\n%+v", err)
 	}
 }
+
+func TestReadOnlyConfiguration(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Servers = []string{"clickhouse:9000"}
+	config.Username = "akvorado"
+	config.Password = "secret"
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Read     func(*Configuration)
+		Expected func(*Configuration)
+	}{
+		{
+			Pos:      helpers.Mark(),
+			Read:     func(*Configuration) {},
+			Expected: func(*Configuration) {},
+		}, {
+			Pos: helpers.Mark(),
+			Read: func(c *Configuration) {
+				c.ReadServers = []string{"replica1:9000", "replica2:9000"}
+			},
+			Expected: func(c *Configuration) {
+				c.Servers = []string{"replica1:9000", "replica2:9000"}
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Read: func(c *Configuration) {
+				c.ReadUsername = "reader"
+			},
+			Expected: func(c *Configuration) {
+				c.Username = "reader"
+				c.Password = ""
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Read: func(c *Configuration) {
+				c.ReadServers = []string{"replica1:9000"}
+				c.ReadUsername = "reader"
+				c.ReadPassword = "other"
+			},
+			Expected: func(c *Configuration) {
+				c.Servers = []string{"replica1:9000"}
+				c.Username = "reader"
+				c.Password = "other"
+			},
+		},
+	}
+	for _, tc := range cases {
+		input := config
+		tc.Read(&input)
+		expected := config
+		tc.Expected(&expected)
+		if diff := helpers.Diff(input.ReadOnly(), expected); diff != "" {
+			t.Errorf("%sReadOnly() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhousedb
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// dialer connects to ClickHouse servers through a proxy. When TLS is
+// configured, the TLS session is established with the server, through the
+// proxy.
+type dialer struct {
+	timeout time.Duration
+	proxy   *url.URL
+	tls     *tls.Config
+}
+
+// newDialer creates a new dialer from the provided configuration. It returns
+// nil when no proxy is configured.
+func newDialer(config Configuration, tlsConfig *tls.Config) (*dialer, error) {
+	if config.Proxy == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(config.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+	return &dialer{
+		timeout: config.DialTimeout,
+		proxy:   proxyURL,
+		tls:     tlsConfig,
+	}, nil
+}
+
+// DialContext connects to the provided address through the proxy.
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	conn, err := d.dialProxy(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if d.tls == nil {
+		return conn, nil
+	}
+	tlsConfig := d.tls
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dialProxy opens a connection to the provided address through the proxy.
+func (d *dialer) dialProxy(ctx context.Context, network, address string) (net.Conn, error) {
+	netDialer := &net.Dialer{}
+	switch d.proxy.Scheme {
+	case "socks5", "socks5h":
+		proxyDialer, err := proxy.FromURL(d.proxy, netDialer)
+		if err != nil {
+			return nil, fmt.Errorf("cannot use SOCKS proxy: %w", err)
+		}
+		conn, err := proxyDialer.(proxy.ContextDialer).DialContext(ctx, network, address)
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect through SOCKS proxy: %w", err)
+		}
+		return conn, nil
+	}
+
+	// HTTP proxy with the CONNECT method
+	proxyAddress := d.proxy.Host
+	if d.proxy.Port() == "" {
+		port := "80"
+		if d.proxy.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddress = net.JoinHostPort(d.proxy.Hostname(), port)
+	}
+	conn, err := netDialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to HTTP proxy: %w", err)
+	}
+	if d.proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot connect to HTTP proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := d.proxy.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot send request to HTTP proxy: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot read answer from HTTP proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy refused connection: %s", resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		conn.Close()
+		return nil, errors.New("unexpected data from HTTP proxy")
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhousedb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialerHTTPProxy(t *testing.T) {
+	// Server echoing what it receives
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	defer server.Close()
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	// HTTP proxy with authentication
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNzd29yZA==" {
+			http.Error(w, "authentication required", http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+	proxyAddress := strings.TrimPrefix(proxy.URL, "http://")
+
+	cases := []struct {
+		Proxy string
+		Error string
+	}{
+		{
+			Proxy: fmt.Sprintf("http://user:password@%s", proxyAddress),
+		}, {
+			Proxy: fmt.Sprintf("http://user:wrong@%s", proxyAddress),
+			Error: "HTTP proxy refused connection: 407 Proxy Authentication Required",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Proxy, func(t *testing.T) {
+			config := DefaultConfiguration()
+			config.Proxy = tc.Proxy
+			d, err := newDialer(config, nil)
+			if err != nil {
+				t.Fatalf("newDialer() error:\n%+v", err)
+			}
+			conn, err := d.DialContext(context.Background(), "tcp", server.Addr().String())
+			if tc.Error != "" {
+				if err == nil || err.Error() != tc.Error {
+					t.Fatalf("DialContext() error == %v but expected %q", err, tc.Error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DialContext() error:\n%+v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(time.Second))
+			if _, err := conn.Write([]byte("hello")); err != nil {
+				t.Fatalf("Write() error:\n%+v", err)
+			}
+			got := make([]byte, 5)
+			if _, err := io.ReadFull(conn, got); err != nil {
+				t.Fatalf("Read() error:\n%+v", err)
+			}
+			if string(got) != "hello" {
+				t.Fatalf("Read() == %q but expected %q", got, "hello")
+			}
+		})
+	}
+}
+
+func TestNewDialer(t *testing.T) {
+	config := DefaultConfiguration()
+	if d, err := newDialer(config, nil); err != nil || d != nil {
+		t.Fatalf("newDialer() == %v, %v but expected nil, nil", d, err)
+	}
+	config.Proxy = "ftp://127.0.0.1:21"
+	if _, err := newDialer(config, nil); err == nil {
+		t.Fatal("newDialer() did not error")
+	}
+	config.Proxy = "socks5://127.0.0.1:1080"
+	if _, err := newDialer(config, nil); err != nil {
+		t.Fatalf("newDialer() error:\n%+v", err)
+	}
+}
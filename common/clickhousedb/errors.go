@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhousedb
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ErrorClass tells how an error returned by ClickHouse should be handled.
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is for errors which are not classified, like network
+	// errors or unlisted exceptions. They should be retried.
+	ErrorClassUnknown ErrorClass = iota
+	// ErrorClassRetryable is for exceptions which are expected to go away,
+	// like timeouts or too many parts.
+	ErrorClassRetryable
+	// ErrorClassFatal is for exceptions which are not expected to go away
+	// without an intervention, like a schema mismatch or an authentication
+	// failure.
+	ErrorClassFatal
+)
+
+// String returns the name of an error class.
+func (ec ErrorClass) String() string {
+	switch ec {
+	case ErrorClassRetryable:
+		return "retryable"
+	case ErrorClassFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// fatalErrors are the exception codes which should not be retried.
+var fatalErrors = []proto.Error{
+	// Schema mismatch
+	proto.ErrIncorrectNumberOfColumns,
+	proto.ErrThereIsNoColumn,
+	proto.ErrDuplicateColumn,
+	proto.ErrNoSuchColumnInTable,
+	proto.ErrNumberOfColumnsDoesntMatch,
+	proto.ErrIllegalTypeOfArgument,
+	proto.ErrIllegalColumn,
+	proto.ErrUnknownIdentifier,
+	proto.ErrTypeMismatch,
+	proto.ErrSyntaxError,
+	proto.ErrUnknownDatabase,
+	proto.ErrUnknownSetting,
+	proto.ErrCannotInsertNullInOrdinaryColumn,
+	// Authentication and authorization
+	proto.ErrReadonly,
+	proto.ErrUnknownUser,
+	proto.ErrWrongPassword,
+	proto.ErrRequiredPassword,
+	proto.ErrDatabaseAccessDenied,
+	proto.ErrReadonlySetting,
+	proto.ErrAccessDenied,
+	proto.ErrAuthenticationFailed,
+}
+
+// retryableErrors are the exception codes which are expected to go away.
+var retryableErrors = []proto.Error{
+	proto.ErrUnknownTable, // the database may not be migrated yet
+	proto.ErrTimeoutExceeded,
+	proto.ErrTooManySimultaneousQueries,
+	proto.ErrSocketTimeout,
+	proto.ErrNetworkError,
+	proto.ErrMemoryLimitExceeded,
+	proto.ErrTableIsReadOnly,
+	proto.ErrTooManyParts,
+	proto.ErrAllConnectionTriesFailed,
+	proto.ErrQueryWasCancelled,
+	proto.ErrTooManyPartitions,
+	proto.ErrServerOverloaded,
+	proto.ErrKeeperException,
+}
+
+// oversizedErrors are the exception codes telling a query was too large to be
+// handled. A smaller one may succeed.
+var oversizedErrors = []proto.Error{
+	proto.ErrMemoryLimitExceeded,
+	proto.ErrCannotAllocateMemory,
+	proto.ErrTooManyRows,
+	proto.ErrTooManyBytes,
+	proto.ErrTooManyRowsOrBytes,
+}
+
+// exceptionCode returns the exception code of an error returned by
+// ClickHouse, either through this component or directly with ch-go.
+func exceptionCode(err error) (proto.Error, bool) {
+	if exception, ok := ch.AsException(err); ok {
+		return exception.Code, true
+	} else if exception := (*clickhouse.Exception)(nil); errors.As(err, &exception) {
+		return proto.Error(exception.Code), true
+	}
+	return 0, false
+}
+
+// ClassifyError classifies an error returned by ClickHouse, either through
+// this component or directly with ch-go.
+func ClassifyError(err error) ErrorClass {
+	code, ok := exceptionCode(err)
+	if !ok {
+		return ErrorClassUnknown
+	}
+	switch {
+	case slices.Contains(fatalErrors, code):
+		return ErrorClassFatal
+	case slices.Contains(retryableErrors, code):
+		return ErrorClassRetryable
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// IsRetryable tells if an error returned by ClickHouse is worth retrying.
+func IsRetryable(err error) bool {
+	return ClassifyError(err) != ErrorClassFatal
+}
+
+// IsOversized tells if an error returned by ClickHouse is due to the size of
+// the query, like when exceeding the memory limit. Splitting an insert may
+// help.
+func IsOversized(err error) bool {
+	code, ok := exceptionCode(err)
+	return ok && slices.Contains(oversizedErrors, code)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhousedb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"akvorado/common/helpers"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		Pos       helpers.Pos
+		Error     error
+		Expected  ErrorClass
+		Retryable bool
+	}{
+		{
+			Pos:       helpers.Mark(),
+			Error:     errors.New("connection refused"),
+			Expected:  ErrorClassUnknown,
+			Retryable: true,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     &ch.Exception{Code: proto.ErrTooManyParts},
+			Expected:  ErrorClassRetryable,
+			Retryable: true,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     &ch.Exception{Code: proto.ErrTimeoutExceeded},
+			Expected:  ErrorClassRetryable,
+			Retryable: true,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     fmt.Errorf("cannot send: %w", &ch.Exception{Code: proto.ErrNoSuchColumnInTable}),
+			Expected:  ErrorClassFatal,
+			Retryable: false,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     &ch.Exception{Code: proto.ErrAuthenticationFailed},
+			Expected:  ErrorClassFatal,
+			Retryable: false,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     &ch.Exception{Code: proto.ErrBadArguments},
+			Expected:  ErrorClassUnknown,
+			Retryable: true,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     &clickhouse.Exception{Code: int32(proto.ErrAuthenticationFailed)},
+			Expected:  ErrorClassFatal,
+			Retryable: false,
+		}, {
+			Pos:       helpers.Mark(),
+			Error:     fmt.Errorf("query: %w", &clickhouse.Exception{Code: int32(proto.ErrTooManyParts)}),
+			Expected:  ErrorClassRetryable,
+			Retryable: true,
+		},
+	}
+	for _, tc := range cases {
+		if got := ClassifyError(tc.Error); got != tc.Expected {
+			t.Errorf("%sClassifyError(%v) == %s but expected %s", tc.Pos, tc.Error, got, tc.Expected)
+		}
+		if got := IsRetryable(tc.Error); got != tc.Retryable {
+			t.Errorf("%sIsRetryable(%v) == %v but expected %v", tc.Pos, tc.Error, got, tc.Retryable)
+		}
+	}
+}
+
+func TestIsOversized(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Error    error
+		Expected bool
+	}{
+		{helpers.Mark(), errors.New("connection refused"), false},
+		{helpers.Mark(), &ch.Exception{Code: proto.ErrTooManyParts}, false},
+		{helpers.Mark(), &ch.Exception{Code: proto.ErrMemoryLimitExceeded}, true},
+		{helpers.Mark(), fmt.Errorf("cannot send: %w", &ch.Exception{Code: proto.ErrTooManyRowsOrBytes}), true},
+		{helpers.Mark(), &clickhouse.Exception{Code: int32(proto.ErrMemoryLimitExceeded)}, true},
+	}
+	for _, tc := range cases {
+		if got := IsOversized(tc.Error); got != tc.Expected {
+			t.Errorf("%sIsOversized(%v) == %v but expected %v", tc.Pos, tc.Error, got, tc.Expected)
+		}
+	}
+}
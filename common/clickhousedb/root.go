@@ -6,6 +6,7 @@ package clickhousedb
 
 import (
 	"context"
+	"net"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -22,6 +23,7 @@ type Component struct {
 	t      tomb.Tomb
 	d      *Dependencies
 	config Configuration
+	dialer *dialer
 
 	healthy chan reporter.ChannelHealthcheckFunc
 	clickhouse.Conn
@@ -38,7 +40,11 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := clickhouse.Open(&clickhouse.Options{
+	dialer, err := newDialer(config, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	options := clickhouse.Options{
 		Addr:             config.Servers,
 		ConnOpenStrategy: clickhouse.ConnOpenRoundRobin,
 		Auth: clickhouse.Auth{
@@ -61,7 +67,15 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 				{Name: "akvorado", Version: helpers.AkvoradoVersion},
 			},
 		},
-	})
+	}
+	if dialer != nil {
+		// TLS is handled by the dialer
+		options.DialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+		options.TLS = nil
+	}
+	conn, err := clickhouse.Open(&options)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +84,7 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 		r:      r,
 		d:      &dependencies,
 		config: config,
+		dialer: dialer,
 
 		healthy: make(chan reporter.ChannelHealthcheckFunc),
 		Conn:    conn,
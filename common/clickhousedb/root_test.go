@@ -93,7 +93,7 @@ func TestMock(t *testing.T) {
 			Query(gomock.Any(), "SELECT 1").
 			Return(mockRows, nil)
 		got := r.RunHealthchecks(context.Background())
-		if diff := helpers.Diff(got.Details["clickhousedb"], reporter.HealthcheckResult{
+		if diff := helpers.Diff(got.Details["clickhousedb"].HealthcheckResult, reporter.HealthcheckResult{
 			Status: reporter.HealthcheckOK,
 			Reason: "database available",
 		}); diff != "" {
@@ -105,7 +105,7 @@ func TestMock(t *testing.T) {
 			Return(nil, errors.New("not available")).
 			After(firstCall)
 		got = r.RunHealthchecks(context.Background())
-		if diff := helpers.Diff(got.Details["clickhousedb"], reporter.HealthcheckResult{
+		if diff := helpers.Diff(got.Details["clickhousedb"].HealthcheckResult, reporter.HealthcheckResult{
 			Status: reporter.HealthcheckWarning,
 			Reason: "database unavailable",
 		}); diff != "" {
@@ -147,7 +147,7 @@ func TestRealClickHouse(t *testing.T) {
 	// Check healthcheck
 	t.Run("healthcheck", func(t *testing.T) {
 		got := r.RunHealthchecks(context.Background())
-		if diff := helpers.Diff(got.Details["clickhousedb"], reporter.HealthcheckResult{
+		if diff := helpers.Diff(got.Details["clickhousedb"].HealthcheckResult, reporter.HealthcheckResult{
 			Status: reporter.HealthcheckOK,
 			Reason: "database available",
 		}); diff != "" {
@@ -23,17 +23,22 @@ var (
 	spacesRegexp                   = regexp.MustCompile(`\s+`)
 	statementBeforeOnClusterRegexp = regexp.MustCompile(fmt.Sprintf("^((?i)%s)", strings.Join([]string{
 		`ALTER TABLE \S+`,
+		`(ALTER|CREATE) (QUOTA|ROLE|USER)( IF NOT EXISTS| OR REPLACE)? \S+`,
 		`ATTACH DICTIONARY \S+`,
 		`(ATTACH|CREATE) DATABASE( IF NOT EXISTS)? \S+`,
+		`BACKUP TABLE \S+`,
 		`(ATTACH|CREATE( OR REPLACE)?|REPLACE) DICTIONARY( IF NOT EXISTS)? \S+`,
 		`(ATTACH|CREATE) LIVE VIEW (IF NOT EXISTS)? \S+`,
 		`(ATTACH|CREATE) MATERIALIZED VIEW( IF NOT EXISTS)? \S+`,
 		`(ATTACH|CREATE( OR REPLACE)?|REPLACE)( TEMPORARY)? TABLE( IF NOT EXISTS)? \S+`,
 		`(DETACH|DROP) DATABASE( IF EXISTS)? \S+`,
 		`(DETACH|DROP) (DICTIONARY|(TEMPORARY )?TABLE|VIEW)( IF EXISTS?) \S+`,
+		`EXCHANGE (DICTIONARIES|TABLES) \S+ AND \S+`,
+		`GRANT`,
 		`KILL MUTATION`,
 		`OPTIMIZE TABLE \S+`,
 		`RENAME TABLE \S+ TO \S+`, // this is incomplete
+		`RESTORE TABLE \S+( AS \S+)?`,
 		`TRUNCATE( TEMPORARY)?( TABLE)?( IF EXISTS)? \S+`,
 		// not part of the grammar
 		`SYSTEM RELOAD DICTIONARIES`,
@@ -48,6 +53,7 @@ func TransformQueryOnCluster(query, cluster string) string {
 	//
 	// ALTER TABLE tableIdentifier clusterClause? alterTableClause (COMMA alterTableClause)*
 	// ATTACH DICTIONARY tableIdentifier clusterClause?
+	// BACKUP TABLE tableIdentifier clusterClause? TO backupDestination
 	// (ATTACH | CREATE) DATABASE (IF NOT EXISTS)? databaseIdentifier clusterClause? engineExpr?
 	// (ATTACH | CREATE (OR REPLACE)? | REPLACE) DICTIONARY (IF NOT EXISTS)? tableIdentifier uuidClause? clusterClause? dictionarySchemaClause dictionaryEngineClause
 	// (ATTACH | CREATE) LIVE VIEW (IF NOT EXISTS)? tableIdentifier uuidClause? clusterClause? (WITH TIMEOUT DECIMAL_LITERAL?)? destinationClause? tableSchemaClause? subqueryClause
@@ -56,10 +62,18 @@ func TransformQueryOnCluster(query, cluster string) string {
 	// (ATTACH | CREATE) (OR REPLACE)? VIEW (IF NOT EXISTS)? tableIdentifier uuidClause? clusterClause? tableSchemaClause? subqueryClause
 	// (DETACH | DROP) DATABASE (IF EXISTS)? databaseIdentifier clusterClause?
 	// (DETACH | DROP) (DICTIONARY | TEMPORARY? TABLE | VIEW) (IF EXISTS)? tableIdentifier clusterClause? (NO DELAY)?
+	// EXCHANGE (DICTIONARIES | TABLES) tableIdentifier AND tableIdentifier clusterClause?
 	// KILL MUTATION clusterClause? whereClause (SYNC | ASYNC | TEST)?
 	// OPTIMIZE TABLE tableIdentifier clusterClause? partitionClause? FINAL? DEDUPLICATE?;
 	// RENAME TABLE tableIdentifier TO tableIdentifier (COMMA tableIdentifier TO tableIdentifier)* clusterClause?;
+	// RESTORE TABLE tableIdentifier (AS tableIdentifier)? clusterClause? FROM backupDestination
 	// TRUNCATE TEMPORARY? TABLE? (IF EXISTS)? tableIdentifier clusterClause?;
+	//
+	// Access control statements are not part of the grammar. From the
+	// documentation:
+	//
+	// (ALTER | CREATE) (QUOTA | ROLE | USER) (IF NOT EXISTS | OR REPLACE)? name clusterClause? ...
+	// GRANT clusterClause? ...
 
 	// In ClickHouse, an identifier uses the following syntax:
 	//
@@ -71,6 +71,46 @@ FROM queue GROUP BY day, level
 `,
 			`CREATE MATERIALIZED VIEW consumer ON CLUSTER akvorado TO daily AS SELECT toDate(toDateTime(timestamp)) AS day, level, count() as total FROM queue GROUP BY day, level`,
 		},
+		{
+			helpers.Mark(),
+			"BACKUP TABLE default.flows_1m0s TO Disk('backups', 'flows_1m0s-20250101000000.zip')",
+			"BACKUP TABLE default.flows_1m0s ON CLUSTER akvorado TO Disk('backups', 'flows_1m0s-20250101000000.zip')",
+		},
+		{
+			helpers.Mark(),
+			"RESTORE TABLE default.flows_1m0s FROM Disk('backups', 'flows_1m0s-20250101000000.zip')",
+			"RESTORE TABLE default.flows_1m0s ON CLUSTER akvorado FROM Disk('backups', 'flows_1m0s-20250101000000.zip')",
+		},
+		{
+			helpers.Mark(),
+			"RESTORE TABLE default.flows_1m0s AS default.flows_1m0s_restore FROM Disk('backups', 'flows_1m0s-20250101000000.zip')",
+			"RESTORE TABLE default.flows_1m0s AS default.flows_1m0s_restore ON CLUSTER akvorado FROM Disk('backups', 'flows_1m0s-20250101000000.zip')",
+		},
+		{
+			helpers.Mark(),
+			"EXCHANGE TABLES default.flows_1m0s AND default.flows_1m0s_restore",
+			"EXCHANGE TABLES default.flows_1m0s AND default.flows_1m0s_restore ON CLUSTER akvorado",
+		},
+		{
+			helpers.Mark(),
+			"CREATE ROLE IF NOT EXISTS akvorado_reader",
+			"CREATE ROLE IF NOT EXISTS akvorado_reader ON CLUSTER akvorado",
+		},
+		{
+			helpers.Mark(),
+			"ALTER USER console DEFAULT ROLE akvorado_reader",
+			"ALTER USER console ON CLUSTER akvorado DEFAULT ROLE akvorado_reader",
+		},
+		{
+			helpers.Mark(),
+			"CREATE QUOTA OR REPLACE console FOR INTERVAL 3600 second MAX queries = 100 TO console",
+			"CREATE QUOTA OR REPLACE console ON CLUSTER akvorado FOR INTERVAL 3600 second MAX queries = 100 TO console",
+		},
+		{
+			helpers.Mark(),
+			"GRANT SELECT, dictGet ON default.* TO akvorado_reader WITH REPLACE OPTION",
+			"GRANT ON CLUSTER akvorado SELECT, dictGet ON default.* TO akvorado_reader WITH REPLACE OPTION",
+		},
 		// Not modified
 		{helpers.Mark(), "SELECT 1", "SELECT 1"},
 	}
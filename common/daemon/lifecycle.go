@@ -11,6 +11,7 @@ import (
 type lifecycleComponent struct {
 	terminateChannel chan struct{}
 	terminateOnce    sync.Once
+	reloadChannel    chan struct{}
 }
 
 // Terminated will return a channel that will be closed when the daemon
@@ -23,3 +24,18 @@ func (c *lifecycleComponent) Terminated() <-chan struct{} {
 func (c *lifecycleComponent) Terminate() {
 	c.terminateOnce.Do(func() { close(c.terminateChannel) })
 }
+
+// ReloadRequested will return a channel receiving a value each time a reload
+// of the configuration is requested.
+func (c *lifecycleComponent) ReloadRequested() <-chan struct{} {
+	return c.reloadChannel
+}
+
+// RequestReload should be called to request a reload of the configuration.
+// Requests are coalesced while the previous one has not been handled.
+func (c *lifecycleComponent) RequestReload() {
+	select {
+	case c.reloadChannel <- struct{}{}:
+	default:
+	}
+}
@@ -5,8 +5,11 @@ package daemon
 
 import (
 	"errors"
+	"os"
+	"syscall"
 	"testing"
 	"testing/synctest"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
@@ -116,3 +119,40 @@ func TestTombTracking(t *testing.T) {
 		c.Stop()
 	})
 }
+
+func TestReload(t *testing.T) {
+	r := reporter.NewMock(t)
+	c, err := New(r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	select {
+	case <-c.ReloadRequested():
+		t.Fatalf("ReloadRequested() received a value while we didn't request a reload")
+	default:
+		// OK
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Kill() error:\n%+v", err)
+	}
+	select {
+	case <-c.ReloadRequested():
+		// OK
+	case <-time.After(time.Second):
+		t.Fatalf("ReloadRequested() did not receive a value after SIGHUP")
+	}
+
+	// Requests are coalesced
+	c.RequestReload()
+	c.RequestReload()
+	<-c.ReloadRequested()
+	select {
+	case <-c.ReloadRequested():
+		t.Fatalf("ReloadRequested() received a second value")
+	default:
+		// OK
+	}
+}
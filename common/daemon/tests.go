@@ -23,6 +23,7 @@ func NewMock(t testing.TB) Component {
 	return &MockComponent{
 		lifecycleComponent: lifecycleComponent{
 			terminateChannel: make(chan struct{}),
+			reloadChannel:    make(chan struct{}, 1),
 		},
 	}
 }
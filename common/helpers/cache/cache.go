@@ -112,6 +112,29 @@ func (c *Cache[K, V]) DeleteLastAccessedBefore(before time.Time) int {
 	return count
 }
 
+// DeleteFunc deletes the items for which the provided function returns true.
+func (c *Cache[K, V]) DeleteFunc(del func(K, V) bool) int {
+	count := 0
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range c.items {
+		if del(k, v.Object) {
+			delete(c.items, k)
+			count++
+		}
+	}
+	return count
+}
+
+// Clear deletes all the items from the cache.
+func (c *Cache[K, V]) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := len(c.items)
+	clear(c.items)
+	return count
+}
+
 // Size returns the size of the cache
 func (c *Cache[K, V]) Size() int {
 	c.mu.RLock()
@@ -97,6 +97,41 @@ func TestDeleteLastAccessedBefore(t *testing.T) {
 	expectCacheGet(t, c, "127.0.0.3", "", false)
 }
 
+func TestDeleteFunc(t *testing.T) {
+	c := cache.New[netip.Addr, string]()
+	now := time.Date(2022, time.December, 31, 10, 23, 0, 0, time.UTC)
+	c.Put(now, netip.MustParseAddr("::ffff:127.0.0.1"), "entry1")
+	c.Put(now, netip.MustParseAddr("::ffff:127.0.0.2"), "entry2")
+	c.Put(now, netip.MustParseAddr("::ffff:127.0.1.1"), "entry3")
+
+	prefix := netip.MustParsePrefix("::ffff:127.0.0.0/120")
+	count := c.DeleteFunc(func(ip netip.Addr, _ string) bool {
+		return prefix.Contains(ip)
+	})
+	if count != 2 {
+		t.Errorf("DeleteFunc() == %d, expected 2", count)
+	}
+	expectCacheGet(t, c, "127.0.0.1", "", false)
+	expectCacheGet(t, c, "127.0.0.2", "", false)
+	expectCacheGet(t, c, "127.0.1.1", "entry3", true)
+}
+
+func TestClear(t *testing.T) {
+	c := cache.New[netip.Addr, string]()
+	t1 := time.Date(2022, time.December, 31, 10, 23, 0, 0, time.UTC)
+	c.Put(t1, netip.MustParseAddr("::ffff:127.0.0.1"), "entry1")
+	c.Put(t1, netip.MustParseAddr("::ffff:127.0.0.2"), "entry2")
+
+	if count := c.Clear(); count != 2 {
+		t.Errorf("Clear(): got %d, expected %d", count, 2)
+	}
+	expectCacheGet(t, c, "127.0.0.1", "", false)
+	expectCacheGet(t, c, "127.0.0.2", "", false)
+	if size := c.Size(); size != 0 {
+		t.Errorf("Size(): got %d, expected %d", size, 0)
+	}
+}
+
 func TestItemsLastUpdatedBefore(t *testing.T) {
 	c := cache.New[netip.Addr, string]()
 	t1 := time.Date(2022, time.December, 31, 10, 23, 0, 0, time.UTC)
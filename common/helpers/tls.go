@@ -20,6 +20,9 @@ type TLSConfiguration struct {
 	Enable bool `validate:"required_with=CAFile CertFile KeyFile"`
 	// SkipVerify removes validity checks of remote certificates
 	SkipVerify bool
+	// ServerName overrides the name used for SNI and to check the remote
+	// certificates. If empty, the name of the remote server is used.
+	ServerName string
 	// CAFile tells the location of the CA certificate to check broker
 	// certificate. If empty, the system CA certificates are used instead.
 	CAFile string // no file as the orchestrator may not have the file
@@ -37,6 +40,7 @@ func (config TLSConfiguration) MakeTLSConfig() (*tls.Config, error) {
 	}
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: config.SkipVerify,
+		ServerName:         config.ServerName,
 	}
 	// Read CA certificate if provided
 	if config.CAFile != "" {
@@ -4,10 +4,13 @@
 package helpers
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"net/netip"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -68,6 +71,33 @@ func isListen(fl validator.FieldLevel) bool {
 	return true
 }
 
+// ParsePortRange parses a port ("2055") or a port range ("9995-9999") and
+// returns the first and the last port of the range.
+func ParsePortRange(val string) (uint16, uint16, error) {
+	first, last, isRange := strings.Cut(val, "-")
+	start, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", first)
+	}
+	if !isRange {
+		return uint16(start), uint16(start), nil
+	}
+	end, err := strconv.ParseUint(last, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", last)
+	}
+	if end < start {
+		return 0, 0, errors.New("port range is reversed")
+	}
+	return uint16(start), uint16(end), nil
+}
+
+// isPortRange validates a port or a port range
+func isPortRange(fl validator.FieldLevel) bool {
+	_, _, err := ParsePortRange(fl.Field().String())
+	return err == nil
+}
+
 // noIntersectField validates a field value does not intersect with another one
 // (both fields should be a slice)
 func noIntersectField(fl validator.FieldLevel) bool {
@@ -94,6 +124,7 @@ func noIntersectField(fl validator.FieldLevel) bool {
 func init() {
 	Validate = validator.New()
 	Validate.RegisterValidation("listen", isListen)
+	Validate.RegisterValidation("portrange", isPortRange)
 	Validate.RegisterValidation("ninterfield", noIntersectField)
 	Validate.RegisterCustomTypeFunc(netipValidation, netip.Addr{}, netip.Prefix{})
 	RegisterSubnetMapValidation[string]()
@@ -42,6 +42,36 @@ func TestListenValidator(t *testing.T) {
 	}
 }
 
+func TestPortRangeValidator(t *testing.T) {
+	s := struct {
+		Ports []string `validate:"dive,portrange"`
+	}{}
+	cases := []struct {
+		Pos   helpers.Pos
+		Ports []string
+		Err   bool
+	}{
+		{helpers.Mark(), nil, false},
+		{helpers.Mark(), []string{"2055"}, false},
+		{helpers.Mark(), []string{"2055", "9995-9999"}, false},
+		{helpers.Mark(), []string{"6343-6343"}, false},
+		{helpers.Mark(), []string{"what"}, true},
+		{helpers.Mark(), []string{"100000"}, true},
+		{helpers.Mark(), []string{"9999-9995"}, true},
+		{helpers.Mark(), []string{"9995-"}, true},
+		{helpers.Mark(), []string{"-9995"}, true},
+	}
+	for _, tc := range cases {
+		s.Ports = tc.Ports
+		err := helpers.Validate.Struct(s)
+		if err == nil && tc.Err {
+			t.Errorf("%sValidate.Struct(%q) expected an error", tc.Pos, tc.Ports)
+		} else if err != nil && !tc.Err {
+			t.Errorf("%sValidate.Struct(%q) error:\n%+v", tc.Pos, tc.Ports, err)
+		}
+	}
+}
+
 func TestNoIntersectWithValidator(t *testing.T) {
 	s := struct {
 		Set1 []string
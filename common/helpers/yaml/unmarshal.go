@@ -2,12 +2,13 @@
 // SPDX-License-Identifier: AGPL-3.0-only
 
 // Package yaml implements YAML support for the Go language. It adds the ability
-// to use the "!include" tag.
+// to use the "!include" tag and to interpolate environment variables.
 package yaml
 
 import (
 	"fmt"
 	"io/fs"
+	"regexp"
 	"slices"
 	"strings"
 
@@ -20,18 +21,59 @@ func Unmarshal(in []byte, out any) (err error) {
 	return yaml.Unmarshal(in, out)
 }
 
+// Options alters the decoding of YAML files with UnmarshalWithOptions.
+type Options struct {
+	// LookupEnv is used to interpolate "${VAR}" and "${VAR:-default}" in
+	// scalar values. "$${" is replaced by "${". When nil, there is no
+	// interpolation.
+	LookupEnv func(string) (string, bool)
+}
+
+// Position is the location of a node in a YAML file.
+type Position struct {
+	File string
+	Line int
+}
+
+// Positions maps the path of each node to its position. A path is built from
+// the keys of the nested maps separated by dots, while a list item is
+// designated by its index between brackets (for example, "inlet.flow[0].type").
+// The root node has an empty path.
+type Positions map[string]Position
+
 // UnmarshalWithInclude decodes the first document found within the in byte
 // slice and assigns decoded values into the out value. It also accepts the
 // "!include" tag to include additional files contained in the provided fs.
 func UnmarshalWithInclude(fsys fs.FS, input string, out any) ([]string, error) {
+	paths, _, err := UnmarshalWithOptions(fsys, input, out, Options{})
+	return paths, err
+}
+
+// UnmarshalWithOptions is like UnmarshalWithInclude, but it also interpolates
+// environment variables if requested and returns the position of each node.
+func UnmarshalWithOptions(fsys fs.FS, input string, out any, options Options) ([]string, Positions, error) {
+	positions := Positions{}
+	node, paths, err := unmarshalNode(fsys, input, options, positions, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+	return paths, positions, node.Decode(out)
+}
+
+// unmarshalNode parses the provided file and returns the root node with all
+// the "!include" tags resolved. The position of each node is recorded using
+// the provided prefix for paths.
+func unmarshalNode(fsys fs.FS, input string, options Options, positions Positions, prefix string) (*yaml.Node, []string, error) {
 	var outNode yaml.Node
 	paths := []string{input}
 	in, err := fs.ReadFile(fsys, input)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read %s: %w", input, err)
+		return nil, nil, fmt.Errorf("cannot read %s: %w", input, err)
 	}
 	if err := Unmarshal(in, &outNode); err != nil {
-		return nil, fmt.Errorf("in %s: %w", input, err)
+		return nil, nil, fmt.Errorf("in %s: %w", input, err)
 	}
 
 	if outNode.Kind == yaml.DocumentNode {
@@ -56,31 +98,94 @@ func UnmarshalWithInclude(fsys fs.FS, input string, out any) ([]string, error) {
 		}
 	}
 
-	// Walk the content nodes and replace them with the file they refer to.
-	todo := []*yaml.Node{&outNode}
+	// Walk the content nodes, record their positions, interpolate
+	// environment variables, and replace included files by their content.
+	type todoNode struct {
+		node *yaml.Node
+		path string
+		line int
+	}
+	todo := []todoNode{{&outNode, prefix, outNode.Line}}
 	for len(todo) > 0 {
 		current := todo[0]
 		todo = todo[1:]
-		if current.Tag != "!include" {
-			todo = append(todo, current.Content...)
-			continue
+		positions[current.path] = Position{File: input, Line: current.line}
+		switch {
+		case current.node.Tag == "!include":
+			if current.node.Alias != nil {
+				return nil, nil, fmt.Errorf("at line %d of %s, no alias is allowed for !include", current.node.Line, input)
+			}
+			if len(current.node.Content) > 0 {
+				return nil, nil, fmt.Errorf("at line %d of %s, no content is allowed for !include", current.node.Line, input)
+			}
+			included, morepaths, err := unmarshalNode(fsys, current.node.Value, options, positions, current.path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("at line %d of %s: %w", current.node.Line, input, err)
+			}
+			paths = append(paths, morepaths...)
+			*current.node = *included
+		case current.node.Kind == yaml.ScalarNode:
+			if options.LookupEnv == nil {
+				continue
+			}
+			value, err := interpolate(current.node.Value, options.LookupEnv)
+			if err != nil {
+				return nil, nil, fmt.Errorf("at line %d of %s: %w", current.node.Line, input, err)
+			}
+			if value != current.node.Value {
+				current.node.Value = value
+				if current.node.Style == 0 {
+					// Let the decoder guess the type of the new value.
+					current.node.Tag = ""
+				}
+			}
+		case current.node.Kind == yaml.MappingNode:
+			for i := 0; i < len(current.node.Content)-1; i += 2 {
+				key, value := current.node.Content[i], current.node.Content[i+1]
+				todo = append(todo, todoNode{value, joinPath(current.path, key.Value), key.Line})
+			}
+		case current.node.Kind == yaml.SequenceNode:
+			for i, item := range current.node.Content {
+				todo = append(todo, todoNode{item, fmt.Sprintf("%s[%d]", current.path, i), item.Line})
+			}
+		}
+	}
+
+	return &outNode, paths, nil
+}
+
+// joinPath appends a key to the provided path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+var interpolationRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolate replaces "${VAR}" and "${VAR:-default}" in the provided value
+// using the environment.
+func interpolate(value string, lookupEnv func(string) (string, bool)) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	var err error
+	result := interpolationRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
 		}
-		if current.Alias != nil {
-			return nil, fmt.Errorf("at line %d of %s, no alias is allowed for !include", current.Line, input)
+		submatches := interpolationRegexp.FindStringSubmatch(match)
+		if value, ok := lookupEnv(submatches[1]); ok {
+			return value
 		}
-		if len(current.Content) > 0 {
-			return nil, fmt.Errorf("at line %d of %s, no content is allowed for !include", current.Line, input)
+		if strings.Contains(match, ":-") {
+			return submatches[2]
 		}
-		var outNode yaml.Node
-		morepaths, err := UnmarshalWithInclude(fsys, current.Value, &outNode)
-		if err != nil {
-			return nil, fmt.Errorf("at line %d of %s: %w", current.Line, input, err)
+		if err == nil {
+			err = fmt.Errorf("environment variable %s is not defined", submatches[1])
 		}
-		paths = append(paths, morepaths...)
-		*current = outNode
-	}
-
-	slices.Sort(paths)
-	paths = slices.Compact(paths)
-	return paths, outNode.Decode(out)
+		return match
+	})
+	return result, err
 }
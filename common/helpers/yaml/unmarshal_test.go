@@ -7,6 +7,7 @@ import (
 	"os"
 	"slices"
 	"testing"
+	"testing/fstest"
 
 	"akvorado/common/helpers"
 	"akvorado/common/helpers/yaml"
@@ -48,3 +49,121 @@ func TestUnmarshalWithIn(t *testing.T) {
 		t.Errorf("UnmarshalWithInclude() paths (-got, +want):\n%s", diff)
 	}
 }
+
+func TestUnmarshalPositions(t *testing.T) {
+	fsys := os.DirFS("testdata")
+	var got any
+	_, gotPositions, err := yaml.UnmarshalWithOptions(fsys, "base.yaml", &got, yaml.Options{})
+	if err != nil {
+		t.Fatalf("UnmarshalWithOptions() error:\n%+v", err)
+	}
+	expected := yaml.Positions{
+		"":                  {"base.yaml", 2},
+		"file1":             {"1.yaml", 2},
+		"file1.name":        {"1.yaml", 2},
+		"file2":             {"2.yaml", 2},
+		"file2.name":        {"2.yaml", 2},
+		"nested":            {"nested.yaml", 2},
+		"nested.file1":      {"1.yaml", 2},
+		"nested.file1.name": {"1.yaml", 2},
+		"list1":             {"list1.yaml", 4},
+		"list1[0]":          {"list1.yaml", 4},
+		"list1[1]":          {"list1.yaml", 5},
+		"list1[2]":          {"list1.yaml", 6},
+		"list2":             {"list2.yaml", 9},
+		"list2[0]":          {"list2.yaml", 9},
+		"list2[1]":          {"list2.yaml", 10},
+		"list2[2]":          {"list2.yaml", 11},
+	}
+	if diff := helpers.Diff(gotPositions, expected); diff != "" {
+		t.Errorf("UnmarshalWithOptions() positions (-got, +want):\n%s", diff)
+	}
+}
+
+func TestUnmarshalInterpolation(t *testing.T) {
+	env := map[string]string{
+		"USER":     "alfred",
+		"PASSWORD": "p4$$w0rd",
+		"PORT":     "8080",
+		"EMPTY":    "",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cases := []struct {
+		Pos      helpers.Pos
+		Input    string
+		Expected any
+		Error    bool
+	}{
+		{
+			Pos:      helpers.Mark(),
+			Input:    `user: ${USER}`,
+			Expected: map[string]any{"user": "alfred"},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `dsn: "${USER}:${PASSWORD}@localhost"`,
+			Expected: map[string]any{"dsn": "alfred:p4$$w0rd@localhost"},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `port: ${PORT}`,
+			Expected: map[string]any{"port": 8080},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `port: "${PORT}"`,
+			Expected: map[string]any{"port": "8080"},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `list: [a, "${USER}", c]`,
+			Expected: map[string]any{"list": []any{"a", "alfred", "c"}},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `host: ${HOST:-localhost}`,
+			Expected: map[string]any{"host": "localhost"},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `empty: ${EMPTY:-default}`,
+			Expected: map[string]any{"empty": nil},
+		}, {
+			Pos:      helpers.Mark(),
+			Input:    `escaped: $${USER} and $USER and $$`,
+			Expected: map[string]any{"escaped": "${USER} and $USER and $$"},
+		}, {
+			Pos:   helpers.Mark(),
+			Input: `host: ${HOST}`,
+			Error: true,
+		},
+	}
+	for _, tc := range cases {
+		fsys := fstest.MapFS{
+			"config.yaml": &fstest.MapFile{Data: []byte(tc.Input)},
+		}
+		var got any
+		_, _, err := yaml.UnmarshalWithOptions(fsys, "config.yaml", &got, yaml.Options{LookupEnv: lookupEnv})
+		if err != nil && !tc.Error {
+			t.Errorf("%sUnmarshalWithOptions(%q) error:\n%+v", tc.Pos, tc.Input, err)
+			continue
+		} else if err == nil && tc.Error {
+			t.Errorf("%sUnmarshalWithOptions(%q) did not error", tc.Pos, tc.Input)
+			continue
+		} else if tc.Error {
+			continue
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%sUnmarshalWithOptions(%q) (-got, +want):\n%s", tc.Pos, tc.Input, diff)
+		}
+	}
+
+	// Without LookupEnv, no interpolation
+	fsys := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte(`user: ${USER}`)},
+	}
+	var got any
+	if _, err := yaml.UnmarshalWithInclude(fsys, "config.yaml", &got); err != nil {
+		t.Fatalf("UnmarshalWithInclude() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, map[string]any{"user": "${USER}"}); diff != "" {
+		t.Errorf("UnmarshalWithInclude() (-got, +want):\n%s", diff)
+	}
+}
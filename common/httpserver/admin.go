@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOnly is a middleware restricting access to requests bearing the
+// configured admin token. When no token is configured, the endpoint is not
+// available.
+func (c *Component) AdminOnly(gc *gin.Context) {
+	if c.config.AdminToken == "" {
+		gc.AbortWithStatusJSON(http.StatusNotFound,
+			gin.H{"message": "Administrative endpoints are disabled."})
+		return
+	}
+	token, ok := strings.CutPrefix(gc.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(c.config.AdminToken)) != 1 {
+		gc.Header("WWW-Authenticate", "Bearer")
+		gc.AbortWithStatusJSON(http.StatusUnauthorized,
+			gin.H{"message": "Invalid or missing admin token."})
+		return
+	}
+	gc.Next()
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpserver_test
+
+import (
+	"net/http"
+	"testing"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminOnly(t *testing.T) {
+	for _, token := range []string{"", "s3cr3t"} {
+		r := reporter.NewMock(t)
+		config := httpserver.DefaultConfiguration()
+		config.Listen = "127.0.0.1:0"
+		config.AdminToken = token
+		h, err := httpserver.New(r, config, httpserver.Dependencies{Daemon: daemon.NewMock(t)})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, h)
+		h.GinRouter.GET("/api/v0/admin", h.AdminOnly, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "welcome"})
+		})
+
+		if token == "" {
+			helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+				{
+					Description: "disabled",
+					URL:         "/api/v0/admin",
+					Header:      http.Header{"Authorization": []string{"Bearer "}},
+					StatusCode:  404,
+					JSONOutput:  gin.H{"message": "Administrative endpoints are disabled."},
+				},
+			})
+			continue
+		}
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				Description: "no token",
+				URL:         "/api/v0/admin",
+				StatusCode:  401,
+				JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+			}, {
+				Description: "wrong token",
+				URL:         "/api/v0/admin",
+				Header:      http.Header{"Authorization": []string{"Bearer s3cr3"}},
+				StatusCode:  401,
+				JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+			}, {
+				Description: "good token",
+				URL:         "/api/v0/admin",
+				Header:      http.Header{"Authorization": []string{"Bearer s3cr3t"}},
+				JSONOutput:  gin.H{"message": "welcome"},
+			},
+		})
+	}
+}
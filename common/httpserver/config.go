@@ -21,6 +21,9 @@ type Configuration struct {
 	Listen string `validate:"required,listen"`
 	// Profiler enables Go profiler as /debug
 	Profiler bool
+	// AdminToken is the bearer token required for administrative endpoints.
+	// When empty, these endpoints are disabled.
+	AdminToken string
 	// Cache configuration
 	Cache CacheConfiguration
 }
@@ -6,6 +6,7 @@
 package httpserver
 
 import (
+	"net/http"
 	"testing"
 
 	"akvorado/common/daemon"
@@ -13,11 +14,22 @@ import (
 	"akvorado/common/reporter"
 )
 
+// MockAdminToken is the admin token configured for the component returned by
+// NewMock.
+const MockAdminToken = "s3cr3t"
+
+// MockAdminHeader returns the header to access administrative endpoints of the
+// component returned by NewMock.
+func MockAdminHeader() http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + MockAdminToken}}
+}
+
 // NewMock create a new HTTP component listening on a random free port.
 func NewMock(t testing.TB, r *reporter.Reporter) *Component {
 	t.Helper()
 	config := DefaultConfiguration()
 	config.Listen = "0.0.0.0:0"
+	config.AdminToken = MockAdminToken
 	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
 	if err != nil {
 		t.Fatalf("New() error:\n%+v", err)
@@ -10,6 +10,7 @@ import (
 	"reflect"
 
 	"akvorado/common/helpers"
+	"akvorado/common/pb"
 	"akvorado/common/reporter"
 
 	"github.com/gin-gonic/gin"
@@ -26,12 +27,20 @@ import (
 type Configuration struct {
 	// Topic defines the topic to write flows to.
 	Topic string `validate:"required"`
+	// TopicPrefix is prepended to the name of the topics and consumer
+	// groups. It allows several environments to share a Kafka cluster.
+	TopicPrefix string
+	// TopicSuffix is appended to the name of the topics (before the
+	// version).
+	TopicSuffix string
 	// Brokers is the list of brokers to connect to.
 	Brokers []string `min=1,dive,validate:"listen"`
 	// TLS defines TLS configuration
 	TLS helpers.TLSConfiguration
 	// SASL defines SASL configuration
 	SASL SASLConfiguration
+	// Envelope defines an optional envelope around payloads
+	Envelope EnvelopeConfiguration
 }
 
 // SASLConfiguration defines SASL configuration.
@@ -56,6 +65,24 @@ func DefaultConfiguration() Configuration {
 	}
 }
 
+// TopicName returns the name of the provided topic with the configured prefix
+// and suffix.
+func (c Configuration) TopicName(topic string) string {
+	return fmt.Sprintf("%s%s%s", c.TopicPrefix, topic, c.TopicSuffix)
+}
+
+// FlowsTopic returns the name of the topic for flows, including the version of
+// the protobuf schema.
+func (c Configuration) FlowsTopic() string {
+	return fmt.Sprintf("%s-v%d", c.TopicName(c.Topic), pb.TopicVersion)
+}
+
+// ConsumerGroupName returns the name of the provided consumer group with the
+// configured prefix.
+func (c Configuration) ConsumerGroupName(group string) string {
+	return fmt.Sprintf("%s%s", c.TopicPrefix, group)
+}
+
 // SASLMechanism defines an SASL algorithm
 type SASLMechanism int
 
@@ -4,9 +4,11 @@
 package kafka
 
 import (
+	"fmt"
 	"testing"
 
 	"akvorado/common/helpers"
+	"akvorado/common/pb"
 	"akvorado/common/reporter"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +20,28 @@ func TestDefaultConfiguration(t *testing.T) {
 	}
 }
 
+func TestTopicNames(t *testing.T) {
+	config := DefaultConfiguration()
+	if got := config.FlowsTopic(); got != fmt.Sprintf("flows-v%d", pb.TopicVersion) {
+		t.Errorf("FlowsTopic() == %q", got)
+	}
+	if got := config.ConsumerGroupName("akvorado-outlet"); got != "akvorado-outlet" {
+		t.Errorf("ConsumerGroupName() == %q", got)
+	}
+
+	config.TopicPrefix = "staging-"
+	config.TopicSuffix = "-eu"
+	if got := config.FlowsTopic(); got != fmt.Sprintf("staging-flows-eu-v%d", pb.TopicVersion) {
+		t.Errorf("FlowsTopic() == %q", got)
+	}
+	if got := config.TopicName("datagrams"); got != "staging-datagrams-eu" {
+		t.Errorf("TopicName() == %q", got)
+	}
+	if got := config.ConsumerGroupName("akvorado-outlet"); got != "staging-akvorado-outlet" {
+		t.Errorf("ConsumerGroupName() == %q", got)
+	}
+}
+
 func TestKafkaNewConfig(t *testing.T) {
 	// It is a bit a pain to test the result, just check we don't have an error
 	cases := []struct {
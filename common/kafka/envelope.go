@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	// EnvelopeHeader is the header listing the transformations applied to a
+	// payload, in order. Records without this header are not enveloped.
+	EnvelopeHeader = "akvorado-envelope"
+	// EnvelopeKeyHeader is the header with the identifier of the key used to
+	// encrypt a payload.
+	EnvelopeKeyHeader = "akvorado-envelope-key"
+
+	envelopeZstd      = "zstd"
+	envelopeAES256GCM = "aes-256-gcm"
+
+	// envelopeMaxDecompressedSize is the maximum size of a decompressed
+	// payload. This is the default maximum size of a Kafka message (1 MiB)
+	// times a compression ratio that legitimate payloads do not reach.
+	envelopeMaxDecompressedSize = 64 << 20
+)
+
+// EnvelopeConfiguration defines an optional envelope around the payloads sent
+// to Kafka, for deployments where encryption cannot be provided by Kafka.
+type EnvelopeConfiguration struct {
+	// Compression compresses payloads with zstd. As encrypted payloads cannot
+	// be compressed by Kafka, this should be enabled with encryption.
+	Compression bool
+	// Keys maps key identifiers to 256-bit AES keys, encoded in base64. They
+	// are used to decrypt payloads.
+	Keys map[string]string `validate:"dive,keys,printascii,endkeys,base64"`
+	// EncryptionKey is the identifier of the key used to encrypt payloads.
+	// When empty, payloads are not encrypted.
+	EncryptionKey string
+}
+
+// Envelope seals and opens payloads sent to Kafka.
+type Envelope struct {
+	compression   bool
+	encryptionKey string
+	aeads         map[string]cipher.AEAD
+	header        []byte
+	encoder       *zstd.Encoder
+	decoder       *zstd.Decoder
+}
+
+// NewEnvelope creates a new envelope from the provided configuration.
+func NewEnvelope(config EnvelopeConfiguration) (*Envelope, error) {
+	e := Envelope{
+		compression:   config.Compression,
+		encryptionKey: config.EncryptionKey,
+		aeads:         map[string]cipher.AEAD{},
+	}
+	for id, encoded := range config.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode envelope key %q: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("envelope key %q should be 256-bit long", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot use envelope key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("cannot use envelope key %q: %w", id, err)
+		}
+		e.aeads[id] = aead
+	}
+	if e.encryptionKey != "" {
+		if _, ok := e.aeads[e.encryptionKey]; !ok {
+			return nil, fmt.Errorf("unknown envelope encryption key %q", e.encryptionKey)
+		}
+	}
+
+	transformations := []string{}
+	if e.compression {
+		transformations = append(transformations, envelopeZstd)
+	}
+	if e.encryptionKey != "" {
+		transformations = append(transformations, envelopeAES256GCM)
+	}
+	e.header = []byte(strings.Join(transformations, ","))
+
+	var err error
+	e.encoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create zstd encoder: %w", err)
+	}
+	e.decoder, err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(envelopeMaxDecompressedSize))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create zstd decoder: %w", err)
+	}
+	return &e, nil
+}
+
+// Enabled tells if payloads are sealed.
+func (e *Envelope) Enabled() bool {
+	return len(e.header) > 0
+}
+
+// Seal compresses and encrypts a payload, as configured. It returns the sealed
+// payload and the headers to attach to the record. When the envelope is not
+// enabled, the payload is returned unmodified.
+func (e *Envelope) Seal(payload []byte) ([]byte, []kgo.RecordHeader) {
+	if !e.Enabled() {
+		return payload, nil
+	}
+	headers := []kgo.RecordHeader{{Key: EnvelopeHeader, Value: e.header}}
+	if e.compression {
+		payload = e.encoder.EncodeAll(payload, nil)
+	}
+	if e.encryptionKey != "" {
+		aead := e.aeads[e.encryptionKey]
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+		rand.Read(nonce)
+		payload = aead.Seal(nonce, nonce, payload, []byte(e.encryptionKey))
+		headers = append(headers, kgo.RecordHeader{Key: EnvelopeKeyHeader, Value: []byte(e.encryptionKey)})
+	}
+	return payload, headers
+}
+
+// Open decrypts and decompresses the payload of a record, according to its
+// headers. Records without envelope are returned unmodified.
+func (e *Envelope) Open(record *kgo.Record) ([]byte, error) {
+	var transformations, keyID string
+	for _, header := range record.Headers {
+		switch header.Key {
+		case EnvelopeHeader:
+			transformations = string(header.Value)
+		case EnvelopeKeyHeader:
+			keyID = string(header.Value)
+		}
+	}
+	if transformations == "" {
+		return record.Value, nil
+	}
+	payload := record.Value
+	steps := strings.Split(transformations, ",")
+	slices.Reverse(steps)
+	for _, step := range steps {
+		switch step {
+		case envelopeAES256GCM:
+			aead, ok := e.aeads[keyID]
+			if !ok {
+				return nil, fmt.Errorf("unknown envelope key %q", keyID)
+			}
+			if len(payload) < aead.NonceSize() {
+				return nil, errors.New("encrypted payload too short")
+			}
+			nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+			plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+			if err != nil {
+				return nil, fmt.Errorf("cannot decrypt payload: %w", err)
+			}
+			payload = plaintext
+		case envelopeZstd:
+			decompressed, err := e.decoder.DecodeAll(payload, nil)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decompress payload: %w", err)
+			}
+			payload = decompressed
+		default:
+			return nil, fmt.Errorf("unknown envelope transformation %q", step)
+		}
+	}
+	return payload, nil
+}
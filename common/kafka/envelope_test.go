@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"akvorado/common/helpers"
+)
+
+var (
+	envelopeKey1 = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	envelopeKey2 = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+)
+
+func TestNewEnvelopeErrors(t *testing.T) {
+	cases := []struct {
+		Pos    helpers.Pos
+		Config EnvelopeConfiguration
+	}{
+		{helpers.Mark(), EnvelopeConfiguration{Keys: map[string]string{"k1": "not base64!"}}},
+		{helpers.Mark(), EnvelopeConfiguration{Keys: map[string]string{"k1": "aGVsbG8="}}},
+		{helpers.Mark(), EnvelopeConfiguration{
+			Keys:          map[string]string{"k1": envelopeKey1},
+			EncryptionKey: "k2",
+		}},
+	}
+	for _, tc := range cases {
+		if _, err := NewEnvelope(tc.Config); err == nil {
+			t.Errorf("%sNewEnvelope() did not error", tc.Pos)
+		}
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	payload := bytes.Repeat([]byte("hello world!"), 100)
+	cases := []struct {
+		Pos     helpers.Pos
+		Config  EnvelopeConfiguration
+		Headers []kgo.RecordHeader
+	}{
+		{helpers.Mark(), EnvelopeConfiguration{}, nil},
+		{helpers.Mark(), EnvelopeConfiguration{Compression: true}, []kgo.RecordHeader{
+			{Key: "akvorado-envelope", Value: []byte("zstd")},
+		}},
+		{helpers.Mark(), EnvelopeConfiguration{
+			Keys:          map[string]string{"k1": envelopeKey1},
+			EncryptionKey: "k1",
+		}, []kgo.RecordHeader{
+			{Key: "akvorado-envelope", Value: []byte("aes-256-gcm")},
+			{Key: "akvorado-envelope-key", Value: []byte("k1")},
+		}},
+		{helpers.Mark(), EnvelopeConfiguration{
+			Compression:   true,
+			Keys:          map[string]string{"k1": envelopeKey1},
+			EncryptionKey: "k1",
+		}, []kgo.RecordHeader{
+			{Key: "akvorado-envelope", Value: []byte("zstd,aes-256-gcm")},
+			{Key: "akvorado-envelope-key", Value: []byte("k1")},
+		}},
+	}
+	// The consumer knows all keys and does not encrypt
+	consumer, err := NewEnvelope(EnvelopeConfiguration{
+		Keys: map[string]string{"k1": envelopeKey1, "k2": envelopeKey2},
+	})
+	if err != nil {
+		t.Fatalf("NewEnvelope() error:\n%+v", err)
+	}
+	for _, tc := range cases {
+		producer, err := NewEnvelope(tc.Config)
+		if err != nil {
+			t.Fatalf("%sNewEnvelope() error:\n%+v", tc.Pos, err)
+		}
+		sealed, headers := producer.Seal(payload)
+		if diff := helpers.Diff(headers, tc.Headers); diff != "" {
+			t.Errorf("%sSeal() headers (-got, +want):\n%s", tc.Pos, diff)
+		}
+		if tc.Config.EncryptionKey != "" && bytes.Contains(sealed, []byte("hello world!")) {
+			t.Errorf("%sSeal() did not encrypt payload", tc.Pos)
+		}
+		if tc.Config.Compression && len(sealed) >= len(payload) {
+			t.Errorf("%sSeal() did not compress payload", tc.Pos)
+		}
+		got, err := consumer.Open(&kgo.Record{Value: sealed, Headers: headers})
+		if err != nil {
+			t.Fatalf("%sOpen() error:\n%+v", tc.Pos, err)
+		}
+		if diff := helpers.Diff(got, payload); diff != "" {
+			t.Errorf("%sOpen() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
+
+func TestEnvelopeOpenErrors(t *testing.T) {
+	producer, err := NewEnvelope(EnvelopeConfiguration{
+		Keys:          map[string]string{"k2": envelopeKey2},
+		EncryptionKey: "k2",
+	})
+	if err != nil {
+		t.Fatalf("NewEnvelope() error:\n%+v", err)
+	}
+	consumer, err := NewEnvelope(EnvelopeConfiguration{
+		Keys: map[string]string{"k1": envelopeKey1},
+	})
+	if err != nil {
+		t.Fatalf("NewEnvelope() error:\n%+v", err)
+	}
+	sealed, headers := producer.Seal([]byte("hello world!"))
+
+	// Unknown key
+	if _, err := consumer.Open(&kgo.Record{Value: sealed, Headers: headers}); err == nil {
+		t.Error("Open() did not error with an unknown key")
+	}
+	// Tampered payload
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := producer.Open(&kgo.Record{Value: sealed, Headers: headers}); err == nil {
+		t.Error("Open() did not error with a tampered payload")
+	}
+	// Unknown transformation
+	if _, err := consumer.Open(&kgo.Record{
+		Value:   []byte("hello"),
+		Headers: []kgo.RecordHeader{{Key: "akvorado-envelope", Value: []byte("rot13")}},
+	}); err == nil {
+		t.Error("Open() did not error with an unknown transformation")
+	}
+	// Decompression bomb
+	bomb, err := NewEnvelope(EnvelopeConfiguration{Compression: true})
+	if err != nil {
+		t.Fatalf("NewEnvelope() error:\n%+v", err)
+	}
+	sealed, headers = bomb.Seal(make([]byte, envelopeMaxDecompressedSize+1))
+	if _, err := consumer.Open(&kgo.Record{Value: sealed, Headers: headers}); !errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		t.Errorf("Open() error:\n%+v", err)
+	}
+}
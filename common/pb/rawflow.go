@@ -12,14 +12,46 @@ import (
 	"akvorado/common/helpers/bimap"
 )
 
-// Version is the version of the schema. On incompatible changes, this should be
-// bumped.
+// Version is the version of the schema. On changes, this should be bumped. The
+// version is embedded in each message and the outlet accepts messages from the
+// previous and the next version: unknown fields are ignored and missing fields
+// keep their default value. This way, inlets and outlets can be upgraded
+// independently.
 var Version = 5
 
+// TopicVersion is the version used in the name of the Kafka topic. It is not
+// tied to Version as inlets and outlets using adjacent versions need to share
+// the same topic. It should only be bumped on changes the outlet cannot cope
+// with, for example when reusing a field number with another type.
+const TopicVersion = 5
+
+// unversionedVersion is the version of messages without a version. They were
+// produced by inlets predating the embedded version.
+const unversionedVersion = 5
+
+// ErrIncompatibleVersion is returned when the version of a message is too old
+// or too recent.
+var ErrIncompatibleVersion = errors.New("incompatible schema version")
+
+// CheckVersion checks if the raw flow was encoded with a compatible version of
+// the schema.
+func (m *RawFlow) CheckVersion() error {
+	version := int(m.Version)
+	if version == 0 {
+		version = unversionedVersion
+	}
+	if version < Version-1 || version > Version+1 {
+		return fmt.Errorf("%w: got %d, expected %d±1", ErrIncompatibleVersion, version, Version)
+	}
+	return nil
+}
+
 var decoderMap = bimap.New(map[RawFlow_Decoder]string{
 	RawFlow_DECODER_NETFLOW: "netflow",
 	RawFlow_DECODER_SFLOW:   "sflow",
 	RawFlow_DECODER_GOB:     "gob",
+	RawFlow_DECODER_GOFLOW2: "goflow2",
+	RawFlow_DECODER_PMACCT:  "pmacct",
 })
 
 // MarshalText turns a decoder to text
@@ -33,6 +33,39 @@ type Source struct {
 	Interval time.Duration `validate:"min=1m"`
 	// TLS defines the TLS configuration if the URL needs it.
 	TLS helpers.TLSConfiguration
+	// BearerToken is a token to send in the Authorization header.
+	BearerToken string
+	// OAuth2 defines how to get a token with the OAuth2 client credentials
+	// flow.
+	OAuth2 OAuth2Configuration
+	// Incremental defines how to only fetch the changes since the last
+	// update.
+	Incremental IncrementalConfiguration
+}
+
+// OAuth2Configuration describes how to get an access token with the OAuth2
+// client credentials flow. It is enabled when TokenURL is not empty.
+type OAuth2Configuration struct {
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string `validate:"omitempty,url"`
+	// ClientID is the client identifier.
+	ClientID string `validate:"required_with=TokenURL"`
+	// ClientSecret is the client secret.
+	ClientSecret string
+	// Scopes are the scopes to request.
+	Scopes []string
+}
+
+// IncrementalConfiguration describes how to fetch only the changes since the
+// last successful update. It is enabled when Parameter is not empty. Results
+// are merged with the previous ones.
+type IncrementalConfiguration struct {
+	// Parameter is the name of the query parameter used to provide the time
+	// of the last successful update.
+	Parameter string
+	// FullInterval tells how often a full update is done to catch up with
+	// removed entries. A value of 0 means never.
+	FullInterval time.Duration `validate:"isdefault|min=1m"`
 }
 
 // TransformQuery represents a jq query to transform data.
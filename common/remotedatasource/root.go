@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/itchyny/gojq"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 	"gopkg.in/tomb.v2"
 
 	"akvorado/common/helpers"
@@ -36,9 +40,25 @@ type Component[T any] struct {
 	dataSources map[string]Source
 	metrics     metrics
 
+	incrementalLock   sync.Mutex
+	incrementalStates map[string]incrementalState[T]
+
 	DataSourcesReady chan bool // closed when all data sources are ready
 }
 
+// Keyer should be implemented by the data types of sources using incremental
+// updates. The key identifies an entry to be replaced by a newer version.
+type Keyer interface {
+	Key() string
+}
+
+// incrementalState is the state kept for a source using incremental updates.
+type incrementalState[T any] struct {
+	lastUpdate time.Time
+	lastFull   time.Time
+	results    []T
+}
+
 var (
 	// ErrBuildRequest is triggered when we cannot build an HTTP request
 	ErrBuildRequest = errors.New("cannot build HTTP request")
@@ -66,6 +86,8 @@ func New[T any](r *reporter.Reporter, provider ProviderFunc, dataType string, da
 		dataType:         dataType,
 		dataSources:      dataSources,
 		DataSourcesReady: make(chan bool),
+
+		incrementalStates: make(map[string]incrementalState[T]),
 	}
 
 	for k, source := range c.dataSources {
@@ -76,6 +98,15 @@ func New[T any](r *reporter.Reporter, provider ProviderFunc, dataType string, da
 		if _, err := source.TLS.MakeTLSConfig(); err != nil {
 			return nil, err
 		}
+		if source.BearerToken != "" && source.OAuth2.TokenURL != "" {
+			return nil, fmt.Errorf("source %s cannot use both a bearer token and OAuth2", k)
+		}
+		if source.Incremental.Parameter != "" {
+			var zero T
+			if _, ok := any(zero).(Keyer); !ok {
+				return nil, fmt.Errorf("source %s cannot use incremental updates for %s", k, dataType)
+			}
+		}
 	}
 
 	c.initMetrics()
@@ -86,17 +117,53 @@ func New[T any](r *reporter.Reporter, provider ProviderFunc, dataType string, da
 // of results decoded from JSON to generic type. Fetch should be used in
 // UpdateSource implementations to update internal data from results.
 // It outputs errors without details because they are used for metrics.
+// When the source uses incremental updates, the changes are merged with
+// the previous results.
 func (c *Component[T]) Fetch(ctx context.Context, name string, source Source) ([]T, error) {
 	var results []T
 	l := c.r.With().Str("name", name).Str("url", source.URL).Logger()
-	l.Info().Msg("update data source")
+
+	// Check if we can do an incremental update
+	now := time.Now()
+	incremental := false
+	c.incrementalLock.Lock()
+	state, ok := c.incrementalStates[name]
+	c.incrementalLock.Unlock()
+	if source.Incremental.Parameter != "" && ok {
+		incremental = source.Incremental.FullInterval == 0 ||
+			now.Sub(state.lastFull) < source.Incremental.FullInterval
+	}
+	sourceURL := source.URL
+	if incremental {
+		u, err := url.Parse(sourceURL)
+		if err != nil {
+			l.Err(err).Msg("unable to parse URL")
+			return nil, ErrBuildRequest
+		}
+		q := u.Query()
+		q.Set(source.Incremental.Parameter, state.lastUpdate.UTC().Format(time.RFC3339))
+		u.RawQuery = q.Encode()
+		sourceURL = u.String()
+		l.Info().Msg("update data source incrementally")
+	} else {
+		l.Info().Msg("update data source")
+	}
 
 	tlsConfig, _ := source.TLS.MakeTLSConfig()
 	client := &http.Client{Transport: &http.Transport{
 		Proxy:           http.ProxyFromEnvironment,
 		TLSClientConfig: tlsConfig,
 	}}
-	req, err := http.NewRequestWithContext(ctx, source.Method, source.URL, nil)
+	if source.OAuth2.TokenURL != "" {
+		oauth2Config := clientcredentials.Config{
+			ClientID:     source.OAuth2.ClientID,
+			ClientSecret: source.OAuth2.ClientSecret,
+			TokenURL:     source.OAuth2.TokenURL,
+			Scopes:       source.OAuth2.Scopes,
+		}
+		client = oauth2Config.Client(context.WithValue(ctx, oauth2.HTTPClient, client))
+	}
+	req, err := http.NewRequestWithContext(ctx, source.Method, sourceURL, nil)
 	if err != nil {
 		l.Err(err).Msg("unable to build new request")
 		return nil, ErrBuildRequest
@@ -104,6 +171,9 @@ func (c *Component[T]) Fetch(ctx context.Context, name string, source Source) ([
 	for headerName, headerValue := range source.Headers {
 		req.Header.Set(headerName, headerValue)
 	}
+	if source.BearerToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", source.BearerToken))
+	}
 	req.Header.Set("accept", "application/json")
 	resp, err := client.Do(req)
 	if err != nil {
@@ -159,13 +229,46 @@ func (c *Component[T]) Fetch(ctx context.Context, name string, source Source) ([
 		}
 		results = append(results, result)
 	}
-	if len(results) == 0 {
+	if len(results) == 0 && !incremental {
 		l.Error().Msg("empty result")
 		return nil, ErrEmpty
 	}
+	if source.Incremental.Parameter != "" {
+		if incremental {
+			results = mergeResults(state.results, results)
+		} else {
+			state.lastFull = now
+		}
+		state.lastUpdate = now
+		state.results = results
+		c.incrementalLock.Lock()
+		c.incrementalStates[name] = state
+		c.incrementalLock.Unlock()
+	}
 	return results, nil
 }
 
+// mergeResults merges changes into previous results. Entries with the same
+// key are replaced.
+func mergeResults[T any](previous, changes []T) []T {
+	results := make([]T, len(previous), len(previous)+len(changes))
+	copy(results, previous)
+	index := make(map[string]int, len(previous))
+	for idx, result := range results {
+		index[any(result).(Keyer).Key()] = idx
+	}
+	for _, change := range changes {
+		key := any(change).(Keyer).Key()
+		if idx, ok := index[key]; ok {
+			results[idx] = change
+			continue
+		}
+		index[key] = len(results)
+		results = append(results, change)
+	}
+	return results
+}
+
 // Start the remote data source fetcher component.
 func (c *Component[T]) Start() error {
 	c.r.Info().Msg("starting remote data source fetcher component")
@@ -15,6 +15,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,10 @@ type remoteData struct {
 	Count       int
 }
 
+func (rd remoteData) Key() string {
+	return rd.Name
+}
+
 type remoteDataHandler struct {
 	data     []remoteData
 	fetcher  *Component[remoteData]
@@ -343,3 +348,164 @@ func TestSourceWithTLS(t *testing.T) {
 		}
 	})
 }
+
+func TestSourceAuthentication(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(400)
+			return
+		}
+		if user, password, _ := r.BasicAuth(); user != "akvorado" || password != "secret" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "oauth2-token", "token_type": "bearer", "expires_in": 3600}`))
+	}))
+	mux.Handle("/data.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer static-token" && auth != "Bearer oauth2-token" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.Write([]byte(`{"results": [{"name": "foo", "description": "bar"}]}`))
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cases := []struct {
+		Description string
+		Source      Source
+		Error       bool
+	}{
+		{
+			Description: "no authentication",
+			Source:      Source{},
+			Error:       true,
+		}, {
+			Description: "bearer token",
+			Source:      Source{BearerToken: "static-token"},
+		}, {
+			Description: "OAuth2",
+			Source: Source{OAuth2: OAuth2Configuration{
+				TokenURL:     fmt.Sprintf("%s/token", server.URL),
+				ClientID:     "akvorado",
+				ClientSecret: "secret",
+			}},
+		}, {
+			Description: "OAuth2 with wrong secret",
+			Source: Source{OAuth2: OAuth2Configuration{
+				TokenURL:     fmt.Sprintf("%s/token", server.URL),
+				ClientID:     "akvorado",
+				ClientSecret: "wrong",
+			}},
+			Error: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			source := tc.Source
+			source.URL = fmt.Sprintf("%s/data.json", server.URL)
+			source.Method = "GET"
+			source.Timeout = time.Second
+			source.Interval = time.Minute
+			source.Transform = MustParseTransformQuery(".results[]")
+			fetcher, err := New[remoteData](reporter.NewMock(t), nil, "test",
+				map[string]Source{"local": source})
+			if err != nil {
+				t.Fatalf("New() error:\n%+v", err)
+			}
+			results, err := fetcher.Fetch(t.Context(), "local", source)
+			if err != nil && !tc.Error {
+				t.Fatalf("Fetch() error:\n%+v", err)
+			} else if err == nil && tc.Error {
+				t.Fatal("Fetch() did not error")
+			} else if err == nil {
+				if diff := helpers.Diff(results, []remoteData{{Name: "foo", Description: "bar"}}); diff != "" {
+					t.Fatalf("Fetch() (-got, +want):\n%s", diff)
+				}
+			}
+		})
+	}
+
+	t.Run("bearer token and OAuth2", func(t *testing.T) {
+		_, err := New[remoteData](reporter.NewMock(t), nil, "test", map[string]Source{
+			"local": {
+				BearerToken: "static-token",
+				OAuth2:      OAuth2Configuration{TokenURL: fmt.Sprintf("%s/token", server.URL)},
+			},
+		})
+		if err == nil {
+			t.Fatal("New() did not error")
+		}
+	})
+}
+
+func TestSourceIncremental(t *testing.T) {
+	var lastSince atomic.Value
+	lastSince.Store("")
+	mux := http.NewServeMux()
+	mux.Handle("/data.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		lastSince.Store(since)
+		w.Header().Add("Content-Type", "application/json")
+		if since == "" {
+			w.Write([]byte(`{"results": [{"name": "foo", "count": 1}, {"name": "bar", "count": 1}]}`))
+		} else {
+			w.Write([]byte(`{"results": [{"name": "bar", "count": 2}, {"name": "baz", "count": 1}]}`))
+		}
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	source := Source{
+		URL:       fmt.Sprintf("%s/data.json", server.URL),
+		Method:    "GET",
+		Timeout:   time.Second,
+		Interval:  time.Minute,
+		Transform: MustParseTransformQuery(".results[]"),
+		Incremental: IncrementalConfiguration{
+			Parameter:    "since",
+			FullInterval: time.Hour,
+		},
+	}
+	fetcher, err := New[remoteData](reporter.NewMock(t), nil, "test",
+		map[string]Source{"local": source})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// First fetch is a full one
+	results, err := fetcher.Fetch(t.Context(), "local", source)
+	if err != nil {
+		t.Fatalf("Fetch() error:\n%+v", err)
+	}
+	if since := lastSince.Load().(string); since != "" {
+		t.Fatalf("Fetch() first request used since=%q", since)
+	}
+	expected := []remoteData{{Name: "foo", Count: 1}, {Name: "bar", Count: 1}}
+	if diff := helpers.Diff(results, expected); diff != "" {
+		t.Fatalf("Fetch() (-got, +want):\n%s", diff)
+	}
+
+	// Second fetch is incremental
+	results, err = fetcher.Fetch(t.Context(), "local", source)
+	if err != nil {
+		t.Fatalf("Fetch() error:\n%+v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, lastSince.Load().(string)); err != nil {
+		t.Fatalf("Fetch() second request used since=%q", lastSince.Load())
+	}
+	expected = []remoteData{{Name: "foo", Count: 1}, {Name: "bar", Count: 2}, {Name: "baz", Count: 1}}
+	if diff := helpers.Diff(results, expected); diff != "" {
+		t.Fatalf("Fetch() (-got, +want):\n%s", diff)
+	}
+
+	// Incremental updates need a key
+	if _, err := New[struct{ Name string }](reporter.NewMock(t), nil, "test",
+		map[string]Source{"local": source}); err == nil {
+		t.Fatal("New() did not error")
+	}
+}
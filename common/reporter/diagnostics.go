@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reporter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+)
+
+// maxCPUProfileDuration is the maximum duration of a CPU profile included in
+// a diagnostics bundle.
+const maxCPUProfileDuration = 2 * time.Minute
+
+// DiagnosticsFunc defines a function returning the state of a component as a
+// value serializable to JSON (for example, the occupancy of its buffers).
+type DiagnosticsFunc func() any
+
+// RegisterDiagnostics registers a new function to be called when building a
+// diagnostics bundle.
+func (r *Reporter) RegisterDiagnostics(name string, df DiagnosticsFunc) {
+	r.diagnosticsLock.Lock()
+	r.diagnostics[name] = df
+	r.diagnosticsLock.Unlock()
+}
+
+// DiagnosticsMemory is a summary of the memory statistics.
+type DiagnosticsMemory struct {
+	HeapAlloc   uint64 `json:"heap-alloc"`
+	HeapInuse   uint64 `json:"heap-inuse"`
+	HeapObjects uint64 `json:"heap-objects"`
+	TotalAlloc  uint64 `json:"total-alloc"`
+	Sys         uint64 `json:"sys"`
+}
+
+// DiagnosticsGC is a summary of the garbage collector statistics.
+type DiagnosticsGC struct {
+	NumGC      int64         `json:"num-gc"`
+	PauseTotal time.Duration `json:"pause-total"`
+	LastGC     time.Time     `json:"last-gc"`
+	NextGC     uint64        `json:"next-gc"`
+}
+
+// DiagnosticsSnapshot is a snapshot of the runtime state.
+type DiagnosticsSnapshot struct {
+	Time       time.Time         `json:"time"`
+	Version    string            `json:"version"`
+	GoVersion  string            `json:"go-version"`
+	CPUs       int               `json:"cpus"`
+	GOMAXPROCS int               `json:"gomaxprocs"`
+	Goroutines int               `json:"goroutines"`
+	Memory     DiagnosticsMemory `json:"memory"`
+	GC         DiagnosticsGC     `json:"gc"`
+	Components map[string]any    `json:"components"`
+}
+
+// DiagnosticsSnapshot returns a snapshot of the runtime state, including the
+// state of each component having registered a diagnostics function.
+func (r *Reporter) DiagnosticsSnapshot() DiagnosticsSnapshot {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	var gcStats debug.GCStats
+	debug.ReadGCStats(&gcStats)
+
+	snapshot := DiagnosticsSnapshot{
+		Time:       time.Now(),
+		Version:    helpers.AkvoradoVersion,
+		GoVersion:  runtime.Version(),
+		CPUs:       runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: DiagnosticsMemory{
+			HeapAlloc:   memStats.HeapAlloc,
+			HeapInuse:   memStats.HeapInuse,
+			HeapObjects: memStats.HeapObjects,
+			TotalAlloc:  memStats.TotalAlloc,
+			Sys:         memStats.Sys,
+		},
+		GC: DiagnosticsGC{
+			NumGC:      gcStats.NumGC,
+			PauseTotal: gcStats.PauseTotal,
+			LastGC:     gcStats.LastGC,
+			NextGC:     memStats.NextGC,
+		},
+		Components: map[string]any{},
+	}
+
+	r.diagnosticsLock.Lock()
+	defer r.diagnosticsLock.Unlock()
+	for name, df := range r.diagnostics {
+		snapshot.Components[name] = df()
+	}
+	return snapshot
+}
+
+// DiagnosticsHTTPHandler is an HTTP handler returning a diagnostics bundle as
+// a gzipped tarball. It contains a runtime snapshot, the healthcheck results,
+// as well as CPU, heap and goroutine profiles. The duration of the CPU profile
+// can be set with the "cpu" query parameter (10 seconds by default, 0 to
+// disable). This handler should be protected as the profiles may leak
+// sensitive information.
+func (r *Reporter) DiagnosticsHTTPHandler(c *gin.Context) {
+	cpuDuration := 10 * time.Second
+	if value := c.Query("cpu"); value != "" {
+		var err error
+		cpuDuration, err = time.ParseDuration(value)
+		if err != nil || cpuDuration < 0 || cpuDuration > maxCPUProfileDuration {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Invalid CPU profile duration %q.", value),
+			})
+			return
+		}
+	}
+
+	files := []diagnosticsFile{}
+
+	// CPU profile first, as it is the slowest and the most likely to fail.
+	if cpuDuration > 0 {
+		var buf bytes.Buffer
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Cannot start CPU profile, another one may be running.",
+			})
+			return
+		}
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(cpuDuration):
+		}
+		pprof.StopCPUProfile()
+		files = append(files, diagnosticsFile{"cpu.pprof", buf.Bytes()})
+	}
+	for _, profile := range []struct {
+		name  string
+		debug int
+		file  string
+	}{
+		{"heap", 0, "heap.pprof"},
+		{"goroutine", 0, "goroutine.pprof"},
+		{"goroutine", 2, "goroutines.txt"},
+	} {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(profile.name).WriteTo(&buf, profile.debug); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": fmt.Sprintf("Cannot build %s profile.", profile.name),
+			})
+			return
+		}
+		files = append(files, diagnosticsFile{profile.file, buf.Bytes()})
+	}
+	snapshot, err := json.MarshalIndent(r.DiagnosticsSnapshot(), "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot build runtime snapshot."})
+		return
+	}
+	files = append(files, diagnosticsFile{"runtime.json", snapshot})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	healthchecks, err := json.MarshalIndent(r.RunHealthchecks(ctx), "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot build healthcheck results."})
+		return
+	}
+	files = append(files, diagnosticsFile{"healthchecks.json", healthchecks})
+
+	// Build the tarball
+	var bundle bytes.Buffer
+	prefix := fmt.Sprintf("akvorado-diagnostics-%s", time.Now().UTC().Format("20060102T150405Z"))
+	if err := writeDiagnosticsBundle(&bundle, prefix, files); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot build diagnostics bundle."})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tar.gz"`, prefix))
+	c.Data(http.StatusOK, "application/gzip", bundle.Bytes())
+}
+
+// diagnosticsFile is a file included in a diagnostics bundle.
+type diagnosticsFile struct {
+	name    string
+	content []byte
+}
+
+// writeDiagnosticsBundle writes the provided files as a gzipped tarball. All
+// files are put in the directory named after the provided prefix.
+func writeDiagnosticsBundle(w io.Writer, prefix string, files []diagnosticsFile) error {
+	now := time.Now()
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    fmt.Sprintf("%s/%s", prefix, file.name),
+			Mode:    0o644,
+			Size:    int64(len(file.content)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
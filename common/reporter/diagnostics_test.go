@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reporter_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiagnosticsSnapshot(t *testing.T) {
+	r := reporter.NewMock(t)
+	r.RegisterDiagnostics("queue", func() any {
+		return map[string]int{"used": 10, "size": 100}
+	})
+	got := r.DiagnosticsSnapshot()
+	if got.Goroutines == 0 {
+		t.Error("DiagnosticsSnapshot(): no goroutine")
+	}
+	if got.Memory.HeapAlloc == 0 {
+		t.Error("DiagnosticsSnapshot(): no heap allocation")
+	}
+	if diff := helpers.Diff(got.Components, map[string]any{
+		"queue": map[string]int{"used": 10, "size": 100},
+	}); diff != "" {
+		t.Errorf("DiagnosticsSnapshot() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestDiagnosticsHTTPHandler(t *testing.T) {
+	r := reporter.NewMock(t)
+	r.RegisterDiagnostics("queue", func() any {
+		return map[string]int{"used": 10, "size": 100}
+	})
+	ginRouter := gin.New()
+	ginRouter.GET("/api/v0/diagnostics", r.DiagnosticsHTTPHandler)
+
+	t.Run("invalid duration", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v0/diagnostics?cpu=1h", nil)
+		w := httptest.NewRecorder()
+		ginRouter.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/v0/diagnostics status code, got %d, expected %d",
+				w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("bundle", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v0/diagnostics?cpu=100ms", nil)
+		w := httptest.NewRecorder()
+		ginRouter.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/v0/diagnostics status code, got %d, expected %d",
+				w.Code, http.StatusOK)
+		}
+
+		gzr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader() error:\n%+v", err)
+		}
+		tr := tar.NewReader(gzr)
+		files := []string{}
+		var snapshot struct {
+			Components map[string]any `json:"components"`
+		}
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Next() error:\n%+v", err)
+			}
+			name := path.Base(header.Name)
+			files = append(files, name)
+			if header.Size == 0 {
+				t.Errorf("%s is empty", name)
+			}
+			if name == "runtime.json" {
+				if err := json.NewDecoder(tr).Decode(&snapshot); err != nil {
+					t.Fatalf("Decode() error:\n%+v", err)
+				}
+			}
+		}
+		if diff := helpers.Diff(files, []string{
+			"cpu.pprof",
+			"heap.pprof",
+			"goroutine.pprof",
+			"goroutines.txt",
+			"runtime.json",
+			"healthchecks.json",
+		}); diff != "" {
+			t.Errorf("GET /api/v0/diagnostics files (-got, +want):\n%s", diff)
+		}
+		if diff := helpers.Diff(snapshot.Components, map[string]any{
+			"queue": map[string]any{"used": 10.0, "size": 100.0},
+		}); diff != "" {
+			t.Errorf("GET /api/v0/diagnostics components (-got, +want):\n%s", diff)
+		}
+	})
+}
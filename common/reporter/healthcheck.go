@@ -21,10 +21,17 @@ type HealthcheckResult struct {
 	Reason string            `json:"reason"`
 }
 
+// HealthcheckDetails is the result of an healthcheck with the time of the
+// last status change.
+type HealthcheckDetails struct {
+	HealthcheckResult
+	LastChange time.Time `json:"last-change"`
+}
+
 // MultipleHealthcheckResults aggregates the result of several healthchecks
 type MultipleHealthcheckResults struct {
-	Status  HealthcheckStatus            `json:"status"`
-	Details map[string]HealthcheckResult `json:"details,omitempty"`
+	Status  HealthcheckStatus             `json:"status"`
+	Details map[string]HealthcheckDetails `json:"details,omitempty"`
 }
 
 const (
@@ -32,6 +39,9 @@ const (
 	HealthcheckOK HealthcheckStatus = iota
 	// HealthcheckWarning says there is a non-fatal condition
 	HealthcheckWarning
+	// HealthcheckStarting says the component is alive but not ready yet
+	// (warming caches, waiting for migrations)
+	HealthcheckStarting
 	// HealthcheckError says there is a big problem with the component
 	HealthcheckError
 )
@@ -42,6 +52,8 @@ func (hs HealthcheckStatus) String() string {
 		return "ok"
 	case HealthcheckWarning:
 		return "warning"
+	case HealthcheckStarting:
+		return "starting"
 	case HealthcheckError:
 		return "error"
 	default:
@@ -62,17 +74,18 @@ type HealthcheckFunc func(context.Context) HealthcheckResult
 func (r *Reporter) RegisterHealthcheck(name string, hf HealthcheckFunc) {
 	r.healthchecksLock.Lock()
 	r.healthchecks[name] = hf
+	delete(r.healthchecksChanges, name)
 	r.healthchecksLock.Unlock()
 }
 
 // RunHealthchecks execute all healthchecks in parallel and returns a
 // global status as well as a map from service names to returned
-// results.
+// results. Each result also tells when its status last changed.
 func (r *Reporter) RunHealthchecks(ctx context.Context) MultipleHealthcheckResults {
 	var wg sync.WaitGroup
 	results := MultipleHealthcheckResults{
 		Status:  HealthcheckOK,
-		Details: map[string]HealthcheckResult{},
+		Details: map[string]HealthcheckDetails{},
 	}
 
 	r.healthchecksLock.Lock()
@@ -94,7 +107,7 @@ func (r *Reporter) RunHealthchecks(ctx context.Context) MultipleHealthcheckResul
 			case <-ctx.Done():
 				return
 			case result := <-resultChan:
-				results.Details[result.name] = result.result
+				results.Details[result.name] = HealthcheckDetails{HealthcheckResult: result.result}
 				runningHealthchecks--
 				if runningHealthchecks == 0 {
 					return
@@ -121,30 +134,65 @@ func (r *Reporter) RunHealthchecks(ctx context.Context) MultipleHealthcheckResul
 	wg.Wait() // keep lock, we don't want something to change
 
 	// Check what we have
+	now := time.Now()
 	for name := range r.healthchecks {
-		if result, ok := results.Details[name]; ok {
-			if result.Status > results.Status {
-				results.Status = result.Status
-			}
-		} else {
-			results.Details[name] = HealthcheckResult{HealthcheckError, "timeout during check"}
-			results.Status = HealthcheckError
+		result, ok := results.Details[name]
+		if !ok {
+			result.HealthcheckResult = HealthcheckResult{HealthcheckError, "timeout during check"}
+		}
+		if result.Status > results.Status {
+			results.Status = result.Status
 		}
+		change, ok := r.healthchecksChanges[name]
+		if !ok || change.status != result.Status {
+			change = healthcheckChange{status: result.Status, when: now}
+			r.healthchecksChanges[name] = change
+		}
+		result.LastChange = change.when
+		results.Details[name] = result
 	}
 
 	return results
 }
 
-// HealthcheckHTTPHandler is an HTTP handler return healthcheck results as JSON.
-func (r *Reporter) HealthcheckHTTPHandler(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-	results := r.RunHealthchecks(ctx)
-	httpStatus := http.StatusOK
-	if results.Status == HealthcheckError {
-		httpStatus = http.StatusServiceUnavailable
+// healthcheckChange records when an healthcheck changed status.
+type healthcheckChange struct {
+	status HealthcheckStatus
+	when   time.Time
+}
+
+// healthcheckHTTPHandler returns an HTTP handler returning healthcheck
+// results as JSON. The HTTP status is 503 when the global status is at least
+// the provided one.
+func (r *Reporter) healthcheckHTTPHandler(failed HealthcheckStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		results := r.RunHealthchecks(ctx)
+		httpStatus := http.StatusOK
+		if results.Status >= failed {
+			httpStatus = http.StatusServiceUnavailable
+		}
+		c.JSON(httpStatus, results)
 	}
-	c.JSON(httpStatus, results)
+}
+
+// LivenessHTTPHandler is an HTTP handler returning healthcheck results as
+// JSON. It fails only when a component is broken.
+func (r *Reporter) LivenessHTTPHandler(c *gin.Context) {
+	r.healthcheckHTTPHandler(HealthcheckError)(c)
+}
+
+// ReadinessHTTPHandler is an HTTP handler returning healthcheck results as
+// JSON. It also fails when a component is still starting.
+func (r *Reporter) ReadinessHTTPHandler(c *gin.Context) {
+	r.healthcheckHTTPHandler(HealthcheckStarting)(c)
+}
+
+// HealthcheckHTTPHandler is an HTTP handler return healthcheck results as
+// JSON. This is the same as LivenessHTTPHandler.
+func (r *Reporter) HealthcheckHTTPHandler(c *gin.Context) {
+	r.LivenessHTTPHandler(c)
 }
 
 // ChannelHealthcheckFunc is the function sent over a channel to signal liveness
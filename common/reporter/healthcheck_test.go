@@ -18,9 +18,26 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func testHealthchecks(ctx context.Context, t *testing.T, r *reporter.Reporter, expected reporter.MultipleHealthcheckResults) {
+// expectedHealthchecks is like MultipleHealthcheckResults without the time
+// of the last change.
+type expectedHealthchecks struct {
+	Status  reporter.HealthcheckStatus
+	Details map[string]reporter.HealthcheckResult
+}
+
+func testHealthchecks(ctx context.Context, t *testing.T, r *reporter.Reporter, expected expectedHealthchecks) {
 	t.Helper()
-	got := r.RunHealthchecks(ctx)
+	results := r.RunHealthchecks(ctx)
+	got := expectedHealthchecks{
+		Status:  results.Status,
+		Details: map[string]reporter.HealthcheckResult{},
+	}
+	for name, details := range results.Details {
+		if details.LastChange.IsZero() {
+			t.Errorf("RunHealthchecks(): no last change for %q", name)
+		}
+		got.Details[name] = details.HealthcheckResult
+	}
 	if diff := helpers.Diff(got, expected); diff != "" {
 		t.Errorf("RunHealthchecks() (-got, +want):\n%s", diff)
 	}
@@ -29,7 +46,7 @@ func testHealthchecks(ctx context.Context, t *testing.T, r *reporter.Reporter, e
 func TestEmptyHealthcheck(t *testing.T) {
 	r := reporter.NewMock(t)
 	testHealthchecks(context.Background(), t, r,
-		reporter.MultipleHealthcheckResults{
+		expectedHealthchecks{
 			Status:  reporter.HealthcheckOK,
 			Details: map[string]reporter.HealthcheckResult{},
 		})
@@ -41,7 +58,7 @@ func TestOneHealthcheck(t *testing.T) {
 		return reporter.HealthcheckResult{reporter.HealthcheckOK, "all well"}
 	})
 	testHealthchecks(context.Background(), t, r,
-		reporter.MultipleHealthcheckResults{
+		expectedHealthchecks{
 			Status: reporter.HealthcheckOK,
 			Details: map[string]reporter.HealthcheckResult{
 				"hc1": {reporter.HealthcheckOK, "all well"},
@@ -58,7 +75,7 @@ func TestFailingHealthcheck(t *testing.T) {
 		return reporter.HealthcheckResult{reporter.HealthcheckError, "not so good"}
 	})
 	testHealthchecks(context.Background(), t, r,
-		reporter.MultipleHealthcheckResults{
+		expectedHealthchecks{
 			Status: reporter.HealthcheckError,
 			Details: map[string]reporter.HealthcheckResult{
 				"hc1": {reporter.HealthcheckOK, "all well"},
@@ -82,7 +99,7 @@ func TestHealthcheckCancelContext(t *testing.T) {
 		cancel()
 	}()
 	testHealthchecks(ctx, t, r,
-		reporter.MultipleHealthcheckResults{
+		expectedHealthchecks{
 			Status: reporter.HealthcheckError,
 			Details: map[string]reporter.HealthcheckResult{
 				"hc1": {reporter.HealthcheckOK, "all well"},
@@ -104,7 +121,7 @@ func TestChannelHealthcheck(t *testing.T) {
 	r := reporter.NewMock(t)
 	r.RegisterHealthcheck("hc1", reporter.ChannelHealthcheck(context.Background(), contact))
 	testHealthchecks(context.Background(), t, r,
-		reporter.MultipleHealthcheckResults{
+		expectedHealthchecks{
 			Status: reporter.HealthcheckOK,
 			Details: map[string]reporter.HealthcheckResult{
 				"hc1": {reporter.HealthcheckOK, "all well, thank you!"},
@@ -137,6 +154,13 @@ func TestHealthcheckHTTPHandler(t *testing.T) {
 	if err := decoder.Decode(&got); err != nil {
 		t.Fatalf("GET /api/v0/healthcheck error:\n%+v", err)
 	}
+	for name, details := range got["details"].(map[string]any) {
+		details := details.(map[string]any)
+		if _, ok := details["last-change"].(string); !ok {
+			t.Errorf("GET /api/v0/healthcheck: no last change for %q", name)
+		}
+		delete(details, "last-change")
+	}
 	expected := gin.H{
 		"status": "error",
 		"details": map[string]any{
@@ -154,3 +178,59 @@ func TestHealthcheckHTTPHandler(t *testing.T) {
 		t.Fatalf("GET /api/v0/healthcheck (-got, +want):\n%s", diff)
 	}
 }
+
+func TestLivenessReadinessHTTPHandlers(t *testing.T) {
+	r := reporter.NewMock(t)
+	status := reporter.HealthcheckStarting
+	r.RegisterHealthcheck("hc1", func(context.Context) reporter.HealthcheckResult {
+		return reporter.HealthcheckResult{reporter.HealthcheckOK, "all well"}
+	})
+	r.RegisterHealthcheck("hc2", func(context.Context) reporter.HealthcheckResult {
+		return reporter.HealthcheckResult{status, "warming caches"}
+	})
+	ginRouter := gin.New()
+	ginRouter.GET("/api/v0/healthcheck/live", r.LivenessHTTPHandler)
+	ginRouter.GET("/api/v0/healthcheck/ready", r.ReadinessHTTPHandler)
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Status   reporter.HealthcheckStatus
+		URL      string
+		Expected int
+	}{
+		{helpers.Mark(), reporter.HealthcheckStarting, "/api/v0/healthcheck/live", http.StatusOK},
+		{helpers.Mark(), reporter.HealthcheckStarting, "/api/v0/healthcheck/ready", http.StatusServiceUnavailable},
+		{helpers.Mark(), reporter.HealthcheckWarning, "/api/v0/healthcheck/live", http.StatusOK},
+		{helpers.Mark(), reporter.HealthcheckWarning, "/api/v0/healthcheck/ready", http.StatusOK},
+		{helpers.Mark(), reporter.HealthcheckError, "/api/v0/healthcheck/live", http.StatusServiceUnavailable},
+		{helpers.Mark(), reporter.HealthcheckError, "/api/v0/healthcheck/ready", http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		status = tc.Status
+		req := httptest.NewRequest("GET", tc.URL, nil)
+		w := httptest.NewRecorder()
+		ginRouter.ServeHTTP(w, req)
+		if w.Code != tc.Expected {
+			t.Errorf("%sGET %s with %s status code, got %d, expected %d",
+				tc.Pos, tc.URL, tc.Status, w.Code, tc.Expected)
+		}
+	}
+}
+
+func TestHealthcheckLastChange(t *testing.T) {
+	r := reporter.NewMock(t)
+	status := reporter.HealthcheckStarting
+	r.RegisterHealthcheck("hc1", func(context.Context) reporter.HealthcheckResult {
+		return reporter.HealthcheckResult{status, "something"}
+	})
+
+	first := r.RunHealthchecks(context.Background()).Details["hc1"].LastChange
+	time.Sleep(10 * time.Millisecond)
+	if got := r.RunHealthchecks(context.Background()).Details["hc1"].LastChange; !got.Equal(first) {
+		t.Errorf("RunHealthchecks() last change moved without status change: %s != %s", got, first)
+	}
+	status = reporter.HealthcheckOK
+	if got := r.RunHealthchecks(context.Background()).Details["hc1"].LastChange; !got.After(first) {
+		t.Errorf("RunHealthchecks() last change did not move after status change: %s <= %s", got, first)
+	}
+}
@@ -4,9 +4,16 @@
 package reporter
 
 import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter/logger"
 )
 
 // Logger is an alias for zerolog.Logger
@@ -19,3 +26,85 @@ func BurstSampler(period time.Duration, burst uint32) zerolog.Sampler {
 		Burst:  burst,
 	}
 }
+
+// everySampler samples one event every N occurrences. It also acts as a hook
+// to add the number of occurrences to the logged events.
+type everySampler struct {
+	every   uint64
+	count   atomic.Uint64
+	sampled atomic.Uint64
+}
+
+// Sample implements zerolog.Sampler.
+func (s *everySampler) Sample(zerolog.Level) bool {
+	count := s.count.Add(1)
+	if (count-1)%s.every != 0 {
+		return false
+	}
+	s.sampled.Store(count)
+	return true
+}
+
+// Run implements zerolog.Hook.
+func (s *everySampler) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Uint64("occurrences", s.sampled.Load())
+}
+
+// SampleEvery returns a logger logging only the first event out of every n
+// occurrences. Logged events include the total number of occurrences. This is
+// intended for per-flow error paths: use a distinct logger for each of them to
+// get meaningful counts.
+func (r *Reporter) SampleEvery(n uint64) Logger {
+	sampler := &everySampler{every: max(n, 1)}
+	return r.Sample(sampler).Hook(sampler)
+}
+
+type logLevelsResponse struct {
+	Default zerolog.Level            `json:"default"`
+	Modules map[string]zerolog.Level `json:"modules"`
+}
+
+// LogLevelsHTTPHandler is an HTTP handler returning the current log levels as
+// JSON.
+func (r *Reporter) LogLevelsHTTPHandler(gc *gin.Context) {
+	base, overrides := logger.Levels()
+	gc.JSON(http.StatusOK, logLevelsResponse{
+		Default: base,
+		Modules: overrides,
+	})
+}
+
+// SetLogLevelHTTPHandler is an HTTP handler to change the log level of a
+// module. An empty module changes the default level. An empty level removes
+// the override for the module.
+func (r *Reporter) SetLogLevelHTTPHandler(gc *gin.Context) {
+	var query struct {
+		Module string `json:"module"`
+		Level  string `json:"level"`
+	}
+	if err := gc.ShouldBindJSON(&query); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if query.Level == "" {
+		if query.Module == "" {
+			gc.JSON(http.StatusBadRequest, gin.H{"message": "Cannot remove the default level."})
+			return
+		}
+		logger.ResetLevel(query.Module)
+		r.Info().Str("target", query.Module).Msg("log level override removed")
+	} else {
+		level, err := zerolog.ParseLevel(query.Level)
+		if err != nil || level == zerolog.NoLevel {
+			gc.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid log level %q.", query.Level)})
+			return
+		}
+		if query.Module == "" {
+			logger.SetDefaultLevel(level)
+		} else {
+			logger.SetLevel(query.Module, level)
+		}
+		r.Info().Str("target", query.Module).Str("level", level.String()).Msg("log level changed")
+	}
+	r.LogLevelsHTTPHandler(gc)
+}
@@ -3,10 +3,18 @@
 
 package logger
 
-// Configuration if the configuration for logger. Currently, there is no configuration.
-type Configuration struct{}
+import "github.com/rs/zerolog"
+
+// Configuration is the configuration for logger.
+type Configuration struct {
+	// Levels overrides the log level for some modules (for example,
+	// outlet/clickhouse). Submodules inherit the level of their parent.
+	Levels map[string]zerolog.Level
+}
 
 // DefaultConfiguration is the default logging configuration.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		Levels: map[string]zerolog.Level{},
+	}
 }
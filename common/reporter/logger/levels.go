@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package logger
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"akvorado/common/reporter/stack"
+)
+
+// levelSettings contains the default log level and the overrides for some
+// modules. It is never modified once published.
+type levelSettings struct {
+	base      zerolog.Level
+	overrides map[string]zerolog.Level
+}
+
+var (
+	levels     atomic.Pointer[levelSettings]
+	levelsLock sync.Mutex
+)
+
+func init() {
+	levels.Store(&levelSettings{base: zerolog.GlobalLevel()})
+}
+
+// normalizeModule turns a module name into the form used for overrides, without
+// the name of the Go module.
+func normalizeModule(module string) string {
+	module = strings.TrimPrefix(module, fmt.Sprintf("%s/", stack.ModuleName))
+	return strings.Trim(module, "/")
+}
+
+// updateLevels applies the provided function to a copy of the current level
+// settings and publishes the result. The global level of zerolog is set to the
+// most verbose level in use, the remaining filtering being done by the hook.
+func updateLevels(update func(*levelSettings)) {
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	current := levels.Load()
+	updated := levelSettings{
+		base:      current.base,
+		overrides: maps.Clone(current.overrides),
+	}
+	if updated.overrides == nil {
+		updated.overrides = map[string]zerolog.Level{}
+	}
+	update(&updated)
+	global := updated.base
+	for _, level := range updated.overrides {
+		global = min(global, level)
+	}
+	zerolog.SetGlobalLevel(global)
+	levels.Store(&updated)
+}
+
+// SetDefaultLevel sets the log level for modules without an override.
+func SetDefaultLevel(level zerolog.Level) {
+	updateLevels(func(ls *levelSettings) {
+		ls.base = level
+	})
+}
+
+// SetLevel sets the log level for the provided module (for example,
+// outlet/clickhouse). Submodules inherit this level unless they have their own
+// override.
+func SetLevel(module string, level zerolog.Level) {
+	updateLevels(func(ls *levelSettings) {
+		ls.overrides[normalizeModule(module)] = level
+	})
+}
+
+// ResetLevel removes the override for the provided module.
+func ResetLevel(module string) {
+	updateLevels(func(ls *levelSettings) {
+		delete(ls.overrides, normalizeModule(module))
+	})
+}
+
+// SetLevels replaces all the overrides with the provided ones.
+func SetLevels(overrides map[string]zerolog.Level) {
+	updateLevels(func(ls *levelSettings) {
+		clear(ls.overrides)
+		for module, level := range overrides {
+			ls.overrides[normalizeModule(module)] = level
+		}
+	})
+}
+
+// Levels returns the default log level and the overrides for each module.
+func Levels() (zerolog.Level, map[string]zerolog.Level) {
+	current := levels.Load()
+	return current.base, maps.Clone(current.overrides)
+}
+
+// levelFor returns the log level for the provided module and whether the
+// events should be filtered by the hook.
+func levelFor(module string) (zerolog.Level, bool) {
+	current := levels.Load()
+	if len(current.overrides) == 0 {
+		return current.base, false
+	}
+	module = normalizeModule(module)
+	level := current.base
+	matched := -1
+	for candidate, candidateLevel := range current.overrides {
+		if len(candidate) <= matched {
+			continue
+		}
+		if module == candidate || strings.HasPrefix(module, candidate+"/") {
+			level = candidateLevel
+			matched = len(candidate)
+		}
+	}
+	return level, true
+}
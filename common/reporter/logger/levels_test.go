@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"akvorado/common/helpers"
+)
+
+func resetLevels(t *testing.T) {
+	base, overrides := Levels()
+	t.Cleanup(func() {
+		SetLevels(overrides)
+		SetDefaultLevel(base)
+	})
+}
+
+func TestLevelFor(t *testing.T) {
+	resetLevels(t)
+	SetDefaultLevel(zerolog.InfoLevel)
+	SetLevel("outlet", zerolog.WarnLevel)
+	SetLevel("akvorado/outlet/clickhouse", zerolog.DebugLevel)
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Module   string
+		Expected zerolog.Level
+	}{
+		{helpers.Mark(), "akvorado/outlet/clickhouse", zerolog.DebugLevel},
+		{helpers.Mark(), "akvorado/outlet/kafka", zerolog.WarnLevel},
+		{helpers.Mark(), "akvorado/outlet", zerolog.WarnLevel},
+		{helpers.Mark(), "akvorado/outletx", zerolog.InfoLevel},
+		{helpers.Mark(), "akvorado/inlet/kafka", zerolog.InfoLevel},
+		{helpers.Mark(), "", zerolog.InfoLevel},
+	}
+	for _, tc := range cases {
+		got, _ := levelFor(tc.Module)
+		if got != tc.Expected {
+			t.Errorf("%slevelFor(%q) == %s, expected %s", tc.Pos, tc.Module, got, tc.Expected)
+		}
+	}
+	if got := zerolog.GlobalLevel(); got != zerolog.DebugLevel {
+		t.Errorf("GlobalLevel() == %s, expected %s", got, zerolog.DebugLevel)
+	}
+
+	ResetLevel("outlet/clickhouse")
+	if got, _ := levelFor("akvorado/outlet/clickhouse"); got != zerolog.WarnLevel {
+		t.Errorf("levelFor() == %s after reset, expected %s", got, zerolog.WarnLevel)
+	}
+	if got := zerolog.GlobalLevel(); got != zerolog.InfoLevel {
+		t.Errorf("GlobalLevel() == %s after reset, expected %s", got, zerolog.InfoLevel)
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	resetLevels(t)
+	var buf bytes.Buffer
+	l := Logger{zerolog.New(&buf).Hook(contextHook{})}
+	SetDefaultLevel(zerolog.InfoLevel)
+
+	// Override for another module: debug messages are dropped.
+	SetLevel("outlet", zerolog.DebugLevel)
+	l.Debug().Msg("debug 1")
+	l.Info().Msg("info 1")
+
+	// Override for this module
+	SetLevel("common/reporter", zerolog.DebugLevel)
+	l.Debug().Msg("debug 2")
+	SetLevel("common/reporter/logger", zerolog.WarnLevel)
+	l.Info().Msg("info 2")
+	l.Warn().Msg("warn 2")
+
+	got := []string{}
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		got = append(got, strings.SplitN(line, `"message":`, 2)[1])
+	}
+	expected := []string{`"info 1"}`, `"debug 2"}`, `"warn 2"}`}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("Logs (-got, +want):\n%s", diff)
+	}
+}
@@ -3,8 +3,8 @@
 
 // Package logger handles logging for akvorado.
 //
-// This is a thin wrapper around zerolog. It is currently not
-// configurable as we don't need anything fancy yet for configuration.
+// This is a thin wrapper around zerolog. The only configuration is the
+// log level for each module, which can also be changed at runtime.
 //
 // It also brings some conventions like the presence of "module" in
 // each context to be able to filter logs more easily. However, this
@@ -28,7 +28,9 @@ type Logger struct {
 }
 
 // New creates a new logger
-func New(_ Configuration) (Logger, error) {
+func New(config Configuration) (Logger, error) {
+	SetLevels(config.Levels)
+
 	// Initialize the logger
 	logger := log.Logger.Hook(contextHook{})
 	return Logger{logger}, nil
@@ -37,7 +39,7 @@ func New(_ Configuration) (Logger, error) {
 type contextHook struct{}
 
 // Run adds more context to an event, including "module" and "caller".
-func (h contextHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+func (h contextHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
 	callStack := stack.Callers()
 	callStack = callStack[3:] // Trial and error, there is a test to check it works.
 
@@ -54,8 +56,19 @@ func (h contextHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
 		}
 		break
 	}
+	module := ""
+	if candidateInfo != nil {
+		module = strings.SplitN(candidateInfo.FunctionName(), ".", 2)[0]
+	}
+
+	// Filter events below the level of the module.
+	if minLevel, ok := levelFor(module); ok && level < minLevel {
+		e.Discard()
+		return
+	}
+
 	if candidateInfo != nil {
 		e.Str("caller", candidateInfo.SourceFile())
-		e.Str("module", strings.SplitN(candidateInfo.FunctionName(), ".", 2)[0])
+		e.Str("module", module)
 	}
 }
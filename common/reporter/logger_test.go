@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reporter_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/reporter/logger"
+)
+
+func TestLogLevelsHTTPHandler(t *testing.T) {
+	r := reporter.NewMock(t)
+	base, _ := logger.Levels()
+	t.Cleanup(func() {
+		logger.ResetLevel("outlet/clickhouse")
+		logger.SetDefaultLevel(base)
+	})
+	ginRouter := gin.Default()
+	ginRouter.GET("/api/v0/log-levels", r.LogLevelsHTTPHandler)
+	ginRouter.POST("/api/v0/log-levels", r.SetLogLevelHTTPHandler)
+
+	cases := []struct {
+		Pos        helpers.Pos
+		Method     string
+		Body       string
+		StatusCode int
+		Expected   gin.H
+	}{
+		{
+			Pos:        helpers.Mark(),
+			Method:     "POST",
+			Body:       `{"level": "info"}`,
+			StatusCode: 200,
+			Expected:   gin.H{"default": "info", "modules": map[string]any{}},
+		}, {
+			Pos:        helpers.Mark(),
+			Method:     "POST",
+			Body:       `{"module": "outlet/clickhouse", "level": "debug"}`,
+			StatusCode: 200,
+			Expected:   gin.H{"default": "info", "modules": map[string]any{"outlet/clickhouse": "debug"}},
+		}, {
+			Pos:        helpers.Mark(),
+			Method:     "GET",
+			StatusCode: 200,
+			Expected:   gin.H{"default": "info", "modules": map[string]any{"outlet/clickhouse": "debug"}},
+		}, {
+			Pos:        helpers.Mark(),
+			Method:     "POST",
+			Body:       `{"module": "outlet/clickhouse", "level": "nope"}`,
+			StatusCode: 400,
+			Expected:   gin.H{"message": `Invalid log level "nope".`},
+		}, {
+			Pos:        helpers.Mark(),
+			Method:     "POST",
+			Body:       `{"level": ""}`,
+			StatusCode: 400,
+			Expected:   gin.H{"message": "Cannot remove the default level."},
+		}, {
+			Pos:        helpers.Mark(),
+			Method:     "POST",
+			Body:       `{"module": "outlet/clickhouse"}`,
+			StatusCode: 200,
+			Expected:   gin.H{"default": "info", "modules": map[string]any{}},
+		},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.Method, "/api/v0/log-levels", strings.NewReader(tc.Body))
+		w := httptest.NewRecorder()
+		ginRouter.ServeHTTP(w, req)
+		if w.Code != tc.StatusCode {
+			t.Errorf("%s%s /api/v0/log-levels status code %d, expected %d", tc.Pos, tc.Method, w.Code, tc.StatusCode)
+		}
+		var got gin.H
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("%s%s /api/v0/log-levels error:\n%+v", tc.Pos, tc.Method, err)
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%s%s /api/v0/log-levels (-got, +want):\n%s", tc.Pos, tc.Method, diff)
+		}
+	}
+	if got := zerolog.GlobalLevel(); got != zerolog.InfoLevel {
+		t.Errorf("GlobalLevel() == %s, expected %s", got, zerolog.InfoLevel)
+	}
+}
+
+func TestSampleEvery(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+	r := reporter.NewMock(t)
+
+	l := r.SampleEvery(3)
+	for i := range 7 {
+		l.Warn().Int("i", i+1).Msg("sampled")
+	}
+
+	got := []gin.H{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var event gin.H
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+		got = append(got, gin.H{"i": event["i"], "occurrences": event["occurrences"]})
+	}
+	expected := []gin.H{
+		{"i": 1.0, "occurrences": 1.0},
+		{"i": 4.0, "occurrences": 4.0},
+		{"i": 7.0, "occurrences": 7.0},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("SampleEvery() logs (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metrics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"akvorado/common/reporter/logger"
+)
+
+// cardinalityOther is the value replacing the values of a label once its
+// cardinality limit is reached.
+const cardinalityOther = "other"
+
+// cardinalityOverflowName is the name of the metric reporting the number of
+// series folded into the "other" value.
+const cardinalityOverflowName = "akvorado_common_reporter_metrics_cardinality_overflow_series"
+
+// cardinalityKey identifies a label of a metric.
+type cardinalityKey struct {
+	metric string
+	label  string
+}
+
+// cardinalityGatherer wraps a gatherer to cap the number of values of some
+// labels. The first values of a label of a metric are kept as is. Once the
+// limit is reached, series with a new value are folded into a series with the
+// value "other".
+type cardinalityGatherer struct {
+	gatherer prometheus.Gatherer
+	logger   logger.Logger
+	config   CardinalityConfiguration
+
+	lock     sync.Mutex
+	seen     map[cardinalityKey]map[string]struct{}
+	overflow map[cardinalityKey]int
+}
+
+// newCardinalityGatherer creates a new gatherer capping the cardinality of the
+// provided gatherer.
+func newCardinalityGatherer(l logger.Logger, config CardinalityConfiguration, gatherer prometheus.Gatherer) *cardinalityGatherer {
+	return &cardinalityGatherer{
+		gatherer: gatherer,
+		logger:   l,
+		config:   config,
+		seen:     map[cardinalityKey]map[string]struct{}{},
+		overflow: map[cardinalityKey]int{},
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *cardinalityGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	if g.config.Limit == 0 || len(g.config.Labels) == 0 {
+		return families, err
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for key := range g.overflow {
+		g.overflow[key] = 0
+	}
+	for _, family := range families {
+		g.capFamily(family)
+	}
+	if len(g.overflow) == 0 {
+		return families, err
+	}
+
+	// Report the number of folded series for each capped label.
+	keys := make([]cardinalityKey, 0, len(g.overflow))
+	for key := range g.overflow {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b cardinalityKey) int {
+		if c := strings.Compare(a.metric, b.metric); c != 0 {
+			return c
+		}
+		return strings.Compare(a.label, b.label)
+	})
+	family := &dto.MetricFamily{
+		Name: proto.String(cardinalityOverflowName),
+		Help: proto.String(`Number of series folded into the "other" value because of the cardinality limit.`),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	for _, key := range keys {
+		family.Metric = append(family.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{
+				{Name: proto.String("label"), Value: proto.String(key.label)},
+				{Name: proto.String("metric"), Value: proto.String(key.metric)},
+			},
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(g.overflow[key]))},
+		})
+	}
+	families = append(families, family)
+	slices.SortFunc(families, func(a, b *dto.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return families, err
+}
+
+// capFamily folds the series of a metric family whose label values are over
+// the limit.
+func (g *cardinalityGatherer) capFamily(family *dto.MetricFamily) {
+	var (
+		folded  bool
+		metrics = make([]*dto.Metric, 0, len(family.Metric))
+		index   = map[string]*dto.Metric{}
+	)
+	for _, metric := range family.Metric {
+		for _, pair := range metric.Label {
+			if !slices.Contains(g.config.Labels, pair.GetName()) {
+				continue
+			}
+			key := cardinalityKey{metric: family.GetName(), label: pair.GetName()}
+			values, ok := g.seen[key]
+			if !ok {
+				values = map[string]struct{}{}
+				g.seen[key] = values
+			}
+			if _, ok := values[pair.GetValue()]; ok {
+				continue
+			}
+			if len(values) < g.config.Limit {
+				values[pair.GetValue()] = struct{}{}
+				continue
+			}
+			if _, ok := g.overflow[key]; !ok {
+				g.logger.Warn().
+					Str("metric", key.metric).
+					Str("label", key.label).
+					Int("limit", g.config.Limit).
+					Msg("too many values for label, fold new ones into \"other\"")
+			}
+			g.overflow[key]++
+			pair.Value = proto.String(cardinalityOther)
+			folded = true
+		}
+		signature := labelsSignature(metric.Label)
+		if existing, ok := index[signature]; ok {
+			mergeMetric(family.GetType(), existing, metric)
+			continue
+		}
+		index[signature] = metric
+		metrics = append(metrics, metric)
+	}
+	if folded {
+		slices.SortFunc(metrics, func(a, b *dto.Metric) int {
+			return strings.Compare(labelsSignature(a.Label), labelsSignature(b.Label))
+		})
+		family.Metric = metrics
+	}
+}
+
+// labelsSignature returns a string identifying a set of label pairs.
+func labelsSignature(pairs []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, pair := range pairs {
+		b.WriteString(pair.GetName())
+		b.WriteByte(0)
+		b.WriteString(pair.GetValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// mergeMetric adds the values of a metric into another one. Quantiles of
+// summaries cannot be merged and are dropped.
+func mergeMetric(kind dto.MetricType, into, from *dto.Metric) {
+	switch kind {
+	case dto.MetricType_COUNTER:
+		into.Counter.Value = proto.Float64(into.Counter.GetValue() + from.Counter.GetValue())
+	case dto.MetricType_GAUGE:
+		into.Gauge.Value = proto.Float64(into.Gauge.GetValue() + from.Gauge.GetValue())
+	case dto.MetricType_UNTYPED:
+		into.Untyped.Value = proto.Float64(into.Untyped.GetValue() + from.Untyped.GetValue())
+	case dto.MetricType_HISTOGRAM:
+		into.Histogram.SampleCount = proto.Uint64(into.Histogram.GetSampleCount() + from.Histogram.GetSampleCount())
+		into.Histogram.SampleSum = proto.Float64(into.Histogram.GetSampleSum() + from.Histogram.GetSampleSum())
+		for idx, bucket := range into.Histogram.Bucket {
+			if idx < len(from.Histogram.Bucket) {
+				bucket.CumulativeCount = proto.Uint64(bucket.GetCumulativeCount() +
+					from.Histogram.Bucket[idx].GetCumulativeCount())
+			}
+		}
+	case dto.MetricType_SUMMARY:
+		into.Summary.SampleCount = proto.Uint64(into.Summary.GetSampleCount() + from.Summary.GetSampleCount())
+		into.Summary.SampleSum = proto.Float64(into.Summary.GetSampleSum() + from.Summary.GetSampleSum())
+		into.Summary.Quantile = nil
+	}
+}
@@ -3,11 +3,49 @@
 
 package metrics
 
-// Configuration is currently empty as this sub-component is not
-// configurable yet.
-type Configuration struct{}
+import "time"
+
+// Configuration is the configuration for the metrics sub-component.
+type Configuration struct {
+	// OTLP is the configuration to push metrics to an OpenTelemetry collector.
+	OTLP OTLPConfiguration
+	// Cardinality is the configuration to cap the number of values of some
+	// labels.
+	Cardinality CardinalityConfiguration
+}
+
+// CardinalityConfiguration is the configuration to cap the number of values
+// of labels derived from dynamic data.
+type CardinalityConfiguration struct {
+	// Labels is the list of labels to cap.
+	Labels []string
+	// Limit is the maximum number of values for each label of a metric. Once
+	// reached, new values are replaced by "other". 0 disables the limit.
+	Limit int `validate:"min=0"`
+}
+
+// OTLPConfiguration is the configuration to export metrics using OTLP over
+// HTTP. Metrics are still available with the Prometheus endpoint.
+type OTLPConfiguration struct {
+	// Endpoint is the URL to push metrics to (for example,
+	// http://otel-collector:4318/v1/metrics). When empty, metrics are not
+	// exported.
+	Endpoint string `validate:"omitempty,url"`
+	// Headers are additional HTTP headers to send with each export.
+	Headers map[string]string
+	// Interval is the interval between two exports.
+	Interval time.Duration `validate:"min=1s"`
+}
 
 // DefaultConfiguration is the default metrics configuration.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		OTLP: OTLPConfiguration{
+			Interval: time.Minute,
+		},
+		Cardinality: CardinalityConfiguration{
+			Labels: []string{"exporter", "agent"},
+			Limit:  5000,
+		},
+	}
 }
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"akvorado/common/helpers"
+)
+
+// Start starts exporting metrics with OTLP if configured.
+func (m *Metrics) Start() error {
+	if m.config.OTLP.Endpoint == "" {
+		return nil
+	}
+	exporter, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(m.config.OTLP.Endpoint),
+		otlpmetrichttp.WithHeaders(m.config.OTLP.Headers))
+	if err != nil {
+		return fmt.Errorf("cannot create OTLP metrics exporter: %w", err)
+	}
+	// All the metrics are registered in the Prometheus registry. The bridge
+	// converts them to OpenTelemetry on each export, after capping the
+	// cardinality of some labels.
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(m.config.OTLP.Interval),
+		sdkmetric.WithProducer(promBridge.NewMetricProducer(promBridge.WithGatherer(m.gatherer))))
+	m.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(otlpResource()))
+	m.logger.Info().Str("endpoint", m.config.OTLP.Endpoint).Msg("exporting metrics with OTLP")
+	return nil
+}
+
+// Stop flushes metrics to the OTLP endpoint and stops exporting them.
+func (m *Metrics) Stop() error {
+	if m.meterProvider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.OTLP.Interval)
+	defer cancel()
+	if err := m.meterProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("cannot stop OTLP metrics exporter: %w", err)
+	}
+	return nil
+}
+
+// otlpResource returns the OpenTelemetry resource describing the current
+// process.
+func otlpResource() *resource.Resource {
+	return resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("akvorado"),
+		semconv.ServiceVersion(helpers.AkvoradoVersion),
+	)
+}
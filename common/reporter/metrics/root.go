@@ -16,6 +16,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 
 	"akvorado/common/reporter/logger"
 	"akvorado/common/reporter/stack"
@@ -26,8 +27,10 @@ type Metrics struct {
 	logger           logger.Logger
 	config           Configuration
 	registry         *prometheus.Registry
+	gatherer         prometheus.Gatherer
 	factoryCache     map[string]*Factory
 	factoryCacheLock sync.RWMutex
+	meterProvider    *sdkmetric.MeterProvider
 }
 
 // New creates a new metric registry and setup the appropriate
@@ -42,6 +45,7 @@ func New(logger logger.Logger, configuration Configuration) (*Metrics, error) {
 		logger:       logger,
 		config:       configuration,
 		registry:     reg,
+		gatherer:     newCardinalityGatherer(logger, configuration.Cardinality, reg),
 		factoryCache: make(map[string]*Factory, 0),
 	}
 
@@ -50,7 +54,7 @@ func New(logger logger.Logger, configuration Configuration) (*Metrics, error) {
 
 // HTTPHandler returns an handler to server Prometheus metrics.
 func (m *Metrics) HTTPHandler() http.Handler {
-	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{
 		ErrorLog: promHTTPLogger{m.logger},
 	})
 }
@@ -4,12 +4,17 @@
 package metrics_test
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"runtime"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -119,3 +124,108 @@ func TestRegisterTwice(t *testing.T) {
 		t.Fatalf("counter1 != counter2")
 	}
 }
+
+func TestOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		body = got
+		if r.Header.Get("X-Token") != "secret" {
+			t.Errorf("OTLP request: X-Token header is %q", r.Header.Get("X-Token"))
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	l, err := logger.New(logger.DefaultConfiguration())
+	if err != nil {
+		t.Fatalf("logger.New() err:\n%+v", err)
+	}
+	config := metrics.DefaultConfiguration()
+	config.OTLP.Endpoint = fmt.Sprintf("%s/v1/metrics", server.URL)
+	config.OTLP.Headers = map[string]string{"X-Token": "secret"}
+	config.OTLP.Interval = time.Hour
+	m, err := metrics.New(l, config)
+	if err != nil {
+		t.Fatalf("metrics.New() err:\n%+v", err)
+	}
+	m.Factory(0).NewCounter(prometheus.CounterOpts{
+		Name: "otlp_counter1",
+		Help: "Some counter",
+	}).Add(18)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	// Stopping flushes the metrics
+	if err := m.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := helpers.Diff(paths, []string{"/v1/metrics"}); diff != "" {
+		t.Errorf("OTLP requests (-got, +want):\n%s", diff)
+	}
+	if !bytes.Contains(body, []byte("akvorado_common_reporter_metrics_test_otlp_counter1")) {
+		t.Error("OTLP request does not contain otlp_counter1")
+	}
+}
+
+func TestCardinality(t *testing.T) {
+	l, err := logger.New(logger.DefaultConfiguration())
+	if err != nil {
+		t.Fatalf("logger.New() err:\n%+v", err)
+	}
+	config := metrics.DefaultConfiguration()
+	config.Cardinality.Labels = []string{"exporter"}
+	config.Cardinality.Limit = 2
+	m, err := metrics.New(l, config)
+	if err != nil {
+		t.Fatalf("metrics.New() err:\n%+v", err)
+	}
+
+	counter := m.Factory(0).NewCounterVec(prometheus.CounterOpts{
+		Name: "cardinality_counter1",
+		Help: "Some counter",
+	}, []string{"exporter", "error"})
+	counter.WithLabelValues("192.0.2.1", "timeout").Add(1)
+	counter.WithLabelValues("192.0.2.2", "timeout").Add(2)
+	counter.WithLabelValues("192.0.2.3", "timeout").Add(3)
+	counter.WithLabelValues("192.0.2.4", "timeout").Add(4)
+	counter.WithLabelValues("192.0.2.1", "decoding").Add(5)
+	gauge := m.Factory(0).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cardinality_gauge1",
+		Help: "Some gauge",
+	}, []string{"listener"})
+	gauge.WithLabelValues("a").Set(1)
+	gauge.WithLabelValues("b").Set(2)
+	gauge.WithLabelValues("c").Set(3)
+
+	req := httptest.NewRequest("GET", "/api/v0/metrics", nil)
+	w := httptest.NewRecorder()
+	m.HTTPHandler().ServeHTTP(w, req)
+	gotFiltered := []string{}
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "akvorado_") {
+			gotFiltered = append(gotFiltered, line)
+		}
+	}
+	expected := []string{
+		`akvorado_common_reporter_metrics_cardinality_overflow_series{label="exporter",metric="akvorado_common_reporter_metrics_test_cardinality_counter1"} 2`,
+		`akvorado_common_reporter_metrics_test_cardinality_counter1{error="decoding",exporter="192.0.2.1"} 5`,
+		`akvorado_common_reporter_metrics_test_cardinality_counter1{error="timeout",exporter="192.0.2.1"} 1`,
+		`akvorado_common_reporter_metrics_test_cardinality_counter1{error="timeout",exporter="192.0.2.2"} 2`,
+		`akvorado_common_reporter_metrics_test_cardinality_counter1{error="timeout",exporter="other"} 7`,
+		`akvorado_common_reporter_metrics_test_cardinality_gauge1{listener="a"} 1`,
+		`akvorado_common_reporter_metrics_test_cardinality_gauge1{listener="b"} 2`,
+		`akvorado_common_reporter_metrics_test_cardinality_gauge1{listener="c"} 3`,
+	}
+	if diff := helpers.Diff(gotFiltered, expected); diff != "" {
+		t.Fatalf("GET /api/v0/metrics (-got, +want):\n%s", diff)
+	}
+}
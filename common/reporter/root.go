@@ -3,14 +3,16 @@
 
 // Package reporter is a façade for reporting duties in akvorado.
 //
-// Such a façade currently includes logging and metrics.
+// Such a façade currently includes logging, metrics and tracing.
 package reporter
 
 import (
+	"errors"
 	"sync"
 
 	"akvorado/common/reporter/logger"
 	"akvorado/common/reporter/metrics"
+	"akvorado/common/reporter/tracing"
 )
 
 // Reporter contains the state for a reporter. It also supports the
@@ -18,9 +20,13 @@ import (
 type Reporter struct {
 	logger.Logger
 	metrics *metrics.Metrics
+	tracing *tracing.Tracing
 
-	healthchecks     map[string]HealthcheckFunc
-	healthchecksLock sync.Mutex
+	healthchecks        map[string]HealthcheckFunc
+	healthchecksChanges map[string]healthcheckChange
+	healthchecksLock    sync.Mutex
+	diagnostics         map[string]DiagnosticsFunc
+	diagnosticsLock     sync.Mutex
 }
 
 // New creates a new reporter from a configuration.
@@ -36,9 +42,30 @@ func New(config Configuration) (*Reporter, error) {
 		return nil, err
 	}
 
+	t, err := tracing.New(l, config.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Reporter{
-		Logger:       l,
-		metrics:      m,
-		healthchecks: make(map[string]HealthcheckFunc),
+		Logger:              l,
+		metrics:             m,
+		tracing:             t,
+		healthchecks:        make(map[string]HealthcheckFunc),
+		healthchecksChanges: make(map[string]healthcheckChange),
+		diagnostics:         make(map[string]DiagnosticsFunc),
 	}, nil
 }
+
+// Start starts the export of metrics and traces, if configured.
+func (r *Reporter) Start() error {
+	if err := r.metrics.Start(); err != nil {
+		return err
+	}
+	return r.tracing.Start()
+}
+
+// Stop flushes and stops the export of metrics and traces.
+func (r *Reporter) Stop() error {
+	return errors.Join(r.tracing.Stop(), r.metrics.Stop())
+}
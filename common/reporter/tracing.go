@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Tracing façade for reporter.
+
+package reporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Span is a span for tracing.
+	Span = trace.Span
+	// SpanStartOption defines options when starting a span.
+	SpanStartOption = trace.SpanStartOption
+)
+
+// StartSpan starts a new span for the provided operation. The tracer is named
+// after the calling module. The returned span should be ended with End().
+func (r *Reporter) StartSpan(ctx context.Context, name string, opts ...SpanStartOption) (context.Context, Span) {
+	return r.tracing.Tracer(1).Start(ctx, name, opts...)
+}
+
+// SpanFromContext returns the current span from the provided context.
+var SpanFromContext = trace.SpanFromContext
+
+// SpanAttributes returns an option to add attributes to a span.
+var SpanAttributes = trace.WithAttributes
+
+// Some helpers to build attributes without importing OpenTelemetry.
+var (
+	// StringAttribute builds a string attribute.
+	StringAttribute = attribute.String
+	// IntAttribute builds an integer attribute.
+	IntAttribute = attribute.Int
+	// BoolAttribute builds a boolean attribute.
+	BoolAttribute = attribute.Bool
+)
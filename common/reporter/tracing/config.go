@@ -0,0 +1,22 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tracing
+
+// Configuration is the configuration for the tracing sub-component.
+type Configuration struct {
+	// Endpoint is the URL to push traces to using OTLP over HTTP (for example,
+	// http://otel-collector:4318/v1/traces). When empty, tracing is disabled.
+	Endpoint string `validate:"omitempty,url"`
+	// Headers are additional HTTP headers to send with each export.
+	Headers map[string]string
+	// SamplingRatio is the ratio of traces to keep.
+	SamplingRatio float64 `validate:"min=0,max=1"`
+}
+
+// DefaultConfiguration is the default tracing configuration.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		SamplingRatio: 1,
+	}
+}
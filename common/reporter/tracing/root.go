@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package tracing handles traces for akvorado.
+//
+// This is a wrapper around OpenTelemetry. When no endpoint is configured, spans
+// are not recorded.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter/logger"
+	"akvorado/common/reporter/stack"
+)
+
+// Tracing represents the internal state of the tracing subsystem.
+type Tracing struct {
+	logger      logger.Logger
+	config      Configuration
+	provider    trace.TracerProvider
+	sdkProvider *sdktrace.TracerProvider
+}
+
+// New creates a new tracing subsystem. Spans are only exported once started.
+func New(logger logger.Logger, configuration Configuration) (*Tracing, error) {
+	return &Tracing{
+		logger:   logger,
+		config:   configuration,
+		provider: noop.NewTracerProvider(),
+	}, nil
+}
+
+// Start starts exporting spans with OTLP if configured.
+func (t *Tracing) Start() error {
+	if t.config.Endpoint == "" {
+		return nil
+	}
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(t.config.Endpoint),
+		otlptracehttp.WithHeaders(t.config.Headers))
+	if err != nil {
+		return fmt.Errorf("cannot create OTLP traces exporter: %w", err)
+	}
+	t.sdkProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.config.SamplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName("akvorado"),
+			semconv.ServiceVersion(helpers.AkvoradoVersion),
+		)))
+	t.provider = t.sdkProvider
+	t.logger.Info().Str("endpoint", t.config.Endpoint).Msg("exporting traces with OTLP")
+	return nil
+}
+
+// Stop flushes remaining spans and stops exporting them.
+func (t *Tracing) Stop() error {
+	if t.sdkProvider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.sdkProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("cannot stop OTLP traces exporter: %w", err)
+	}
+	return nil
+}
+
+// Tracer returns a tracer named after the calling module.
+func (t *Tracing) Tracer(skipCallstack int) trace.Tracer {
+	callStack := stack.Callers()
+	call := callStack[1+skipCallstack]
+	module := strings.SplitN(call.Info().FunctionName(), ".", 2)[0]
+	return t.provider.Tracer(module)
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter/logger"
+	"akvorado/common/reporter/tracing"
+)
+
+func TestDisabled(t *testing.T) {
+	l, err := logger.New(logger.DefaultConfiguration())
+	if err != nil {
+		t.Fatalf("logger.New() err:\n%+v", err)
+	}
+	tr, err := tracing.New(l, tracing.DefaultConfiguration())
+	if err != nil {
+		t.Fatalf("tracing.New() err:\n%+v", err)
+	}
+	if err := tr.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	_, span := tr.Tracer(0).Start(context.Background(), "operation")
+	if span.IsRecording() {
+		t.Error("IsRecording() should be false when tracing is disabled")
+	}
+	span.End()
+	if err := tr.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+}
+
+func TestOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		body = got
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	l, err := logger.New(logger.DefaultConfiguration())
+	if err != nil {
+		t.Fatalf("logger.New() err:\n%+v", err)
+	}
+	config := tracing.DefaultConfiguration()
+	config.Endpoint = fmt.Sprintf("%s/v1/traces", server.URL)
+	tr, err := tracing.New(l, config)
+	if err != nil {
+		t.Fatalf("tracing.New() err:\n%+v", err)
+	}
+	if err := tr.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	_, span := tr.Tracer(0).Start(context.Background(), "some operation")
+	if !span.IsRecording() {
+		t.Error("IsRecording() should be true when tracing is enabled")
+	}
+	span.End()
+	// Stopping flushes the spans
+	if err := tr.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := helpers.Diff(paths, []string{"/v1/traces"}); diff != "" {
+		t.Errorf("OTLP requests (-got, +want):\n%s", diff)
+	}
+	for _, expected := range []string{"some operation", "akvorado/common/reporter/tracing_test"} {
+		if !bytes.Contains(body, []byte(expected)) {
+			t.Errorf("OTLP request does not contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Aggregator sums flows into time windows. Flows of the same window sharing the
+// same values for the kept columns are merged into a single row. Bytes and
+// packets are scaled by the sampling rate and the sampling rate of merged rows
+// is 1. The other columns are left empty.
+type Aggregator struct {
+	schema   *Schema
+	interval uint32
+	keys     []ColumnKey // kept columns, except the ones always set
+	windows  map[uint32]*aggregatorWindow
+	key      []byte
+}
+
+// aggregatorWindow contains the merged rows of a time window.
+type aggregatorWindow struct {
+	bf   *FlowMessage
+	rows map[string]int
+}
+
+// NewAggregator creates a new aggregator using windows of the provided
+// duration and keeping the provided columns.
+func (schema *Schema) NewAggregator(interval time.Duration, columns []ColumnKey) *Aggregator {
+	a := &Aggregator{
+		schema:   schema,
+		interval: max(uint32(interval.Seconds()), 1),
+		windows:  map[uint32]*aggregatorWindow{},
+	}
+	for _, key := range columns {
+		switch key {
+		case ColumnTimeReceived, ColumnSamplingRate, ColumnBytes, ColumnPackets, ColumnRawBytes, ColumnRawPackets:
+			continue
+		}
+		if !slices.Contains(a.keys, key) {
+			a.keys = append(a.keys, key)
+		}
+	}
+	return a
+}
+
+// Add merges the flows batched in the provided flow message. No flow should be
+// in progress. The provided flow message is left untouched.
+func (a *Aggregator) Add(bf *FlowMessage) {
+	times := bf.batch.columns[ColumnTimeReceived].(*proto.ColDateTime).Data
+	rates := *bf.batch.columns[ColumnSamplingRate].(*proto.ColUInt64)
+	bytes := *bf.batch.columns[ColumnBytes].(*proto.ColUInt64)
+	packets := *bf.batch.columns[ColumnPackets].(*proto.ColUInt64)
+	// Unscaled counters, only when enabled
+	var rawBytes, rawPackets proto.ColUInt64
+	if col := bf.batch.columns[ColumnRawBytes]; col != nil {
+		rawBytes = *col.(*proto.ColUInt64)
+	}
+	if col := bf.batch.columns[ColumnRawPackets]; col != nil {
+		rawPackets = *col.(*proto.ColUInt64)
+	}
+	for row := range bf.batch.rowCount {
+		start := uint32(times[row]) - uint32(times[row])%a.interval
+		w, ok := a.windows[start]
+		if !ok {
+			w = &aggregatorWindow{
+				bf:   a.schema.NewFlowMessage(),
+				rows: map[string]int{},
+			}
+			a.windows[start] = w
+		}
+		rate := max(rates[row], 1)
+
+		a.key = a.key[:0]
+		for _, key := range a.keys {
+			if col := bf.batch.columns[key]; col != nil {
+				a.key = appendRowKey(a.key, col, row)
+			}
+		}
+		if idx, ok := w.rows[string(a.key)]; ok {
+			(*w.bf.batch.columns[ColumnBytes].(*proto.ColUInt64))[idx] += bytes[row] * rate
+			(*w.bf.batch.columns[ColumnPackets].(*proto.ColUInt64))[idx] += packets[row] * rate
+			if rawBytes != nil {
+				(*w.bf.batch.columns[ColumnRawBytes].(*proto.ColUInt64))[idx] += rawBytes[row]
+			}
+			if rawPackets != nil {
+				(*w.bf.batch.columns[ColumnRawPackets].(*proto.ColUInt64))[idx] += rawPackets[row]
+			}
+			continue
+		}
+
+		// New row
+		for _, key := range a.keys {
+			if col := bf.batch.columns[key]; col != nil {
+				appendRowValue(w.bf.batch.columns[key], col, row)
+				w.bf.batch.columnSet.Set(uint(key))
+			}
+		}
+		w.bf.AppendDateTime(ColumnTimeReceived, start)
+		w.bf.AppendUint(ColumnSamplingRate, 1)
+		w.bf.AppendUint(ColumnBytes, bytes[row]*rate)
+		w.bf.AppendUint(ColumnPackets, packets[row]*rate)
+		if rawBytes != nil {
+			w.bf.AppendUint(ColumnRawBytes, rawBytes[row])
+		}
+		if rawPackets != nil {
+			w.bf.AppendUint(ColumnRawPackets, rawPackets[row])
+		}
+		w.bf.batch.rowCount++
+		w.bf.appendDefaultValues()
+		w.bf.reset()
+		w.bf.check()
+		w.rows[string(a.key)] = w.bf.batch.rowCount - 1
+	}
+}
+
+// Flush appends the rows of the windows ending before the provided time to the
+// provided flow message, oldest first, and forgets them. When the provided time
+// is zero, all the windows are flushed. No flow should be in progress in the
+// provided flow message.
+func (a *Aggregator) Flush(into *FlowMessage, before time.Time) {
+	for _, start := range slices.Sorted(maps.Keys(a.windows)) {
+		if !before.IsZero() && int64(start)+int64(a.interval) > before.Unix() {
+			break
+		}
+		into.AppendBatch(a.windows[start].bf)
+		delete(a.windows, start)
+	}
+}
+
+// RowCount returns the number of merged rows not flushed yet.
+func (a *Aggregator) RowCount() int {
+	count := 0
+	for _, w := range a.windows {
+		count += w.bf.batch.rowCount
+	}
+	return count
+}
+
+// appendRowKey appends the value of the provided row of a column to a key.
+func appendRowKey(key []byte, col proto.Column, row int) []byte {
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		return binary.BigEndian.AppendUint64(key, (*col)[row])
+	case *proto.ColUInt32:
+		return binary.BigEndian.AppendUint32(key, (*col)[row])
+	case *proto.ColUInt16:
+		return binary.BigEndian.AppendUint16(key, (*col)[row])
+	case *proto.ColUInt8:
+		return append(key, (*col)[row])
+	case *proto.ColIPv6:
+		value := (*col)[row]
+		return append(key, value[:]...)
+	case *proto.ColDateTime:
+		return binary.BigEndian.AppendUint32(key, uint32(col.Data[row]))
+	case *proto.ColEnum8:
+		return append(key, byte((*col)[row]))
+	case *proto.ColLowCardinality[string]:
+		key = binary.AppendUvarint(key, uint64(len(col.Values[row])))
+		return append(key, col.Values[row]...)
+	case *proto.ColLowCardinality[proto.IPv6]:
+		value := col.Values[row]
+		return append(key, value[:]...)
+	case *proto.ColArr[uint32]:
+		values := col.Row(row)
+		key = binary.AppendUvarint(key, uint64(len(values)))
+		for _, value := range values {
+			key = binary.BigEndian.AppendUint32(key, value)
+		}
+		return key
+	case *proto.ColArr[proto.UInt128]:
+		values := col.Row(row)
+		key = binary.AppendUvarint(key, uint64(len(values)))
+		for _, value := range values {
+			key = binary.BigEndian.AppendUint64(key, value.High)
+			key = binary.BigEndian.AppendUint64(key, value.Low)
+		}
+		return key
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+	}
+}
+
+// appendRowValue appends the value of the provided row of a column to another
+// column of the same type.
+func appendRowValue(dst, src proto.Column, row int) {
+	switch dst := dst.(type) {
+	case *proto.ColUInt64:
+		dst.Append((*src.(*proto.ColUInt64))[row])
+	case *proto.ColUInt32:
+		dst.Append((*src.(*proto.ColUInt32))[row])
+	case *proto.ColUInt16:
+		dst.Append((*src.(*proto.ColUInt16))[row])
+	case *proto.ColUInt8:
+		dst.Append((*src.(*proto.ColUInt8))[row])
+	case *proto.ColIPv6:
+		dst.Append((*src.(*proto.ColIPv6))[row])
+	case *proto.ColDateTime:
+		dst.Data = append(dst.Data, src.(*proto.ColDateTime).Data[row])
+	case *proto.ColEnum8:
+		dst.Append((*src.(*proto.ColEnum8))[row])
+	case *proto.ColLowCardinality[string]:
+		dst.Append(src.(*proto.ColLowCardinality[string]).Values[row])
+	case *proto.ColLowCardinality[proto.IPv6]:
+		dst.Append(src.(*proto.ColLowCardinality[proto.IPv6]).Values[row])
+	case *proto.ColArr[uint32]:
+		dst.Append(src.(*proto.ColArr[uint32]).Row(row))
+	case *proto.ColArr[proto.UInt128]:
+		dst.Append(src.(*proto.ColArr[proto.UInt128]).Row(row))
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", dst.Type()))
+	}
+}
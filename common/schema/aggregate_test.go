@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/google/go-cmp/cmp"
+
+	"akvorado/common/helpers"
+)
+
+func TestAggregator(t *testing.T) {
+	c := NewMock(t).EnableAllColumns()
+	exporter1 := netip.MustParseAddr("::ffff:192.0.2.1")
+	exporter2 := netip.MustParseAddr("::ffff:192.0.2.2")
+	appendFlow := func(bf *FlowMessage, t uint32, exporter netip.Addr, name string, srcAS uint32, bytes, packets uint64) {
+		bf.TimeReceived = t
+		bf.SamplingRate = 100
+		bf.ExporterAddress = exporter
+		bf.SrcAddr = netip.MustParseAddr("2001:db8::1")
+		bf.SrcAS = srcAS
+		bf.AppendString(ColumnExporterName, name)
+		bf.AppendUint(ColumnBytes, bytes)
+		bf.AppendUint(ColumnPackets, packets)
+		bf.AppendArrayUInt32(ColumnDstCommunities, []uint32{srcAS})
+		bf.Finalize()
+	}
+
+	a := c.NewAggregator(time.Minute, []ColumnKey{
+		ColumnExporterAddress, ColumnExporterName, ColumnDstCommunities, ColumnBytes,
+	})
+	bf := c.NewFlowMessage()
+	appendFlow(bf, 1000, exporter1, "exporter1", 65000, 1000, 10)
+	appendFlow(bf, 1010, exporter1, "exporter1", 65001, 2000, 20)
+	appendFlow(bf, 1010, exporter1, "exporter1", 65000, 3000, 30)
+	appendFlow(bf, 1019, exporter2, "exporter2", 65000, 4000, 40)
+	appendFlow(bf, 1020, exporter1, "exporter1", 65000, 5000, 50) // next window
+	a.Add(bf)
+	bf.Clear()
+	appendFlow(bf, 1015, exporter1, "exporter1", 65000, 6000, 60)
+	a.Add(bf)
+	if a.RowCount() != 4 {
+		t.Fatalf("RowCount() == %d, expected 4", a.RowCount())
+	}
+
+	// Nothing to flush
+	got := c.NewFlowMessage()
+	a.Flush(got, time.Unix(1019, 0))
+	if got.FlowCount() != 0 {
+		t.Fatalf("Flush() returned %d flows, expected 0", got.FlowCount())
+	}
+
+	// First window
+	a.Flush(got, time.Unix(1020, 0))
+	expected := c.NewFlowMessage()
+	for _, flow := range []struct {
+		exporter    netip.Addr
+		name        string
+		communities []uint32
+		bytes       uint64
+		packets     uint64
+		rawBytes    uint64
+		rawPackets  uint64
+	}{
+		{exporter1, "exporter1", []uint32{65000}, 1_000_000, 10_000, 10_000, 100},
+		{exporter1, "exporter1", []uint32{65001}, 200_000, 2_000, 2_000, 20},
+		{exporter2, "exporter2", []uint32{65000}, 400_000, 4_000, 4_000, 40},
+	} {
+		expected.TimeReceived = 960
+		expected.SamplingRate = 1
+		expected.ExporterAddress = flow.exporter
+		expected.AppendString(ColumnExporterName, flow.name)
+		expected.AppendUint(ColumnBytes, flow.bytes)
+		expected.AppendUint(ColumnPackets, flow.packets)
+		expected.AppendUint(ColumnRawBytes, flow.rawBytes)
+		expected.AppendUint(ColumnRawPackets, flow.rawPackets)
+		expected.AppendArrayUInt32(ColumnDstCommunities, flow.communities)
+		expected.Finalize()
+	}
+	diffOpts := []cmp.Option{
+		cmp.Comparer(func(x, y proto.ColLowCardinality[string]) bool {
+			return slices.Compare(x.Values, y.Values) == 0
+		}),
+		cmp.Comparer(func(x, y proto.ColLowCardinality[proto.IPv6]) bool {
+			return slices.Equal(x.Values, y.Values)
+		}),
+	}
+	if got.FlowCount() != 3 {
+		t.Fatalf("Flush() returned %d flows, expected 3", got.FlowCount())
+	}
+	for idx, col := range got.batch.columns {
+		if col == nil {
+			continue
+		}
+		if diff := helpers.Diff(col, expected.batch.columns[idx], diffOpts...); diff != "" {
+			t.Errorf("Flush(), column %s (-got, +want):\n%s", ColumnKey(idx), diff)
+		}
+	}
+	if a.RowCount() != 1 {
+		t.Fatalf("RowCount() == %d, expected 1", a.RowCount())
+	}
+
+	// Second window
+	got.Clear()
+	a.Flush(got, time.Unix(2000, 0))
+	if got.FlowCount() != 1 {
+		t.Fatalf("Flush() returned %d flows, expected 1", got.FlowCount())
+	}
+	if diff := helpers.Diff(*got.batch.columns[ColumnBytes].(*proto.ColUInt64), proto.ColUInt64{500_000}); diff != "" {
+		t.Errorf("Flush() bytes (-got, +want):\n%s", diff)
+	}
+	if a.RowCount() != 0 {
+		t.Fatalf("RowCount() == %d, expected 0", a.RowCount())
+	}
+}
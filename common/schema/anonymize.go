@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"net/netip"
+)
+
+// anonymizer keeps the state needed to hash addresses. It is specific to a
+// flow message to avoid any locking.
+type anonymizer struct {
+	period uint64
+	mac    hash.Hash
+	sum    [sha256.Size]byte
+}
+
+// anonymizedColumn tells if the addresses in a column should be anonymized.
+func anonymizedColumn(columnKey ColumnKey) bool {
+	switch columnKey {
+	case ColumnSrcAddr, ColumnDstAddr, ColumnSrcAddrNAT, ColumnDstAddrNAT:
+		return true
+	}
+	return false
+}
+
+// anonymize anonymizes the provided address according to the configuration
+// of the schema.
+func (bf *FlowMessage) anonymize(value netip.Addr) netip.Addr {
+	config := &bf.schema.anonymization
+	switch config.Mode {
+	case "truncate":
+		bits := config.IPv6PrefixLength
+		if value.Is4() {
+			bits = config.IPv4PrefixLength
+		} else if value.Is4In6() {
+			bits = 96 + config.IPv4PrefixLength
+		}
+		prefix, _ := value.Prefix(bits)
+		return prefix.Addr()
+	case "hash":
+		a := &bf.anonymizer
+		var period uint64
+		if rotation := uint64(config.KeyRotation.Seconds()); rotation > 0 {
+			period = uint64(bf.TimeReceived) / rotation
+		}
+		if a.mac == nil || a.period != period {
+			// Derive the key for the current period
+			var input [8]byte
+			binary.BigEndian.PutUint64(input[:], period)
+			derive := hmac.New(sha256.New, []byte(config.Key))
+			derive.Write(input[:])
+			a.mac = hmac.New(sha256.New, derive.Sum(nil))
+			a.period = period
+		}
+		a.mac.Reset()
+		address := value.As16()
+		a.mac.Write(address[:])
+		sum := a.mac.Sum(a.sum[:0])
+		if value.Is4() {
+			return netip.AddrFrom4([4]byte(sum[:4]))
+		}
+		if value.Is4In6() {
+			copy(address[12:], sum[:4])
+			return netip.AddrFrom16(address)
+		}
+		return netip.AddrFrom16([16]byte(sum[:16]))
+	}
+	return value
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+)
+
+func TestAnonymizeTruncate(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Anonymization.Mode = "truncate"
+	c, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	bf := c.NewFlowMessage()
+	bf.AppendIPv6(ColumnExporterAddress, netip.MustParseAddr("::ffff:203.0.113.14"))
+	bf.AppendIPv6(ColumnSrcAddr, netip.MustParseAddr("::ffff:192.0.2.123"))
+	bf.AppendIPv6(ColumnDstAddr, netip.MustParseAddr("2001:db8:1:2::1"))
+
+	expected := map[ColumnKey]any{
+		ColumnExporterAddress: netip.MustParseAddr("::ffff:203.0.113.14"),
+		ColumnSrcAddr:         netip.MustParseAddr("::ffff:192.0.2.0"),
+		ColumnDstAddr:         netip.MustParseAddr("2001:db8:1::"),
+	}
+	if diff := helpers.Diff(bf.OtherColumns, expected); diff != "" {
+		t.Fatalf("AppendIPv6() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestAnonymizeHash(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Anonymization.Mode = "hash"
+	config.Anonymization.Key = "secret"
+	config.Anonymization.KeyRotation = time.Hour
+	c, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	v4 := netip.MustParseAddr("::ffff:192.0.2.123")
+	v6 := netip.MustParseAddr("2001:db8::1")
+	hash := func(timeReceived uint32, addr netip.Addr) netip.Addr {
+		bf := c.NewFlowMessage()
+		bf.TimeReceived = timeReceived
+		return bf.anonymize(addr)
+	}
+
+	got := hash(1000, v4)
+	if got == v4 || !got.Is4In6() {
+		t.Errorf("anonymize(%s) == %s", v4, got)
+	}
+	if other := hash(2000, v4); other != got {
+		t.Errorf("anonymize(%s) not stable in a period: %s != %s", v4, other, got)
+	}
+	if other := hash(5000, v4); other == got {
+		t.Errorf("anonymize(%s) stable across periods", v4)
+	}
+	if got := hash(1000, v6); got == v6 || got.Is4In6() {
+		t.Errorf("anonymize(%s) == %s", v6, got)
+	}
+}
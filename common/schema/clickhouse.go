@@ -132,6 +132,56 @@ func (schema Schema) ClickHousePrimaryKeys() []string {
 	return cols
 }
 
+// WithMainOnlyColumns returns a copy of the schema where the provided columns
+// are only present in the main table. This is used to remove some columns from
+// a specific aggregated table.
+func (schema Schema) WithMainOnlyColumns(keys []ColumnKey) (Schema, error) {
+	if len(keys) == 0 {
+		return schema, nil
+	}
+	schema.columns = slices.Clone(schema.columns)
+	for i := range schema.columns {
+		column := &schema.columns[i]
+		if column.AliasOf != 0 && slices.Contains(keys, column.AliasOf) {
+			// Column aliases follow their target
+			column.ClickHouseMainOnly = true
+			continue
+		}
+		if !slices.Contains(keys, column.Key) {
+			continue
+		}
+		if column.NoDisable {
+			return Schema{}, fmt.Errorf("column %q cannot be present on main table only", column.Name)
+		}
+		if slices.Contains(schema.clickhousePrimaryKeys, column.Key) {
+			return Schema{}, fmt.Errorf("column %q cannot be present on main table only (primary key)", column.Name)
+		}
+		column.ClickHouseMainOnly = true
+	}
+	for _, column := range schema.columns {
+		if column.Disabled || column.ClickHouseMainOnly || column.ClickHouseAlias == "" {
+			continue
+		}
+		for _, depend := range column.Depends {
+			if slices.Contains(keys, depend) {
+				return Schema{}, fmt.Errorf("column %q cannot be present on main table only without %q",
+					depend, column.Name)
+			}
+		}
+	}
+	return schema.finalize(), nil
+}
+
+// clickhouseConversationID returns the expression computing the identifier of
+// a conversation. Both directions of a conversation get the same identifier
+// when they are received during the same time window.
+func clickhouseConversationID(window time.Duration) string {
+	return fmt.Sprintf("cityHash64("+
+		"if((SrcAddr, SrcPort) <= (DstAddr, DstPort), "+
+		"(SrcAddr, SrcPort, DstAddr, DstPort), (DstAddr, DstPort, SrcAddr, SrcPort)), "+
+		"Proto, toStartOfInterval(TimeReceived, toIntervalSecond(%d)))", uint64(window.Seconds()))
+}
+
 // ClickHouseHash returns an hash of the inpt table in ClickHouse
 func (schema Schema) ClickHouseHash() string {
 	hash := fnv.New128()
@@ -150,7 +200,7 @@ func (bf *FlowMessage) AppendDateTime(columnKey ColumnKey, value uint32) {
 	}
 	bf.batch.columnSet.Set(uint(columnKey))
 	col.(*proto.ColDateTime).AppendRaw(proto.DateTime(value))
-	bf.appendDebug(columnKey, value)
+	appendDebug(bf, columnKey, value)
 }
 
 // AppendUint adds an UInt64/32/16/8 or Enum8 value to the provided column
@@ -163,19 +213,19 @@ func (bf *FlowMessage) AppendUint(columnKey ColumnKey, value uint64) {
 	switch col := col.(type) {
 	case *proto.ColUInt64:
 		col.Append(value)
-		bf.appendDebug(columnKey, value)
+		appendDebug(bf, columnKey, value)
 	case *proto.ColUInt32:
 		col.Append(uint32(value))
-		bf.appendDebug(columnKey, uint32(value))
+		appendDebug(bf, columnKey, uint32(value))
 	case *proto.ColUInt16:
 		col.Append(uint16(value))
-		bf.appendDebug(columnKey, uint16(value))
+		appendDebug(bf, columnKey, uint16(value))
 	case *proto.ColUInt8:
 		col.Append(uint8(value))
-		bf.appendDebug(columnKey, uint8(value))
+		appendDebug(bf, columnKey, uint8(value))
 	case *proto.ColEnum8:
 		col.Append(proto.Enum8(value))
-		bf.appendDebug(columnKey, uint8(value))
+		appendDebug(bf, columnKey, uint8(value))
 	default:
 		panic(fmt.Sprintf("unhandled uint type %q", col.Type()))
 	}
@@ -196,7 +246,7 @@ func (bf *FlowMessage) AppendString(columnKey ColumnKey, value string) {
 		panic(fmt.Sprintf("unhandled string type %q", col.Type()))
 	}
 	bf.batch.columnSet.Set(uint(columnKey))
-	bf.appendDebug(columnKey, value)
+	appendDebug(bf, columnKey, value)
 }
 
 // AppendIPv6 adds an IPv6 value to the provided column
@@ -206,6 +256,9 @@ func (bf *FlowMessage) AppendIPv6(columnKey ColumnKey, value netip.Addr) {
 	if !value.IsValid() || col == nil || bf.batch.columnSet.Test(uint(columnKey)) {
 		return
 	}
+	if anonymizedColumn(columnKey) {
+		value = bf.anonymize(value)
+	}
 	switch col := col.(type) {
 	case *proto.ColIPv6:
 		col.Append(value.As16())
@@ -215,7 +268,7 @@ func (bf *FlowMessage) AppendIPv6(columnKey ColumnKey, value netip.Addr) {
 		panic(fmt.Sprintf("unhandled string type %q", col.Type()))
 	}
 	bf.batch.columnSet.Set(uint(columnKey))
-	bf.appendDebug(columnKey, value)
+	appendDebug(bf, columnKey, value)
 }
 
 // AppendArrayUInt32 adds an Array(UInt32) value to the provided column
@@ -227,7 +280,7 @@ func (bf *FlowMessage) AppendArrayUInt32(columnKey ColumnKey, value []uint32) {
 	}
 	bf.batch.columnSet.Set(uint(columnKey))
 	col.(*proto.ColArr[uint32]).Append(value)
-	bf.appendDebug(columnKey, value)
+	appendDebug(bf, columnKey, value)
 }
 
 // AppendArrayUInt128 adds an Array(UInt128) value to the provided column
@@ -239,10 +292,12 @@ func (bf *FlowMessage) AppendArrayUInt128(columnKey ColumnKey, value []UInt128)
 	}
 	bf.batch.columnSet.Set(uint(columnKey))
 	col.(*proto.ColArr[proto.UInt128]).Append(value)
-	bf.appendDebug(columnKey, value)
+	appendDebug(bf, columnKey, value)
 }
 
-func (bf *FlowMessage) appendDebug(columnKey ColumnKey, value any) {
+// appendDebug records the value in OtherColumns when in debug mode. It is
+// generic to avoid boxing the value when debug is disabled.
+func appendDebug[T any](bf *FlowMessage, columnKey ColumnKey, value T) {
 	if !debug {
 		return
 	}
@@ -368,6 +423,109 @@ func (bf *FlowMessage) Undo() {
 	bf.reset()
 }
 
+// AppendBatch appends the flows batched in another flow message to the current
+// batch. No flow should be in progress in any of the flow messages. The other
+// flow message is left untouched.
+func (bf *FlowMessage) AppendBatch(other *FlowMessage) {
+	bf.appendRows(other, 0, other.batch.rowCount)
+}
+
+// SplitBatch appends each flow batched in the current flow message to one of
+// the target flow messages. The target is selected by its index, returned by
+// the provided function from the exporter address of the flow. No flow should
+// be in progress in any of the flow messages. The current flow message is left
+// untouched.
+func (bf *FlowMessage) SplitBatch(targets []*FlowMessage, selector func(exporter netip.Addr) int) {
+	exporters := bf.batch.columns[ColumnExporterAddress].(*proto.ColLowCardinality[proto.IPv6]).Values
+	for start := 0; start < bf.batch.rowCount; {
+		// Flows from the same exporter are often consecutive
+		end := start + 1
+		for end < bf.batch.rowCount && exporters[end] == exporters[start] {
+			end++
+		}
+		target := selector(netip.AddrFrom16(exporters[start]))
+		targets[target].appendRows(bf, start, end)
+		start = end
+	}
+}
+
+// HalveBatch appends the first half of the flows batched in the current flow
+// message to the first target flow message and the remaining ones to the second
+// one. No flow should be in progress in any of the flow messages. The current
+// flow message is left untouched.
+func (bf *FlowMessage) HalveBatch(first, second *FlowMessage) {
+	half := bf.batch.rowCount / 2
+	first.appendRows(bf, 0, half)
+	second.appendRows(bf, half, bf.batch.rowCount)
+}
+
+// appendRows appends the rows from start (included) to end (excluded) of the
+// batch of another flow message to the current batch.
+func (bf *FlowMessage) appendRows(other *FlowMessage, start, end int) {
+	for idx, col := range bf.batch.columns {
+		if col == nil {
+			continue
+		}
+		switch col := col.(type) {
+		case *proto.ColUInt64:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColUInt64))[start:end]...)
+		case *proto.ColUInt32:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColUInt32))[start:end]...)
+		case *proto.ColUInt16:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColUInt16))[start:end]...)
+		case *proto.ColUInt8:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColUInt8))[start:end]...)
+		case *proto.ColIPv6:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColIPv6))[start:end]...)
+		case *proto.ColDateTime:
+			col.Data = append(col.Data, other.batch.columns[idx].(*proto.ColDateTime).Data[start:end]...)
+		case *proto.ColEnum8:
+			*col = append(*col, (*other.batch.columns[idx].(*proto.ColEnum8))[start:end]...)
+		case *proto.ColLowCardinality[string]:
+			col.Values = append(col.Values, other.batch.columns[idx].(*proto.ColLowCardinality[string]).Values[start:end]...)
+		case *proto.ColLowCardinality[proto.IPv6]:
+			col.Values = append(col.Values, other.batch.columns[idx].(*proto.ColLowCardinality[proto.IPv6]).Values[start:end]...)
+		case *proto.ColArr[uint32]:
+			otherCol := other.batch.columns[idx].(*proto.ColArr[uint32])
+			for i := start; i < end; i++ {
+				col.Append(otherCol.Row(i))
+			}
+		case *proto.ColArr[proto.UInt128]:
+			otherCol := other.batch.columns[idx].(*proto.ColArr[proto.UInt128])
+			for i := start; i < end; i++ {
+				col.Append(otherCol.Row(i))
+			}
+		default:
+			panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+		}
+	}
+	bf.batch.rowCount += end - start
+	bf.check()
+}
+
+// ClickHouseProtoBlock returns a proto.Input with at most size flows of the
+// current batch, starting at the provided offset. The flows are copied to a
+// separate set of columns, reused on each call: the returned input is only
+// valid until the next call. When offset is past the last flow, the returned
+// input is empty.
+func (bf *FlowMessage) ClickHouseProtoBlock(offset, size int) proto.Input {
+	if bf.block == nil {
+		bf.block = bf.schema.NewFlowMessage()
+	}
+	bf.block.Clear()
+	if offset < bf.batch.rowCount {
+		bf.block.appendRows(bf, offset, min(offset+size, bf.batch.rowCount))
+	}
+	return bf.block.batch.input
+}
+
+// SwapBatch exchanges the flows batched in the current flow message with the
+// ones batched in another flow message. No flow should be in progress in any
+// of the flow messages.
+func (bf *FlowMessage) SwapBatch(other *FlowMessage) {
+	bf.batch, other.batch = other.batch, bf.batch
+}
+
 // Finalize finalizes the current FlowMessage. It can then be reused for the
 // next one. It is crucial to always call Finalize, otherwise the batch could be
 // faulty.
@@ -386,14 +544,26 @@ func (bf *FlowMessage) Finalize() {
 		bf.AppendUint(ColumnSrcVlan, uint64(bf.SrcVlan))
 		bf.AppendUint(ColumnDstVlan, uint64(bf.DstVlan))
 	}
+	bf.appendRawCounter(ColumnRawBytes, ColumnBytes)
+	bf.appendRawCounter(ColumnRawPackets, ColumnPackets)
 	bf.batch.rowCount++
 	bf.appendDefaultValues()
 	bf.reset()
 	bf.check()
 }
 
+// appendRawCounter copies the value of a counter for the current flow to the
+// column keeping the unscaled value, if this column is enabled.
+func (bf *FlowMessage) appendRawCounter(raw, counter ColumnKey) {
+	if bf.batch.columns[raw] == nil || !bf.batch.columnSet.Test(uint(counter)) {
+		return
+	}
+	bf.AppendUint(raw, (*bf.batch.columns[counter].(*proto.ColUInt64))[bf.batch.rowCount])
+}
+
 func reverse(bf *FlowMessage, columnKey ColumnKey) ColumnKey {
-	if !bf.reversed {
+	if !bf.reversed || int(columnKey) >= len(columnReverseTable) {
+		// Dynamic columns are never reversed
 		return columnKey
 	}
 	return columnReverseTable[columnKey]
@@ -4,6 +4,7 @@
 package schema
 
 import (
+	"fmt"
 	"net/netip"
 	"slices"
 	"strings"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/ClickHouse/ch-go/proto"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestAppendDefault(t *testing.T) {
@@ -571,6 +573,180 @@ func TestUndoArrayUInt128(t *testing.T) {
 	})
 }
 
+func TestAppendBatch(t *testing.T) {
+	c := NewMock(t).EnableAllColumns()
+	appendFlow := func(bf *FlowMessage, n uint64) {
+		bf.TimeReceived = 1000 + uint32(n)
+		bf.SrcAddr = netip.MustParseAddr("2001:db8::1")
+		bf.AppendUint(ColumnBytes, 100*n)
+		bf.AppendString(ColumnExporterName, fmt.Sprintf("exporter%d", n))
+		bf.AppendArrayUInt32(ColumnDstCommunities, []uint32{uint32(n), uint32(n + 1)})
+		bf.AppendArrayUInt128(ColumnDstLargeCommunities, []UInt128{{High: n, Low: n}})
+		bf.Finalize()
+	}
+
+	// All flows in a single batch
+	expected := c.NewFlowMessage()
+	for n := range uint64(5) {
+		appendFlow(expected, n)
+	}
+
+	// Flows in two batches, appended together
+	got := c.NewFlowMessage()
+	other := c.NewFlowMessage()
+	for n := range uint64(5) {
+		if n < 2 {
+			appendFlow(got, n)
+		} else {
+			appendFlow(other, n)
+		}
+	}
+	got.AppendBatch(other)
+
+	diffOpts := []cmp.Option{
+		cmp.Comparer(func(x, y proto.ColLowCardinality[string]) bool {
+			return slices.Compare(x.Values, y.Values) == 0
+		}),
+		cmp.Comparer(func(x, y proto.ColLowCardinality[proto.IPv6]) bool {
+			return slices.Equal(x.Values, y.Values)
+		}),
+	}
+	if got.FlowCount() != 5 {
+		t.Errorf("FlowCount() == %d, expected 5", got.FlowCount())
+	}
+	if other.FlowCount() != 3 {
+		t.Errorf("FlowCount() for other message == %d, expected 3", other.FlowCount())
+	}
+	for idx, col := range got.batch.columns {
+		if col == nil {
+			continue
+		}
+		if diff := helpers.Diff(col, expected.batch.columns[idx], diffOpts...); diff != "" {
+			t.Errorf("AppendBatch(), column %s (-got, +want):\n%s", ColumnKey(idx), diff)
+		}
+	}
+
+	// Swap the batch with an empty message
+	empty := c.NewFlowMessage()
+	got.SwapBatch(empty)
+	if got.FlowCount() != 0 || empty.FlowCount() != 5 {
+		t.Errorf("SwapBatch() did not swap batches (%d, %d)", got.FlowCount(), empty.FlowCount())
+	}
+	if diff := helpers.Diff(empty.batch.columns[ColumnBytes], expected.batch.columns[ColumnBytes]); diff != "" {
+		t.Errorf("SwapBatch() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestClickHouseProtoBlock(t *testing.T) {
+	c := NewMock(t).EnableAllColumns()
+	bf := c.NewFlowMessage()
+	for n := range uint64(5) {
+		bf.TimeReceived = 1000 + uint32(n)
+		bf.AppendUint(ColumnBytes, 100*n)
+		bf.AppendString(ColumnExporterName, fmt.Sprintf("exporter%d", n))
+		bf.AppendArrayUInt32(ColumnDstCommunities, []uint32{uint32(n)})
+		bf.Finalize()
+	}
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Offset   int
+		Expected []uint64
+	}{
+		{helpers.Mark(), 0, []uint64{0, 100}},
+		{helpers.Mark(), 2, []uint64{200, 300}},
+		{helpers.Mark(), 4, []uint64{400}},
+		{helpers.Mark(), 6, []uint64{}},
+	}
+	for _, tc := range cases {
+		input := bf.ClickHouseProtoBlock(tc.Offset, 2)
+		for _, col := range input {
+			if col.Data.Rows() != len(tc.Expected) {
+				t.Errorf("%sClickHouseProtoBlock(), column %s has %d rows, expected %d",
+					tc.Pos, col.Name, col.Data.Rows(), len(tc.Expected))
+			}
+		}
+		got := []uint64(*bf.block.batch.columns[ColumnBytes].(*proto.ColUInt64))
+		if diff := helpers.Diff(got, tc.Expected, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%sClickHouseProtoBlock() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+	if bf.FlowCount() != 5 {
+		t.Errorf("FlowCount() == %d, expected 5", bf.FlowCount())
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	c := NewMock(t).EnableAllColumns()
+	bf := c.NewFlowMessage()
+	for n := range uint64(6) {
+		bf.TimeReceived = 1000 + uint32(n)
+		bf.ExporterAddress = netip.AddrFrom16(netip.MustParseAddr(fmt.Sprintf("::ffff:192.0.2.%d", 1+n/2)).As16())
+		bf.AppendUint(ColumnBytes, 100*n)
+		bf.AppendArrayUInt32(ColumnDstCommunities, []uint32{uint32(n)})
+		bf.Finalize()
+	}
+
+	targets := []*FlowMessage{c.NewFlowMessage(), c.NewFlowMessage()}
+	bf.SplitBatch(targets, func(exporter netip.Addr) int {
+		if exporter == netip.MustParseAddr("::ffff:192.0.2.2") {
+			return 1
+		}
+		return 0
+	})
+	if bf.FlowCount() != 6 {
+		t.Errorf("FlowCount() == %d, expected 6", bf.FlowCount())
+	}
+	for idx, expected := range [][]uint64{{0, 100, 400, 500}, {200, 300}} {
+		got := []uint64(*targets[idx].batch.columns[ColumnBytes].(*proto.ColUInt64))
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("SplitBatch(), target %d (-got, +want):\n%s", idx, diff)
+		}
+		if targets[idx].FlowCount() != len(expected) {
+			t.Errorf("SplitBatch(), target %d: FlowCount() == %d, expected %d",
+				idx, targets[idx].FlowCount(), len(expected))
+		}
+	}
+	got := targets[1].batch.columns[ColumnDstCommunities].(*proto.ColArr[uint32]).Row(1)
+	if diff := helpers.Diff(got, []uint32{3}); diff != "" {
+		t.Errorf("SplitBatch(), DstCommunities (-got, +want):\n%s", diff)
+	}
+}
+
+func TestHalveBatch(t *testing.T) {
+	c := NewMock(t).EnableAllColumns()
+	bf := c.NewFlowMessage()
+	for n := range uint64(5) {
+		bf.TimeReceived = 1000 + uint32(n)
+		bf.AppendUint(ColumnBytes, 100*n)
+		bf.AppendArrayUInt32(ColumnDstCommunities, []uint32{uint32(n)})
+		bf.Finalize()
+	}
+
+	first, second := c.NewFlowMessage(), c.NewFlowMessage()
+	bf.HalveBatch(first, second)
+	if bf.FlowCount() != 5 {
+		t.Errorf("FlowCount() == %d, expected 5", bf.FlowCount())
+	}
+	for idx, target := range []struct {
+		bf       *FlowMessage
+		expected []uint64
+	}{{first, []uint64{0, 100}}, {second, []uint64{200, 300, 400}}} {
+		got := []uint64(*target.bf.batch.columns[ColumnBytes].(*proto.ColUInt64))
+		if diff := helpers.Diff(got, target.expected); diff != "" {
+			t.Errorf("HalveBatch(), target %d (-got, +want):\n%s", idx, diff)
+		}
+		if target.bf.FlowCount() != len(target.expected) {
+			t.Errorf("HalveBatch(), target %d: FlowCount() == %d, expected %d",
+				idx, target.bf.FlowCount(), len(target.expected))
+		}
+	}
+	got := second.batch.columns[ColumnDstCommunities].(*proto.ColArr[uint32]).Row(0)
+	if diff := helpers.Diff(got, []uint32{2}); diff != "" {
+		t.Errorf("HalveBatch(), DstCommunities (-got, +want):\n%s", diff)
+	}
+}
+
 func TestBuildProtoInput(t *testing.T) {
 	// Use a smaller version
 	exporterAddress := netip.MustParseAddr("::ffff:203.0.113.14")
@@ -651,3 +827,57 @@ func TestReverse(t *testing.T) {
 		t.Errorf("DstAS == %d, should be %d", bf.DstAS, 65000)
 	}
 }
+
+func BenchmarkFinalize(b *testing.B) {
+	DisableDebug(b)
+	c := NewMock(b).EnableAllColumns()
+	bf := c.NewFlowMessage()
+	srcAddr := netip.MustParseAddr("2001:db8::1")
+	dstAddr := netip.MustParseAddr("2001:db8::2")
+	asPath := []uint32{65000, 65001}
+	fill := func() {
+		for range 1000 {
+			bf.TimeReceived = 1000
+			bf.SamplingRate = 20000
+			bf.SrcAddr = srcAddr
+			bf.DstAddr = dstAddr
+			bf.AppendUint(ColumnBytes, 1500)
+			bf.AppendUint(ColumnPackets, 1)
+			bf.AppendString(ColumnExporterName, "exporter1")
+			bf.AppendString(ColumnInIfName, "Gi0/0/0/1")
+			bf.AppendArrayUInt32(ColumnDstASPath, asPath)
+			bf.Finalize()
+		}
+	}
+	// Warm up the buffers
+	fill()
+	bf.Clear()
+	b.ReportAllocs()
+	for b.Loop() {
+		fill()
+		bf.Clear()
+	}
+}
+
+func TestFinalizeAllocations(t *testing.T) {
+	DisableDebug(t)
+	c := NewMock(t).EnableAllColumns()
+	bf := c.NewFlowMessage()
+	srcAddr := netip.MustParseAddr("2001:db8::1")
+	asPath := []uint32{65000, 65001}
+	fill := func() {
+		for range 100 {
+			bf.TimeReceived = 1000
+			bf.SrcAddr = srcAddr
+			bf.AppendUint(ColumnBytes, 1500)
+			bf.AppendString(ColumnExporterName, "exporter1")
+			bf.AppendArrayUInt32(ColumnDstASPath, asPath)
+			bf.Finalize()
+		}
+		bf.Clear()
+	}
+	fill()
+	if allocs := testing.AllocsPerRun(10, fill); allocs > 0 {
+		t.Errorf("Finalize() allocations: %.0f, expected 0", allocs)
+	}
+}
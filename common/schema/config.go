@@ -5,8 +5,12 @@ package schema
 
 import (
 	"errors"
+	"reflect"
+	"time"
 
 	"akvorado/common/helpers"
+
+	"github.com/go-viper/mapstructure/v2"
 )
 
 // Configuration describes the configuration for the schema component.
@@ -23,6 +27,58 @@ type Configuration struct {
 	Materialize []ColumnKey
 	// CustomDictionaries allows enrichment of flows with custom metadata
 	CustomDictionaries map[string]CustomDict `validate:"dive"`
+	// CustomColumns lists additional columns to add to the flows
+	CustomColumns []CustomColumn `validate:"dive"`
+	// ColumnAliases lists alternative names for columns, for example after a rename
+	ColumnAliases []ColumnAlias `validate:"dive"`
+	// ConversationWindow is the time window used to match both directions
+	// of a conversation for the ConversationID column
+	ConversationWindow time.Duration `validate:"min=1s"`
+	// Anonymization defines how to anonymize source and destination
+	// addresses before storing them
+	Anonymization AnonymizationConfiguration
+}
+
+// AnonymizationConfiguration describes how to anonymize addresses.
+type AnonymizationConfiguration struct {
+	// Mode is either none, truncate (keep only a prefix of the address), or
+	// hash (replace the address with a keyed hash)
+	Mode string `validate:"oneof=none truncate hash"`
+	// IPv4PrefixLength and IPv6PrefixLength are the prefix lengths to keep
+	// when truncating addresses
+	IPv4PrefixLength int `validate:"min=0,max=32"`
+	IPv6PrefixLength int `validate:"min=0,max=128"`
+	// Key is the secret key to hash addresses
+	Key string `validate:"required_if=Mode hash"`
+	// KeyRotation is how often the key used to hash addresses is derived
+	// again. 0 means never.
+	KeyRotation time.Duration `validate:"min=0"`
+}
+
+// ColumnAlias represents an alternative name for a column. It is kept until
+// the end of the deprecation window.
+type ColumnAlias struct {
+	// Name is the alternative name for the column (usually its previous name)
+	Name string `validate:"required,alphanum"`
+	// Column is the name of the column the alias points to
+	Column string `validate:"required,alphanum,nefield=Name"`
+	// Until is the last day (YYYY-MM-DD) the alias is kept. Empty means forever.
+	Until string `validate:"omitempty,datetime=2006-01-02"`
+}
+
+// CustomColumn represents an additional column declared in the configuration
+type CustomColumn struct {
+	Name string `validate:"required,alphanum"`
+	Type string `validate:"required,oneof=String UInt8 UInt16 UInt32 UInt64 IPv6"`
+	// Default is the value to use when the column is not set by the decoder
+	Default string
+	// IPFIXEnterprise and IPFIXField is the IPFIX information element (or
+	// the NetFlow v9 field when the enterprise number is 0) to decode the
+	// column from.
+	IPFIXEnterprise uint32
+	IPFIXField      uint16
+	// MainTableOnly tells if the column is present only in the main table
+	MainTableOnly bool
 }
 
 // CustomDict represents a single custom dictionary
@@ -52,7 +108,15 @@ type CustomDictAttribute struct {
 
 // DefaultConfiguration returns the default configuration for the schema component.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		ConversationWindow: time.Minute,
+		Anonymization: AnonymizationConfiguration{
+			Mode:             "none",
+			IPv4PrefixLength: 24,
+			IPv6PrefixLength: 48,
+			KeyRotation:      24 * time.Hour,
+		},
+	}
 }
 
 // MarshalText turns a column key to text
@@ -79,6 +143,17 @@ func (ck *ColumnKey) UnmarshalText(input []byte) error {
 	return errors.New("unknown provider")
 }
 
+// GetCustomColumnsConfig returns the custom columns encoded in this schema
+func (c *Component) GetCustomColumnsConfig() []CustomColumn {
+	return c.c.CustomColumns
+}
+
+// ExpiredColumnAliases returns the names of the column aliases whose
+// deprecation window has ended.
+func (c *Component) ExpiredColumnAliases() []string {
+	return c.expiredAliases
+}
+
 // GetCustomDictConfig returns the custom dicts encoded in this schema
 func (c *Component) GetCustomDictConfig() map[string]CustomDict {
 	return c.c.CustomDictionaries
@@ -98,6 +173,13 @@ func DefaultCustomDictKeyConfiguration() CustomDictKey {
 	}
 }
 
+// DefaultCustomColumnConfiguration is the default config for a CustomColumn
+func DefaultCustomColumnConfiguration() CustomColumn {
+	return CustomColumn{
+		Type: "String",
+	}
+}
+
 // DefaultCustomDictAttributeConfiguration is the default config for a CustomDictAttribute
 func DefaultCustomDictAttributeConfiguration() CustomDictAttribute {
 	return CustomDictAttribute{
@@ -105,11 +187,35 @@ func DefaultCustomDictAttributeConfiguration() CustomDictAttribute {
 	}
 }
 
+// columnAliasUnmarshallerHook turns the end of the deprecation window of a
+// column alias back into a string as YAML decodes unquoted dates as
+// timestamps.
+func columnAliasUnmarshallerHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		if from.Kind() != reflect.Map || from.IsNil() || to.Type() != reflect.TypeFor[ColumnAlias]() {
+			return from.Interface(), nil
+		}
+		for _, k := range from.MapKeys() {
+			key := helpers.ElemOrIdentity(k)
+			if key.Kind() != reflect.String || !helpers.MapStructureMatchName(key.String(), "Until") {
+				continue
+			}
+			if until, ok := helpers.ElemOrIdentity(from.MapIndex(k)).Interface().(time.Time); ok {
+				from.SetMapIndex(k, reflect.ValueOf(until.Format(time.DateOnly)))
+			}
+		}
+		return from.Interface(), nil
+	}
+}
+
 func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(columnAliasUnmarshallerHook())
 	helpers.RegisterMapstructureUnmarshallerHook(
 		helpers.DefaultValuesUnmarshallerHook(DefaultCustomDictConfiguration()))
 	helpers.RegisterMapstructureUnmarshallerHook(
 		helpers.DefaultValuesUnmarshallerHook(DefaultCustomDictKeyConfiguration()))
 	helpers.RegisterMapstructureUnmarshallerHook(
 		helpers.DefaultValuesUnmarshallerHook(DefaultCustomDictAttributeConfiguration()))
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultCustomColumnConfiguration()))
 }
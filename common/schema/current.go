@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"net/netip"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// CurrentUint returns the value of an unsigned integer column for the current
+// flow, as it will be stored once finalized. It returns 0 when the column is
+// not set or disabled.
+func (bf *FlowMessage) CurrentUint(columnKey ColumnKey) uint64 {
+	if bf.batch.columnSet.Test(uint(columnKey)) {
+		switch col := bf.batch.columns[columnKey].(type) {
+		case *proto.ColUInt64:
+			return col.Row(col.Rows() - 1)
+		case *proto.ColUInt32:
+			return uint64(col.Row(col.Rows() - 1))
+		case *proto.ColUInt16:
+			return uint64(col.Row(col.Rows() - 1))
+		case *proto.ColUInt8:
+			return uint64(col.Row(col.Rows() - 1))
+		case *proto.ColEnum8:
+			return uint64(col.Row(col.Rows() - 1))
+		case *proto.ColDateTime:
+			return uint64(col.Data[col.Rows()-1])
+		}
+		return 0
+	}
+	if bf.batch.columns[columnKey] == nil {
+		return 0
+	}
+	// Not set yet, use the value Finalize() would use.
+	switch reverse(bf, columnKey) {
+	case ColumnTimeReceived:
+		return uint64(bf.TimeReceived)
+	case ColumnSamplingRate:
+		return bf.SamplingRate
+	case ColumnSrcAS:
+		return uint64(bf.SrcAS)
+	case ColumnDstAS:
+		return uint64(bf.DstAS)
+	case ColumnSrcNetMask:
+		return uint64(bf.SrcNetMask)
+	case ColumnDstNetMask:
+		return uint64(bf.DstNetMask)
+	case ColumnSrcVlan:
+		return uint64(bf.SrcVlan)
+	case ColumnDstVlan:
+		return uint64(bf.DstVlan)
+	}
+	return 0
+}
+
+// CurrentIPv6 returns the value of an IPv6 column for the current flow, as it
+// will be stored once finalized. It returns an invalid address when the column
+// is not set or disabled.
+func (bf *FlowMessage) CurrentIPv6(columnKey ColumnKey) netip.Addr {
+	if bf.batch.columnSet.Test(uint(columnKey)) {
+		switch col := bf.batch.columns[columnKey].(type) {
+		case *proto.ColIPv6:
+			return netip.AddrFrom16(col.Row(col.Rows() - 1))
+		case *proto.ColLowCardinality[proto.IPv6]:
+			return netip.AddrFrom16(col.Row(col.Rows() - 1))
+		}
+		return netip.Addr{}
+	}
+	if bf.batch.columns[columnKey] == nil {
+		return netip.Addr{}
+	}
+	// Not set yet, use the value Finalize() would use.
+	var value netip.Addr
+	switch reverse(bf, columnKey) {
+	case ColumnExporterAddress:
+		value = bf.ExporterAddress
+	case ColumnSrcAddr:
+		value = bf.SrcAddr
+	case ColumnDstAddr:
+		value = bf.DstAddr
+	case ColumnNextHop:
+		value = bf.NextHop
+	}
+	if value.IsValid() && anonymizedColumn(columnKey) {
+		value = bf.anonymize(value)
+	}
+	return value
+}
+
+// CurrentInterfaces returns the input and output interface indexes of the
+// current flow, taking its direction into account.
+func (bf *FlowMessage) CurrentInterfaces() (uint32, uint32) {
+	if bf.reversed {
+		return bf.OutIf, bf.InIf
+	}
+	return bf.InIf, bf.OutIf
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestCurrentValues(t *testing.T) {
+	c := NewMock(t)
+	bf := c.NewFlowMessage()
+
+	bf.TimeReceived = 1000
+	bf.SamplingRate = 100
+	bf.ExporterAddress = netip.MustParseAddr("::ffff:203.0.113.14")
+	bf.SrcAddr = netip.MustParseAddr("::ffff:192.0.2.1")
+	bf.DstAddr = netip.MustParseAddr("::ffff:198.51.100.1")
+	bf.SrcAS = 65201
+	bf.DstAS = 65202
+	bf.InIf = 10
+	bf.OutIf = 20
+	bf.AppendUint(ColumnBytes, 1500)
+	bf.AppendUint(ColumnProto, 6)
+	bf.AppendUint(ColumnSrcPort, 443)
+	bf.AppendUint(ColumnDstAS, 65000) // takes precedence over bf.DstAS
+
+	for _, tc := range []struct {
+		key      ColumnKey
+		expected uint64
+	}{
+		{ColumnTimeReceived, 1000},
+		{ColumnSamplingRate, 100},
+		{ColumnBytes, 1500},
+		{ColumnProto, 6},
+		{ColumnSrcPort, 443},
+		{ColumnDstPort, 0},
+		{ColumnSrcAS, 65201},
+		{ColumnDstAS, 65000},
+		{ColumnSrcVlan, 0}, // disabled
+	} {
+		if got := bf.CurrentUint(tc.key); got != tc.expected {
+			t.Errorf("CurrentUint(%s) == %d, expected %d", tc.key, got, tc.expected)
+		}
+	}
+	for _, tc := range []struct {
+		key      ColumnKey
+		expected netip.Addr
+	}{
+		{ColumnExporterAddress, netip.MustParseAddr("::ffff:203.0.113.14")},
+		{ColumnSrcAddr, netip.MustParseAddr("::ffff:192.0.2.1")},
+		{ColumnDstAddr, netip.MustParseAddr("::ffff:198.51.100.1")},
+		{ColumnNextHop, netip.Addr{}}, // disabled
+	} {
+		if got := bf.CurrentIPv6(tc.key); got != tc.expected {
+			t.Errorf("CurrentIPv6(%s) == %s, expected %s", tc.key, got, tc.expected)
+		}
+	}
+	if in, out := bf.CurrentInterfaces(); in != 10 || out != 20 {
+		t.Errorf("CurrentInterfaces() == %d, %d, expected 10, 20", in, out)
+	}
+
+	// After finalizing, values should match the stored ones.
+	bf.Finalize()
+	bf.Reverse()
+	bf.SrcAddr = netip.MustParseAddr("::ffff:192.0.2.1")
+	bf.InIf = 10
+	bf.OutIf = 20
+	bf.AppendUint(ColumnSrcPort, 443)
+	if got := bf.CurrentUint(ColumnDstPort); got != 443 {
+		t.Errorf("CurrentUint(DstPort) == %d, expected 443", got)
+	}
+	if got := bf.CurrentIPv6(ColumnDstAddr); got != netip.MustParseAddr("::ffff:192.0.2.1") {
+		t.Errorf("CurrentIPv6(DstAddr) == %s, expected ::ffff:192.0.2.1", got)
+	}
+	if got := bf.CurrentIPv6(ColumnSrcAddr); got.IsValid() {
+		t.Errorf("CurrentIPv6(SrcAddr) == %s, expected invalid address", got)
+	}
+	if in, out := bf.CurrentInterfaces(); in != 20 || out != 10 {
+		t.Errorf("CurrentInterfaces() == %d, %d, expected 20, 10", in, out)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"akvorado/common/helpers/bimap"
 
@@ -97,6 +98,8 @@ const (
 	ColumnExporterSite
 	ColumnExporterRegion
 	ColumnExporterTenant
+	ColumnObservationDomainID
+	ColumnLostRecords
 	ColumnSrcAddr
 	ColumnDstAddr
 	ColumnSrcNetMask
@@ -147,10 +150,17 @@ const (
 	ColumnDstAddrNAT
 	ColumnSrcPortNAT
 	ColumnDstPortNAT
+	ColumnNATEvent
+	ColumnFirewallEvent
+	ColumnFirewallExtEvent
+	ColumnInACL
+	ColumnOutACL
 	ColumnSrcMAC
 	ColumnDstMAC
 	ColumnIPTTL
 	ColumnIPTos
+	ColumnIPDSCP
+	ColumnIPECN
 	ColumnIPFragmentID
 	ColumnIPFragmentOffset
 	ColumnIPv6FlowLabel
@@ -167,6 +177,13 @@ const (
 	ColumnMPLS2ndLabel
 	ColumnMPLS3rdLabel
 	ColumnMPLS4thLabel
+	ColumnConversationID
+	ColumnDropReason
+	ColumnInIfGroup
+	ColumnOutIfGroup
+	ColumnReceivedSamplingRate
+	ColumnRawBytes
+	ColumnRawPackets
 
 	// ColumnLast points to after the last static column, custom dictionaries
 	// (dynamic columns) come after ColumnLast
@@ -177,6 +194,7 @@ const (
 	ColumnGroupL2 ColumnGroup = iota + 1
 	ColumnGroupNAT
 	ColumnGroupL3L4
+	ColumnGroupFirewall
 
 	ColumnGroupLast
 )
@@ -216,6 +234,40 @@ func flows() Schema {
 			{Key: ColumnExporterSite, ParserType: "string", ClickHouseType: "LowCardinality(String)", ClickHouseNotSortingKey: true},
 			{Key: ColumnExporterRegion, ParserType: "string", ClickHouseType: "LowCardinality(String)", ClickHouseNotSortingKey: true},
 			{Key: ColumnExporterTenant, ParserType: "string", ClickHouseType: "LowCardinality(String)", ClickHouseNotSortingKey: true},
+			{Key: ColumnObservationDomainID, Disabled: true, ParserType: "uint", ClickHouseType: "UInt32", ClickHouseNotSortingKey: true},
+			{
+				Key:                 ColumnLostRecords,
+				Disabled:            true,
+				ParserType:          "uint",
+				ClickHouseType:      "UInt32",
+				ClickHouseMainOnly:  true,
+				ConsoleNotDimension: true,
+			},
+			{
+				Key:                ColumnReceivedSamplingRate,
+				Disabled:           true,
+				ParserType:         "uint",
+				ClickHouseType:     "UInt64",
+				ClickHouseMainOnly: true,
+			},
+			{
+				Key:                 ColumnRawBytes,
+				Disabled:            true,
+				ParserType:          "uint",
+				ClickHouseType:      "UInt64",
+				ClickHouseCodec:     "T64, LZ4",
+				ClickHouseMainOnly:  true,
+				ConsoleNotDimension: true,
+			},
+			{
+				Key:                 ColumnRawPackets,
+				Disabled:            true,
+				ParserType:          "uint",
+				ClickHouseType:      "UInt64",
+				ClickHouseCodec:     "T64, LZ4",
+				ClickHouseMainOnly:  true,
+				ConsoleNotDimension: true,
+			},
 			{
 				Key:                ColumnSrcAddr,
 				ParserType:         "ip",
@@ -459,9 +511,67 @@ END`,
 				ClickHouseType:     "UInt16",
 				ClickHouseMainOnly: true,
 			},
+			{
+				Key:                ColumnNATEvent,
+				Disabled:           true,
+				Group:              ColumnGroupNAT,
+				ParserType:         "uint",
+				ClickHouseType:     "UInt8",
+				ClickHouseMainOnly: true,
+			},
+			{
+				Key:                ColumnFirewallEvent,
+				Disabled:           true,
+				Group:              ColumnGroupFirewall,
+				ParserType:         "uint",
+				ClickHouseType:     "UInt8",
+				ClickHouseMainOnly: true,
+			},
+			{
+				Key:                ColumnFirewallExtEvent,
+				Disabled:           true,
+				Group:              ColumnGroupFirewall,
+				ParserType:         "uint",
+				ClickHouseType:     "UInt16",
+				ClickHouseMainOnly: true,
+			},
+			{
+				Key:                ColumnInACL,
+				Disabled:           true,
+				Group:              ColumnGroupFirewall,
+				ParserType:         "string",
+				ClickHouseType:     "LowCardinality(String)",
+				ClickHouseMainOnly: true,
+			},
+			{
+				Key:                ColumnOutACL,
+				Disabled:           true,
+				Group:              ColumnGroupFirewall,
+				ParserType:         "string",
+				ClickHouseType:     "LowCardinality(String)",
+				ClickHouseMainOnly: true,
+			},
 			{Key: ColumnSrcMAC, Disabled: true, Group: ColumnGroupL2, ClickHouseType: "UInt64"},
 			{Key: ColumnIPTTL, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt8"},
 			{Key: ColumnIPTos, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt8"},
+			{
+				Key:             ColumnIPDSCP,
+				Depends:         []ColumnKey{ColumnIPTos},
+				Disabled:        true,
+				Group:           ColumnGroupL3L4,
+				ParserType:      "uint",
+				ClickHouseType:  "UInt8",
+				ClickHouseAlias: "bitShiftRight(IPTos, 2)",
+			},
+			{
+				Key:             ColumnIPECN,
+				Depends:         []ColumnKey{ColumnIPTos},
+				Disabled:        true,
+				Group:           ColumnGroupL3L4,
+				ParserType:      "uint",
+				ClickHouseType:  "UInt8",
+				ClickHouseAlias: "bitAnd(IPTos, 3)",
+			},
 			{Key: ColumnIPFragmentID, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt32"},
 			{Key: ColumnIPFragmentOffset, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt16"},
 			{Key: ColumnIPv6FlowLabel, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt32"},
@@ -542,6 +652,27 @@ END`,
 				ClickHouseAlias:    "MPLSLabels[4]",
 				ParserType:         "uint",
 			},
+			{
+				Key:                    ColumnConversationID,
+				Disabled:               true,
+				ClickHouseMainOnly:     true,
+				ClickHouseType:         "UInt64",
+				ClickHouseGenerateFrom: clickhouseConversationID(time.Minute),
+				ParserType:             "uint",
+			},
+			{
+				Key:            ColumnDropReason,
+				Disabled:       true,
+				ParserType:     "string",
+				ClickHouseType: "LowCardinality(String)",
+			},
+			{
+				Key:                     ColumnInIfGroup,
+				Disabled:                true,
+				ParserType:              "string",
+				ClickHouseType:          "LowCardinality(String)",
+				ClickHouseNotSortingKey: true,
+			},
 		},
 	}.finalize()
 }
@@ -575,7 +706,11 @@ func (schema Schema) finalize() Schema {
 
 		ncolumns = append(ncolumns, column)
 
-		// Expand the schema Src → Dst and InIf → OutIf
+		// Expand the schema Src → Dst and InIf → OutIf. Dynamic columns are
+		// declared explicitly for each direction.
+		if column.Key >= ColumnLast {
+			continue
+		}
 		alreadyExists := func(name string) bool {
 			key, _ := columnNameMap.LoadKey(name)
 			for _, column := range schema.columns {
@@ -4,6 +4,7 @@
 package schema
 
 import (
+	"slices"
 	"testing"
 
 	"akvorado/common/helpers"
@@ -67,3 +68,33 @@ func TestMarshalUnmarshal(t *testing.T) {
 	interfaceBoundaryMap.TestMarshalUnmarshal(t)
 	columnNameMap.TestMarshalUnmarshal(t)
 }
+
+func TestWithMainOnlyColumns(t *testing.T) {
+	c := NewMock(t)
+	sch, err := c.WithMainOnlyColumns([]ColumnKey{ColumnSrcCountry, ColumnDstCountry})
+	if err != nil {
+		t.Fatalf("WithMainOnlyColumns() error:\n%+v", err)
+	}
+	if column, _ := sch.LookupColumnByKey(ColumnSrcCountry); !column.ClickHouseMainOnly {
+		t.Error("WithMainOnlyColumns(): SrcCountry is not main only")
+	}
+	if column, _ := c.LookupColumnByKey(ColumnSrcCountry); column.ClickHouseMainOnly {
+		t.Error("WithMainOnlyColumns(): original schema was modified")
+	}
+	if slices.Contains(sch.ClickHouseSortingKeys(), "DstCountry") {
+		t.Error("ClickHouseSortingKeys() still contains DstCountry")
+	}
+	if slices.Contains(sch.ClickHouseSelectColumns(ClickHouseSkipMainOnlyColumns), "SrcCountry") {
+		t.Error("ClickHouseSelectColumns() still contains SrcCountry")
+	}
+
+	for _, keys := range [][]ColumnKey{
+		{ColumnBytes},
+		{ColumnExporterAddress},
+		{ColumnPacketSize},
+	} {
+		if _, err := c.WithMainOnlyColumns(keys); err == nil {
+			t.Errorf("WithMainOnlyColumns(%v) did not error", keys)
+		}
+	}
+}
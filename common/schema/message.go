@@ -38,9 +38,11 @@ type FlowMessage struct {
 	// Only for tests
 	OtherColumns map[ColumnKey]any
 
-	reversed bool
-	batch    clickhouseBatch
-	schema   *Schema
+	reversed   bool
+	batch      clickhouseBatch
+	block      *FlowMessage // see ClickHouseProtoBlock()
+	schema     *Schema
+	anonymizer anonymizer
 }
 
 // clickhouseBatch stores columns for efficient streaming. It is embedded
@@ -56,13 +58,16 @@ type clickhouseBatch struct {
 // but the current ClickHouse batch is left untouched.
 func (bf *FlowMessage) reset() {
 	*bf = FlowMessage{
-		batch:  bf.batch,
-		schema: bf.schema,
+		batch:      bf.batch,
+		block:      bf.block,
+		schema:     bf.schema,
+		anonymizer: bf.anonymizer,
 	}
 	bf.batch.columnSet.ClearAll()
 }
 
-// Clear clears all column data.
+// Clear clears all column data. Column buffers keep their capacity and are
+// reused for the next batch.
 func (bf *FlowMessage) Clear() {
 	bf.reset()
 	bf.batch.input.Reset()
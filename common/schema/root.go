@@ -8,8 +8,11 @@ package schema
 
 import (
 	"fmt"
+	"net/netip"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -17,7 +20,8 @@ import (
 
 // Component represents the schema compomenent.
 type Component struct {
-	c Configuration
+	c              Configuration
+	expiredAliases []string
 
 	Schema
 }
@@ -41,6 +45,10 @@ func New(config Configuration) (*Component, error) {
 			}
 		}
 	}
+	schema.anonymization = config.Anonymization
+	if column, ok := schema.LookupColumnByKey(ColumnConversationID); ok && config.ConversationWindow > 0 {
+		column.ClickHouseGenerateFrom = clickhouseConversationID(config.ConversationWindow)
+	}
 	for _, k := range config.Enabled {
 		if column, ok := schema.LookupColumnByKey(k); ok {
 			column.Disabled = false
@@ -84,6 +92,24 @@ func New(config Configuration) (*Component, error) {
 		}
 	}
 
+	// Add custom columns after the static ones. Like custom dictionaries,
+	// they are not referenced in the code.
+	for _, cc := range config.CustomColumns {
+		if slices.ContainsFunc(schema.columns, func(column Column) bool {
+			return column.Name == cc.Name
+		}) {
+			return nil, fmt.Errorf("custom column %q already exists", cc.Name)
+		}
+		column, err := customColumn(cc)
+		if err != nil {
+			return nil, err
+		}
+		column.Key = ColumnLast + schema.dynamicColumns
+		columnNameMap.Insert(column.Key, column.Name)
+		schema.dynamicColumns++
+		schema.columns = append(schema.columns, column)
+	}
+
 	// Add new columns from custom dictionaries after the static ones as we dont
 	// reference the dicts in the code and they are created during runtime from
 	// the config, this is enough for us.
@@ -159,8 +185,99 @@ func New(config Configuration) (*Component, error) {
 
 	schema.columns = append(schema.columns, customDictColumns...)
 
+	// Add column aliases last as they may target any other column.
+	expiredAliases := []string{}
+	today := time.Now().UTC().Format(time.DateOnly)
+	for _, alias := range config.ColumnAliases {
+		if slices.ContainsFunc(schema.columns, func(column Column) bool {
+			return column.Name == alias.Name
+		}) {
+			return nil, fmt.Errorf("column alias %q conflicts with an existing column", alias.Name)
+		}
+		idx := slices.IndexFunc(schema.columns, func(column Column) bool {
+			return column.Name == alias.Column
+		})
+		if idx == -1 {
+			return nil, fmt.Errorf("column alias %q targets unknown column %q", alias.Name, alias.Column)
+		}
+		target := schema.columns[idx]
+		if target.AliasOf != 0 {
+			return nil, fmt.Errorf("column alias %q cannot target another alias", alias.Name)
+		}
+		if alias.Until != "" && alias.Until < today {
+			// Deprecation window ended, the alias should be removed.
+			expiredAliases = append(expiredAliases, alias.Name)
+			continue
+		}
+		key := ColumnLast + schema.dynamicColumns
+		schema.columns = append(schema.columns, Column{
+			Key:                 key,
+			Name:                alias.Name,
+			Disabled:            target.Disabled,
+			Group:               target.Group,
+			Depends:             []ColumnKey{target.Key},
+			AliasOf:             target.Key,
+			ParserType:          target.ParserType,
+			ClickHouseType:      target.ClickHouseType,
+			ClickHouseAlias:     target.Name,
+			ClickHouseMainOnly:  target.ClickHouseMainOnly,
+			ConsoleNotDimension: true,
+		})
+		columnNameMap.Insert(key, alias.Name)
+		schema.dynamicColumns++
+	}
+
 	return &Component{
-		c:      config,
-		Schema: schema.finalize(),
+		c:              config,
+		expiredAliases: expiredAliases,
+		Schema:         schema.finalize(),
 	}, nil
 }
+
+// customColumn turns a custom column from the configuration into a column.
+// When a default value is provided, it is substituted to the zero value in
+// ClickHouse.
+func customColumn(cc CustomColumn) (Column, error) {
+	column := Column{
+		Name:               cc.Name,
+		IPFIXEnterprise:    cc.IPFIXEnterprise,
+		IPFIXField:         cc.IPFIXField,
+		ClickHouseMainOnly: cc.MainTableOnly,
+	}
+	var zero, value string
+	switch cc.Type {
+	case "String":
+		column.ParserType = "string"
+		column.ClickHouseType = "LowCardinality(String)"
+		zero = "''"
+		value = fmt.Sprintf("'%s'", strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(cc.Default))
+	case "IPv6":
+		column.ParserType = "ip"
+		column.ClickHouseType = "IPv6"
+		if cc.Default != "" {
+			addr, err := netip.ParseAddr(cc.Default)
+			if err != nil {
+				return Column{}, fmt.Errorf("invalid default value for custom column %q: %w", cc.Name, err)
+			}
+			zero = "toIPv6('::')"
+			value = fmt.Sprintf("toIPv6('%s')", netip.AddrFrom16(addr.As16()))
+		}
+	default:
+		column.ParserType = "uint"
+		column.ClickHouseType = cc.Type
+		if cc.Default != "" {
+			bits, _ := strconv.Atoi(strings.TrimPrefix(cc.Type, "UInt"))
+			n, err := strconv.ParseUint(cc.Default, 10, bits)
+			if err != nil {
+				return Column{}, fmt.Errorf("invalid default value for custom column %q: %w", cc.Name, err)
+			}
+			zero = "0"
+			value = strconv.FormatUint(n, 10)
+		}
+	}
+	if cc.Default != "" {
+		column.ClickHouseGenerateFrom = fmt.Sprintf("if(%s = %s, %s, %s)", cc.Name, zero, value, cc.Name)
+		column.ClickHouseSelfGenerated = true
+	}
+	return column, nil
+}
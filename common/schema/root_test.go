@@ -5,6 +5,7 @@ package schema_test
 
 import (
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
 	"akvorado/common/schema"
@@ -215,3 +216,170 @@ func TestCustomDictNoKeyErr(t *testing.T) {
 		t.Fatalf("New() did not error correctly\n %s", diff)
 	}
 }
+
+func TestCustomColumns(t *testing.T) {
+	config := schema.DefaultConfiguration()
+	config.CustomColumns = []schema.CustomColumn{
+		{Name: "TenantID", Type: "UInt32", Default: "42", IPFIXEnterprise: 1234, IPFIXField: 1},
+		{Name: "TenantName", Type: "String", Default: "don't know"},
+		{Name: "Collector", Type: "IPv6", Default: "192.0.2.1", MainTableOnly: true},
+		{Name: "Shard", Type: "UInt8"},
+	}
+	s, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	got := []schema.Column{}
+	for _, name := range []string{"TenantID", "TenantName", "Collector", "Shard"} {
+		column, ok := s.LookupColumnByName(name)
+		if !ok {
+			t.Fatalf("LookupColumnByName(%q) not found", name)
+		}
+		// Key is dynamic
+		column.Key = 0
+		got = append(got, *column)
+	}
+	expected := []schema.Column{
+		{
+			Name:                    "TenantID",
+			ParserType:              "uint",
+			IPFIXEnterprise:         1234,
+			IPFIXField:              1,
+			ClickHouseType:          "UInt32",
+			ClickHouseGenerateFrom:  "if(TenantID = 0, 42, TenantID)",
+			ClickHouseSelfGenerated: true,
+		}, {
+			Name:                    "TenantName",
+			ParserType:              "string",
+			ClickHouseType:          "LowCardinality(String)",
+			ClickHouseGenerateFrom:  `if(TenantName = '', 'don\'t know', TenantName)`,
+			ClickHouseSelfGenerated: true,
+		}, {
+			Name:                    "Collector",
+			ParserType:              "ip",
+			ClickHouseType:          "IPv6",
+			ClickHouseGenerateFrom:  "if(Collector = toIPv6('::'), toIPv6('::ffff:192.0.2.1'), Collector)",
+			ClickHouseSelfGenerated: true,
+			ClickHouseMainOnly:      true,
+		}, {
+			Name:           "Shard",
+			ParserType:     "uint",
+			ClickHouseType: "UInt8",
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("New() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestCustomColumnsErrors(t *testing.T) {
+	cases := []struct {
+		Pos    helpers.Pos
+		Column schema.CustomColumn
+	}{
+		{helpers.Mark(), schema.CustomColumn{Name: "SrcAddr", Type: "String"}},
+		{helpers.Mark(), schema.CustomColumn{Name: "TenantID", Type: "UInt8", Default: "256"}},
+		{helpers.Mark(), schema.CustomColumn{Name: "TenantID", Type: "UInt8", Default: "nope"}},
+		{helpers.Mark(), schema.CustomColumn{Name: "Collector", Type: "IPv6", Default: "nope"}},
+	}
+	for _, tc := range cases {
+		config := schema.DefaultConfiguration()
+		config.CustomColumns = []schema.CustomColumn{tc.Column}
+		if _, err := schema.New(config); err == nil {
+			t.Errorf("%sNew() did not error", tc.Pos)
+		}
+	}
+}
+
+func TestColumnAliases(t *testing.T) {
+	config := schema.DefaultConfiguration()
+	config.ColumnAliases = []schema.ColumnAlias{
+		{Name: "Exporter", Column: "ExporterName", Until: "2999-12-31"},
+		{Name: "InIfCircuit", Column: "InIfDescription"},
+		{Name: "Peer", Column: "ExporterAddress", Until: "2000-01-01"},
+	}
+	s, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	column, ok := s.LookupColumnByName("Exporter")
+	if !ok {
+		t.Fatal("LookupColumnByName(\"Exporter\") not found")
+	}
+	got := *column
+	// Key is dynamic
+	got.Key = 0
+	expected := schema.Column{
+		Name:                    "Exporter",
+		Depends:                 []schema.ColumnKey{schema.ColumnExporterName},
+		AliasOf:                 schema.ColumnExporterName,
+		ParserType:              "string",
+		ClickHouseType:          "LowCardinality(String)",
+		ClickHouseAlias:         "ExporterName",
+		ClickHouseNotSortingKey: true,
+		ConsoleNotDimension:     true,
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("LookupColumnByName() (-got, +want):\n%s", diff)
+	}
+	if column, ok := s.LookupColumnByName("InIfCircuit"); !ok {
+		t.Fatal("LookupColumnByName(\"InIfCircuit\") not found")
+	} else if column.ClickHouseAlias != "InIfDescription" {
+		t.Fatalf("InIfCircuit alias is %q", column.ClickHouseAlias)
+	}
+	if _, ok := s.LookupColumnByName("Peer"); ok {
+		t.Fatal("LookupColumnByName(\"Peer\") found an expired alias")
+	}
+	if diff := helpers.Diff(s.ExpiredColumnAliases(), []string{"Peer"}); diff != "" {
+		t.Fatalf("ExpiredColumnAliases() (-got, +want):\n%s", diff)
+	}
+
+	// The alias follows its target in aggregated tables
+	sch, err := s.WithMainOnlyColumns([]schema.ColumnKey{schema.ColumnExporterName})
+	if err != nil {
+		t.Fatalf("WithMainOnlyColumns() error:\n%+v", err)
+	}
+	if column, _ := sch.LookupColumnByName("Exporter"); !column.ClickHouseMainOnly {
+		t.Fatal("Exporter is not main only")
+	}
+}
+
+func TestColumnAliasesErrors(t *testing.T) {
+	cases := []struct {
+		Pos   helpers.Pos
+		Alias schema.ColumnAlias
+	}{
+		{helpers.Mark(), schema.ColumnAlias{Name: "SrcAddr", Column: "DstAddr"}},
+		{helpers.Mark(), schema.ColumnAlias{Name: "Exporter", Column: "Nothing"}},
+	}
+	for _, tc := range cases {
+		config := schema.DefaultConfiguration()
+		config.ColumnAliases = []schema.ColumnAlias{tc.Alias}
+		if _, err := schema.New(config); err == nil {
+			t.Errorf("%sNew() did not error", tc.Pos)
+		}
+	}
+}
+
+func TestConversationWindow(t *testing.T) {
+	config := schema.DefaultConfiguration()
+	config.ConversationWindow = 30 * time.Second
+	config.Enabled = []schema.ColumnKey{schema.ColumnConversationID}
+	s, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	column, ok := s.LookupColumnByKey(schema.ColumnConversationID)
+	if !ok || column.Disabled {
+		t.Fatal("ConversationID not enabled")
+	}
+	expected := "cityHash64(" +
+		"if((SrcAddr, SrcPort) <= (DstAddr, DstPort), " +
+		"(SrcAddr, SrcPort, DstAddr, DstPort), (DstAddr, DstPort, SrcAddr, SrcPort)), " +
+		"Proto, toStartOfInterval(TimeReceived, toIntervalSecond(30)))"
+	if diff := helpers.Diff(column.ClickHouseGenerateFrom, expected); diff != "" {
+		t.Fatalf("ClickHouseGenerateFrom (-got, +want):\n%s", diff)
+	}
+}
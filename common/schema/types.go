@@ -19,6 +19,9 @@ type Schema struct {
 	// For ClickHouse. This is the set of primary keys (order is important and
 	// may not follow column order) for the aggregated tables.
 	clickhousePrimaryKeys []ColumnKey
+
+	// anonymization tells how to anonymize addresses before storing them
+	anonymization AnonymizationConfiguration
 }
 
 // Column represents a column of data.
@@ -29,10 +32,20 @@ type Column struct {
 	NoDisable bool
 	Group     ColumnGroup
 	Depends   []ColumnKey
+	// AliasOf is the column this column is an alternative name for. Such a
+	// column is an ALIAS in ClickHouse and the console uses the target
+	// column instead.
+	AliasOf ColumnKey
 
 	// For parser.
 	ParserType string
 
+	// For decoders. `IPFIXField' is the IPFIX information element (with
+	// `IPFIXEnterprise' as the enterprise number) to decode the column from.
+	// It is only used for custom columns.
+	IPFIXEnterprise uint32
+	IPFIXField      uint16
+
 	// For ClickHouse. `NotSortingKey' is for columns generated from other
 	// columns. It is only useful if not ClickHouseMainOnly and not Alias.
 	// `GenerateFrom' is for a column that's generated from an SQL expression
@@ -122,7 +122,7 @@ func TestRoot(t *testing.T) {
 		dockerClientMock.EXPECT().ServerVersion(gomock.Any(), gomock.Any()).
 			Return(client.ServerVersionResult{}, nil)
 		got := r.RunHealthchecks(context.Background())
-		if diff := helpers.Diff(got.Details["conntrack-fixer"], reporter.HealthcheckResult{
+		if diff := helpers.Diff(got.Details["conntrack-fixer"].HealthcheckResult, reporter.HealthcheckResult{
 			Status: reporter.HealthcheckOK,
 			Reason: "docker client alive",
 		}); diff != "" {
@@ -131,7 +131,7 @@ func TestRoot(t *testing.T) {
 		dockerClientMock.EXPECT().ServerVersion(gomock.Any(), gomock.Any()).
 			Return(client.ServerVersionResult{}, errors.New("unexpected"))
 		got = r.RunHealthchecks(context.Background())
-		if diff := helpers.Diff(got.Details["conntrack-fixer"], reporter.HealthcheckResult{
+		if diff := helpers.Diff(got.Details["conntrack-fixer"].HealthcheckResult, reporter.HealthcheckResult{
 			Status: reporter.HealthcheckWarning,
 			Reason: "docker client unavailable",
 		}); diff != "" {
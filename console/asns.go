@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+	"akvorado/console/database"
+)
+
+// parseASN parses the AS number from the URL.
+func parseASN(gc *gin.Context) (uint32, bool) {
+	asn, err := strconv.ParseUint(gc.Param("asn"), 10, 32)
+	if err != nil || asn == 0 {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "bad AS number format"})
+		return 0, false
+	}
+	return uint32(asn), true
+}
+
+func (c *Component) asnsListHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	names, err := c.d.Database.ListASNNames(ctx)
+	if err != nil {
+		c.r.Err(err).Msg("unable to list AS names")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "unable to list AS names"})
+		return
+	}
+	gc.JSON(http.StatusOK, gin.H{"asns": names})
+}
+
+func (c *Component) asnsSetHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	asn, ok := parseASN(gc)
+	if !ok {
+		return
+	}
+	var name database.ASNName
+	if err := gc.ShouldBindJSON(&name); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	name.ASN = asn
+	name.User = user
+	if err := c.d.Database.SetASNName(ctx, name); err != nil {
+		c.r.Err(err).Msg("cannot set AS name")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "cannot set AS name"})
+		return
+	}
+	gc.JSON(http.StatusNoContent, nil)
+}
+
+func (c *Component) asnsDeleteHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	asn, ok := parseASN(gc)
+	if !ok {
+		return
+	}
+	if err := c.d.Database.DeleteASNName(ctx, asn); errors.Is(err, database.ErrASNNameNotFound) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "AS name not found"})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("cannot delete AS name")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "cannot delete AS name"})
+		return
+	}
+	gc.JSON(http.StatusNoContent, nil)
+}
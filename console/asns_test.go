@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+)
+
+func TestASNsHandlers(t *testing.T) {
+	_, h, _, _ := NewMock(t, DefaultConfiguration())
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "list, no names",
+			URL:         "/api/v0/console/asns",
+			JSONOutput:  gin.H{"asns": []gin.H{}},
+		},
+		{
+			Description: "set one name",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64512",
+			StatusCode:  204,
+			JSONInput:   gin.H{"name": "Lab"},
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "set another name",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64513",
+			Header: func() http.Header {
+				headers := make(http.Header)
+				headers.Add("Remote-User", "alfred")
+				return headers
+			}(),
+			StatusCode:  204,
+			JSONInput:   gin.H{"name": "Datacenter"},
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "update a name",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64512",
+			StatusCode:  204,
+			JSONInput:   gin.H{"name": "Private lab"},
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "set an empty name",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64514",
+			StatusCode:  400,
+			JSONInput:   gin.H{"name": ""},
+			JSONOutput:  gin.H{"message": "Key: 'ASNName.Name' Error:Field validation for 'Name' failed on the 'required' tag"},
+		},
+		{
+			Description: "set an invalid AS number",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/AS64514",
+			StatusCode:  400,
+			JSONInput:   gin.H{"name": "Invalid"},
+			JSONOutput:  gin.H{"message": "bad AS number format"},
+		},
+		{
+			Description: "list names",
+			URL:         "/api/v0/console/asns",
+			JSONOutput: gin.H{"asns": []gin.H{
+				{"asn": 64512, "name": "Private lab", "user": "__default"},
+				{"asn": 64513, "name": "Datacenter", "user": "alfred"},
+			}},
+		},
+		{
+			Description: "delete a name",
+			Method:      "DELETE",
+			URL:         "/api/v0/console/asns/64513",
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "delete a missing name",
+			Method:      "DELETE",
+			URL:         "/api/v0/console/asns/64513",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "AS name not found"},
+		},
+		{
+			Description: "list remaining names",
+			URL:         "/api/v0/console/asns",
+			JSONOutput: gin.H{"asns": []gin.H{
+				{"asn": 64512, "name": "Private lab", "user": "__default"},
+			}},
+		},
+	})
+}
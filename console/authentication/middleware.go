@@ -24,7 +24,7 @@ type UserInformation struct {
 
 // UserAuthentication is a middleware to fill information about the
 // current user. It does not really perform authentication but relies
-// on HTTP headers.
+// on HTTP headers, unless an API token is provided.
 func (c *Component) UserAuthentication() gin.HandlerFunc {
 	var logoutURLTmpl, avatarURLTmpl *template.Template
 	if c.config.LogoutURL != "" {
@@ -35,7 +35,15 @@ func (c *Component) UserAuthentication() gin.HandlerFunc {
 	}
 
 	return func(gc *gin.Context) {
-		var info UserInformation
+		info, authenticated, ok := c.tokenAuthentication(gc)
+		if !ok {
+			return
+		}
+		if authenticated {
+			gc.Set("user", info)
+			gc.Next()
+			return
+		}
 		if err := gc.ShouldBindWith(&info, customHeaderBinding{c}); err != nil {
 			if c.config.DefaultUser.Login == "" {
 				gc.JSON(http.StatusUnauthorized, gin.H{"message": "No user logged in."})
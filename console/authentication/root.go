@@ -10,6 +10,8 @@ import "akvorado/common/reporter"
 type Component struct {
 	r      *reporter.Reporter
 	config Configuration
+
+	tokenValidator TokenValidator
 }
 
 // New creates a new authentication component.
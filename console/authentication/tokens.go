@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenInformation contains the scope of the API token used to authenticate
+// the current request.
+type TokenInformation struct {
+	// ReadOnly tells if the token cannot modify anything.
+	ReadOnly bool
+	// Endpoints are the endpoints the token can access. When empty, all
+	// endpoints are accessible.
+	Endpoints []string
+}
+
+// ErrInvalidToken is returned by a token validator when the provided token
+// does not exist or is expired.
+var ErrInvalidToken = errors.New("invalid API token")
+
+// TokenValidator checks an API token and returns the associated user and
+// scope.
+type TokenValidator func(ctx context.Context, token string) (UserInformation, TokenInformation, error)
+
+// RegisterTokenValidator registers the function to use to check API tokens
+// provided with the "Authorization: Bearer" header. Without it, this header
+// is ignored.
+func (c *Component) RegisterTokenValidator(validator TokenValidator) {
+	c.tokenValidator = validator
+}
+
+// tokenAuthentication authenticates the request with an API token, if any. The
+// second returned value tells if a token was provided and the third one if the
+// request can go further.
+func (c *Component) tokenAuthentication(gc *gin.Context) (UserInformation, bool, bool) {
+	if c.tokenValidator == nil {
+		return UserInformation{}, false, true
+	}
+	token, ok := strings.CutPrefix(gc.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return UserInformation{}, false, true
+	}
+	info, scope, err := c.tokenValidator(gc.Request.Context(), strings.TrimSpace(token))
+	if errors.Is(err, ErrInvalidToken) {
+		gc.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid API token."})
+		gc.Abort()
+		return UserInformation{}, true, false
+	} else if err != nil {
+		c.r.Err(err).Msg("cannot check API token")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot check API token."})
+		gc.Abort()
+		return UserInformation{}, true, false
+	}
+	if len(scope.Endpoints) > 0 && !slices.ContainsFunc(scope.Endpoints, func(endpoint string) bool {
+		endpoint = strings.TrimSuffix(endpoint, "/")
+		return gc.FullPath() == endpoint || strings.HasPrefix(gc.FullPath(), endpoint+"/")
+	}) {
+		gc.JSON(http.StatusForbidden, gin.H{"message": "API token not allowed for this endpoint."})
+		gc.Abort()
+		return UserInformation{}, true, false
+	}
+	gc.Set("token", scope)
+	return info, true, true
+}
+
+// RequireWrite is a middleware rejecting requests authenticated with a
+// read-only API token.
+func (c *Component) RequireWrite() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if token, ok := gc.Get("token"); ok && token.(TokenInformation).ReadOnly {
+			gc.JSON(http.StatusForbidden, gin.H{"message": "API token is read-only."})
+			gc.Abort()
+			return
+		}
+		gc.Next()
+	}
+}
+
+// RequireNoToken is a middleware rejecting requests authenticated with an API
+// token.
+func (c *Component) RequireNoToken() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if _, ok := gc.Get("token"); ok {
+			gc.JSON(http.StatusForbidden, gin.H{"message": "Not allowed with an API token."})
+			gc.Abort()
+			return
+		}
+		gc.Next()
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
 	"time"
 
+	"akvorado/common/schema"
 	"akvorado/console/query"
 )
 
@@ -20,11 +22,26 @@ type flowsTable struct {
 	Name       string
 	Resolution time.Duration
 	Oldest     time.Time
+	Columns    []string // nil when unknown
+}
+
+// hasColumns tells if the table contains all the provided columns.
+func (table flowsTable) hasColumns(keys []schema.ColumnKey) bool {
+	if table.Columns == nil {
+		return true
+	}
+	for _, key := range keys {
+		if !slices.Contains(table.Columns, key.String()) {
+			return false
+		}
+	}
+	return true
 }
 
 // refreshFlowsTables refreshes the information we have about flows
 // tables (live one and consolidated ones). This information includes
-// the consolidation interval and the oldest available data.
+// the consolidation interval, the oldest available data and the available
+// columns.
 func (c *Component) refreshFlowsTables() error {
 	ctx := c.t.Context(nil)
 	var tables []struct {
@@ -42,6 +59,24 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 	if err != nil {
 		return fmt.Errorf("cannot query flows table metadata: %w", err)
 	}
+	var columns []struct {
+		Table string `ch:"table"`
+		Name  string `ch:"name"`
+	}
+	err = c.d.ClickHouseDB.Select(ctx, &columns, `
+SELECT table, name
+FROM system.columns
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+AND table NOT LIKE '%_local'
+`)
+	if err != nil {
+		return fmt.Errorf("cannot query flows columns metadata: %w", err)
+	}
+	tableColumns := map[string][]string{}
+	for _, column := range columns {
+		tableColumns[column.Table] = append(tableColumns[column.Table], column.Name)
+	}
 
 	newFlowsTables := []flowsTable{}
 	for _, table := range tables {
@@ -69,6 +104,7 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 			Name:       table.Name,
 			Resolution: resolution,
 			Oldest:     oldest[0].T,
+			Columns:    tableColumns[table.Name],
 		})
 	}
 	if len(newFlowsTables) == 0 {
@@ -87,6 +123,7 @@ type inputContext struct {
 	End                    time.Time
 	StartForTableSelection *time.Time
 	MainTableRequired      bool
+	RequiredColumns        []schema.ColumnKey
 	Points                 uint
 	Units                  string
 }
@@ -163,6 +200,10 @@ func (c *Component) finalizeTemplateQuery(query templateQuery) string {
 	switch input.Units {
 	case "pps":
 		units = `SUM(Packets*SamplingRate)`
+	case "fps":
+		// Flow records received by the outlet. They are not scaled by the
+		// sampling rate. Only the main table keeps individual flows.
+		units = `COUNT(*)`
 	case "l3bps":
 		units = `SUM(Bytes*SamplingRate*8)`
 	case "l2bps":
@@ -217,30 +258,49 @@ func (c *Component) computeTableAndInterval(input inputContext) (string, time.Du
 
 	// Select table
 	targetIntervalForTableSelection := targetInterval
-	if input.MainTableRequired {
+	if input.MainTableRequired || input.Units == "fps" {
 		return "flows", time.Second, targetInterval
 	}
 	startForTableSelection := input.Start
 	if input.StartForTableSelection != nil {
 		startForTableSelection = *input.StartForTableSelection
 	}
-	table, computedInterval := c.getBestTable(startForTableSelection, targetIntervalForTableSelection)
+	columns := input.RequiredColumns
+	switch input.Units {
+	case "inl2%":
+		columns = append(slices.Clone(columns), schema.ColumnInIfSpeed)
+	case "outl2%":
+		columns = append(slices.Clone(columns), schema.ColumnOutIfSpeed)
+	}
+	// Exclude tables whose resolution is too fine for the time range
+	var minResolution time.Duration
+	if c.config.Guardrails.MaxIntervals > 0 {
+		minResolution = input.End.Sub(input.Start) / time.Duration(c.config.Guardrails.MaxIntervals)
+	}
+	table, computedInterval := c.getBestTable(startForTableSelection, targetIntervalForTableSelection, minResolution, columns)
 	return table, computedInterval, targetInterval
 }
 
-// Get the best table starting at the specified time.
-func (c *Component) getBestTable(start time.Time, targetInterval time.Duration) (string, time.Duration) {
+// Get the best table starting at the specified time, with a resolution at least
+// equal to the provided one, and containing the provided columns.
+func (c *Component) getBestTable(start time.Time, targetInterval, minResolution time.Duration, columns []schema.ColumnKey) (string, time.Duration) {
 	c.flowsTablesLock.RLock()
-	defer c.flowsTablesLock.RUnlock()
+	tables := []flowsTable{}
+	for _, table := range c.flowsTables {
+		if table.hasColumns(columns) && max(table.Resolution, time.Second) >= minResolution {
+			tables = append(tables, table)
+		}
+	}
+	c.flowsTablesLock.RUnlock()
 
 	table := "flows"
 	computedInterval := time.Second
-	if len(c.flowsTables) > 0 {
+	if len(tables) > 0 {
 		// We can use the consolidated data. The first
 		// criteria is to find the tables matching the time
 		// criteria.
 		candidates := []int{}
-		for idx, table := range c.flowsTables {
+		for idx, table := range tables {
 			if start.After(table.Oldest.Add(table.Resolution)) {
 				candidates = append(candidates, idx)
 			}
@@ -248,35 +308,35 @@ func (c *Component) getBestTable(start time.Time, targetInterval time.Duration)
 		if len(candidates) == 0 {
 			// No candidate, fallback to the one with oldest data
 			best := 0
-			for idx, table := range c.flowsTables {
-				if c.flowsTables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
+			for idx, table := range tables {
+				if tables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
 					best = idx
 				}
 			}
 			candidates = []int{best}
 			// Add other candidates that are not far off in term of oldest data
-			for idx, table := range c.flowsTables {
+			for idx, table := range tables {
 				if idx == best {
 					continue
 				}
-				if c.flowsTables[best].Oldest.After(table.Oldest) {
+				if tables[best].Oldest.After(table.Oldest) {
 					candidates = append(candidates, idx)
 				}
 			}
 		}
 		sort.Slice(candidates, func(i, j int) bool {
-			return c.flowsTables[candidates[i]].Resolution < c.flowsTables[candidates[j]].Resolution
+			return tables[candidates[i]].Resolution < tables[candidates[j]].Resolution
 		})
 		// If possible, use the first resolution before the target interval
 		for len(candidates) > 1 {
-			if c.flowsTables[candidates[1]].Resolution <= targetInterval {
+			if tables[candidates[1]].Resolution <= targetInterval {
 				candidates = candidates[1:]
 			} else {
 				break
 			}
 		}
-		table = c.flowsTables[candidates[0]].Name
-		computedInterval = c.flowsTables[candidates[0]].Resolution
+		table = tables[candidates[0]].Name
+		computedInterval = tables[candidates[0]].Resolution
 	}
 	if computedInterval < time.Second {
 		computedInterval = time.Second
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/schema"
 
 	"go.uber.org/mock/gomock"
 )
@@ -33,6 +34,23 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 			{"flows_1m0s"},
 			{"flows_5m0s"},
 		})
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT table, name
+FROM system.columns
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+AND table NOT LIKE '%_local'
+`).
+		Return(nil).
+		SetArg(1, []struct {
+			Table string `ch:"table"`
+			Name  string `ch:"name"`
+		}{
+			{"flows", "SrcAddr"},
+			{"flows", "SrcCountry"},
+			{"flows_1m0s", "SrcCountry"},
+		})
 	mockConn.EXPECT().
 		Select(gomock.Any(), gomock.Any(), `SELECT MIN(TimeReceived) AS t FROM flows`).
 		Return(nil).
@@ -62,10 +80,10 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 	}
 
 	expected := []flowsTable{
-		{"flows", time.Duration(0), time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC)},
-		{"flows_1h0m0s", time.Hour, time.Date(2022, 1, 10, 15, 45, 10, 0, time.UTC)},
-		{"flows_1m0s", time.Minute, time.Date(2022, 4, 20, 15, 45, 10, 0, time.UTC)},
-		{"flows_5m0s", 5 * time.Minute, time.Date(2022, 2, 10, 15, 45, 10, 0, time.UTC)},
+		{"flows", time.Duration(0), time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC), []string{"SrcAddr", "SrcCountry"}},
+		{"flows_1h0m0s", time.Hour, time.Date(2022, 1, 10, 15, 45, 10, 0, time.UTC), nil},
+		{"flows_1m0s", time.Minute, time.Date(2022, 4, 20, 15, 45, 10, 0, time.UTC), []string{"SrcCountry"}},
+		{"flows_5m0s", 5 * time.Minute, time.Date(2022, 2, 10, 15, 45, 10, 0, time.UTC), nil},
 	}
 	if diff := helpers.Diff(c.flowsTables, expected); diff != "" {
 		t.Fatalf("refreshFlowsTables() diff:\n%s", diff)
@@ -99,9 +117,23 @@ func TestFinalizeQuery(t *testing.T) {
 				Points:            86400,
 			},
 			Expected: "SELECT TimeReceived, SrcPort FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC')",
+		}, {
+			Description: "flow rate requires the main table",
+			Tables: []flowsTable{
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+			},
+			Query: "SELECT {{ .Units }}/{{ .Interval }} FROM {{ .Table }} WHERE {{ .Timefilter }}",
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 720,
+				Units:  "fps",
+			},
+			Expected: "SELECT COUNT(*)/120 FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC')",
 		}, {
 			Description: "only flows table available",
-			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)}},
+			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC), nil}},
 			Query:       "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -111,7 +143,7 @@ func TestFinalizeQuery(t *testing.T) {
 			Expected: "SELECT 1 FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC')",
 		}, {
 			Description: "timefilter.Start and timefilter.Stop",
-			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)}},
+			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC), nil}},
 			Query:       "SELECT {{ .TimefilterStart }}, {{ .TimefilterEnd }}",
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -121,7 +153,7 @@ func TestFinalizeQuery(t *testing.T) {
 			Expected: "SELECT toDateTime('2022-04-10 15:45:10', 'UTC'), toDateTime('2022-04-11 15:45:10', 'UTC')",
 		}, {
 			Description: "only flows table and out of range request",
-			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC)}},
+			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC), nil}},
 			Query:       "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -132,8 +164,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select consolidated table",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .Interval }}",
 			Context: inputContext{
@@ -145,8 +177,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select consolidated table out of range",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 17, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 17, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
@@ -158,8 +190,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select flows table out of range",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 17, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 17, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
@@ -171,8 +203,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "use flows table for resolution (control for next case)",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 10, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 10, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 10, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 10, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .Interval }}",
 			Context: inputContext{
@@ -184,8 +216,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "use flows table for resolution and for data",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 10, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 10, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 10, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 10, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .Interval }}",
 			Context: inputContext{
@@ -201,8 +233,8 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select flows table with better resolution",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 16, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 17, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 16, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 17, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .Interval }}",
 			Context: inputContext{
@@ -214,9 +246,9 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select consolidated table with better resolution",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .Interval }}",
 			Context: inputContext{
@@ -228,9 +260,9 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select consolidated table with better range",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
@@ -242,9 +274,9 @@ func TestFinalizeQuery(t *testing.T) {
 		}, {
 			Description: "select best resolution when equality for oldest data",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 22, 40, 55, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 22, 40, 0, 0, time.UTC)},
-				{"flows_1h0m0s", time.Hour, time.Date(2022, 4, 10, 22, 0, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 22, 40, 55, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 10, 22, 40, 0, 0, time.UTC), nil},
+				{"flows_1h0m0s", time.Hour, time.Date(2022, 4, 10, 22, 0, 10, 0, time.UTC), nil},
 			},
 			Query: "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}",
 			Context: inputContext{
@@ -275,10 +307,10 @@ func TestFinalizeQuery(t *testing.T) {
 			Description: "Small interval outside main table expiration",
 			Query:       "SELECT InIfProvider FROM {{ .Table }}",
 			Tables: []flowsTable{
-				{"flows", time.Duration(0), time.Date(2022, 11, 6, 12, 0, 0, 0, time.UTC)},
-				{"flows_1h0m0s", time.Hour, time.Date(2022, 4, 25, 18, 0, 0, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 11, 14, 12, 0, 0, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 8, 23, 12, 0, 0, 0, time.UTC)},
+				{"flows", time.Duration(0), time.Date(2022, 11, 6, 12, 0, 0, 0, time.UTC), nil},
+				{"flows_1h0m0s", time.Hour, time.Date(2022, 4, 25, 18, 0, 0, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 11, 14, 12, 0, 0, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 8, 23, 12, 0, 0, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 10, 30, 1, 0, 0, 0, time.UTC),
@@ -330,7 +362,7 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
 		}, {
 			Description: "only flows table available, out of range",
-			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC)}},
+			Tables:      []flowsTable{{"flows", 0, time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC), nil}},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 8, 15, 45, 10, 0, time.UTC),
 				End:    time.Date(2022, 4, 9, 15, 45, 10, 0, time.UTC),
@@ -340,9 +372,9 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "consolidated table with better resolution",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -353,9 +385,9 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "consolidated table available, but main required",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:             time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -364,12 +396,26 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 				MainTableRequired: true,
 			},
 			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
+		}, {
+			Description: "consolidated table available, but missing a column",
+			Tables: []flowsTable{
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), []string{"SrcCountry"}},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), []string{"DstCountry"}},
+			},
+			Context: inputContext{
+				Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points:          288, // 5-minute resolution,
+				RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry},
+			},
+			Expected: tableIntervalOutput{Table: "flows_1m0s", Interval: 60},
 		}, {
 			Description: "consolidated table available, but out of range",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -380,9 +426,9 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "consolidated table available, main table required, out of range",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 20, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:             time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -403,7 +449,7 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "target interval smaller than 1 second",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -414,9 +460,9 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "multiple tables with same resolution, choose oldest data",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 10, 12, 45, 10, 0, time.UTC)},
-				{"flows_1m0s_a", time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
-				{"flows_1m0s_b", time.Minute, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 10, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s_a", time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s_b", time.Minute, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -427,10 +473,10 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "choose best resolution below target interval",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC)},
-				{"flows_10s", 10 * time.Second, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
-				{"flows_30s", 30 * time.Second, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
-				{"flows_2m0s", 2 * time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_10s", 10 * time.Second, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_30s", 30 * time.Second, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_2m0s", 2 * time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -441,9 +487,9 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "all tables out of range, choose table with oldest data",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 15, 12, 45, 10, 0, time.UTC)},
-				{"flows_1m0s", time.Minute, time.Date(2022, 4, 14, 12, 45, 10, 0, time.UTC)},
-				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 12, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 15, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 14, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 12, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -454,8 +500,8 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "resolution exactly matches target interval",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC)},
-				{"flows_2m0s", 2 * time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_2m0s", 2 * time.Minute, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -466,8 +512,8 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		}, {
 			Description: "sub-second resolution gets clamped to 1 second",
 			Tables: []flowsTable{
-				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC)},
-				{"flows_100ms", 100 * time.Millisecond, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC)},
+				{"flows", 0, time.Date(2022, 4, 8, 12, 45, 10, 0, time.UTC), nil},
+				{"flows_100ms", 100 * time.Millisecond, time.Date(2022, 4, 9, 12, 45, 10, 0, time.UTC), nil},
 			},
 			Context: inputContext{
 				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
@@ -5,9 +5,12 @@ package console
 
 import (
 	"net/http"
+	"slices"
 	"time"
 
+	"akvorado/common/clickhousedb"
 	"akvorado/common/helpers"
+	"akvorado/console/authentication"
 	"akvorado/console/query"
 
 	"github.com/gin-gonic/gin"
@@ -25,12 +28,131 @@ type Configuration struct {
 	HomepageGraphFilter string
 	// HomepageGraphTimeRange defines the time range to use for the homepage graph
 	HomepageGraphTimeRange time.Duration `validate:"min=1m"`
+	// HomepageGraphRefresh defines how often the latest point of the homepage
+	// graph is streamed to the clients
+	HomepageGraphRefresh time.Duration `validate:"min=1s"`
+	// HomepageForecast defines the capacity forecast for the homepage
+	HomepageForecast HomepageForecastConfiguration
 	// DimensionsLimit put an upper limit to the number of dimensions to return.
 	DimensionsLimit int `validate:"min=10"`
 	// Branding enables some branding on the console
 	Branding bool
 	// CacheTTL tells how long to keep the most costly requests in cache.
 	CacheTTL time.Duration `validate:"min=5s"`
+	// Guardrails defines limits for queries sent to ClickHouse.
+	Guardrails GuardrailsConfiguration
+	// Export defines limits for exports of visualizations.
+	Export ExportConfiguration
+	// Operations defines the services to scrape for the operations page.
+	Operations OperationsConfiguration
+	// TrafficMetrics defines traffic metrics periodically evaluated and
+	// exported to Prometheus.
+	TrafficMetrics TrafficMetricsConfiguration
+	// Parity defines a second ClickHouse destination to compare with the
+	// main one.
+	Parity ParityConfiguration
+	// CrossCheck defines how flows are compared with the interface counters.
+	CrossCheck CrossCheckConfiguration
+}
+
+// CrossCheckConfiguration defines how the flows are compared with the
+// interface counters polled by the metadata providers.
+type CrossCheckConfiguration struct {
+	// Window is the time range used to compare flows and counters
+	Window time.Duration `validate:"min=1h"`
+	// Tolerance is the relative difference above which an interface is
+	// reported
+	Tolerance float64 `validate:"min=0,max=1"`
+}
+
+// ParityConfiguration defines a second ClickHouse destination to compare with
+// the main one, for example to validate a dual-write migration.
+type ParityConfiguration struct {
+	// Enabled tells if the parity page is enabled
+	Enabled bool
+	// ClickHouse defines how to connect to the second destination
+	ClickHouse clickhousedb.Configuration
+	// Tolerance is the relative difference above which a time bucket is
+	// reported as a mismatch
+	Tolerance float64 `validate:"min=0,max=1"`
+	// AllowedUsers is the list of logins allowed to use the parity page
+	AllowedUsers []string
+}
+
+// TrafficMetricsConfiguration defines traffic metrics periodically evaluated
+// and exported to Prometheus.
+type TrafficMetricsConfiguration struct {
+	// Interval is the interval between two evaluations
+	Interval time.Duration `validate:"min=1m"`
+	// Window is the time range used to compute the traffic rates
+	Window time.Duration `validate:"min=1m"`
+	// BaselineOffset is how far in the past the time range used to compute
+	// the baseline is (0 to disable the baseline)
+	BaselineOffset time.Duration `validate:"omitempty,min=1h"`
+	// Metrics is the list of traffic metrics to evaluate
+	Metrics []TrafficMetricConfiguration `validate:"unique=Name,dive"`
+}
+
+// TrafficMetricConfiguration defines a traffic metric.
+type TrafficMetricConfiguration struct {
+	// Name is the name of the metric, used as a label
+	Name string `validate:"required"`
+	// Filter is the filter to apply to flows
+	Filter query.Filter
+	// Dimensions are the dimensions to group flows by
+	Dimensions []query.Column `validate:"min=1"`
+	// Limit is the maximum number of series to export (10 when 0)
+	Limit int `validate:"min=0,max=1000"`
+}
+
+// OperationsConfiguration defines the services whose metrics are scraped to
+// summarize the health of the pipeline.
+type OperationsConfiguration struct {
+	// Inlet is the list of base URLs of the inlet services
+	Inlet []string `validate:"dive,url"`
+	// Outlet is the list of base URLs of the outlet services
+	Outlet []string `validate:"dive,url"`
+	// Orchestrator is the list of base URLs of the orchestrator services
+	Orchestrator []string `validate:"dive,url"`
+	// Timeout is the maximum time to scrape the metrics of a service
+	Timeout time.Duration `validate:"min=100ms"`
+}
+
+// GuardrailsConfiguration defines limits for queries sent to ClickHouse. A
+// zero value disables the associated limit.
+type GuardrailsConfiguration struct {
+	// MaxIntervals is the maximum number of intervals of the selected table
+	// a graph can span. It limits the time range depending on the resolution.
+	MaxIntervals uint64
+	// MaxRowsToRead is the maximum number of rows a query can read.
+	MaxRowsToRead uint64
+	// QueryTimeout is the maximum duration of a request.
+	QueryTimeout time.Duration `validate:"omitempty,min=1s"`
+}
+
+// ExportConfiguration defines limits for exports of visualizations.
+type ExportConfiguration struct {
+	// MaxRows is the maximum number of rows of an export.
+	MaxRows uint64 `validate:"min=1"`
+	// UserMaxRows overrides the maximum number of rows for some users, using
+	// their login.
+	UserMaxRows map[string]uint64
+}
+
+// HomepageForecastConfiguration defines the capacity forecast displayed on the
+// homepage.
+type HomepageForecastConfiguration struct {
+	// Filter is the filtering string selecting the traffic to forecast
+	Filter string
+	// Capacity is the capacity threshold in bits per second. The forecast is
+	// not displayed when 0.
+	Capacity float64 `validate:"min=0"`
+	// History is the time range used to fit the trend
+	History time.Duration `validate:"min=24h"`
+	// Horizon is how far to project the trend
+	Horizon time.Duration `validate:"min=24h"`
+	// Seasonal tells if a weekly seasonal component should be added to the trend
+	Seasonal bool
 }
 
 // HomepageTopWidget represents a top widget on the homepage.
@@ -77,6 +199,8 @@ type VisualizeOptionsConfiguration struct {
 	Bidirectional bool `json:"bidirectional"`
 	// PreviousPeriod tells if a graph should display the previous period (for stacked)
 	PreviousPeriod bool `json:"previousPeriod"`
+	// RangeStats tells if statistics over the whole range should be computed (all except sankey)
+	RangeStats bool `json:"rangeStats"`
 }
 
 // DefaultConfiguration represents the default configuration for the console component.
@@ -102,6 +226,33 @@ func DefaultConfiguration() Configuration {
 		CacheTTL:               3 * time.Hour,
 		HomepageGraphFilter:    "InIfBoundary = 'external'",
 		HomepageGraphTimeRange: 24 * time.Hour,
+		HomepageGraphRefresh:   time.Minute,
+		HomepageForecast: HomepageForecastConfiguration{
+			Filter:   "InIfBoundary = 'external'",
+			History:  30 * 24 * time.Hour,
+			Horizon:  180 * 24 * time.Hour,
+			Seasonal: true,
+		},
+		Export: ExportConfiguration{
+			MaxRows:     100_000,
+			UserMaxRows: map[string]uint64{},
+		},
+		Operations: OperationsConfiguration{
+			Timeout: 2 * time.Second,
+		},
+		TrafficMetrics: TrafficMetricsConfiguration{
+			Interval:       time.Minute,
+			Window:         5 * time.Minute,
+			BaselineOffset: 7 * 24 * time.Hour,
+		},
+		Parity: ParityConfiguration{
+			ClickHouse: clickhousedb.DefaultConfiguration(),
+			Tolerance:  0.01,
+		},
+		CrossCheck: CrossCheckConfiguration{
+			Window:    6 * time.Hour,
+			Tolerance: 0.2,
+		},
 	}
 }
 
@@ -124,6 +275,17 @@ func (c *Component) configHandlerFunc(gc *gin.Context) {
 		"dimensions":              dimensions,
 		"truncatable":             truncatable,
 		"homepageTopWidgets":      c.config.HomepageTopWidgets,
-		"branding":                c.config.Branding,
+		"homepageForecast": gin.H{
+			"filter":   c.config.HomepageForecast.Filter,
+			"capacity": c.config.HomepageForecast.Capacity,
+			"history":  c.config.HomepageForecast.History.Seconds(),
+			"horizon":  c.config.HomepageForecast.Horizon.Seconds(),
+			"seasonal": c.config.HomepageForecast.Seasonal,
+		},
+		"branding": c.config.Branding,
+		"operations": len(c.config.Operations.Inlet)+len(c.config.Operations.Outlet)+
+			len(c.config.Operations.Orchestrator) > 0,
+		"parity": c.config.Parity.Enabled && slices.Contains(c.config.Parity.AllowedUsers,
+			gc.MustGet("user").(authentication.UserInformation).Login),
 	})
 }
@@ -29,9 +29,17 @@ func TestConfigHandler(t *testing.T) {
 					"limitType":      "avg",
 					"bidirectional":  false,
 					"previousPeriod": false,
+					"rangeStats":     false,
 				},
 				"homepageTopWidgets": []string{"src-as", "src-port", "protocol", "src-country", "etype"},
-				"dimensionsLimit":    50,
+				"homepageForecast": gin.H{
+					"filter":   "InIfBoundary = 'external'",
+					"capacity": 0,
+					"history":  2592000,
+					"horizon":  15552000,
+					"seasonal": true,
+				},
+				"dimensionsLimit": 50,
 				"dimensions": []string{
 					"ExporterAddress",
 					"ExporterName",
@@ -88,6 +96,8 @@ func TestConfigHandler(t *testing.T) {
 				},
 				"truncatable": []string{"SrcAddr", "DstAddr"},
 				"branding":    false,
+				"operations":  false,
+				"parity":      false,
 			},
 		},
 	})
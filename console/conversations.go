@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/console/query"
+)
+
+// conversationsHandlerInput describes the input for the /widget/conversations
+// endpoint.
+type conversationsHandlerInput struct {
+	schema *schema.Component
+	Start  time.Time    `json:"start" binding:"required"`
+	End    time.Time    `json:"end" binding:"required,gtfield=Start"`
+	Filter query.Filter `json:"filter"`                // where ...
+	Ports  bool         `json:"ports"`                 // expand protocol and ports
+	Limit  int          `json:"limit" binding:"min=1"` // number of conversations
+}
+
+// conversationsHandlerOutput describes the output for the
+// /widget/conversations endpoint.
+type conversationsHandlerOutput struct {
+	Dimensions    []query.Column `json:"dimensions"`
+	Conversations []conversation `json:"conversations"`
+}
+
+// conversation is one row of the top conversations.
+type conversation struct {
+	Values []string `json:"values"`
+	Bps    float64  `json:"bps"`
+	Pps    float64  `json:"pps"`
+	Filter string   `json:"filter"` // filter expression selecting this conversation
+}
+
+// dimensions returns the columns defining a conversation.
+func (input conversationsHandlerInput) dimensions() []query.Column {
+	dimensions := []query.Column{
+		query.NewColumn("SrcAddr"),
+		query.NewColumn("DstAddr"),
+	}
+	if input.Ports {
+		dimensions = append(dimensions,
+			query.NewColumn("Proto"),
+			query.NewColumn("SrcPort"),
+			query.NewColumn("DstPort"))
+	}
+	return dimensions
+}
+
+// toSQL converts a conversations input to an SQL request. The provided
+// dimensions should have been validated.
+func (input conversationsHandlerInput) toSQL(dimensions []query.Column) templateQuery {
+	selectFields := make([]string, len(dimensions))
+	groupFields := make([]string, len(dimensions))
+	for idx, column := range dimensions {
+		selectFields[idx] = column.ToSQLSelect(input.schema)
+		groupFields[idx] = column.String()
+	}
+	seconds := max(int64(input.End.Sub(input.Start).Seconds()), 1)
+	template := fmt.Sprintf(`
+SELECT
+ [%s] AS dimensions,
+ SUM(Bytes*SamplingRate*8)/%d AS bps,
+ SUM(Packets*SamplingRate)/%d AS pps
+FROM {{ .Table }}
+WHERE %s
+GROUP BY %s
+ORDER BY bps DESC
+LIMIT %d`,
+		strings.Join(selectFields, ", "),
+		seconds, seconds,
+		templateWhere(input.Filter),
+		strings.Join(groupFields, ", "),
+		input.Limit)
+	return templateQuery{
+		Template: strings.TrimSpace(template),
+		Context: inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: true,
+			RequiredColumns:   requiredColumns(dimensions, input.Filter),
+			Points:            1,
+		},
+	}
+}
+
+func (c *Component) widgetConversationsHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input := conversationsHandlerInput{schema: c.d.Schema}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if input.Limit > c.config.DimensionsLimit {
+		gc.JSON(http.StatusBadRequest,
+			gin.H{"message": fmt.Sprintf("Limit is set beyond maximum value (%d)",
+				c.config.DimensionsLimit)})
+		return
+	}
+	dimensions := input.dimensions()
+	if err := query.Columns(dimensions).Validate(input.schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
+	queries := []templateQuery{input.toSQL(dimensions)}
+	if err := c.checkGuardrails(queries); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	sqlQuery := c.finalizeTemplateQueries(queries)
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+
+	results := []struct {
+		Dimensions []string `ch:"dimensions"`
+		Bps        float64  `ch:"bps"`
+		Pps        float64  `ch:"pps"`
+	}{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+		c.queryError(gc, err, sqlQuery)
+		return
+	}
+
+	output := conversationsHandlerOutput{
+		Dimensions:    dimensions,
+		Conversations: make([]conversation, 0, len(results)),
+	}
+	for _, result := range results {
+		// Build the filter to pivot to the flows of this conversation.
+		// Values that cannot be expressed are skipped.
+		expressions := []string{}
+		for idx, column := range dimensions {
+			if idx >= len(result.Dimensions) {
+				break
+			}
+			if expression, ok := column.ToFilterExpression(input.schema, result.Dimensions[idx]); ok {
+				expressions = append(expressions, expression)
+			}
+		}
+		output.Conversations = append(output.Conversations, conversation{
+			Values: result.Dimensions,
+			Bps:    result.Bps,
+			Pps:    result.Pps,
+			Filter: strings.Join(expressions, " AND "),
+		})
+	}
+	gc.JSON(http.StatusOK, output)
+}
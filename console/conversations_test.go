@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/console/query"
+)
+
+func TestConversationsQuerySQL(t *testing.T) {
+	cases := []struct {
+		Description string
+		Pos         helpers.Pos
+		Input       conversationsHandlerInput
+		Expected    templateQuery
+	}{
+		{
+			Description: "ports collapsed, no filter",
+			Pos:         helpers.Mark(),
+			Input: conversationsHandlerInput{
+				Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:   time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC),
+				Limit: 10,
+			},
+			Expected: templateQuery{
+				Context: inputContext{
+					Start:             time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:               time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC),
+					MainTableRequired: true,
+					RequiredColumns:   []schema.ColumnKey{schema.ColumnSrcAddr, schema.ColumnDstAddr},
+					Points:            1,
+				},
+				Template: `SELECT
+ [replaceRegexpOne(IPv6NumToString(SrcAddr), '^::ffff:', ''), replaceRegexpOne(IPv6NumToString(DstAddr), '^::ffff:', '')] AS dimensions,
+ SUM(Bytes*SamplingRate*8)/3600 AS bps,
+ SUM(Packets*SamplingRate)/3600 AS pps
+FROM {{ .Table }}
+WHERE {{ .Timefilter }}
+GROUP BY SrcAddr, DstAddr
+ORDER BY bps DESC
+LIMIT 10`,
+			},
+		}, {
+			Description: "ports expanded, with filter",
+			Pos:         helpers.Mark(),
+			Input: conversationsHandlerInput{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC),
+				Filter: query.NewFilter("DstCountry = 'FR'"),
+				Ports:  true,
+				Limit:  5,
+			},
+			Expected: templateQuery{
+				Context: inputContext{
+					Start:             time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:               time.Date(2022, 4, 10, 16, 45, 10, 0, time.UTC),
+					MainTableRequired: true,
+					RequiredColumns: []schema.ColumnKey{
+						schema.ColumnDstCountry,
+						schema.ColumnSrcCountry,
+						schema.ColumnSrcAddr,
+						schema.ColumnDstAddr,
+						schema.ColumnProto,
+						schema.ColumnSrcPort,
+						schema.ColumnDstPort,
+					},
+					Points: 1,
+				},
+				Template: `SELECT
+ [replaceRegexpOne(IPv6NumToString(SrcAddr), '^::ffff:', ''), replaceRegexpOne(IPv6NumToString(DstAddr), '^::ffff:', ''), dictGetOrDefault('protocols', 'name', Proto, '???'), replaceRegexpOne(multiIf(Proto==6, concat(toString(SrcPort), '/', dictGetOrDefault('tcp', 'name', SrcPort,'')), Proto==17, concat(toString(SrcPort), '/', dictGetOrDefault('udp', 'name', SrcPort,'')), toString(SrcPort)), '/$', ''), replaceRegexpOne(multiIf(Proto==6, concat(toString(DstPort), '/', dictGetOrDefault('tcp', 'name', DstPort,'')), Proto==17, concat(toString(DstPort), '/', dictGetOrDefault('udp', 'name', DstPort,'')), toString(DstPort)), '/$', '')] AS dimensions,
+ SUM(Bytes*SamplingRate*8)/3600 AS bps,
+ SUM(Packets*SamplingRate)/3600 AS pps
+FROM {{ .Table }}
+WHERE {{ .Timefilter }} AND (DstCountry = 'FR')
+GROUP BY SrcAddr, DstAddr, Proto, SrcPort, DstPort
+ORDER BY bps DESC
+LIMIT 5`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc.Input.schema = schema.NewMock(t)
+		dimensions := tc.Input.dimensions()
+		if err := query.Columns(dimensions).Validate(tc.Input.schema); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		if err := tc.Input.Filter.Validate(tc.Input.schema); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		t.Run(tc.Description, func(t *testing.T) {
+			got := tc.Input.toSQL(dimensions)
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("%stoSQL (-got, +want):\n%s", tc.Pos, diff)
+			}
+		})
+	}
+}
+
+func TestConversationsHandler(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+
+	expectedSQL := []struct {
+		Dimensions []string `ch:"dimensions"`
+		Bps        float64  `ch:"bps"`
+		Pps        float64  `ch:"pps"`
+	}{
+		{[]string{"192.0.2.1", "2001:db8::1", "TCP", "43211", "443/https"}, 8000, 100},
+		{[]string{"192.0.2.2", "192.0.2.3", "???", "0", "0"}, 4000, 20},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, expectedSQL).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/widget/conversations",
+			JSONInput: gin.H{
+				"start":  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"filter": "DstCountry = 'FR'",
+				"ports":  true,
+				"limit":  10,
+			},
+			JSONOutput: gin.H{
+				"dimensions": []string{"SrcAddr", "DstAddr", "Proto", "SrcPort", "DstPort"},
+				"conversations": []gin.H{
+					{
+						"values": []string{"192.0.2.1", "2001:db8::1", "TCP", "43211", "443/https"},
+						"bps":    8000,
+						"pps":    100,
+						"filter": "SrcAddr = 192.0.2.1 AND DstAddr = 2001:db8::1 AND Proto = \"TCP\" AND SrcPort = 43211 AND DstPort = 443",
+					}, {
+						"values": []string{"192.0.2.2", "192.0.2.3", "???", "0", "0"},
+						"bps":    4000,
+						"pps":    20,
+						"filter": "SrcAddr = 192.0.2.2 AND DstAddr = 192.0.2.3 AND SrcPort = 0 AND DstPort = 0",
+					},
+				},
+			},
+		}, {
+			Description: "limit too high",
+			URL:         "/api/v0/console/widget/conversations",
+			StatusCode:  400,
+			JSONInput: gin.H{
+				"start": time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"limit": 1000,
+			},
+			JSONOutput: gin.H{"message": "Limit is set beyond maximum value (50)"},
+		},
+	})
+}
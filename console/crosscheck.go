@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// crossCheckMinimumRate is the rate (in bits per second) under which a
+// direction of an interface is never flagged, to avoid reporting idle
+// interfaces.
+const crossCheckMinimumRate = 1_000_000
+
+// crossCheckResult is an interface as returned by ClickHouse.
+type crossCheckResult struct {
+	Address         string `json:"address"`
+	Name            string `json:"name"`
+	Interface       string `json:"interface"`
+	Duration        int64  `json:"duration"`
+	CounterInBytes  uint64 `json:"counter-in-bytes"`
+	CounterOutBytes uint64 `json:"counter-out-bytes"`
+	FlowInBytes     uint64 `json:"flow-in-bytes"`
+	FlowOutBytes    uint64 `json:"flow-out-bytes"`
+}
+
+// crossCheckOutput is an interface as returned by the API.
+type crossCheckOutput struct {
+	crossCheckResult
+	InDeviation  float64 `json:"in-deviation"`
+	OutDeviation float64 `json:"out-deviation"`
+	Flagged      bool    `json:"flagged"`
+}
+
+// crossCheckQuery returns the query comparing the interface counters with the
+// flows over the provided window. For each interface, the counters are
+// compared with the flows received between the first and the last sample of
+// the counters. Interfaces with a single sample or whose counters were reset
+// are ignored.
+func crossCheckQuery(window time.Duration) string {
+	return fmt.Sprintf(`
+WITH counters AS (
+ SELECT
+  ExporterAddress,
+  IfName,
+  argMax(ExporterName, TimeReceived) AS ExporterName,
+  min(TimeReceived) AS First,
+  max(TimeReceived) AS Last,
+  argMax(InOctets, TimeReceived) - argMin(InOctets, TimeReceived) AS InBytes,
+  argMax(OutOctets, TimeReceived) - argMin(OutOctets, TimeReceived) AS OutBytes
+ FROM interfaces_counters
+ WHERE TimeReceived > date_sub(second, %[1]d, now())
+ GROUP BY ExporterAddress, IfName
+ HAVING Last > First
+  AND argMax(InOctets, TimeReceived) >= argMin(InOctets, TimeReceived)
+  AND argMax(OutOctets, TimeReceived) >= argMin(OutOctets, TimeReceived)
+), traffic AS (
+ SELECT
+  ExporterAddress,
+  InIfName AS IfName,
+  toStartOfMinute(TimeReceived) AS Time,
+  SUM(Bytes*SamplingRate) AS InBytes,
+  toUInt64(0) AS OutBytes
+ FROM flows
+ WHERE TimeReceived > date_sub(second, %[1]d, now())
+ GROUP BY ExporterAddress, IfName, Time
+ UNION ALL
+ SELECT
+  ExporterAddress,
+  OutIfName AS IfName,
+  toStartOfMinute(TimeReceived) AS Time,
+  toUInt64(0) AS InBytes,
+  SUM(Bytes*SamplingRate) AS OutBytes
+ FROM flows
+ WHERE TimeReceived > date_sub(second, %[1]d, now())
+ GROUP BY ExporterAddress, IfName, Time
+)
+SELECT
+ replaceRegexpOne(IPv6NumToString(c.ExporterAddress), '^::ffff:', '') AS Address,
+ c.ExporterName AS Name,
+ c.IfName AS Interface,
+ dateDiff('second', c.First, c.Last) AS Duration,
+ c.InBytes AS CounterInBytes,
+ c.OutBytes AS CounterOutBytes,
+ sumIf(t.InBytes, t.Time >= c.First AND t.Time < c.Last) AS FlowInBytes,
+ sumIf(t.OutBytes, t.Time >= c.First AND t.Time < c.Last) AS FlowOutBytes
+FROM counters AS c
+LEFT JOIN traffic AS t
+ON t.ExporterAddress = c.ExporterAddress AND t.IfName = c.IfName
+GROUP BY Address, Name, Interface, Duration, CounterInBytes, CounterOutBytes
+ORDER BY Name, Interface`, uint64(window.Seconds()))
+}
+
+// crossCheckDeviation returns the deviation of the flows from the counters
+// for one direction of an interface. It is 0 when the rate is too low.
+func crossCheckDeviation(counter, flow uint64, duration int64) float64 {
+	if duration <= 0 || max(counter, flow)*8/uint64(duration) < crossCheckMinimumRate {
+		return 0
+	}
+	return parityDifference(counter, flow)
+}
+
+func (c *Component) crossCheckHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	query := crossCheckQuery(c.config.CrossCheck.Window)
+	gc.Header("X-SQL-Query", query)
+
+	results := []crossCheckResult{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, query); err != nil {
+		c.queryError(gc, err, query)
+		return
+	}
+	interfaces := make([]crossCheckOutput, len(results))
+	flagged := 0
+	for idx, result := range results {
+		output := crossCheckOutput{
+			crossCheckResult: result,
+			InDeviation:      crossCheckDeviation(result.CounterInBytes, result.FlowInBytes, result.Duration),
+			OutDeviation:     crossCheckDeviation(result.CounterOutBytes, result.FlowOutBytes, result.Duration),
+		}
+		output.Flagged = math.Abs(output.InDeviation) > c.config.CrossCheck.Tolerance ||
+			math.Abs(output.OutDeviation) > c.config.CrossCheck.Tolerance
+		if output.Flagged {
+			flagged++
+		}
+		interfaces[idx] = output
+	}
+	gc.IndentedJSON(http.StatusOK, gin.H{
+		"interfaces": interfaces,
+		"flagged":    flagged,
+		"tolerance":  c.config.CrossCheck.Tolerance,
+	})
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+)
+
+func TestCrossCheck(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), crossCheckQuery(6*time.Hour)).
+		SetArg(1, []crossCheckResult{
+			{
+				Address:         "192.0.2.1",
+				Name:            "router1",
+				Interface:       "Gi0/0/1",
+				Duration:        3600,
+				CounterInBytes:  4_000_000_000,
+				CounterOutBytes: 4_000_000_000,
+				FlowInBytes:     3_600_000_000,
+				FlowOutBytes:    1_000_000_000,
+			}, {
+				Address:         "192.0.2.1",
+				Name:            "router1",
+				Interface:       "Gi0/0/2",
+				Duration:        3600,
+				CounterInBytes:  1000,
+				CounterOutBytes: 2000,
+			},
+		}).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/exporters/crosscheck",
+			JSONOutput: gin.H{
+				"flagged":   1,
+				"tolerance": 0.2,
+				"interfaces": []gin.H{
+					{
+						"address":           "192.0.2.1",
+						"name":              "router1",
+						"interface":         "Gi0/0/1",
+						"duration":          3600,
+						"counter-in-bytes":  4_000_000_000,
+						"counter-out-bytes": 4_000_000_000,
+						"flow-in-bytes":     3_600_000_000,
+						"flow-out-bytes":    1_000_000_000,
+						"in-deviation":      -0.1,
+						"out-deviation":     -0.75,
+						"flagged":           true,
+					}, {
+						// Idle interface
+						"address":           "192.0.2.1",
+						"name":              "router1",
+						"interface":         "Gi0/0/2",
+						"duration":          3600,
+						"counter-in-bytes":  1000,
+						"counter-out-bytes": 2000,
+						"flow-in-bytes":     0,
+						"flow-out-bytes":    0,
+						"in-deviation":      0,
+						"out-deviation":     0,
+						"flagged":           false,
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIToken represents an API token in database. Only a hash of the token is
+// stored.
+type APIToken struct {
+	ID          uint64     `json:"id"`
+	User        string     `gorm:"index" json:"user"`
+	Description string     `json:"description" binding:"required"`
+	Hash        string     `gorm:"uniqueIndex;size:64" json:"-"`
+	Prefix      string     `json:"prefix"`
+	Scope       string     `json:"scope" binding:"required,oneof=read-only read-write"`
+	Endpoints   []string   `gorm:"serializer:json" json:"endpoints,omitempty" binding:"dive,startswith=/api/v0/console/"`
+	Created     time.Time  `json:"created"`
+	Expires     *time.Time `json:"expires,omitempty"`
+}
+
+// ErrAPITokenNotFound is returned when an API token does not exist or is not
+// owned by the user.
+var ErrAPITokenNotFound = errors.New("no matching API token")
+
+// CreateAPIToken creates a new API token in database.
+func (c *Component) CreateAPIToken(ctx context.Context, t *APIToken) error {
+	t.ID = 0
+	if err := gorm.G[APIToken](c.db).Create(ctx, t); err != nil {
+		return fmt.Errorf("unable to create new API token: %w", err)
+	}
+	return nil
+}
+
+// ListAPITokens lists the API tokens of the provided user.
+func (c *Component) ListAPITokens(ctx context.Context, user string) ([]APIToken, error) {
+	results, err := gorm.G[APIToken](c.db).Where(APIToken{User: user}).Order("id").Find(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve API tokens: %w", err)
+	}
+	return results, nil
+}
+
+// LookupAPIToken returns the API token with the provided hash.
+func (c *Component) LookupAPIToken(ctx context.Context, hash string) (APIToken, error) {
+	t, err := gorm.G[APIToken](c.db).Where(APIToken{Hash: hash}).First(ctx)
+	if err == gorm.ErrRecordNotFound {
+		return APIToken{}, ErrAPITokenNotFound
+	} else if err != nil {
+		return APIToken{}, fmt.Errorf("cannot get API token: %w", err)
+	}
+	return t, nil
+}
+
+// DeleteAPIToken revokes the provided API token. Only its owner can revoke it.
+func (c *Component) DeleteAPIToken(ctx context.Context, id uint64, user string) error {
+	rows, err := gorm.G[APIToken](c.db).Where(APIToken{ID: id, User: user}).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot delete API token: %w", err)
+	}
+	if rows == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
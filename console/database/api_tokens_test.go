@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestAPITokens(t *testing.T) {
+	r := reporter.NewMock(t)
+	c := NewMock(t, r, DefaultConfiguration())
+	ctx := context.Background()
+	created := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+
+	for _, token := range []APIToken{
+		{User: "marty", Description: "first", Hash: "aaaa", Scope: "read-only", Created: created},
+		{User: "judith", Description: "second", Hash: "bbbb", Scope: "read-write", Created: created},
+		{
+			User:        "marty",
+			Description: "third",
+			Hash:        "cccc",
+			Scope:       "read-only",
+			Endpoints:   []string{"/api/v0/console/graph/line"},
+			Created:     created,
+		},
+	} {
+		if err := c.CreateAPIToken(ctx, &token); err != nil {
+			t.Fatalf("CreateAPIToken() error:\n%+v", err)
+		}
+	}
+	if err := c.CreateAPIToken(ctx, &APIToken{User: "marty", Hash: "aaaa"}); err == nil {
+		t.Fatal("CreateAPIToken() with duplicate hash did not error")
+	}
+
+	got, err := c.ListAPITokens(ctx, "marty")
+	if err != nil {
+		t.Fatalf("ListAPITokens() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, []APIToken{
+		{ID: 1, User: "marty", Description: "first", Hash: "aaaa", Scope: "read-only", Created: created},
+		{
+			ID:          3,
+			User:        "marty",
+			Description: "third",
+			Hash:        "cccc",
+			Scope:       "read-only",
+			Endpoints:   []string{"/api/v0/console/graph/line"},
+			Created:     created,
+		},
+	}); diff != "" {
+		t.Fatalf("ListAPITokens() (-got, +want):\n%s", diff)
+	}
+
+	token, err := c.LookupAPIToken(ctx, "bbbb")
+	if err != nil {
+		t.Fatalf("LookupAPIToken() error:\n%+v", err)
+	}
+	if token.ID != 2 || token.User != "judith" {
+		t.Fatalf("LookupAPIToken() got:\n%+v", token)
+	}
+	if _, err := c.LookupAPIToken(ctx, "dddd"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("LookupAPIToken() error:\n%+v", err)
+	}
+
+	if err := c.DeleteAPIToken(ctx, 2, "marty"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("DeleteAPIToken() error:\n%+v", err)
+	}
+	if err := c.DeleteAPIToken(ctx, 2, "judith"); err != nil {
+		t.Fatalf("DeleteAPIToken() error:\n%+v", err)
+	}
+	if _, err := c.LookupAPIToken(ctx, "bbbb"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("LookupAPIToken() error:\n%+v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ASNName represents a name overriding the one of an AS number.
+type ASNName struct {
+	ASN  uint32 `gorm:"primaryKey;autoIncrement:false" json:"asn"`
+	Name string `json:"name" binding:"required"`
+	User string `json:"user"` // last user to update the name
+}
+
+// ErrASNNameNotFound is returned when there is no name for an AS number.
+var ErrASNNameNotFound = errors.New("no matching AS name")
+
+// ListASNNames lists all the AS names, sorted by AS number.
+func (c *Component) ListASNNames(ctx context.Context) ([]ASNName, error) {
+	results, err := gorm.G[ASNName](c.db).Order("asn").Find(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve AS names: %w", err)
+	}
+	return results, nil
+}
+
+// SetASNName creates or updates the name of an AS number.
+func (c *Component) SetASNName(ctx context.Context, n ASNName) error {
+	err := gorm.G[ASNName](c.db, clause.OnConflict{UpdateAll: true}).Create(ctx, &n)
+	if err != nil {
+		return fmt.Errorf("unable to set AS name: %w", err)
+	}
+	return nil
+}
+
+// DeleteASNName deletes the name of an AS number.
+func (c *Component) DeleteASNName(ctx context.Context, asn uint32) error {
+	rows, err := gorm.G[ASNName](c.db).Where("asn = ?", asn).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot delete AS name: %w", err)
+	}
+	if rows == 0 {
+		return ErrASNNameNotFound
+	}
+	return nil
+}
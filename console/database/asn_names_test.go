@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestASNNames(t *testing.T) {
+	r := reporter.NewMock(t)
+	c := NewMock(t, r, DefaultConfiguration())
+	ctx := context.Background()
+
+	for _, n := range []ASNName{
+		{ASN: 64513, Name: "Datacenter", User: "marty"},
+		{ASN: 64512, Name: "Lab", User: "marty"},
+		{ASN: 64513, Name: "Main datacenter", User: "judith"},
+	} {
+		if err := c.SetASNName(ctx, n); err != nil {
+			t.Fatalf("SetASNName() error:\n%+v", err)
+		}
+	}
+	got, err := c.ListASNNames(ctx)
+	if err != nil {
+		t.Fatalf("ListASNNames() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, []ASNName{
+		{ASN: 64512, Name: "Lab", User: "marty"},
+		{ASN: 64513, Name: "Main datacenter", User: "judith"},
+	}); diff != "" {
+		t.Fatalf("ListASNNames() (-got, +want):\n%s", diff)
+	}
+
+	if err := c.DeleteASNName(ctx, 64512); err != nil {
+		t.Fatalf("DeleteASNName() error:\n%+v", err)
+	}
+	if err := c.DeleteASNName(ctx, 64512); !errors.Is(err, ErrASNNameNotFound) {
+		t.Fatalf("DeleteASNName() error:\n%+v", err)
+	}
+	got, err = c.ListASNNames(ctx)
+	if err != nil {
+		t.Fatalf("ListASNNames() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, []ASNName{
+		{ASN: 64513, Name: "Main datacenter", User: "judith"},
+	}); diff != "" {
+		t.Fatalf("ListASNNames() (-got, +want):\n%s", diff)
+	}
+}
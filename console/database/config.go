@@ -23,6 +23,7 @@ func DefaultConfiguration() Configuration {
 
 // BuiltinSavedFilter is a saved filter
 type BuiltinSavedFilter struct {
-	Description string `validate:"required"`
-	Content     string `validate:"required"`
+	Description string   `validate:"required"`
+	Content     string   `validate:"required"`
+	Tags        []string `validate:"dive,required"`
 }
@@ -64,7 +64,7 @@ func (c *Component) Start() error {
 	default:
 		return fmt.Errorf("%q is not a supporter driver", c.config.Driver)
 	}
-	if err := c.db.AutoMigrate(&SavedFilter{}); err != nil {
+	if err := c.db.AutoMigrate(&SavedFilter{}, &ASNName{}, &APIToken{}); err != nil {
 		return fmt.Errorf("cannot migrate database: %w", err)
 	}
 	return c.populate()
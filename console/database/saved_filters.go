@@ -7,19 +7,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 // SavedFilter represents a saved filter in database.
 type SavedFilter struct {
-	ID          uint64 `json:"id"`
-	User        string `gorm:"index" json:"user"`
-	Shared      bool   `json:"shared"`
-	Description string `json:"description" binding:"required"`
-	Content     string `json:"content" binding:"required"`
+	ID          uint64   `json:"id"`
+	User        string   `gorm:"index" json:"user"`
+	Shared      bool     `json:"shared"`
+	Description string   `json:"description" binding:"required"`
+	Content     string   `json:"content" binding:"required"`
+	Tags        []string `gorm:"serializer:json" json:"tags,omitempty" binding:"dive,required"`
+	Uses        uint64   `json:"uses"`
 }
 
+// ErrSavedFilterNotFound is returned when a saved filter does not exist or
+// is not accessible to the user.
+var ErrSavedFilterNotFound = errors.New("no matching saved filter")
+
 // To populate a few filters:
 // http 127.0.0.1:8080/api/v0/console/filter/saved shared:=true description="ASN/To Iliad" content="InIfBoundary=external AND DstAS IN (AS12322, AS51207, AS29447)" Remote-User:spiderman
 // http 127.0.0.1:8080/api/v0/console/filter/saved shared:=true description="ASN/From Google" content="InIfBoundary=external AND DstAS IN (AS15169, AS36040)" Remote-User:donald
@@ -28,6 +36,7 @@ type SavedFilter struct {
 // CreateSavedFilter creates a new saved filter in database.
 func (c *Component) CreateSavedFilter(ctx context.Context, f SavedFilter) error {
 	f.ID = 0
+	f.Uses = 0
 	err := gorm.G[SavedFilter](c.db).Create(ctx, &f)
 	if err != nil {
 		return fmt.Errorf("unable to create new saved filter: %w", err)
@@ -48,6 +57,66 @@ func (c *Component) ListSavedFilters(ctx context.Context, user string) ([]SavedF
 	return results, nil
 }
 
+// SearchSavedFilters lists saved filters for the provided user matching the
+// provided text and having all the provided tags. The text is searched in the
+// description, the content and the tags, without taking case into account.
+func (c *Component) SearchSavedFilters(ctx context.Context, user string, text string, tags []string) ([]SavedFilter, error) {
+	results, err := c.ListSavedFilters(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(strings.ToLower(text))
+	return slices.DeleteFunc(results, func(f SavedFilter) bool {
+		for _, tag := range tags {
+			if !slices.Contains(f.Tags, tag) {
+				return true
+			}
+		}
+		haystack := strings.ToLower(strings.Join(
+			append([]string{f.Description, f.Content}, f.Tags...), "\n"))
+		for _, word := range words {
+			if !strings.Contains(haystack, word) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// UpdateSavedFilter updates the description, the content, the tags and the
+// visibility of the provided saved filter. Only its owner can update it.
+func (c *Component) UpdateSavedFilter(ctx context.Context, f SavedFilter) error {
+	db := gorm.G[SavedFilter](c.db)
+	condition := SavedFilter{ID: f.ID, User: f.User}
+	if _, err := db.Where(condition).First(ctx); err == gorm.ErrRecordNotFound {
+		return ErrSavedFilterNotFound
+	} else if err != nil {
+		return fmt.Errorf("cannot get saved filter: %w", err)
+	}
+	if _, err := db.Where(condition).
+		Select("Description", "Content", "Tags", "Shared").
+		Updates(ctx, f); err != nil {
+		return fmt.Errorf("cannot update saved filter: %w", err)
+	}
+	return nil
+}
+
+// UseSavedFilter increments the usage count of the provided saved filter. It
+// should be either owned by the provided user or shared.
+func (c *Component) UseSavedFilter(ctx context.Context, id uint64, user string) error {
+	db := gorm.G[SavedFilter](c.db)
+	f, err := db.Where(SavedFilter{ID: id}).First(ctx)
+	if err == gorm.ErrRecordNotFound || (err == nil && f.User != user && !f.Shared) {
+		return ErrSavedFilterNotFound
+	} else if err != nil {
+		return fmt.Errorf("cannot get saved filter: %w", err)
+	}
+	if _, err := db.Where(SavedFilter{ID: id}).Update(ctx, "uses", gorm.Expr("uses + 1")); err != nil {
+		return fmt.Errorf("cannot update saved filter usage: %w", err)
+	}
+	return nil
+}
+
 // DeleteSavedFilter deletes the provided saved filter
 func (c *Component) DeleteSavedFilter(ctx context.Context, f SavedFilter) error {
 	rows, err := gorm.G[SavedFilter](c.db).Where(f).Delete(ctx)
@@ -55,7 +124,7 @@ func (c *Component) DeleteSavedFilter(ctx context.Context, f SavedFilter) error
 		return fmt.Errorf("cannot delete saved filter: %w", err)
 	}
 	if rows == 0 {
-		return errors.New("no matching saved filter to delete")
+		return ErrSavedFilterNotFound
 	}
 	return nil
 }
@@ -75,11 +144,19 @@ func (c *Component) populate() error {
 			Description: filter.Description,
 			Content:     filter.Content,
 		}
-		if _, err := db.Where(savedFilter).First(ctx); err == gorm.ErrRecordNotFound {
+		existing, err := db.Where(savedFilter).First(ctx)
+		savedFilter.Tags = filter.Tags
+		if err == gorm.ErrRecordNotFound {
 			err := db.Create(ctx, &savedFilter)
 			if err != nil {
 				return fmt.Errorf("unable add builtin filter: %w", err)
 			}
+		} else if err == nil && !slices.Equal(existing.Tags, filter.Tags) {
+			if _, err := db.Where(SavedFilter{ID: existing.ID}).
+				Select("Tags").
+				Updates(ctx, savedFilter); err != nil {
+				return fmt.Errorf("unable to update builtin filter: %w", err)
+			}
 		}
 	}
 
@@ -96,7 +173,7 @@ outer:
 			}
 		}
 		c.r.Info().Msgf("remove old builtin filter %q", result.Description)
-		if _, err := db.Where(result).Delete(ctx); err != nil {
+		if _, err := db.Where(SavedFilter{ID: result.ID}).Delete(ctx); err != nil {
 			return fmt.Errorf("cannot delete old builtin filter: %w", err)
 		}
 	}
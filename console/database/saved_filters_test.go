@@ -6,6 +6,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net"
 	"testing"
@@ -30,6 +31,8 @@ func testSavedFilter(t *testing.T, c *Component) {
 		Shared:      true,
 		Description: "judith's filter",
 		Content:     "InIfBoundary = external",
+		Tags:        []string{"boundary", "transit"},
+		Uses:        18,
 	}); err != nil {
 		t.Fatalf("CreateSavedFilter() error:\n%+v", err)
 	}
@@ -60,6 +63,7 @@ func testSavedFilter(t *testing.T, c *Component) {
 			Shared:      true,
 			Description: "judith's filter",
 			Content:     "InIfBoundary = external",
+			Tags:        []string{"boundary", "transit"},
 		}, {
 			ID:          3,
 			User:        "marty",
@@ -71,6 +75,67 @@ func testSavedFilter(t *testing.T, c *Component) {
 		t.Fatalf("ListSavedFilters() (-got, +want):\n%s", diff)
 	}
 
+	// Search
+	searches := []struct {
+		Text     string
+		Tags     []string
+		Expected []uint64
+	}{
+		{"", nil, []uint64{1, 2, 3}},
+		{"Boundary", nil, []uint64{2, 3}},
+		{"marty second", nil, []uint64{3}},
+		{"transit", nil, []uint64{2}},
+		{"", []string{"boundary"}, []uint64{2}},
+		{"internal", []string{"boundary"}, []uint64{}},
+	}
+	for _, search := range searches {
+		got, err := c.SearchSavedFilters(context.Background(), "marty", search.Text, search.Tags)
+		if err != nil {
+			t.Fatalf("SearchSavedFilters(%q, %v) error:\n%+v", search.Text, search.Tags, err)
+		}
+		ids := []uint64{}
+		for _, f := range got {
+			ids = append(ids, f.ID)
+		}
+		if diff := helpers.Diff(ids, search.Expected); diff != "" {
+			t.Errorf("SearchSavedFilters(%q, %v) (-got, +want):\n%s", search.Text, search.Tags, diff)
+		}
+	}
+
+	// Update
+	if err := c.UpdateSavedFilter(context.Background(), SavedFilter{
+		ID:          3,
+		User:        "judith",
+		Description: "judith's attempt",
+		Content:     "InIfBoundary = internal",
+	}); !errors.Is(err, ErrSavedFilterNotFound) {
+		t.Fatalf("UpdateSavedFilter() error:\n%+v", err)
+	}
+	if err := c.UpdateSavedFilter(context.Background(), SavedFilter{
+		ID:          3,
+		User:        "marty",
+		Shared:      false,
+		Description: "marty's updated filter",
+		Content:     "InIfBoundary = internal",
+		Tags:        []string{"internal"},
+		Uses:        18,
+	}); err != nil {
+		t.Fatalf("UpdateSavedFilter() error:\n%+v", err)
+	}
+
+	// Use
+	if err := c.UseSavedFilter(context.Background(), 3, "judith"); !errors.Is(err, ErrSavedFilterNotFound) {
+		t.Fatalf("UseSavedFilter() error:\n%+v", err)
+	}
+	for range 2 {
+		if err := c.UseSavedFilter(context.Background(), 3, "marty"); err != nil {
+			t.Fatalf("UseSavedFilter() error:\n%+v", err)
+		}
+	}
+	if err := c.UseSavedFilter(context.Background(), 2, "marty"); err != nil {
+		t.Fatalf("UseSavedFilter() error:\n%+v", err)
+	}
+
 	// Delete
 	if err := c.DeleteSavedFilter(context.Background(), SavedFilter{ID: 1}); err != nil {
 		t.Fatalf("DeleteSavedFilter() error:\n%+v", err)
@@ -83,12 +148,16 @@ func testSavedFilter(t *testing.T, c *Component) {
 			Shared:      true,
 			Description: "judith's filter",
 			Content:     "InIfBoundary = external",
+			Tags:        []string{"boundary", "transit"},
+			Uses:        1,
 		}, {
 			ID:          3,
 			User:        "marty",
-			Shared:      true,
-			Description: "marty's second filter",
+			Shared:      false,
+			Description: "marty's updated filter",
 			Content:     "InIfBoundary = internal",
+			Tags:        []string{"internal"},
+			Uses:        2,
 		},
 	}); diff != "" {
 		t.Fatalf("ListSavedFilters() (-got, +want):\n%s", diff)
@@ -206,6 +275,7 @@ func TestPopulateSavedFilters(t *testing.T) {
 		}, {
 			Description: "second filter",
 			Content:     "content of second filter",
+			Tags:        []string{"builtin"},
 		},
 	}
 	r := reporter.NewMock(t)
@@ -225,12 +295,14 @@ func TestPopulateSavedFilters(t *testing.T) {
 			Shared:      true,
 			Description: "second filter",
 			Content:     "content of second filter",
+			Tags:        []string{"builtin"},
 		},
 	}); diff != "" {
 		t.Fatalf("ListSavedFilters() (-got, +want):\n%s", diff)
 	}
 
 	c.config.SavedFilters = c.config.SavedFilters[:1]
+	c.config.SavedFilters[0].Tags = []string{"updated"}
 	c.populate()
 	got, _ = c.ListSavedFilters(context.Background(), "marty")
 	if diff := helpers.Diff(got, []SavedFilter{
@@ -240,6 +312,7 @@ func TestPopulateSavedFilters(t *testing.T) {
 			Shared:      true,
 			Description: "first filter",
 			Content:     "content of first filter",
+			Tags:        []string{"updated"},
 		},
 	}); diff != "" {
 		t.Fatalf("ListSavedFilters() (-got, +want):\n%s", diff)
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+	"akvorado/console/export"
+)
+
+// graphExportHandlerInput describes the input for the /graph/line/export
+// endpoint. This is the same input as for the /graph/line endpoint with the
+// requested format.
+type graphExportHandlerInput struct {
+	graphLineHandlerInput
+	Format export.Format `json:"format" binding:"required,oneof=csv parquet xlsx"`
+}
+
+// maxExportRows returns the maximum number of rows of an export for the
+// provided user.
+func (c *Component) maxExportRows(user string) uint64 {
+	if maxRows, ok := c.config.Export.UserMaxRows[user]; ok {
+		return maxRows
+	}
+	return c.config.Export.MaxRows
+}
+
+// estimateRows returns an upper bound of the number of rows returned by the
+// provided queries.
+func (c *Component) estimateRows(input graphLineHandlerInput, queries []templateQuery) uint64 {
+	series := uint64(1)
+	if len(input.Dimensions) > 0 {
+		series = uint64(input.Limit) + 1 // "Other" row
+	}
+	var total uint64
+	for _, query := range queries {
+		_, interval, targetInterval := c.computeTableAndInterval(query.Context)
+		if targetInterval > interval {
+			interval = targetInterval.Truncate(interval)
+		}
+		span := query.Context.End.Sub(query.Context.Start)
+		total += (uint64(span/interval) + 2) * series
+	}
+	return total
+}
+
+// graphExportHandlerFunc sends the data of a line graph in the requested
+// format.
+func (c *Component) graphExportHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input := graphExportHandlerInput{
+		graphLineHandlerInput: graphLineHandlerInput{
+			graphCommonHandlerInput: graphCommonHandlerInput{schema: c.d.Schema},
+		},
+	}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := c.validateGraphLineInput(&input.graphLineHandlerInput); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
+	queries := input.toSQL()
+	if err := c.checkGuardrails(queries); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	maxRows := c.maxExportRows(user)
+	if estimated := c.estimateRows(input.graphLineHandlerInput, queries); estimated > maxRows {
+		gc.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Export would contain up to %d rows, maximum is %d. Reduce the time range or the number of points.",
+				estimated, maxRows),
+		})
+		return
+	}
+	sqlQuery := c.finalizeTemplateQueries(queries)
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+
+	rows, err := c.d.ClickHouseDB.Conn.Query(ctx, sqlQuery)
+	if err != nil {
+		c.queryError(gc, err, sqlQuery)
+		return
+	}
+	defer rows.Close()
+
+	// Build the list of columns. The axis is only present when there are
+	// several of them.
+	columns := []export.Column{{Name: "Time", Type: export.ColumnTime}}
+	withAxis := len(queries) > 1
+	if withAxis {
+		columns = append(columns, export.Column{Name: "Axis", Type: export.ColumnString})
+	}
+	for _, dimension := range input.Dimensions {
+		columns = append(columns, export.Column{Name: dimension.String(), Type: export.ColumnString})
+	}
+	columns = append(columns, export.Column{Name: input.Units, Type: export.ColumnFloat})
+
+	// The export is built in a temporary file and only sent once complete.
+	// Otherwise, an error would result in a truncated file looking valid.
+	file, err := os.CreateTemp("", "akvorado-export-*")
+	if err != nil {
+		c.r.Err(err).Msg("unable to create temporary file for export")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create export."})
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	w, err := export.NewWriter(input.Format, file, columns)
+	if err != nil {
+		c.r.Err(err).Msg("unable to start export")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create export."})
+		return
+	}
+
+	var (
+		axis       uint8
+		t          time.Time
+		xps        float64
+		dimensions []string
+		count      uint64
+		row        = make([]any, len(columns))
+	)
+	for rows.Next() {
+		if count >= maxRows {
+			c.r.Warn().Str("user", user).Msg("export truncated: too many rows")
+			break
+		}
+		if err := rows.Scan(&axis, &t, &xps, &dimensions); err != nil {
+			c.r.Err(err).Msg("unable to parse row for export")
+			gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to parse result."})
+			return
+		}
+		i := 0
+		row[i] = t
+		i++
+		if withAxis {
+			row[i] = input.axisName(int(axis))
+			i++
+		}
+		// When requesting the previous period, we get an empty dimension.
+		// Put it back.
+		for idx := range input.Dimensions {
+			if idx < len(dimensions) {
+				row[i] = dimensions[idx]
+			} else {
+				row[i] = "Other"
+			}
+			i++
+		}
+		row[i] = xps
+		if err := w.Write(row); err != nil {
+			c.r.Err(err).Msg("unable to write row for export")
+			gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create export."})
+			return
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		c.queryError(gc, err, sqlQuery)
+		return
+	}
+	if err := w.Close(); err != nil {
+		c.r.Err(err).Msg("unable to complete export")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create export."})
+		return
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		c.r.Err(err).Msg("unable to rewind export")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create export."})
+		return
+	}
+	gc.DataFromReader(http.StatusOK, size, input.Format.ContentType(), file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="akvorado-%s.%s"`,
+			input.Start.UTC().Format("20060102-150405"), input.Format),
+	})
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	cw := &csvWriter{
+		w:      csv.NewWriter(w),
+		record: make([]string, len(columns)),
+	}
+	for idx, column := range columns {
+		cw.record[idx] = column.Name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write writes one row.
+func (cw *csvWriter) Write(row []any) error {
+	for idx, value := range row {
+		switch value := value.(type) {
+		case time.Time:
+			cw.record[idx] = value.UTC().Format(time.RFC3339)
+		case string:
+			cw.record[idx] = value
+		case float64:
+			cw.record[idx] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+// Close flushes the remaining rows.
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// parquetRowGroupSize is the number of rows in each row group. Rows of the
+// current row group are kept in memory.
+const parquetRowGroupSize = 65536
+
+const parquetMagic = "PAR1"
+
+// Constants from the Parquet format specification.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRepetitionRequired = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetPageData           = 0
+	parquetCodecZstd          = 6
+)
+
+// parquetWriter writes a Parquet file. Each column of a row group is written
+// as a single data page with plain encoding and compressed with zstd. All
+// columns are required.
+type parquetWriter struct {
+	w         io.Writer
+	columns   []Column
+	encoder   *zstd.Encoder
+	offset    int64
+	values    [][]byte // encoded values of the current row group for each column
+	rows      int      // number of rows in the current row group
+	totalRows int64
+	rowGroups []parquetRowGroup
+}
+
+type parquetRowGroup struct {
+	rows   int
+	chunks []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	offset           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+func newParquetWriter(w io.Writer, columns []Column) (*parquetWriter, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	pw := &parquetWriter{
+		w:       w,
+		columns: columns,
+		encoder: encoder,
+		values:  make([][]byte, len(columns)),
+	}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write buffers one row. Once the row group is complete, it is written.
+func (pw *parquetWriter) Write(row []any) error {
+	for idx, value := range row {
+		switch value := value.(type) {
+		case time.Time:
+			pw.values[idx] = binary.LittleEndian.AppendUint64(pw.values[idx], uint64(value.UnixMilli()))
+		case string:
+			pw.values[idx] = binary.LittleEndian.AppendUint32(pw.values[idx], uint32(len(value)))
+			pw.values[idx] = append(pw.values[idx], value...)
+		case float64:
+			pw.values[idx] = binary.LittleEndian.AppendUint64(pw.values[idx], math.Float64bits(value))
+		}
+	}
+	pw.rows++
+	if pw.rows >= parquetRowGroupSize {
+		return pw.flush()
+	}
+	return nil
+}
+
+// flush writes the current row group.
+func (pw *parquetWriter) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	rowGroup := parquetRowGroup{rows: pw.rows}
+	for idx, data := range pw.values {
+		compressed := pw.encoder.EncodeAll(data, nil)
+		e := thriftEncoder{}
+		e.structBegin(0) // PageHeader
+		e.i32(1, parquetPageData)
+		e.i32(2, int32(len(data)))
+		e.i32(3, int32(len(compressed)))
+		e.structBegin(5) // DataPageHeader
+		e.i32(1, int32(pw.rows))
+		e.i32(2, parquetEncodingPlain)
+		e.i32(3, parquetEncodingRLE)
+		e.i32(4, parquetEncodingRLE)
+		e.structEnd()
+		e.structEnd()
+
+		chunk := parquetColumnChunk{
+			offset:           pw.offset,
+			uncompressedSize: int64(len(e.buf) + len(data)),
+			compressedSize:   int64(len(e.buf) + len(compressed)),
+		}
+		if err := pw.write(e.buf); err != nil {
+			return err
+		}
+		if err := pw.write(compressed); err != nil {
+			return err
+		}
+		rowGroup.chunks = append(rowGroup.chunks, chunk)
+		pw.values[idx] = data[:0]
+	}
+	pw.rowGroups = append(pw.rowGroups, rowGroup)
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+	return nil
+}
+
+// parquetTypes returns the physical type and the converted type of a column.
+func (column Column) parquetTypes() (int32, int32) {
+	switch column.Type {
+	case ColumnTime:
+		return parquetTypeInt64, parquetConvertedTimestampMillis
+	case ColumnString:
+		return parquetTypeByteArray, parquetConvertedUTF8
+	default:
+		return parquetTypeDouble, -1
+	}
+}
+
+// Close writes the last row group and the file metadata.
+func (pw *parquetWriter) Close() error {
+	defer pw.encoder.Close()
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	e := thriftEncoder{}
+	e.structBegin(0) // FileMetaData
+	e.i32(1, 1)
+	e.list(2, thriftStruct, len(pw.columns)+1)
+	e.structBegin(0) // SchemaElement for the root
+	e.string(4, "schema")
+	e.i32(5, int32(len(pw.columns)))
+	e.structEnd()
+	for _, column := range pw.columns {
+		physicalType, convertedType := column.parquetTypes()
+		e.structBegin(0) // SchemaElement
+		e.i32(1, physicalType)
+		e.i32(3, parquetRepetitionRequired)
+		e.string(4, column.Name)
+		if convertedType >= 0 {
+			e.i32(6, convertedType)
+		}
+		e.structEnd()
+	}
+	e.i64(3, pw.totalRows)
+	e.list(4, thriftStruct, len(pw.rowGroups))
+	for _, rowGroup := range pw.rowGroups {
+		var totalSize int64
+		e.structBegin(0) // RowGroup
+		e.list(1, thriftStruct, len(rowGroup.chunks))
+		for idx, chunk := range rowGroup.chunks {
+			physicalType, _ := pw.columns[idx].parquetTypes()
+			totalSize += chunk.uncompressedSize
+			e.structBegin(0) // ColumnChunk
+			e.i64(2, chunk.offset)
+			e.structBegin(3) // ColumnMetaData
+			e.i32(1, physicalType)
+			e.list(2, thriftI32, 1)
+			e.zigzag(parquetEncodingPlain)
+			e.list(3, thriftBinary, 1)
+			e.stringValue(pw.columns[idx].Name)
+			e.i32(4, parquetCodecZstd)
+			e.i64(5, int64(rowGroup.rows))
+			e.i64(6, chunk.uncompressedSize)
+			e.i64(7, chunk.compressedSize)
+			e.i64(9, chunk.offset)
+			e.structEnd()
+			e.structEnd()
+		}
+		e.i64(2, totalSize)
+		e.i64(3, int64(rowGroup.rows))
+		e.structEnd()
+	}
+	e.string(6, "akvorado")
+	e.structEnd()
+
+	if err := pw.write(e.buf); err != nil {
+		return err
+	}
+	if err := pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(e.buf)))); err != nil {
+		return err
+	}
+	return pw.write([]byte(parquetMagic))
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package export writes tabular data to files in various formats. Memory usage
+// is bounded: rows are streamed to the underlying writer or, for Excel files,
+// to a temporary file.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// ColumnType is the type of the values of a column.
+type ColumnType int
+
+const (
+	// ColumnTime is for time.Time values.
+	ColumnTime ColumnType = iota
+	// ColumnString is for string values.
+	ColumnString
+	// ColumnFloat is for float64 values.
+	ColumnFloat
+)
+
+// Column describes a column.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Writer writes rows to a file.
+type Writer interface {
+	// Write writes one row. Values should match the types of the columns.
+	Write(row []any) error
+	// Close writes the remaining data. It does not close the underlying
+	// writer.
+	Close() error
+}
+
+// Format is a file format.
+type Format string
+
+const (
+	// FormatCSV is for CSV files.
+	FormatCSV Format = "csv"
+	// FormatParquet is for Apache Parquet files.
+	FormatParquet Format = "parquet"
+	// FormatXLSX is for Excel files.
+	FormatXLSX Format = "xlsx"
+)
+
+// ContentType returns the MIME type for the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// NewWriter returns a new writer for the provided format.
+func NewWriter(f Format, w io.Writer, columns []Column) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
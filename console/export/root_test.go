@@ -0,0 +1,383 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/xuri/excelize/v2"
+
+	"akvorado/common/helpers"
+)
+
+var (
+	testColumns = []Column{
+		{Name: "Time", Type: ColumnTime},
+		{Name: "ExporterName", Type: ColumnString},
+		{Name: "l3bps", Type: ColumnFloat},
+	}
+	testRows = [][]any{
+		{time.Date(2022, 4, 10, 15, 45, 0, 0, time.UTC), "router1", 1000.5},
+		{time.Date(2022, 4, 10, 15, 46, 0, 0, time.UTC), "router<2>", 2000.0},
+	}
+)
+
+func writeAll(t *testing.T, f Format) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf, testColumns)
+	if err != nil {
+		t.Fatalf("NewWriter() error:\n%+v", err)
+	}
+	for _, row := range testRows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write() error:\n%+v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error:\n%+v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("pdf", io.Discard, testColumns); err == nil {
+		t.Fatal("NewWriter() did not error")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	got := string(writeAll(t, FormatCSV))
+	expected := `Time,ExporterName,l3bps
+2022-04-10T15:45:00Z,router1,1000.5
+2022-04-10T15:46:00Z,router<2>,2000
+`
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("CSV (-got, +want):\n%s", diff)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	got := writeAll(t, FormatXLSX)
+	f, err := excelize.OpenReader(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("OpenReader() error:\n%+v", err)
+	}
+	defer f.Close()
+	if diff := helpers.Diff(f.GetSheetList(), []string{"Akvorado"}); diff != "" {
+		t.Fatalf("XLSX sheets (-got, +want):\n%s", diff)
+	}
+	rows, err := f.GetRows("Akvorado", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatalf("GetRows() error:\n%+v", err)
+	}
+	expected := [][]string{
+		{"Time", "ExporterName", "l3bps"},
+		{"44661.65625", "router1", "1000.5"},
+		{"44661.65694444445", "router<2>", "2000"},
+	}
+	if diff := helpers.Diff(rows, expected); diff != "" {
+		t.Fatalf("XLSX rows (-got, +want):\n%s", diff)
+	}
+	// Times are displayed as dates
+	value, err := f.GetCellValue("Akvorado", "A2")
+	if err != nil {
+		t.Fatalf("GetCellValue() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(value, "4/10/22 15:45"); diff != "" {
+		t.Fatalf("XLSX time (-got, +want):\n%s", diff)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	got := writeAll(t, FormatParquet)
+	if !bytes.HasPrefix(got, []byte(parquetMagic)) || !bytes.HasSuffix(got, []byte(parquetMagic)) {
+		t.Fatalf("Parquet file does not start or end with magic")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(got[len(got)-8:]))
+	footer := got[len(got)-8-footerLength : len(got)-8]
+	for _, expected := range []string{"schema", "Time", "ExporterName", "l3bps", "akvorado"} {
+		if !bytes.Contains(footer, []byte(expected)) {
+			t.Errorf("Parquet footer does not contain %q", expected)
+		}
+	}
+
+	// Pages of each column are right after the magic. Compression is
+	// deterministic, so we can build the expected pages.
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error:\n%+v", err)
+	}
+	defer encoder.Close()
+	values := [][]byte{
+		binary.LittleEndian.AppendUint64(
+			binary.LittleEndian.AppendUint64(nil, uint64(testRows[0][0].(time.Time).UnixMilli())),
+			uint64(testRows[1][0].(time.Time).UnixMilli())),
+		[]byte("\x07\x00\x00\x00router1\x09\x00\x00\x00router<2>"),
+		binary.LittleEndian.AppendUint64(
+			binary.LittleEndian.AppendUint64(nil, math.Float64bits(1000.5)),
+			math.Float64bits(2000)),
+	}
+	expected := []byte(parquetMagic)
+	for _, data := range values {
+		compressed := encoder.EncodeAll(data, nil)
+		e := thriftEncoder{}
+		e.structBegin(0)
+		e.i32(1, parquetPageData)
+		e.i32(2, int32(len(data)))
+		e.i32(3, int32(len(compressed)))
+		e.structBegin(5)
+		e.i32(1, 2)
+		e.i32(2, parquetEncodingPlain)
+		e.i32(3, parquetEncodingRLE)
+		e.i32(4, parquetEncodingRLE)
+		e.structEnd()
+		e.structEnd()
+		expected = append(expected, e.buf...)
+		expected = append(expected, compressed...)
+	}
+	if diff := helpers.Diff(got[:len(expected)], expected); diff != "" {
+		t.Fatalf("Parquet pages (-got, +want):\n%s", diff)
+	}
+	if len(expected)+footerLength+8 != len(got) {
+		t.Fatalf("Parquet file has unexpected data between pages and footer")
+	}
+}
+
+// thriftDecoder decodes structures encoded with the Thrift compact protocol.
+// Integers are returned as int64, binaries as []byte, lists as []any and
+// structures as map[int16]any.
+type thriftDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *thriftDecoder) varint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errors.New("invalid varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *thriftDecoder) zigzag() int64 {
+	v := d.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *thriftDecoder) byte() byte {
+	if len(d.buf) == 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *thriftDecoder) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return d.zigzag()
+	case thriftBinary:
+		n := int(d.varint())
+		if n > len(d.buf) {
+			d.err = io.ErrUnexpectedEOF
+			return nil
+		}
+		v := d.buf[:n]
+		d.buf = d.buf[n:]
+		return v
+	case thriftList:
+		header := d.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.varint())
+		}
+		list := []any{}
+		for range size {
+			if d.err != nil {
+				break
+			}
+			list = append(list, d.value(header&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return d.structure()
+	}
+	d.err = fmt.Errorf("unsupported type %d", typ)
+	return nil
+}
+
+func (d *thriftDecoder) structure() map[int16]any {
+	fields := map[int16]any{}
+	var last int16
+	for d.err == nil {
+		header := d.byte()
+		if header == 0 {
+			break
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(d.zigzag())
+		}
+		fields[last] = d.value(header & 0x0f)
+	}
+	return fields
+}
+
+// readParquet reads back a Parquet file written by parquetWriter and returns
+// the columns and the rows.
+func readParquet(t *testing.T, file []byte) ([]Column, [][]any) {
+	t.Helper()
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatalf("Parquet file does not start or end with magic")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	d := thriftDecoder{buf: file[len(file)-8-footerLength : len(file)-8]}
+	metadata := d.structure()
+	if d.err != nil {
+		t.Fatalf("Parquet footer decoding error:\n%+v", d.err)
+	}
+
+	columns := []Column{}
+	for _, element := range metadata[2].([]any)[1:] {
+		element := element.(map[int16]any)
+		column := Column{Name: string(element[4].([]byte))}
+		switch element[1].(int64) {
+		case parquetTypeInt64:
+			if element[6].(int64) != parquetConvertedTimestampMillis {
+				t.Fatalf("Parquet column %q has unexpected converted type", column.Name)
+			}
+			column.Type = ColumnTime
+		case parquetTypeByteArray:
+			column.Type = ColumnString
+		case parquetTypeDouble:
+			column.Type = ColumnFloat
+		}
+		columns = append(columns, column)
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error:\n%+v", err)
+	}
+	defer decoder.Close()
+	rows := [][]any{}
+	for _, rowGroup := range metadata[4].([]any) {
+		rowGroup := rowGroup.(map[int16]any)
+		count := int(rowGroup[3].(int64))
+		groupRows := make([][]any, count)
+		for idx := range groupRows {
+			groupRows[idx] = make([]any, len(columns))
+		}
+		for idx, chunk := range rowGroup[1].([]any) {
+			meta := chunk.(map[int16]any)[3].(map[int16]any)
+			if meta[4].(int64) != parquetCodecZstd {
+				t.Fatalf("Parquet column %q is not compressed with zstd", columns[idx].Name)
+			}
+			d := thriftDecoder{buf: file[meta[9].(int64):]}
+			header := d.structure()
+			if d.err != nil {
+				t.Fatalf("Parquet page header decoding error:\n%+v", d.err)
+			}
+			compressed := d.buf[:header[3].(int64)]
+			data, err := decoder.DecodeAll(compressed, nil)
+			if err != nil {
+				t.Fatalf("DecodeAll() error:\n%+v", err)
+			}
+			for row := range count {
+				switch columns[idx].Type {
+				case ColumnTime:
+					groupRows[row][idx] = time.UnixMilli(int64(binary.LittleEndian.Uint64(data))).UTC()
+					data = data[8:]
+				case ColumnString:
+					n := binary.LittleEndian.Uint32(data)
+					groupRows[row][idx] = string(data[4 : 4+n])
+					data = data[4+n:]
+				case ColumnFloat:
+					groupRows[row][idx] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+					data = data[8:]
+				}
+			}
+			if len(data) != 0 {
+				t.Fatalf("Parquet column %q has trailing data", columns[idx].Name)
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if metadata[3].(int64) != int64(len(rows)) {
+		t.Fatalf("Parquet file has %d rows, expected %d", len(rows), metadata[3].(int64))
+	}
+	return columns, rows
+}
+
+func TestParquetReadBack(t *testing.T) {
+	columns, rows := readParquet(t, writeAll(t, FormatParquet))
+	if diff := helpers.Diff(columns, testColumns); diff != "" {
+		t.Fatalf("Parquet columns (-got, +want):\n%s", diff)
+	}
+	if diff := helpers.Diff(rows, testRows); diff != "" {
+		t.Fatalf("Parquet rows (-got, +want):\n%s", diff)
+	}
+}
+
+func TestParquetSeveralRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatParquet, &buf, testColumns)
+	if err != nil {
+		t.Fatalf("NewWriter() error:\n%+v", err)
+	}
+	expected := [][]any{}
+	base := time.Date(2022, 4, 10, 15, 45, 0, 0, time.UTC)
+	for i := range parquetRowGroupSize + 10 {
+		row := []any{base.Add(time.Duration(i) * time.Second), fmt.Sprintf("router%d", i%7), float64(i)}
+		expected = append(expected, row)
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write() error:\n%+v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error:\n%+v", err)
+	}
+	_, rows := readParquet(t, buf.Bytes())
+	if diff := helpers.Diff(rows, expected); diff != "" {
+		t.Fatalf("Parquet rows (-got, +want):\n%s", diff)
+	}
+}
+
+func TestThriftEncoder(t *testing.T) {
+	e := thriftEncoder{}
+	e.structBegin(0)
+	e.i32(1, 1)
+	e.i64(20, -3)
+	e.string(21, "ab")
+	e.structBegin(22)
+	e.structEnd()
+	e.list(23, thriftI32, 1)
+	e.zigzag(2)
+	e.structEnd()
+	expected := []byte{
+		0x15, 0x02, // field 1, i32, 1
+		0x06, 0x28, 0x05, // field 20 (long form), i64, -3
+		0x18, 0x02, 'a', 'b', // field 21, binary, "ab"
+		0x1c, 0x00, // field 22, empty struct
+		0x19, 0x15, 0x04, // field 23, list of 1 i32, 2
+		0x00,
+	}
+	if diff := helpers.Diff(e.buf, expected); diff != "" {
+		t.Fatalf("thriftEncoder (-got, +want):\n%s", diff)
+	}
+}
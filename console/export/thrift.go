@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package export
+
+import "encoding/binary"
+
+// Types for the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftEncoder encodes structures with the Thrift compact protocol. This is
+// only what is needed to write Parquet metadata.
+type thriftEncoder struct {
+	buf     []byte
+	lastIDs []int16 // last field ID for each nested structure
+}
+
+func (e *thriftEncoder) varint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *thriftEncoder) zigzag(v int64) {
+	e.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+// field writes the header of a field of the current structure.
+func (e *thriftEncoder) field(id int16, typ byte) {
+	last := &e.lastIDs[len(e.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.zigzag(int64(id))
+	}
+	*last = id
+}
+
+// i32 writes a 32-bit integer field.
+func (e *thriftEncoder) i32(id int16, v int32) {
+	e.field(id, thriftI32)
+	e.zigzag(int64(v))
+}
+
+// i64 writes a 64-bit integer field.
+func (e *thriftEncoder) i64(id int16, v int64) {
+	e.field(id, thriftI64)
+	e.zigzag(v)
+}
+
+// string writes a string field.
+func (e *thriftEncoder) string(id int16, v string) {
+	e.field(id, thriftBinary)
+	e.stringValue(v)
+}
+
+// stringValue writes a string, without a field header (for lists).
+func (e *thriftEncoder) stringValue(v string) {
+	e.varint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// list writes the header of a list field.
+func (e *thriftEncoder) list(id int16, elemType byte, size int) {
+	e.field(id, thriftList)
+	if size < 15 {
+		e.buf = append(e.buf, byte(size)<<4|elemType)
+	} else {
+		e.buf = append(e.buf, 0xf0|elemType)
+		e.varint(uint64(size))
+	}
+}
+
+// structBegin starts a structure field. With an ID of 0, no field header is
+// written (for lists and for the top-level structure).
+func (e *thriftEncoder) structBegin(id int16) {
+	if id != 0 {
+		e.field(id, thriftStruct)
+	}
+	e.lastIDs = append(e.lastIDs, 0)
+}
+
+// structEnd ends a structure.
+func (e *thriftEncoder) structEnd() {
+	e.buf = append(e.buf, 0)
+	e.lastIDs = e.lastIDs[:len(e.lastIDs)-1]
+}
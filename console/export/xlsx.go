@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package export
+
+import (
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheetName is the name of the only sheet of the workbook.
+const xlsxSheetName = "Akvorado"
+
+// xlsxWriter writes an Excel file with a single sheet. Rows are written with
+// the stream writer of excelize which spills them to a temporary file when
+// they do not fit in memory. The archive is written on close.
+type xlsxWriter struct {
+	w    io.Writer
+	f    *excelize.File
+	sw   *excelize.StreamWriter
+	rows int
+}
+
+func newXLSXWriter(w io.Writer, columns []Column) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSheetName); err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw, err := f.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	xw := &xlsxWriter{w: w, f: f, sw: sw}
+	header := make([]any, len(columns))
+	for idx, column := range columns {
+		header[idx] = column.Name
+	}
+	if err := xw.Write(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return xw, nil
+}
+
+// Write writes one row.
+func (xw *xlsxWriter) Write(row []any) error {
+	xw.rows++
+	cell, err := excelize.CoordinatesToCellName(1, xw.rows)
+	if err != nil {
+		return err
+	}
+	return xw.sw.SetRow(cell, row)
+}
+
+// Close terminates the sheet and writes the archive.
+func (xw *xlsxWriter) Close() error {
+	defer xw.f.Close()
+	if err := xw.sw.Flush(); err != nil {
+		return err
+	}
+	_, err := xw.f.WriteTo(xw.w)
+	return err
+}
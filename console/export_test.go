@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/helpers"
+	"akvorado/console/query"
+)
+
+func TestGraphExportHandler(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Export.UserMaxRows = map[string]uint64{"alfred": 10}
+	_, h, mockConn, _ := NewMock(t, config)
+	ctrl := gomock.NewController(t)
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	type result struct {
+		Axis       uint8
+		Time       time.Time
+		Xps        float64
+		Dimensions []string
+	}
+	expectRows := func(results []result) {
+		mockRows := mocks.NewMockRows(ctrl)
+		mockConn.EXPECT().Query(gomock.Any(), gomock.Any()).Return(mockRows, nil)
+		for _, r := range results {
+			mockRows.EXPECT().Next().Return(true)
+			mockRows.EXPECT().Scan(gomock.Any()).
+				DoAndReturn(func(args ...any) any {
+					*args[0].(*uint8) = r.Axis
+					*args[1].(*time.Time) = r.Time
+					*args[2].(*float64) = r.Xps
+					*args[3].(*[]string) = r.Dimensions
+					return nil
+				})
+		}
+		mockRows.EXPECT().Next().Return(false)
+		mockRows.EXPECT().Err().Return(nil)
+		mockRows.EXPECT().Close()
+	}
+	input := func(format string, bidirectional, previousPeriod bool) gin.H {
+		return gin.H{
+			"start":           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+			"end":             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+			"points":          100,
+			"limit":           2,
+			"limitType":       "avg",
+			"dimensions":      []string{"ExporterName", "InIfProvider"},
+			"filter":          "DstCountry = 'FR'",
+			"units":           "l3bps",
+			"bidirectional":   bidirectional,
+			"previous-period": previousPeriod,
+			"format":          format,
+		}
+	}
+
+	t.Run("single direction", func(t *testing.T) {
+		expectRows([]result{
+			{1, base, 1000, []string{"router1", "provider1"}},
+			{1, base, 1900.5, []string{"Other", "Other"}},
+			{1, base.Add(time.Minute), 500, []string{"router1", "provider1"}},
+		})
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				URL:         "/api/v0/console/graph/line/export",
+				JSONInput:   input("csv", false, false),
+				ContentType: "text/csv; charset=utf-8",
+				FirstLines: []string{
+					"Time,ExporterName,InIfProvider,l3bps",
+					"2009-11-10T23:00:00Z,router1,provider1,1000",
+					"2009-11-10T23:00:00Z,Other,Other,1900.5",
+					"2009-11-10T23:01:00Z,router1,provider1,500",
+				},
+			},
+		})
+	})
+
+	t.Run("several axes", func(t *testing.T) {
+		expectRows([]result{
+			{1, base, 1000, []string{"router1", "provider1"}},
+			{2, base, 100, []string{"router1", "provider1"}},
+			{3, base, 800, []string{}},
+		})
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				URL:         "/api/v0/console/graph/line/export",
+				JSONInput:   input("csv", true, true),
+				ContentType: "text/csv; charset=utf-8",
+				FirstLines: []string{
+					"Time,Axis,ExporterName,InIfProvider,l3bps",
+					"2009-11-10T23:00:00Z,Direct,router1,provider1,1000",
+					"2009-11-10T23:00:00Z,Reverse,router1,provider1,100",
+					"2009-11-10T23:00:00Z,Previous day,Other,Other,800",
+				},
+			},
+		})
+	})
+
+	t.Run("excel", func(t *testing.T) {
+		expectRows([]result{
+			{1, base, 1000, []string{"router1", "provider1"}},
+			{1, base.Add(time.Minute), 500, []string{"router1", "provider1"}},
+		})
+		payload, err := json.Marshal(input("xlsx", false, false))
+		if err != nil {
+			t.Fatalf("json.Marshal() error:\n%+v", err)
+		}
+		resp, err := http.Post(fmt.Sprintf("http://%s/api/v0/console/graph/line/export", h.LocalAddr()),
+			"application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /api/v0/console/graph/line/export:\n%+v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("POST /api/v0/console/graph/line/export: got status code %d, not 200", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="akvorado-20220410-154510.xlsx"` {
+			t.Errorf("POST /api/v0/console/graph/line/export: got Content-Disposition %q", got)
+		}
+		f, err := excelize.OpenReader(resp.Body)
+		if err != nil {
+			t.Fatalf("OpenReader() error:\n%+v", err)
+		}
+		defer f.Close()
+		got, err := f.GetRows("Akvorado", excelize.Options{RawCellValue: true})
+		if err != nil {
+			t.Fatalf("GetRows() error:\n%+v", err)
+		}
+		expected := [][]string{
+			{"Time", "ExporterName", "InIfProvider", "l3bps"},
+			{"40127.958333333336", "router1", "provider1", "1000"},
+			{"40127.959027777775", "router1", "provider1", "500"},
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("GetRows() (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("error while reading rows", func(t *testing.T) {
+		mockRows := mocks.NewMockRows(ctrl)
+		mockConn.EXPECT().Query(gomock.Any(), gomock.Any()).Return(mockRows, nil)
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().Scan(gomock.Any()).
+			DoAndReturn(func(args ...any) any {
+				*args[0].(*uint8) = 1
+				*args[1].(*time.Time) = base
+				*args[2].(*float64) = 1000
+				*args[3].(*[]string) = []string{"router1", "provider1"}
+				return nil
+			})
+		mockRows.EXPECT().Next().Return(false)
+		mockRows.EXPECT().Err().Return(errors.New("connection reset"))
+		mockRows.EXPECT().Close()
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				URL:        "/api/v0/console/graph/line/export",
+				JSONInput:  input("csv", false, false),
+				StatusCode: 500,
+				JSONOutput: gin.H{"message": "Unable to query database."},
+			},
+		})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				Description: "unknown format",
+				URL:         "/api/v0/console/graph/line/export",
+				JSONInput:   input("pdf", false, false),
+				StatusCode:  400,
+				JSONOutput: gin.H{
+					"message": "Key: 'graphExportHandlerInput.Format' Error:Field validation for 'Format' failed on the 'oneof' tag",
+				},
+			}, {
+				Description: "too many rows",
+				URL:         "/api/v0/console/graph/line/export",
+				Header: func() http.Header {
+					headers := make(http.Header)
+					headers.Add("Remote-User", "alfred")
+					return headers
+				}(),
+				JSONInput:  input("xlsx", false, false),
+				StatusCode: 400,
+				JSONOutput: gin.H{
+					"message": "Export would contain up to 306 rows, maximum is 10. Reduce the time range or the number of points.",
+				},
+			},
+		})
+	})
+}
+
+func TestEstimateRows(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	input := graphLineHandlerInput{
+		graphCommonHandlerInput: graphCommonHandlerInput{
+			Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+			End:   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+			Limit: 10,
+			Units: "l3bps",
+		},
+		Points:         100,
+		Bidirectional:  true,
+		PreviousPeriod: true,
+	}
+	input.schema = c.d.Schema
+	if err := c.validateGraphLineInput(&input); err != nil {
+		t.Fatalf("validateGraphLineInput() error:\n%+v", err)
+	}
+	// 4 axes with 102 points each
+	if got, expected := c.estimateRows(input, input.toSQL()), uint64(408); got != expected {
+		t.Errorf("estimateRows() == %d, expected %d", got, expected)
+	}
+	// With dimensions, 11 rows for each point
+	input.Dimensions = []query.Column{query.NewColumn("ExporterName")}
+	if err := c.validateGraphLineInput(&input); err != nil {
+		t.Fatalf("validateGraphLineInput() error:\n%+v", err)
+	}
+	if got, expected := c.estimateRows(input, input.toSQL()), uint64(4488); got != expected {
+		t.Errorf("estimateRows() == %d, expected %d", got, expected)
+	}
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exporterStaleDelay is the delay after which an exporter not sending flows is
+// considered stale.
+const exporterStaleDelay = 5 * time.Minute
+
+// exporterResult is an exporter as returned by ClickHouse.
+type exporterResult struct {
+	Address          string    `json:"address"`
+	Name             string    `json:"name"`
+	Group            string    `json:"group"`
+	Role             string    `json:"role"`
+	Site             string    `json:"site"`
+	Region           string    `json:"region"`
+	Tenant           string    `json:"tenant"`
+	Interfaces       uint64    `json:"interfaces"`
+	LastFlow         time.Time `json:"last-flow"`
+	FlowRate         float64   `json:"flow-rate"`
+	SamplingRate     float64   `json:"sampling-rate"`
+	DecodeErrors     uint64    `json:"decode-errors"`
+	EnrichmentMisses uint64    `json:"enrichment-misses"`
+}
+
+// exporterOutput is an exporter as returned by the API.
+type exporterOutput struct {
+	exporterResult
+	Stale bool `json:"stale"`
+}
+
+// exportersQuery returns the known exporters with the time of their last flow.
+// The flow rate and the sampling rate are computed over the last 5 minutes. The
+// decode errors and the enrichment misses are summed over the last hour from
+// the ingest accounting table.
+const exportersQuery = `
+SELECT
+ replaceRegexpOne(IPv6NumToString(ExporterAddress), '^::ffff:', '') AS Address,
+ e.Name, e.Group, e.Role, e.Site, e.Region, e.Tenant,
+ e.Interfaces, e.LastFlow,
+ r.FlowRate, r.SamplingRate,
+ a.DecodeErrors, a.EnrichmentMisses
+FROM (
+ SELECT
+  ExporterAddress,
+  argMax(ExporterName, TimeReceived) AS Name,
+  argMax(ExporterGroup, TimeReceived) AS Group,
+  argMax(ExporterRole, TimeReceived) AS Role,
+  argMax(ExporterSite, TimeReceived) AS Site,
+  argMax(ExporterRegion, TimeReceived) AS Region,
+  argMax(ExporterTenant, TimeReceived) AS Tenant,
+  uniqExact(IfName) AS Interfaces,
+  max(TimeReceived) AS LastFlow
+ FROM exporters
+ GROUP BY ExporterAddress
+) AS e
+LEFT JOIN (
+ SELECT
+  ExporterAddress,
+  COUNT(*)/300 AS FlowRate,
+  avg(SamplingRate) AS SamplingRate
+ FROM flows
+ WHERE TimeReceived > date_sub(minute, 5, now())
+ GROUP BY ExporterAddress
+) AS r
+USING ExporterAddress
+LEFT JOIN (
+ SELECT
+  ExporterAddress,
+  SUM(DecodeErrors) AS DecodeErrors,
+  SUM(EnrichmentMisses) AS EnrichmentMisses
+ FROM exporters_accounting
+ WHERE TimeReceived > date_sub(hour, 1, now())
+ GROUP BY ExporterAddress
+) AS a
+USING ExporterAddress
+ORDER BY e.Name, Address`
+
+func (c *Component) exportersHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	gc.Header("X-SQL-Query", exportersQuery)
+
+	results := []exporterResult{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, exportersQuery); err != nil {
+		c.queryError(gc, err, exportersQuery)
+		return
+	}
+	now := c.d.Clock.Now()
+	exporters := make([]exporterOutput, len(results))
+	stale := 0
+	for idx, result := range results {
+		exporters[idx] = exporterOutput{
+			exporterResult: result,
+			Stale:          now.Sub(result.LastFlow) > exporterStaleDelay,
+		}
+		if exporters[idx].Stale {
+			stale++
+		}
+	}
+	gc.IndentedJSON(http.StatusOK, gin.H{
+		"exporters": exporters,
+		"stale":     stale,
+	})
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+)
+
+func TestExporters(t *testing.T) {
+	_, h, mockConn, mockClock := NewMock(t, DefaultConfiguration())
+	mockClock.Set(time.Date(2022, 4, 12, 15, 45, 10, 0, time.UTC))
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), exportersQuery).
+		SetArg(1, []exporterResult{
+			{
+				Address:          "192.0.2.1",
+				Name:             "router1",
+				Group:            "core",
+				Site:             "paris",
+				Interfaces:       12,
+				LastFlow:         time.Date(2022, 4, 12, 15, 45, 0, 0, time.UTC),
+				FlowRate:         1500.5,
+				SamplingRate:     1000,
+				EnrichmentMisses: 14,
+			}, {
+				Address:    "192.0.2.2",
+				Name:       "router2",
+				Group:      "edge",
+				Site:       "lyon",
+				Interfaces: 4,
+				LastFlow:   time.Date(2022, 4, 12, 14, 15, 0, 0, time.UTC),
+			},
+		}).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/exporters",
+			JSONOutput: gin.H{
+				"stale": 1,
+				"exporters": []gin.H{
+					{
+						"address":           "192.0.2.1",
+						"name":              "router1",
+						"group":             "core",
+						"role":              "",
+						"site":              "paris",
+						"region":            "",
+						"tenant":            "",
+						"interfaces":        12,
+						"last-flow":         "2022-04-12T15:45:00Z",
+						"flow-rate":         1500.5,
+						"sampling-rate":     1000,
+						"stale":             false,
+						"decode-errors":     0,
+						"enrichment-misses": 14,
+					}, {
+						"address":           "192.0.2.2",
+						"name":              "router2",
+						"group":             "edge",
+						"role":              "",
+						"site":              "lyon",
+						"region":            "",
+						"tenant":            "",
+						"interfaces":        4,
+						"last-flow":         "2022-04-12T14:15:00Z",
+						"flow-rate":         0,
+						"sampling-rate":     0,
+						"stale":             true,
+						"decode-errors":     0,
+						"enrichment-misses": 0,
+					},
+				},
+			},
+		},
+	})
+}
@@ -4,8 +4,10 @@
 package console
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +19,7 @@ import (
 	"akvorado/console/authentication"
 	"akvorado/console/database"
 	"akvorado/console/filter"
+	"akvorado/console/query"
 )
 
 // filterValidateHandlerInput describes the input for the /filter/validate endpoint.
@@ -58,6 +61,166 @@ func (c *Component) filterValidateHandlerFunc(gc *gin.Context) {
 	})
 }
 
+// filterDrillDownHandlerInput describes the input of the /filter/drilldown
+// endpoint. Values are the ones returned for each dimension by a graph.
+type filterDrillDownHandlerInput struct {
+	Dimensions     []query.Column `json:"dimensions" binding:"required,min=1"`
+	Values         []string       `json:"values" binding:"required,min=1"`
+	TruncateAddrV4 int            `json:"truncate-v4" binding:"min=0,max=32"`
+	TruncateAddrV6 int            `json:"truncate-v6" binding:"min=0,max=128"`
+}
+
+// filterDrillDownHandlerOutput describes the output of the /filter/drilldown
+// endpoint. For each dimension, it contains the filter expression selecting
+// the provided value, or an empty string if this is not possible.
+type filterDrillDownHandlerOutput struct {
+	Expressions []string `json:"expressions"`
+}
+
+func (c *Component) filterDrillDownHandlerFunc(gc *gin.Context) {
+	var input filterDrillDownHandlerInput
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if len(input.Dimensions) != len(input.Values) {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Dimensions and values should have the same length."})
+		return
+	}
+	if err := query.Columns(input.Dimensions).Validate(c.d.Schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	expressions := make([]string, len(input.Dimensions))
+	for idx, qc := range input.Dimensions {
+		expressions[idx] = c.drillDownExpression(qc, input.Values[idx],
+			input.TruncateAddrV4, input.TruncateAddrV6)
+	}
+	gc.JSON(http.StatusOK, filterDrillDownHandlerOutput{Expressions: expressions})
+}
+
+// drillDownExpression returns the filter expression selecting the provided
+// value for a dimension, or an empty string if this is not possible.
+func (c *Component) drillDownExpression(qc query.Column, value string, truncateV4, truncateV6 int) string {
+	if value == "Other" {
+		return ""
+	}
+	// Truncated IP addresses select the matching subnet
+	if column, _ := c.d.Schema.LookupColumnByKey(qc.Key()); column.ConsoleTruncateIP {
+		if ip, err := netip.ParseAddr(value); err == nil {
+			if ip.Is4() && truncateV4 > 0 && truncateV4 < 32 {
+				return fmt.Sprintf("%s << %s/%d", qc, ip, truncateV4)
+			}
+			if ip.Is6() && truncateV6 > 0 && truncateV6 < 128 {
+				return fmt.Sprintf("%s << %s/%d", qc, ip, truncateV6)
+			}
+		}
+	}
+	expression, _ := qc.ToFilterExpression(c.d.Schema, value)
+	return expression
+}
+
+// filterSelectionHandlerInput describes the input of the /filter/selection
+// endpoint. Rows are several rows returned by a graph.
+type filterSelectionHandlerInput struct {
+	Dimensions     []query.Column `json:"dimensions" binding:"required,min=1"`
+	Rows           [][]string     `json:"rows" binding:"required,min=1"`
+	Exclude        bool           `json:"exclude"`
+	TruncateAddrV4 int            `json:"truncate-v4" binding:"min=0,max=32"`
+	TruncateAddrV6 int            `json:"truncate-v6" binding:"min=0,max=128"`
+}
+
+// filterSelectionHandlerOutput describes the output of the /filter/selection
+// endpoint. It contains a filter expression selecting (or excluding) all the
+// provided rows.
+type filterSelectionHandlerOutput struct {
+	Expression string `json:"expression"`
+}
+
+func (c *Component) filterSelectionHandlerFunc(gc *gin.Context) {
+	var input filterSelectionHandlerInput
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	for _, row := range input.Rows {
+		if len(row) != len(input.Dimensions) {
+			gc.JSON(http.StatusBadRequest, gin.H{"message": "Dimensions and values should have the same length."})
+			return
+		}
+	}
+	if err := query.Columns(input.Dimensions).Validate(c.d.Schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	expression := c.selectionExpression(input)
+	if expression == "" {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "No filter expression can select these rows."})
+		return
+	}
+	gc.JSON(http.StatusOK, filterSelectionHandlerOutput{Expression: expression})
+}
+
+// selectionExpression returns the filter expression selecting (or excluding)
+// all the rows of the provided input. With only one dimension, an IN (or
+// NOTIN) list is used when the column supports it. Otherwise, the expressions
+// for each row are combined with OR.
+func (c *Component) selectionExpression(input filterSelectionHandlerInput) string {
+	rows := []string{}
+	values := []string{}
+	for _, row := range input.Rows {
+		expressions := []string{}
+		for idx, qc := range input.Dimensions {
+			expression := c.drillDownExpression(qc, row[idx], input.TruncateAddrV4, input.TruncateAddrV6)
+			if expression != "" {
+				expressions = append(expressions, expression)
+			}
+		}
+		switch len(expressions) {
+		case 0:
+			continue
+		case 1:
+			rows = append(rows, expressions[0])
+		default:
+			rows = append(rows, fmt.Sprintf("(%s)", strings.Join(expressions, " AND ")))
+		}
+		if len(input.Dimensions) == 1 && values != nil {
+			value, ok := strings.CutPrefix(expressions[0], fmt.Sprintf("%s = ", input.Dimensions[0]))
+			if ok {
+				values = append(values, value)
+			} else {
+				values = nil
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	// Try an IN list. The filter parser tells if the column supports it.
+	if len(values) > 1 {
+		operator := "IN"
+		if input.Exclude {
+			operator = "NOTIN"
+		}
+		expression := fmt.Sprintf("%s %s (%s)", input.Dimensions[0], operator, strings.Join(values, ", "))
+		if _, err := filter.Parse("", []byte(expression),
+			filter.GlobalStore("meta", &filter.Meta{Schema: c.d.Schema})); err == nil {
+			return expression
+		}
+	}
+
+	switch {
+	case len(rows) == 1 && !input.Exclude:
+		return rows[0]
+	case len(rows) == 1 && strings.HasPrefix(rows[0], "("):
+		return fmt.Sprintf("NOT %s", rows[0])
+	case input.Exclude:
+		return fmt.Sprintf("NOT (%s)", strings.Join(rows, " OR "))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(rows, " OR "))
+}
+
 // filterCompleteHandlerInput describes the input of the /filter/complete endpoint.
 type filterCompleteHandlerInput struct {
 	What   string `json:"what" binding:"required,oneof=column operator value"`
@@ -90,7 +253,7 @@ func (c *Component) filterCompleteHandlerFunc(gc *gin.Context) {
 		// We use the schema directly.
 		columns := []string{}
 		for _, column := range c.d.Schema.Columns() {
-			if column.Disabled {
+			if column.Disabled || column.AliasOf != 0 {
 				continue
 			}
 			if strings.HasPrefix(strings.ToLower(column.Name), strings.ToLower(input.Prefix)) {
@@ -151,6 +314,20 @@ func (c *Component) filterCompleteHandlerFunc(gc *gin.Context) {
 				Label:  "IPv6",
 				Detail: "ethernet type",
 			})
+		case "tcpflags":
+			for _, flag := range []string{"FIN", "SYN", "RST", "PSH", "ACK", "URG", "ECE", "CWR", "NS"} {
+				completions = append(completions, filterCompletion{
+					Label:  flag,
+					Detail: "TCP flag",
+				})
+			}
+		case "natevent":
+			for _, event := range []string{"create", "delete", "exhausted"} {
+				completions = append(completions, filterCompletion{
+					Label:  event,
+					Detail: "NAT event",
+				})
+			}
 		case "proto":
 			// Do not complete from ClickHouse, we want a subset of options
 			completions = append(completions,
@@ -382,6 +559,58 @@ UNION DISTINCT
 				})
 			}
 			input.Prefix = ""
+		case "dropreason":
+			results := []struct {
+				Label string `ch:"label"`
+			}{}
+			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, fmt.Sprintf(`
+SELECT DropReason AS label
+FROM flows
+WHERE TimeReceived > date_sub(minute, 10, now())
+AND DropReason != ''
+AND positionCaseInsensitive(label, $1) >= 1
+GROUP BY DropReason
+ORDER BY COUNT(*) DESC
+LIMIT %d`, input.Limit), input.Prefix); err != nil {
+				c.r.Err(err).Msg("unable to query database")
+				break
+			}
+			for _, result := range results {
+				completions = append(completions, filterCompletion{
+					Label:  result.Label,
+					Detail: "drop reason",
+					Quoted: true,
+				})
+			}
+			input.Prefix = ""
+		case "inifgroup", "outifgroup":
+			columnName := "InIfGroup"
+			if inputColumn == "outifgroup" {
+				columnName = "OutIfGroup"
+			}
+			results := []struct {
+				Label string `ch:"label"`
+			}{}
+			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, fmt.Sprintf(`
+SELECT %s AS label
+FROM flows
+WHERE TimeReceived > date_sub(minute, 10, now())
+AND %s != ''
+AND positionCaseInsensitive(label, $1) >= 1
+GROUP BY %s
+ORDER BY COUNT(*) DESC
+LIMIT %d`, columnName, columnName, columnName, input.Limit), input.Prefix); err != nil {
+				c.r.Err(err).Msg("unable to query database")
+				break
+			}
+			for _, result := range results {
+				completions = append(completions, filterCompletion{
+					Label:  result.Label,
+					Detail: "interface group",
+					Quoted: true,
+				})
+			}
+			input.Prefix = ""
 		case "exportername", "exportergroup", "exporterrole", "exportersite", "exporterregion", "exportertenant":
 			column = c.fixQueryColumnName(inputColumn)
 			detail = fmt.Sprintf("exporter %s", inputColumn[8:])
@@ -465,7 +694,7 @@ LIMIT %d`, col.Name, col.Name, input.Limit), input.Prefix); err != nil {
 func (c *Component) filterSavedListHandlerFunc(gc *gin.Context) {
 	ctx := c.t.Context(gc.Request.Context())
 	user := gc.MustGet("user").(authentication.UserInformation).Login
-	filters, err := c.d.Database.ListSavedFilters(ctx, user)
+	filters, err := c.d.Database.SearchSavedFilters(ctx, user, gc.Query("q"), gc.QueryArray("tag"))
 	if err != nil {
 		c.r.Err(err).Msg("unable to list filters")
 		gc.JSON(http.StatusInternalServerError, gin.H{"message": "unable to list filters"})
@@ -493,6 +722,51 @@ func (c *Component) filterSavedDeleteHandlerFunc(gc *gin.Context) {
 	gc.JSON(http.StatusNoContent, nil)
 }
 
+func (c *Component) filterSavedUpdateHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	id, err := strconv.ParseUint(gc.Param("id"), 10, 64)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "bad ID format"})
+		return
+	}
+	var filter database.SavedFilter
+	if err := gc.ShouldBindJSON(&filter); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	filter.ID = id
+	filter.User = user
+	if err := c.d.Database.UpdateSavedFilter(ctx, filter); errors.Is(err, database.ErrSavedFilterNotFound) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "filter not found"})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("cannot update saved filter")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "cannot update filter"})
+		return
+	}
+	gc.JSON(http.StatusNoContent, nil)
+}
+
+func (c *Component) filterSavedUseHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	id, err := strconv.ParseUint(gc.Param("id"), 10, 64)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "bad ID format"})
+		return
+	}
+	if err := c.d.Database.UseSavedFilter(ctx, id, user); errors.Is(err, database.ErrSavedFilterNotFound) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "filter not found"})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("cannot record saved filter usage")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "cannot record filter usage"})
+		return
+	}
+	gc.JSON(http.StatusNoContent, nil)
+}
+
 func (c *Component) filterSavedAddHandlerFunc(gc *gin.Context) {
 	ctx := c.t.Context(gc.Request.Context())
 	user := gc.MustGet("user").(authentication.UserInformation).Login
@@ -22,6 +22,8 @@ type Meta struct {
 	ReverseDirection bool
 	// MainTableRequired tells if the main table is required to execute the expression (used as output)
 	MainTableRequired bool
+	// Columns lists the columns used by the expression (used as output)
+	Columns []schema.ColumnKey
 }
 
 // flattenExpr takes an expression and flattens it to a slice of strings. It
@@ -56,6 +58,9 @@ func (c *current) flattenExpr(expr []any, meta *Meta) []string {
 		if col.ClickHouseMainOnly {
 			meta.MainTableRequired = true
 		}
+		if !slices.Contains(meta.Columns, col.Key) {
+			meta.Columns = append(meta.Columns, col.Key)
+		}
 		return col
 	}
 	var result []string // flattened, pre-join
@@ -107,6 +112,12 @@ func (c *current) acceptColumn() (schema.Column, error) {
 	sch := c.globalStore["meta"].(*Meta).Schema
 	for _, column := range sch.Columns() {
 		if strings.EqualFold(name, column.Name) {
+			if column.AliasOf != 0 {
+				// Use the target of a column alias
+				if target, ok := sch.LookupColumnByKey(column.AliasOf); ok {
+					return *target, nil
+				}
+			}
 			return column, nil
 		}
 	}
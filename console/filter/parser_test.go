@@ -8,6 +8,8 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/common/schema"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestValidFilter(t *testing.T) {
@@ -307,6 +309,18 @@ output provider */ = 'telia'`,
 			Input: `DstPortNAT = 22`, Output: `DstPortNAT = 22`,
 			MetaOut: Meta{MainTableRequired: true},
 		},
+		{
+			Input: `NATEvent = 4`, Output: `NATEvent = 4`,
+			MetaOut: Meta{MainTableRequired: true},
+		},
+		{
+			Input: `natevent = create`, Output: `NATEvent IN (1, 4, 6, 8, 10, 14, 16)`,
+			MetaOut: Meta{MainTableRequired: true},
+		},
+		{
+			Input: `NATEvent != EXHAUSTED`, Output: `NATEvent NOT IN (3, 12, 13, 18)`,
+			MetaOut: Meta{MainTableRequired: true},
+		},
 		{Input: `SrcMAC = 00:11:22:33:44:55`, Output: `SrcMAC = MACStringToNum('00:11:22:33:44:55')`},
 		{Input: `DstMAC = 00:11:22:33:44:55`, Output: `DstMAC = MACStringToNum('00:11:22:33:44:55')`},
 		{Input: `SrcMAC != 00:0c:fF:33:44:55`, Output: `SrcMAC != MACStringToNum('00:0c:ff:33:44:55')`},
@@ -317,6 +331,8 @@ output provider */ = 'telia'`,
 		{Input: `ipfragmentoffset = 3`, Output: `IPFragmentOffset = 3`},
 		{Input: `ipv6flowlabel = 0`, Output: `IPv6FlowLabel = 0`},
 		{Input: `tcpflags = 2`, Output: `TCPFlags = 2`},
+		{Input: `TCPFlags HAS SYN`, Output: `bitTest(TCPFlags, 1) = 1`},
+		{Input: `tcpflags has syn and tcpflags nothas ack`, Output: `bitTest(TCPFlags, 1) = 1 AND bitTest(TCPFlags, 4) = 0`},
 		{Input: `icmpv4type = 8 AND icmpv4code = 0`, Output: `ICMPv4Type = 8 AND ICMPv4Code = 0`},
 		{Input: `icmpv6type = 8 or icmpv6code = 0`, Output: `ICMPv6Type = 8 OR ICMPv6Code = 0`},
 		{Input: `icmpv6 = "echo-reply"`, Output: `ICMPv6 = 'echo-reply'`},
@@ -359,12 +375,89 @@ output provider */ = 'telia'`,
 		if diff := helpers.Diff(got.(string), tc.Output); diff != "" {
 			t.Errorf("Parse(%q) (-got, +want):\n%s", tc.Input, diff)
 		}
-		if diff := helpers.Diff(tc.MetaIn, tc.MetaOut); diff != "" {
+		if diff := helpers.Diff(tc.MetaIn, tc.MetaOut, cmpopts.IgnoreFields(Meta{}, "Columns")); diff != "" {
 			t.Errorf("Parse(%q) meta (-got, +want):\n%s", tc.Input, diff)
 		}
 	}
 }
 
+func TestFilterColumns(t *testing.T) {
+	cases := []struct {
+		Input    string
+		MetaIn   Meta
+		Expected []schema.ColumnKey
+	}{
+		{
+			Input:    `ExporterName = 'something'`,
+			Expected: []schema.ColumnKey{schema.ColumnExporterName},
+		}, {
+			Input:    `SrcCountry = 'FR' AND (DstCountry = 'US' OR SrcCountry = 'DE')`,
+			Expected: []schema.ColumnKey{schema.ColumnSrcCountry, schema.ColumnDstCountry},
+		}, {
+			Input:    `SrcCountry = 'FR' AND InIfBoundary = external`,
+			MetaIn:   Meta{ReverseDirection: true},
+			Expected: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnOutIfBoundary},
+		},
+	}
+	for _, tc := range cases {
+		tc.MetaIn.Schema = schema.NewMock(t)
+		if _, err := Parse("", []byte(tc.Input), GlobalStore("meta", &tc.MetaIn)); err != nil {
+			t.Errorf("Parse(%q) error:\n%+v", tc.Input, err)
+			continue
+		}
+		if diff := helpers.Diff(tc.MetaIn.Columns, tc.Expected); diff != "" {
+			t.Errorf("Parse(%q) columns (-got, +want):\n%s", tc.Input, diff)
+		}
+	}
+}
+
+func TestColumnAliasFilter(t *testing.T) {
+	cases := []struct {
+		Input    string
+		MetaIn   Meta
+		Output   string
+		Expected []schema.ColumnKey
+	}{
+		{
+			Input:    `Exporter = 'something'`,
+			Output:   `ExporterName = 'something'`,
+			Expected: []schema.ColumnKey{schema.ColumnExporterName},
+		}, {
+			Input:    `SrcCountryCode = 'FR'`,
+			Output:   `SrcCountry = 'FR'`,
+			Expected: []schema.ColumnKey{schema.ColumnSrcCountry},
+		}, {
+			Input:    `SrcCountryCode = 'FR'`,
+			MetaIn:   Meta{ReverseDirection: true},
+			Output:   `DstCountry = 'FR'`,
+			Expected: []schema.ColumnKey{schema.ColumnDstCountry},
+		},
+	}
+	config := schema.DefaultConfiguration()
+	config.ColumnAliases = []schema.ColumnAlias{
+		{Name: "Exporter", Column: "ExporterName"},
+		{Name: "SrcCountryCode", Column: "SrcCountry"},
+	}
+	s, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	for _, tc := range cases {
+		tc.MetaIn.Schema = s
+		got, err := Parse("", []byte(tc.Input), GlobalStore("meta", &tc.MetaIn))
+		if err != nil {
+			t.Errorf("Parse(%q) error:\n%+v", tc.Input, err)
+			continue
+		}
+		if diff := helpers.Diff(got.(string), tc.Output); diff != "" {
+			t.Errorf("Parse(%q) (-got, +want):\n%s", tc.Input, diff)
+		}
+		if diff := helpers.Diff(tc.MetaIn.Columns, tc.Expected); diff != "" {
+			t.Errorf("Parse(%q) columns (-got, +want):\n%s", tc.Input, diff)
+		}
+	}
+}
+
 func TestValidMaterializedFilter(t *testing.T) {
 	cases := []struct {
 		Input   string
@@ -404,7 +497,7 @@ func TestValidMaterializedFilter(t *testing.T) {
 		if diff := helpers.Diff(got.(string), tc.Output); diff != "" {
 			t.Errorf("Parse(%q) (-got, +want):\n%s", tc.Input, diff)
 		}
-		if diff := helpers.Diff(tc.MetaIn, tc.MetaOut); diff != "" {
+		if diff := helpers.Diff(tc.MetaIn, tc.MetaOut, cmpopts.IgnoreFields(Meta{}, "Columns")); diff != "" {
 			t.Errorf("Parse(%q) meta (-got, +want):\n%s", tc.Input, diff)
 		}
 	}
@@ -439,6 +532,9 @@ func TestInvalidFilter(t *testing.T) {
 		{Input: `SrcVlan = 1000`},
 		{Input: `DstVlan = 1000`},
 		{Input: `SrcMAC = 00:11:22:33:44:55:66`, EnableAll: true},
+		{Input: `TCPFlags HAS FOO`, EnableAll: true},
+		{Input: `NATEvent = foo`, EnableAll: true},
+		{Input: `TCPFlags HAS 2`, EnableAll: true},
 		{Input: `SrcAddrDimensionAttribute = 8`},
 		{Input: `InvalidDimensionAttribute = "Test"`},
 	}
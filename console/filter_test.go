@@ -229,6 +229,22 @@ UNION DISTINCT
 				{"label": "IPv6", "detail": "ethernet type", "quoted": false},
 			}},
 		},
+		{
+			URL:        "/api/v0/console/filter/complete",
+			StatusCode: 200,
+			JSONInput:  gin.H{"what": "value", "column": "tcpflags", "prefix": "c"},
+			JSONOutput: gin.H{"completions": []gin.H{
+				{"label": "CWR", "detail": "TCP flag", "quoted": false},
+			}},
+		},
+		{
+			URL:        "/api/v0/console/filter/complete",
+			StatusCode: 200,
+			JSONInput:  gin.H{"what": "value", "column": "natevent", "prefix": "e"},
+			JSONOutput: gin.H{"completions": []gin.H{
+				{"label": "exhausted", "detail": "NAT event", "quoted": false},
+			}},
+		},
 		{
 			URL:        "/api/v0/console/filter/complete",
 			StatusCode: 200,
@@ -353,9 +369,128 @@ UNION DISTINCT
 					"user":        "__default",
 					"description": "test 1",
 					"content":     "InIfBoundary = external",
+					"uses":        0,
+				},
+			}},
+		},
+		{
+			Description: "store one filter with tags",
+			URL:         "/api/v0/console/filter/saved",
+			StatusCode:  204,
+			JSONInput: gin.H{
+				"description": "test 2",
+				"content":     "InIfBoundary = internal",
+				"shared":      true,
+				"tags":        []string{"internal"},
+			},
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "store one filter with an empty tag",
+			URL:         "/api/v0/console/filter/saved",
+			StatusCode:  400,
+			JSONInput: gin.H{
+				"description": "test 3",
+				"content":     "InIfBoundary = internal",
+				"tags":        []string{""},
+			},
+			JSONOutput: gin.H{"message": "Key: 'SavedFilter.Tags[0]' Error:Field validation for 'Tags[0]' failed on the 'required' tag"},
+		},
+		{
+			Description: "search stored filters",
+			URL:         "/api/v0/console/filter/saved?q=INTERNAL",
+			JSONOutput: gin.H{"filters": []gin.H{
+				{
+					"id":          2,
+					"shared":      true,
+					"user":        "__default",
+					"description": "test 2",
+					"content":     "InIfBoundary = internal",
+					"tags":        []string{"internal"},
+					"uses":        0,
+				},
+			}},
+		},
+		{
+			Description: "search stored filters by tag",
+			URL:         "/api/v0/console/filter/saved?tag=external",
+			JSONOutput:  gin.H{"filters": []gin.H{}},
+		},
+		{
+			Description: "update stored filter as another user",
+			Method:      "PUT",
+			URL:         "/api/v0/console/filter/saved/2",
+			Header: func() http.Header {
+				headers := make(http.Header)
+				headers.Add("Remote-User", "alfred")
+				return headers
+			}(),
+			JSONInput: gin.H{
+				"description": "test 2 by alfred",
+				"content":     "InIfBoundary = internal",
+			},
+			StatusCode: 404,
+			JSONOutput: gin.H{"message": "filter not found"},
+		},
+		{
+			Description: "update stored filter",
+			Method:      "PUT",
+			URL:         "/api/v0/console/filter/saved/2",
+			JSONInput: gin.H{
+				"description": "test 2 updated",
+				"content":     "InIfBoundary = internal",
+				"shared":      true,
+				"tags":        []string{"internal", "updated"},
+			},
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "use stored filter as another user",
+			Method:      "POST",
+			URL:         "/api/v0/console/filter/saved/2/use",
+			Header: func() http.Header {
+				headers := make(http.Header)
+				headers.Add("Remote-User", "alfred")
+				return headers
+			}(),
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		},
+		{
+			Description: "use private stored filter as another user",
+			Method:      "POST",
+			URL:         "/api/v0/console/filter/saved/1/use",
+			Header: func() http.Header {
+				headers := make(http.Header)
+				headers.Add("Remote-User", "alfred")
+				return headers
+			}(),
+			StatusCode: 404,
+			JSONOutput: gin.H{"message": "filter not found"},
+		},
+		{
+			Description: "list stored filters after update",
+			URL:         "/api/v0/console/filter/saved?tag=updated",
+			JSONOutput: gin.H{"filters": []gin.H{
+				{
+					"id":          2,
+					"shared":      true,
+					"user":        "__default",
+					"description": "test 2 updated",
+					"content":     "InIfBoundary = internal",
+					"tags":        []string{"internal", "updated"},
+					"uses":        1,
 				},
 			}},
 		},
+		{
+			Description: "delete stored filter with tags",
+			Method:      "DELETE",
+			URL:         "/api/v0/console/filter/saved/2",
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		},
 		{
 			Description: "list stored filters as another user",
 			URL:         "/api/v0/console/filter/saved",
@@ -398,6 +533,104 @@ UNION DISTINCT
 			StatusCode:  200,
 			JSONOutput:  gin.H{"filters": []gin.H{}},
 		},
+		{
+			Description: "drill down",
+			URL:         "/api/v0/console/filter/drilldown",
+			JSONInput: gin.H{
+				"dimensions": []string{"ExporterName", "SrcAS", "InIfBoundary", "DstPort", "SrcAddr", "Proto"},
+				"values":     []string{"th2-router1", "65000: Example Org", "external", "443/https", "2001:db8::1", "Other"},
+			},
+			JSONOutput: gin.H{"expressions": []string{
+				`ExporterName = "th2-router1"`,
+				"SrcAS = AS65000",
+				"InIfBoundary = external",
+				"DstPort = 443",
+				"SrcAddr = 2001:db8::1",
+				"",
+			}},
+		},
+		{
+			Description: "drill down with truncated addresses",
+			URL:         "/api/v0/console/filter/drilldown",
+			JSONInput: gin.H{
+				"dimensions":  []string{"SrcAddr", "DstAddr"},
+				"values":      []string{"192.0.2.0", "2001:db8::"},
+				"truncate-v4": 24,
+				"truncate-v6": 48,
+			},
+			JSONOutput: gin.H{"expressions": []string{
+				"SrcAddr << 192.0.2.0/24",
+				"DstAddr << 2001:db8::/48",
+			}},
+		},
+		{
+			Description: "drill down with mismatched lengths",
+			URL:         "/api/v0/console/filter/drilldown",
+			JSONInput: gin.H{
+				"dimensions": []string{"SrcAS", "DstAS"},
+				"values":     []string{"AS65000"},
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "Dimensions and values should have the same length."},
+		},
+		{
+			Description: "selection with an IN list",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"ExporterName"},
+				"rows":       [][]string{{"th2-router1"}, {"th2-router2"}},
+			},
+			JSONOutput: gin.H{"expression": `ExporterName IN ("th2-router1", "th2-router2")`},
+		},
+		{
+			Description: "selection with a NOTIN list",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"SrcAS"},
+				"rows":       [][]string{{"65000: Example Org"}, {"Other"}, {"65001: Another Org"}},
+				"exclude":    true,
+			},
+			JSONOutput: gin.H{"expression": "SrcAS NOTIN (AS65000, AS65001)"},
+		},
+		{
+			Description: "selection without IN support",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"DstPort"},
+				"rows":       [][]string{{"443/https"}, {"80/http"}},
+				"exclude":    true,
+			},
+			JSONOutput: gin.H{"expression": "NOT (DstPort = 443 OR DstPort = 80)"},
+		},
+		{
+			Description: "selection with several dimensions",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"ExporterName", "SrcAS"},
+				"rows":       [][]string{{"th2-router1", "65000: Example Org"}, {"th2-router2", "Other"}},
+			},
+			JSONOutput: gin.H{"expression": `((ExporterName = "th2-router1" AND SrcAS = AS65000) OR ExporterName = "th2-router2")`},
+		},
+		{
+			Description: "selection of a single row",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"ExporterName", "SrcAS"},
+				"rows":       [][]string{{"th2-router1", "65000: Example Org"}},
+				"exclude":    true,
+			},
+			JSONOutput: gin.H{"expression": `NOT (ExporterName = "th2-router1" AND SrcAS = AS65000)`},
+		},
+		{
+			Description: "selection without usable values",
+			URL:         "/api/v0/console/filter/selection",
+			JSONInput: gin.H{
+				"dimensions": []string{"SrcAS"},
+				"rows":       [][]string{{"Other"}},
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "No filter expression can select these rows."},
+		},
 	})
 }
 
@@ -448,6 +681,39 @@ UNION DISTINCT
 			{"echo-reply"},
 		}).
 		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT DropReason AS label
+FROM flows
+WHERE TimeReceived > date_sub(minute, 10, now())
+AND DropReason != ''
+AND positionCaseInsensitive(label, $1) >= 1
+GROUP BY DropReason
+ORDER BY COUNT(*) DESC
+LIMIT 20`, "ac").
+		SetArg(1, []struct {
+			Label string `ch:"label"`
+		}{
+			{"acl"},
+		}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT OutIfGroup AS label
+FROM flows
+WHERE TimeReceived > date_sub(minute, 10, now())
+AND OutIfGroup != ''
+AND positionCaseInsensitive(label, $1) >= 1
+GROUP BY OutIfGroup
+ORDER BY COUNT(*) DESC
+LIMIT 20`, "tr").
+		SetArg(1, []struct {
+			Label string `ch:"label"`
+		}{
+			{"transit-lag1"},
+			{"transit-lag2"},
+		}).
+		Return(nil)
 
 	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
 		{
@@ -469,6 +735,23 @@ UNION DISTINCT
 				{"label": "echo-reply", "detail": "ICMPv6", "quoted": true},
 			}},
 		},
+		{
+			URL:        "/api/v0/console/filter/complete",
+			StatusCode: 200,
+			JSONInput:  gin.H{"what": "value", "column": "dropReason", "prefix": "ac"},
+			JSONOutput: gin.H{"completions": []gin.H{
+				{"label": "acl", "detail": "drop reason", "quoted": true},
+			}},
+		},
+		{
+			URL:        "/api/v0/console/filter/complete",
+			StatusCode: 200,
+			JSONInput:  gin.H{"what": "value", "column": "outIfGroup", "prefix": "tr"},
+			JSONOutput: gin.H{"completions": []gin.H{
+				{"label": "transit-lag1", "detail": "interface group", "quoted": true},
+				{"label": "transit-lag2", "detail": "interface group", "quoted": true},
+			}},
+		},
 	})
 }
 
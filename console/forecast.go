@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/console/query"
+)
+
+// forecastSeasonPeriod is the period used for the seasonal component of a
+// forecast.
+const forecastSeasonPeriod = 7 * 24 * time.Hour
+
+// forecastMaxPoints is the maximum number of projected points.
+const forecastMaxPoints = 500
+
+// forecastHandlerInput describes the input for the /widget/forecast endpoint.
+type forecastHandlerInput struct {
+	schema   *schema.Component
+	Start    time.Time    `json:"start" binding:"required"`
+	End      time.Time    `json:"end" binding:"required,gtfield=Start"`
+	Until    time.Time    `json:"until" binding:"required,gtfield=End"` // end of the projection
+	Filter   query.Filter `json:"filter"`                               // where ...
+	Points   int          `json:"points" binding:"min=3,max=2000"`      // number of historical points
+	Capacity float64      `json:"capacity" binding:"min=0"`             // capacity threshold in bps
+	Seasonal bool         `json:"seasonal"`                             // add a weekly seasonal component
+	Format   string       `json:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// forecastPoint is a point of the history or of the projection.
+type forecastPoint struct {
+	Time time.Time `json:"t" ch:"time"`
+	Bps  float64   `json:"bps" ch:"bps"`
+}
+
+// forecastHandlerOutput describes the output for the /widget/forecast
+// endpoint.
+type forecastHandlerOutput struct {
+	History  []forecastPoint `json:"history"`
+	Forecast []forecastPoint `json:"forecast"`
+	Growth   float64         `json:"growth"`   // bps per day
+	Seasonal bool            `json:"seasonal"` // seasonal component used
+	Capacity float64         `json:"capacity"`
+	Crossing *time.Time      `json:"crossing"` // when capacity is crossed
+}
+
+// toSQL converts a forecast input to an SQL request.
+func (input forecastHandlerInput) toSQL() templateQuery {
+	template := fmt.Sprintf(`
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ SUM(Bytes*SamplingRate*8/{{ .Interval }}) AS bps
+FROM {{ .Table }}
+WHERE %s
+GROUP BY time
+ORDER BY time WITH FILL
+ FROM {{ .TimefilterStart }}
+ TO {{ .TimefilterEnd }} + INTERVAL 1 second
+ STEP {{ .Interval }}`, templateWhere(input.Filter))
+	return templateQuery{
+		Template: strings.TrimSpace(template),
+		Context: inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: false,
+			RequiredColumns:   requiredColumns(nil, input.Filter),
+			Points:            uint(input.Points),
+		},
+	}
+}
+
+// forecast fits a linear trend on the provided history, optionally with a
+// weekly seasonal component, and projects it until the provided time. The
+// points of the history should be evenly spaced.
+func forecast(history []forecastPoint, until time.Time, seasonal bool, capacity float64) forecastHandlerOutput {
+	output := forecastHandlerOutput{
+		History:  history,
+		Forecast: []forecastPoint{},
+		Capacity: capacity,
+	}
+	if len(history) < 2 {
+		return output
+	}
+	first := history[0].Time
+	step := history[1].Time.Sub(first)
+	if step <= 0 {
+		return output
+	}
+
+	// Least squares fit of the trend
+	var sumX, sumY, sumXX, sumXY float64
+	n := float64(len(history))
+	for _, point := range history {
+		x := point.Time.Sub(first).Seconds()
+		sumX += x
+		sumY += point.Bps
+		sumXX += x * x
+		sumXY += x * point.Bps
+	}
+	var slope float64
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		slope = (n*sumXY - sumX*sumY) / denominator
+	}
+	intercept := (sumY - slope*sumX) / n
+	trend := func(t time.Time) float64 {
+		return intercept + slope*t.Sub(first).Seconds()
+	}
+	output.Growth = slope * (24 * time.Hour).Seconds()
+
+	// Seasonal component: average deviation from the trend for each phase of
+	// the period. This requires at least two periods of history.
+	var season []float64
+	phases := int(forecastSeasonPeriod / step)
+	phase := func(t time.Time) int {
+		return int(t.Sub(first)/step) % phases
+	}
+	if seasonal && forecastSeasonPeriod%step == 0 &&
+		history[len(history)-1].Time.Sub(first) >= 2*forecastSeasonPeriod {
+		season = make([]float64, phases)
+		counts := make([]int, phases)
+		for _, point := range history {
+			season[phase(point.Time)] += point.Bps - trend(point.Time)
+			counts[phase(point.Time)]++
+		}
+		for idx := range season {
+			if counts[idx] > 0 {
+				season[idx] /= float64(counts[idx])
+			}
+		}
+		output.Seasonal = true
+	}
+
+	// Projection. The step is increased to limit the number of points.
+	last := history[len(history)-1].Time
+	projectionStep := step * time.Duration(math.Ceil(
+		float64(until.Sub(last))/float64(step)/forecastMaxPoints))
+	for t := last.Add(projectionStep); !t.After(until); t = t.Add(projectionStep) {
+		value := trend(t)
+		if season != nil {
+			value += season[phase(t)]
+		}
+		value = max(value, 0)
+		output.Forecast = append(output.Forecast, forecastPoint{Time: t, Bps: value})
+		if capacity > 0 && output.Crossing == nil && value >= capacity {
+			crossing := t
+			output.Crossing = &crossing
+		}
+	}
+	return output
+}
+
+func (c *Component) widgetForecastHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input := forecastHandlerInput{schema: c.d.Schema, Points: 200}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
+	queries := []templateQuery{input.toSQL()}
+	if err := c.checkGuardrails(queries); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	sqlQuery := c.finalizeTemplateQueries(queries)
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+
+	results := []forecastPoint{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+		c.queryError(gc, err, sqlQuery)
+		return
+	}
+	// The last point is incomplete.
+	if len(results) > 0 {
+		results = results[:len(results)-1]
+	}
+	if len(results) < 2 {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Not enough data to forecast."})
+		return
+	}
+	output := forecast(results, input.Until, input.Seasonal, input.Capacity)
+
+	if input.Format == "csv" {
+		gc.Header("Content-Type", "text/csv; charset=utf-8")
+		gc.Status(http.StatusOK)
+		w := csv.NewWriter(gc.Writer)
+		w.Write([]string{"time", "type", "bps"})
+		for _, rows := range []struct {
+			kind   string
+			points []forecastPoint
+		}{{"history", output.History}, {"forecast", output.Forecast}} {
+			for _, point := range rows.points {
+				w.Write([]string{
+					point.Time.UTC().Format(time.RFC3339),
+					rows.kind,
+					strconv.FormatFloat(point.Bps, 'f', 0, 64),
+				})
+			}
+		}
+		w.Flush()
+		return
+	}
+	gc.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/console/query"
+)
+
+func TestForecastQuerySQL(t *testing.T) {
+	input := forecastHandlerInput{
+		Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+		End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+		Until:  time.Date(2022, 5, 11, 15, 45, 10, 0, time.UTC),
+		Filter: query.NewFilter("DstCountry = 'FR'"),
+		Points: 100,
+	}
+	input.schema = schema.NewMock(t)
+	if err := input.Filter.Validate(input.schema); err != nil {
+		t.Fatalf("Validate() error:\n%+v", err)
+	}
+	expected := templateQuery{
+		Context: inputContext{
+			Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+			End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+			RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
+			Points:          100,
+		},
+		Template: `SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ SUM(Bytes*SamplingRate*8/{{ .Interval }}) AS bps
+FROM {{ .Table }}
+WHERE {{ .Timefilter }} AND (DstCountry = 'FR')
+GROUP BY time
+ORDER BY time WITH FILL
+ FROM {{ .TimefilterStart }}
+ TO {{ .TimefilterEnd }} + INTERVAL 1 second
+ STEP {{ .Interval }}`,
+	}
+	if diff := helpers.Diff(input.toSQL(), expected); diff != "" {
+		t.Fatalf("toSQL (-got, +want):\n%s", diff)
+	}
+}
+
+func TestForecast(t *testing.T) {
+	start := time.Date(2022, 4, 4, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("linear", func(t *testing.T) {
+		// 1 Gbps, growing by 10 Mbps per day
+		history := []forecastPoint{}
+		for i := range 30 {
+			history = append(history, forecastPoint{
+				Time: start.Add(time.Duration(i) * day),
+				Bps:  1e9 + float64(i)*1e7,
+			})
+		}
+		got := forecast(history, start.Add(100*day), false, 1.5e9)
+		if math.Abs(got.Growth-1e7) > 1 {
+			t.Errorf("forecast() growth: %f, expected %f", got.Growth, 1e7)
+		}
+		if got.Seasonal {
+			t.Error("forecast() used a seasonal component")
+		}
+		if len(got.Forecast) != 71 {
+			t.Fatalf("forecast() returned %d points, expected 71", len(got.Forecast))
+		}
+		if got.Crossing == nil {
+			t.Fatal("forecast() did not cross capacity")
+		}
+		if diff := helpers.Diff(*got.Crossing, start.Add(50*day)); diff != "" {
+			t.Errorf("forecast() crossing (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("seasonal", func(t *testing.T) {
+		// Flat traffic, except during weekends
+		history := []forecastPoint{}
+		for i := range 28 {
+			bps := 1e9
+			if i%7 >= 5 {
+				bps = 5e8
+			}
+			history = append(history, forecastPoint{
+				Time: start.Add(time.Duration(i) * day),
+				Bps:  bps,
+			})
+		}
+		got := forecast(history, start.Add(35*day), true, 2e9)
+		if !got.Seasonal {
+			t.Fatal("forecast() did not use a seasonal component")
+		}
+		if got.Crossing != nil {
+			t.Errorf("forecast() crossed capacity at %s", got.Crossing)
+		}
+		if len(got.Forecast) != 8 {
+			t.Fatalf("forecast() returned %d points, expected 8", len(got.Forecast))
+		}
+		// The weekend dip should be projected.
+		for _, idx := range []int{5, 6} {
+			dip := got.Forecast[4].Bps - got.Forecast[idx].Bps
+			if math.Abs(dip-5e8) > 1e7 {
+				t.Errorf("forecast() weekend dip for point %d: %f, expected %f", idx, dip, 5e8)
+			}
+		}
+	})
+
+	t.Run("not enough history for seasonal", func(t *testing.T) {
+		history := []forecastPoint{}
+		for i := range 10 {
+			history = append(history, forecastPoint{
+				Time: start.Add(time.Duration(i) * day),
+				Bps:  1e9,
+			})
+		}
+		got := forecast(history, start.Add(20*day), true, 0)
+		if got.Seasonal {
+			t.Error("forecast() used a seasonal component")
+		}
+	})
+
+	t.Run("points are capped", func(t *testing.T) {
+		history := []forecastPoint{
+			{Time: start, Bps: 1e9},
+			{Time: start.Add(time.Minute), Bps: 1e9},
+		}
+		got := forecast(history, start.Add(30*day), false, 0)
+		if len(got.Forecast) > forecastMaxPoints {
+			t.Errorf("forecast() returned %d points", len(got.Forecast))
+		}
+	})
+}
+
+func TestForecastHandler(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+
+	start := time.Date(2022, 4, 10, 0, 0, 0, 0, time.UTC)
+	expectedSQL := []forecastPoint{
+		{start, 1000},
+		{start.Add(24 * time.Hour), 2000},
+		{start.Add(48 * time.Hour), 3000},
+		{start.Add(72 * time.Hour), 100},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, expectedSQL).
+		Return(nil).
+		Times(2)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, expectedSQL[:2]).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/widget/forecast",
+			JSONInput: gin.H{
+				"start":    start,
+				"end":      start.Add(72 * time.Hour),
+				"until":    start.Add(120 * time.Hour),
+				"filter":   "DstCountry = 'FR'",
+				"capacity": 4500,
+			},
+			JSONOutput: gin.H{
+				"history": []gin.H{
+					{"t": "2022-04-10T00:00:00Z", "bps": 1000},
+					{"t": "2022-04-11T00:00:00Z", "bps": 2000},
+					{"t": "2022-04-12T00:00:00Z", "bps": 3000},
+				},
+				"forecast": []gin.H{
+					{"t": "2022-04-13T00:00:00Z", "bps": 4000},
+					{"t": "2022-04-14T00:00:00Z", "bps": 5000},
+					{"t": "2022-04-15T00:00:00Z", "bps": 6000},
+				},
+				"growth":   1000,
+				"seasonal": false,
+				"capacity": 4500,
+				"crossing": "2022-04-14T00:00:00Z",
+			},
+		}, {
+			Description: "CSV output",
+			URL:         "/api/v0/console/widget/forecast",
+			JSONInput: gin.H{
+				"start":  start,
+				"end":    start.Add(72 * time.Hour),
+				"until":  start.Add(96 * time.Hour),
+				"format": "csv",
+			},
+			ContentType: "text/csv; charset=utf-8",
+			FirstLines: []string{
+				"time,type,bps",
+				"2022-04-10T00:00:00Z,history,1000",
+				"2022-04-11T00:00:00Z,history,2000",
+				"2022-04-12T00:00:00Z,history,3000",
+				"2022-04-13T00:00:00Z,forecast,4000",
+				"2022-04-14T00:00:00Z,forecast,5000",
+			},
+		}, {
+			Description: "not enough data",
+			URL:         "/api/v0/console/widget/forecast",
+			StatusCode:  400,
+			JSONInput: gin.H{
+				"start": start,
+				"end":   start.Add(24 * time.Hour),
+				"until": start.Add(96 * time.Hour),
+			},
+			JSONOutput: gin.H{"message": "Not enough data to forecast."},
+		}, {
+			Description: "until before end",
+			URL:         "/api/v0/console/widget/forecast",
+			StatusCode:  400,
+			JSONInput: gin.H{
+				"start": start,
+				"end":   start.Add(24 * time.Hour),
+				"until": start,
+			},
+			JSONOutput: gin.H{"message": "Key: 'forecastHandlerInput.Until' Error:Field validation for 'Until' failed on the 'gtfield' tag"},
+		},
+	})
+}
@@ -4,6 +4,11 @@
 import { createRouter, createWebHistory } from "vue-router";
 import HomePage from "@/views/HomePage.vue";
 import VisualizePage from "@/views/VisualizePage.vue";
+import ExportersPage from "@/views/ExportersPage.vue";
+import OperationsPage from "@/views/OperationsPage.vue";
+import ParityPage from "@/views/ParityPage.vue";
+import ASNsPage from "@/views/ASNsPage.vue";
+import TokensPage from "@/views/TokensPage.vue";
 import DocumentationPage from "@/views/DocumentationPage.vue";
 import ErrorPage from "@/views/ErrorPage.vue";
 
@@ -36,6 +41,36 @@ const router = createRouter({
       meta: { title: "Visualize" },
       props: (route) => ({ routeState: route.params.state }),
     },
+    {
+      path: "/exporters",
+      name: "Exporters",
+      component: ExportersPage,
+      meta: { title: "Exporters" },
+    },
+    {
+      path: "/operations",
+      name: "Operations",
+      component: OperationsPage,
+      meta: { title: "Operations" },
+    },
+    {
+      path: "/parity",
+      name: "Parity",
+      component: ParityPage,
+      meta: { title: "Parity" },
+    },
+    {
+      path: "/asns",
+      name: "ASNs",
+      component: ASNsPage,
+      meta: { title: "AS names" },
+    },
+    {
+      path: "/tokens",
+      name: "Tokens",
+      component: TokensPage,
+      meta: { title: "API tokens" },
+    },
     {
       path: "/docs",
       redirect: "/docs/intro",
@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: AGPL-3.0-only
 
 import { describe, expect, it } from "vitest";
-import { formatXps, compareFields } from "./index";
+import { formatXps, compareFields, combineFilters } from "./index";
 
 describe("formatXps", () => {
   it("formats small values without suffix", () => {
@@ -89,3 +89,22 @@ describe("compareFields", () => {
     expect(compareFields("SrcAddr", "SrcAddr")).toBe(0);
   });
 });
+
+describe("combineFilters", () => {
+  it("returns the added expression without filter", () => {
+    expect(combineFilters("", "SrcAS = AS65000")).toBe("SrcAS = AS65000");
+    expect(combineFilters("  ", "SrcAS = AS65000")).toBe("SrcAS = AS65000");
+  });
+
+  it("combines with the current filter", () => {
+    expect(combineFilters("InIfBoundary = external", "SrcAS = AS65000")).toBe(
+      "(InIfBoundary = external) AND SrcAS = AS65000",
+    );
+  });
+
+  it("keeps the closing parenthesis after a comment", () => {
+    expect(combineFilters("DstPort = 443 -- HTTPS", "SrcAS = AS65000")).toBe(
+      "(DstPort = 443 -- HTTPS\n) AND SrcAS = AS65000",
+    );
+  });
+});
@@ -36,4 +36,14 @@ export function compareFields(f1: string, f2: string) {
   return f1.localeCompare(f2);
 }
 
+// Combine an expression with an existing filter
+export function combineFilters(current: string, added: string) {
+  current = current.trim();
+  if (current === "") return added;
+  // A comment would swallow the closing parenthesis.
+  return current.includes("--")
+    ? `(${current}\n) AND ${added}`
+    : `(${current}) AND ${added}`;
+}
+
 export { dataColor, dataColorGrey } from "./palette.js";
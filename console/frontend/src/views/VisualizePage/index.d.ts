@@ -3,7 +3,13 @@
 
 import type { GraphType } from "./graphtypes";
 
-export type Units = "l3bps" | "l2bps" | "pps" | "inl2%" | "outl2%";
+export type Units =
+  | "l3bps"
+  | "l2bps"
+  | "pps"
+  | "fps"
+  | "inl2%"
+  | "outl2%";
 export type GraphSankeyHandlerInput = {
   start: string;
   end: string;
@@ -16,6 +22,8 @@ export type GraphLineHandlerInput = GraphSankeyHandlerInput & {
   points: number;
   bidirectional: boolean;
   "previous-period": boolean;
+  "range-stats": boolean;
+  completeness: boolean;
 };
 export type GraphSankeyHandlerOutput = {
   rows: string[][];
@@ -38,6 +46,10 @@ export type GraphLineHandlerOutput = {
   max: number[];
   last: number[];
   "95th": number[];
+  "range-min"?: number[];
+  "range-average"?: number[];
+  "range-max"?: number[];
+  completeness?: number[];
 };
 export type GraphSankeyHandlerResult = GraphSankeyHandlerOutput & {
   graphType: Extract<GraphType, "sankey">;
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// ClickHouse error codes for exceeded limits
+const (
+	clickhouseErrTooManyRows        = 158
+	clickhouseErrTimeoutExceeded    = 159
+	clickhouseErrTooManyRowsOrBytes = 396
+)
+
+// guardrailsMiddleware applies the configured limits to the context of each
+// request. The context is used for all queries sent to ClickHouse.
+func (c *Component) guardrailsMiddleware() gin.HandlerFunc {
+	settings := clickhouse.Settings{}
+	if c.config.Guardrails.MaxRowsToRead > 0 {
+		settings["max_rows_to_read"] = c.config.Guardrails.MaxRowsToRead
+	}
+	return func(gc *gin.Context) {
+		ctx := gc.Request.Context()
+		if len(settings) > 0 {
+			ctx = clickhouse.Context(ctx, clickhouse.WithSettings(settings))
+		}
+		if c.config.Guardrails.QueryTimeout > 0 {
+			var cancel stdcontext.CancelFunc
+			ctx, cancel = stdcontext.WithTimeout(ctx, c.config.Guardrails.QueryTimeout)
+			defer cancel()
+		}
+		gc.Request = gc.Request.WithContext(ctx)
+		gc.Next()
+	}
+}
+
+// checkGuardrails checks the provided queries do not span too many intervals
+// of the selected tables. Tables with a too fine resolution are already
+// excluded during selection, so this only happens when no table is suitable.
+func (c *Component) checkGuardrails(queries []templateQuery) error {
+	maxIntervals := c.config.Guardrails.MaxIntervals
+	if maxIntervals == 0 {
+		return nil
+	}
+	for _, query := range queries {
+		_, interval, _ := c.computeTableAndInterval(query.Context)
+		span := query.Context.End.Sub(query.Context.Start)
+		if uint64(span/interval) > maxIntervals {
+			return fmt.Errorf("time range is too large, maximum is %s for the selected dimensions and filter",
+				interval*time.Duration(maxIntervals))
+		}
+	}
+	return nil
+}
+
+// queryError logs an error returned by ClickHouse and reports it to the user.
+// When a limit was exceeded, the user gets a message explaining what to do.
+func (c *Component) queryError(gc *gin.Context, err error, query string) {
+	var exception *clickhouse.Exception
+	var netErr net.Error
+	switch {
+	case errors.As(err, &exception) && (exception.Code == clickhouseErrTooManyRows ||
+		exception.Code == clickhouseErrTooManyRowsOrBytes):
+		c.r.Info().Err(err).Str("query", query).Msg("query stopped: too many rows")
+		gc.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "Query would read too many rows. Reduce the time range or use a more specific filter.",
+		})
+	case errors.Is(err, stdcontext.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout(),
+		errors.As(err, &exception) && exception.Code == clickhouseErrTimeoutExceeded:
+		c.r.Info().Err(err).Str("query", query).Msg("query stopped: timeout")
+		gc.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "Query took too long. Reduce the time range or use a more specific filter.",
+		})
+	default:
+		c.r.Err(err).Str("query", query).Msg("unable to query database")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	stdcontext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+)
+
+func TestGuardrailsTableSelection(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Guardrails.MaxIntervals = 2000
+	c, _, _, _ := NewMock(t, config)
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC), nil},
+		{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC), nil},
+		{"flows_5m0s", 5 * time.Minute, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC), nil},
+	}
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Context  inputContext
+		Expected tableIntervalOutput
+		Error    bool
+	}{
+		{
+			// Within limits for the raw table
+			Pos: helpers.Mark(),
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 10, 16, 0, 10, 0, time.UTC),
+				Points: 900,
+			},
+			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
+		}, {
+			// Raw table would be used without limits
+			Pos: helpers.Mark(),
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 86400,
+			},
+			Expected: tableIntervalOutput{Table: "flows_1m0s", Interval: 60},
+		}, {
+			// 1-minute table is not enough
+			Pos: helpers.Mark(),
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 12, 15, 45, 10, 0, time.UTC),
+				Points: 200,
+			},
+			Expected: tableIntervalOutput{Table: "flows_5m0s", Interval: 300},
+		}, {
+			// Main table required
+			Pos: helpers.Mark(),
+			Context: inputContext{
+				Start:             time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:               time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points:            200,
+				MainTableRequired: true,
+			},
+			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
+			Error:    true,
+		}, {
+			// No table is suitable
+			Pos: helpers.Mark(),
+			Context: inputContext{
+				Start:  time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 200,
+			},
+			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
+			Error:    true,
+		},
+	}
+	for _, tc := range cases {
+		table, interval, _ := c.computeTableAndInterval(tc.Context)
+		got := tableIntervalOutput{
+			Table:    table,
+			Interval: uint64(interval.Seconds()),
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%scomputeTableAndInterval() (-got, +want):\n%s", tc.Pos, diff)
+		}
+		err := c.checkGuardrails([]templateQuery{{Context: tc.Context}})
+		if err != nil && !tc.Error {
+			t.Errorf("%scheckGuardrails() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%scheckGuardrails() did not error", tc.Pos)
+		}
+	}
+}
+
+func TestGuardrailsHandler(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Guardrails.MaxIntervals = 3600
+	config.Guardrails.MaxRowsToRead = 1_000_000
+	config.Guardrails.QueryTimeout = 10 * time.Second
+	_, h, mockConn, _ := NewMock(t, config)
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx stdcontext.Context, _ any, _ string, _ ...any) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("Select() context has no deadline")
+			}
+			return &clickhouse.Exception{Code: 158, Message: "Limit for rows (controlled by 'max_rows_to_read' setting) exceeded"}
+		})
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf("read: %w", stdcontext.DeadlineExceeded))
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&clickhouse.Exception{Code: 60, Message: "Unknown table"})
+
+	input := gin.H{
+		"start":      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+		"end":        time.Date(2022, 4, 10, 16, 15, 10, 0, time.UTC),
+		"limit":      10,
+		"dimensions": []string{"SrcAS", "ExporterName"},
+		"units":      "l3bps",
+	}
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "too many rows",
+			URL:         "/api/v0/console/graph/sankey",
+			JSONInput:   input,
+			StatusCode:  422,
+			JSONOutput: gin.H{
+				"message": "Query would read too many rows. Reduce the time range or use a more specific filter.",
+			},
+		}, {
+			Description: "timeout",
+			URL:         "/api/v0/console/graph/sankey",
+			JSONInput:   input,
+			StatusCode:  422,
+			JSONOutput: gin.H{
+				"message": "Query took too long. Reduce the time range or use a more specific filter.",
+			},
+		}, {
+			Description: "other error",
+			URL:         "/api/v0/console/graph/sankey",
+			JSONInput:   input,
+			StatusCode:  500,
+			JSONOutput: gin.H{
+				"message": "Unable to query database.",
+			},
+		}, {
+			Description: "time range too large",
+			URL:         "/api/v0/console/graph/sankey",
+			JSONInput: gin.H{
+				"start":      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":        time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"limit":      10,
+				"dimensions": []string{"SrcAS", "ExporterName"},
+				"units":      "l3bps",
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{
+				"message": "Time range is too large, maximum is 1h0m0s for the selected dimensions and filter",
+			},
+		},
+	})
+}
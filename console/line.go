@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"akvorado/common/helpers"
+	"akvorado/common/schema"
 	"akvorado/console/query"
 )
 
@@ -24,6 +25,8 @@ type graphLineHandlerInput struct {
 	Points         uint `json:"points" binding:"required,min=5,max=2000"` // minimum number of points
 	Bidirectional  bool `json:"bidirectional"`
 	PreviousPeriod bool `json:"previous-period"`
+	RangeStats     bool `json:"range-stats"`  // compute min/avg/max over the whole range
+	Completeness   bool `json:"completeness"` // compute ingest completeness for each point
 }
 
 // graphLineHandlerOutput describes the output for the /graph/line endpoint. A
@@ -41,6 +44,45 @@ type graphLineHandlerOutput struct {
 	Max                  []int          `json:"max"`     // row → max xps
 	Last                 []int          `json:"last"`    // row → last xps
 	NinetyFivePercentile []int          `json:"95th"`    // row → 95th xps
+
+	// Statistics over the whole range at the finest resolution available,
+	// only when requested
+	RangeMin     []int `json:"range-min,omitempty"`     // row → min xps over range
+	RangeAverage []int `json:"range-average,omitempty"` // row → average xps over range
+	RangeMax     []int `json:"range-max,omitempty"`     // row → max xps over range
+
+	// Ratio of exporters seen for each point compared to the maximum number
+	// of exporters seen at any point, only when requested
+	Completeness []float64 `json:"completeness,omitempty"` // t → ratio
+}
+
+// validateGraphLineInput checks the dimensions, the filter, and the limit of
+// an input for the /graph/line endpoint.
+func (c *Component) validateGraphLineInput(input *graphLineHandlerInput) error {
+	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+		return err
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		return err
+	}
+	if input.Limit > c.config.DimensionsLimit {
+		return fmt.Errorf("limit is set beyond maximum value (%d)", c.config.DimensionsLimit)
+	}
+	return nil
+}
+
+// axisName returns the name of the provided axis.
+func (input graphLineHandlerInput) axisName(axis int) string {
+	switch axis {
+	case 1:
+		return "Direct"
+	case 2:
+		return "Reverse"
+	case 3, 4:
+		_, name := nearestPeriod(input.End.Sub(input.Start))
+		return fmt.Sprintf("Previous %s", name)
+	}
+	return ""
 }
 
 // reverseDirection reverts the direction of a provided input. It does not
@@ -99,6 +141,56 @@ type toSQL1Options struct {
 	reverseDirection  bool
 	offsetedStart     time.Time
 	mainTableRequired bool
+	requiredColumns   []schema.ColumnKey
+}
+
+// dimensionsSQL returns the SQL expression selecting the dimensions of a row,
+// using "Other" for rows not selected, and the expression to use for the
+// dimensions of interpolated rows.
+func (input graphLineHandlerInput) dimensionsSQL() (string, string) {
+	if len(input.Dimensions) == 0 {
+		return "emptyArrayString() AS dimensions", "emptyArrayString()"
+	}
+	selectFields := []string{}
+	dimensions := []string{}
+	others := []string{}
+	for _, column := range input.Dimensions {
+		selectFields = append(selectFields, column.ToSQLSelect(input.schema))
+		dimensions = append(dimensions, column.String())
+		others = append(others, "'Other'")
+	}
+	return fmt.Sprintf(`if((%s) IN rows, [%s], [%s]) AS dimensions`,
+			strings.Join(dimensions, ", "),
+			strings.Join(selectFields, ", "),
+			strings.Join(others, ", ")),
+		fmt.Sprintf("[%s]", strings.Join(others, ", "))
+}
+
+// withSQL returns the WITH clause defining the source and the selected rows.
+func (input graphLineHandlerInput) withSQL(where string) string {
+	with := []string{fmt.Sprintf("source AS (%s)", input.sourceSelect())}
+	if len(input.Dimensions) > 0 {
+		dimensions := []string{}
+		for _, column := range input.Dimensions {
+			dimensions = append(dimensions, column.String())
+		}
+		with = append(with, selectLineRowsByLimitType(input, dimensions, where))
+	}
+	return fmt.Sprintf("\nWITH\n %s", strings.Join(with, ",\n "))
+}
+
+// units returns the units to use, swapping interface-based units when the
+// direction is reversed.
+func (input graphLineHandlerInput) units(reverseDirection bool) string {
+	if reverseDirection {
+		switch input.Units {
+		case "inl2%":
+			return "outl2%"
+		case "outl2%":
+			return "inl2%"
+		}
+	}
+	return input.Units
 }
 
 func (input graphLineHandlerInput) toSQL1(axis int, options toSQL1Options) templateQuery {
@@ -112,52 +204,17 @@ func (input graphLineHandlerInput) toSQL1(axis int, options toSQL1Options) templ
 	where := templateWhere(input.Filter)
 
 	// Select
+	dimensionsField, dimensionsInterpolate := input.dimensionsSQL()
 	fields := []string{
 		fmt.Sprintf(`{{ call .ToStartOfInterval "TimeReceived" }}%s AS time`, offsetShift),
 		`{{ .Units }}/{{ .Interval }} AS xps`,
-	}
-	selectFields := []string{}
-	dimensions := []string{}
-	dimensionsInterpolate := ""
-	others := []string{}
-	for _, column := range input.Dimensions {
-		field := column.ToSQLSelect(input.schema)
-		selectFields = append(selectFields, field)
-		dimensions = append(dimensions, column.String())
-		others = append(others, "'Other'")
-	}
-	if len(dimensions) > 0 {
-		fields = append(fields, fmt.Sprintf(`if((%s) IN rows, [%s], [%s]) AS dimensions`,
-			strings.Join(dimensions, ", "),
-			strings.Join(selectFields, ", "),
-			strings.Join(others, ", ")))
-		dimensionsInterpolate = fmt.Sprintf("[%s]", strings.Join(others, ", "))
-	} else {
-		fields = append(fields, "emptyArrayString() AS dimensions")
-		dimensionsInterpolate = "emptyArrayString()"
+		dimensionsField,
 	}
 
 	// With
 	withStr := ""
 	if !options.skipWithClause {
-		with := []string{fmt.Sprintf("source AS (%s)", input.sourceSelect())}
-		if len(dimensions) > 0 {
-			with = append(with, selectLineRowsByLimitType(input, dimensions, where))
-		}
-		if len(with) > 0 {
-			withStr = fmt.Sprintf("\nWITH\n %s", strings.Join(with, ",\n "))
-		}
-	}
-
-	// Units
-	units := input.Units
-	if options.reverseDirection {
-		switch units {
-		case "inl2%":
-			units = "outl2%"
-		case "outl2%":
-			units = "inl2%"
-		}
+		withStr = input.withSQL(where)
 	}
 
 	template := fmt.Sprintf(`%s
@@ -181,8 +238,9 @@ ORDER BY time WITH FILL
 		End:                    input.End,
 		StartForTableSelection: startForInterval,
 		MainTableRequired:      options.mainTableRequired,
+		RequiredColumns:        options.requiredColumns,
 		Points:                 input.Points,
-		Units:                  units,
+		Units:                  input.units(options.reverseDirection),
 	}
 
 	return templateQuery{
@@ -197,14 +255,21 @@ func (input graphLineHandlerInput) toSQL() []templateQuery {
 	// consistency. This is useful as previous period will remove the
 	// dimensions.
 	mainTableRequired := requireMainTable(input.schema, input.Dimensions, input.Filter)
+	dimensions := input.Dimensions
+	if input.Bidirectional {
+		dimensions = slices.Concat(dimensions, input.reverseDirection().Dimensions)
+	}
+	columns := requiredColumns(dimensions, input.Filter)
 	queries := []templateQuery{input.toSQL1(1, toSQL1Options{
 		mainTableRequired: mainTableRequired,
+		requiredColumns:   columns,
 	})}
 	if input.Bidirectional {
 		queries = append(queries, input.reverseDirection().toSQL1(2, toSQL1Options{
 			skipWithClause:    true,
 			reverseDirection:  true,
 			mainTableRequired: mainTableRequired,
+			requiredColumns:   columns,
 		}))
 	}
 	if input.PreviousPeriod {
@@ -212,6 +277,7 @@ func (input graphLineHandlerInput) toSQL() []templateQuery {
 			skipWithClause:    true,
 			offsetedStart:     input.Start,
 			mainTableRequired: mainTableRequired,
+			requiredColumns:   columns,
 		}))
 	}
 	if input.Bidirectional && input.PreviousPeriod {
@@ -220,11 +286,109 @@ func (input graphLineHandlerInput) toSQL() []templateQuery {
 			reverseDirection:  true,
 			offsetedStart:     input.Start,
 			mainTableRequired: mainTableRequired,
+			requiredColumns:   columns,
 		}))
 	}
 	return queries
 }
 
+// rangeStatsSQL1 builds the query computing the statistics of each row for one
+// axis. Rates are computed for each interval of the selected table and
+// aggregated over the whole range.
+func (input graphLineHandlerInput) rangeStatsSQL1(axis int, options toSQL1Options) templateQuery {
+	where := templateWhere(input.Filter)
+	dimensionsField, _ := input.dimensionsSQL()
+	withStr := ""
+	if !options.skipWithClause {
+		withStr = input.withSQL(where)
+	}
+	template := fmt.Sprintf(`%s
+SELECT
+ %d AS axis,
+ dimensions,
+ MIN(xps) AS xps_min,
+ SUM(xps)/greatest(dateDiff('second', {{ .TimefilterStart }}, {{ .TimefilterEnd }})/{{ .Interval }}, 1) AS xps_average,
+ MAX(xps) AS xps_max
+FROM (
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ {{ .Units }}/{{ .Interval }} AS xps,
+ %s
+FROM source
+WHERE %s
+GROUP BY time, dimensions)
+GROUP BY dimensions`, withStr, axis, dimensionsField, where)
+
+	// Request one point per second to use the finest resolution available.
+	points := uint(max(input.End.Sub(input.Start)/time.Second, 1))
+	return templateQuery{
+		Template: strings.TrimSpace(template),
+		Context: inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: options.mainTableRequired,
+			RequiredColumns:   options.requiredColumns,
+			Points:            points,
+			Units:             input.units(options.reverseDirection),
+		},
+	}
+}
+
+// toSQLRangeStats converts a graph input to an SQL request computing the
+// minimum, average, and maximum rates of each row over the whole range. The
+// previous period is ignored.
+func (input graphLineHandlerInput) toSQLRangeStats() []templateQuery {
+	mainTableRequired := requireMainTable(input.schema, input.Dimensions, input.Filter)
+	dimensions := input.Dimensions
+	if input.Bidirectional {
+		dimensions = slices.Concat(dimensions, input.reverseDirection().Dimensions)
+	}
+	columns := requiredColumns(dimensions, input.Filter)
+	queries := []templateQuery{input.rangeStatsSQL1(1, toSQL1Options{
+		mainTableRequired: mainTableRequired,
+		requiredColumns:   columns,
+	})}
+	if input.Bidirectional {
+		queries = append(queries, input.reverseDirection().rangeStatsSQL1(2, toSQL1Options{
+			skipWithClause:    true,
+			reverseDirection:  true,
+			mainTableRequired: mainTableRequired,
+			requiredColumns:   columns,
+		}))
+	}
+	return queries
+}
+
+// toSQLCompleteness builds the query counting the exporters sending flows for
+// each point. The filter is ignored as this is about the collection of flows,
+// but the table and the interval are the same as for the main query.
+func (input graphLineHandlerInput) toSQLCompleteness() templateQuery {
+	dimensions := input.Dimensions
+	if input.Bidirectional {
+		dimensions = slices.Concat(dimensions, input.reverseDirection().Dimensions)
+	}
+	template := `SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ uniq(ExporterAddress) AS exporters
+FROM {{ .Table }}
+WHERE {{ .Timefilter }}
+GROUP BY time
+ORDER BY time WITH FILL
+ FROM {{ .TimefilterStart }}
+ TO {{ .TimefilterEnd }} + INTERVAL 1 second
+ STEP {{ .Interval }}`
+	return templateQuery{
+		Template: template,
+		Context: inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
+			RequiredColumns:   requiredColumns(dimensions, input.Filter),
+			Points:            input.Points,
+		},
+	}
+}
+
 func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 	ctx := c.t.Context(gc.Request.Context())
 	input := graphLineHandlerInput{graphCommonHandlerInput: graphCommonHandlerInput{schema: c.d.Schema}}
@@ -232,24 +396,35 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+	if err := c.validateGraphLineInput(&input); err != nil {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := input.Filter.Validate(input.schema); err != nil {
-		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
-		return
+
+	queries := input.toSQL()
+	var statsQueries, completenessQueries []templateQuery
+	if input.RangeStats {
+		statsQueries = input.toSQLRangeStats()
 	}
-	if input.Limit > c.config.DimensionsLimit {
-		gc.JSON(http.StatusBadRequest,
-			gin.H{"message": fmt.Sprintf("Limit is set beyond maximum value (%d)",
-				c.config.DimensionsLimit)})
+	if input.Completeness {
+		completenessQueries = []templateQuery{input.toSQLCompleteness()}
+	}
+	if err := c.checkGuardrails(slices.Concat(queries, statsQueries, completenessQueries)); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-
-	queries := input.toSQL()
 	sqlQuery := c.finalizeTemplateQueries(queries)
-	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	headerQuery := sqlQuery
+	var statsQuery, completenessQuery string
+	if input.RangeStats {
+		statsQuery = c.finalizeTemplateQueries(statsQueries)
+		headerQuery = fmt.Sprintf("%s;\n%s", headerQuery, statsQuery)
+	}
+	if input.Completeness {
+		completenessQuery = c.finalizeTemplateQueries(completenessQueries)
+		headerQuery = fmt.Sprintf("%s;\n%s", headerQuery, completenessQuery)
+	}
+	gc.Header("X-SQL-Query", strings.ReplaceAll(headerQuery, "\n", "  "))
 
 	results := []struct {
 		Axis       uint8     `ch:"axis"`
@@ -258,8 +433,7 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 		Dimensions []string  `ch:"dimensions"`
 	}{}
 	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
-		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, sqlQuery)
 		return
 	}
 
@@ -438,17 +612,67 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 		}
 	}
 
-	for _, axis := range output.Axis {
-		switch axis {
-		case 1:
-			output.AxisNames[axis] = "Direct"
-		case 2:
-			output.AxisNames[axis] = "Reverse"
-		case 3, 4:
-			diff := input.End.Sub(input.Start)
-			_, name := nearestPeriod(diff)
-			output.AxisNames[axis] = fmt.Sprintf("Previous %s", name)
+	// Statistics over the whole range
+	if input.RangeStats {
+		stats := []struct {
+			Axis       uint8    `ch:"axis"`
+			Dimensions []string `ch:"dimensions"`
+			Min        float64  `ch:"xps_min"`
+			Average    float64  `ch:"xps_average"`
+			Max        float64  `ch:"xps_max"`
+		}{}
+		if err := c.d.ClickHouseDB.Conn.Select(ctx, &stats, statsQuery); err != nil {
+			c.queryError(gc, err, statsQuery)
+			return
 		}
+		rowIndexes := map[string]int{}
+		for i, row := range output.Rows {
+			rowIndexes[fmt.Sprintf("%d-%s", output.Axis[i], row)] = i
+		}
+		output.RangeMin = make([]int, totalRows)
+		output.RangeAverage = make([]int, totalRows)
+		output.RangeMax = make([]int, totalRows)
+		for _, stat := range stats {
+			i, ok := rowIndexes[fmt.Sprintf("%d-%s", stat.Axis, stat.Dimensions)]
+			if !ok {
+				continue
+			}
+			output.RangeMin[i] = int(stat.Min)
+			output.RangeAverage[i] = int(stat.Average)
+			output.RangeMax[i] = int(stat.Max)
+		}
+	}
+
+	// Ingest completeness
+	if input.Completeness {
+		completeness := []struct {
+			Time      time.Time `ch:"time"`
+			Exporters uint64    `ch:"exporters"`
+		}{}
+		if err := c.d.ClickHouseDB.Conn.Select(ctx, &completeness, completenessQuery); err != nil {
+			c.queryError(gc, err, completenessQuery)
+			return
+		}
+		timeIndexes := map[int64]int{}
+		for i, t := range output.Time {
+			timeIndexes[t.Unix()] = i
+		}
+		var expected uint64
+		for _, point := range completeness {
+			expected = max(expected, point.Exporters)
+		}
+		output.Completeness = make([]float64, len(output.Time))
+		for _, point := range completeness {
+			i, ok := timeIndexes[point.Time.Unix()]
+			if !ok || expected == 0 {
+				continue
+			}
+			output.Completeness[i] = math.Round(100*float64(point.Exporters)/float64(expected)) / 100
+		}
+	}
+
+	for _, axis := range output.Axis {
+		output.AxisNames[axis] = input.axisName(axis)
 	}
 	gc.JSON(http.StatusOK, output)
 }
@@ -303,6 +303,7 @@ ORDER BY time WITH FILL
 						Points:            100,
 						Units:             "l3bps",
 						MainTableRequired: true,
+						RequiredColumns:   []schema.ColumnKey{schema.ColumnSrcAddr, schema.ColumnDstAddr},
 					},
 					Template: `WITH
  source AS (SELECT * REPLACE (tupleElement(IPv6CIDRToRange(SrcAddr, if(tupleElement(IPv6CIDRToRange(SrcAddr, 96), 1) = toIPv6('::ffff:0.0.0.0'), 120, 48)), 1) AS SrcAddr) FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -338,10 +339,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
@@ -376,10 +378,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnInIfDescription, schema.ColumnSrcCountry, schema.ColumnOutIfDescription, schema.ColumnDstCountry},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
@@ -415,10 +418,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
@@ -437,10 +441,11 @@ ORDER BY time WITH FILL
  INTERPOLATE (dimensions AS emptyArrayString()))`,
 				}, {
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
 					},
 					Template: `SELECT 2 AS axis, * FROM (
 SELECT
@@ -474,10 +479,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "inl2%",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "inl2%",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
@@ -496,10 +502,11 @@ ORDER BY time WITH FILL
  INTERPOLATE (dimensions AS emptyArrayString()))`,
 				}, {
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "outl2%",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "outl2%",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
 					},
 					Template: `SELECT 2 AS axis, * FROM (
 SELECT
@@ -536,10 +543,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -580,10 +588,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -624,10 +633,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider, schema.ColumnOutIfProvider},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -647,10 +657,11 @@ ORDER BY time WITH FILL
  INTERPOLATE (dimensions AS ['Other', 'Other']))`,
 				}, {
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider, schema.ColumnOutIfProvider},
 					},
 					Template: `SELECT 2 AS axis, * FROM (
 SELECT
@@ -688,10 +699,11 @@ ORDER BY time WITH FILL
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 100,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -717,8 +729,9 @@ ORDER BY time WITH FILL
 							t := time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC)
 							return &t
 						}(),
-						Points: 100,
-						Units:  "l3bps",
+						Points:          100,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnExporterName, schema.ColumnInIfProvider},
 					},
 					Template: `SELECT 3 AS axis, * FROM (
 SELECT
@@ -760,6 +773,7 @@ ORDER BY time WITH FILL
 						MainTableRequired: true,
 						Points:            100,
 						Units:             "l3bps",
+						RequiredColumns:   []schema.ColumnKey{schema.ColumnInIfBoundary, schema.ColumnOutIfBoundary, schema.ColumnSrcAddr, schema.ColumnDstAddr},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -788,6 +802,7 @@ ORDER BY time WITH FILL
 						MainTableRequired: true,
 						Points:            100,
 						Units:             "l3bps",
+						RequiredColumns:   []schema.ColumnKey{schema.ColumnInIfBoundary, schema.ColumnOutIfBoundary, schema.ColumnSrcAddr, schema.ColumnDstAddr},
 					},
 					Template: `SELECT 3 AS axis, * FROM (
 SELECT
@@ -823,6 +838,203 @@ ORDER BY time WITH FILL
 	}
 }
 
+func TestGraphRangeStatsSQL(t *testing.T) {
+	cases := []struct {
+		Description string
+		Pos         helpers.Pos
+		Input       graphLineHandlerInput
+		Expected    []templateQuery
+	}{
+		{
+			Description: "no dimensions",
+			Pos:         helpers.Mark(),
+			Input: graphLineHandlerInput{
+				graphCommonHandlerInput: graphCommonHandlerInput{
+					Start:      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:        time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					Dimensions: []query.Column{},
+					Filter:     query.NewFilter("DstCountry = 'FR'"),
+					Units:      "l3bps",
+				},
+				Points:     100,
+				RangeStats: true,
+			},
+			Expected: []templateQuery{
+				{
+					Context: inputContext{
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          86400,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry},
+					},
+					Template: `WITH
+ source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
+SELECT
+ 1 AS axis,
+ dimensions,
+ MIN(xps) AS xps_min,
+ SUM(xps)/greatest(dateDiff('second', {{ .TimefilterStart }}, {{ .TimefilterEnd }})/{{ .Interval }}, 1) AS xps_average,
+ MAX(xps) AS xps_max
+FROM (
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ {{ .Units }}/{{ .Interval }} AS xps,
+ emptyArrayString() AS dimensions
+FROM source
+WHERE {{ .Timefilter }} AND (DstCountry = 'FR')
+GROUP BY time, dimensions)
+GROUP BY dimensions`,
+				},
+			},
+		}, {
+			Description: "dimensions, bidirectional, inl2%",
+			Pos:         helpers.Mark(),
+			Input: graphLineHandlerInput{
+				graphCommonHandlerInput: graphCommonHandlerInput{
+					Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					Limit: 20,
+					Dimensions: []query.Column{
+						query.NewColumn("ExporterName"),
+						query.NewColumn("InIfProvider"),
+					},
+					Filter: query.Filter{},
+					Units:  "inl2%",
+				},
+				Points:         100,
+				Bidirectional:  true,
+				PreviousPeriod: true,
+				RangeStats:     true,
+			},
+			Expected: []templateQuery{
+				{
+					Context: inputContext{
+						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points: 86400,
+						Units:  "inl2%",
+						RequiredColumns: []schema.ColumnKey{
+							schema.ColumnExporterName,
+							schema.ColumnInIfProvider,
+							schema.ColumnOutIfProvider,
+						},
+					},
+					Template: `WITH
+ source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
+ rows AS (SELECT ExporterName, InIfProvider FROM source WHERE {{ .Timefilter }} GROUP BY ExporterName, InIfProvider ORDER BY {{ .Units }} DESC LIMIT 20)
+SELECT
+ 1 AS axis,
+ dimensions,
+ MIN(xps) AS xps_min,
+ SUM(xps)/greatest(dateDiff('second', {{ .TimefilterStart }}, {{ .TimefilterEnd }})/{{ .Interval }}, 1) AS xps_average,
+ MAX(xps) AS xps_max
+FROM (
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ {{ .Units }}/{{ .Interval }} AS xps,
+ if((ExporterName, InIfProvider) IN rows, [ExporterName, InIfProvider], ['Other', 'Other']) AS dimensions
+FROM source
+WHERE {{ .Timefilter }}
+GROUP BY time, dimensions)
+GROUP BY dimensions`,
+				}, {
+					Context: inputContext{
+						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points: 86400,
+						Units:  "outl2%",
+						RequiredColumns: []schema.ColumnKey{
+							schema.ColumnExporterName,
+							schema.ColumnInIfProvider,
+							schema.ColumnOutIfProvider,
+						},
+					},
+					Template: `SELECT
+ 2 AS axis,
+ dimensions,
+ MIN(xps) AS xps_min,
+ SUM(xps)/greatest(dateDiff('second', {{ .TimefilterStart }}, {{ .TimefilterEnd }})/{{ .Interval }}, 1) AS xps_average,
+ MAX(xps) AS xps_max
+FROM (
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ {{ .Units }}/{{ .Interval }} AS xps,
+ if((ExporterName, OutIfProvider) IN rows, [ExporterName, OutIfProvider], ['Other', 'Other']) AS dimensions
+FROM source
+WHERE {{ .Timefilter }}
+GROUP BY time, dimensions)
+GROUP BY dimensions`,
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc.Input.schema = schema.NewMock(t)
+		if err := query.Columns(tc.Input.Dimensions).Validate(tc.Input.schema); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		if err := tc.Input.Filter.Validate(tc.Input.schema); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		t.Run(tc.Description, func(t *testing.T) {
+			got := tc.Input.toSQLRangeStats()
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("%stoSQLRangeStats (-got, +want):\n%s", tc.Pos, diff)
+			}
+		})
+	}
+}
+
+func TestGraphCompletenessSQL(t *testing.T) {
+	input := graphLineHandlerInput{
+		graphCommonHandlerInput: graphCommonHandlerInput{
+			schema: schema.NewMock(t),
+			Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+			End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+			Dimensions: []query.Column{
+				query.NewColumn("ExporterName"),
+			},
+			Filter: query.NewFilter("DstCountry = 'FR'"),
+			Units:  "l3bps",
+		},
+		Points:        100,
+		Bidirectional: true,
+		Completeness:  true,
+	}
+	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+		t.Fatalf("Validate() error:\n%+v", err)
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		t.Fatalf("Validate() error:\n%+v", err)
+	}
+	expected := templateQuery{
+		Context: inputContext{
+			Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+			End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+			Points: 100,
+			RequiredColumns: []schema.ColumnKey{
+				schema.ColumnDstCountry,
+				schema.ColumnSrcCountry,
+				schema.ColumnExporterName,
+			},
+		},
+		Template: `SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS time,
+ uniq(ExporterAddress) AS exporters
+FROM {{ .Table }}
+WHERE {{ .Timefilter }}
+GROUP BY time
+ORDER BY time WITH FILL
+ FROM {{ .TimefilterStart }}
+ TO {{ .TimefilterEnd }} + INTERVAL 1 second
+ STEP {{ .Interval }}`,
+	}
+	if diff := helpers.Diff(input.toSQLCompleteness(), expected); diff != "" {
+		t.Errorf("toSQLCompleteness (-got, +want):\n%s", diff)
+	}
+}
+
 func TestGraphLineHandler(t *testing.T) {
 	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
 	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -1670,6 +1882,146 @@ func TestGraphLineHandler(t *testing.T) {
 			},
 		})
 	})
+
+	t.Run("range stats", func(t *testing.T) {
+		expectedSQL := []struct {
+			Axis       uint8     `ch:"axis"`
+			Time       time.Time `ch:"time"`
+			Xps        float64   `ch:"xps"`
+			Dimensions []string  `ch:"dimensions"`
+		}{
+			{1, base, 1000, []string{"router1"}},
+			{1, base, 500, []string{"Other"}},
+			{1, base.Add(time.Minute), 3000, []string{"router1"}},
+			{1, base.Add(time.Minute), 100, []string{"Other"}},
+			{1, base.Add(2 * time.Minute), 2000, []string{"router1"}},
+			{1, base.Add(2 * time.Minute), 300, []string{"Other"}},
+		}
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), gomock.Any()).
+			SetArg(1, expectedSQL).
+			Return(nil)
+		expectedStatsSQL := []struct {
+			Axis       uint8    `ch:"axis"`
+			Dimensions []string `ch:"dimensions"`
+			Min        float64  `ch:"xps_min"`
+			Average    float64  `ch:"xps_average"`
+			Max        float64  `ch:"xps_max"`
+		}{
+			{1, []string{"Other"}, 10, 280.5, 1200},
+			{1, []string{"router1"}, 400, 1900.2, 4500},
+			{1, []string{"router2"}, 1, 1, 1}, // not in output
+		}
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), gomock.Any()).
+			SetArg(1, expectedStatsSQL).
+			Return(nil)
+
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				URL: "/api/v0/console/graph/line",
+				JSONInput: gin.H{
+					"start":       time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					"end":         time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					"points":      100,
+					"limit":       1,
+					"dimensions":  []string{"ExporterName"},
+					"units":       "l3bps",
+					"range-stats": true,
+				},
+				JSONOutput: gin.H{
+					"rows": [][]string{
+						{"router1"},
+						{"Other"},
+					},
+					"t": []string{
+						"2009-11-10T23:00:00Z",
+						"2009-11-10T23:01:00Z",
+						"2009-11-10T23:02:00Z",
+					},
+					"points": [][]int{
+						{1000, 3000, 2000},
+						{500, 100, 300},
+					},
+					"min":           []int{1000, 100},
+					"max":           []int{3000, 500},
+					"last":          []int{3000, 100},
+					"average":       []int{2000, 300},
+					"95th":          []int{2900, 480},
+					"range-min":     []int{400, 10},
+					"range-average": []int{1900, 280},
+					"range-max":     []int{4500, 1200},
+					"axis":          []int{1, 1},
+					"axis-names": map[int]string{
+						1: "Direct",
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("completeness", func(t *testing.T) {
+		expectedSQL := []struct {
+			Axis       uint8     `ch:"axis"`
+			Time       time.Time `ch:"time"`
+			Xps        float64   `ch:"xps"`
+			Dimensions []string  `ch:"dimensions"`
+		}{
+			{1, base, 1000, []string{}},
+			{1, base.Add(time.Minute), 3000, []string{}},
+			{1, base.Add(2 * time.Minute), 2000, []string{}},
+		}
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), gomock.Any()).
+			SetArg(1, expectedSQL).
+			Return(nil)
+		expectedCompletenessSQL := []struct {
+			Time      time.Time `ch:"time"`
+			Exporters uint64    `ch:"exporters"`
+		}{
+			{base, 3},
+			{base.Add(time.Minute), 2},
+			{base.Add(2 * time.Minute), 0},
+		}
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), gomock.Any()).
+			SetArg(1, expectedCompletenessSQL).
+			Return(nil)
+
+		helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				URL: "/api/v0/console/graph/line",
+				JSONInput: gin.H{
+					"start":        time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					"end":          time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					"points":       100,
+					"limit":        1,
+					"dimensions":   []string{},
+					"units":        "l3bps",
+					"completeness": true,
+				},
+				JSONOutput: gin.H{
+					"rows": [][]string{{}},
+					"t": []string{
+						"2009-11-10T23:00:00Z",
+						"2009-11-10T23:01:00Z",
+						"2009-11-10T23:02:00Z",
+					},
+					"points":       [][]int{{1000, 3000, 2000}},
+					"min":          []int{1000},
+					"max":          []int{3000},
+					"last":         []int{3000},
+					"average":      []int{2000},
+					"95th":         []int{2900},
+					"completeness": []float64{1, 0.67, 0},
+					"axis":         []int{1},
+					"axis-names": map[int]string{
+						1: "Direct",
+					},
+				},
+			},
+		})
+	})
 }
 
 func TestGetTableInterval(t *testing.T) {
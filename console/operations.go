@@ -0,0 +1,282 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+// operationsKafkaLagWarning is the consumer lag above which the outlet is
+// considered to be late.
+const operationsKafkaLagWarning = 100_000
+
+// operationsStatus is the status of an indicator of the operations page.
+type operationsStatus string
+
+const (
+	operationsOK      operationsStatus = "ok"
+	operationsWarning operationsStatus = "warning"
+	operationsError   operationsStatus = "error"
+)
+
+// worse returns the worse of two statuses.
+func (s operationsStatus) worse(other operationsStatus) operationsStatus {
+	order := []operationsStatus{operationsOK, operationsWarning, operationsError}
+	if slices.Index(order, other) > slices.Index(order, s) {
+		return other
+	}
+	return s
+}
+
+// operationsIndicator describes how to extract an indicator from the metrics
+// of a service.
+type operationsIndicator struct {
+	Service string
+	Name    string
+	Metric  string
+	// Labels are the labels to keep, values for other labels are summed.
+	Labels []string
+	// Status computes the status of the indicator from its value and its
+	// increase since the previous scrape (0 for the first scrape).
+	Status func(value, increase float64) operationsStatus
+}
+
+// operationsIndicators are the indicators displayed on the operations page.
+var operationsIndicators = []operationsIndicator{
+	{
+		Service: "inlet",
+		Name:    "Kernel drops",
+		Metric:  "akvorado_inlet_flow_input_udp_in_dropped_packets_total",
+		Labels:  []string{"listener"},
+		Status:  warnOnIncrease,
+	}, {
+		Service: "inlet",
+		Name:    "Received packets",
+		Metric:  "akvorado_inlet_flow_input_udp_packets_total",
+		Labels:  []string{"listener"},
+		Status:  func(float64, float64) operationsStatus { return operationsOK },
+	}, {
+		Service: "outlet",
+		Name:    "Kafka lag",
+		Metric:  "akvorado_outlet_kafka_consumergroup_lag_messages",
+		Status: func(value, _ float64) operationsStatus {
+			if value < 0 || value > operationsKafkaLagWarning {
+				return operationsWarning
+			}
+			return operationsOK
+		},
+	}, {
+		Service: "outlet",
+		Name:    "ClickHouse insert errors",
+		Metric:  "akvorado_outlet_clickhouse_errors_total",
+		Labels:  []string{"error"},
+		Status:  errorOnIncrease,
+	}, {
+		Service: "outlet",
+		Name:    "Re-export errors",
+		Metric:  "akvorado_outlet_reexport_errors_total",
+		Labels:  []string{"target"},
+		Status:  errorOnIncrease,
+	}, {
+		Service: "orchestrator",
+		Name:    "Running migrations",
+		Metric:  "akvorado_orchestrator_clickhouse_running_migrations",
+		Status:  warnWhenPositive,
+	}, {
+		Service: "orchestrator",
+		Name:    "Schema drift steps",
+		Metric:  "akvorado_orchestrator_clickhouse_schema_drift_steps",
+		Status:  warnWhenPositive,
+	},
+}
+
+func warnOnIncrease(_, increase float64) operationsStatus {
+	if increase > 0 {
+		return operationsWarning
+	}
+	return operationsOK
+}
+
+func errorOnIncrease(_, increase float64) operationsStatus {
+	if increase > 0 {
+		return operationsError
+	}
+	return operationsOK
+}
+
+func warnWhenPositive(value, _ float64) operationsStatus {
+	if value > 0 {
+		return operationsWarning
+	}
+	return operationsOK
+}
+
+// operationsIndicatorOutput is an indicator as returned by the API.
+type operationsIndicatorOutput struct {
+	Name     string            `json:"name"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Value    float64           `json:"value"`
+	Increase float64           `json:"increase"`
+	Status   operationsStatus  `json:"status"`
+}
+
+// operationsServiceOutput is a scraped service as returned by the API.
+type operationsServiceOutput struct {
+	Service    string                      `json:"service"`
+	URL        string                      `json:"url"`
+	Up         bool                        `json:"up"`
+	Error      string                      `json:"error,omitempty"`
+	Status     operationsStatus            `json:"status"`
+	Indicators []operationsIndicatorOutput `json:"indicators"`
+}
+
+// operationsState keeps the values of the previous scrape to compute the
+// increase of counters.
+type operationsState struct {
+	lock     sync.Mutex
+	previous map[string]float64
+}
+
+// scrapeService fetches and parses the metrics of the provided service. The
+// scrape is canceled with the provided incoming request.
+func (c *Component) scrapeService(incoming *http.Request, service, baseURL string) (map[string]*dto.MetricFamily, error) {
+	url := fmt.Sprintf("%s/api/v0/%s/metrics", strings.TrimSuffix(baseURL, "/"), service)
+	req, err := http.NewRequestWithContext(c.t.Context(incoming.Context()), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{Timeout: c.config.Operations.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse metrics: %w", err)
+	}
+	return families, nil
+}
+
+// operationsExtract computes the indicators of a service from its metrics.
+func (c *Component) operationsExtract(service, baseURL string, families map[string]*dto.MetricFamily) []operationsIndicatorOutput {
+	indicators := []operationsIndicatorOutput{}
+	for _, indicator := range operationsIndicators {
+		if indicator.Service != service {
+			continue
+		}
+		family, ok := families[indicator.Metric]
+		if !ok {
+			continue
+		}
+		outputs := map[string]*operationsIndicatorOutput{}
+		keys := []string{}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				if slices.Contains(indicator.Labels, label.GetName()) {
+					labels[label.GetName()] = label.GetValue()
+				}
+			}
+			key := indicator.Name
+			for _, name := range indicator.Labels {
+				key = fmt.Sprintf("%s,%s=%s", key, name, labels[name])
+			}
+			output, ok := outputs[key]
+			if !ok {
+				output = &operationsIndicatorOutput{Name: indicator.Name}
+				if len(labels) > 0 {
+					output.Labels = labels
+				}
+				outputs[key] = output
+				keys = append(keys, key)
+			}
+			switch {
+			case metric.Counter != nil:
+				output.Value += metric.GetCounter().GetValue()
+			case metric.Gauge != nil:
+				output.Value += metric.GetGauge().GetValue()
+			case metric.Untyped != nil:
+				output.Value += metric.GetUntyped().GetValue()
+			}
+		}
+		slices.Sort(keys)
+
+		c.operations.lock.Lock()
+		for _, key := range keys {
+			output := outputs[key]
+			stateKey := fmt.Sprintf("%s %s %s", service, baseURL, key)
+			if previous, ok := c.operations.previous[stateKey]; ok && output.Value > previous {
+				output.Increase = output.Value - previous
+			}
+			c.operations.previous[stateKey] = output.Value
+			output.Status = indicator.Status(output.Value, output.Increase)
+			indicators = append(indicators, *output)
+		}
+		c.operations.lock.Unlock()
+	}
+	return indicators
+}
+
+func (c *Component) operationsHandlerFunc(gc *gin.Context) {
+	services := []operationsServiceOutput{}
+	for _, service := range []struct {
+		Name string
+		URLs []string
+	}{
+		{"inlet", c.config.Operations.Inlet},
+		{"outlet", c.config.Operations.Outlet},
+		{"orchestrator", c.config.Operations.Orchestrator},
+	} {
+		for _, url := range service.URLs {
+			services = append(services, operationsServiceOutput{
+				Service: service.Name,
+				URL:     url,
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for idx := range services {
+		wg.Go(func() {
+			output := &services[idx]
+			families, err := c.scrapeService(gc.Request, output.Service, output.URL)
+			if err != nil {
+				output.Error = err.Error()
+				output.Status = operationsError
+				output.Indicators = []operationsIndicatorOutput{}
+				return
+			}
+			output.Up = true
+			output.Indicators = c.operationsExtract(output.Service, output.URL, families)
+			output.Status = operationsOK
+			for _, indicator := range output.Indicators {
+				output.Status = output.Status.worse(indicator.Status)
+			}
+		})
+	}
+	wg.Wait()
+
+	status := operationsOK
+	for _, service := range services {
+		status = status.worse(service.Status)
+	}
+	gc.IndentedJSON(http.StatusOK, gin.H{
+		"services": services,
+		"status":   status,
+	})
+}
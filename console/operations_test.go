@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+
+	"akvorado/common/helpers"
+)
+
+const operationsOutletMetrics = `# TYPE akvorado_outlet_kafka_consumergroup_lag_messages gauge
+akvorado_outlet_kafka_consumergroup_lag_messages 1200
+# TYPE akvorado_outlet_clickhouse_errors_total counter
+akvorado_outlet_clickhouse_errors_total{error="send"} 4
+akvorado_outlet_clickhouse_errors_total{error="connect"} 1
+# TYPE akvorado_outlet_flows_received_total counter
+akvorado_outlet_flows_received_total 1000
+`
+
+func TestOperations(t *testing.T) {
+	outlet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/outlet/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(operationsOutletMetrics))
+	}))
+	defer outlet.Close()
+	orchestrator := httptest.NewServer(http.NotFoundHandler())
+	defer orchestrator.Close()
+
+	config := DefaultConfiguration()
+	config.Operations.Outlet = []string{outlet.URL}
+	config.Operations.Orchestrator = []string{orchestrator.URL + "/"}
+	_, h, _, _ := NewMock(t, config)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/operations",
+			JSONOutput: gin.H{
+				"status": "error",
+				"services": []gin.H{
+					{
+						"service": "outlet",
+						"url":     outlet.URL,
+						"up":      true,
+						"status":  "ok",
+						"indicators": []gin.H{
+							{
+								"name":     "Kafka lag",
+								"value":    1200,
+								"increase": 0,
+								"status":   "ok",
+							}, {
+								"name":     "ClickHouse insert errors",
+								"labels":   gin.H{"error": "connect"},
+								"value":    1,
+								"increase": 0,
+								"status":   "ok",
+							}, {
+								"name":     "ClickHouse insert errors",
+								"labels":   gin.H{"error": "send"},
+								"value":    4,
+								"increase": 0,
+								"status":   "ok",
+							},
+						},
+					}, {
+						"service":    "orchestrator",
+						"url":        orchestrator.URL + "/",
+						"up":         false,
+						"error":      "unexpected status 404",
+						"status":     "error",
+						"indicators": []gin.H{},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestOperationsExtract(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	extract := func(metrics string) []operationsIndicatorOutput {
+		t.Helper()
+		parser := expfmt.NewTextParser(model.UTF8Validation)
+		families, err := parser.TextToMetricFamilies(strings.NewReader(metrics))
+		if err != nil {
+			t.Fatalf("TextToMetricFamilies() error:\n%+v", err)
+		}
+		return c.operationsExtract("inlet", "http://inlet", families)
+	}
+
+	got := extract(`# TYPE akvorado_inlet_flow_input_udp_in_dropped_packets_total gauge
+akvorado_inlet_flow_input_udp_in_dropped_packets_total{listener=":2055",worker="0"} 10
+akvorado_inlet_flow_input_udp_in_dropped_packets_total{listener=":2055",worker="1"} 5
+`)
+	expected := []operationsIndicatorOutput{
+		{
+			Name:   "Kernel drops",
+			Labels: map[string]string{"listener": ":2055"},
+			Value:  15,
+			Status: operationsOK,
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("operationsExtract() (-got, +want):\n%s", diff)
+	}
+
+	// Drops are increasing
+	got = extract(`# TYPE akvorado_inlet_flow_input_udp_in_dropped_packets_total gauge
+akvorado_inlet_flow_input_udp_in_dropped_packets_total{listener=":2055",worker="0"} 12
+akvorado_inlet_flow_input_udp_in_dropped_packets_total{listener=":2055",worker="1"} 5
+`)
+	expected[0].Value = 17
+	expected[0].Increase = 2
+	expected[0].Status = operationsWarning
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("operationsExtract() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestOperationsStatusWorse(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		A, B     operationsStatus
+		Expected operationsStatus
+	}{
+		{helpers.Mark(), operationsOK, operationsOK, operationsOK},
+		{helpers.Mark(), operationsOK, operationsWarning, operationsWarning},
+		{helpers.Mark(), operationsError, operationsWarning, operationsError},
+		{helpers.Mark(), operationsWarning, operationsOK, operationsWarning},
+	}
+	for _, tc := range cases {
+		if got := tc.A.worse(tc.B); got != tc.Expected {
+			t.Errorf("%s%s.worse(%s) == %s, expected %s", tc.Pos, tc.A, tc.B, got, tc.Expected)
+		}
+	}
+}
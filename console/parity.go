@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+)
+
+// parityHandlerInput describes the input for the /parity endpoint.
+type parityHandlerInput struct {
+	Start  time.Time `json:"start" binding:"required"`
+	End    time.Time `json:"end" binding:"required,gtfield=Start"`
+	Points uint      `json:"points" binding:"required,min=5,max=2000"`
+}
+
+// parityRow is a time bucket returned by one of the destinations.
+type parityRow struct {
+	Time  time.Time `ch:"Time"`
+	Flows uint64    `ch:"Flows"`
+	Bytes uint64    `ch:"Bytes"`
+}
+
+// parityBucket compares a time bucket between the main destination and the
+// second one.
+type parityBucket struct {
+	Time      time.Time `json:"t"`
+	Flows     [2]uint64 `json:"flows"`
+	Bytes     [2]uint64 `json:"bytes"`
+	FlowsDiff float64   `json:"flows-diff"`
+	BytesDiff float64   `json:"bytes-diff"`
+	Mismatch  bool      `json:"mismatch"`
+}
+
+// parityDifference returns the relative difference between two values, using
+// the largest one as reference.
+func parityDifference(main, other uint64) float64 {
+	if main == other {
+		return 0
+	}
+	return (float64(other) - float64(main)) / float64(max(main, other))
+}
+
+// parityBuckets merges the rows returned by the two destinations.
+func (c *Component) parityBuckets(main, other []parityRow) []parityBucket {
+	buckets := []parityBucket{}
+	index := map[int64]int{}
+	for destination, rows := range [][]parityRow{main, other} {
+		for _, row := range rows {
+			idx, ok := index[row.Time.Unix()]
+			if !ok {
+				idx = len(buckets)
+				index[row.Time.Unix()] = idx
+				buckets = append(buckets, parityBucket{Time: row.Time})
+			}
+			buckets[idx].Flows[destination] += row.Flows
+			buckets[idx].Bytes[destination] += row.Bytes
+		}
+	}
+	slices.SortFunc(buckets, func(a, b parityBucket) int {
+		return a.Time.Compare(b.Time)
+	})
+	for idx := range buckets {
+		bucket := &buckets[idx]
+		bucket.FlowsDiff = parityDifference(bucket.Flows[0], bucket.Flows[1])
+		bucket.BytesDiff = parityDifference(bucket.Bytes[0], bucket.Bytes[1])
+		bucket.Mismatch = math.Abs(bucket.FlowsDiff) > c.config.Parity.Tolerance ||
+			math.Abs(bucket.BytesDiff) > c.config.Parity.Tolerance
+	}
+	return buckets
+}
+
+func (c *Component) parityHandlerFunc(gc *gin.Context) {
+	if c.parityDB == nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Parity page is not enabled."})
+		return
+	}
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	if !slices.Contains(c.config.Parity.AllowedUsers, user) {
+		gc.JSON(http.StatusForbidden, gin.H{"message": "Parity page is restricted to allowed users."})
+		return
+	}
+	ctx := c.t.Context(gc.Request.Context())
+	var input parityHandlerInput
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
+	// Flows are compared using the raw table as both destinations may not
+	// have the same consolidated tables yet.
+	tq := templateQuery{
+		Template: `
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS Time,
+ count() AS Flows,
+ SUM(Bytes*SamplingRate) AS Bytes
+FROM {{ .Table }}
+WHERE {{ .Timefilter }}
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM {{ .TimefilterStart }}
+ TO {{ .TimefilterEnd }} + INTERVAL 1 second
+ STEP {{ .Interval }}`,
+		Context: inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: true,
+			Points:            input.Points,
+		},
+	}
+	if err := c.checkGuardrails([]templateQuery{tq}); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	query := strings.TrimSpace(c.finalizeTemplateQuery(tq))
+	gc.Header("X-SQL-Query", query)
+
+	var wg sync.WaitGroup
+	var rows [2][]parityRow
+	var errs [2]error
+	for idx, db := range []*clickhousedb.Component{c.d.ClickHouseDB, c.parityDB} {
+		wg.Go(func() {
+			errs[idx] = db.Conn.Select(ctx, &rows[idx], query)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			c.queryError(gc, err, query)
+			return
+		}
+	}
+
+	buckets := c.parityBuckets(rows[0], rows[1])
+	mismatches := 0
+	for _, bucket := range buckets {
+		if bucket.Mismatch {
+			mismatches++
+		}
+	}
+	gc.JSON(http.StatusOK, gin.H{
+		"buckets":    buckets,
+		"mismatches": mismatches,
+		"tolerance":  c.config.Parity.Tolerance,
+	})
+}
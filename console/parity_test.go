@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/helpers"
+)
+
+func TestParityDisabled(t *testing.T) {
+	_, h, _, _ := NewMock(t, DefaultConfiguration())
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/parity",
+			JSONInput: gin.H{
+				"start":  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+				"end":    time.Date(2009, time.November, 11, 0, 0, 0, 0, time.UTC),
+				"points": 5,
+			},
+			StatusCode: 404,
+			JSONOutput: gin.H{"message": "Parity page is not enabled."},
+		},
+	})
+}
+
+func TestParity(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Parity.Enabled = true
+	config.Parity.AllowedUsers = []string{"__default"}
+	config.Guardrails.MaxIntervals = 3600
+	c, h, mockConn, _ := NewMock(t, config)
+	parityConn := mocks.NewMockConn(gomock.NewController(t))
+	parityConn.EXPECT().Close().Return(nil).AnyTimes()
+	c.parityDB.Conn = parityConn
+
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	expectedSQL := strings.TrimSpace(`
+SELECT
+ toStartOfInterval(TimeReceived + INTERVAL 720 second, INTERVAL 720 second) - INTERVAL 720 second AS Time,
+ count() AS Flows,
+ SUM(Bytes*SamplingRate) AS Bytes
+FROM flows
+WHERE TimeReceived BETWEEN toDateTime('2009-11-10 23:00:00', 'UTC') AND toDateTime('2009-11-11 00:00:00', 'UTC')
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM toDateTime('2009-11-10 23:00:00', 'UTC')
+ TO toDateTime('2009-11-11 00:00:00', 'UTC') + INTERVAL 1 second
+ STEP 720`)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), expectedSQL).
+		SetArg(1, []parityRow{
+			{base, 1000, 1_000_000},
+			{base.Add(12 * time.Minute), 1000, 1_000_000},
+			{base.Add(24 * time.Minute), 1000, 1_000_000},
+		}).
+		Return(nil)
+	parityConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), expectedSQL).
+		SetArg(1, []parityRow{
+			{base, 1000, 1_000_000},
+			{base.Add(12 * time.Minute), 995, 999_000},
+			{base.Add(24 * time.Minute), 500, 500_000},
+		}).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "user not allowed",
+			URL:         "/api/v0/console/parity",
+			Header: func() http.Header {
+				headers := make(http.Header)
+				headers.Add("Remote-User", "alfred")
+				return headers
+			}(),
+			JSONInput: gin.H{
+				"start":  base,
+				"end":    base.Add(time.Hour),
+				"points": 5,
+			},
+			StatusCode: 403,
+			JSONOutput: gin.H{"message": "Parity page is restricted to allowed users."},
+		}, {
+			Description: "bad input",
+			URL:         "/api/v0/console/parity",
+			JSONInput: gin.H{
+				"start":  base.Add(time.Hour),
+				"end":    base,
+				"points": 5,
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{
+				"message": "Key: 'parityHandlerInput.End' Error:Field validation for 'End' failed on the 'gtfield' tag",
+			},
+		}, {
+			Description: "time range too large",
+			URL:         "/api/v0/console/parity",
+			JSONInput: gin.H{
+				"start":  base,
+				"end":    base.Add(24 * time.Hour),
+				"points": 5,
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{
+				"message": "Time range is too large, maximum is 1h0m0s for the selected dimensions and filter",
+			},
+		}, {
+			Description: "compare destinations",
+			URL:         "/api/v0/console/parity",
+			JSONInput: gin.H{
+				"start":  base,
+				"end":    base.Add(time.Hour),
+				"points": 5,
+			},
+			JSONOutput: gin.H{
+				"buckets": []gin.H{
+					{
+						"t":          "2009-11-10T23:00:00Z",
+						"flows":      []uint64{1000, 1000},
+						"bytes":      []uint64{1_000_000, 1_000_000},
+						"flows-diff": 0,
+						"bytes-diff": 0,
+						"mismatch":   false,
+					}, {
+						"t":          "2009-11-10T23:12:00Z",
+						"flows":      []uint64{1000, 995},
+						"bytes":      []uint64{1_000_000, 999_000},
+						"flows-diff": -0.005,
+						"bytes-diff": -0.001,
+						"mismatch":   false,
+					}, {
+						"t":          "2009-11-10T23:24:00Z",
+						"flows":      []uint64{1000, 500},
+						"bytes":      []uint64{1_000_000, 500_000},
+						"flows-diff": -0.5,
+						"bytes-diff": -0.5,
+						"mismatch":   true,
+					},
+				},
+				"mismatches": 1,
+				"tolerance":  0.01,
+			},
+		},
+	})
+}
@@ -5,6 +5,7 @@ package console
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"akvorado/common/schema"
@@ -23,6 +24,18 @@ func requireMainTable(sch *schema.Component, qcs []query.Column, qf query.Filter
 	return false
 }
 
+// requiredColumns returns the columns needed by the provided dimensions and
+// filter. They are used to select a table containing all of them.
+func requiredColumns(qcs []query.Column, qf query.Filter) []schema.ColumnKey {
+	keys := slices.Clone(qf.Columns())
+	for _, qc := range qcs {
+		if !slices.Contains(keys, qc.Key()) {
+			keys = append(keys, qc.Key())
+		}
+	}
+	return keys
+}
+
 // fixQueryColumnName fix capitalization of the provided column name
 func (c *Component) fixQueryColumnName(name string) string {
 	name = strings.ToLower(name)
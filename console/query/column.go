@@ -7,6 +7,8 @@ package query
 
 import (
 	"fmt"
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"akvorado/common/helpers"
@@ -65,7 +67,16 @@ func (qc *Column) Key() schema.ColumnKey {
 // Validate should be called before using the column. We need a schema component
 // for that.
 func (qc *Column) Validate(schema *schema.Component) error {
-	if column, ok := schema.LookupColumnByName(qc.name); ok && !column.ConsoleNotDimension && !column.Disabled {
+	column, ok := schema.LookupColumnByName(qc.name)
+	if ok && column.AliasOf != 0 {
+		// Use the target of a column alias
+		column, ok = schema.LookupColumnByKey(column.AliasOf)
+		if !ok {
+			return fmt.Errorf("unknown target for column alias %s", qc.name)
+		}
+		qc.name = column.Name
+	}
+	if ok && !column.ConsoleNotDimension && !column.Disabled {
 		qc.key = column.Key
 		qc.validated = true
 		return nil
@@ -141,6 +152,10 @@ func (qc Column) ToSQLSelect(sch *schema.Component) string {
 			array[bit] = fmt.Sprintf("if(bitTest(%s, %d) = 1, '%s', '')", qc, bit, v[:1])
 		}
 		strValue = fmt.Sprintf("arrayStringConcat([%s], '')", strings.Join(array, ", "))
+	case schema.ColumnIPDSCP:
+		strValue = codepointsToSQL(qc, dscpNames)
+	case schema.ColumnIPECN:
+		strValue = codepointsToSQL(qc, ecnNames)
 	case schema.ColumnDstPort, schema.ColumnSrcPort:
 		strValue = fmt.Sprintf(`replaceRegexpOne(multiIf(%s==6, concat(toString(%s), '/', dictGetOrDefault('%s', 'name', %s,'')), %s==17, concat(toString(%s), '/', dictGetOrDefault('%s', 'name', %s,'')), toString(%s)), '/$', '')`,
 			schema.ColumnProto, qc, schema.DictionaryTCP, qc, schema.ColumnProto, qc, schema.DictionaryUDP, qc, qc)
@@ -158,3 +173,115 @@ func (qc Column) ToSQLSelect(sch *schema.Component) string {
 	}
 	return strValue
 }
+
+// codepoint is a value of a field with a well-known name.
+type codepoint struct {
+	Value uint8
+	Name  string
+}
+
+// dscpNames are the names of the standard DSCP values (RFC 4594 and RFC 8622).
+var dscpNames = []codepoint{
+	{0, "BE"}, {1, "LE"},
+	{8, "CS1"}, {10, "AF11"}, {12, "AF12"}, {14, "AF13"},
+	{16, "CS2"}, {18, "AF21"}, {20, "AF22"}, {22, "AF23"},
+	{24, "CS3"}, {26, "AF31"}, {28, "AF32"}, {30, "AF33"},
+	{32, "CS4"}, {34, "AF41"}, {36, "AF42"}, {38, "AF43"},
+	{40, "CS5"}, {44, "VA"}, {46, "EF"},
+	{48, "CS6"}, {56, "CS7"},
+}
+
+// ecnNames are the names of the ECN codepoints (RFC 3168).
+var ecnNames = []codepoint{
+	{0, "Not-ECT"}, {1, "ECT(1)"}, {2, "ECT(0)"}, {3, "CE"},
+}
+
+// codepointsToSQL returns an expression displaying the value of a column
+// followed by its name when known (for example, "46/EF").
+func codepointsToSQL(qc Column, codepoints []codepoint) string {
+	values := make([]string, len(codepoints))
+	names := make([]string, len(codepoints))
+	for idx, codepoint := range codepoints {
+		values[idx] = strconv.Itoa(int(codepoint.Value))
+		names[idx] = fmt.Sprintf("'%s'", codepoint.Name)
+	}
+	return fmt.Sprintf(`replaceRegexpOne(concat(toString(%s), '/', transform(%s, [%s], [%s], '')), '/$', '')`,
+		qc, qc, strings.Join(values, ", "), strings.Join(names, ", "))
+}
+
+// ToFilterExpression turns a value, as returned by the expression from
+// ToSQLSelect(), into a filter expression selecting this value. It returns
+// false when this is not possible.
+func (qc Column) ToFilterExpression(sch *schema.Component, value string) (string, bool) {
+	key := qc.Key()
+	switch key {
+	// Special cases
+	case schema.ColumnSrcAS, schema.ColumnDstAS, schema.ColumnDst1stAS, schema.ColumnDst2ndAS, schema.ColumnDst3rdAS:
+		asn, _, _ := strings.Cut(value, ":")
+		if _, err := strconv.ParseUint(asn, 10, 32); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s = AS%s", qc, asn), true
+	case schema.ColumnInIfBoundary, schema.ColumnOutIfBoundary:
+		switch value {
+		case "external", "internal", "undefined":
+			return fmt.Sprintf("%s = %s", qc, value), true
+		}
+		return "", false
+	case schema.ColumnEType:
+		if value != "IPv4" && value != "IPv6" {
+			return "", false
+		}
+		return fmt.Sprintf("%s = %s", qc, value), true
+	case schema.ColumnProto:
+		if value == "???" {
+			return "", false
+		}
+		return filterStringExpression(qc, value)
+	case schema.ColumnDstPort, schema.ColumnSrcPort, schema.ColumnIPDSCP, schema.ColumnIPECN:
+		value, _, _ = strings.Cut(value, "/")
+		if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s = %s", qc, value), true
+	case schema.ColumnSrcMAC, schema.ColumnDstMAC:
+		return fmt.Sprintf("%s = %s", qc, value), true
+	case schema.ColumnMPLSLabels, schema.ColumnDstASPath, schema.ColumnDstCommunities, schema.ColumnTCPFlags:
+		return "", false
+	}
+
+	// Generic cases
+	column, ok := sch.LookupColumnByKey(key)
+	if !ok {
+		return "", false
+	}
+	switch column.ParserType {
+	case "ip":
+		if _, err := netip.ParseAddr(value); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s = %s", qc, value), true
+	case "uint":
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s = %s", qc, value), true
+	case "string":
+		return filterStringExpression(qc, value)
+	}
+	return "", false
+}
+
+// filterStringExpression returns a filter expression comparing a column with
+// a quoted string. The filter language has no escape sequence.
+func filterStringExpression(qc Column, value string) (string, bool) {
+	switch {
+	case strings.ContainsAny(value, "\r\n"):
+		return "", false
+	case !strings.Contains(value, `"`):
+		return fmt.Sprintf(`%s = "%s"`, qc, value), true
+	case !strings.Contains(value, "'"):
+		return fmt.Sprintf(`%s = '%s'`, qc, value), true
+	}
+	return "", false
+}
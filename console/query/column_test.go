@@ -4,6 +4,7 @@
 package query_test
 
 import (
+	"fmt"
 	"testing"
 
 	"akvorado/common/helpers"
@@ -42,6 +43,30 @@ func TestUnmarshalQueryColumn(t *testing.T) {
 	}
 }
 
+func TestQueryColumnAlias(t *testing.T) {
+	config := schema.DefaultConfiguration()
+	config.ColumnAliases = []schema.ColumnAlias{
+		{Name: "Exporter", Column: "ExporterName"},
+	}
+	sch, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	var qc query.Column
+	if err := qc.UnmarshalText([]byte("Exporter")); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	if err := qc.Validate(sch); err != nil {
+		t.Fatalf("Validate() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(qc.Key(), schema.ColumnExporterName); diff != "" {
+		t.Fatalf("Key() (-got, +want):\n%s", diff)
+	}
+	if diff := helpers.Diff(qc.String(), "ExporterName"); diff != "" {
+		t.Fatalf("String() (-got, +want):\n%s", diff)
+	}
+}
+
 func TestQueryColumnSQLSelect(t *testing.T) {
 	sch := schema.NewMock(t)
 	cases := []struct {
@@ -109,6 +134,12 @@ func TestQueryColumnSQLSelect(t *testing.T) {
 		}, {
 			Input:    schema.ColumnSrcPort,
 			Expected: "replaceRegexpOne(multiIf(Proto==6, concat(toString(SrcPort), '/', dictGetOrDefault('tcp', 'name', SrcPort,'')), Proto==17, concat(toString(SrcPort), '/', dictGetOrDefault('udp', 'name', SrcPort,'')), toString(SrcPort)), '/$', '')",
+		}, {
+			Input:    schema.ColumnIPECN,
+			Expected: "replaceRegexpOne(concat(toString(IPECN), '/', transform(IPECN, [0, 1, 2, 3], ['Not-ECT', 'ECT(1)', 'ECT(0)', 'CE'], '')), '/$', '')",
+		}, {
+			Input:    schema.ColumnIPDSCP,
+			Expected: "replaceRegexpOne(concat(toString(IPDSCP), '/', transform(IPDSCP, [0, 1, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34, 36, 38, 40, 44, 46, 48, 56], ['BE', 'LE', 'CS1', 'AF11', 'AF12', 'AF13', 'CS2', 'AF21', 'AF22', 'AF23', 'CS3', 'AF31', 'AF32', 'AF33', 'CS4', 'AF41', 'AF42', 'AF43', 'CS5', 'VA', 'EF', 'CS6', 'CS7'], '')), '/$', '')",
 		},
 	}
 	for _, tc := range cases {
@@ -125,6 +156,59 @@ func TestQueryColumnSQLSelect(t *testing.T) {
 	}
 }
 
+func TestQueryColumnFilterExpression(t *testing.T) {
+	sch := schema.NewMock(t).EnableAllColumns()
+	cases := []struct {
+		Input    schema.ColumnKey
+		Value    string
+		Expected string
+	}{
+		{schema.ColumnSrcAddr, "192.0.2.1", "SrcAddr = 192.0.2.1"},
+		{schema.ColumnExporterAddress, "2001:db8::1", "ExporterAddress = 2001:db8::1"},
+		{schema.ColumnSrcAddr, "not an IP", ""},
+		{schema.ColumnDstAS, "12322: Free SAS", "DstAS = AS12322"},
+		{schema.ColumnDst2ndAS, "0: ???", "Dst2ndAS = AS0"},
+		{schema.ColumnProto, "TCP", `Proto = "TCP"`},
+		{schema.ColumnProto, "???", ""},
+		{schema.ColumnEType, "IPv6", "EType = IPv6"},
+		{schema.ColumnEType, "???", ""},
+		{schema.ColumnOutIfSpeed, "10000", "OutIfSpeed = 10000"},
+		{schema.ColumnExporterName, "th2-edge1", `ExporterName = "th2-edge1"`},
+		{schema.ColumnInIfDescription, `Transit: "Cogent"`, `InIfDescription = 'Transit: "Cogent"'`},
+		{schema.ColumnInIfDescription, `"Cogent's"`, ""},
+		{schema.ColumnDstMAC, "11:22:33:44:55:66", "DstMAC = 11:22:33:44:55:66"},
+		{schema.ColumnInIfBoundary, "external", "InIfBoundary = external"},
+		{schema.ColumnDstPort, "443/https", "DstPort = 443"},
+		{schema.ColumnSrcPort, "5353", "SrcPort = 5353"},
+		{schema.ColumnIPDSCP, "46/EF", "IPDSCP = 46"},
+		{schema.ColumnIPDSCP, "7", "IPDSCP = 7"},
+		{schema.ColumnIPECN, "3/CE", "IPECN = 3"},
+		{schema.ColumnDstASPath, "1299 12322", ""},
+		{schema.ColumnTCPFlags, "S", ""},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s=%s", tc.Input, tc.Value), func(t *testing.T) {
+			column := query.NewColumn(tc.Input.String())
+			if err := column.Validate(sch); err != nil {
+				t.Fatalf("Validate() error:\n%+v", err)
+			}
+			got, ok := column.ToFilterExpression(sch, tc.Value)
+			if ok != (tc.Expected != "") {
+				t.Fatalf("ToFilterExpression() == %v, expected %v", ok, !ok)
+			}
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("ToFilterExpression() (-got, +want):\n%s", diff)
+			}
+			if ok {
+				filter := query.NewFilter(got)
+				if err := filter.Validate(sch); err != nil {
+					t.Errorf("Validate(%q) error:\n%+v", got, err)
+				}
+			}
+		})
+	}
+}
+
 func TestReverseDirection(t *testing.T) {
 	columns := query.Columns{
 		query.NewColumn("SrcAS"),
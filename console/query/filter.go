@@ -5,6 +5,7 @@ package query
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"akvorado/common/schema"
@@ -17,6 +18,7 @@ type Filter struct {
 	filter            string
 	reverseFilter     string
 	mainTableRequired bool
+	columns           []schema.ColumnKey
 }
 
 // NewFilter creates a new filter. It should be validated with Validate() before use.
@@ -57,19 +59,25 @@ func (qf *Filter) Validate(sch *schema.Component) error {
 		return nil
 	}
 	input := []byte(qf.filter)
-	meta := &filter.Meta{Schema: sch}
-	direct, err := filter.Parse("", input, filter.GlobalStore("meta", meta))
+	directMeta := &filter.Meta{Schema: sch}
+	direct, err := filter.Parse("", input, filter.GlobalStore("meta", directMeta))
 	if err != nil {
 		return fmt.Errorf("cannot parse filter: %s", filter.HumanError(err))
 	}
-	meta = &filter.Meta{Schema: sch, ReverseDirection: true}
-	reverse, err := filter.Parse("", input, filter.GlobalStore("meta", meta))
+	reverseMeta := &filter.Meta{Schema: sch, ReverseDirection: true}
+	reverse, err := filter.Parse("", input, filter.GlobalStore("meta", reverseMeta))
 	if err != nil {
 		return fmt.Errorf("cannot parse reverse filter: %s", filter.HumanError(err))
 	}
 	qf.filter = direct.(string)
 	qf.reverseFilter = reverse.(string)
-	qf.mainTableRequired = meta.MainTableRequired
+	qf.mainTableRequired = reverseMeta.MainTableRequired
+	qf.columns = directMeta.Columns
+	for _, key := range reverseMeta.Columns {
+		if !slices.Contains(qf.columns, key) {
+			qf.columns = append(qf.columns, key)
+		}
+	}
 	qf.validated = true
 	return nil
 }
@@ -80,6 +88,12 @@ func (qf Filter) MainTableRequired() bool {
 	return qf.mainTableRequired
 }
 
+// Columns returns the columns used by this filter (in both directions).
+func (qf Filter) Columns() []schema.ColumnKey {
+	qf.check()
+	return qf.columns
+}
+
 // Reverse provides the reverse filter.
 func (qf Filter) Reverse() string {
 	qf.check()
@@ -5,6 +5,7 @@
 package console
 
 import (
+	"fmt"
 	"io/fs"
 	"net/http"
 	"os"
@@ -38,6 +39,11 @@ type Component struct {
 	flowsTables     []flowsTable
 	flowsTablesLock sync.RWMutex
 
+	operations     operationsState
+	trafficMetrics trafficMetricsState
+	widgetGraph    widgetGraphState
+	parityDB       *clickhousedb.Component // second destination, when enabled
+
 	metrics struct {
 		clickhouseQueries *reporter.CounterVec
 	}
@@ -66,7 +72,22 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 		r:           r,
 		d:           &dependencies,
 		config:      config,
-		flowsTables: []flowsTable{{"flows", 0, time.Time{}}},
+		flowsTables: []flowsTable{{"flows", 0, time.Time{}, nil}},
+		operations:  operationsState{previous: map[string]float64{}},
+	}
+
+	if err := c.initTrafficMetrics(); err != nil {
+		return nil, err
+	}
+	c.d.Auth.RegisterTokenValidator(c.validateAPIToken)
+	if config.Parity.Enabled {
+		parityDB, err := clickhousedb.New(r, config.Parity.ClickHouse.ReadOnly(), clickhousedb.Dependencies{
+			Daemon: dependencies.Daemon,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize parity ClickHouse component: %w", err)
+		}
+		c.parityDB = parityDB
 	}
 
 	c.d.Daemon.Track(&c.t, "console")
@@ -89,7 +110,7 @@ func (c *Component) Start() error {
 	c.d.HTTP.AddHandler("/assets/", http.StripPrefix("/assets/", http.HandlerFunc(c.staticAssetsHandlerFunc)))
 	c.d.HTTP.AddHandler("/assets/docs/", http.StripPrefix("/assets/docs/", http.HandlerFunc(c.docAssetsHandlerFunc)))
 	// Dynamic assets
-	endpoint := c.d.HTTP.GinRouter.Group("/api/v0/console", c.d.Auth.UserAuthentication())
+	endpoint := c.d.HTTP.GinRouter.Group("/api/v0/console", c.d.Auth.UserAuthentication(), c.guardrailsMiddleware())
 	endpoint.GET("/configuration", c.configHandlerFunc)
 	endpoint.GET("/docs/:name", c.docsHandlerFunc)
 	endpoint.GET("/widget/flow-last", c.d.HTTP.CacheByRequestPath(5*time.Second), c.widgetFlowLastHandlerFunc)
@@ -97,16 +118,34 @@ func (c *Component) Start() error {
 	endpoint.GET("/widget/exporters", c.d.HTTP.CacheByRequestPath(30*time.Second), c.widgetExportersHandlerFunc)
 	endpoint.GET("/widget/top/:name", c.d.HTTP.CacheByRequestPath(30*time.Second), c.widgetTopHandlerFunc)
 	endpoint.GET("/widget/graph", c.d.HTTP.CacheByRequestPath(5*time.Minute), c.widgetGraphHandlerFunc)
+	endpoint.GET("/widget/graph/stream", c.widgetGraphStreamHandlerFunc)
+	endpoint.POST("/widget/conversations", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.widgetConversationsHandlerFunc)
+	endpoint.POST("/widget/forecast", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.widgetForecastHandlerFunc)
+	endpoint.GET("/exporters", c.d.HTTP.CacheByRequestPath(30*time.Second), c.exportersHandlerFunc)
+	endpoint.GET("/exporters/crosscheck", c.d.HTTP.CacheByRequestPath(time.Minute), c.crossCheckHandlerFunc)
+	endpoint.GET("/operations", c.d.HTTP.CacheByRequestPath(10*time.Second), c.operationsHandlerFunc)
+	endpoint.POST("/parity", c.parityHandlerFunc)
 	endpoint.POST("/graph/line", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.graphLineHandlerFunc)
+	endpoint.POST("/graph/line/export", c.graphExportHandlerFunc)
 	endpoint.POST("/graph/sankey", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.graphSankeyHandlerFunc)
 	endpoint.POST("/graph/table-interval", c.getTableAndIntervalHandlerFunc)
 	endpoint.POST("/filter/validate", c.filterValidateHandlerFunc)
 	endpoint.POST("/filter/complete", c.d.HTTP.CacheByRequestBody(time.Minute), c.filterCompleteHandlerFunc)
+	endpoint.POST("/filter/drilldown", c.filterDrillDownHandlerFunc)
+	endpoint.POST("/filter/selection", c.filterSelectionHandlerFunc)
 	endpoint.GET("/filter/saved", c.filterSavedListHandlerFunc)
-	endpoint.DELETE("/filter/saved/:id", c.filterSavedDeleteHandlerFunc)
-	endpoint.POST("/filter/saved", c.filterSavedAddHandlerFunc)
+	endpoint.DELETE("/filter/saved/:id", c.d.Auth.RequireWrite(), c.filterSavedDeleteHandlerFunc)
+	endpoint.POST("/filter/saved", c.d.Auth.RequireWrite(), c.filterSavedAddHandlerFunc)
+	endpoint.PUT("/filter/saved/:id", c.d.Auth.RequireWrite(), c.filterSavedUpdateHandlerFunc)
+	endpoint.POST("/filter/saved/:id/use", c.d.Auth.RequireWrite(), c.filterSavedUseHandlerFunc)
+	endpoint.GET("/asns", c.asnsListHandlerFunc)
+	endpoint.PUT("/asns/:asn", c.d.Auth.RequireWrite(), c.asnsSetHandlerFunc)
+	endpoint.DELETE("/asns/:asn", c.d.Auth.RequireWrite(), c.asnsDeleteHandlerFunc)
 	endpoint.GET("/user/info", c.d.Auth.UserInfoHandlerFunc)
 	endpoint.GET("/user/avatar", c.d.Auth.UserAvatarHandlerFunc)
+	endpoint.GET("/user/tokens", c.d.Auth.RequireNoToken(), c.apiTokensListHandlerFunc)
+	endpoint.POST("/user/tokens", c.d.Auth.RequireNoToken(), c.apiTokensCreateHandlerFunc)
+	endpoint.DELETE("/user/tokens/:id", c.d.Auth.RequireNoToken(), c.apiTokensDeleteHandlerFunc)
 
 	c.t.Go(func() error {
 		ticker := time.NewTicker(10 * time.Second)
@@ -125,6 +164,9 @@ func (c *Component) Start() error {
 			}
 		}
 	})
+	if len(c.config.TrafficMetrics.Metrics) > 0 {
+		c.t.Go(c.runTrafficMetrics)
+	}
 	return nil
 }
 
@@ -133,7 +175,11 @@ func (c *Component) Stop() error {
 	defer c.r.Info().Msg("console component stopped")
 	c.r.Info().Msg("stopping console component")
 	c.t.Kill(nil)
-	return c.t.Wait()
+	err := c.t.Wait()
+	if c.parityDB != nil {
+		c.parityDB.Close()
+	}
+	return err
 }
 
 // embedOrLiveFS returns a subset of the provided embedded filesystem,
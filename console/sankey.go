@@ -76,6 +76,7 @@ ORDER BY xps DESC`,
 		Start:             input.Start,
 		End:               input.End,
 		MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
+		RequiredColumns:   requiredColumns(input.Dimensions, input.Filter),
 		Points:            20,
 		Units:             input.Units,
 	}
@@ -114,6 +115,11 @@ func (c *Component) graphSankeyHandlerFunc(gc *gin.Context) {
 		return
 	}
 
+	if err := c.checkGuardrails(queries); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
 	// Prepare and execute query
 	sqlQuery := c.finalizeTemplateQueries(queries)
 	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
@@ -122,8 +128,7 @@ func (c *Component) graphSankeyHandlerFunc(gc *gin.Context) {
 		Dimensions []string `ch:"dimensions"`
 	}{}
 	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
-		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, sqlQuery)
 		return
 	}
 
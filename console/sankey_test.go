@@ -41,10 +41,11 @@ func TestSankeyQuerySQL(t *testing.T) {
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 20,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          20,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnSrcAS, schema.ColumnExporterName},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -80,10 +81,11 @@ ORDER BY xps DESC`,
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 20,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          20,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnSrcAS, schema.ColumnExporterName},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -118,10 +120,11 @@ ORDER BY xps DESC`,
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 20,
-						Units:  "l2bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          20,
+						Units:           "l2bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnSrcAS, schema.ColumnExporterName},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -156,10 +159,11 @@ ORDER BY xps DESC`,
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 20,
-						Units:  "pps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          20,
+						Units:           "pps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnSrcAS, schema.ColumnExporterName},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -194,10 +198,11 @@ ORDER BY xps DESC`,
 			Expected: []templateQuery{
 				{
 					Context: inputContext{
-						Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
-						End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
-						Points: 20,
-						Units:  "l3bps",
+						Start:           time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+						End:             time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+						Points:          20,
+						Units:           "l3bps",
+						RequiredColumns: []schema.ColumnKey{schema.ColumnDstCountry, schema.ColumnSrcCountry, schema.ColumnSrcAS, schema.ColumnExporterName},
 					},
 					Template: `WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	stdcontext "context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+	"akvorado/console/database"
+)
+
+// apiTokenPrefix is the prefix of all API tokens. It helps to spot them, for
+// example in secret scanners.
+const apiTokenPrefix = "akvorado_"
+
+// hashAPIToken returns the hash of an API token, as stored in database.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateAPIToken checks an API token against the database.
+func (c *Component) validateAPIToken(ctx stdcontext.Context, token string) (authentication.UserInformation, authentication.TokenInformation, error) {
+	t, err := c.d.Database.LookupAPIToken(ctx, hashAPIToken(token))
+	if errors.Is(err, database.ErrAPITokenNotFound) {
+		return authentication.UserInformation{}, authentication.TokenInformation{}, authentication.ErrInvalidToken
+	} else if err != nil {
+		return authentication.UserInformation{}, authentication.TokenInformation{}, err
+	}
+	if t.Expires != nil && !c.d.Clock.Now().Before(*t.Expires) {
+		return authentication.UserInformation{}, authentication.TokenInformation{}, authentication.ErrInvalidToken
+	}
+	return authentication.UserInformation{Login: t.User},
+		authentication.TokenInformation{
+			ReadOnly:  t.Scope == "read-only",
+			Endpoints: t.Endpoints,
+		}, nil
+}
+
+func (c *Component) apiTokensListHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	tokens, err := c.d.Database.ListAPITokens(ctx, user)
+	if err != nil {
+		c.r.Err(err).Msg("unable to list API tokens")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to list API tokens."})
+		return
+	}
+	gc.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (c *Component) apiTokensCreateHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	var t database.APIToken
+	if err := gc.ShouldBindJSON(&t); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	now := c.d.Clock.Now()
+	if t.Expires != nil && !t.Expires.After(now) {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Expiration date is in the past."})
+		return
+	}
+
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	t.User = user
+	t.Hash = hashAPIToken(token)
+	t.Prefix = token[:len(apiTokenPrefix)+6]
+	t.Created = now
+	if err := c.d.Database.CreateAPIToken(ctx, &t); err != nil {
+		c.r.Err(err).Msg("cannot create API token")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot create API token."})
+		return
+	}
+	// The token is only displayed once.
+	gc.JSON(http.StatusOK, gin.H{"id": t.ID, "token": token})
+}
+
+func (c *Component) apiTokensDeleteHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	id, err := strconv.ParseUint(gc.Param("id"), 10, 64)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Bad ID format."})
+		return
+	}
+	if err := c.d.Database.DeleteAPIToken(ctx, id, user); errors.Is(err, database.ErrAPITokenNotFound) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "API token not found."})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("cannot delete API token")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot delete API token."})
+		return
+	}
+	gc.JSON(http.StatusNoContent, nil)
+}
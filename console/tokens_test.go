@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+)
+
+func TestAPITokens(t *testing.T) {
+	_, h, _, mockClock := NewMock(t, DefaultConfiguration())
+	now := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+	mockClock.Set(now)
+
+	// Create a few tokens
+	create := func(input gin.H) string {
+		t.Helper()
+		body, _ := json.Marshal(input)
+		req, _ := http.NewRequest("POST",
+			fmt.Sprintf("http://%s/api/v0/console/user/tokens", h.LocalAddr()),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Remote-User", "alfred")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/v0/console/user/tokens:\n%+v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("POST /api/v0/console/user/tokens: got status code %d", resp.StatusCode)
+		}
+		var result struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("POST /api/v0/console/user/tokens:\n%+v", err)
+		}
+		if !strings.HasPrefix(result.Token, "akvorado_") {
+			t.Fatalf("POST /api/v0/console/user/tokens: got token %q", result.Token)
+		}
+		return result.Token
+	}
+	readWrite := create(gin.H{"description": "automation", "scope": "read-write"})
+	readOnly := create(gin.H{
+		"description": "wallboard",
+		"scope":       "read-only",
+		"expires":     now.Add(time.Hour),
+	})
+	scoped := create(gin.H{
+		"description": "AS names",
+		"scope":       "read-only",
+		"endpoints":   []string{"/api/v0/console/asns"},
+	})
+	bearer := func(token string) http.Header {
+		headers := make(http.Header)
+		headers.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		return headers
+	}
+	alfred := http.Header{"Remote-User": []string{"alfred"}}
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "list tokens",
+			URL:         "/api/v0/console/user/tokens",
+			Header:      alfred,
+			JSONOutput: gin.H{"tokens": []gin.H{
+				{
+					"id":          1,
+					"user":        "alfred",
+					"description": "automation",
+					"prefix":      readWrite[:15],
+					"scope":       "read-write",
+					"created":     "2026-10-16T10:00:00Z",
+				}, {
+					"id":          2,
+					"user":        "alfred",
+					"description": "wallboard",
+					"prefix":      readOnly[:15],
+					"scope":       "read-only",
+					"created":     "2026-10-16T10:00:00Z",
+					"expires":     "2026-10-16T11:00:00Z",
+				}, {
+					"id":          3,
+					"user":        "alfred",
+					"description": "AS names",
+					"prefix":      scoped[:15],
+					"scope":       "read-only",
+					"endpoints":   []string{"/api/v0/console/asns"},
+					"created":     "2026-10-16T10:00:00Z",
+				},
+			}},
+		}, {
+			Description: "list tokens of another user",
+			URL:         "/api/v0/console/user/tokens",
+			JSONOutput:  gin.H{"tokens": []gin.H{}},
+		}, {
+			Description: "create token with bad scope",
+			URL:         "/api/v0/console/user/tokens",
+			JSONInput:   gin.H{"description": "bad", "scope": "admin"},
+			StatusCode:  400,
+			JSONOutput: gin.H{
+				"message": "Key: 'APIToken.Scope' Error:Field validation for 'Scope' failed on the 'oneof' tag",
+			},
+		}, {
+			Description: "create expired token",
+			URL:         "/api/v0/console/user/tokens",
+			JSONInput:   gin.H{"description": "bad", "scope": "read-only", "expires": now},
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": "Expiration date is in the past."},
+		}, {
+			Description: "user info with token",
+			URL:         "/api/v0/console/user/info",
+			Header:      bearer(readWrite),
+			JSONOutput:  gin.H{"login": "alfred"},
+		}, {
+			Description: "user info with invalid token",
+			URL:         "/api/v0/console/user/info",
+			Header:      bearer("akvorado_nothing"),
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid API token."},
+		}, {
+			Description: "write with read-write token",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64512",
+			Header:      bearer(readWrite),
+			JSONInput:   gin.H{"name": "Lab"},
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		}, {
+			Description: "write with read-only token",
+			Method:      "PUT",
+			URL:         "/api/v0/console/asns/64512",
+			Header:      bearer(readOnly),
+			JSONInput:   gin.H{"name": "Lab"},
+			StatusCode:  403,
+			JSONOutput:  gin.H{"message": "API token is read-only."},
+		}, {
+			Description: "read with scoped token",
+			URL:         "/api/v0/console/asns",
+			Header:      bearer(scoped),
+			JSONOutput:  gin.H{"asns": []gin.H{{"asn": 64512, "name": "Lab", "user": "alfred"}}},
+		}, {
+			Description: "read outside scope",
+			URL:         "/api/v0/console/user/info",
+			Header:      bearer(scoped),
+			StatusCode:  403,
+			JSONOutput:  gin.H{"message": "API token not allowed for this endpoint."},
+		}, {
+			Description: "manage tokens with a token",
+			URL:         "/api/v0/console/user/tokens",
+			Header:      bearer(readWrite),
+			StatusCode:  403,
+			JSONOutput:  gin.H{"message": "Not allowed with an API token."},
+		}, {
+			Description: "revoke token of another user",
+			Method:      "DELETE",
+			URL:         "/api/v0/console/user/tokens/1",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "API token not found."},
+		}, {
+			Description: "revoke token",
+			Method:      "DELETE",
+			URL:         "/api/v0/console/user/tokens/1",
+			Header:      alfred,
+			StatusCode:  204,
+			ContentType: "application/json; charset=utf-8",
+		}, {
+			Description: "use revoked token",
+			URL:         "/api/v0/console/user/info",
+			Header:      bearer(readWrite),
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid API token."},
+		},
+	})
+
+	// Expired token
+	mockClock.Add(time.Hour)
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "use expired token",
+			URL:         "/api/v0/console/user/info",
+			Header:      bearer(readOnly),
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid API token."},
+		},
+	})
+}
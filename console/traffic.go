@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/console/query"
+)
+
+// trafficMetricDefaultLimit is the number of series exported for a traffic
+// metric when no limit is configured.
+const trafficMetricDefaultLimit = 10
+
+// trafficMetricsState contains the state of the traffic metrics.
+type trafficMetricsState struct {
+	bps            *reporter.GaugeVec
+	pps            *reporter.GaugeVec
+	baselineBps    *reporter.GaugeVec
+	baselinePps    *reporter.GaugeVec
+	errors         *reporter.CounterVec
+	lastEvaluation *reporter.GaugeVec
+
+	// exported are the keys currently exported for each metric and period
+	exported map[string]map[string]bool
+}
+
+// trafficMetricRow is a row returned when evaluating a traffic metric.
+type trafficMetricRow struct {
+	Dimensions []string `ch:"dimensions"`
+	Bps        float64  `ch:"bps"`
+	Pps        float64  `ch:"pps"`
+}
+
+// initTrafficMetrics validates the configuration of the traffic metrics and
+// registers the associated Prometheus metrics.
+func (c *Component) initTrafficMetrics() error {
+	// Filters are modified during validation
+	metrics := make([]TrafficMetricConfiguration, len(c.config.TrafficMetrics.Metrics))
+	copy(metrics, c.config.TrafficMetrics.Metrics)
+	for idx := range metrics {
+		if err := metrics[idx].Filter.Validate(c.d.Schema); err != nil {
+			return fmt.Errorf("traffic metric %q: %w", metrics[idx].Name, err)
+		}
+		if err := query.Columns(metrics[idx].Dimensions).Validate(c.d.Schema); err != nil {
+			return fmt.Errorf("traffic metric %q: %w", metrics[idx].Name, err)
+		}
+		if metrics[idx].Limit == 0 {
+			metrics[idx].Limit = trafficMetricDefaultLimit
+		}
+	}
+	c.config.TrafficMetrics.Metrics = metrics
+
+	c.trafficMetrics.exported = map[string]map[string]bool{}
+	c.trafficMetrics.bps = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "traffic_bps",
+			Help: "Traffic in bits per second for a traffic metric.",
+		}, []string{"name", "key"})
+	c.trafficMetrics.pps = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "traffic_pps",
+			Help: "Traffic in packets per second for a traffic metric.",
+		}, []string{"name", "key"})
+	c.trafficMetrics.baselineBps = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "traffic_baseline_bps",
+			Help: "Baseline traffic in bits per second for a traffic metric.",
+		}, []string{"name", "key"})
+	c.trafficMetrics.baselinePps = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "traffic_baseline_pps",
+			Help: "Baseline traffic in packets per second for a traffic metric.",
+		}, []string{"name", "key"})
+	c.trafficMetrics.errors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "traffic_evaluation_errors_total",
+			Help: "Number of errors while evaluating a traffic metric.",
+		}, []string{"name"})
+	c.trafficMetrics.lastEvaluation = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "traffic_last_evaluation_seconds",
+			Help: "Timestamp of the last successful evaluation of a traffic metric.",
+		}, []string{"name"})
+	return nil
+}
+
+// trafficMetricSQL builds the SQL query to evaluate a traffic metric over the
+// provided time range.
+func (c *Component) trafficMetricSQL(metric TrafficMetricConfiguration, start, end time.Time) string {
+	selectors := make([]string, len(metric.Dimensions))
+	for idx, column := range metric.Dimensions {
+		selectors[idx] = column.ToSQLSelect(c.d.Schema)
+	}
+	seconds := uint64(end.Sub(start).Seconds())
+	template := fmt.Sprintf(`
+SELECT
+ [%s] AS dimensions,
+ SUM(Bytes*SamplingRate*8)/%d AS bps,
+ SUM(Packets*SamplingRate)/%d AS pps
+FROM {{ .Table }}
+WHERE %s
+GROUP BY dimensions
+ORDER BY bps DESC
+LIMIT %d`, strings.Join(selectors, ", "), seconds, seconds,
+		templateWhere(metric.Filter), metric.Limit)
+	return c.finalizeTemplateQuery(templateQuery{
+		Template: strings.TrimSpace(template),
+		Context: inputContext{
+			Start:             start,
+			End:               end,
+			MainTableRequired: metric.Filter.MainTableRequired(),
+			RequiredColumns:   requiredColumns(metric.Dimensions, metric.Filter),
+			Points:            1,
+		},
+	})
+}
+
+// evaluateTrafficMetrics evaluates all the traffic metrics and updates the
+// associated Prometheus metrics.
+func (c *Component) evaluateTrafficMetrics() {
+	ctx := c.t.Context(nil)
+	config := c.config.TrafficMetrics
+	end := c.d.Clock.Now().Truncate(time.Minute)
+	start := end.Add(-config.Window)
+	for _, metric := range config.Metrics {
+		type period struct {
+			name       string
+			start, end time.Time
+			bps, pps   *reporter.GaugeVec
+		}
+		periods := []period{{"current", start, end, c.trafficMetrics.bps, c.trafficMetrics.pps}}
+		if config.BaselineOffset > 0 {
+			periods = append(periods, period{"baseline",
+				start.Add(-config.BaselineOffset), end.Add(-config.BaselineOffset),
+				c.trafficMetrics.baselineBps, c.trafficMetrics.baselinePps})
+		}
+		failed := false
+		for _, p := range periods {
+			sqlQuery := c.trafficMetricSQL(metric, p.start, p.end)
+			results := []trafficMetricRow{}
+			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+				c.r.Err(err).Str("name", metric.Name).Str("query", sqlQuery).
+					Msg("cannot evaluate traffic metric")
+				c.trafficMetrics.errors.WithLabelValues(metric.Name).Inc()
+				failed = true
+				continue
+			}
+			exportedKey := fmt.Sprintf("%s %s", metric.Name, p.name)
+			previous := c.trafficMetrics.exported[exportedKey]
+			current := make(map[string]bool, len(results))
+			for _, result := range results {
+				key := strings.Join(result.Dimensions, ", ")
+				p.bps.WithLabelValues(metric.Name, key).Set(result.Bps)
+				p.pps.WithLabelValues(metric.Name, key).Set(result.Pps)
+				current[key] = true
+			}
+			for key := range previous {
+				if !current[key] {
+					p.bps.DeleteLabelValues(metric.Name, key)
+					p.pps.DeleteLabelValues(metric.Name, key)
+				}
+			}
+			c.trafficMetrics.exported[exportedKey] = current
+		}
+		if !failed {
+			c.trafficMetrics.lastEvaluation.WithLabelValues(metric.Name).Set(float64(end.Unix()))
+		}
+	}
+}
+
+// runTrafficMetrics periodically evaluates the traffic metrics until the
+// component is stopped.
+func (c *Component) runTrafficMetrics() error {
+	ticker := c.d.Clock.Ticker(c.config.TrafficMetrics.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.evaluateTrafficMetrics()
+		case <-c.t.Dying():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+	"akvorado/console/query"
+)
+
+func TestTrafficMetrics(t *testing.T) {
+	config := DefaultConfiguration()
+	config.TrafficMetrics.Metrics = []TrafficMetricConfiguration{
+		{
+			Name:       "peers",
+			Filter:     query.NewFilter("InIfBoundary = external"),
+			Dimensions: []query.Column{query.NewColumn("SrcAS"), query.NewColumn("SrcCountry")},
+			Limit:      2,
+		},
+	}
+	c, _, mockConn, mockClock := NewMock(t, config)
+	mockClock.Set(time.Date(2026, 10, 16, 12, 0, 30, 0, time.UTC))
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `SELECT
+ [concat(toString(SrcAS), ': ', dictGetOrDefault('asns', 'name', SrcAS, '???')), SrcCountry] AS dimensions,
+ SUM(Bytes*SamplingRate*8)/300 AS bps,
+ SUM(Packets*SamplingRate)/300 AS pps
+FROM flows
+WHERE TimeReceived BETWEEN toDateTime('2026-10-16 11:55:00', 'UTC') AND toDateTime('2026-10-16 12:00:00', 'UTC') AND (InIfBoundary = 'external')
+GROUP BY dimensions
+ORDER BY bps DESC
+LIMIT 2`).
+		SetArg(1, []trafficMetricRow{
+			{[]string{"64476: Example", "FR"}, 1_000_000, 100},
+			{[]string{"64477: Other", "US"}, 500_000, 50},
+		}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, []trafficMetricRow{
+			{[]string{"64476: Example", "FR"}, 800_000, 80},
+		}).
+		Return(nil)
+	c.evaluateTrafficMetrics()
+
+	gotMetrics := c.r.GetMetrics("akvorado_console_traffic_")
+	expectedMetrics := map[string]string{
+		`bps{key="64476: Example, FR",name="peers"}`:          "1e+06",
+		`bps{key="64477: Other, US",name="peers"}`:            "500000",
+		`pps{key="64476: Example, FR",name="peers"}`:          "100",
+		`pps{key="64477: Other, US",name="peers"}`:            "50",
+		`baseline_bps{key="64476: Example, FR",name="peers"}`: "800000",
+		`baseline_pps{key="64476: Example, FR",name="peers"}`: "80",
+		`last_evaluation_seconds{name="peers"}`:               "1.792152e+09",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Series not present anymore are removed
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, []trafficMetricRow{
+			{[]string{"64477: Other", "US"}, 600_000, 60},
+		}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("unavailable"))
+	c.evaluateTrafficMetrics()
+
+	gotMetrics = c.r.GetMetrics("akvorado_console_traffic_")
+	expectedMetrics = map[string]string{
+		`bps{key="64477: Other, US",name="peers"}`:            "600000",
+		`pps{key="64477: Other, US",name="peers"}`:            "60",
+		`baseline_bps{key="64476: Example, FR",name="peers"}`: "800000",
+		`baseline_pps{key="64476: Example, FR",name="peers"}`: "80",
+		`evaluation_errors_total{name="peers"}`:               "1",
+		`last_evaluation_seconds{name="peers"}`:               "1.792152e+09",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -4,10 +4,13 @@
 package console
 
 import (
+	stdcontext "context"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -50,8 +53,7 @@ LIMIT 1`, strings.Join(selectClause, ",\n "))
 	// Do not increase counter for this one.
 	rows, err := c.d.ClickHouseDB.Conn.Query(ctx, query)
 	if err != nil {
-		c.r.Err(err).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, query)
 		return
 	}
 
@@ -88,8 +90,7 @@ func (c *Component) widgetFlowRateHandlerFunc(gc *gin.Context) {
 	var result float64
 	row := c.d.ClickHouseDB.Conn.QueryRow(ctx, query)
 	if err := row.Scan(&result); err != nil {
-		c.r.Err(err).Msg("unable to parse result")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to parse result."})
+		c.queryError(gc, err, query)
 		return
 	}
 	gc.IndentedJSON(http.StatusOK, gin.H{
@@ -109,8 +110,7 @@ func (c *Component) widgetExportersHandlerFunc(gc *gin.Context) {
 	}{}
 	err := c.d.ClickHouseDB.Conn.Select(ctx, &exporters, query)
 	if err != nil {
-		c.r.Err(err).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, query)
 		return
 	}
 	exporterList := make([]string, len(exporters))
@@ -140,6 +140,7 @@ func (c *Component) widgetTopHandlerFunc(gc *gin.Context) {
 		groupby           string
 		filter            string
 		mainTableRequired bool
+		columns           []schema.ColumnKey
 	)
 
 	type URIParams struct {
@@ -155,21 +156,28 @@ func (c *Component) widgetTopHandlerFunc(gc *gin.Context) {
 	case HomepageTopWidgetSrcAS:
 		selector = fmt.Sprintf(`concat(toString(SrcAS), ': ', dictGetOrDefault('%s', 'name', SrcAS, '???'))`, schema.DictionaryASNs)
 		groupby = `SrcAS`
+		columns = []schema.ColumnKey{schema.ColumnSrcAS}
 	case HomepageTopWidgetDstAS:
 		selector = fmt.Sprintf(`concat(toString(DstAS), ': ', dictGetOrDefault('%s', 'name', DstAS, '???'))`, schema.DictionaryASNs)
 		groupby = `DstAS`
+		columns = []schema.ColumnKey{schema.ColumnDstAS}
 	case HomepageTopWidgetSrcCountry:
 		selector = `SrcCountry`
+		columns = []schema.ColumnKey{schema.ColumnSrcCountry}
 	case HomepageTopWidgetDstCountry:
 		selector = `DstCountry`
+		columns = []schema.ColumnKey{schema.ColumnDstCountry}
 	case HomepageTopWidgetExporter:
 		selector = "ExporterName"
+		columns = []schema.ColumnKey{schema.ColumnExporterName}
 	case HomepageTopWidgetProtocol:
 		selector = fmt.Sprintf(`dictGetOrDefault('%s', 'name', Proto, '???')`, schema.DictionaryProtocols)
 		groupby = `Proto`
+		columns = []schema.ColumnKey{schema.ColumnProto}
 	case HomepageTopWidgetEtype:
 		selector = `if(equals(EType, 34525), 'IPv6', if(equals(EType, 2048), 'IPv4', '???'))`
 		groupby = `EType`
+		columns = []schema.ColumnKey{schema.ColumnEType}
 	case HomepageTopWidgetSrcPort:
 		selector = fmt.Sprintf(`concat(dictGetOrDefault('%s', 'name', Proto, '???'), '/', toString(SrcPort))`, schema.DictionaryProtocols)
 		groupby = `Proto, SrcPort`
@@ -184,8 +192,10 @@ func (c *Component) widgetTopHandlerFunc(gc *gin.Context) {
 	}
 	if strings.HasPrefix(gc.Param("name"), "src-") {
 		filter = "AND InIfBoundary = 'external'"
+		columns = append(columns, schema.ColumnInIfBoundary)
 	} else if strings.HasPrefix(gc.Param("name"), "dst-") {
 		filter = "AND OutIfBoundary = 'external'"
+		columns = append(columns, schema.ColumnOutIfBoundary)
 	}
 	if groupby == "" {
 		groupby = selector
@@ -212,6 +222,7 @@ LIMIT 5`,
 			Start:             now.Add(-5 * time.Minute),
 			End:               now,
 			MainTableRequired: mainTableRequired,
+			RequiredColumns:   columns,
 			Points:            5,
 		},
 	})
@@ -220,13 +231,22 @@ LIMIT 5`,
 	results := []topResult{}
 	err := c.d.ClickHouseDB.Conn.Select(ctx, &results, strings.TrimSpace(query))
 	if err != nil {
-		c.r.Err(err).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, query)
 		return
 	}
 	gc.JSON(http.StatusOK, gin.H{"top": results})
 }
 
+// widgetGraphContext returns the input context for the homepage graph.
+func (c *Component) widgetGraphContext(now time.Time) inputContext {
+	return inputContext{
+		Start:             now.Add(-c.config.HomepageGraphTimeRange),
+		End:               now,
+		MainTableRequired: false,
+		Points:            200,
+	}
+}
+
 func (c *Component) widgetGraphHandlerFunc(gc *gin.Context) {
 	filter := c.config.HomepageGraphFilter
 	if filter != "" {
@@ -250,12 +270,7 @@ ORDER BY Time WITH FILL
 
 	query := c.finalizeTemplateQuery(templateQuery{
 		Template: template,
-		Context: inputContext{
-			Start:             now.Add(-c.config.HomepageGraphTimeRange),
-			End:               now,
-			MainTableRequired: false,
-			Points:            200,
-		},
+		Context:  c.widgetGraphContext(now),
 	})
 	gc.Header("X-SQL-Query", query)
 
@@ -265,10 +280,96 @@ ORDER BY Time WITH FILL
 	}{}
 	err := c.d.ClickHouseDB.Conn.Select(ctx, &results, strings.TrimSpace(query))
 	if err != nil {
-		c.r.Err(err).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		c.queryError(gc, err, query)
 		return
 	}
 
 	gc.JSON(http.StatusOK, gin.H{"data": results})
 }
+
+// widgetGraphPoint is a point of the homepage graph.
+type widgetGraphPoint struct {
+	Time time.Time `json:"t"`
+	Gbps float64   `json:"gbps"`
+}
+
+// widgetGraphState keeps the latest point of the homepage graph, shared by
+// all the streams.
+type widgetGraphState struct {
+	lock    sync.Mutex
+	updated time.Time
+	points  []widgetGraphPoint
+}
+
+// widgetGraphLatestPoints returns the latest complete point of the homepage
+// graph. It uses the same table and interval as the complete graph. The result
+// is reused until the next refresh to not run a query for each client.
+func (c *Component) widgetGraphLatestPoints(ctx stdcontext.Context) ([]widgetGraphPoint, error) {
+	c.widgetGraph.lock.Lock()
+	defer c.widgetGraph.lock.Unlock()
+	now := c.d.Clock.Now()
+	if !c.widgetGraph.updated.IsZero() && now.Sub(c.widgetGraph.updated) < c.config.HomepageGraphRefresh {
+		return c.widgetGraph.points, nil
+	}
+
+	filter := c.config.HomepageGraphFilter
+	if filter != "" {
+		filter = fmt.Sprintf("AND %s", filter)
+	}
+	template := fmt.Sprintf(`
+SELECT
+ {{ call .ToStartOfInterval "TimeReceived" }} AS Time,
+ SUM(Bytes*SamplingRate*8/{{ .Interval }})/1000/1000/1000 AS Gbps
+FROM {{ .Table }}
+WHERE TimeReceived >= {{ .TimefilterEnd }} - INTERVAL {{ .Interval }} second
+AND TimeReceived < {{ .TimefilterEnd }}
+%s
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM {{ .TimefilterEnd }} - INTERVAL {{ .Interval }} second
+ TO {{ .TimefilterEnd }}
+ STEP {{ .Interval }}`,
+		filter)
+	query := c.finalizeTemplateQuery(templateQuery{
+		Template: template,
+		Context:  c.widgetGraphContext(now),
+	})
+
+	results := []widgetGraphPoint{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, strings.TrimSpace(query)); err != nil {
+		return nil, err
+	}
+	c.widgetGraph.updated = now
+	c.widgetGraph.points = results
+	return results, nil
+}
+
+// widgetGraphStreamHandlerFunc streams the latest point of the homepage graph
+// using server-sent events. The client fetches the complete graph first and
+// merges each update.
+func (c *Component) widgetGraphStreamHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	ticker := c.d.Clock.Ticker(c.config.HomepageGraphRefresh)
+	defer ticker.Stop()
+	gc.Header("Cache-Control", "no-cache")
+	gc.Header("X-Accel-Buffering", "no")
+	gc.Stream(func(io.Writer) bool {
+		points, err := c.widgetGraphLatestPoints(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			c.r.Err(err).Msg("unable to query database for homepage graph")
+			gc.SSEvent("error", gin.H{"message": "Unable to query database."})
+		} else {
+			gc.SSEvent("update", gin.H{"data": points})
+		}
+		gc.Writer.Flush()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
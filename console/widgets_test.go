@@ -4,7 +4,11 @@
 package console
 
 import (
+	"bufio"
+	stdcontext "context"
+	"fmt"
 	"net"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
@@ -345,3 +349,70 @@ ORDER BY Time WITH FILL
 		})
 	}
 }
+
+func TestWidgetGraphStream(t *testing.T) {
+	c, h, mockConn, mockClock := NewMock(t, DefaultConfiguration())
+	base := time.Date(2009, time.November, 11, 22, 52, 48, 0, time.UTC)
+	mockClock.Set(time.Date(2009, time.November, 11, 23, 0, 0, 0, time.UTC))
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), strings.TrimSpace(`
+SELECT
+ toStartOfInterval(TimeReceived + INTERVAL 144 second, INTERVAL 432 second) - INTERVAL 144 second AS Time,
+ SUM(Bytes*SamplingRate*8/432)/1000/1000/1000 AS Gbps
+FROM flows
+WHERE TimeReceived >= toDateTime('2009-11-11 23:00:00', 'UTC') - INTERVAL 432 second
+AND TimeReceived < toDateTime('2009-11-11 23:00:00', 'UTC')
+AND InIfBoundary = 'external'
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM toDateTime('2009-11-11 23:00:00', 'UTC') - INTERVAL 432 second
+ TO toDateTime('2009-11-11 23:00:00', 'UTC')
+ STEP 432`)).
+		SetArg(1, []widgetGraphPoint{{base, 25.3}}).
+		Return(nil)
+
+	// The result is shared until the next refresh
+	for range 2 {
+		got, err := c.widgetGraphLatestPoints(t.Context())
+		if err != nil {
+			t.Fatalf("widgetGraphLatestPoints() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, []widgetGraphPoint{{base, 25.3}}); diff != "" {
+			t.Fatalf("widgetGraphLatestPoints() (-got, +want):\n%s", diff)
+		}
+	}
+
+	// Stream the first event
+	ctx, cancel := stdcontext.WithCancel(t.Context())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("http://%s/api/v0/console/widget/graph/stream", h.LocalAddr()), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v0/console/widget/graph/stream:\n%+v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+		t.Fatalf("GET /api/v0/console/widget/graph/stream content type: got %q", got)
+	}
+	reader := bufio.NewReader(resp.Body)
+	got := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error:\n%+v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		got = append(got, line)
+	}
+	expected := []string{
+		"event:update",
+		`data:{"data":[{"t":"2009-11-11T22:52:48Z","gbps":25.3}]}`,
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("GET /api/v0/console/widget/graph/stream (-got, +want):\n%s", diff)
+	}
+}
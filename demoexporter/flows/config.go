@@ -20,6 +20,9 @@ type Configuration struct {
 	// one, all exporters will produce the same data if provided
 	// the same flows.
 	Seed int64
+	// Scenarios describe events altering the generated flows at
+	// regular intervals.
+	Scenarios []ScenarioConfiguration `validate:"dive"`
 }
 
 // FlowConfiguration describes the configuration for a flow.
@@ -34,6 +37,10 @@ type FlowConfiguration struct {
 	PeakHour time.Duration `validate:"required,min=0,max=24h"`
 	// PeakMultiplier defines how to multiply the `PerSecond` when near the peak hour
 	Multiplier float64 `validate:"required,gt=0"`
+	// Profile defines how the rate evolves during the day: "peak" (the
+	// default) for a sharp increase near the peak hour, "sine" for a
+	// daily sine wave, "flat" for a constant rate.
+	Profile string `validate:"omitempty,oneof=peak sine flat"`
 	// SrcNet defines the source network to use
 	SrcNet netip.Prefix `validate:"required"`
 	// DstNet defines the destination network to use
@@ -56,6 +63,48 @@ type FlowConfiguration struct {
 	ReverseDirectionRatio float32 `validate:"min=0"`
 }
 
+// ScenarioConfiguration describes a scenario happening at regular intervals.
+type ScenarioConfiguration struct {
+	// Type is the type of scenario: "ddos" generates additional flows
+	// toward a target, "link-failure" stops using some interfaces.
+	Type string `validate:"required,oneof=ddos link-failure"`
+	// Every defines how often the scenario happens.
+	Every time.Duration `validate:"min=1m"`
+	// Start defines when the scenario starts in each period.
+	Start time.Duration `validate:"min=0,ltfield=Every"`
+	// Duration defines how long the scenario lasts.
+	Duration time.Duration `validate:"min=1s,ltefield=Every"`
+
+	// PerSecond defines how many attack flows are created per second
+	// during a DDoS.
+	PerSecond float64 `validate:"required_if=Type ddos,omitempty,gt=0"`
+	// InIfIndex defines the source interfaces of the attack
+	InIfIndex []int `validate:"required_if=Type ddos,dive,min=1"`
+	// OutIfIndex defines the output interfaces of the attack
+	OutIfIndex []int `validate:"required_if=Type ddos,dive,min=1"`
+	// SrcNet defines the network of the attackers
+	SrcNet netip.Prefix `validate:"required_if=Type ddos"`
+	// DstNet defines the network of the victims
+	DstNet netip.Prefix `validate:"required_if=Type ddos"`
+	// SrcAS defines the AS numbers of the attackers
+	SrcAS []uint32
+	// DstAS defines the AS numbers of the victims
+	DstAS []uint32
+	// SrcPort defines the source ports of the attack
+	SrcPort []uint16
+	// DstPort defines the destination ports of the attack
+	DstPort []uint16
+	// Protocol defines the IP protocols of the attack (UDP by default)
+	Protocol []string `validate:"dive,oneof=tcp udp icmp"`
+	// Size defines the packet size of the attack
+	Size uint `validate:"isdefault|min=64,isdefault|max=9000"`
+
+	// IfIndex defines the interfaces going down during a link failure.
+	// Flows are moved to the remaining interfaces of each flow
+	// configuration, or dropped if there is none.
+	IfIndex []int `validate:"required_if=Type link-failure,dive,min=1"`
+}
+
 // DefaultConfiguration represents the default configuration for the flows component.
 func DefaultConfiguration() Configuration {
 	return Configuration{
@@ -35,3 +35,78 @@ func TestDefaultConfiguration(t *testing.T) {
 		t.Fatalf("validate.Struct() error:\n%+v", err)
 	}
 }
+
+func TestScenarioConfiguration(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Scenario ScenarioConfiguration
+		Error    bool
+	}{
+		{
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:       "ddos",
+				Every:      6 * time.Hour,
+				Start:      time.Hour,
+				Duration:   10 * time.Minute,
+				PerSecond:  100,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				SrcNet:     netip.MustParsePrefix("0.0.0.0/0"),
+				DstNet:     netip.MustParsePrefix("192.0.2.10/32"),
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:       "ddos",
+				Every:      6 * time.Hour,
+				Duration:   10 * time.Minute,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				DstNet:     netip.MustParsePrefix("192.0.2.10/32"),
+			},
+			Error: true,
+		}, {
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:     "link-failure",
+				Every:    time.Hour,
+				Duration: 10 * time.Minute,
+				IfIndex:  []int{10},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:     "link-failure",
+				Every:    time.Hour,
+				Duration: 10 * time.Minute,
+			},
+			Error: true,
+		}, {
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:     "link-failure",
+				Every:    time.Hour,
+				Duration: 2 * time.Hour,
+				IfIndex:  []int{10},
+			},
+			Error: true,
+		}, {
+			Pos: helpers.Mark(),
+			Scenario: ScenarioConfiguration{
+				Type:     "earthquake",
+				Every:    time.Hour,
+				Duration: 10 * time.Minute,
+			},
+			Error: true,
+		},
+	}
+	for _, tc := range cases {
+		err := helpers.Validate.Struct(tc.Scenario)
+		if err == nil && tc.Error {
+			t.Errorf("%sValidate.Struct() did not error", tc.Pos)
+		} else if err != nil && !tc.Error {
+			t.Errorf("%sValidate.Struct() error:\n%+v", tc.Pos, err)
+		}
+	}
+}
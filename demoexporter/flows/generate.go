@@ -10,6 +10,7 @@ import (
 	"math/bits"
 	"math/rand/v2"
 	"net/netip"
+	"slices"
 	"time"
 
 	"akvorado/common/helpers"
@@ -57,6 +58,81 @@ func peakHourDistance(now, peak time.Duration) float64 {
 	return (12 - delta) / 12
 }
 
+// rateMultiplier returns the multiplier to apply to the rate of the flow
+// configuration for the provided time of the day.
+func (fc FlowConfiguration) rateMultiplier(now time.Duration) float64 {
+	switch fc.Profile {
+	case "flat":
+		return 1
+	case "sine":
+		angle := 2 * math.Pi * (now - fc.PeakHour).Hours() / 24
+		return 1 + (fc.Multiplier-1)*(1+math.Cos(angle))/2
+	default:
+		distance := peakHourDistance(now, fc.PeakHour)
+		square := distance * distance
+		return 1 + (fc.Multiplier-1)*square/(2.*(square-distance)+1.)
+	}
+}
+
+// active tells if the scenario is active at the provided time.
+func (sc ScenarioConfiguration) active(now time.Time) bool {
+	elapsed := time.Duration(now.UnixNano()) % sc.Every
+	return (elapsed-sc.Start+sc.Every)%sc.Every < sc.Duration
+}
+
+// applyScenarios returns the flow configurations altered by the scenarios
+// active at the provided time.
+func applyScenarios(flowConfigs []FlowConfiguration, scenarios []ScenarioConfiguration, now time.Time) []FlowConfiguration {
+	down := []int{}
+	attacks := []FlowConfiguration{}
+	for _, scenario := range scenarios {
+		if !scenario.active(now) {
+			continue
+		}
+		switch scenario.Type {
+		case "link-failure":
+			down = append(down, scenario.IfIndex...)
+		case "ddos":
+			protocol := scenario.Protocol
+			if len(protocol) == 0 {
+				protocol = []string{"udp"}
+			}
+			attacks = append(attacks, FlowConfiguration{
+				PerSecond:  scenario.PerSecond,
+				InIfIndex:  scenario.InIfIndex,
+				OutIfIndex: scenario.OutIfIndex,
+				Multiplier: 1,
+				Profile:    "flat",
+				SrcNet:     scenario.SrcNet,
+				DstNet:     scenario.DstNet,
+				SrcAS:      scenario.SrcAS,
+				DstAS:      scenario.DstAS,
+				SrcPort:    scenario.SrcPort,
+				DstPort:    scenario.DstPort,
+				Protocol:   protocol,
+				Size:       scenario.Size,
+			})
+		}
+	}
+	if len(down) == 0 && len(attacks) == 0 {
+		return flowConfigs
+	}
+
+	isDown := func(ifIndex int) bool { return slices.Contains(down, ifIndex) }
+	result := make([]FlowConfiguration, 0, len(flowConfigs)+len(attacks))
+	for _, flowConfig := range append(slices.Clone(flowConfigs), attacks...) {
+		if len(down) > 0 {
+			flowConfig.InIfIndex = slices.DeleteFunc(slices.Clone(flowConfig.InIfIndex), isDown)
+			flowConfig.OutIfIndex = slices.DeleteFunc(slices.Clone(flowConfig.OutIfIndex), isDown)
+			if len(flowConfig.InIfIndex) == 0 || len(flowConfig.OutIfIndex) == 0 {
+				continue
+			}
+		}
+		result = append(result, flowConfig)
+	}
+	return result
+}
+
 // chooseRandom returns a random value from a slice
 func chooseRandom[T any](r *rand.Rand, slice []T) T {
 	if len(slice) == 0 {
@@ -73,9 +149,10 @@ func chooseRandom[T any](r *rand.Rand, slice []T) T {
 // configuration, for the provided date. It returns one second worth
 // of flows. This is stateless and not very efficient if we have many
 // flow configurations.
-func generateFlows(flowConfigs []FlowConfiguration, seed int64, now time.Time) []generatedFlow {
+func generateFlows(flowConfigs []FlowConfiguration, scenarios []ScenarioConfiguration, seed int64, now time.Time) []generatedFlow {
 	flows := []generatedFlow{}
 	now = now.Truncate(time.Second)
+	flowConfigs = applyScenarios(flowConfigs, scenarios, now)
 
 	// Initialize the random number generator to a known state
 	hash := fnv.New64()
@@ -85,9 +162,7 @@ func generateFlows(flowConfigs []FlowConfiguration, seed int64, now time.Time) [
 	nowTime := now.Sub(now.Truncate(time.Hour * 24))
 	for _, flowConfig := range flowConfigs {
 		// Compute how many per seconds
-		distance := peakHourDistance(nowTime, flowConfig.PeakHour)
-		square := distance * distance
-		multiplier := 1 + (flowConfig.Multiplier-1)*square/(2.*(square-distance)+1.)
+		multiplier := flowConfig.rateMultiplier(nowTime)
 		count := rateToCount(flowConfig.PerSecond*multiplier*(0.9+r.Float64()/5), now)
 		for ; count > 0; count-- {
 			flow := generatedFlow{
@@ -83,6 +83,153 @@ func TestPeakHourDistance(t *testing.T) {
 	}
 }
 
+func TestRateMultiplier(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Profile  string
+		Now      time.Duration
+		Expected float64
+	}{
+		{helpers.Mark(), "", 12 * time.Hour, 3},
+		{helpers.Mark(), "", 0, 1},
+		{helpers.Mark(), "peak", 12 * time.Hour, 3},
+		{helpers.Mark(), "sine", 12 * time.Hour, 3},
+		{helpers.Mark(), "sine", 6 * time.Hour, 2},
+		{helpers.Mark(), "sine", 18 * time.Hour, 2},
+		{helpers.Mark(), "sine", 0, 1},
+		{helpers.Mark(), "flat", 12 * time.Hour, 1},
+		{helpers.Mark(), "flat", 0, 1},
+	}
+	for _, tc := range cases {
+		fc := FlowConfiguration{
+			PeakHour:   12 * time.Hour,
+			Multiplier: 3,
+			Profile:    tc.Profile,
+		}
+		got := fc.rateMultiplier(tc.Now)
+		if math.Abs(got-tc.Expected) > 0.01 {
+			t.Errorf("%srateMultiplier(%s) with %q profile == %f, expected %f",
+				tc.Pos, tc.Now, tc.Profile, got, tc.Expected)
+		}
+	}
+}
+
+func TestScenarioActive(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Start    time.Duration
+		Now      time.Duration
+		Expected bool
+	}{
+		{helpers.Mark(), 0, 0, true},
+		{helpers.Mark(), 0, 9 * time.Minute, true},
+		{helpers.Mark(), 0, 10 * time.Minute, false},
+		{helpers.Mark(), 0, 59 * time.Minute, false},
+		{helpers.Mark(), 0, 60 * time.Minute, true},
+		{helpers.Mark(), 30 * time.Minute, 29 * time.Minute, false},
+		{helpers.Mark(), 30 * time.Minute, 35 * time.Minute, true},
+		{helpers.Mark(), 55 * time.Minute, 58 * time.Minute, true},
+		{helpers.Mark(), 55 * time.Minute, 62 * time.Minute, true},
+		{helpers.Mark(), 55 * time.Minute, 65 * time.Minute, false},
+	}
+	base := time.Date(2022, 3, 18, 15, 0, 0, 0, time.UTC)
+	for _, tc := range cases {
+		scenario := ScenarioConfiguration{
+			Type:     "ddos",
+			Every:    time.Hour,
+			Start:    tc.Start,
+			Duration: 10 * time.Minute,
+		}
+		if got := scenario.active(base.Add(tc.Now)); got != tc.Expected {
+			t.Errorf("%sactive(%s) with start %s == %v, expected %v",
+				tc.Pos, tc.Now, tc.Start, got, tc.Expected)
+		}
+	}
+}
+
+func TestApplyScenarios(t *testing.T) {
+	flowConfigs := []FlowConfiguration{
+		{
+			PerSecond:  1,
+			InIfIndex:  []int{10, 11},
+			OutIfIndex: []int{20},
+			Protocol:   []string{"tcp"},
+		}, {
+			PerSecond:  1,
+			InIfIndex:  []int{11},
+			OutIfIndex: []int{21},
+			Protocol:   []string{"tcp"},
+		},
+	}
+	linkFailure := ScenarioConfiguration{
+		Type:     "link-failure",
+		Every:    time.Hour,
+		Duration: 10 * time.Minute,
+		IfIndex:  []int{11},
+	}
+	ddos := ScenarioConfiguration{
+		Type:       "ddos",
+		Every:      time.Hour,
+		Start:      5 * time.Minute,
+		Duration:   10 * time.Minute,
+		PerSecond:  100,
+		InIfIndex:  []int{10},
+		OutIfIndex: []int{21},
+		SrcNet:     netip.MustParsePrefix("0.0.0.0/0"),
+		DstNet:     netip.MustParsePrefix("192.0.2.10/32"),
+		SrcPort:    []uint16{123},
+	}
+	base := time.Date(2022, 3, 18, 15, 0, 0, 0, time.UTC)
+
+	t.Run("inactive", func(t *testing.T) {
+		got := applyScenarios(flowConfigs, []ScenarioConfiguration{linkFailure, ddos}, base.Add(30*time.Minute))
+		if diff := helpers.Diff(got, flowConfigs); diff != "" {
+			t.Errorf("applyScenarios() (-got, +want):\n%s", diff)
+		}
+	})
+	t.Run("link failure", func(t *testing.T) {
+		got := applyScenarios(flowConfigs, []ScenarioConfiguration{linkFailure, ddos}, base.Add(2*time.Minute))
+		expected := []FlowConfiguration{
+			{
+				PerSecond:  1,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				Protocol:   []string{"tcp"},
+			},
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("applyScenarios() (-got, +want):\n%s", diff)
+		}
+		if diff := helpers.Diff(flowConfigs[0].InIfIndex, []int{10, 11}); diff != "" {
+			t.Errorf("applyScenarios() modified its input (-got, +want):\n%s", diff)
+		}
+	})
+	t.Run("link failure and DDoS", func(t *testing.T) {
+		got := applyScenarios(flowConfigs, []ScenarioConfiguration{linkFailure, ddos}, base.Add(7*time.Minute))
+		expected := []FlowConfiguration{
+			{
+				PerSecond:  1,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				Protocol:   []string{"tcp"},
+			}, {
+				PerSecond:  100,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{21},
+				Multiplier: 1,
+				Profile:    "flat",
+				SrcNet:     netip.MustParsePrefix("0.0.0.0/0"),
+				DstNet:     netip.MustParsePrefix("192.0.2.10/32"),
+				SrcPort:    []uint16{123},
+				Protocol:   []string{"udp"},
+			},
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("applyScenarios() (-got, +want):\n%s", diff)
+		}
+	})
+}
+
 func TestChooseRandom(t *testing.T) {
 	cases := [][]int{
 		nil,
@@ -293,7 +440,7 @@ func TestGenerateFlows(t *testing.T) {
 	now := time.Date(2022, 3, 18, 15, 0, 0, 0, time.UTC)
 	for _, tc := range cases {
 		t.Run(fmt.Sprintf("case %s", tc.Pos), func(t *testing.T) {
-			got := generateFlows([]FlowConfiguration{tc.FlowConfiguration}, 0, now)
+			got := generateFlows([]FlowConfiguration{tc.FlowConfiguration}, nil, 0, now)
 			if diff := helpers.Diff(got, tc.Expected); diff != "" {
 				t.Fatalf("%sgeneratedFlows() (-got, +want):\n%s", tc.Pos, diff)
 			}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flows
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+)
+
+// Packet is a NetFlow payload with the number of flows it contains.
+type Packet struct {
+	Payload []byte
+	Flows   int
+}
+
+// GeneratePackets returns the NetFlow packets for one second worth of flows
+// generated at the provided time. The first packet defines the templates and
+// does not contain any flow. This is used to generate load without sending
+// packets on the network.
+func GeneratePackets(config Configuration, start, now time.Time) []Packet {
+	ctx := context.Background()
+	packets := []Packet{}
+	for payload := range getNetFlowTemplates(ctx, 1, config.SamplingRate, start, now) {
+		packets = append(packets, Packet{Payload: payload})
+	}
+	flows := generateFlows(config.Flows, config.Scenarios, config.Seed, now)
+	for payload := range getNetFlowData(ctx, flows, 1, start, now) {
+		packets = append(packets, Packet{
+			Payload: payload,
+			Flows:   int(binary.BigEndian.Uint16(payload[2:4])),
+		})
+	}
+	return packets
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flows
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestGeneratePackets(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Flows = []FlowConfiguration{
+		{
+			PerSecond:  100,
+			InIfIndex:  []int{10},
+			OutIfIndex: []int{20},
+			Multiplier: 1,
+			Profile:    "flat",
+			SrcNet:     netip.MustParsePrefix("192.0.2.0/24"),
+			DstNet:     netip.MustParsePrefix("203.0.113.0/24"),
+			SrcAS:      []uint32{65201},
+			DstAS:      []uint32{65202},
+			Protocol:   []string{"tcp"},
+		}, {
+			PerSecond:  100,
+			InIfIndex:  []int{10},
+			OutIfIndex: []int{20},
+			Multiplier: 1,
+			Profile:    "flat",
+			SrcNet:     netip.MustParsePrefix("2001:db8::/64"),
+			DstNet:     netip.MustParsePrefix("2001:db8:1::/64"),
+			SrcAS:      []uint32{65201},
+			DstAS:      []uint32{65202},
+			Protocol:   []string{"udp"},
+		},
+	}
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	packets := GeneratePackets(config, now.Add(-time.Hour), now)
+	if len(packets) < 3 {
+		t.Fatalf("GeneratePackets() returned %d packets, expected at least 3", len(packets))
+	}
+	if packets[0].Flows != 0 {
+		t.Errorf("GeneratePackets() first packet has %d flows, expected 0", packets[0].Flows)
+	}
+	got := 0
+	for _, packet := range packets {
+		got += packet.Flows
+	}
+	expected := len(generateFlows(config.Flows, nil, config.Seed, now))
+	if got != expected {
+		t.Errorf("GeneratePackets() returned %d flows, expected %d", got, expected)
+	}
+}
@@ -101,7 +101,7 @@ func (c *Component) Start() error {
 							start, now))
 				}
 				templateCount++
-				flows := generateFlows(c.config.Flows, c.config.Seed, now)
+				flows := generateFlows(c.config.Flows, c.config.Scenarios, c.config.Seed, now)
 				transmit("data",
 					getNetFlowData(ctx, flows, sequenceNumber,
 						start, now))
@@ -8,7 +8,10 @@ import (
 	"akvorado/common/pb"
 	"akvorado/inlet/flow/input"
 	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/flow/input/kafka"
+	"akvorado/inlet/flow/input/tcp"
 	"akvorado/inlet/flow/input/udp"
+	"akvorado/inlet/flow/input/unix"
 )
 
 // Configuration describes the configuration for the flow component
@@ -51,8 +54,11 @@ func (ic InputConfiguration) MarshalYAML() (any, error) {
 }
 
 var inputs = map[string](func() input.Configuration){
-	"udp":  udp.DefaultConfiguration,
-	"file": file.DefaultConfiguration,
+	"udp":   udp.DefaultConfiguration,
+	"file":  file.DefaultConfiguration,
+	"kafka": kafka.DefaultConfiguration,
+	"unix":  unix.DefaultConfiguration,
+	"tcp":   tcp.DefaultConfiguration,
 }
 
 func init() {
@@ -4,6 +4,7 @@
 package flow
 
 import (
+	"net/netip"
 	"strings"
 	"testing"
 
@@ -13,8 +14,11 @@ import (
 	"akvorado/common/pb"
 
 	"akvorado/common/helpers"
+	kafkaCommon "akvorado/common/kafka"
 	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/flow/input/kafka"
 	"akvorado/inlet/flow/input/udp"
+	"akvorado/inlet/flow/input/unix"
 )
 
 func TestDecodeConfiguration(t *testing.T) {
@@ -57,6 +61,65 @@ func TestDecodeConfiguration(t *testing.T) {
 					UseSrcAddrForExporterAddr: false,
 				}},
 			},
+		}, {
+			Description: "several ports",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"inputs": []gin.H{
+						{
+							"type":    "udp",
+							"decoder": "netflow",
+							"listen":  "192.0.2.1:2055",
+							"ports":   []any{2056, "9995-9999", "6343"},
+							"decoders": gin.H{
+								"6343": "sflow",
+							},
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_NETFLOW,
+					Config: &udp.Configuration{
+						Workers: 1,
+						Listen:  "192.0.2.1:2055",
+						Ports:   []string{"2056", "9995-9999", "6343"},
+						Decoders: map[uint16]pb.RawFlow_Decoder{
+							6343: pb.RawFlow_DECODER_SFLOW,
+						},
+					},
+				}},
+			},
+		}, {
+			Description: "PROXY protocol",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"inputs": []gin.H{
+						{
+							"type":                   "udp",
+							"decoder":                "netflow",
+							"listen":                 "192.0.2.1:2055",
+							"proxy-protocol-sources": []string{"198.51.100.0/24", "2001:db8::/64"},
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_NETFLOW,
+					Config: &udp.Configuration{
+						Workers: 1,
+						Listen:  "192.0.2.1:2055",
+						ProxyProtocolSources: []netip.Prefix{
+							netip.MustParsePrefix("198.51.100.0/24"),
+							netip.MustParsePrefix("2001:db8::/64"),
+						},
+					},
+				}},
+			},
 		}, {
 			Description: "ignore queue-size",
 			Initial:     func() any { return Configuration{} },
@@ -159,6 +222,65 @@ func TestDecodeConfiguration(t *testing.T) {
 				}},
 			},
 		},
+		{
+			Description: "kafka input",
+			Initial: func() any {
+				return Configuration{}
+			},
+			Configuration: func() any {
+				return gin.H{
+					"inputs": []gin.H{
+						{
+							"type":           "kafka",
+							"decoder":        "netflow",
+							"brokers":        []string{"kafka1:9092", "kafka2:9092"},
+							"topic":          "netflow",
+							"address-header": "exporter",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_NETFLOW,
+					Config: &kafka.Configuration{
+						Configuration: kafkaCommon.Configuration{
+							Topic:   "netflow",
+							Brokers: []string{"kafka1:9092", "kafka2:9092"},
+						},
+						ConsumerGroup: "akvorado-inlet",
+						AddressHeader: "exporter",
+					},
+				}},
+			},
+		},
+		{
+			Description: "unix input",
+			Initial: func() any {
+				return Configuration{}
+			},
+			Configuration: func() any {
+				return gin.H{
+					"inputs": []gin.H{
+						{
+							"type":         "unix",
+							"decoder":      "sflow",
+							"systemd":      true,
+							"systemd-name": "sflow",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_SFLOW,
+					Config: &unix.Configuration{
+						Systemd:     true,
+						SystemdName: "sflow",
+					},
+				}},
+			},
+		},
 		{
 			Description: "only set one item",
 			Initial: func() any {
@@ -313,15 +435,23 @@ func TestMarshalYAML(t *testing.T) {
 	}
 	expected := `inputs:
     - decoder: netflow
+      decoders: {}
       listen: 192.0.2.11:2055
+      ports: []
+      proxyprotocolsources: []
       receivebuffer: 0
+      receivebufferautotune: false
       timestampsource: netflow-first-switched
       type: udp
       usesrcaddrforexporteraddr: false
       workers: 3
     - decoder: sflow
+      decoders: {}
       listen: 192.0.2.11:6343
+      ports: []
+      proxyprotocolsources: []
       receivebuffer: 0
+      receivebufferautotune: false
       timestampsource: input
       type: udp
       usesrcaddrforexporteraddr: true
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"akvorado/common/kafka"
+	"akvorado/inlet/flow/input"
+)
+
+// Configuration describes Kafka input configuration.
+type Configuration struct {
+	kafka.Configuration `mapstructure:",squash" yaml:"-,inline"`
+	// ConsumerGroup is the name of the consumer group to use.
+	ConsumerGroup string `validate:"min=1,ascii"`
+	// AddressHeader is the name of the record header containing the IP
+	// address of the exporter. When empty, the record key is used instead.
+	AddressHeader string
+}
+
+// DefaultConfiguration is the default configuration for this input.
+func DefaultConfiguration() input.Configuration {
+	config := kafka.DefaultConfiguration()
+	config.Topic = "datagrams"
+	return &Configuration{
+		Configuration: config,
+		ConsumerGroup: "akvorado-inlet",
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package kafka handles datagrams received from a Kafka topic, as produced by
+// an external collection layer.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+// Input represents the state of a Kafka input.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config Configuration
+
+	metrics struct {
+		bytes    *reporter.CounterVec
+		messages *reporter.CounterVec
+		errors   *reporter.CounterVec
+	}
+
+	kafkaOpts []kgo.Opt
+	send      input.SendFunc // function to send to kafka
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// New instantiate a new Kafka consumer from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	kafkaOpts, err := kafka.NewConfig(r, configuration.Configuration)
+	if err != nil {
+		return nil, err
+	}
+	kafkaOpts = append(kafkaOpts,
+		kgo.ConsumerGroup(configuration.ConsumerGroupName(configuration.ConsumerGroup)),
+		kgo.ConsumeStartOffset(kgo.NewOffset().AtEnd()),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
+		kgo.ConsumeTopics(configuration.TopicName(configuration.Topic)),
+	)
+	if err := kgo.ValidateOpts(kafkaOpts...); err != nil {
+		return nil, fmt.Errorf("invalid Kafka configuration: %w", err)
+	}
+
+	input := &Input{
+		r:         r,
+		config:    configuration,
+		kafkaOpts: kafkaOpts,
+		send:      send,
+	}
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"exporter"},
+	)
+	input.metrics.messages = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "messages_total",
+			Help: "Messages received by the application.",
+		},
+		[]string{"exporter"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving messages by the application.",
+		},
+		[]string{"error"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/kafka")
+	return input, nil
+}
+
+// Start starts consuming the Kafka topic and producing flows.
+func (in *Input) Start() error {
+	in.r.Info().Str("topic", in.config.TopicName(in.config.Topic)).Msg("starting Kafka input")
+	client, err := kgo.NewClient(in.kafkaOpts...)
+	if err != nil {
+		in.r.Err(err).Msg("unable to create Kafka client")
+		return fmt.Errorf("unable to create Kafka client: %w", err)
+	}
+
+	in.t.Go(func() error {
+		defer client.Close()
+		ctx := in.t.Context(context.Background())
+		errLogger := in.r.Sample(reporter.BurstSampler(time.Minute, 1))
+		flow := pb.RawFlow{}
+		var source [16]byte
+		for {
+			fetches := client.PollFetches(ctx)
+			if fetches.IsClientClosed() || ctx.Err() != nil {
+				return nil
+			}
+			fetches.EachError(func(_ string, _ int32, err error) {
+				errLogger.Err(err).Msg("unable to fetch messages from Kafka")
+				in.metrics.errors.WithLabelValues("cannot fetch").Inc()
+			})
+			fetches.EachRecord(func(record *kgo.Record) {
+				address := record.Key
+				if in.config.AddressHeader != "" {
+					address = nil
+					for _, header := range record.Headers {
+						if header.Key == in.config.AddressHeader {
+							address = header.Value
+							break
+						}
+					}
+				}
+				ip, err := netip.ParseAddr(string(address))
+				if err != nil {
+					errLogger.Err(err).Msg("invalid exporter address in Kafka message")
+					in.metrics.errors.WithLabelValues("invalid exporter address").Inc()
+					return
+				}
+				exporter := ip.Unmap().String()
+				in.metrics.bytes.WithLabelValues(exporter).Add(float64(len(record.Value)))
+				in.metrics.messages.WithLabelValues(exporter).Inc()
+
+				source = ip.As16()
+				flow.Reset()
+				flow.TimeReceived = uint64(record.Timestamp.Unix())
+				flow.Payload = record.Value
+				flow.SourceAddress = source[:]
+				in.send(exporter, &flow)
+			})
+		}
+	})
+	return nil
+}
+
+// Stop stops the Kafka consumer.
+func (in *Input) Stop() error {
+	defer in.r.Info().Msg("Kafka input stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
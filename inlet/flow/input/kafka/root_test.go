@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+func TestKafkaInput(t *testing.T) {
+	r := reporter.NewMock(t)
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "datagrams"),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer cluster.Close()
+
+	// Create a producer client
+	producerConfiguration := kafka.DefaultConfiguration()
+	producerConfiguration.Brokers = cluster.ListenAddrs()
+	producerOpts, err := kafka.NewConfig(reporter.NewMock(t), producerConfiguration)
+	if err != nil {
+		t.Fatalf("NewConfig() error:\n%+v", err)
+	}
+	producerOpts = append(producerOpts, kgo.ProducerLinger(0))
+	producer, err := kgo.NewClient(producerOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+
+	// Start the input
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Brokers = cluster.ListenAddrs()
+	configuration.AddressHeader = "exporter"
+	got := make(chan *pb.RawFlow, 10)
+	send := func(exporter string, flow *pb.RawFlow) {
+		if exporter != "192.0.2.1" {
+			t.Errorf("send() exporter == %q, expected 192.0.2.1", exporter)
+		}
+		got <- &pb.RawFlow{
+			TimeReceived:  flow.TimeReceived,
+			Payload:       slices.Clone(flow.Payload),
+			SourceAddress: slices.Clone(flow.SourceAddress),
+		}
+	}
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	// Send messages until the consumer group is ready
+	timestamp := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	records := []*kgo.Record{
+		{
+			Topic:   "datagrams",
+			Value:   []byte("invalid"),
+			Headers: []kgo.RecordHeader{{Key: "exporter", Value: []byte("nothing")}},
+		}, {
+			Topic:     "datagrams",
+			Value:     []byte("hello world!"),
+			Timestamp: timestamp,
+			Headers:   []kgo.RecordHeader{{Key: "exporter", Value: []byte("192.0.2.1")}},
+		},
+	}
+	var flow *pb.RawFlow
+	deadline := time.Now().Add(5 * time.Second)
+	for flow == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("no flow received")
+		}
+		if err := producer.ProduceSync(context.Background(), records...).FirstErr(); err != nil {
+			t.Fatalf("ProduceSync() error:\n%+v", err)
+		}
+		select {
+		case flow = <-got:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	expected := &pb.RawFlow{
+		TimeReceived:  uint64(timestamp.Unix()),
+		Payload:       []byte("hello world!"),
+		SourceAddress: net.ParseIP("192.0.2.1").To16(),
+	}
+	if diff := helpers.Diff(flow, expected); diff != "" {
+		t.Fatalf("Input data (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_kafka_", "errors_total")
+	if gotMetrics[`errors_total{error="invalid exporter address"}`] == "" {
+		t.Errorf("Metrics: missing errors for invalid addresses, got %v", gotMetrics)
+	}
+}
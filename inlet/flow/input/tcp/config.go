@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"time"
+
+	"akvorado/inlet/flow/input"
+)
+
+// Configuration describes TCP input configuration.
+type Configuration struct {
+	// Listen tells which port to listen to.
+	Listen string `validate:"required,listen"`
+	// MaxConnections is the maximum number of simultaneous connections. When
+	// 0, there is no limit.
+	MaxConnections int `validate:"min=0"`
+	// IdleTimeout is the maximum duration to wait for a new message before
+	// closing a connection. When 0, idle connections are kept.
+	IdleTimeout time.Duration `validate:"min=0"`
+	// TLS defines the TLS configuration of the listener.
+	TLS TLSConfiguration
+}
+
+// TLSConfiguration describes the TLS configuration of the listener.
+type TLSConfiguration struct {
+	// Enable says if TLS should be used.
+	Enable bool `validate:"required_with=CertFile KeyFile ClientCAFile"`
+	// CertFile tells the location of the server certificate.
+	CertFile string `validate:"required_with=Enable"`
+	// KeyFile tells the location of the server key. When empty, the key is
+	// read from CertFile.
+	KeyFile string
+	// ClientCAFile tells the location of the CA certificate to check client
+	// certificates. When set, exporters have to present a valid certificate.
+	ClientCAFile string
+}
+
+// DefaultConfiguration is the default configuration for this input.
+func DefaultConfiguration() input.Configuration {
+	return &Configuration{
+		Listen:         ":0",
+		MaxConnections: 100,
+		IdleTimeout:    5 * time.Minute,
+	}
+}
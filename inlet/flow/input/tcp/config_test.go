@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+)
+
+func TestConfigurationValidation(t *testing.T) {
+	cases := []struct {
+		Pos    helpers.Pos
+		Config Configuration
+		Error  bool
+	}{
+		{helpers.Mark(), *DefaultConfiguration().(*Configuration), false},
+		{helpers.Mark(), Configuration{}, true},
+		{helpers.Mark(), Configuration{Listen: ":4739"}, false},
+		{helpers.Mark(), Configuration{Listen: ":4739", MaxConnections: -1}, true},
+		{helpers.Mark(), Configuration{Listen: ":4739", IdleTimeout: -time.Second}, true},
+		{helpers.Mark(), Configuration{Listen: ":4739", TLS: TLSConfiguration{Enable: true}}, true},
+		{helpers.Mark(), Configuration{Listen: ":4739", TLS: TLSConfiguration{CertFile: "/etc/akvorado/cert.pem"}}, true},
+		{helpers.Mark(), Configuration{Listen: ":4739", TLS: TLSConfiguration{
+			Enable:   true,
+			CertFile: "/etc/akvorado/cert.pem",
+		}}, false},
+	}
+	for _, tc := range cases {
+		err := helpers.Validate.Struct(tc.Config)
+		if err != nil && !tc.Error {
+			t.Errorf("%sValidate() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%sValidate() did not error", tc.Pos)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package tcp handles IPFIX messages received over TCP or TLS, as described
+// in RFC 7011. Messages are framed using the length field of the IPFIX
+// header. Templates are scoped to the transport session: as the outlet keys
+// templates by exporter address, only one connection per exporter is kept and
+// a new connection from the same exporter replaces the previous one.
+package tcp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+const (
+	// headerLength is the length of the IPFIX message header.
+	headerLength = 16
+	// ipfixVersion is the version number of IPFIX in the message header.
+	ipfixVersion = 10
+)
+
+// messageTimeout is the maximum duration to receive a complete message once
+// its first byte has been received. This is a variable for testing purpose.
+var messageTimeout = 10 * time.Second
+
+// Input represents the state of a TCP input.
+type Input struct {
+	r         *reporter.Reporter
+	t         tomb.Tomb
+	config    Configuration
+	tlsConfig *tls.Config
+
+	metrics struct {
+		bytes       *reporter.CounterVec
+		packets     *reporter.CounterVec
+		errors      *reporter.CounterVec
+		connections *reporter.GaugeVec
+	}
+
+	lock    sync.Mutex
+	conns   map[string]net.Conn // active connection for each exporter
+	address net.Addr            // listening address, for testing purpose
+
+	send input.SendFunc // function to send to kafka
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// New instantiate a new TCP listener from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	if configuration.Listen == "" {
+		return nil, errors.New("listen value missing")
+	}
+	input := &Input{
+		r:      r,
+		config: configuration,
+		conns:  map[string]net.Conn{},
+		send:   send,
+	}
+	if configuration.TLS.Enable {
+		tlsConfig, err := configuration.TLS.makeTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		input.tlsConfig = tlsConfig
+	}
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.packets = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "packets_total",
+			Help: "Messages received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving messages by the application.",
+		},
+		[]string{"listener", "error"},
+	)
+	input.metrics.connections = r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "connections",
+			Help: "Number of active connections.",
+		},
+		[]string{"listener"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/tcp")
+	return input, nil
+}
+
+// makeTLSConfig builds the TLS configuration for the listener.
+func (config TLSConfiguration) makeTLSConfig() (*tls.Config, error) {
+	keyFile := config.KeyFile
+	if keyFile == "" {
+		keyFile = config.CertFile
+	}
+	cert, err := tls.LoadX509KeyPair(config.CertFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if config.ClientCAFile != "" {
+		caCert, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read client CA certificate: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if ok := caCertPool.AppendCertsFromPEM(caCert); !ok {
+			return nil, errors.New("cannot parse client CA certificate")
+		}
+		tlsConfig.ClientCAs = caCertPool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Start starts listening to the provided TCP socket and producing flows.
+func (in *Input) Start() error {
+	in.r.Info().Str("listen", in.config.Listen).Msg("starting TCP input")
+	listener, err := net.Listen("tcp", in.config.Listen)
+	if err != nil {
+		return fmt.Errorf("unable to listen to %s: %w", in.config.Listen, err)
+	}
+	in.address = listener.Addr()
+	if in.tlsConfig != nil {
+		listener = tls.NewListener(listener, in.tlsConfig)
+	}
+	in.r.Info().Str("listen", in.address.String()).Msg("TCP input listening")
+
+	in.t.Go(func() error {
+		errLogger := in.r.Sample(reporter.BurstSampler(time.Minute, 1))
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				errLogger.Err(err).Msg("unable to accept connection")
+				in.metrics.errors.WithLabelValues(in.config.Listen, "cannot accept").Inc()
+				continue
+			}
+			if !in.register(conn) {
+				errLogger.Warn().Str("remote", conn.RemoteAddr().String()).Msg("too many connections")
+				in.metrics.errors.WithLabelValues(in.config.Listen, "too many connections").Inc()
+				conn.Close()
+				continue
+			}
+			in.t.Go(func() error {
+				in.handleConnection(conn)
+				return nil
+			})
+		}
+	})
+
+	// Watch for termination and close on dying
+	in.t.Go(func() error {
+		<-in.t.Dying()
+		listener.Close()
+		in.lock.Lock()
+		for _, conn := range in.conns {
+			conn.Close()
+		}
+		in.lock.Unlock()
+		return nil
+	})
+	return nil
+}
+
+// exporterAddress returns the exporter address of a connection.
+func exporterAddress(conn net.Conn) netip.Addr {
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return tcpAddr.AddrPort().Addr().Unmap()
+	}
+	return netip.Addr{}
+}
+
+// register registers a new connection. A previous connection from the same
+// exporter is closed. It returns false if the connection should be rejected.
+func (in *Input) register(conn net.Conn) bool {
+	exporter := exporterAddress(conn).String()
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	select {
+	case <-in.t.Dying():
+		return false
+	default:
+	}
+	if previous, ok := in.conns[exporter]; ok {
+		in.r.Info().Str("exporter", exporter).Msg("new connection replaces the previous one")
+		previous.Close()
+	} else if in.config.MaxConnections > 0 && len(in.conns) >= in.config.MaxConnections {
+		return false
+	}
+	in.conns[exporter] = conn
+	in.metrics.connections.WithLabelValues(in.config.Listen).Set(float64(len(in.conns)))
+	return true
+}
+
+// unregister unregisters a connection, unless it was already replaced.
+func (in *Input) unregister(conn net.Conn) {
+	exporter := exporterAddress(conn).String()
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	if in.conns[exporter] == conn {
+		delete(in.conns, exporter)
+	}
+	in.metrics.connections.WithLabelValues(in.config.Listen).Set(float64(len(in.conns)))
+}
+
+// handleConnection reads IPFIX messages from a connection until it is closed.
+// Messages are sent synchronously: when the sender is slow, the connection is
+// not read and TCP flow control pushes back to the exporter.
+func (in *Input) handleConnection(conn net.Conn) {
+	defer in.unregister(conn)
+	defer conn.Close()
+
+	ip := exporterAddress(conn)
+	exporter := ip.String()
+	address := ip.As16()
+	listen := in.config.Listen
+	logger := in.r.With().Str("listen", listen).Str("exporter", exporter).Logger()
+	errLogger := logger.Sample(reporter.BurstSampler(time.Minute, 1))
+	logger.Debug().Msg("new connection")
+
+	reader := bufio.NewReader(conn)
+	payload := make([]byte, 65535)
+	flow := pb.RawFlow{}
+	for {
+		// Wait for the next message, then read it with a deadline to not
+		// let a slow client hold a connection slot.
+		if err := in.setDeadline(conn, in.config.IdleTimeout); err != nil {
+			errLogger.Err(err).Msg("unable to set deadline")
+			in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+			return
+		}
+		if _, err := reader.Peek(1); err != nil {
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				logger.Debug().Msg("close idle connection")
+				in.metrics.errors.WithLabelValues(listen, "idle timeout").Inc()
+			case !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed):
+				errLogger.Err(err).Msg("unable to receive message")
+				in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+			}
+			return
+		}
+		if err := in.setDeadline(conn, messageTimeout); err != nil {
+			errLogger.Err(err).Msg("unable to set deadline")
+			in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+			return
+		}
+
+		// Read the header to get the message length. As there is no way to
+		// resynchronize a stream, any error closes the connection.
+		if _, err := io.ReadFull(reader, payload[:headerLength]); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				errLogger.Err(err).Msg("unable to receive message")
+				in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+			}
+			return
+		}
+		received := time.Now()
+		version := binary.BigEndian.Uint16(payload[0:2])
+		length := int(binary.BigEndian.Uint16(payload[2:4]))
+		if version != ipfixVersion {
+			errLogger.Error().Uint16("version", version).Msg("unsupported version")
+			in.metrics.errors.WithLabelValues(listen, "unsupported version").Inc()
+			return
+		}
+		if length < headerLength {
+			errLogger.Error().Int("length", length).Msg("invalid message length")
+			in.metrics.errors.WithLabelValues(listen, "invalid length").Inc()
+			return
+		}
+		if _, err := io.ReadFull(reader, payload[headerLength:length]); err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				errLogger.Err(err).Msg("unable to receive message")
+				in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+			}
+			return
+		}
+
+		data := payload[:length]
+		in.metrics.bytes.WithLabelValues(listen, exporter).Add(float64(len(data)))
+		in.metrics.packets.WithLabelValues(listen, exporter).Inc()
+
+		flow.Reset()
+		flow.TimeReceived = uint64(received.Unix())
+		flow.Payload = data
+		flow.SourceAddress = address[:]
+		in.send(exporter, &flow)
+	}
+}
+
+// setDeadline sets the read deadline of a connection to the provided duration
+// from now. When the duration is 0, there is no deadline.
+func (in *Input) setDeadline(conn net.Conn, timeout time.Duration) error {
+	if timeout == 0 {
+		return conn.SetReadDeadline(time.Time{})
+	}
+	return conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// Stop stops the TCP listener.
+func (in *Input) Stop() error {
+	defer in.r.Info().Msg("TCP input stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
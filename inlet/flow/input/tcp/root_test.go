@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+// ipfixMessage builds an IPFIX message with the provided body.
+func ipfixMessage(version uint16, body string) []byte {
+	message := make([]byte, headerLength, headerLength+len(body))
+	binary.BigEndian.PutUint16(message[0:2], version)
+	binary.BigEndian.PutUint16(message[2:4], uint16(headerLength+len(body)))
+	return append(message, body...)
+}
+
+// startInput starts a TCP input and returns it with a channel receiving the
+// payloads sent to Kafka.
+func startInput(t *testing.T, configuration *Configuration) (*reporter.Reporter, *Input, chan []byte) {
+	t.Helper()
+	r := reporter.NewMock(t)
+	received := make(chan []byte, 10)
+	exporter := netip.MustParseAddr("::ffff:127.0.0.1").As16()
+	send := func(gotExporter string, got *pb.RawFlow) {
+		if gotExporter != "127.0.0.1" {
+			t.Errorf("Exporter: got %q, expected %q", gotExporter, "127.0.0.1")
+		}
+		expected := &pb.RawFlow{
+			TimeReceived:  got.TimeReceived,
+			Payload:       got.Payload,
+			SourceAddress: exporter[:],
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("Input data (-got, +want):\n%s", diff)
+		}
+		received <- append([]byte{}, got.Payload...)
+	}
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+	return r, in.(*Input), received
+}
+
+// expectPayloads checks the payloads received match the expected ones.
+func expectPayloads(t *testing.T, received chan []byte, expected ...[]byte) {
+	t.Helper()
+	for _, payload := range expected {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+		case got := <-received:
+			if diff := helpers.Diff(got, payload); diff != "" {
+				t.Fatalf("Payload (-got, +want):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestTCPInput(t *testing.T) {
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	r, in, received := startInput(t, configuration)
+
+	conn, err := net.Dial("tcp", in.address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+
+	// Two messages in one write, then a message split over two writes
+	message1 := ipfixMessage(10, "hello world!")
+	message2 := ipfixMessage(10, "")
+	message3 := ipfixMessage(10, "goodbye world!")
+	if _, err := conn.Write(append(message1, message2...)); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	if _, err := conn.Write(message3[:10]); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := conn.Write(message3[10:]); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectPayloads(t, received, message1, message2, message3)
+
+	// A NetFlow v9 message closes the connection
+	if _, err := conn.Write(ipfixMessage(9, "hello")); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() did not error")
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_")
+	expectedMetrics := map[string]string{
+		`bytes_total{exporter="127.0.0.1",listener="127.0.0.1:0"}`:         "74",
+		`packets_total{exporter="127.0.0.1",listener="127.0.0.1:0"}`:       "3",
+		`errors_total{error="unsupported version",listener="127.0.0.1:0"}`: "1",
+		`connections{listener="127.0.0.1:0"}`:                              "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPInputTimeouts(t *testing.T) {
+	defer func(timeout time.Duration) { messageTimeout = timeout }(messageTimeout)
+	messageTimeout = 50 * time.Millisecond
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.IdleTimeout = 100 * time.Millisecond
+	r, in, received := startInput(t, configuration)
+
+	expectClosed := func(conn net.Conn, after time.Duration) {
+		t.Helper()
+		start := time.Now()
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Fatal("Read() did not error")
+		} else if errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatal("connection not closed by the input")
+		}
+		if elapsed := time.Since(start); elapsed < after/2 {
+			t.Fatalf("connection closed after %s, expected about %s", elapsed, after)
+		}
+	}
+
+	// An idle connection is closed
+	conn, err := net.Dial("tcp", in.address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	message := ipfixMessage(10, "hello world!")
+	if _, err := conn.Write(message); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectPayloads(t, received, message)
+	expectClosed(conn, configuration.IdleTimeout)
+
+	// A partial message is not waited for forever
+	conn, err = net.Dial("tcp", in.address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(message[:10]); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectClosed(conn, messageTimeout)
+	time.Sleep(10 * time.Millisecond)
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_", "errors_total", "connections")
+	expectedMetrics := map[string]string{
+		`errors_total{error="idle timeout",listener="127.0.0.1:0"}`:   "1",
+		`errors_total{error="cannot receive",listener="127.0.0.1:0"}`: "1",
+		`connections{listener="127.0.0.1:0"}`:                         "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPInputReplaceConnection(t *testing.T) {
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.MaxConnections = 1
+	r, in, received := startInput(t, configuration)
+
+	conn1, err := net.Dial("tcp", in.address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn1.Close()
+	message := ipfixMessage(10, "hello world!")
+	if _, err := conn1.Write(message); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectPayloads(t, received, message)
+
+	// A second connection from the same exporter replaces the first one,
+	// despite the limit on the number of connections.
+	conn2, err := net.Dial("tcp", in.address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn2.Close()
+	if _, err := conn2.Write(message); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectPayloads(t, received, message)
+	conn1.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn1.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() on first connection did not error")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_", "connections")
+	expectedMetrics := map[string]string{
+		`connections{listener="127.0.0.1:0"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+// writeCertificate generates a self-signed certificate and writes it with its
+// key to the provided directory.
+func writeCertificate(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error:\n%+v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"Test Organization"}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error:\n%+v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey() error:\n%+v", err)
+	}
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() error:\n%+v", err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestTLSInput(t *testing.T) {
+	certFile, keyFile, cert := writeCertificate(t, t.TempDir())
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.TLS = TLSConfiguration{
+		Enable:   true,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+	_, in, received := startInput(t, configuration)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	conn, err := tls.Dial("tcp", in.address.String(), &tls.Config{RootCAs: pool})
+	if err != nil {
+		t.Fatalf("tls.Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	message := ipfixMessage(10, "hello world!")
+	if _, err := conn.Write(message); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	expectPayloads(t, received, message)
+}
@@ -4,7 +4,10 @@
 package udp
 
 import (
+	"net/netip"
+
 	"akvorado/common/helpers"
+	"akvorado/common/pb"
 	"akvorado/inlet/flow/input"
 )
 
@@ -12,6 +15,11 @@ import (
 type Configuration struct {
 	// Listen tells which port to listen to.
 	Listen string `validate:"required,listen"`
+	// Ports is a list of additional ports or port ranges (like 9995-9999) to
+	// listen to on the same address as Listen.
+	Ports []string `validate:"dive,portrange"`
+	// Decoders overrides the decoder of the input for some ports.
+	Decoders map[uint16]pb.RawFlow_Decoder
 	// Workers define the number of workers to use for receiving flows. The max
 	// should match the array length in reuseport_kern.c.
 	Workers int `validate:"required,min=1,max=256"`
@@ -21,6 +29,15 @@ type Configuration struct {
 	// The value cannot exceed the kernel max value
 	// (net.core.wmem_max).
 	ReceiveBuffer uint
+	// ReceiveBufferAutotune requests the maximum buffer size allowed by the
+	// kernel (net.core.rmem_max) for each listening socket. It cannot be used
+	// with ReceiveBuffer.
+	ReceiveBufferAutotune bool `validate:"excluded_with=ReceiveBuffer"`
+	// ProxyProtocolSources is a list of subnets for relays or load balancers
+	// prefixing packets with a PROXY protocol v2 header. For these sources,
+	// the source address in the header is used instead of the source address
+	// of the packet.
+	ProxyProtocolSources []netip.Prefix
 }
 
 // DefaultConfiguration is the default configuration for this input
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package udp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"akvorado/common/reporter"
+
+	"golang.org/x/sys/unix"
+)
+
+// dropsPollInterval tells how often the kernel drop counters are polled.
+const dropsPollInterval = 10 * time.Second
+
+// socket identifies a listening socket in the kernel drop counters.
+type socket struct {
+	listen string
+	worker string
+	inode  uint64
+}
+
+// socketInode returns the inode of the provided socket.
+func socketInode(fd uintptr) (uint64, error) {
+	var st unix.Stat_t
+	if err := unix.Fstat(int(fd), &st); err != nil {
+		return 0, err
+	}
+	return st.Ino, nil
+}
+
+// parseProcNetUDP parses the content of /proc/net/udp or /proc/net/udp6 and
+// adds the number of dropped packets of each socket to the provided map,
+// indexed by inode.
+func parseProcNetUDP(r io.Reader, drops map[uint64]uint64) error {
+	scanner := bufio.NewScanner(r)
+	inodeIdx, dropsIdx := 0, 0
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if line == 0 {
+			// The header and the rows do not split fields the same way,
+			// so we count from the end.
+			for idx, field := range fields {
+				switch field {
+				case "inode":
+					inodeIdx = idx - len(fields)
+				case "drops":
+					dropsIdx = idx - len(fields)
+				}
+			}
+			if inodeIdx == 0 || dropsIdx == 0 {
+				return fmt.Errorf("cannot find inode and drops columns in %q", scanner.Text())
+			}
+			continue
+		}
+		if len(fields) < -inodeIdx || len(fields) < -dropsIdx {
+			return fmt.Errorf("cannot parse line %d", line+1)
+		}
+		inode, err := strconv.ParseUint(fields[len(fields)+inodeIdx], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse inode at line %d: %w", line+1, err)
+		}
+		count, err := strconv.ParseUint(fields[len(fields)+dropsIdx], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse drops at line %d: %w", line+1, err)
+		}
+		drops[inode] += count
+	}
+	return scanner.Err()
+}
+
+// pollDrops updates the drop counters of each socket from the kernel and
+// returns the number of packets dropped since the previous call.
+func (in *Input) pollDrops() (uint64, error) {
+	drops, err := socketDrops()
+	if err != nil {
+		return 0, err
+	}
+	var total uint64
+	for _, s := range in.sockets {
+		count, ok := drops[s.inode]
+		if !ok {
+			continue
+		}
+		in.metrics.inDrops.WithLabelValues(s.listen, s.worker).Set(float64(count))
+		total += count
+	}
+	var increase uint64
+	if total > in.lastDrops {
+		increase = total - in.lastDrops
+	}
+	in.lastDrops = total
+	return increase, nil
+}
+
+// watchDrops polls the kernel drop counters until the input is stopped. It
+// returns immediately if these counters are not available.
+func (in *Input) watchDrops() {
+	if _, err := in.pollDrops(); err != nil {
+		in.r.Debug().Err(err).Msg("kernel drop counters not available")
+		return
+	}
+	in.r.RegisterHealthcheck(fmt.Sprintf("udp/%s", in.config.Listen), in.dropsHealthcheck)
+	in.t.Go(func() error {
+		ticker := time.NewTicker(dropsPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-in.t.Dying():
+				return nil
+			case <-ticker.C:
+				increase, err := in.pollDrops()
+				if err != nil {
+					in.r.Err(err).Msg("cannot read kernel drop counters")
+					continue
+				}
+				in.recentDrops.Store(increase)
+			}
+		}
+	})
+}
+
+// dropsHealthcheck returns a warning when packets were dropped by the kernel
+// during the last poll.
+func (in *Input) dropsHealthcheck(context.Context) reporter.HealthcheckResult {
+	if drops := in.recentDrops.Load(); drops > 0 {
+		return reporter.HealthcheckResult{
+			Status: reporter.HealthcheckWarning,
+			Reason: fmt.Sprintf("%d packets dropped by the kernel during the last %s",
+				drops, dropsPollInterval),
+		}
+	}
+	return reporter.HealthcheckResult{
+		Status: reporter.HealthcheckOK,
+		Reason: "no packet dropped by the kernel",
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package udp
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+func TestParseProcNetUDP(t *testing.T) {
+	udp := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  218: 00000000:0807 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 67643151 2 ffff8e5b8b3c2d00 486525
+  805: 0100007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 20147 2 ffff8e5b81b0b400 0
+`
+	udp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  218: 00000000000000000000000000000000:0807 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 67643152 2 ffff8e5b8b3c3600 12
+`
+	got := map[uint64]uint64{}
+	for _, content := range []string{udp, udp6} {
+		if err := parseProcNetUDP(strings.NewReader(content), got); err != nil {
+			t.Fatalf("parseProcNetUDP() error:\n%+v", err)
+		}
+	}
+	expected := map[uint64]uint64{
+		67643151: 486525,
+		20147:    0,
+		67643152: 12,
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("parseProcNetUDP() (-got, +want):\n%s", diff)
+	}
+
+	for _, content := range []string{
+		"sl local_address\n",
+		udp + "  1: 00000000:0807\n",
+		udp + "  1: 00000000:0807 00000000:0000 07 00000000:00000000 00:00000000 00000000 0 0 abc 2 ffff8e5b8b3c2d00 0\n",
+	} {
+		if err := parseProcNetUDP(strings.NewReader(content), map[uint64]uint64{}); err == nil {
+			t.Errorf("parseProcNetUDP(%q) did not error", content)
+		}
+	}
+}
+
+func TestKernelDrops(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skip Linux-only test")
+	}
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.Workers = 2
+	in, err := configuration.New(r, daemon.NewMock(t), func(string, *pb.RawFlow) {})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+	input := in.(*Input)
+
+	if len(input.sockets) != 2 {
+		t.Fatalf("Start() registered %d sockets, expected 2", len(input.sockets))
+	}
+	drops, err := socketDrops()
+	if err != nil {
+		t.Fatalf("socketDrops() error:\n%+v", err)
+	}
+	for _, s := range input.sockets {
+		if _, ok := drops[s.inode]; !ok {
+			t.Errorf("socketDrops() does not contain socket for worker %s", s.worker)
+		}
+	}
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_udp_", "in_dropped")
+	expectedMetrics := map[string]string{
+		`in_dropped_packets_total{listener="127.0.0.1:0",worker="0"}`: "0",
+		`in_dropped_packets_total{listener="127.0.0.1:0",worker="1"}`: "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Healthcheck
+	got := r.RunHealthchecks(context.Background())
+	if got.Details["udp/127.0.0.1:0"].Status != reporter.HealthcheckOK {
+		t.Errorf("RunHealthchecks() == %+v, expected ok for UDP input", got)
+	}
+	input.recentDrops.Store(10)
+	got = r.RunHealthchecks(context.Background())
+	if got.Details["udp/127.0.0.1:0"].Status != reporter.HealthcheckWarning {
+		t.Errorf("RunHealthchecks() == %+v, expected warning for UDP input", got)
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package udp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// proxySignature is the signature starting a PROXY protocol v2 header. It
+// cannot be mistaken for the start of a NetFlow, IPFIX, or sFlow packet.
+var proxySignature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// parseProxyHeader parses the PROXY protocol v2 header at the start of the
+// provided payload. It returns the original source address and the length of
+// the header. When there is no header, the length is 0. For a LOCAL command
+// (sent by the relay itself) or an unspecified address family, the returned
+// address is invalid.
+func parseProxyHeader(payload []byte) (netip.Addr, int, error) {
+	if !bytes.HasPrefix(payload, proxySignature) {
+		return netip.Addr{}, 0, nil
+	}
+	if len(payload) < 16 {
+		return netip.Addr{}, 0, errors.New("truncated PROXY header")
+	}
+	versionCommand, family := payload[12], payload[13]
+	length := 16 + int(binary.BigEndian.Uint16(payload[14:16]))
+	if versionCommand>>4 != 2 {
+		return netip.Addr{}, 0, fmt.Errorf("unsupported PROXY protocol version %d", versionCommand>>4)
+	}
+	if len(payload) < length {
+		return netip.Addr{}, 0, errors.New("truncated PROXY header")
+	}
+	switch versionCommand & 0xf {
+	case 0: // LOCAL
+		return netip.Addr{}, length, nil
+	case 1: // PROXY
+	default:
+		return netip.Addr{}, 0, fmt.Errorf("unsupported PROXY command %d", versionCommand&0xf)
+	}
+	addresses := payload[16:length]
+	switch family >> 4 {
+	case 0: // AF_UNSPEC
+		return netip.Addr{}, length, nil
+	case 1: // AF_INET
+		if len(addresses) < 12 {
+			return netip.Addr{}, 0, errors.New("truncated PROXY IPv4 addresses")
+		}
+		return netip.AddrFrom4([4]byte(addresses[:4])), length, nil
+	case 2: // AF_INET6
+		if len(addresses) < 36 {
+			return netip.Addr{}, 0, errors.New("truncated PROXY IPv6 addresses")
+		}
+		return netip.AddrFrom16([16]byte(addresses[:16])).Unmap(), length, nil
+	default:
+		return netip.Addr{}, 0, fmt.Errorf("unsupported PROXY address family %d", family>>4)
+	}
+}
+
+// fromProxy tells if the provided source is allowed to send packets with a
+// PROXY protocol header.
+func (in *Input) fromProxy(source netip.Addr) bool {
+	source = source.Unmap()
+	for _, prefix := range in.config.ProxyProtocolSources {
+		if prefix.Contains(source) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package udp
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+// proxyHeader builds a PROXY protocol v2 header.
+func proxyHeader(command, family byte, addresses []byte) []byte {
+	header := append([]byte{}, proxySignature...)
+	header = append(header, 0x20|command, family<<4|0x2,
+		byte(len(addresses)>>8), byte(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestParseProxyHeader(t *testing.T) {
+	ipv4 := []byte{192, 0, 2, 10, 198, 51, 100, 1, 0x08, 0x07, 0x08, 0x07}
+	ipv6 := append(netip.MustParseAddr("2001:db8::10").AsSlice(),
+		netip.MustParseAddr("2001:db8::1").AsSlice()...)
+	ipv6 = append(ipv6, 0x08, 0x07, 0x08, 0x07)
+	cases := []struct {
+		Pos      helpers.Pos
+		Payload  []byte
+		Expected netip.Addr
+		Length   int
+		Error    bool
+	}{
+		{
+			Pos:     helpers.Mark(),
+			Payload: []byte{0, 9, 0, 1},
+		}, {
+			Pos:      helpers.Mark(),
+			Payload:  append(proxyHeader(1, 1, ipv4), 0, 9),
+			Expected: netip.MustParseAddr("192.0.2.10"),
+			Length:   28,
+		}, {
+			Pos:      helpers.Mark(),
+			Payload:  append(proxyHeader(1, 2, ipv6), 0, 10),
+			Expected: netip.MustParseAddr("2001:db8::10"),
+			Length:   52,
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: append(proxyHeader(0, 0, nil), 0, 9),
+			Length:  16,
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: proxyHeader(1, 1, ipv4)[:20],
+			Error:   true,
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: proxyHeader(1, 2, ipv4),
+			Error:   true,
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: proxyHeader(2, 1, ipv4),
+			Error:   true,
+		},
+	}
+	for _, tc := range cases {
+		got, length, err := parseProxyHeader(tc.Payload)
+		if err != nil && !tc.Error {
+			t.Errorf("%sparseProxyHeader() error:\n%+v", tc.Pos, err)
+			continue
+		} else if err == nil && tc.Error {
+			t.Errorf("%sparseProxyHeader() did not error", tc.Pos)
+			continue
+		}
+		if got != tc.Expected || length != tc.Length {
+			t.Errorf("%sparseProxyHeader() == %s, %d but expected %s, %d",
+				tc.Pos, got, length, tc.Expected, tc.Length)
+		}
+	}
+}
+
+func TestUDPInputProxyProtocol(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.ProxyProtocolSources = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	received := make(chan *pb.RawFlow, 10)
+	send := func(_ string, got *pb.RawFlow) {
+		received <- &pb.RawFlow{
+			SourceAddress: got.SourceAddress,
+			Payload:       append([]byte{}, got.Payload...),
+		}
+	}
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+	conn, err := net.Dial("udp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+
+	ipv4 := []byte{192, 0, 2, 10, 127, 0, 0, 1, 0x08, 0x07, 0x08, 0x07}
+	for _, packet := range [][]byte{
+		append(proxyHeader(1, 1, ipv4), []byte("hello world!")...),
+		[]byte("hello world!"),
+		proxyHeader(1, 1, ipv4)[:20],
+		append(proxyHeader(0, 0, nil), []byte("hello world!")...),
+	} {
+		if _, err := conn.Write(packet); err != nil {
+			t.Fatalf("Write() error:\n%+v", err)
+		}
+	}
+
+	expected := []*pb.RawFlow{
+		{SourceAddress: net.ParseIP("192.0.2.10").To16(), Payload: []byte("hello world!")},
+		{SourceAddress: net.ParseIP("127.0.0.1").To16(), Payload: []byte("hello world!")},
+		{SourceAddress: net.ParseIP("127.0.0.1").To16(), Payload: []byte("hello world!")},
+	}
+	for idx := range expected {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("no flow %d received", idx)
+		case got := <-received:
+			if diff := helpers.Diff(got, expected[idx]); diff != "" {
+				t.Errorf("Input data %d (-got, +want):\n%s", idx, diff)
+			}
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_udp_", "packets_total", "errors_total")
+	expectedMetrics := map[string]string{
+		`packets_total{exporter="192.0.2.10",listener="127.0.0.1:0",worker="0"}`: "1",
+		`packets_total{exporter="127.0.0.1",listener="127.0.0.1:0",worker="0"}`:  "2",
+		`errors_total{listener="127.0.0.1:0",worker="0"}`:                        "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -10,12 +10,14 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"gopkg.in/tomb.v2"
 
 	"akvorado/common/daemon"
+	"akvorado/common/helpers"
 	"akvorado/common/pb"
 	"akvorado/common/reporter"
 	"akvorado/inlet/flow/input"
@@ -37,8 +39,19 @@ type Input struct {
 		ebpf          reporter.Gauge
 	}
 
-	address net.Addr       // listening address, for testing purpoese
-	send    input.SendFunc // function to send to kafka
+	listeners   []*listener
+	address     net.Addr       // listening address, for testing purpoese
+	send        input.SendFunc // function to send to kafka
+	sockets     []socket       // sockets to watch for kernel drops
+	lastDrops   uint64         // total kernel drops at the last poll
+	recentDrops atomic.Uint64  // kernel drops during the last poll
+}
+
+// listener is a port to listen to. Each listener gets its own set of sockets.
+type listener struct {
+	listen  string             // address to listen to, as used in metrics
+	decoder pb.RawFlow_Decoder // decoder to use, if not the one of the input
+	address net.Addr           // actual listening address
 }
 
 var (
@@ -105,149 +118,246 @@ func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Compo
 	)
 	input.metrics.ebpf.Set(0)
 
+	// Build the list of listeners
+	host, port, err := net.SplitHostPort(configuration.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", configuration.Listen, err)
+	}
+	mainPort, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen port %q: %w", port, err)
+	}
+	ports := map[uint16]bool{uint16(mainPort): true}
+	input.listeners = []*listener{{
+		listen:  configuration.Listen,
+		decoder: configuration.Decoders[uint16(mainPort)],
+	}}
+	for _, portRange := range configuration.Ports {
+		start, end, err := helpers.ParsePortRange(portRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port range %q: %w", portRange, err)
+		}
+		for p := int(start); p <= int(end); p++ {
+			port := uint16(p)
+			if ports[port] {
+				return nil, fmt.Errorf("port %d is listed twice", port)
+			}
+			ports[port] = true
+			input.listeners = append(input.listeners, &listener{
+				listen:  net.JoinHostPort(host, strconv.Itoa(p)),
+				decoder: configuration.Decoders[port],
+			})
+		}
+	}
+	for port := range configuration.Decoders {
+		if !ports[port] {
+			return nil, fmt.Errorf("decoder set for port %d which is not listened to", port)
+		}
+	}
+
 	daemon.Track(&input.t, "inlet/flow/input/udp")
 	return input, nil
 }
 
-// Start starts listening to the provided UDP socket and producing flows.
+// Start starts listening to the provided UDP sockets and producing flows.
 func (in *Input) Start() error {
 	in.r.Info().Str("listen", in.config.Listen).Msg("starting UDP input")
 
-	// Listen to UDP port
+	// Listen to UDP ports
+	conns := []*net.UDPConn{}
+	ebpf := true
+	for _, l := range in.listeners {
+		lconns, fds, err := in.listen(l)
+		conns = append(conns, lconns...)
+		if err != nil {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			return err
+		}
+		if err := setupReuseportEBPF(fds); err != nil {
+			in.r.Warn().Err(err).Str("listen", l.listen).Msg("cannot attach eBPF program for SO_REUSEPORT")
+			ebpf = false
+		}
+		for i, conn := range lconns {
+			in.startWorker(l, i, conn)
+		}
+	}
+	in.address = in.listeners[0].address
+	if ebpf {
+		in.metrics.ebpf.Set(1)
+	} else {
+		in.metrics.ebpf.Set(0)
+	}
+
+	// Watch kernel drop counters
+	in.watchDrops()
+
+	// Watch for termination and close on dying
+	in.t.Go(func() error {
+		<-in.t.Dying()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		cleanupReuseportEBPF()
+		return nil
+	})
+
+	return nil
+}
+
+// listen creates the sockets for the provided listener, one for each worker.
+func (in *Input) listen(l *listener) ([]*net.UDPConn, []uintptr, error) {
 	conns := []*net.UDPConn{}
 	fds := []uintptr{}
+	requested := int(in.config.ReceiveBuffer)
+	if in.config.ReceiveBufferAutotune {
+		size, err := maxReceiveBuffer()
+		if err != nil {
+			in.r.Warn().Err(err).Str("listen", l.listen).Msg("cannot get maximum receive buffer size")
+		} else {
+			requested = size
+		}
+	}
 	for i := range in.config.Workers {
 		var listenAddr net.Addr
-		if in.address != nil {
+		if l.address != nil {
 			// We already are listening on one address, let's
 			// listen to the same (useful when using :0).
-			listenAddr = in.address
+			listenAddr = l.address
 		} else {
 			var err error
-			listenAddr, err = net.ResolveUDPAddr("udp", in.config.Listen)
+			listenAddr, err = net.ResolveUDPAddr("udp", l.listen)
 			if err != nil {
-				return fmt.Errorf("unable to resolve %v: %w", in.config.Listen, err)
+				return conns, fds, fmt.Errorf("unable to resolve %v: %w", l.listen, err)
 			}
 		}
 		pconn, err := listenConfig(in.r, udpSocketOptions, &fds).
 			ListenPacket(in.t.Context(context.Background()), "udp", listenAddr.String())
 		if err != nil {
-			return fmt.Errorf("unable to listen to %v: %w", listenAddr, err)
+			return conns, fds, fmt.Errorf("unable to listen to %v: %w", listenAddr, err)
 		}
 		udpConn := pconn.(*net.UDPConn)
-		in.address = udpConn.LocalAddr()
+		conns = append(conns, udpConn)
+		l.address = udpConn.LocalAddr()
 		if i == 0 {
-			in.r.Info().Str("listen", in.address.String()).Msg("UDP input listening")
+			in.r.Info().Str("listen", l.address.String()).Msg("UDP input listening")
 		}
 
 		// Set/get buffer size
-		if in.config.ReceiveBuffer > 0 {
-			if err := udpConn.SetReadBuffer(int(in.config.ReceiveBuffer)); err != nil {
+		if requested > 0 {
+			if err := udpConn.SetReadBuffer(requested); err != nil {
 				// On Linux, this does not trigger an error when we are above net.core.rmem_max.
 				in.r.Warn().
 					Str("error", err.Error()).
-					Str("listen", in.config.Listen).
-					Msgf("unable to set requested buffer size (%d bytes)", in.config.ReceiveBuffer)
+					Str("listen", l.listen).
+					Msgf("unable to set requested buffer size (%d bytes)", requested)
 			}
 		}
 		if syscallConn, err := udpConn.SyscallConn(); err == nil {
 			var actualSize int
+			var inode uint64
 			syscallConn.Control(func(fd uintptr) {
 				if val, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF); err == nil {
 					actualSize = val
 				}
+				if val, err := socketInode(fd); err == nil {
+					inode = val
+				}
 			})
-			in.metrics.bufferSize.WithLabelValues(in.config.Listen, strconv.Itoa(i)).Set(float64(actualSize))
-			if in.config.ReceiveBuffer > 0 && actualSize < int(in.config.ReceiveBuffer) {
+			in.metrics.bufferSize.WithLabelValues(l.listen, strconv.Itoa(i)).Set(float64(actualSize))
+			if inode != 0 {
+				in.sockets = append(in.sockets, socket{
+					listen: l.listen,
+					worker: strconv.Itoa(i),
+					inode:  inode,
+				})
+			}
+			if requested > 0 && actualSize < requested {
 				in.r.Warn().
-					Str("listen", in.config.Listen).
-					Int("requested", int(in.config.ReceiveBuffer)).
+					Str("listen", l.listen).
+					Int("requested", requested).
 					Int("actual", actualSize).
 					Msg("UDP receive buffer size was capped by system limits (check net.core.rmem_max)")
 			}
 		}
-
-		conns = append(conns, udpConn)
 	}
+	return conns, fds, nil
+}
 
-	if err := setupReuseportEBPF(fds); err == nil {
-		in.metrics.ebpf.Set(1)
-	} else {
-		in.r.Warn().Err(err).Msg("cannot attach eBPF program for SO_REUSEPORT")
-		in.metrics.ebpf.Set(0)
-	}
+// startWorker starts a worker receiving packets from the provided socket.
+func (in *Input) startWorker(l *listener, workerID int, conn *net.UDPConn) {
+	worker := strconv.Itoa(workerID)
+	in.t.Go(func() error {
+		payload := make([]byte, 9000)
+		oob := make([]byte, oobLength)
+		flow := pb.RawFlow{}
+		listen := l.listen
+		logger := in.r.With().
+			Str("worker", worker).
+			Str("listen", listen).
+			Logger()
+		dying := in.t.Dying()
+		errLogger := logger.Sample(reporter.BurstSampler(time.Minute, 1))
+		for count := 0; ; count++ {
+			n, oobn, _, source, err := conn.ReadMsgUDP(payload, oob)
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				errLogger.Err(err).Msg("unable to receive UDP packet")
+				in.metrics.errors.WithLabelValues(listen, worker).Inc()
+				continue
+			}
 
-	for i := range in.config.Workers {
-		workerID := i
-		worker := strconv.Itoa(i)
-		in.t.Go(func() error {
-			payload := make([]byte, 9000)
-			oob := make([]byte, oobLength)
-			flow := pb.RawFlow{}
-			listen := in.config.Listen
-			l := in.r.With().
-				Str("worker", worker).
-				Str("listen", listen).
-				Logger()
-			dying := in.t.Dying()
-			errLogger := l.Sample(reporter.BurstSampler(time.Minute, 1))
-			for count := 0; ; count++ {
-				n, oobn, _, source, err := conns[workerID].ReadMsgUDP(payload, oob)
+			oobMsg, err := parseSocketControlMessage(oob[:oobn])
+			if err != nil {
+				errLogger.Err(err).Msg("unable to decode UDP control message")
+			} else {
+				in.metrics.inDrops.WithLabelValues(listen, worker).Set(
+					float64(oobMsg.Drops))
+			}
+			if oobMsg.Received.IsZero() {
+				oobMsg.Received = time.Now()
+			}
+
+			srcIP := source.IP.String()
+			srcAddress := source.IP.To16()
+			start := 0
+			if in.fromProxy(source.AddrPort().Addr()) {
+				original, length, err := parseProxyHeader(payload[:n])
 				if err != nil {
-					if errors.Is(err, net.ErrClosed) {
-						return nil
-					}
-					errLogger.Err(err).Msg("unable to receive UDP packet")
+					errLogger.Err(err).Str("source", srcIP).Msg("unable to decode PROXY header")
 					in.metrics.errors.WithLabelValues(listen, worker).Inc()
 					continue
 				}
-
-				oobMsg, err := parseSocketControlMessage(oob[:oobn])
-				if err != nil {
-					errLogger.Err(err).Msg("unable to decode UDP control message")
-				} else {
-					in.metrics.inDrops.WithLabelValues(listen, worker).Set(
-						float64(oobMsg.Drops))
-				}
-				if oobMsg.Received.IsZero() {
-					oobMsg.Received = time.Now()
-				}
-
-				srcIP := source.IP.String()
-				in.metrics.bytes.WithLabelValues(listen, worker, srcIP).
-					Add(float64(n))
-				in.metrics.packets.WithLabelValues(listen, worker, srcIP).
-					Inc()
-				in.metrics.packetSizeSum.WithLabelValues(listen, worker, srcIP).
-					Observe(float64(n))
-
-				flow.Reset()
-				flow.TimeReceived = uint64(oobMsg.Received.Unix())
-				flow.Payload = payload[:n]
-				flow.SourceAddress = source.IP.To16()
-				in.send(srcIP, &flow)
-
-				select {
-				case <-dying:
-					return nil
-				default:
+				start = length
+				if original.IsValid() {
+					srcIP = original.String()
+					srcAddress = net.IP(original.AsSlice()).To16()
 				}
 			}
-		})
+			in.metrics.bytes.WithLabelValues(listen, worker, srcIP).
+				Add(float64(n - start))
+			in.metrics.packets.WithLabelValues(listen, worker, srcIP).
+				Inc()
+			in.metrics.packetSizeSum.WithLabelValues(listen, worker, srcIP).
+				Observe(float64(n - start))
 
-	}
+			flow.Reset()
+			flow.TimeReceived = uint64(oobMsg.Received.Unix())
+			flow.Payload = payload[start:n]
+			flow.SourceAddress = srcAddress
+			flow.Decoder = l.decoder
+			in.send(srcIP, &flow)
 
-	// Watch for termination and close on dying
-	in.t.Go(func() error {
-		<-in.t.Dying()
-		for _, conn := range conns {
-			conn.Close()
+			select {
+			case <-dying:
+				return nil
+			default:
+			}
 		}
-		cleanupReuseportEBPF()
-		return nil
 	})
-
-	return nil
 }
 
 // Stop stops the UDP listeners
@@ -115,6 +115,27 @@ func TestUDPReceiveBuffer(t *testing.T) {
 	if bufferSize2 < bufferSize1 {
 		t.Fatalf("Buffer size was unchanged (%f <= %f)", bufferSize1, bufferSize2)
 	}
+
+	// With autotuning
+	maxSize, err := maxReceiveBuffer()
+	if err != nil {
+		t.Skipf("maxReceiveBuffer() error:\n%+v", err)
+	}
+	r = reporter.NewMock(t)
+	configuration = DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.ReceiveBufferAutotune = true
+	in, err = configuration.New(r, daemon.NewMock(t), func(string, *pb.RawFlow) {})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+	gotMetrics = r.GetMetrics("akvorado_inlet_flow_input_udp_", "buffer_size")
+	bufferSize = gotMetrics[`buffer_size_bytes{listener="127.0.0.1:0",worker="0"}`]
+	bufferSize3, _ := strconv.ParseFloat(bufferSize, 32)
+	if bufferSize3 < float64(maxSize) {
+		t.Fatalf("Buffer size was not autotuned (%f < %d)", bufferSize3, maxSize)
+	}
 }
 
 func TestUDPWorkerBalancing(t *testing.T) {
@@ -193,3 +214,87 @@ func TestUDPWorkerBalancing(t *testing.T) {
 		}
 	}
 }
+
+func TestUDPMultiplePorts(t *testing.T) {
+	// Find a free port
+	pconn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error:\n%+v", err)
+	}
+	port := pconn.LocalAddr().(*net.UDPAddr).Port
+	pconn.Close()
+
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.Ports = []string{strconv.Itoa(port)}
+	configuration.Decoders = map[uint16]pb.RawFlow_Decoder{
+		uint16(port): pb.RawFlow_DECODER_SFLOW,
+	}
+
+	got := make(chan pb.RawFlow_Decoder, 2)
+	in, err := configuration.New(r, daemon.NewMock(t), func(_ string, flow *pb.RawFlow) {
+		got <- flow.Decoder
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	for _, address := range []string{
+		in.(*Input).address.String(),
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+	} {
+		conn, err := net.Dial("udp", address)
+		if err != nil {
+			t.Fatalf("Dial() error:\n%+v", err)
+		}
+		if _, err := conn.Write([]byte("hello world!")); err != nil {
+			t.Fatalf("Write() error:\n%+v", err)
+		}
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("no flow received from %s", address)
+		case decoder := <-got:
+			expected := pb.RawFlow_DECODER_UNSPECIFIED
+			if address != in.(*Input).address.String() {
+				expected = pb.RawFlow_DECODER_SFLOW
+			}
+			if decoder != expected {
+				t.Errorf("Decoder for %s == %s, expected %s", address, decoder, expected)
+			}
+		}
+	}
+}
+
+func TestUDPPortsConfiguration(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Ports    []string
+		Decoders map[uint16]pb.RawFlow_Decoder
+		Error    bool
+	}{
+		{helpers.Mark(), []string{"2056", "9995-9999"}, nil, false},
+		{helpers.Mark(), []string{"9995-9999"}, map[uint16]pb.RawFlow_Decoder{
+			2055: pb.RawFlow_DECODER_NETFLOW,
+			9997: pb.RawFlow_DECODER_SFLOW,
+		}, false},
+		{helpers.Mark(), []string{"2055"}, nil, true},
+		{helpers.Mark(), []string{"9995-9999", "9999"}, nil, true},
+		{helpers.Mark(), []string{"9995-9999"}, map[uint16]pb.RawFlow_Decoder{
+			6343: pb.RawFlow_DECODER_SFLOW,
+		}, true},
+	}
+	for _, tc := range cases {
+		configuration := DefaultConfiguration().(*Configuration)
+		configuration.Listen = "127.0.0.1:2055"
+		configuration.Ports = tc.Ports
+		configuration.Decoders = tc.Decoders
+		_, err := configuration.New(reporter.NewMock(t), daemon.NewMock(t), func(string, *pb.RawFlow) {})
+		if err != nil && !tc.Error {
+			t.Errorf("%sNew() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%sNew() did not error", tc.Pos)
+		}
+	}
+}
@@ -6,6 +6,10 @@
 package udp
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 	"unsafe"
@@ -49,3 +53,31 @@ func parseSocketControlMessage(b []byte) (oobMessage, error) {
 	}
 	return result, nil
 }
+
+// maxReceiveBuffer returns the maximum receive buffer size allowed by the
+// kernel (net.core.rmem_max).
+func maxReceiveBuffer() (int, error) {
+	content, err := os.ReadFile("/proc/sys/net/core/rmem_max")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+// socketDrops returns the number of dropped packets for each UDP socket,
+// indexed by inode.
+func socketDrops() (map[uint64]uint64, error) {
+	drops := map[uint64]uint64{}
+	for _, path := range []string{"/proc/net/udp", "/proc/net/udp6"} {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = parseProcNetUDP(f, drops)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+		}
+	}
+	return drops, nil
+}
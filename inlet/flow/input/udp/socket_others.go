@@ -27,3 +27,13 @@ func setupReuseportEBPF([]uintptr) error {
 // cleanupReuseportEBPF is a no-op on non-Linux platforms
 func cleanupReuseportEBPF() {
 }
+
+// maxReceiveBuffer is not supported on non-Linux platforms
+func maxReceiveBuffer() (int, error) {
+	return 0, errors.New("maximum receive buffer size not available on this platform")
+}
+
+// socketDrops is not supported on non-Linux platforms
+func socketDrops() (map[uint64]uint64, error) {
+	return nil, errors.New("kernel drop counters not available on this platform")
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package unix
+
+import "akvorado/inlet/flow/input"
+
+// Configuration describes Unix socket input configuration.
+type Configuration struct {
+	// Listen is the path of the Unix datagram socket to create. Each datagram
+	// should be prefixed by the exporter IP address.
+	Listen string `validate:"required_without=Systemd,excluded_with=Systemd"`
+	// Systemd tells to use the sockets passed by systemd with socket
+	// activation instead. They can be UDP or Unix datagram sockets.
+	Systemd bool
+	// SystemdName restricts the sockets passed by systemd to the ones with
+	// this name (set with FileDescriptorName=).
+	SystemdName string `validate:"excluded_without=Systemd"`
+}
+
+// DefaultConfiguration is the default configuration for this input.
+func DefaultConfiguration() input.Configuration {
+	return &Configuration{}
+}
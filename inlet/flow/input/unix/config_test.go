@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package unix
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestConfigurationValidation(t *testing.T) {
+	cases := []struct {
+		Pos    helpers.Pos
+		Config Configuration
+		Error  bool
+	}{
+		{helpers.Mark(), Configuration{}, true},
+		{helpers.Mark(), Configuration{Listen: "/run/akvorado/flows.sock"}, false},
+		{helpers.Mark(), Configuration{Systemd: true}, false},
+		{helpers.Mark(), Configuration{Systemd: true, SystemdName: "netflow"}, false},
+		{helpers.Mark(), Configuration{Listen: "/run/akvorado/flows.sock", Systemd: true}, true},
+		{helpers.Mark(), Configuration{Listen: "/run/akvorado/flows.sock", SystemdName: "netflow"}, true},
+	}
+	for _, tc := range cases {
+		err := helpers.Validate.Struct(tc.Config)
+		if err != nil && !tc.Error {
+			t.Errorf("%sValidate() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%sValidate() did not error", tc.Pos)
+		}
+	}
+}
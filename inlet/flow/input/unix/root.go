@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package unix handles datagrams received on Unix sockets or on sockets passed
+// by systemd. This enables a privileged process to own the listening port and
+// hand the datagrams to an unprivileged inlet.
+package unix
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+// headerLength is the length of the header prefixing each datagram received on
+// a Unix socket. It contains the exporter IP address, as an IPv6 address.
+const headerLength = 16
+
+// Input represents the state of a Unix socket input.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config Configuration
+
+	metrics struct {
+		bytes   *reporter.CounterVec
+		packets *reporter.CounterVec
+		errors  *reporter.CounterVec
+	}
+
+	send input.SendFunc // function to send to kafka
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// New instantiate a new Unix socket listener from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	if configuration.Listen == "" && !configuration.Systemd {
+		return nil, errors.New("no path provided for Unix socket input")
+	}
+	input := &Input{
+		r:      r,
+		config: configuration,
+		send:   send,
+	}
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.packets = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "packets_total",
+			Help: "Packets received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving packets by the application.",
+		},
+		[]string{"listener", "error"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/unix")
+	return input, nil
+}
+
+// Start starts listening to the Unix socket or to the sockets passed by
+// systemd and producing flows.
+func (in *Input) Start() error {
+	var conns []net.PacketConn
+	var listeners []string
+	if in.config.Systemd {
+		in.r.Info().Str("name", in.config.SystemdName).Msg("starting systemd socket input")
+		files, err := openSystemdFiles(in.config.SystemdName)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			conn, err := net.FilePacketConn(file)
+			file.Close()
+			if err != nil {
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return fmt.Errorf("socket %q passed by systemd is not a datagram socket: %w",
+					file.Name(), err)
+			}
+			conns = append(conns, conn)
+			listeners = append(listeners, conn.LocalAddr().String())
+		}
+	} else {
+		in.r.Info().Str("listen", in.config.Listen).Msg("starting Unix socket input")
+		// Remove a stale socket from a previous run.
+		if info, err := os.Lstat(in.config.Listen); err == nil && info.Mode().Type() == fs.ModeSocket {
+			os.Remove(in.config.Listen)
+		}
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: in.config.Listen, Net: "unixgram"})
+		if err != nil {
+			return fmt.Errorf("unable to listen to %s: %w", in.config.Listen, err)
+		}
+		conns = append(conns, conn)
+		listeners = append(listeners, in.config.Listen)
+	}
+	for idx, conn := range conns {
+		in.r.Info().Str("listen", listeners[idx]).Msg("Unix socket input listening")
+		in.startWorker(listeners[idx], conn)
+	}
+
+	// Watch for termination and close on dying
+	in.t.Go(func() error {
+		<-in.t.Dying()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		if in.config.Listen != "" {
+			os.Remove(in.config.Listen)
+		}
+		return nil
+	})
+	return nil
+}
+
+// startWorker starts a worker receiving packets from the provided socket.
+// Packets received on a Unix socket are expected to be prefixed by the
+// exporter address.
+func (in *Input) startWorker(listen string, conn net.PacketConn) {
+	_, withHeader := conn.(*net.UnixConn)
+	in.t.Go(func() error {
+		payload := make([]byte, 9000+headerLength)
+		flow := pb.RawFlow{}
+		logger := in.r.With().Str("listen", listen).Logger()
+		errLogger := logger.Sample(reporter.BurstSampler(time.Minute, 1))
+		dying := in.t.Dying()
+		for {
+			n, source, err := conn.ReadFrom(payload)
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				errLogger.Err(err).Msg("unable to receive packet")
+				in.metrics.errors.WithLabelValues(listen, "cannot receive").Inc()
+				continue
+			}
+			received := time.Now()
+
+			var ip netip.Addr
+			data := payload[:n]
+			if withHeader {
+				if n < headerLength {
+					errLogger.Error().Int("size", n).Msg("packet too short")
+					in.metrics.errors.WithLabelValues(listen, "packet too short").Inc()
+					continue
+				}
+				ip = netip.AddrFrom16([16]byte(data[:headerLength]))
+				data = data[headerLength:]
+			} else if udpAddr, ok := source.(*net.UDPAddr); ok {
+				ip = udpAddr.AddrPort().Addr()
+			} else {
+				errLogger.Error().Msg("unknown source address")
+				in.metrics.errors.WithLabelValues(listen, "unknown source address").Inc()
+				continue
+			}
+			exporter := ip.Unmap().String()
+			in.metrics.bytes.WithLabelValues(listen, exporter).Add(float64(len(data)))
+			in.metrics.packets.WithLabelValues(listen, exporter).Inc()
+
+			address := ip.As16()
+			flow.Reset()
+			flow.TimeReceived = uint64(received.Unix())
+			flow.Payload = data
+			flow.SourceAddress = address[:]
+			in.send(exporter, &flow)
+
+			select {
+			case <-dying:
+				return nil
+			default:
+			}
+		}
+	})
+}
+
+// Stop stops the Unix socket listeners.
+func (in *Input) Stop() error {
+	defer in.r.Info().Msg("Unix socket input stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package unix
+
+import (
+	"net"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+func TestUnixInput(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = filepath.Join(t.TempDir(), "flows.sock")
+
+	done := make(chan bool)
+	exporter := netip.MustParseAddr("::ffff:192.0.2.1").As16()
+	expected := &pb.RawFlow{
+		SourceAddress: exporter[:],
+		Payload:       []byte("hello world!"),
+	}
+	send := func(gotExporter string, got *pb.RawFlow) {
+		expected.TimeReceived = got.TimeReceived
+		if gotExporter != "192.0.2.1" {
+			t.Errorf("Exporter: got %q, expected %q", gotExporter, "192.0.2.1")
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("Input data (-got, +want):\n%s", diff)
+		}
+		close(done)
+	}
+
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: configuration.Listen, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("DialUnix() error:\n%+v", err)
+	}
+	defer conn.Close()
+
+	// Send a too short packet, then a valid one
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	if _, err := conn.Write(append(exporter[:], []byte("hello world!")...)); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("no decoded flows received")
+	case <-done:
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_unix_")
+	expectedMetrics := map[string]string{
+		`bytes_total{exporter="192.0.2.1",listener="` + configuration.Listen + `"}`:      "12",
+		`packets_total{exporter="192.0.2.1",listener="` + configuration.Listen + `"}`:    "1",
+		`errors_total{error="packet too short",listener="` + configuration.Listen + `"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
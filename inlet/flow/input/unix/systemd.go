@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package unix
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd.
+const listenFdsStart = 3
+
+// systemdFile is a file descriptor passed by systemd.
+type systemdFile struct {
+	fd   int
+	name string
+}
+
+// systemdFiles parses the environment variables set by systemd for socket
+// activation and returns the file descriptors with the provided name (or all
+// of them if the name is empty).
+func systemdFiles(getenv func(string) string, pid int, name string) ([]systemdFile, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, fmt.Errorf("no socket passed by systemd to process %d", pid)
+	}
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid number of sockets passed by systemd %q", getenv("LISTEN_FDS"))
+	}
+	names := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+	files := []systemdFile{}
+	for idx := range count {
+		file := systemdFile{fd: listenFdsStart + idx, name: "unknown"}
+		if idx < len(names) && names[idx] != "" {
+			file.name = names[idx]
+		}
+		if name != "" && file.name != name {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no socket named %q passed by systemd", name)
+	}
+	return files, nil
+}
+
+// openSystemdFiles returns the files for the sockets passed by systemd to
+// this process.
+func openSystemdFiles(name string) ([]*os.File, error) {
+	files, err := systemdFiles(os.Getenv, os.Getpid(), name)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*os.File, 0, len(files))
+	for _, file := range files {
+		syscall.CloseOnExec(file.fd)
+		result = append(result, os.NewFile(uintptr(file.fd), file.name))
+	}
+	return result, nil
+}
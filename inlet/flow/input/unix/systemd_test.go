@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package unix
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"akvorado/common/helpers"
+)
+
+func TestSystemdFiles(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Env      map[string]string
+		Name     string
+		Expected []systemdFile
+		Error    bool
+	}{
+		{
+			Pos:   helpers.Mark(),
+			Env:   map[string]string{},
+			Error: true,
+		}, {
+			Pos:   helpers.Mark(),
+			Env:   map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"},
+			Error: true,
+		}, {
+			Pos:   helpers.Mark(),
+			Env:   map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "0"},
+			Error: true,
+		}, {
+			Pos:      helpers.Mark(),
+			Env:      map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "1"},
+			Expected: []systemdFile{{fd: 3, name: "unknown"}},
+		}, {
+			Pos: helpers.Mark(),
+			Env: map[string]string{
+				"LISTEN_PID":     "100",
+				"LISTEN_FDS":     "3",
+				"LISTEN_FDNAMES": "netflow:sflow:netflow",
+			},
+			Expected: []systemdFile{
+				{fd: 3, name: "netflow"},
+				{fd: 4, name: "sflow"},
+				{fd: 5, name: "netflow"},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Env: map[string]string{
+				"LISTEN_PID":     "100",
+				"LISTEN_FDS":     "3",
+				"LISTEN_FDNAMES": "netflow:sflow:netflow",
+			},
+			Name: "netflow",
+			Expected: []systemdFile{
+				{fd: 3, name: "netflow"},
+				{fd: 5, name: "netflow"},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Env: map[string]string{
+				"LISTEN_PID":     "100",
+				"LISTEN_FDS":     "1",
+				"LISTEN_FDNAMES": "sflow",
+			},
+			Name:  "netflow",
+			Error: true,
+		},
+	}
+	for _, tc := range cases {
+		got, err := systemdFiles(func(key string) string { return tc.Env[key] }, 100, tc.Name)
+		if err != nil && !tc.Error {
+			t.Errorf("%ssystemdFiles() error:\n%+v", tc.Pos, err)
+			continue
+		} else if err == nil && tc.Error {
+			t.Errorf("%ssystemdFiles() did not error", tc.Pos)
+			continue
+		}
+		if diff := helpers.Diff(got, tc.Expected, cmp.AllowUnexported(systemdFile{})); diff != "" {
+			t.Errorf("%ssystemdFiles() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
@@ -34,7 +34,7 @@ type Component struct {
 type Dependencies struct {
 	Daemon daemon.Component
 	HTTP   *httpserver.Component
-	Kafka  *kafka.Component
+	Kafka  kafka.Sender
 }
 
 // New creates a new flow component.
@@ -78,8 +78,11 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 // Send sends a raw flow to Kafka.
 func (c *Component) Send(config InputConfiguration) input.SendFunc {
 	return func(exporter string, flow *pb.RawFlow) {
+		flow.Version = uint32(pb.Version)
 		flow.TimestampSource = config.TimestampSource
-		flow.Decoder = config.Decoder
+		if flow.Decoder == pb.RawFlow_DECODER_UNSPECIFIED {
+			flow.Decoder = config.Decoder
+		}
 		flow.UseSourceAddress = config.UseSrcAddrForExporterAddr
 
 		// Get a payload from the pool and extend it if needed. We use a pool of
@@ -60,7 +60,7 @@ func TestFlow(t *testing.T) {
 		defer mu.Unlock()
 
 		// Check topic
-		expectedTopic := fmt.Sprintf("flows-v%d", pb.Version)
+		expectedTopic := fmt.Sprintf("flows-v%d", pb.TopicVersion)
 		if record.Topic != expectedTopic {
 			t.Errorf("Expected topic %s, got %s", expectedTopic, record.Topic)
 			return
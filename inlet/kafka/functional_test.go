@@ -24,7 +24,7 @@ import (
 func TestFakeKafka(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
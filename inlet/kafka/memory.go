@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"slices"
+
+	"akvorado/common/reporter"
+)
+
+// MemoryComponent sends flows to an in-memory queue instead of Kafka. It is
+// used when the inlet and the outlet run in the same process.
+type MemoryComponent struct {
+	r       *reporter.Reporter
+	queue   chan<- []byte
+	metrics metrics
+}
+
+// NewMemory creates a new component sending flows to the provided queue. The
+// queue is closed when the component is stopped.
+func NewMemory(r *reporter.Reporter, queue chan<- []byte) *MemoryComponent {
+	return &MemoryComponent{
+		r:       r,
+		queue:   queue,
+		metrics: newMetrics(r),
+	}
+}
+
+// Stop stops the component and closes the queue.
+func (c *MemoryComponent) Stop() error {
+	close(c.queue)
+	c.r.Info().Msg("in-memory producer stopped")
+	return nil
+}
+
+// Send a message to the queue. It blocks when the queue is full.
+func (c *MemoryComponent) Send(exporter string, payload []byte, finalizer func()) {
+	c.queue <- slices.Clone(payload)
+	c.metrics.bytesSent.WithLabelValues(exporter).Add(float64(len(payload)))
+	c.metrics.messagesSent.WithLabelValues(exporter).Inc()
+	finalizer()
+}
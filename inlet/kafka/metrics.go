@@ -13,26 +13,28 @@ type metrics struct {
 	errors       *reporter.CounterVec
 }
 
-func (c *Component) initMetrics() {
-	c.metrics.messagesSent = c.r.CounterVec(
+func newMetrics(r *reporter.Reporter) metrics {
+	var m metrics
+	m.messagesSent = r.CounterVec(
 		reporter.CounterOpts{
 			Name: "sent_messages_total",
 			Help: "Number of messages sent from a given exporter.",
 		},
 		[]string{"exporter"},
 	)
-	c.metrics.bytesSent = c.r.CounterVec(
+	m.bytesSent = r.CounterVec(
 		reporter.CounterOpts{
 			Name: "sent_bytes_total",
 			Help: "Number of bytes sent from a given exporter.",
 		},
 		[]string{"exporter"},
 	)
-	c.metrics.errors = c.r.CounterVec(
+	m.errors = r.CounterVec(
 		reporter.CounterOpts{
 			Name: "errors_total",
 			Help: "Number of errors when sending.",
 		},
 		[]string{"error"},
 	)
+	return m
 }
@@ -17,10 +17,14 @@ import (
 
 	"akvorado/common/daemon"
 	"akvorado/common/kafka"
-	"akvorado/common/pb"
 	"akvorado/common/reporter"
 )
 
+// Sender is the interface to send flows to the outlet.
+type Sender interface {
+	Send(exporter string, payload []byte, finalizer func())
+}
+
 // Component represents the Kafka exporter.
 type Component struct {
 	r      *reporter.Reporter
@@ -31,6 +35,7 @@ type Component struct {
 	kafkaOpts   []kgo.Opt
 	kafkaTopic  string
 	kafkaClient *kgo.Client
+	envelope    *kafka.Envelope
 	errLogger   reporter.Logger
 	metrics     metrics
 }
@@ -48,14 +53,20 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		return nil, err
 	}
 
+	envelope, err := kafka.NewEnvelope(configuration.Envelope)
+	if err != nil {
+		return nil, err
+	}
+
 	c := Component{
 		r:          r,
 		d:          &dependencies,
 		config:     configuration,
-		kafkaTopic: fmt.Sprintf("%s-v%d", configuration.Topic, pb.Version),
+		kafkaTopic: configuration.FlowsTopic(),
+		envelope:   envelope,
 		errLogger:  r.Sample(reporter.BurstSampler(10*time.Second, 3)),
 	}
-	c.initMetrics()
+	c.metrics = newMetrics(r)
 
 	// Initialize options error to be able to validate them.
 	kafkaOpts = append(kafkaOpts,
@@ -87,10 +98,28 @@ func (c *Component) Start() error {
 	}
 	c.r.RegisterMetricCollector(kafkaMetrics)
 	c.kafkaClient = kafkaClient
+	c.r.RegisterDiagnostics("kafka/producer", func() any {
+		return map[string]any{
+			"buffered-records":     kafkaClient.BufferedProduceRecords(),
+			"buffered-bytes":       kafkaClient.BufferedProduceBytes(),
+			"max-buffered-records": c.config.QueueSize,
+		}
+	})
 
-	// When dying, close the client
+	// When dying, flush buffered messages and close the client
 	c.t.Go(func() error {
 		<-c.t.Dying()
+		c.r.Info().
+			Int64("records", kafkaClient.BufferedProduceRecords()).
+			Msg("flushing Kafka producer")
+		// Allow a small grace time to send buffered messages.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := kafkaClient.Flush(ctx); err != nil {
+			c.r.Err(err).
+				Int64("records", kafkaClient.BufferedProduceRecords()).
+				Msg("unable to flush Kafka producer")
+		}
 		kafkaClient.Close()
 		return nil
 	})
@@ -112,6 +141,13 @@ func (c *Component) Send(exporter string, payload []byte, finalizer func()) {
 		Key:   []byte(exporter),
 		Value: payload,
 	}
+	if c.envelope.Enabled() {
+		// The sealed payload is a copy, the original one can be released.
+		payload, record.Headers = c.envelope.Seal(payload)
+		record.Value = payload
+		finalizer()
+		finalizer = func() {}
+	}
 	c.kafkaClient.Produce(context.Background(), record, func(r *kgo.Record, err error) {
 		if err == nil {
 			c.metrics.bytesSent.WithLabelValues(exporter).Add(float64(len(payload)))
@@ -24,7 +24,7 @@ import (
 
 func TestKafka(t *testing.T) {
 	r := reporter.NewMock(t)
-	topic := fmt.Sprintf("flows-v%d", pb.Version)
+	topic := fmt.Sprintf("flows-v%d", pb.TopicVersion)
 	config := DefaultConfiguration()
 	config.QueueSize = 1
 	c, mock := NewMock(t, r, config)
@@ -126,3 +126,58 @@ func TestKafka(t *testing.T) {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
 	}
 }
+
+func TestKafkaEnvelope(t *testing.T) {
+	r := reporter.NewMock(t)
+	topic := fmt.Sprintf("flows-v%d", pb.TopicVersion)
+	config := DefaultConfiguration()
+	config.Envelope = kafka.EnvelopeConfiguration{
+		Compression:   true,
+		Keys:          map[string]string{"k1": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="},
+		EncryptionKey: "k1",
+	}
+	c, mock := NewMock(t, r, config)
+	defer mock.Close()
+
+	// The payload is released before being sent
+	released := make(chan struct{})
+	c.Send("127.0.0.1", []byte("hello world!"), func() { close(released) })
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("Send() timeout")
+	}
+	c.Flush(t)
+
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(mock.ListenAddrs()...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer consumer.Close()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	fetches := consumer.PollRecords(ctx, 1)
+	if errs := fetches.Errors(); len(errs) > 0 {
+		t.Fatalf("PollRecords() error:\n%+v", errs)
+	}
+	records := fetches.Records()
+	if len(records) != 1 {
+		t.Fatalf("PollRecords() returned %d records, expected 1", len(records))
+	}
+	envelope, err := kafka.NewEnvelope(config.Envelope)
+	if err != nil {
+		t.Fatalf("NewEnvelope() error:\n%+v", err)
+	}
+	got, err := envelope.Open(records[0])
+	if err != nil {
+		t.Fatalf("Open() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(string(got), "hello world!"); diff != "" {
+		t.Fatalf("Open() (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var accessNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateAccessControl checks the names used for access control and the
+// references to quotas.
+func validateAccessControl(config AccessControlConfiguration) error {
+	for _, role := range []string{config.WriterRole, config.ReaderRole} {
+		if !accessNameRegex.MatchString(role) {
+			return fmt.Errorf("invalid name %q for role", role)
+		}
+	}
+	quotas := map[string]bool{}
+	for _, quota := range config.Quotas {
+		if !accessNameRegex.MatchString(quota.Name) {
+			return fmt.Errorf("invalid name %q for quota", quota.Name)
+		}
+		if quotas[quota.Name] {
+			return fmt.Errorf("duplicate quota %q", quota.Name)
+		}
+		quotas[quota.Name] = true
+	}
+	users := map[string]bool{}
+	for _, user := range config.Users {
+		if !accessNameRegex.MatchString(user.Name) {
+			return fmt.Errorf("invalid name %q for user", user.Name)
+		}
+		if users[user.Name] {
+			return fmt.Errorf("duplicate user %q", user.Name)
+		}
+		users[user.Name] = true
+		if user.Quota != "" && !quotas[user.Quota] {
+			return fmt.Errorf("unknown quota %q for user %q", user.Quota, user.Name)
+		}
+	}
+	return nil
+}
+
+// passwordIdentification returns the clause identifying a user with a salted
+// SHA-256 hash of its password. The ALTER USER statement is executed on each
+// start: the salt is derived from the user name and the password instead of
+// being random to keep it idempotent. It is still different for each user and
+// each password.
+func passwordIdentification(name, password string) string {
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write([]byte(name))
+	salt := hex.EncodeToString(mac.Sum(nil)[:16])
+	hash := sha256.Sum256([]byte(password + salt))
+	return fmt.Sprintf("IDENTIFIED WITH sha256_hash BY '%s' SALT '%s'", hex.EncodeToString(hash[:]), salt)
+}
+
+// accessControlStatements returns the statements creating or updating the
+// roles, users and quotas. They can be executed on each start.
+func accessControlStatements(database string, config AccessControlConfiguration) []string {
+	statements := []string{
+		fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s", config.WriterRole),
+		fmt.Sprintf("GRANT INSERT ON %s.* TO %s WITH REPLACE OPTION", database, config.WriterRole),
+		fmt.Sprintf("GRANT SELECT ON system.clusters TO %s", config.WriterRole),
+		fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s", config.ReaderRole),
+		fmt.Sprintf("GRANT SELECT, dictGet ON %s.* TO %s WITH REPLACE OPTION", database, config.ReaderRole),
+	}
+
+	quotaUsers := map[string][]string{}
+	for _, user := range config.Users {
+		role := config.ReaderRole
+		if user.Role == "writer" {
+			role = config.WriterRole
+		}
+		identified := passwordIdentification(user.Name, user.Password)
+		statements = append(statements,
+			fmt.Sprintf("CREATE USER IF NOT EXISTS %s %s", user.Name, identified),
+			fmt.Sprintf("ALTER USER %s %s", user.Name, identified),
+			fmt.Sprintf("GRANT %s TO %s WITH REPLACE OPTION", role, user.Name),
+			fmt.Sprintf("ALTER USER %s DEFAULT ROLE %s", user.Name, role))
+		if user.Quota != "" {
+			quotaUsers[user.Quota] = append(quotaUsers[user.Quota], user.Name)
+		}
+	}
+
+	for _, quota := range config.Quotas {
+		limits := []string{}
+		for _, limit := range []struct {
+			name  string
+			value uint64
+		}{
+			{"queries", quota.Queries},
+			{"errors", quota.Errors},
+			{"result_rows", quota.ResultRows},
+			{"read_rows", quota.ReadRows},
+			{"execution_time", uint64(quota.ExecutionTime.Seconds())},
+		} {
+			if limit.value > 0 {
+				limits = append(limits, fmt.Sprintf("%s = %d", limit.name, limit.value))
+			}
+		}
+		statement := fmt.Sprintf("CREATE QUOTA OR REPLACE %s FOR INTERVAL %d second",
+			quota.Name, uint64(quota.Interval.Seconds()))
+		if len(limits) == 0 {
+			statement = fmt.Sprintf("%s TRACKING ONLY", statement)
+		} else {
+			statement = fmt.Sprintf("%s MAX %s", statement, strings.Join(limits, ", "))
+		}
+		if users := quotaUsers[quota.Name]; len(users) > 0 {
+			statement = fmt.Sprintf("%s TO %s", statement, strings.Join(users, ", "))
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// applyAccessControl creates or updates the configured roles, users and
+// quotas. Users and quotas removed from the configuration are not deleted.
+func (c *Component) applyAccessControl(ctx context.Context) error {
+	config := c.config.AccessControl
+	if len(config.Users) == 0 && len(config.Quotas) == 0 {
+		return nil
+	}
+	for _, statement := range accessControlStatements(c.d.ClickHouse.DatabaseName(), config) {
+		if err := c.migrationExec(ctx, statement); err != nil {
+			// Do not leak the password hash in logs.
+			name, _, _ := strings.Cut(statement, " IDENTIFIED ")
+			return fmt.Errorf("cannot execute %q: %w", name, err)
+		}
+	}
+	c.r.Info().
+		Int("users", len(config.Users)).
+		Int("quotas", len(config.Quotas)).
+		Msg("access control updated")
+	return nil
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestValidateAccessControl(t *testing.T) {
+	cases := []struct {
+		Pos    helpers.Pos
+		Config func(*AccessControlConfiguration)
+		Error  bool
+	}{
+		{helpers.Mark(), func(*AccessControlConfiguration) {}, false},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Users = []UserConfiguration{{Name: "outlet", Password: "secret", Role: "writer"}}
+		}, false},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Quotas = []QuotaConfiguration{{Name: "console", Interval: time.Hour}}
+			c.Users = []UserConfiguration{{Name: "console", Password: "secret", Role: "reader", Quota: "console"}}
+		}, false},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.WriterRole = "akvorado-writer"
+		}, true},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Users = []UserConfiguration{{Name: "console; DROP USER default", Password: "secret", Role: "reader"}}
+		}, true},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Users = []UserConfiguration{
+				{Name: "console", Password: "secret", Role: "reader"},
+				{Name: "console", Password: "secret", Role: "writer"},
+			}
+		}, true},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Users = []UserConfiguration{{Name: "console", Password: "secret", Role: "reader", Quota: "unknown"}}
+		}, true},
+		{helpers.Mark(), func(c *AccessControlConfiguration) {
+			c.Quotas = []QuotaConfiguration{{Name: "console"}, {Name: "console"}}
+		}, true},
+	}
+	for _, tc := range cases {
+		config := DefaultConfiguration().AccessControl
+		tc.Config(&config)
+		err := validateAccessControl(config)
+		if err != nil && !tc.Error {
+			t.Errorf("%svalidateAccessControl() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%svalidateAccessControl() did not error", tc.Pos)
+		}
+	}
+}
+
+func TestAccessControlStatements(t *testing.T) {
+	config := DefaultConfiguration().AccessControl
+	config.Users = []UserConfiguration{
+		{Name: "outlet", Password: "secret", Role: "writer"},
+		{Name: "console", Password: "password", Role: "reader", Quota: "console"},
+	}
+	config.Quotas = []QuotaConfiguration{
+		{Name: "console", Interval: time.Hour, Queries: 1000, ExecutionTime: 10 * time.Minute},
+		{Name: "tracking", Interval: time.Minute},
+	}
+	got := accessControlStatements("default", config)
+	expected := []string{
+		"CREATE ROLE IF NOT EXISTS akvorado_writer",
+		"GRANT INSERT ON default.* TO akvorado_writer WITH REPLACE OPTION",
+		"GRANT SELECT ON system.clusters TO akvorado_writer",
+		"CREATE ROLE IF NOT EXISTS akvorado_reader",
+		"GRANT SELECT, dictGet ON default.* TO akvorado_reader WITH REPLACE OPTION",
+		"CREATE USER IF NOT EXISTS outlet IDENTIFIED WITH sha256_hash BY 'db0fc4b7401f50c9c284b2b75e3d3228c77e20db1ee70a4b893452e1000b09d5' SALT 'f2869474c5e2877c1ac8728dcac6c1b9'",
+		"ALTER USER outlet IDENTIFIED WITH sha256_hash BY 'db0fc4b7401f50c9c284b2b75e3d3228c77e20db1ee70a4b893452e1000b09d5' SALT 'f2869474c5e2877c1ac8728dcac6c1b9'",
+		"GRANT akvorado_writer TO outlet WITH REPLACE OPTION",
+		"ALTER USER outlet DEFAULT ROLE akvorado_writer",
+		"CREATE USER IF NOT EXISTS console IDENTIFIED WITH sha256_hash BY 'e1bed46dbc7d9b148d736641e314822e91774827f23fc973658816fe2f763174' SALT 'f264b1eeaf5249fdf01aa6f843498e28'",
+		"ALTER USER console IDENTIFIED WITH sha256_hash BY 'e1bed46dbc7d9b148d736641e314822e91774827f23fc973658816fe2f763174' SALT 'f264b1eeaf5249fdf01aa6f843498e28'",
+		"GRANT akvorado_reader TO console WITH REPLACE OPTION",
+		"ALTER USER console DEFAULT ROLE akvorado_reader",
+		"CREATE QUOTA OR REPLACE console FOR INTERVAL 3600 second MAX queries = 1000, execution_time = 600 TO console",
+		"CREATE QUOTA OR REPLACE tracking FOR INTERVAL 60 second TRACKING ONLY",
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("accessControlStatements() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestPasswordIdentification(t *testing.T) {
+	// Same user and password: same clause, to keep statements idempotent.
+	if diff := helpers.Diff(passwordIdentification("outlet", "secret"),
+		passwordIdentification("outlet", "secret")); diff != "" {
+		t.Fatalf("passwordIdentification() (-got, +want):\n%s", diff)
+	}
+	// Same password for two users: different salts and hashes.
+	salt := func(clause string) string {
+		_, salt, _ := strings.Cut(clause, " SALT ")
+		return salt
+	}
+	outlet := passwordIdentification("outlet", "secret")
+	console := passwordIdentification("console", "secret")
+	if outlet == console || salt(outlet) == salt(console) {
+		t.Fatalf("passwordIdentification() returns the same salt for two users:\n%s\n%s", outlet, console)
+	}
+}
+
+func TestApplyAccessControlPlan(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, _ := clickhousedb.NewMock(t, r)
+	config := DefaultConfiguration()
+	config.AccessControl.Users = []UserConfiguration{{Name: "outlet", Password: "secret", Role: "writer"}}
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Statements are only recorded when planning
+	plan := migrationPlan{}
+	if err := c.applyAccessControl(context.WithValue(context.Background(), migrationPlanKey{}, &plan)); err != nil {
+		t.Fatalf("applyAccessControl() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(len(plan.Statements),
+		len(accessControlStatements("default", config.AccessControl))); diff != "" {
+		t.Fatalf("applyAccessControl() (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"akvorado/common/remotedatasource"
+	"akvorado/common/schema"
+)
+
+type externalASN struct {
+	ASN  uint32 `validate:"min=1"`
+	Name string `validate:"required"`
+}
+
+// Key returns the key identifying the AS number for incremental updates.
+func (a externalASN) Key() string {
+	return strconv.FormatUint(uint64(a.ASN), 10)
+}
+
+// UpdateASNSource updates a remote ASN source. It returns the number of AS
+// numbers retrieved.
+func (c *Component) UpdateASNSource(ctx context.Context, name string, source remotedatasource.Source) (int, error) {
+	results, err := c.asnSourcesFetcher.Fetch(ctx, name, source)
+	if err != nil {
+		return 0, err
+	}
+	c.asnSourcesLock.Lock()
+	c.asnSources[name] = results
+	c.asnSourcesLock.Unlock()
+	c.triggerASNsReload()
+	return len(results), nil
+}
+
+// asnOverrides returns the AS names overriding the builtin ones. The ones
+// from the configuration take precedence over the ones from remote sources.
+func (c *Component) asnOverrides() map[uint32]string {
+	overrides := map[uint32]string{}
+	c.asnSourcesLock.RLock()
+	names := make([]string, 0, len(c.asnSources))
+	for name := range c.asnSources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, asn := range c.asnSources[name] {
+			overrides[asn.ASN] = asn.Name
+		}
+	}
+	c.asnSourcesLock.RUnlock()
+	for asn, name := range c.config.ASNs {
+		overrides[asn] = name
+	}
+	return overrides
+}
+
+func (c *Component) triggerASNsReload() {
+	select {
+	case c.asnsUpdateChan <- true:
+	default:
+	}
+}
+
+// asnsReloader reloads the ASN dictionary each time a remote source is
+// updated.
+func (c *Component) asnsReloader() {
+	if !c.config.SkipMigrations {
+		select {
+		case <-c.t.Dying():
+			return
+		case <-c.migrationsDone:
+		}
+	}
+	for {
+		select {
+		case <-c.t.Dying():
+			return
+		case <-c.asnsUpdateChan:
+		}
+		func() {
+			ctx, cancel := context.WithTimeout(c.t.Context(nil), time.Minute)
+			defer cancel()
+			c.metrics.asnsReload.Inc()
+			if err := c.ReloadDictionary(ctx, schema.DictionaryASNs); err != nil {
+				c.r.Err(err).Msg("failed to refresh ASN dictionary")
+			}
+		}()
+	}
+}
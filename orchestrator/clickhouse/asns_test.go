@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/remotedatasource"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestASNSources(t *testing.T) {
+	r := reporter.NewMock(t)
+
+	// Setup an HTTP server to serve the JSON
+	mux := http.NewServeMux()
+	mux.Handle("/asns.json", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(`
+{
+  "asns": [
+    {"number": 2, "description": "Delaware"},
+    {"number": 64512, "description": "Private lab"},
+    {"number": 64513, "description": "Private datacenter"}
+  ]
+}
+`))
+	}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	server := &http.Server{
+		Addr:    listener.Addr().String(),
+		Handler: mux,
+	}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	config := DefaultConfiguration()
+	config.SkipMigrations = true
+	config.ASNs = map[uint32]string{
+		64513: "Datacenter",
+	}
+	config.ASNSources = map[string]remotedatasource.Source{
+		"internal": {
+			URL:      fmt.Sprintf("http://%s/asns.json", listener.Addr()),
+			Method:   "GET",
+			Timeout:  20 * time.Millisecond,
+			Interval: 100 * time.Millisecond,
+			Transform: remotedatasource.MustParseTransformQuery(
+				`.asns[] | { asn: .number, name: .description }`),
+		},
+	}
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+		GeoIP:  geoip.NewMock(t, r, false),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+	time.Sleep(50 * time.Millisecond)
+
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL:         "/api/v0/orchestrator/clickhouse/asns.csv",
+			ContentType: "text/csv; charset=utf-8",
+			FirstLines: []string{
+				`asn,name`,
+				`2,Delaware`,
+				`64512,Private lab`,
+				`64513,Datacenter`,
+				`1,Level 3 Communications`,
+				`3,Massachusetts Institute of Technology`,
+			},
+		},
+	})
+
+	gotMetrics := r.GetMetrics("akvorado_common_remotedatasource_data_")
+	expectedMetrics := map[string]string{
+		`total{source="internal",type="asn_source"}`: "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/schema"
+)
+
+// resolutionGap is a time range missing from the table of a resolution while
+// a finer-grained table still has data for it.
+type resolutionGap struct {
+	Table    string        `json:"table"`
+	Interval time.Duration `json:"-"`
+	Source   string        `json:"source"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+}
+
+// resolutionGapsReport is the result of a detection of resolution gaps.
+type resolutionGapsReport struct {
+	Checked time.Time       `json:"checked"`
+	Error   string          `json:"error,omitempty"`
+	Gaps    []resolutionGap `json:"gaps"`
+}
+
+// flowsTableOldest is the oldest flow of a flows table.
+type flowsTableOldest struct {
+	Table  string    `ch:"name"`
+	Oldest time.Time `ch:"oldest"`
+}
+
+// resolutionTable returns the name of the distributed flows table for a
+// resolution.
+func (c *Component) resolutionTable(resolution ResolutionConfiguration) string {
+	if resolution.Interval == 0 {
+		return c.distributedTable("flows")
+	}
+	return c.distributedTable(fmt.Sprintf("flows_%s", resolution.Interval))
+}
+
+// startOfInterval rounds down the provided time to the provided interval, like
+// toStartOfInterval() in ClickHouse.
+func startOfInterval(t time.Time, interval time.Duration) time.Time {
+	seconds := int64(interval.Seconds())
+	return time.Unix(t.Unix()-t.Unix()%seconds, 0).UTC()
+}
+
+// planResolutionGaps returns the gaps of each aggregated table, using the
+// oldest flow of each table. A table can be backfilled from a finer-grained
+// table whose interval divides its own and which does not skip more columns.
+// When several tables are eligible, the one with the oldest data is used. The
+// gap starts at the oldest data of the source, but not before the TTL of the
+// table. It ends after the oldest interval of the table as this one is
+// usually partial. Empty tables are ignored.
+func (c *Component) planResolutionGaps(oldest map[string]time.Time, now time.Time) []resolutionGap {
+	gaps := []resolutionGap{}
+	for idx, resolution := range c.config.Resolutions {
+		if resolution.Interval == 0 {
+			continue
+		}
+		table := c.resolutionTable(resolution)
+		tableOldest, ok := oldest[table]
+		if !ok {
+			continue
+		}
+		var source string
+		var sourceOldest time.Time
+		for _, candidate := range slices.Backward(c.config.Resolutions[:idx]) {
+			if candidate.Interval != 0 && resolution.Interval%candidate.Interval != 0 {
+				continue
+			}
+			if slices.ContainsFunc(candidate.SkipColumns, func(key schema.ColumnKey) bool {
+				return !slices.Contains(resolution.SkipColumns, key)
+			}) {
+				continue
+			}
+			candidateTable := c.resolutionTable(candidate)
+			candidateOldest, ok := oldest[candidateTable]
+			if ok && (source == "" || candidateOldest.Before(sourceOldest)) {
+				source, sourceOldest = candidateTable, candidateOldest
+			}
+		}
+		if source == "" {
+			continue
+		}
+		start := startOfInterval(sourceOldest, resolution.Interval)
+		if resolution.TTL > 0 {
+			if expected := startOfInterval(now.Add(-resolution.TTL), resolution.Interval); start.Before(expected) {
+				start = expected
+			}
+		}
+		end := startOfInterval(tableOldest, resolution.Interval)
+		if !start.Before(end) {
+			continue
+		}
+		end = end.Add(resolution.Interval)
+		gaps = append(gaps, resolutionGap{
+			Table:    table,
+			Interval: resolution.Interval,
+			Source:   source,
+			Start:    start,
+			End:      end,
+		})
+	}
+	return gaps
+}
+
+// detectResolutionGaps detects the gaps in the aggregated tables and records
+// the report.
+func (c *Component) detectResolutionGaps(ctx context.Context) *resolutionGapsReport {
+	report := resolutionGapsReport{
+		Checked: time.Now(),
+		Gaps:    []resolutionGap{},
+	}
+	selects := []string{}
+	for _, resolution := range c.config.Resolutions {
+		selects = append(selects, fmt.Sprintf(
+			"SELECT '%s' AS name, min(TimeReceived) AS oldest FROM %s",
+			c.resolutionTable(resolution), c.resolutionTable(resolution)))
+	}
+	var rows []flowsTableOldest
+	if err := c.d.ClickHouse.Select(ctx, &rows, strings.Join(selects, "\nUNION ALL\n")); err != nil {
+		c.r.Err(err).Msg("cannot detect resolution gaps")
+		report.Error = err.Error()
+	} else {
+		oldest := map[string]time.Time{}
+		for _, row := range rows {
+			// An empty table returns the epoch.
+			if row.Oldest.Unix() > 0 {
+				oldest[row.Table] = row.Oldest
+			}
+		}
+		report.Gaps = c.planResolutionGaps(oldest, report.Checked)
+		for _, gap := range report.Gaps {
+			c.r.Warn().
+				Str("table", gap.Table).
+				Str("source", gap.Source).
+				Time("start", gap.Start).
+				Time("end", gap.End).
+				Msg("resolution gap detected, use /api/v0/orchestrator/clickhouse/backfill to fill it")
+		}
+	}
+	c.metrics.resolutionGaps.Set(float64(len(report.Gaps)))
+	c.resolutionGapsReport.Store(&report)
+	return &report
+}
+
+// backfillResolutionGaps fills the provided gaps from their source table. The
+// data is inserted in chunks of about a day. The last interval of a gap is
+// already partially present in the table: it is deleted before being
+// inserted again.
+func (c *Component) backfillResolutionGaps(ctx context.Context, gaps []resolutionGap) error {
+	for _, gap := range gaps {
+		resolution := c.config.Resolutions[slices.IndexFunc(c.config.Resolutions,
+			func(resolution ResolutionConfiguration) bool {
+				return resolution.Interval == gap.Interval
+			})]
+		selectQuery, err := c.aggregatedFlowsSelectQuery(resolution, gap.Source)
+		if err != nil {
+			return fmt.Errorf("cannot build select statement for %s: %w", gap.Table, err)
+		}
+		chunk := resolution.Interval * ((24*time.Hour + resolution.Interval - 1) / resolution.Interval)
+		for start := gap.Start; start.Before(gap.End); start = start.Add(chunk) {
+			end := start.Add(chunk)
+			if end.After(gap.End) {
+				end = gap.End
+			}
+			c.r.Info().
+				Str("table", gap.Table).
+				Str("source", gap.Source).
+				Time("start", start).
+				Time("end", end).
+				Msg("backfill resolution gap")
+			if end.Equal(gap.End) {
+				if err := c.d.ClickHouse.ExecOnCluster(
+					clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+						"mutations_sync": 2,
+					})),
+					fmt.Sprintf(`ALTER TABLE %s.%s DELETE
+WHERE TimeReceived >= toDateTime(%d) AND TimeReceived < toDateTime(%d)`,
+						c.d.ClickHouse.DatabaseName(), c.localTable(gap.Table),
+						gap.End.Add(-gap.Interval).Unix(), gap.End.Unix())); err != nil {
+					c.metrics.backfillErrors.Inc()
+					return fmt.Errorf("cannot delete partial interval from %s: %w", gap.Table, err)
+				}
+			}
+			if err := c.d.ClickHouse.Exec(ctx, fmt.Sprintf(`INSERT INTO %s.%s %s
+WHERE TimeReceived >= toDateTime(%d) AND TimeReceived < toDateTime(%d)`,
+				c.d.ClickHouse.DatabaseName(), gap.Table, selectQuery,
+				start.Unix(), end.Unix())); err != nil {
+				c.metrics.backfillErrors.Inc()
+				return fmt.Errorf("cannot backfill %s from %s: %w", gap.Table, gap.Source, err)
+			}
+			c.metrics.backfillChunks.Inc()
+		}
+	}
+	return nil
+}
+
+// resolutionGapsChecker detects resolution gaps once migrations are done.
+func (c *Component) resolutionGapsChecker() error {
+	select {
+	case <-c.t.Dying():
+		return nil
+	case <-c.migrationsDone:
+	}
+	c.detectResolutionGaps(c.t.Context(context.Background()))
+	return nil
+}
+
+func (c *Component) backfillHandlerFunc(gc *gin.Context) {
+	if c.d.ClickHouse == nil {
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "ClickHouse is not available."})
+		return
+	}
+	if gc.Request.Method == http.MethodPost {
+		select {
+		case <-c.migrationsDone:
+		default:
+			gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "Migrations are not done yet."})
+			return
+		}
+		if !c.backfillRunning.CompareAndSwap(false, true) {
+			gc.JSON(http.StatusConflict, gin.H{"message": "Backfill is already running."})
+			return
+		}
+		report := c.detectResolutionGaps(gc.Request.Context())
+		if report.Error != "" {
+			c.backfillRunning.Store(false)
+			gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot detect resolution gaps."})
+			return
+		}
+		if len(report.Gaps) == 0 {
+			c.backfillRunning.Store(false)
+			gc.JSON(http.StatusOK, gin.H{"message": "No resolution gap to backfill."})
+			return
+		}
+		c.t.Go(func() error {
+			defer c.backfillRunning.Store(false)
+			ctx := c.t.Context(context.Background())
+			if err := c.backfillResolutionGaps(ctx, report.Gaps); err != nil {
+				c.r.Err(err).Msg("cannot backfill resolution gaps")
+			}
+			c.detectResolutionGaps(ctx)
+			return nil
+		})
+		gc.JSON(http.StatusAccepted, gin.H{"message": "Backfill started."})
+		return
+	}
+	report := c.resolutionGapsReport.Load()
+	if report == nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Resolution gaps not checked yet."})
+		return
+	}
+	gc.JSON(http.StatusOK, struct {
+		*resolutionGapsReport
+		Running bool `json:"running"`
+	}{report, c.backfillRunning.Load()})
+}
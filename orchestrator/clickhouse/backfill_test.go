@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestPlanResolutionGaps(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 10, 2, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	cases := []struct {
+		Pos         helpers.Pos
+		Resolutions []ResolutionConfiguration
+		Oldest      map[string]time.Time
+		Expected    []resolutionGap
+	}{
+		{
+			Pos:         helpers.Mark(),
+			Resolutions: DefaultConfiguration().Resolutions,
+			Oldest: map[string]time.Time{
+				"flows":        now.Add(-15 * day),
+				"flows_1m0s":   now.Add(-7 * day),
+				"flows_5m0s":   now.Add(-90 * day),
+				"flows_1h0m0s": now.Add(-360 * day),
+			},
+			Expected: []resolutionGap{},
+		}, {
+			Pos:         helpers.Mark(),
+			Resolutions: DefaultConfiguration().Resolutions,
+			Oldest: map[string]time.Time{
+				"flows":        now.Add(-15 * day),
+				"flows_1m0s":   now.Add(-7 * day),
+				"flows_5m0s":   now.Add(-2 * time.Hour),
+				"flows_1h0m0s": now.Add(-360 * day),
+			},
+			Expected: []resolutionGap{
+				{
+					Table:    "flows_5m0s",
+					Interval: 5 * time.Minute,
+					Source:   "flows",
+					Start:    time.Date(2026, time.October, 1, 10, 0, 0, 0, time.UTC),
+					End:      time.Date(2026, time.October, 16, 8, 5, 0, 0, time.UTC),
+				},
+			},
+		}, {
+			Pos:         helpers.Mark(),
+			Resolutions: DefaultConfiguration().Resolutions,
+			Oldest: map[string]time.Time{
+				"flows":        now.Add(-15 * day),
+				"flows_1m0s":   now.Add(-7 * day),
+				"flows_5m0s":   now.Add(-15*day + time.Minute),
+				"flows_1h0m0s": now.Add(-360 * day),
+			},
+			Expected: []resolutionGap{},
+		}, {
+			Pos:         helpers.Mark(),
+			Resolutions: DefaultConfiguration().Resolutions,
+			Oldest: map[string]time.Time{
+				"flows_1m0s": now.Add(-7 * day),
+				"flows_5m0s": now.Add(-90 * day),
+			},
+			Expected: []resolutionGap{},
+		}, {
+			Pos: helpers.Mark(),
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0, TTL: 30 * day},
+				{Interval: time.Minute, TTL: 30 * day},
+				{Interval: 5 * time.Minute, TTL: 3 * day},
+			},
+			Oldest: map[string]time.Time{
+				"flows":      now.Add(-30 * day),
+				"flows_1m0s": now.Add(-30 * day),
+				"flows_5m0s": now.Add(-time.Hour),
+			},
+			Expected: []resolutionGap{
+				{
+					Table:    "flows_5m0s",
+					Interval: 5 * time.Minute,
+					Source:   "flows_1m0s",
+					Start:    time.Date(2026, time.October, 13, 10, 0, 0, 0, time.UTC),
+					End:      time.Date(2026, time.October, 16, 9, 5, 0, 0, time.UTC),
+				},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0, TTL: 30 * day},
+				{Interval: 5 * time.Minute, TTL: 30 * day},
+				{Interval: 7 * time.Minute, TTL: 30 * day},
+			},
+			Oldest: map[string]time.Time{
+				"flows":      now.Add(-2 * day),
+				"flows_5m0s": now.Add(-30 * day),
+				"flows_7m0s": now.Add(-time.Hour),
+			},
+			Expected: []resolutionGap{
+				{
+					Table:    "flows_7m0s",
+					Interval: 7 * time.Minute,
+					Source:   "flows",
+					Start:    time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC),
+					End:      time.Date(2026, time.October, 16, 9, 8, 0, 0, time.UTC),
+				},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0, TTL: 30 * day},
+				{Interval: time.Minute, TTL: 30 * day, SkipColumns: []schema.ColumnKey{schema.ColumnSrcCountry}},
+				{Interval: 5 * time.Minute, TTL: 30 * day},
+			},
+			Oldest: map[string]time.Time{
+				"flows":      now.Add(-2 * day),
+				"flows_1m0s": now.Add(-30 * day),
+				"flows_5m0s": now.Add(-time.Hour),
+			},
+			Expected: []resolutionGap{
+				{
+					Table:    "flows_5m0s",
+					Interval: 5 * time.Minute,
+					Source:   "flows",
+					Start:    time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC),
+					End:      time.Date(2026, time.October, 16, 9, 5, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		r := reporter.NewMock(t)
+		chComponent, _ := clickhousedb.NewMock(t, r)
+		config := DefaultConfiguration()
+		config.Resolutions = tc.Resolutions
+		c, err := New(r, config, Dependencies{
+			Daemon:     daemon.NewMock(t),
+			HTTP:       httpserver.NewMock(t, r),
+			Schema:     schema.NewMock(t),
+			GeoIP:      geoip.NewMock(t, r, false),
+			ClickHouse: chComponent,
+		})
+		if err != nil {
+			t.Fatalf("%sNew() error:\n%+v", tc.Pos, err)
+		}
+		got := c.planResolutionGaps(tc.Oldest, now)
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%splanResolutionGaps() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	now := time.Now()
+	oldestQuery := `SELECT 'flows' AS name, min(TimeReceived) AS oldest FROM flows
+UNION ALL
+SELECT 'flows_1m0s' AS name, min(TimeReceived) AS oldest FROM flows_1m0s
+UNION ALL
+SELECT 'flows_5m0s' AS name, min(TimeReceived) AS oldest FROM flows_5m0s
+UNION ALL
+SELECT 'flows_1h0m0s' AS name, min(TimeReceived) AS oldest FROM flows_1h0m0s`
+	withGap := []flowsTableOldest{
+		{"flows", now.Add(-20 * time.Hour)},
+		{"flows_1m0s", now.Add(-20 * time.Hour)},
+		{"flows_5m0s", now.Add(-2 * time.Hour)},
+		{"flows_1h0m0s", now.Add(-300 * 24 * time.Hour)},
+	}
+	withoutGap := []flowsTableOldest{
+		{"flows", now.Add(-20 * time.Hour)},
+		{"flows_1m0s", now.Add(-20 * time.Hour)},
+		{"flows_5m0s", now.Add(-20 * time.Hour)},
+		{"flows_1h0m0s", now.Add(-300 * 24 * time.Hour)},
+	}
+	start := startOfInterval(now.Add(-20*time.Hour), 5*time.Minute)
+	end := startOfInterval(now.Add(-2*time.Hour), 5*time.Minute).Add(5 * time.Minute)
+
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "not checked yet",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Resolution gaps not checked yet."},
+		}, {
+			Description: "before migrations",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			Header:      httpserver.MockAdminHeader(),
+			StatusCode:  503,
+			JSONOutput:  gin.H{"message": "Migrations are not done yet."},
+		},
+	})
+
+	// Detection
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), oldestQuery).
+		SetArg(1, withGap).
+		Return(nil)
+	report := c.detectResolutionGaps(t.Context())
+	expectedGaps := []resolutionGap{
+		{
+			Table:    "flows_5m0s",
+			Interval: 5 * time.Minute,
+			Source:   "flows_1m0s",
+			Start:    start,
+			End:      end,
+		},
+	}
+	if diff := helpers.Diff(report.Gaps, expectedGaps); diff != "" {
+		t.Fatalf("detectResolutionGaps() (-got, +want):\n%s", diff)
+	}
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "report",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			JSONOutput: gin.H{
+				"checked": report.Checked.Format(time.RFC3339Nano),
+				"running": false,
+				"gaps": []gin.H{
+					{
+						"table":  "flows_5m0s",
+						"source": "flows_1m0s",
+						"start":  start.Format(time.RFC3339),
+						"end":    end.Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+
+	// Backfill
+	close(c.migrationsDone)
+	selectQuery, err := c.aggregatedFlowsSelectQuery(c.config.Resolutions[2], "flows_1m0s")
+	if err != nil {
+		t.Fatalf("aggregatedFlowsSelectQuery() error:\n%+v", err)
+	}
+	gomock.InOrder(
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), oldestQuery).
+			SetArg(1, withGap).
+			Return(nil),
+		mockConn.EXPECT().
+			Exec(gomock.Any(), fmt.Sprintf(`ALTER TABLE default.flows_5m0s DELETE
+WHERE TimeReceived >= toDateTime(%d) AND TimeReceived < toDateTime(%d)`,
+				end.Add(-5*time.Minute).Unix(), end.Unix())).
+			Return(nil),
+		mockConn.EXPECT().
+			Exec(gomock.Any(), fmt.Sprintf(`INSERT INTO default.flows_5m0s %s
+WHERE TimeReceived >= toDateTime(%d) AND TimeReceived < toDateTime(%d)`,
+				selectQuery, start.Unix(), end.Unix())).
+			Return(nil),
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), oldestQuery).
+			SetArg(1, withoutGap).
+			Return(nil),
+	)
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "backfill without token",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "start backfill",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			Header:      httpserver.MockAdminHeader(),
+			StatusCode:  202,
+			JSONOutput:  gin.H{"message": "Backfill started."},
+		},
+	})
+	for i := 0; c.backfillRunning.Load(); i++ {
+		if i > 100 {
+			t.Fatal("backfill not done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "resolution_gaps", "backfill_")
+	expectedMetrics := map[string]string{
+		`resolution_gaps`:       "0",
+		`backfill_chunks_total`: "1",
+		`backfill_errors_total`: "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), oldestQuery).
+		SetArg(1, withoutGap).
+		Return(nil)
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "nothing to backfill",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/backfill",
+			Header:      httpserver.MockAdminHeader(),
+			JSONOutput:  gin.H{"message": "No resolution gap to backfill."},
+		},
+	})
+}
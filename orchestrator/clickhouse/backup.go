@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"akvorado/common/clickhousedb"
+)
+
+// backupNameRegex matches the name of a backup: the table name and a
+// timestamp.
+var backupNameRegex = regexp.MustCompile(`^(\w+)-(\d{14})$`)
+
+// backupName returns the name of a backup for the provided table.
+func backupName(table string, now time.Time) string {
+	return fmt.Sprintf("%s-%s", table, now.UTC().Format("20060102150405"))
+}
+
+// backupDestination returns the backup destination for a BACKUP or RESTORE
+// statement.
+func (bc BackupConfiguration) backupDestination(name string) (string, error) {
+	switch {
+	case bc.Disk != "":
+		return fmt.Sprintf("Disk(%s, %s)",
+			quoteString(bc.Disk), quoteString(fmt.Sprintf("%s.zip", name))), nil
+	case bc.S3URL != "":
+		url := fmt.Sprintf("%s/%s", strings.TrimRight(bc.S3URL, "/"), name)
+		if bc.S3AccessKeyID != "" {
+			return fmt.Sprintf("S3(%s, %s, %s)",
+				quoteString(url),
+				quoteString(bc.S3AccessKeyID),
+				quoteString(bc.S3SecretAccessKey)), nil
+		}
+		return fmt.Sprintf("S3(%s)", quoteString(url)), nil
+	}
+	return "", errors.New("no backup destination configured")
+}
+
+// backupTableOnce returns a function to backup the provided table. The backup
+// is only done on the first invocation and when backups are enabled.
+func (c *Component) backupTableOnce(table string) func(context.Context) error {
+	done := false
+	return func(ctx context.Context) error {
+		if plan := migrationPlanFromContext(ctx); plan != nil {
+			plan.Destructive = true
+			return nil
+		}
+		if done || !c.config.Backup.Enable {
+			return nil
+		}
+		name := backupName(table, time.Now())
+		destination, err := c.config.Backup.backupDestination(name)
+		if err != nil {
+			return err
+		}
+		c.r.Warn().Str("backup", name).Msgf("backup %s before destructive migration step", table)
+		if err := c.d.ClickHouse.ExecOnCluster(ctx,
+			fmt.Sprintf("BACKUP TABLE %s.%s TO %s",
+				c.d.ClickHouse.DatabaseName(), table, destination)); err != nil {
+			return fmt.Errorf("cannot backup table %s: %w", table, err)
+		}
+		c.metrics.migrationsBackups.Inc()
+		done = true
+		return nil
+	}
+}
+
+// RestoreBackup restores a table from the provided backup. The backup is
+// restored under a temporary name and exchanged with the existing table, which
+// is only dropped once the restore succeeded. The orchestrator should not be
+// running while restoring a backup.
+func RestoreBackup(ctx context.Context, ch *clickhousedb.Component, config BackupConfiguration, name string) error {
+	matches := backupNameRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return fmt.Errorf("invalid backup name %q", name)
+	}
+	table := fmt.Sprintf("%s.%s", ch.DatabaseName(), matches[1])
+	restored := fmt.Sprintf("%s_restore", table)
+	destination, err := config.backupDestination(name)
+	if err != nil {
+		return err
+	}
+	if err := ch.ExecOnCluster(ctx,
+		fmt.Sprintf("DROP TABLE IF EXISTS %s SYNC", restored)); err != nil {
+		return fmt.Errorf("cannot drop table %s: %w", restored, err)
+	}
+	if err := ch.ExecOnCluster(ctx,
+		fmt.Sprintf("RESTORE TABLE %s AS %s FROM %s", table, restored, destination)); err != nil {
+		return fmt.Errorf("cannot restore table %s: %w", table, err)
+	}
+	var exists uint64
+	if err := ch.QueryRow(ctx,
+		"SELECT count() FROM system.tables WHERE database = $1 AND name = $2",
+		ch.DatabaseName(), matches[1]).Scan(&exists); err != nil {
+		return fmt.Errorf("cannot check if table %s exists: %w", table, err)
+	}
+	if exists == 0 {
+		if err := ch.ExecOnCluster(ctx,
+			fmt.Sprintf("RENAME TABLE %s TO %s", restored, table)); err != nil {
+			return fmt.Errorf("cannot rename table %s: %w", restored, err)
+		}
+		return nil
+	}
+	if err := ch.ExecOnCluster(ctx,
+		fmt.Sprintf("EXCHANGE TABLES %s AND %s", table, restored)); err != nil {
+		return fmt.Errorf("cannot exchange tables %s and %s: %w", table, restored, err)
+	}
+	if err := ch.ExecOnCluster(ctx,
+		fmt.Sprintf("DROP TABLE IF EXISTS %s SYNC", restored)); err != nil {
+		return fmt.Errorf("cannot drop previous table %s: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestBackupName(t *testing.T) {
+	now := time.Date(2025, time.March, 4, 15, 16, 17, 0, time.UTC)
+	got := backupName("flows_1m0s", now)
+	if diff := helpers.Diff(got, "flows_1m0s-20250304151617"); diff != "" {
+		t.Fatalf("backupName() (-got, +want):\n%s", diff)
+	}
+	matches := backupNameRegex.FindStringSubmatch(got)
+	if matches == nil {
+		t.Fatalf("backupNameRegex does not match %q", got)
+	}
+	if diff := helpers.Diff(matches[1], "flows_1m0s"); diff != "" {
+		t.Fatalf("backupNameRegex (-got, +want):\n%s", diff)
+	}
+}
+
+func TestBackupDestination(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Config   BackupConfiguration
+		Expected string
+		Error    bool
+	}{
+		{
+			Pos:    helpers.Mark(),
+			Config: BackupConfiguration{},
+			Error:  true,
+		}, {
+			Pos:      helpers.Mark(),
+			Config:   BackupConfiguration{Disk: "backups"},
+			Expected: "Disk('backups', 'flows-20250304151617.zip')",
+		}, {
+			Pos:      helpers.Mark(),
+			Config:   BackupConfiguration{S3URL: "https://bucket.s3.amazonaws.com/akvorado/"},
+			Expected: "S3('https://bucket.s3.amazonaws.com/akvorado/flows-20250304151617')",
+		}, {
+			Pos: helpers.Mark(),
+			Config: BackupConfiguration{
+				S3URL:             "https://bucket.s3.amazonaws.com/akvorado",
+				S3AccessKeyID:     "AKIA",
+				S3SecretAccessKey: "secret'",
+			},
+			Expected: `S3('https://bucket.s3.amazonaws.com/akvorado/flows-20250304151617', 'AKIA', 'secret\'')`,
+		},
+	}
+	for _, tc := range cases {
+		got, err := tc.Config.backupDestination("flows-20250304151617")
+		if err != nil && !tc.Error {
+			t.Errorf("%sbackupDestination() error:\n%+v", tc.Pos, err)
+			continue
+		} else if err == nil && tc.Error {
+			t.Errorf("%sbackupDestination() did not error", tc.Pos)
+			continue
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%sbackupDestination() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	config := BackupConfiguration{Disk: "backups"}
+	for _, exists := range []uint64{0, 1} {
+		r := reporter.NewMock(t)
+		chComponent, mockConn := clickhousedb.NewMock(t, r)
+		mockRow := mocks.NewMockRow(gomock.NewController(t))
+		mockRow.EXPECT().Scan(gomock.Any()).SetArg(0, exists).Return(nil)
+		calls := []any{
+			mockConn.EXPECT().
+				Exec(gomock.Any(), "DROP TABLE IF EXISTS default.flows_1m0s_restore SYNC").
+				Return(nil),
+			mockConn.EXPECT().
+				Exec(gomock.Any(), "RESTORE TABLE default.flows_1m0s AS default.flows_1m0s_restore FROM Disk('backups', 'flows_1m0s-20250304151617.zip')").
+				Return(nil),
+			mockConn.EXPECT().
+				QueryRow(gomock.Any(), "SELECT count() FROM system.tables WHERE database = $1 AND name = $2",
+					"default", "flows_1m0s").
+				Return(mockRow),
+		}
+		if exists == 0 {
+			calls = append(calls,
+				mockConn.EXPECT().
+					Exec(gomock.Any(), "RENAME TABLE default.flows_1m0s_restore TO default.flows_1m0s").
+					Return(nil))
+		} else {
+			calls = append(calls,
+				mockConn.EXPECT().
+					Exec(gomock.Any(), "EXCHANGE TABLES default.flows_1m0s AND default.flows_1m0s_restore").
+					Return(nil),
+				mockConn.EXPECT().
+					Exec(gomock.Any(), "DROP TABLE IF EXISTS default.flows_1m0s_restore SYNC").
+					Return(nil))
+		}
+		gomock.InOrder(calls...)
+		if err := RestoreBackup(context.Background(), chComponent, config, "flows_1m0s-20250304151617"); err != nil {
+			t.Fatalf("RestoreBackup() error:\n%+v", err)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"akvorado/common/remotedatasource"
 
 	"akvorado/common/helpers"
+	"akvorado/common/schema"
 
 	"github.com/go-viper/mapstructure/v2"
 )
@@ -27,6 +28,10 @@ type Configuration struct {
 	// ASNs is a mapping from AS numbers to names. It replaces or
 	// extends the builtin list of AS numbers.
 	ASNs map[uint32]string
+	// ASNSources defines a set of remote sources mapping AS numbers to
+	// names. They extend the builtin list of AS numbers and are
+	// overridden by ASNs.
+	ASNSources map[string]remotedatasource.Source `validate:"dive"`
 	// Networks is a mapping from IP networks to attributes. It is used
 	// to instantiate the SrcNet* and DstNet* columns.
 	Networks *helpers.SubnetMap[NetworkAttributes] `validate:"omitempty,dive"`
@@ -44,6 +49,149 @@ type Configuration struct {
 	// OrchestratorBasicAuth holds optional basic auth credentials to reach
 	// orchestrator from ClickHouse
 	OrchestratorBasicAuth *ConfigurationBasicAuth
+	// Backup defines how to backup tables before a migration step which may
+	// lose data.
+	Backup BackupConfiguration
+	// MaterializeTTL tells if the TTL should be materialized on existing data
+	// when it is modified. Otherwise, it can be materialized later through
+	// the API.
+	MaterializeTTL bool
+	// DropPopulatedColumns tells if the columns disabled in the schema
+	// should be dropped from the flows tables even when they contain data.
+	// Otherwise, only empty ones are dropped.
+	DropPopulatedColumns bool
+	// CustomViews defines additional tables populated from the flows table
+	// through a materialized view.
+	CustomViews []CustomViewConfiguration `validate:"dive"`
+	// SchemaDrift defines how to detect a drift between the schema of the
+	// database and the expected one.
+	SchemaDrift SchemaDriftConfiguration
+	// RawTablesGC defines how to drop the raw tables of previous schemas.
+	RawTablesGC RawTablesGCConfiguration
+	// AccessControl defines the users, roles and quotas to create in
+	// ClickHouse.
+	AccessControl AccessControlConfiguration
+	// RemoteWrite defines how to push aggregates of the ingested flows to a
+	// Prometheus-compatible TSDB.
+	RemoteWrite RemoteWriteConfiguration
+}
+
+// AccessControlConfiguration describes the users, roles and quotas the
+// orchestrator should create and maintain in ClickHouse. When no user is
+// defined, access control is left untouched.
+type AccessControlConfiguration struct {
+	// WriterRole is the name of the role allowed to insert flows.
+	WriterRole string `validate:"required"`
+	// ReaderRole is the name of the role allowed to query flows.
+	ReaderRole string `validate:"required,nefield=WriterRole"`
+	// Users is the list of users to create.
+	Users []UserConfiguration `validate:"dive"`
+	// Quotas is the list of quotas to create.
+	Quotas []QuotaConfiguration `validate:"dive"`
+}
+
+// UserConfiguration describes a ClickHouse user.
+type UserConfiguration struct {
+	// Name is the name of the user.
+	Name string `validate:"required"`
+	// Password is the password of the user.
+	Password string `validate:"required"`
+	// Role is either "writer" (for the outlet) or "reader" (for the
+	// console).
+	Role string `validate:"oneof=writer reader"`
+	// Quota is the name of the quota to apply to the user, if any.
+	Quota string
+}
+
+// QuotaConfiguration describes a ClickHouse quota. A limit of 0 means no
+// limit.
+type QuotaConfiguration struct {
+	// Name is the name of the quota.
+	Name string `validate:"required"`
+	// Interval is the interval over which the limits are enforced.
+	Interval time.Duration `validate:"min=1s"`
+	// Queries is the maximum number of queries.
+	Queries uint64
+	// Errors is the maximum number of queries which threw an exception.
+	Errors uint64
+	// ResultRows is the maximum number of rows returned.
+	ResultRows uint64
+	// ReadRows is the maximum number of rows read.
+	ReadRows uint64
+	// ExecutionTime is the maximum total query execution time.
+	ExecutionTime time.Duration `validate:"min=0"`
+}
+
+// DefaultQuotaConfiguration is the default configuration for a quota.
+func DefaultQuotaConfiguration() QuotaConfiguration {
+	return QuotaConfiguration{
+		Interval: time.Hour,
+	}
+}
+
+// SchemaDriftConfiguration describes how to periodically compare the schema
+// of the database with the expected one.
+type SchemaDriftConfiguration struct {
+	// Interval is the interval between two checks. A value of 0 disables
+	// the periodic check.
+	Interval time.Duration `validate:"min=0"`
+	// AutoHeal tells if the non-destructive migration steps fixing a drift
+	// should be applied.
+	AutoHeal bool
+}
+
+// RawTablesGCConfiguration describes how to drop the raw tables (and their
+// consumers) created for previous schemas. Each schema change creates a new
+// generation of raw tables, identified by the hash of the schema.
+type RawTablesGCConfiguration struct {
+	// Interval is the interval between two collections. A value of 0
+	// disables the collection.
+	Interval time.Duration `validate:"min=0"`
+	// Keep is the number of previous generations to always keep.
+	Keep uint
+	// GracePeriod is how long a generation is kept after being replaced by
+	// a newer one.
+	GracePeriod time.Duration `validate:"min=0"`
+	// DryRun tells to only report the generations to drop.
+	DryRun bool
+}
+
+// RemoteWriteConfiguration describes how to push aggregates of the ingested
+// flows (flows per second for each exporter group, bytes per second for each
+// destination network) with the Prometheus remote-write protocol.
+type RemoteWriteConfiguration struct {
+	// URL is the remote-write endpoint. When empty, nothing is pushed.
+	URL string `validate:"omitempty,url"`
+	// Interval is the interval between two pushes. Rates are computed over
+	// the same interval.
+	Interval time.Duration `validate:"min=10s"`
+	// Delay is how far in the past the computed interval ends, to let flows
+	// reach ClickHouse.
+	Delay time.Duration `validate:"min=0"`
+	// Timeout is the maximum duration of a push.
+	Timeout time.Duration `validate:"min=1s"`
+	// Headers are additional HTTP headers, for example for authentication.
+	Headers map[string]string
+	// Labels are added to all the series, for example to identify the site.
+	Labels map[string]string `validate:"dive,keys,required,endkeys"`
+}
+
+// BackupConfiguration describes how to backup tables before a destructive
+// migration step. Backups are done with the BACKUP statement from ClickHouse
+// and either stored on a ClickHouse disk or in a S3 bucket.
+type BackupConfiguration struct {
+	// Enable tells if tables should be backed up before a destructive step.
+	Enable bool
+	// Disk is the name of the ClickHouse disk to store backups into. It
+	// should be declared as an allowed backup disk in ClickHouse.
+	Disk string `validate:"excluded_with=S3URL"`
+	// S3URL is the URL of the S3 bucket (and optional prefix) to store
+	// backups into.
+	S3URL string `validate:"omitempty,url"`
+	// S3AccessKeyID is the access key to use for S3.
+	S3AccessKeyID string
+	// S3SecretAccessKey is the secret key to use for S3.
+	S3SecretAccessKey string
 }
 
 // ConfigurationBasicAuth holds Username and Password subfields
@@ -62,19 +210,87 @@ type ResolutionConfiguration struct {
 	// TTL is how long to keep data for this resolution. A
 	// value of 0 means to never expire.
 	TTL time.Duration `validate:"isdefault|min=1h"`
+	// StoragePolicy is the ClickHouse storage policy to use for the
+	// table of this resolution. When empty, the default policy is used.
+	StoragePolicy string
+	// Moves tells when data should be moved to another disk or volume.
+	Moves []MoveConfiguration `validate:"dive"`
+	// SkipColumns lists the columns to not keep in the aggregated table of
+	// this resolution (in addition to the ones only present in the main
+	// table).
+	SkipColumns []schema.ColumnKey
+}
+
+// MoveConfiguration describes when to move data of a resolution to another
+// disk or volume of the storage policy.
+type MoveConfiguration struct {
+	// After is the age of the data before it is moved.
+	After time.Duration `validate:"min=1h"`
+	// Disk is the disk to move data to.
+	Disk string `validate:"required_without=Volume,excluded_with=Volume"`
+	// Volume is the volume to move data to.
+	Volume string `validate:"required_without=Disk,excluded_with=Disk"`
+}
+
+// CustomViewConfiguration describes a table populated from the main flows
+// table with a materialized view. The table is named after Name while the
+// materialized view uses the same name with the `_consumer` suffix.
+type CustomViewConfiguration struct {
+	// Name is the name of the table.
+	Name string `validate:"required"`
+	// Select is the list of expressions to select from the flows table.
+	Select []string `validate:"min=1"`
+	// Where is an optional filter to apply on flows.
+	Where string
+	// GroupBy is an optional list of expressions to group flows by.
+	GroupBy []string
+	// Engine is the MergeTree engine to use for the table.
+	Engine string `validate:"oneof=MergeTree SummingMergeTree AggregatingMergeTree ReplacingMergeTree"`
+	// PartitionBy is an optional partition key for the table.
+	PartitionBy string
+	// OrderBy is the sorting key for the table.
+	OrderBy []string `validate:"min=1"`
+	// TTL is how long to keep data in the table, using the TimeReceived
+	// column. A value of 0 means to never expire.
+	TTL time.Duration `validate:"isdefault|min=1h"`
+}
+
+// DefaultCustomViewConfiguration is the default configuration for a custom view.
+func DefaultCustomViewConfiguration() CustomViewConfiguration {
+	return CustomViewConfiguration{
+		Engine: "SummingMergeTree",
+	}
 }
 
 // DefaultConfiguration represents the default configuration for the ClickHouse configurator.
 func DefaultConfiguration() Configuration {
 	return Configuration{
 		Resolutions: []ResolutionConfiguration{
-			{0, 15 * 24 * time.Hour},                   // 15 days
-			{time.Minute, 7 * 24 * time.Hour},          // 7 days
-			{5 * time.Minute, 3 * 30 * 24 * time.Hour}, // 90 days
-			{time.Hour, 12 * 30 * 24 * time.Hour},      // 1 year
+			{Interval: 0, TTL: 15 * 24 * time.Hour},                   // 15 days
+			{Interval: time.Minute, TTL: 7 * 24 * time.Hour},          // 7 days
+			{Interval: 5 * time.Minute, TTL: 3 * 30 * 24 * time.Hour}, // 90 days
+			{Interval: time.Hour, TTL: 12 * 30 * 24 * time.Hour},      // 1 year
 		},
 		MaxPartitions:         50,
 		NetworkSourcesTimeout: 10 * time.Second,
+		MaterializeTTL:        true,
+		SchemaDrift: SchemaDriftConfiguration{
+			Interval: time.Hour,
+		},
+		RawTablesGC: RawTablesGCConfiguration{
+			Interval:    time.Hour,
+			Keep:        2,
+			GracePeriod: 7 * 24 * time.Hour,
+		},
+		AccessControl: AccessControlConfiguration{
+			WriterRole: "akvorado_writer",
+			ReaderRole: "akvorado_reader",
+		},
+		RemoteWrite: RemoteWriteConfiguration{
+			Interval: time.Minute,
+			Delay:    time.Minute,
+			Timeout:  10 * time.Second,
+		},
 	}
 }
 
@@ -121,6 +337,10 @@ func NetworkAttributesUnmarshallerHook() mapstructure.DecodeHookFunc {
 func init() {
 	helpers.RegisterMapstructureUnmarshallerHook(helpers.SubnetMapUnmarshallerHook[NetworkAttributes]())
 	helpers.RegisterMapstructureUnmarshallerHook(NetworkAttributesUnmarshallerHook())
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultCustomViewConfiguration()))
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultQuotaConfiguration()))
 	helpers.RegisterMapstructureDeprecatedFields[Configuration](
 		"SystemLogTTL",
 		"PrometheusEndpoint",
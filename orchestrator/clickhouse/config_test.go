@@ -5,6 +5,7 @@ package clickhouse
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -103,3 +104,19 @@ func TestDefaultConfiguration(t *testing.T) {
 func init() {
 	helpers.RegisterSubnetMapCmp[NetworkAttributes]()
 }
+
+func TestMoveConfigurationValidation(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Resolutions[1].Moves = []MoveConfiguration{{After: 24 * time.Hour, Disk: "cold"}}
+	if err := helpers.Validate.Struct(config); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+	config.Resolutions[1].Moves = []MoveConfiguration{{After: 24 * time.Hour}}
+	if err := helpers.Validate.Struct(config); err == nil {
+		t.Fatal("validate.Struct() did not error without disk or volume")
+	}
+	config.Resolutions[1].Moves = []MoveConfiguration{{After: 24 * time.Hour, Disk: "cold", Volume: "cold"}}
+	if err := helpers.Validate.Struct(config); err == nil {
+		t.Fatal("validate.Struct() did not error with both disk and volume")
+	}
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var customViewNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateCustomViews checks if the names of the custom views are acceptable
+// and unique.
+func validateCustomViews(views []CustomViewConfiguration) error {
+	seen := map[string]bool{}
+	for _, view := range views {
+		if err := validateCustomView(view); err != nil {
+			return err
+		}
+		if seen[view.Name] {
+			return fmt.Errorf("duplicate custom view %q", view.Name)
+		}
+		seen[view.Name] = true
+	}
+	return nil
+}
+
+// validateCustomView checks if the name of a custom view is acceptable.
+func validateCustomView(view CustomViewConfiguration) error {
+	if !customViewNameRegex.MatchString(view.Name) {
+		return fmt.Errorf("invalid name %q for custom view", view.Name)
+	}
+	for _, prefix := range []string{"flows", "exporters", "asns", "networks", "protocols", "icmp", "tcp", "udp", "custom_dict_"} {
+		if strings.HasPrefix(view.Name, prefix) {
+			return fmt.Errorf("custom view %q cannot start with %q", view.Name, prefix)
+		}
+	}
+	return nil
+}
+
+// customViewSelect returns the SELECT query for the provided custom view.
+func customViewSelect(database, table string, view CustomViewConfiguration) (string, error) {
+	return stemplate(`
+SELECT
+ {{ .Columns }}
+FROM {{ .Database }}.{{ .Table }}
+{{- if .Where }}
+WHERE {{ .Where }}
+{{- end }}
+{{- if .GroupBy }}
+GROUP BY {{ .GroupBy }}
+{{- end }}`, gin.H{
+		"Database": database,
+		"Table":    table,
+		"Columns":  strings.Join(view.Select, ",\n "),
+		"Where":    view.Where,
+		"GroupBy":  strings.Join(view.GroupBy, ", "),
+	})
+}
+
+// customViewFingerprint returns the fingerprint of the consumer view of a
+// custom view. It is stored as the comment of the view as ClickHouse
+// reformats the SELECT query and it cannot be compared with the configured
+// one.
+func customViewFingerprint(target, selectQuery string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s", target, selectQuery)))
+	return fmt.Sprintf("akvorado:%x", sum[:16])
+}
+
+// createCustomViewTable creates the table for a custom view if it does not
+// exist. The columns are inferred from the SELECT query. An existing table is
+// not modified.
+func (c *Component) createCustomViewTable(ctx context.Context, view CustomViewConfiguration) error {
+	tableName := c.localTable(view.Name)
+	if ok, err := c.tableAlreadyExists(ctx, tableName, "name", tableName); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("%s already exists, skip migration", tableName)
+		return errSkipStep
+	}
+
+	selectQuery, err := customViewSelect(c.d.ClickHouse.DatabaseName(), c.localTable("flows"), view)
+	if err != nil {
+		return fmt.Errorf("cannot build select statement for %s: %w", tableName, err)
+	}
+	var columns []struct {
+		Name              string `ch:"name"`
+		Type              string `ch:"type"`
+		DefaultType       string `ch:"default_type"`
+		DefaultExpression string `ch:"default_expression"`
+		Comment           string `ch:"comment"`
+		CodecExpression   string `ch:"codec_expression"`
+		TTLExpression     string `ch:"ttl_expression"`
+	}
+	if err := c.d.ClickHouse.Select(ctx, &columns, fmt.Sprintf("DESCRIBE (%s)", selectQuery)); err != nil {
+		return fmt.Errorf("cannot get columns for %s: %w", tableName, err)
+	}
+	schema := []string{}
+	for _, column := range columns {
+		schema = append(schema, fmt.Sprintf("`%s` %s", column.Name, column.Type))
+	}
+
+	createQuery, err := stemplate(`
+CREATE TABLE {{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+{{- if .PartitionBy }}
+PARTITION BY {{ .PartitionBy }}
+{{- end }}
+ORDER BY ({{ .OrderBy }})
+{{- if .TTL }}
+TTL TimeReceived + toIntervalSecond({{ .TTL }})
+{{- end }}`, gin.H{
+		"Table":       tableName,
+		"Schema":      strings.Join(schema, ", "),
+		"Engine":      c.mergeTreeEngine(tableName, strings.TrimSuffix(view.Engine, "MergeTree")),
+		"PartitionBy": view.PartitionBy,
+		"OrderBy":     strings.Join(view.OrderBy, ", "),
+		"TTL":         uint64(view.TTL.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot build create table statement for %s: %w", tableName, err)
+	}
+	c.r.Info().Msgf("create %s", tableName)
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// createCustomViewConsumerView creates the materialized view populating the
+// table of a custom view. It is replaced if the SELECT query changed, using
+// the fingerprint stored in its comment.
+func (c *Component) createCustomViewConsumerView(ctx context.Context, view CustomViewConfiguration) error {
+	viewName := fmt.Sprintf("%s_consumer", view.Name)
+	selectQuery, err := customViewSelect(c.d.ClickHouse.DatabaseName(), c.localTable("flows"), view)
+	if err != nil {
+		return fmt.Errorf("cannot build select statement for consumer %s: %w", viewName, err)
+	}
+
+	// Check the existing one
+	target := c.localTable(view.Name)
+	fingerprint := customViewFingerprint(target, selectQuery)
+	if ok, err := c.tableAlreadyExists(ctx, viewName, "comment", fingerprint); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("%s already exists, skip migration", viewName)
+		return errSkipStep
+	}
+
+	// Drop and create
+	c.r.Info().Msgf("create %s", viewName)
+	if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
+	}
+	if err := c.migrationExec(ctx,
+		fmt.Sprintf("CREATE MATERIALIZED VIEW %s TO %s AS %s\nCOMMENT %s", viewName,
+			target, selectQuery, quoteString(fingerprint))); err != nil {
+		return fmt.Errorf("cannot create %s: %w", viewName, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestValidateCustomView(t *testing.T) {
+	cases := []struct {
+		Pos   helpers.Pos
+		Name  string
+		Error bool
+	}{
+		{helpers.Mark(), "customer_daily", false},
+		{helpers.Mark(), "daily2", false},
+		{helpers.Mark(), "Daily", true},
+		{helpers.Mark(), "2daily", true},
+		{helpers.Mark(), "customer-daily", true},
+		{helpers.Mark(), "flows_daily", true},
+		{helpers.Mark(), "exporters_daily", true},
+		{helpers.Mark(), "custom_dict_daily", true},
+	}
+	for _, tc := range cases {
+		err := validateCustomView(CustomViewConfiguration{Name: tc.Name})
+		if err != nil && !tc.Error {
+			t.Errorf("%svalidateCustomView(%q) error:\n%+v", tc.Pos, tc.Name, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("%svalidateCustomView(%q) did not error", tc.Pos, tc.Name)
+		}
+	}
+}
+
+func TestValidateCustomViews(t *testing.T) {
+	if err := validateCustomViews([]CustomViewConfiguration{
+		{Name: "customer_daily"},
+		{Name: "customer_hourly"},
+	}); err != nil {
+		t.Errorf("validateCustomViews() error:\n%+v", err)
+	}
+	if err := validateCustomViews([]CustomViewConfiguration{
+		{Name: "customer_daily"},
+		{Name: "customer_daily"},
+	}); err == nil {
+		t.Error("validateCustomViews() did not error on duplicate names")
+	}
+}
+
+func TestCustomViewFingerprint(t *testing.T) {
+	fingerprint := customViewFingerprint("daily", "SELECT 1")
+	if fingerprint != customViewFingerprint("daily", "SELECT 1") {
+		t.Error("customViewFingerprint() is not stable")
+	}
+	if fingerprint == customViewFingerprint("daily", "SELECT 2") {
+		t.Error("customViewFingerprint() does not depend on the query")
+	}
+	if fingerprint == customViewFingerprint("hourly", "SELECT 1") {
+		t.Error("customViewFingerprint() does not depend on the target")
+	}
+}
+
+func TestCustomViewSelect(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		View     CustomViewConfiguration
+		Expected string
+	}{
+		{
+			Pos: helpers.Mark(),
+			View: CustomViewConfiguration{
+				Select: []string{"TimeReceived", "Bytes"},
+			},
+			Expected: `
+SELECT
+ TimeReceived,
+ Bytes
+FROM default.flows`,
+		}, {
+			Pos: helpers.Mark(),
+			View: CustomViewConfiguration{
+				Select: []string{
+					"toStartOfDay(TimeReceived) AS TimeReceived",
+					"InIfProvider",
+					"SUM(Bytes*SamplingRate) AS Bytes",
+				},
+				Where:   "InIfBoundary = 'external'",
+				GroupBy: []string{"TimeReceived", "InIfProvider"},
+			},
+			Expected: `
+SELECT
+ toStartOfDay(TimeReceived) AS TimeReceived,
+ InIfProvider,
+ SUM(Bytes*SamplingRate) AS Bytes
+FROM default.flows
+WHERE InIfBoundary = 'external'
+GROUP BY TimeReceived, InIfProvider`,
+		},
+	}
+	for _, tc := range cases {
+		got, err := customViewSelect("default", "flows", tc.View)
+		if err != nil {
+			t.Errorf("%scustomViewSelect() error:\n%+v", tc.Pos, err)
+			continue
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%scustomViewSelect() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
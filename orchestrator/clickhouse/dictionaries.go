@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"akvorado/common/reporter"
+)
+
+// dictionariesMetricsInterval is the interval between two updates of the
+// metrics about dictionaries.
+var dictionariesMetricsInterval = time.Minute
+
+// dictionaryStatus is the status of a dictionary as reported by ClickHouse.
+type dictionaryStatus struct {
+	Name         string    `ch:"name"`
+	Status       string    `ch:"status"`
+	ElementCount uint64    `ch:"element_count"`
+	LastUpdate   time.Time `ch:"last_successful_update_time"`
+}
+
+// updateDictionariesMetrics fetches the status of the dictionaries from
+// ClickHouse and updates the associated metrics.
+func (c *Component) updateDictionariesMetrics(ctx context.Context) error {
+	var dictionaries []dictionaryStatus
+	if err := c.d.ClickHouse.Select(ctx, &dictionaries, `
+SELECT name, toString(status) AS status, element_count, last_successful_update_time
+FROM system.dictionaries
+WHERE database = currentDatabase()
+`); err != nil {
+		return fmt.Errorf("cannot get dictionaries status: %w", err)
+	}
+	for _, dictionary := range dictionaries {
+		loaded := 0.
+		if dictionary.Status == "LOADED" {
+			loaded = 1
+		}
+		c.metrics.dictionaryLoaded.WithLabelValues(dictionary.Name).Set(loaded)
+		c.metrics.dictionaryElements.WithLabelValues(dictionary.Name).Set(float64(dictionary.ElementCount))
+		if !dictionary.LastUpdate.IsZero() {
+			c.metrics.dictionaryLastUpdate.WithLabelValues(dictionary.Name).
+				Set(float64(dictionary.LastUpdate.Unix()))
+		}
+	}
+	return nil
+}
+
+// dictionariesMetricsUpdater periodically updates the metrics about
+// dictionaries once migrations are done.
+func (c *Component) dictionariesMetricsUpdater() error {
+	if !c.config.SkipMigrations {
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-c.migrationsDone:
+		}
+	}
+	errLogger := c.r.Sample(reporter.BurstSampler(time.Minute, 1))
+	ticker := time.NewTicker(dictionariesMetricsInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(c.t.Context(nil), dictionariesMetricsInterval)
+		if err := c.updateDictionariesMetrics(ctx); err != nil {
+			errLogger.Err(err).Msg("cannot update dictionaries metrics")
+		}
+		cancel()
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchCustomDictionaries watches the source files of the custom dictionaries
+// and reloads the matching dictionary when one of them is modified.
+func (c *Component) watchCustomDictionaries() error {
+	sources := map[string]string{}
+	for name, dict := range c.d.Schema.GetCustomDictConfig() {
+		sources[filepath.Clean(dict.Source)] = fmt.Sprintf("custom_dict_%s", name)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot setup watcher: %w", err)
+	}
+	dirs := map[string]bool{}
+	for path := range sources {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("cannot watch custom dictionary directory: %w", err)
+		}
+	}
+	c.t.Go(func() error {
+		errLogger := c.r.Sample(reporter.BurstSampler(10*time.Second, 1))
+		defer watcher.Close()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return errors.New("file watcher died")
+				}
+				errLogger.Err(err).Msg("error from watcher")
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return errors.New("file watcher died")
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				dictName, ok := sources[filepath.Clean(event.Name)]
+				if !ok {
+					continue
+				}
+				c.r.Info().Str("dictionary", dictName).Msg("source modified, reload dictionary")
+				ctx, cancel := context.WithTimeout(c.t.Context(nil), time.Minute)
+				c.metrics.dictionaryReload.WithLabelValues(dictName).Inc()
+				if err := c.ReloadDictionary(ctx, dictName); err != nil {
+					c.r.Err(err).Str("dictionary", dictName).Msg("failed to reload dictionary")
+				}
+				cancel()
+			}
+		}
+	})
+	return nil
+}
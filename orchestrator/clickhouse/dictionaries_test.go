@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+const dictionariesStatusQuery = `
+SELECT name, toString(status) AS status, element_count, last_successful_update_time
+FROM system.dictionaries
+WHERE database = currentDatabase()
+`
+
+func TestDictionariesMetrics(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), dictionariesStatusQuery).
+		Return(nil).
+		SetArg(1, []dictionaryStatus{
+			{"asns", "LOADED", 1000, time.Unix(1760000000, 0)},
+			{"networks", "FAILED", 0, time.Time{}},
+		})
+	if err := c.updateDictionariesMetrics(context.Background()); err != nil {
+		t.Fatalf("updateDictionariesMetrics() error:\n%+v", err)
+	}
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_dictionary_")
+	expectedMetrics := map[string]string{
+		`elements{dictionary="asns"}`:            "1000",
+		`elements{dictionary="networks"}`:        "0",
+		`loaded{dictionary="asns"}`:              "1",
+		`loaded{dictionary="networks"}`:          "0",
+		`last_update_seconds{dictionary="asns"}`: "1.76e+09",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestWatchCustomDictionaries(t *testing.T) {
+	tmp := t.TempDir()
+	source := filepath.Join(tmp, "test.csv")
+	if err := os.WriteFile(source, []byte("addr,name\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	schemaConfig := schema.DefaultConfiguration()
+	schemaConfig.CustomDictionaries = map[string]schema.CustomDict{
+		"test": {
+			Source:     source,
+			Keys:       []schema.CustomDictKey{{Name: "addr", Type: "String"}},
+			Attributes: []schema.CustomDictAttribute{{Name: "name", Type: "String"}},
+		},
+	}
+	sch, err := schema.New(schemaConfig)
+	if err != nil {
+		t.Fatalf("schema.New() error:\n%+v", err)
+	}
+
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	mockConn.EXPECT().
+		Exec(gomock.Any(), "SYSTEM RELOAD DICTIONARY default.networks").
+		Return(nil).
+		AnyTimes()
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), dictionariesStatusQuery).
+		Return(nil).
+		AnyTimes()
+	reloaded := make(chan struct{})
+	mockConn.EXPECT().
+		Exec(gomock.Any(), "SYSTEM RELOAD DICTIONARY default.custom_dict_test").
+		DoAndReturn(func(context.Context, string, ...any) error {
+			close(reloaded)
+			return nil
+		})
+
+	config := DefaultConfiguration()
+	config.SkipMigrations = true
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     sch,
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Another file: no reload
+	if err := os.WriteFile(filepath.Join(tmp, "other.csv"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	// Source: reload
+	if err := os.WriteFile(source, []byte("addr,name\n192.0.2.1,test\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("dictionary not reloaded")
+	}
+}
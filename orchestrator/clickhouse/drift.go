@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// migrationPlan records the statements a migration step would execute. A step
+// is destructive when it would backup a table first.
+type migrationPlan struct {
+	Statements  []string `json:"statements"`
+	Destructive bool     `json:"destructive"`
+	Healed      bool     `json:"healed"`
+}
+
+// schemaDriftReport is the result of a schema drift check. Each migration
+// step which is not applied yet is a drift.
+type schemaDriftReport struct {
+	Checked time.Time       `json:"checked"`
+	Error   string          `json:"error,omitempty"`
+	Drift   []migrationPlan `json:"drift"`
+}
+
+// schemaDriftCheck is attached to the context when checking for a schema
+// drift.
+type schemaDriftCheck struct {
+	heal  bool
+	drift []migrationPlan
+}
+
+type (
+	migrationPlanKey    struct{}
+	schemaDriftCheckKey struct{}
+)
+
+var spacesRegex = regexp.MustCompile(`\s+`)
+
+// migrationPlanFromContext returns the migration plan attached to the
+// context, if any.
+func migrationPlanFromContext(ctx context.Context) *migrationPlan {
+	plan, _ := ctx.Value(migrationPlanKey{}).(*migrationPlan)
+	return plan
+}
+
+// migrationExec executes a statement for a migration step. When the context
+// has a migration plan attached, the statement is only recorded.
+func (c *Component) migrationExec(ctx context.Context, query string, args ...any) error {
+	if plan := migrationPlanFromContext(ctx); plan != nil {
+		plan.Statements = append(plan.Statements,
+			strings.TrimSpace(spacesRegex.ReplaceAllString(query, " ")))
+		return nil
+	}
+	return c.d.ClickHouse.ExecOnCluster(ctx, query, args...)
+}
+
+// checkMigrations plans each of the provided migration steps and records the
+// ones which would execute statements. When healing is requested, the
+// non-destructive ones are applied.
+func (c *Component) checkMigrations(ctx context.Context, check *schemaDriftCheck, fns ...func(context.Context) error) error {
+	for _, fn := range fns {
+		plan := migrationPlan{}
+		if err := fn(context.WithValue(ctx, migrationPlanKey{}, &plan)); err != nil && err != errSkipStep {
+			return err
+		}
+		if len(plan.Statements) == 0 {
+			continue
+		}
+		if check.heal && !plan.Destructive {
+			if err := fn(ctx); err != nil && err != errSkipStep {
+				return err
+			}
+			plan.Healed = true
+			c.metrics.schemaDriftHealed.Inc()
+		}
+		check.drift = append(check.drift, plan)
+	}
+	return nil
+}
+
+// checkSchemaDrift compares the schema of the database with the expected one
+// and returns a report. Non-destructive drift is fixed if configured so.
+func (c *Component) checkSchemaDrift(ctx context.Context) *schemaDriftReport {
+	c.schemaDriftLock.Lock()
+	defer c.schemaDriftLock.Unlock()
+
+	check := schemaDriftCheck{heal: c.config.SchemaDrift.AutoHeal}
+	err := c.applyMigrations(context.WithValue(ctx, schemaDriftCheckKey{}, &check))
+	report := schemaDriftReport{
+		Checked: time.Now(),
+		Drift:   []migrationPlan{},
+	}
+	if err != nil {
+		c.r.Err(err).Msg("cannot check schema drift")
+		c.metrics.schemaDriftErrors.Inc()
+		report.Error = err.Error()
+	}
+	pending, destructive := 0, 0
+	for _, plan := range check.drift {
+		report.Drift = append(report.Drift, plan)
+		if plan.Healed {
+			continue
+		}
+		pending++
+		if plan.Destructive {
+			destructive++
+		}
+	}
+	c.metrics.schemaDriftSteps.Set(float64(pending))
+	c.metrics.schemaDriftDestructiveSteps.Set(float64(destructive))
+	if pending > 0 {
+		c.r.Warn().Msgf("schema drift detected: %d migration steps to apply (%d destructive)",
+			pending, destructive)
+	}
+	c.schemaDriftReport.Store(&report)
+	return &report
+}
+
+// schemaDriftChecker periodically checks for a schema drift once migrations
+// are done.
+func (c *Component) schemaDriftChecker() error {
+	select {
+	case <-c.t.Dying():
+		return nil
+	case <-c.migrationsDone:
+	}
+	ticker := time.NewTicker(c.config.SchemaDrift.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(c.t.Context(nil), c.config.SchemaDrift.Interval)
+		c.checkSchemaDrift(ctx)
+		cancel()
+	}
+}
+
+func (c *Component) schemaDriftHandlerFunc(gc *gin.Context) {
+	if c.d.ClickHouse == nil {
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "ClickHouse is not available."})
+		return
+	}
+	if gc.Request.Method == http.MethodPost {
+		select {
+		case <-c.migrationsDone:
+		default:
+			gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "Migrations are not done yet."})
+			return
+		}
+		gc.JSON(http.StatusOK, c.checkSchemaDrift(gc.Request.Context()))
+		return
+	}
+	report := c.schemaDriftReport.Load()
+	if report == nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Schema drift not checked yet."})
+		return
+	}
+	gc.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestCheckMigrations(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	steps := []func(context.Context) error{
+		// Non-destructive step
+		func(ctx context.Context) error {
+			if err := c.migrationExec(ctx, "DROP TABLE IF EXISTS foo_consumer SYNC"); err != nil {
+				return err
+			}
+			return c.migrationExec(ctx, "CREATE MATERIALIZED VIEW foo_consumer\nTO foo AS SELECT 1")
+		},
+		// Nothing to do
+		func(context.Context) error {
+			return errSkipStep
+		},
+		// Destructive step
+		func(ctx context.Context) error {
+			if err := c.backupTableOnce("bar")(ctx); err != nil {
+				return err
+			}
+			return c.migrationExec(ctx, "ALTER TABLE bar DROP COLUMN baz")
+		},
+	}
+
+	t.Run("check", func(t *testing.T) {
+		check := schemaDriftCheck{}
+		if err := c.wrapMigrations(
+			context.WithValue(context.Background(), schemaDriftCheckKey{}, &check),
+			steps...); err != nil {
+			t.Fatalf("wrapMigrations() error:\n%+v", err)
+		}
+		expected := []migrationPlan{
+			{
+				Statements: []string{
+					"DROP TABLE IF EXISTS foo_consumer SYNC",
+					"CREATE MATERIALIZED VIEW foo_consumer TO foo AS SELECT 1",
+				},
+			}, {
+				Statements:  []string{"ALTER TABLE bar DROP COLUMN baz"},
+				Destructive: true,
+			},
+		}
+		if diff := helpers.Diff(check.drift, expected); diff != "" {
+			t.Fatalf("wrapMigrations() (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("heal", func(t *testing.T) {
+		mockConn.EXPECT().
+			Exec(gomock.Any(), "DROP TABLE IF EXISTS foo_consumer SYNC").
+			Return(nil)
+		mockConn.EXPECT().
+			Exec(gomock.Any(), "CREATE MATERIALIZED VIEW foo_consumer\nTO foo AS SELECT 1").
+			Return(nil)
+		check := schemaDriftCheck{heal: true}
+		if err := c.wrapMigrations(
+			context.WithValue(context.Background(), schemaDriftCheckKey{}, &check),
+			steps...); err != nil {
+			t.Fatalf("wrapMigrations() error:\n%+v", err)
+		}
+		if len(check.drift) != 2 || !check.drift[0].Healed || check.drift[1].Healed {
+			t.Fatalf("wrapMigrations() healed the wrong steps: %+v", check.drift)
+		}
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "schema_drift_", "migrations_")
+		expectedMetrics := map[string]string{
+			`schema_drift_errors_total`:         "0",
+			`schema_drift_healed_steps_total`:   "1",
+			`schema_drift_steps`:                "0",
+			`schema_drift_destructive_steps`:    "0",
+			`migrations_applied_steps_total`:    "0",
+			`migrations_backups_total`:          "0",
+			`migrations_notapplied_steps_total`: "0",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+}
+
+func TestSchemaDrift(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+	ch := startTestComponent(t, r, chComponent, nil)
+	ctx := context.Background()
+
+	// No drift after migrations
+	report := ch.checkSchemaDrift(ctx)
+	if report.Error != "" || len(report.Drift) != 0 {
+		t.Fatalf("checkSchemaDrift() after migrations:\n%+v", report)
+	}
+
+	// Drop a view and check again
+	if err := chComponent.ExecOnCluster(ctx, "DROP TABLE exporters_consumer SYNC"); err != nil {
+		t.Fatalf("ExecOnCluster() error:\n%+v", err)
+	}
+	report = ch.checkSchemaDrift(ctx)
+	if report.Error != "" || len(report.Drift) != 1 || report.Drift[0].Destructive {
+		t.Fatalf("checkSchemaDrift() after dropping a view:\n%+v", report)
+	}
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "schema_drift_steps")
+	expectedMetrics := map[string]string{`schema_drift_steps`: "1"}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Heal it
+	ch.config.SchemaDrift.AutoHeal = true
+	report = ch.checkSchemaDrift(ctx)
+	if report.Error != "" || len(report.Drift) != 1 || !report.Drift[0].Healed {
+		t.Fatalf("checkSchemaDrift() with auto-heal:\n%+v", report)
+	}
+	report = ch.checkSchemaDrift(ctx)
+	if report.Error != "" || len(report.Drift) != 0 {
+		t.Fatalf("checkSchemaDrift() after auto-heal:\n%+v", report)
+	}
+}
+
+func TestSchemaDriftEndpoint(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, _ := clickhousedb.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "no check yet",
+			URL:         "/api/v0/orchestrator/clickhouse/schema/drift",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Schema drift not checked yet."},
+		}, {
+			Description: "check without token",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/schema/drift",
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "check before migrations",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/schema/drift",
+			Header:      httpserver.MockAdminHeader(),
+			StatusCode:  503,
+			JSONOutput:  gin.H{"message": "Migrations are not done yet."},
+		},
+	})
+
+	c.schemaDriftReport.Store(&schemaDriftReport{
+		Checked: time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC),
+		Drift: []migrationPlan{
+			{
+				Statements:  []string{"ALTER TABLE flows MODIFY TTL TimeReceived + toIntervalSecond(86400)"},
+				Destructive: true,
+			},
+		},
+	})
+	helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "last report",
+			URL:         "/api/v0/orchestrator/clickhouse/schema/drift",
+			JSONOutput: gin.H{
+				"checked": "2026-10-16T10:00:00Z",
+				"drift": []gin.H{
+					{
+						"statements":  []string{"ALTER TABLE flows MODIFY TTL TimeReceived + toIntervalSecond(86400)"},
+						"destructive": true,
+						"healed":      false,
+					},
+				},
+			},
+		},
+	})
+}
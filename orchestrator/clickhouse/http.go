@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
@@ -44,6 +46,22 @@ func (c *Component) registerHTTPHandlers() error {
 		}))
 	}
 
+	// TTL materialization
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/clickhouse/ttl/materialize", c.ttlMaterializeHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/clickhouse/ttl/materialize", c.d.HTTP.AdminOnly, c.ttlMaterializeHandlerFunc)
+
+	// Schema drift
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/clickhouse/schema/drift", c.schemaDriftHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/clickhouse/schema/drift", c.d.HTTP.AdminOnly, c.schemaDriftHandlerFunc)
+
+	// Raw tables collection
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/clickhouse/raw-tables/gc", c.rawTablesGCHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/clickhouse/raw-tables/gc", c.d.HTTP.AdminOnly, c.rawTablesGCHandlerFunc)
+
+	// Resolution gaps backfill
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/clickhouse/backfill", c.backfillHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/clickhouse/backfill", c.d.HTTP.AdminOnly, c.backfillHandlerFunc)
+
 	// networks.csv
 	c.d.HTTP.AddHandler("/api/v0/orchestrator/clickhouse/networks.csv",
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -106,7 +124,8 @@ func (c *Component) registerHTTPHandlers() error {
 		}))
 
 	// asns.csv (when there are some custom-defined ASNs)
-	if len(c.config.ASNs) != 0 {
+	customASNs := len(c.config.ASNs) != 0 || len(c.config.ASNSources) != 0
+	if customASNs {
 		c.d.HTTP.AddHandler("/api/v0/orchestrator/clickhouse/asns.csv",
 			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 				f, err := data.Open("data/asns.csv")
@@ -124,8 +143,9 @@ func (c *Component) registerHTTPHandlers() error {
 				wr := csv.NewWriter(w)
 				wr.Write([]string{"asn", "name"})
 				// Custom ASNs
-				for asn, name := range c.config.ASNs {
-					wr.Write([]string{strconv.Itoa(int(asn)), name})
+				overrides := c.asnOverrides()
+				for _, asn := range slices.Sorted(maps.Keys(overrides)) {
+					wr.Write([]string{strconv.Itoa(int(asn)), overrides[asn]})
 				}
 				// Other ASNs
 				for count := 0; ; count++ {
@@ -145,7 +165,7 @@ func (c *Component) registerHTTPHandlers() error {
 						c.r.Err(err).Msgf("invalid AS number (line %d)", count)
 						continue
 					}
-					if _, ok := c.config.ASNs[uint32(asn)]; !ok {
+					if _, ok := overrides[uint32(asn)]; !ok {
 						wr.Write(record)
 					}
 				}
@@ -162,7 +182,7 @@ func (c *Component) registerHTTPHandlers() error {
 		if entry.IsDir() {
 			continue
 		}
-		if entry.Name() == "asns.csv" && len(c.config.ASNs) != 0 {
+		if entry.Name() == "asns.csv" && customASNs {
 			continue
 		}
 		url := fmt.Sprintf("/api/v0/orchestrator/clickhouse/%s", entry.Name())
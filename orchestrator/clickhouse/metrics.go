@@ -9,8 +9,31 @@ type metrics struct {
 	migrationsRunning    reporter.Gauge
 	migrationsApplied    reporter.Counter
 	migrationsNotApplied reporter.Counter
+	migrationsBackups    reporter.Counter
 
 	networksReload reporter.Counter
+	asnsReload     reporter.Counter
+
+	dictionaryReload     *reporter.CounterVec
+	dictionaryLoaded     *reporter.GaugeVec
+	dictionaryElements   *reporter.GaugeVec
+	dictionaryLastUpdate *reporter.GaugeVec
+
+	schemaDriftSteps            reporter.Gauge
+	schemaDriftDestructiveSteps reporter.Gauge
+	schemaDriftHealed           reporter.Counter
+	schemaDriftErrors           reporter.Counter
+
+	rawTablesDropped  reporter.Counter
+	rawTablesObsolete reporter.Gauge
+	rawTablesGCErrors reporter.Counter
+
+	remoteWriteSeries reporter.Counter
+	remoteWriteErrors reporter.Counter
+
+	resolutionGaps reporter.Gauge
+	backfillChunks reporter.Counter
+	backfillErrors reporter.Counter
 }
 
 func (c *Component) initMetrics() {
@@ -32,10 +55,122 @@ func (c *Component) initMetrics() {
 			Help: "Number of migration steps not applied.",
 		},
 	)
+	c.metrics.migrationsBackups = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "migrations_backups_total",
+			Help: "Number of tables backed up before a destructive migration step.",
+		},
+	)
 	c.metrics.networksReload = c.r.Counter(
 		reporter.CounterOpts{
 			Name: "networks_dictionary_reload_total",
 			Help: "Number of reloads triggered for networks dictionary.",
 		},
 	)
+	c.metrics.asnsReload = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "asns_dictionary_reload_total",
+			Help: "Number of reloads triggered for ASN dictionary.",
+		},
+	)
+	c.metrics.dictionaryReload = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "custom_dictionary_reload_total",
+			Help: "Number of reloads triggered for custom dictionaries.",
+		},
+		[]string{"dictionary"},
+	)
+	c.metrics.dictionaryLoaded = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "dictionary_loaded",
+			Help: "Whether a dictionary is loaded in ClickHouse.",
+		},
+		[]string{"dictionary"},
+	)
+	c.metrics.dictionaryElements = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "dictionary_elements",
+			Help: "Number of elements in a dictionary.",
+		},
+		[]string{"dictionary"},
+	)
+	c.metrics.dictionaryLastUpdate = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "dictionary_last_update_seconds",
+			Help: "Timestamp of the last successful update of a dictionary.",
+		},
+		[]string{"dictionary"},
+	)
+	c.metrics.schemaDriftSteps = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "schema_drift_steps",
+			Help: "Number of migration steps to apply to fix the schema drift.",
+		},
+	)
+	c.metrics.schemaDriftDestructiveSteps = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "schema_drift_destructive_steps",
+			Help: "Number of destructive migration steps to apply to fix the schema drift.",
+		},
+	)
+	c.metrics.schemaDriftHealed = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "schema_drift_healed_steps_total",
+			Help: "Number of migration steps applied to fix a schema drift.",
+		},
+	)
+	c.metrics.schemaDriftErrors = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "schema_drift_errors_total",
+			Help: "Number of errors while checking for a schema drift.",
+		},
+	)
+	c.metrics.rawTablesDropped = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "raw_tables_dropped_generations_total",
+			Help: "Number of generations of raw tables dropped.",
+		},
+	)
+	c.metrics.rawTablesObsolete = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "raw_tables_obsolete_generations",
+			Help: "Number of generations of raw tables from previous schemas still present.",
+		},
+	)
+	c.metrics.rawTablesGCErrors = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "raw_tables_gc_errors_total",
+			Help: "Number of errors while collecting raw tables.",
+		},
+	)
+	c.metrics.remoteWriteSeries = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "remote_write_series_total",
+			Help: "Number of series pushed with remote-write.",
+		},
+	)
+	c.metrics.remoteWriteErrors = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "remote_write_errors_total",
+			Help: "Number of errors while pushing series with remote-write.",
+		},
+	)
+	c.metrics.resolutionGaps = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "resolution_gaps",
+			Help: "Number of gaps detected in aggregated tables.",
+		},
+	)
+	c.metrics.backfillChunks = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "backfill_chunks_total",
+			Help: "Number of chunks inserted while backfilling aggregated tables.",
+		},
+	)
+	c.metrics.backfillErrors = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "backfill_errors_total",
+			Help: "Number of errors while backfilling aggregated tables.",
+		},
+	)
 }
@@ -10,9 +10,27 @@ import (
 	"net"
 	"strings"
 
+	"akvorado/common/reporter"
 	"akvorado/common/schema"
 )
 
+// migrationsHealthcheck tells if the database migrations are done. Until then,
+// the orchestrator is not ready.
+func (c *Component) migrationsHealthcheck(context.Context) reporter.HealthcheckResult {
+	select {
+	case <-c.migrationsDone:
+		return reporter.HealthcheckResult{
+			Status: reporter.HealthcheckOK,
+			Reason: "migrations done",
+		}
+	default:
+		return reporter.HealthcheckResult{
+			Status: reporter.HealthcheckStarting,
+			Reason: "migrations in progress",
+		}
+	}
+}
+
 // migrateDatabase execute database migration
 func (c *Component) migrateDatabase() error {
 	ctx := c.t.Context(nil)
@@ -53,6 +71,35 @@ func (c *Component) migrateDatabase() error {
 		c.shards = int(shardNum)
 	}
 
+	migrationCtx, span := c.r.StartSpan(ctx, "migrate database")
+	err := c.applyMigrations(migrationCtx)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+	if err != nil {
+		return err
+	}
+	if err := c.applyAccessControl(ctx); err != nil {
+		return fmt.Errorf("unable to apply access control: %w", err)
+	}
+
+	close(c.migrationsDone)
+	c.metrics.migrationsRunning.Set(0)
+	c.r.Info().Msg("database migration done")
+
+	// Reload dictionaries
+	if err := c.d.ClickHouse.ExecOnCluster(ctx, "SYSTEM RELOAD DICTIONARIES"); err != nil {
+		c.r.Err(err).Msg("unable to reload dictionaries after migration")
+	}
+
+	return nil
+}
+
+// applyMigrations executes all the migration steps. This is also used to
+// detect a drift of the schema: in this case, the statements are only
+// recorded.
+func (c *Component) applyMigrations(ctx context.Context) error {
 	// Create dictionaries
 	err := c.wrapMigrations(
 		ctx,
@@ -133,27 +180,43 @@ func (c *Component) migrateDatabase() error {
 		}
 	}
 
+	// Custom views
+	for _, view := range c.config.CustomViews {
+		err := c.wrapMigrations(ctx,
+			func(ctx context.Context) error {
+				return c.createCustomViewTable(ctx, view)
+			}, func(ctx context.Context) error {
+				return c.createDistributedTable(ctx, view.Name)
+			}, func(ctx context.Context) error {
+				return c.createCustomViewConsumerView(ctx, view)
+			})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Remaining tables
 	err = c.wrapMigrations(ctx,
 		c.createExportersTable,
 		c.createExportersConsumerView,
+		c.createExportersAccountingTable,
+		func(ctx context.Context) error {
+			return c.createDistributedTable(ctx, "exporters_accounting")
+		},
+		c.createFlowsQuarantineTable,
+		func(ctx context.Context) error {
+			return c.createDistributedTable(ctx, "flows_quarantine")
+		},
+		c.createInterfacesCountersTable,
+		func(ctx context.Context) error {
+			return c.createDistributedTable(ctx, "interfaces_counters")
+		},
 		c.createRawFlowsTable,
 		c.createRawFlowsConsumerView,
+		c.createLocalRawFlowsTable,
+		c.createLocalRawFlowsConsumerView,
 	)
-	if err != nil {
-		return err
-	}
-
-	close(c.migrationsDone)
-	c.metrics.migrationsRunning.Set(0)
-	c.r.Info().Msg("database migration done")
-
-	// Reload dictionaries
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, "SYSTEM RELOAD DICTIONARIES"); err != nil {
-		c.r.Err(err).Msg("unable to reload dictionaries after migration")
-	}
-
-	return nil
+	return err
 }
 
 // guessHTTPBaseURL tries to guess the appropriate URL to access our
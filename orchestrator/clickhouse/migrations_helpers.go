@@ -4,6 +4,7 @@
 package clickhouse
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
@@ -26,6 +27,9 @@ var errSkipStep = errors.New("migration: skip this step")
 // metrics up-to-date as long as the migration function returns `errSkipStep`
 // when a step is skipped.
 func (c *Component) wrapMigrations(ctx context.Context, fns ...func(context.Context) error) error {
+	if check, ok := ctx.Value(schemaDriftCheckKey{}).(*schemaDriftCheck); ok {
+		return c.checkMigrations(ctx, check, fns...)
+	}
 	for _, fn := range fns {
 		if err := fn(ctx); err == nil {
 			c.metrics.migrationsApplied.Inc()
@@ -58,8 +62,8 @@ func quoteString(s string) string {
 }
 
 // tableAlreadyExists compare the provided table with the one in database.
-// `column` can either be "create_table_query" or "as_select". target is the
-// expected value.
+// `column` can be "create_table_query", "as_select" or any other column of
+// system.tables, like "name" or "comment". target is the expected value.
 func (c *Component) tableAlreadyExists(ctx context.Context, table, column, target string) (bool, error) {
 	// Normalize a bit the target. This is far from perfect, but we test that
 	// and we hope this does not differ between ClickHouse versions!
@@ -171,7 +175,7 @@ LAYOUT({{ .Layout }}())
 	}
 	c.r.Info().Msgf("create dictionary %s", name)
 	createOrReplaceQuery := strings.Replace(createQuery, "CREATE ", "CREATE OR REPLACE ", 1)
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createOrReplaceQuery); err != nil {
+	if err := c.migrationExec(ctx, createOrReplaceQuery); err != nil {
 		return fmt.Errorf("cannot create dictionary %s: %w", name, err)
 	}
 	return nil
@@ -224,7 +228,7 @@ TTL TimeReceived + toIntervalDay(1)`,
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
 	createOrReplaceQuery := strings.Replace(createQuery, "CREATE ", "CREATE OR REPLACE ", 1)
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createOrReplaceQuery); err != nil {
+	if err := c.migrationExec(ctx, createOrReplaceQuery); err != nil {
 		return fmt.Errorf("cannot create exporters table: %w", err)
 	}
 
@@ -270,10 +274,10 @@ func (c *Component) createExportersConsumerView(ctx context.Context) error {
 
 	// Drop existing table and recreate
 	c.r.Info().Msg("create exporters view")
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, `DROP TABLE IF EXISTS exporters_consumer SYNC`); err != nil {
+	if err := c.migrationExec(ctx, `DROP TABLE IF EXISTS exporters_consumer SYNC`); err != nil {
 		return fmt.Errorf("cannot drop existing exporters view: %w", err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`
+	if err := c.migrationExec(ctx, fmt.Sprintf(`
 CREATE MATERIALIZED VIEW exporters_consumer TO %s AS %s
 `, "exporters", selectQuery)); err != nil {
 		return fmt.Errorf("cannot create exporters view: %w", err)
@@ -282,11 +286,172 @@ CREATE MATERIALIZED VIEW exporters_consumer TO %s AS %s
 	return nil
 }
 
+// createExportersAccountingTable creates the table for the per-exporter ingest
+// accounting written by the outlets. Data is kept as long as the longest
+// resolution. An existing table is not modified.
+func (c *Component) createExportersAccountingTable(ctx context.Context) error {
+	tableName := c.localTable("exporters_accounting")
+	if ok, err := c.tableAlreadyExists(ctx, tableName, "name", tableName); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("%s already exists, skip migration", tableName)
+		return errSkipStep
+	}
+
+	var ttl time.Duration
+	for _, resolution := range c.config.Resolutions {
+		if resolution.TTL == 0 {
+			ttl = 0
+			break
+		}
+		ttl = max(ttl, resolution.TTL)
+	}
+	cols := []string{
+		"`TimeReceived` DateTime",
+		"`ExporterAddress` LowCardinality(IPv6)",
+		"`Flows` UInt64",
+		"`Bytes` UInt64",
+		"`DecodeErrors` UInt64",
+		"`EnrichmentMisses` UInt64",
+	}
+	createQuery, err := stemplate(`
+CREATE TABLE {{ .Database }}.{{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMM(TimeReceived)
+ORDER BY (TimeReceived, ExporterAddress)
+{{- if .TTL }}
+TTL TimeReceived + toIntervalSecond({{ .TTL }})
+{{- end }}`, gin.H{
+		"Database": c.d.ClickHouse.DatabaseName(),
+		"Table":    tableName,
+		"Schema":   strings.Join(cols, ", "),
+		"Engine":   c.mergeTreeEngine(tableName, "Summing", "(Flows, Bytes, DecodeErrors, EnrichmentMisses)"),
+		"TTL":      uint64(ttl.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot build query to create %s: %w", tableName, err)
+	}
+	c.r.Info().Msgf("create %s", tableName)
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// createFlowsQuarantineTable creates the table for the flows which could not
+// be enriched and were diverted to quarantine by the outlets. Data is only
+// kept for a week. An existing table is not modified.
+func (c *Component) createFlowsQuarantineTable(ctx context.Context) error {
+	tableName := c.localTable("flows_quarantine")
+	if ok, err := c.tableAlreadyExists(ctx, tableName, "name", tableName); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("%s already exists, skip migration", tableName)
+		return errSkipStep
+	}
+
+	cols := []string{
+		"`TimeReceived` DateTime",
+		"`ExporterAddress` LowCardinality(IPv6)",
+		"`Source` LowCardinality(String)",
+		"`InIf` UInt32",
+		"`OutIf` UInt32",
+		"`SamplingRate` UInt64",
+		"`Bytes` UInt64",
+		"`Packets` UInt64",
+	}
+	createQuery, err := stemplate(`
+CREATE TABLE {{ .Database }}.{{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMMDD(TimeReceived)
+ORDER BY (TimeReceived, ExporterAddress)
+TTL TimeReceived + toIntervalSecond({{ .TTL }})`, gin.H{
+		"Database": c.d.ClickHouse.DatabaseName(),
+		"Table":    tableName,
+		"Schema":   strings.Join(cols, ", "),
+		"Engine":   c.mergeTreeEngine(tableName, ""),
+		"TTL":      uint64((7 * 24 * time.Hour).Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot build query to create %s: %w", tableName, err)
+	}
+	c.r.Info().Msgf("create %s", tableName)
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// createInterfacesCountersTable creates the table for the interface counters
+// polled by the metadata providers of the outlets. Data is kept for a month.
+// An existing table is not modified.
+func (c *Component) createInterfacesCountersTable(ctx context.Context) error {
+	tableName := c.localTable("interfaces_counters")
+	if ok, err := c.tableAlreadyExists(ctx, tableName, "name", tableName); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("%s already exists, skip migration", tableName)
+		return errSkipStep
+	}
+
+	cols := []string{
+		"`TimeReceived` DateTime",
+		"`ExporterAddress` LowCardinality(IPv6)",
+		"`ExporterName` LowCardinality(String)",
+		"`IfIndex` UInt32",
+		"`IfName` LowCardinality(String)",
+		"`InOctets` UInt64",
+		"`OutOctets` UInt64",
+	}
+	createQuery, err := stemplate(`
+CREATE TABLE {{ .Database }}.{{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMM(TimeReceived)
+ORDER BY (ExporterAddress, IfName, TimeReceived)
+TTL TimeReceived + toIntervalSecond({{ .TTL }})`, gin.H{
+		"Database": c.d.ClickHouse.DatabaseName(),
+		"Table":    tableName,
+		"Schema":   strings.Join(cols, ", "),
+		"Engine":   c.mergeTreeEngine(tableName, ""),
+		"TTL":      uint64((30 * 24 * time.Hour).Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot build query to create %s: %w", tableName, err)
+	}
+	c.r.Info().Msgf("create %s", tableName)
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tableName, err)
+	}
+	return nil
+}
+
 // createRawFlowsTable creates the raw flow table
 func (c *Component) createRawFlowsTable(ctx context.Context) error {
-	hash := c.d.Schema.ClickHouseHash()
-	tableName := fmt.Sprintf("flows_%s_raw", hash)
+	return c.createRawFlowsTableNamed(ctx, fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash()))
+}
 
+// createLocalRawFlowsTable creates the raw flow table feeding the local flows
+// table of a shard. It is used by the outlet to insert flows directly into a
+// shard, bypassing the distributed table.
+func (c *Component) createLocalRawFlowsTable(ctx context.Context) error {
+	tableName := fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash())
+	if c.localTable(tableName) == tableName {
+		return errSkipStep
+	}
+	return c.createRawFlowsTableNamed(ctx, c.localTable(tableName))
+}
+
+// createRawFlowsTableNamed creates a raw flow table with the provided name.
+func (c *Component) createRawFlowsTableNamed(ctx context.Context, tableName string) error {
 	// Build CREATE query
 	createQuery, err := stemplate(
 		"CREATE TABLE {{ .Database }}.{{ .Table }} ({{ .Schema }}) ENGINE = `Null`",
@@ -298,32 +463,32 @@ func (c *Component) createRawFlowsTable(ctx context.Context) error {
 				schema.ClickHouseSkipAliasedColumns),
 		})
 	if err != nil {
-		return fmt.Errorf("cannot build query to create raw flows table: %w", err)
+		return fmt.Errorf("cannot build query to create %s: %w", tableName, err)
 	}
 
 	// Check if the table already exists with the right schema
 	if ok, err := c.tableAlreadyExists(ctx, tableName, "create_table_query", createQuery); err != nil {
 		return err
 	} else if ok {
-		c.r.Info().Msg("raw flows table already exists, skip migration")
+		c.r.Info().Msgf("%s already exists, skip migration", tableName)
 		return errSkipStep
 	}
 
 	// Drop table if it exists as well as all the dependents and recreate the raw table
-	c.r.Info().Msg("create raw flows table")
+	c.r.Info().Msgf("create %s", tableName)
 	for _, table := range []string{
 		fmt.Sprintf("%s_consumer", tableName),
 		tableName,
 	} {
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, table)); err != nil {
+		if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, table)); err != nil {
 			return fmt.Errorf("cannot drop %s: %w", table, err)
 		}
 	}
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
-		return fmt.Errorf("cannot create raw flows table: %w", err)
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tableName, err)
 	}
 
 	return nil
@@ -331,8 +496,27 @@ func (c *Component) createRawFlowsTable(ctx context.Context) error {
 
 var dictionaryNetworksLookupRegex = regexp.MustCompile(`\bc_(Src|Dst)Networks\[([[:lower:]]+)\]\B`)
 
+// createRawFlowsConsumerView creates the view moving flows from the raw flow
+// table to the flows table.
 func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
+	return c.createRawFlowsConsumerViewNamed(ctx,
+		fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash()),
+		c.distributedTable("flows"))
+}
+
+// createLocalRawFlowsConsumerView creates the view moving flows from the local
+// raw flow table to the local flows table.
+func (c *Component) createLocalRawFlowsConsumerView(ctx context.Context) error {
 	tableName := fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash())
+	if c.localTable(tableName) == tableName {
+		return errSkipStep
+	}
+	return c.createRawFlowsConsumerViewNamed(ctx, c.localTable(tableName), c.localTable("flows"))
+}
+
+// createRawFlowsConsumerViewNamed creates the view moving flows from the
+// provided raw flow table to the provided target table.
+func (c *Component) createRawFlowsConsumerViewNamed(ctx context.Context, tableName, target string) error {
 	viewName := fmt.Sprintf("%s_consumer", tableName)
 
 	// Build SELECT query
@@ -410,19 +594,19 @@ func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
 	if ok, err := c.tableAlreadyExists(ctx, viewName, "as_select", selectQuery); err != nil {
 		return err
 	} else if ok {
-		c.r.Info().Msg("raw flows consumer view already exists, skip migration")
+		c.r.Info().Msgf("%s already exists, skip migration", viewName)
 		return errSkipStep
 	}
 
 	// Drop and create
-	c.r.Info().Msg("create raw flows consumer view")
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+	c.r.Info().Msgf("create %s", viewName)
+	if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx,
+	if err := c.migrationExec(ctx,
 		fmt.Sprintf("CREATE MATERIALIZED VIEW %s TO %s AS %s",
-			viewName, c.distributedTable("flows"), selectQuery)); err != nil {
-		return fmt.Errorf("cannot create raw flows consumer view: %w", err)
+			viewName, target, selectQuery)); err != nil {
+		return fmt.Errorf("cannot create %s: %w", viewName, err)
 	}
 
 	return nil
@@ -439,56 +623,25 @@ func (c *Component) createOrUpdateFlowsTable(ctx context.Context, resolution Res
 		tableName = fmt.Sprintf("flows_%s", resolution.Interval)
 	}
 	tableName = c.localTable(tableName)
-	partitionInterval := uint64((resolution.TTL / time.Duration(c.config.MaxPartitions)).Seconds())
-	ttl := uint64(resolution.TTL.Seconds())
+	sch, err := c.d.Schema.WithMainOnlyColumns(resolution.SkipColumns)
+	if err != nil {
+		return fmt.Errorf("cannot build schema for %s: %w", tableName, err)
+	}
+	ttlClause := flowsTableTTLClause(resolution)
 	settings := `index_granularity = 8192, ttl_only_drop_parts = 1`
+	if resolution.StoragePolicy != "" {
+		settings = fmt.Sprintf("%s, storage_policy = %s", settings, quoteString(resolution.StoragePolicy))
+	}
 
 	// Create table if it does not exist
 	if ok, err := c.tableAlreadyExists(ctx, tableName, "name", tableName); err != nil {
 		return err
 	} else if !ok {
-		var createQuery string
-		var err error
-		if resolution.Interval == 0 {
-			createQuery, err = stemplate(`
-CREATE TABLE {{ .Table }} ({{ .Schema }})
-ENGINE = {{ .Engine }}
-PARTITION BY toYYYYMMDDhhmmss(toStartOfInterval(TimeReceived, INTERVAL {{ .PartitionInterval }} second))
-ORDER BY (toStartOfFiveMinutes(TimeReceived), ExporterAddress, InIfName, OutIfName)
-TTL TimeReceived + toIntervalSecond({{ .TTL }})
-SETTINGS {{ .Settings }}
-`, gin.H{
-				"Table":             tableName,
-				"Schema":            c.d.Schema.ClickHouseCreateTable(),
-				"PartitionInterval": partitionInterval,
-				"TTL":               ttl,
-				"Engine":            c.mergeTreeEngine(tableName, ""),
-				"Settings":          settings,
-			})
-		} else {
-			createQuery, err = stemplate(`
-CREATE TABLE {{ .Table }} ({{ .Schema }})
-ENGINE = {{ .Engine }}
-PARTITION BY toYYYYMMDDhhmmss(toStartOfInterval(TimeReceived, INTERVAL {{ .PartitionInterval }} second))
-PRIMARY KEY ({{ .PrimaryKey }})
-ORDER BY ({{ .SortingKey }})
-TTL TimeReceived + toIntervalSecond({{ .TTL }})
-SETTINGS {{ .Settings }}
-`, gin.H{
-				"Table":             tableName,
-				"Schema":            c.d.Schema.ClickHouseCreateTable(schema.ClickHouseSkipMainOnlyColumns),
-				"PartitionInterval": partitionInterval,
-				"PrimaryKey":        strings.Join(c.d.Schema.ClickHousePrimaryKeys(), ", "),
-				"SortingKey":        strings.Join(c.d.Schema.ClickHouseSortingKeys(), ", "),
-				"TTL":               ttl,
-				"Engine":            c.mergeTreeEngine(tableName, "Summing", "(Bytes, Packets)"),
-				"Settings":          settings,
-			})
-		}
+		createQuery, err := c.flowsTableCreateQuery(tableName, resolution, sch, settings)
 		if err != nil {
 			return fmt.Errorf("cannot build create table statement for %s: %w", tableName, err)
 		}
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
+		if err := c.migrationExec(ctx, createQuery); err != nil {
 			return fmt.Errorf("cannot create %s: %w", tableName, err)
 		}
 		return nil
@@ -516,9 +669,66 @@ ORDER BY position ASC
 	// Plan for modifications. We don't check everything: we assume the
 	// modifications to be done are covered by the unit tests.
 	modifications := []string{}
+	destructive := false
+	backup := c.backupTableOnce(tableName)
+
+	// Remove the columns we don't want to keep in this table anymore. A
+	// column cannot be removed from the sorting key with MODIFY ORDER BY: in
+	// this case, the aggregated table is recreated.
+	droppedColumns := []string{}
+	recreate := false
+	for _, existingColumn := range existingColumns {
+		column, ok := sch.LookupColumnByName(existingColumn.Name)
+		if !ok {
+			continue
+		}
+		if resolution.Interval > 0 && slices.Contains(resolution.SkipColumns, column.Key) {
+			droppedColumns = append(droppedColumns,
+				fmt.Sprintf("DROP COLUMN %s", existingColumn.Name))
+			recreate = recreate || existingColumn.IsSortingKey != 0
+			continue
+		}
+		if !column.Disabled {
+			continue
+		}
+		// The column was disabled in the schema.
+		if existingColumn.DefaultKind == "ALIAS" {
+			c.r.Info().Msgf("drop disabled column %s from %s", existingColumn.Name, tableName)
+			modifications = append(modifications,
+				fmt.Sprintf("DROP COLUMN %s", existingColumn.Name))
+			continue
+		}
+		if resolution.Interval == 0 && existingColumn.IsSortingKey != 0 {
+			c.r.Warn().Msgf("disabled column %s is part of the sorting key of %s, keep it",
+				existingColumn.Name, tableName)
+			continue
+		}
+		if !c.config.DropPopulatedColumns {
+			populated, err := c.columnIsPopulated(ctx, tableName, existingColumn.Name)
+			if err != nil {
+				return err
+			}
+			if populated {
+				c.r.Warn().Msgf("disabled column %s from %s contains data, keep it (drop-populated-columns is not set)",
+					existingColumn.Name, tableName)
+				continue
+			}
+		}
+		c.r.Info().Msgf("drop disabled column %s from %s", existingColumn.Name, tableName)
+		droppedColumns = append(droppedColumns,
+			fmt.Sprintf("DROP COLUMN %s", existingColumn.Name))
+		recreate = recreate || (resolution.Interval > 0 && existingColumn.IsSortingKey != 0)
+	}
+	if recreate {
+		if err := backup(ctx); err != nil {
+			return err
+		}
+		return c.recreateFlowsTable(ctx, tableName, resolution, sch, settings)
+	}
+
 	previousColumn := ""
 outer:
-	for _, wantedColumn := range c.d.Schema.Columns() {
+	for _, wantedColumn := range sch.Columns() {
 		if resolution.Interval > 0 && wantedColumn.ClickHouseMainOnly {
 			continue
 		}
@@ -528,7 +738,7 @@ outer:
 				modifyTypeOrCodec := false
 				if wantedColumn.ClickHouseType != existingColumn.Type {
 					modifyTypeOrCodec = true
-					if slices.Contains(c.d.Schema.ClickHousePrimaryKeys(), wantedColumn.Name) {
+					if slices.Contains(sch.ClickHousePrimaryKeys(), wantedColumn.Name) {
 						return fmt.Errorf("table %s, primary key column %s has a non-matching type: %s vs %s",
 							tableName, wantedColumn.Name, existingColumn.Type, wantedColumn.ClickHouseType)
 					}
@@ -543,7 +753,10 @@ outer:
 				if (wantedColumn.ClickHouseAlias != "") != (existingColumn.DefaultKind == "ALIAS") {
 					// either the column was an alias and should be none, or the other way around. Either way, we need to recreate.
 					c.r.Debug().Msg(fmt.Sprintf("column %s alias content has changed, recreating. New ALIAS: %s", existingColumn.Name, wantedColumn.ClickHouseAlias))
-					err := c.d.ClickHouse.ExecOnCluster(ctx,
+					if err := backup(ctx); err != nil {
+						return err
+					}
+					err := c.migrationExec(ctx,
 						fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tableName, existingColumn.Name))
 					if err != nil {
 						return fmt.Errorf("cannot drop %s from %s to cleanup aliasing: %w",
@@ -554,13 +767,16 @@ outer:
 						fmt.Sprintf("ADD COLUMN %s AFTER %s", wantedColumn.ClickHouseDefinition(), previousColumn))
 				}
 
-				if resolution.Interval > 0 && slices.Contains(c.d.Schema.ClickHousePrimaryKeys(), wantedColumn.Name) && existingColumn.IsPrimaryKey == 0 {
+				if resolution.Interval > 0 && slices.Contains(sch.ClickHousePrimaryKeys(), wantedColumn.Name) && existingColumn.IsPrimaryKey == 0 {
 					return fmt.Errorf("table %s, column %s should be a primary key, cannot change that",
 						tableName, wantedColumn.Name)
 				}
 				if resolution.Interval > 0 && !wantedColumn.ClickHouseNotSortingKey && existingColumn.IsSortingKey == 0 {
 					// That's something we can fix, but we need to drop it before recreating it
-					err := c.d.ClickHouse.ExecOnCluster(ctx,
+					if err := backup(ctx); err != nil {
+						return err
+					}
+					err := c.migrationExec(ctx,
 						fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tableName, existingColumn.Name))
 					if err != nil {
 						return fmt.Errorf("cannot drop %s from %s to fix ordering: %w",
@@ -570,6 +786,7 @@ outer:
 					modifications = append(modifications,
 						fmt.Sprintf("ADD COLUMN %s AFTER %s", wantedColumn.ClickHouseDefinition(), previousColumn))
 				} else if modifyTypeOrCodec {
+					destructive = destructive || wantedColumn.ClickHouseType != existingColumn.Type
 					modifications = append(modifications,
 						fmt.Sprintf("MODIFY COLUMN %s", wantedColumn.ClickHouseDefinition()))
 				}
@@ -578,7 +795,7 @@ outer:
 			}
 		}
 		// Add the missing column. Only if not primary.
-		if resolution.Interval > 0 && slices.Contains(c.d.Schema.ClickHousePrimaryKeys(), wantedColumn.Name) {
+		if resolution.Interval > 0 && slices.Contains(sch.ClickHousePrimaryKeys(), wantedColumn.Name) {
 			return fmt.Errorf("table %s, column %s is missing but it is a primary key",
 				tableName, wantedColumn.Name)
 		}
@@ -587,49 +804,85 @@ outer:
 			fmt.Sprintf("ADD COLUMN %s AFTER %s", wantedColumn.ClickHouseDefinition(), previousColumn))
 		previousColumn = wantedColumn.Name
 	}
+	// Remove the column aliases whose deprecation window has ended. They do
+	// not hold any data.
+	for _, existingColumn := range existingColumns {
+		if existingColumn.DefaultKind == "ALIAS" &&
+			slices.Contains(c.d.Schema.ExpiredColumnAliases(), existingColumn.Name) {
+			c.r.Info().Msgf("drop expired column alias %s from %s", existingColumn.Name, tableName)
+			modifications = append(modifications,
+				fmt.Sprintf("DROP COLUMN %s", existingColumn.Name))
+		}
+	}
 	modified := false
-	if len(modifications) > 0 {
+	if len(modifications) > 0 || len(droppedColumns) > 0 {
 		// Also update ORDER BY
 		if resolution.Interval > 0 {
 			modifications = append(modifications,
-				fmt.Sprintf("MODIFY ORDER BY (%s)", strings.Join(c.d.Schema.ClickHouseSortingKeys(), ", ")))
+				fmt.Sprintf("MODIFY ORDER BY (%s)", strings.Join(sch.ClickHouseSortingKeys(), ", ")))
+		}
+		if destructive || len(droppedColumns) > 0 {
+			if err := backup(ctx); err != nil {
+				return err
+			}
 		}
 		c.r.Info().Msgf("apply %d modifications to %s", len(modifications), tableName)
 		if resolution.Interval > 0 {
 			// Drop the view
 			viewName := fmt.Sprintf("%s_consumer", tableName)
-			if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+			if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
 				return fmt.Errorf("cannot drop %s: %w", viewName, err)
 			}
 		}
-		err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf("ALTER TABLE %s %s", tableName, strings.Join(modifications, ", ")))
-		if err != nil {
-			return fmt.Errorf("cannot update table %s: %w", tableName, err)
+		if len(modifications) > 0 {
+			err := c.migrationExec(ctx, fmt.Sprintf("ALTER TABLE %s %s", tableName, strings.Join(modifications, ", ")))
+			if err != nil {
+				return fmt.Errorf("cannot update table %s: %w", tableName, err)
+			}
+		}
+		if len(droppedColumns) > 0 {
+			err := c.migrationExec(ctx, fmt.Sprintf("ALTER TABLE %s %s", tableName, strings.Join(droppedColumns, ", ")))
+			if err != nil {
+				return fmt.Errorf("cannot drop columns from %s: %w", tableName, err)
+			}
 		}
 		modified = true
 	}
 
 	// Check if we need to update the settings
-	settingsClauseLike := fmt.Sprintf("CAST(engine_full LIKE '%% SETTINGS %s', 'String')", settings)
+	settingsClauseLike := fmt.Sprintf("CAST(engine_full LIKE %s, 'String')",
+		quoteString(fmt.Sprintf("%% SETTINGS %s", settings)))
 	if ok, err := c.tableAlreadyExists(ctx, tableName, settingsClauseLike, "1"); err != nil {
 		return err
 	} else if !ok {
 		c.r.Info().Msgf("updating settings of %s to %s", tableName, resolution.Interval)
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf("ALTER TABLE %s MODIFY SETTING %s", tableName, settings)); err != nil {
+		if err := c.migrationExec(ctx, fmt.Sprintf("ALTER TABLE %s MODIFY SETTING %s", tableName, settings)); err != nil {
 			return fmt.Errorf("cannot modify settings for table %s: %w", tableName, err)
 		}
 		modified = true
 	}
 
 	// Check if we need to update the TTL
-	ttlClause := fmt.Sprintf("TTL TimeReceived + toIntervalSecond(%d)", ttl)
-	ttlClauseLike := fmt.Sprintf("CAST(engine_full LIKE '%% %s %%', 'String')", ttlClause)
+	ttlClauseLike := fmt.Sprintf("CAST(engine_full LIKE %s, 'String')",
+		quoteString(fmt.Sprintf("%% %s %%", ttlClause)))
 	if ok, err := c.tableAlreadyExists(ctx, tableName, ttlClauseLike, "1"); err != nil {
 		return err
 	} else if !ok {
-		c.r.Warn().
-			Msgf("updating TTL of %s with interval %s, this can take a long time", tableName, resolution.Interval)
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf("ALTER TABLE %s MODIFY %s", tableName, ttlClause)); err != nil {
+		if err := backup(ctx); err != nil {
+			return err
+		}
+		ttlCtx := ctx
+		if c.config.MaterializeTTL {
+			c.r.Warn().
+				Msgf("updating TTL of %s with interval %s, this can take a long time", tableName, resolution.Interval)
+		} else {
+			c.r.Info().
+				Msgf("updating TTL of %s with interval %s, without materializing it", tableName, resolution.Interval)
+			ttlCtx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+				"materialize_ttl_after_modify": 0,
+			}))
+		}
+		if err := c.migrationExec(ttlCtx, fmt.Sprintf("ALTER TABLE %s MODIFY %s", tableName, ttlClause)); err != nil {
 			return fmt.Errorf("cannot modify TTL for table %s: %w", tableName, err)
 		}
 		modified = true
@@ -641,6 +894,159 @@ outer:
 	return errSkipStep
 }
 
+// recreateFlowsTable replaces an existing aggregated flows table by a new one
+// matching the schema and copies the data over. This is needed to remove
+// columns from the sorting key. This is not supported in a cluster as the data
+// is spread over several shards.
+//
+// A consumer feeds the new table before the old one is stopped, so no flow is
+// lost while the data is copied. Flows received between the two are counted
+// twice, but this only spans a few milliseconds. Only the data received before
+// the switch-over is copied from the old table.
+func (c *Component) recreateFlowsTable(ctx context.Context, tableName string, resolution ResolutionConfiguration, sch schema.Schema, settings string) error {
+	if c.d.ClickHouse.ClusterName() != "" {
+		return fmt.Errorf("table %s needs to be recreated to remove columns from its sorting key, "+
+			"this is not supported in a cluster: drop the table to let it be created again", tableName)
+	}
+	var existingColumns []string
+	if err := c.d.ClickHouse.Select(ctx, &existingColumns, `
+SELECT name
+FROM system.columns
+WHERE database = $1
+AND table = $2
+AND default_kind = ''
+`, c.d.ClickHouse.DatabaseName(), tableName); err != nil {
+		return fmt.Errorf("cannot query columns table: %w", err)
+	}
+	columns := []string{}
+	for _, column := range sch.ClickHouseSelectColumns(
+		schema.ClickHouseSkipMainOnlyColumns,
+		schema.ClickHouseSkipAliasedColumns) {
+		if slices.Contains(existingColumns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	newTableName := fmt.Sprintf("%s_new", tableName)
+	createQuery, err := c.flowsTableCreateQuery(newTableName, resolution, sch, settings)
+	if err != nil {
+		return fmt.Errorf("cannot build create table statement for %s: %w", newTableName, err)
+	}
+	selectQuery, err := c.aggregatedFlowsSelectQuery(resolution, c.localTable("flows"))
+	if err != nil {
+		return fmt.Errorf("cannot build select statement for %s: %w", newTableName, err)
+	}
+	c.r.Warn().Msgf("recreate %s to remove columns from its sorting key, this can take a long time", tableName)
+	viewName := fmt.Sprintf("%s_consumer", tableName)
+	newViewName := fmt.Sprintf("%s_consumer", newTableName)
+	for _, table := range []string{newViewName, newTableName} {
+		if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, table)); err != nil {
+			return fmt.Errorf("cannot drop %s: %w", table, err)
+		}
+	}
+	if err := c.migrationExec(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create %s: %w", newTableName, err)
+	}
+
+	// Switch the flows to the new table
+	if err := c.migrationExec(ctx, fmt.Sprintf(`CREATE MATERIALIZED VIEW %s TO %s AS %s`,
+		newViewName, newTableName, selectQuery)); err != nil {
+		return fmt.Errorf("cannot create %s: %w", newViewName, err)
+	}
+	if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+		return fmt.Errorf("cannot drop %s: %w", viewName, err)
+	}
+	switchOver := startOfInterval(time.Now(), resolution.Interval).Add(resolution.Interval)
+	c.r.Info().Time("switch-over", switchOver).Msgf("%s now receives new flows", newTableName)
+
+	// Copy the data received before the switch-over
+	if err := c.migrationExec(ctx, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE TimeReceived < toDateTime(%d)",
+		newTableName, strings.Join(columns, ", "), strings.Join(columns, ", "), tableName,
+		switchOver.Unix())); err != nil {
+		return fmt.Errorf("cannot copy data from %s to %s: %w", tableName, newTableName, err)
+	}
+
+	// Exchange the tables and attach the consumer to the final name
+	if err := c.migrationExec(ctx, fmt.Sprintf("EXCHANGE TABLES %s AND %s", tableName, newTableName)); err != nil {
+		return fmt.Errorf("cannot exchange %s and %s: %w", tableName, newTableName, err)
+	}
+	if err := c.migrationExec(ctx, fmt.Sprintf(`CREATE MATERIALIZED VIEW %s TO %s AS %s`,
+		viewName, tableName, selectQuery)); err != nil {
+		return fmt.Errorf("cannot create %s: %w", viewName, err)
+	}
+	for _, table := range []string{newViewName, newTableName} {
+		if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE %s SYNC`, table)); err != nil {
+			return fmt.Errorf("cannot drop %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// flowsTableCreateQuery returns the statement to create a flows table for the
+// provided resolution.
+func (c *Component) flowsTableCreateQuery(tableName string, resolution ResolutionConfiguration, sch schema.Schema, settings string) (string, error) {
+	partitionInterval := uint64((resolution.TTL / time.Duration(c.config.MaxPartitions)).Seconds())
+	ttlClause := flowsTableTTLClause(resolution)
+	if resolution.Interval == 0 {
+		return stemplate(`
+CREATE TABLE {{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMMDDhhmmss(toStartOfInterval(TimeReceived, INTERVAL {{ .PartitionInterval }} second))
+ORDER BY (toStartOfFiveMinutes(TimeReceived), ExporterAddress, InIfName, OutIfName)
+{{ .TTL }}
+SETTINGS {{ .Settings }}
+`, gin.H{
+			"Table":             tableName,
+			"Schema":            sch.ClickHouseCreateTable(),
+			"PartitionInterval": partitionInterval,
+			"TTL":               ttlClause,
+			"Engine":            c.mergeTreeEngine(tableName, ""),
+			"Settings":          settings,
+		})
+	}
+	return stemplate(`
+CREATE TABLE {{ .Table }} ({{ .Schema }})
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMMDDhhmmss(toStartOfInterval(TimeReceived, INTERVAL {{ .PartitionInterval }} second))
+PRIMARY KEY ({{ .PrimaryKey }})
+ORDER BY ({{ .SortingKey }})
+{{ .TTL }}
+SETTINGS {{ .Settings }}
+`, gin.H{
+		"Table":             tableName,
+		"Schema":            sch.ClickHouseCreateTable(schema.ClickHouseSkipMainOnlyColumns),
+		"PartitionInterval": partitionInterval,
+		"PrimaryKey":        strings.Join(sch.ClickHousePrimaryKeys(), ", "),
+		"SortingKey":        strings.Join(sch.ClickHouseSortingKeys(), ", "),
+		"TTL":               ttlClause,
+		"Engine":            c.mergeTreeEngine(tableName, "Summing", "(Bytes, Packets)"),
+		"Settings":          settings,
+	})
+}
+
+// flowsTableTTLClause returns the TTL clause for a flows table. Data is first
+// moved to the configured disks or volumes and deleted at the end.
+func flowsTableTTLClause(resolution ResolutionConfiguration) string {
+	moves := slices.Clone(resolution.Moves)
+	slices.SortStableFunc(moves, func(a, b MoveConfiguration) int {
+		return cmp.Compare(a.After, b.After)
+	})
+	expressions := []string{}
+	for _, move := range moves {
+		var target string
+		if move.Disk != "" {
+			target = fmt.Sprintf("DISK %s", quoteString(move.Disk))
+		} else {
+			target = fmt.Sprintf("VOLUME %s", quoteString(move.Volume))
+		}
+		expressions = append(expressions,
+			fmt.Sprintf("TimeReceived + toIntervalSecond(%d) TO %s", uint64(move.After.Seconds()), target))
+	}
+	expressions = append(expressions,
+		fmt.Sprintf("TimeReceived + toIntervalSecond(%d)", uint64(resolution.TTL.Seconds())))
+	return fmt.Sprintf("TTL %s", strings.Join(expressions, ", "))
+}
+
 func (c *Component) createFlowsConsumerView(ctx context.Context, resolution ResolutionConfiguration) error {
 	if resolution.Interval == 0 {
 		// The consumer for the main table is created elsewhere.
@@ -648,21 +1054,7 @@ func (c *Component) createFlowsConsumerView(ctx context.Context, resolution Reso
 	}
 	tableName := fmt.Sprintf("flows_%s", resolution.Interval)
 	viewName := fmt.Sprintf("%s_consumer", tableName)
-
-	// Build SELECT query
-	selectQuery, err := stemplate(`
-SELECT
- toStartOfInterval(TimeReceived, toIntervalSecond({{ .Seconds }})) AS TimeReceived,
- {{ .Columns }}
-FROM {{ .Database }}.{{ .Table }}`, gin.H{
-		"Database": c.d.ClickHouse.DatabaseName(),
-		"Table":    c.localTable("flows"),
-		"Seconds":  uint64(resolution.Interval.Seconds()),
-		"Columns": strings.Join(c.d.Schema.ClickHouseSelectColumns(
-			schema.ClickHouseSkipTimeReceived,
-			schema.ClickHouseSkipMainOnlyColumns,
-			schema.ClickHouseSkipAliasedColumns), ",\n "),
-	})
+	selectQuery, err := c.aggregatedFlowsSelectQuery(resolution, c.localTable("flows"))
 	if err != nil {
 		return fmt.Errorf("cannot build select statement for consumer %s: %w", viewName, err)
 	}
@@ -677,10 +1069,10 @@ FROM {{ .Database }}.{{ .Table }}`, gin.H{
 
 	// Drop and create
 	c.r.Info().Msgf("create %s", viewName)
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+	if err := c.migrationExec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx,
+	if err := c.migrationExec(ctx,
 		fmt.Sprintf(`CREATE MATERIALIZED VIEW %s TO %s AS %s`, viewName,
 			c.localTable(tableName), selectQuery)); err != nil {
 		return fmt.Errorf("cannot create %s: %w", viewName, err)
@@ -688,6 +1080,64 @@ FROM {{ .Database }}.{{ .Table }}`, gin.H{
 	return nil
 }
 
+// aggregatedFlowsSelectQuery returns the query to aggregate the flows from the
+// provided source table for the provided resolution.
+func (c *Component) aggregatedFlowsSelectQuery(resolution ResolutionConfiguration, source string) (string, error) {
+	sch, err := c.d.Schema.WithMainOnlyColumns(resolution.SkipColumns)
+	if err != nil {
+		return "", fmt.Errorf("cannot build schema for resolution %s: %w", resolution.Interval, err)
+	}
+	return stemplate(`
+SELECT
+ toStartOfInterval(TimeReceived, toIntervalSecond({{ .Seconds }})) AS TimeReceived,
+ {{ .Columns }}
+FROM {{ .Database }}.{{ .Table }}`, gin.H{
+		"Database": c.d.ClickHouse.DatabaseName(),
+		"Table":    source,
+		"Seconds":  uint64(resolution.Interval.Seconds()),
+		"Columns": strings.Join(sch.ClickHouseSelectColumns(
+			schema.ClickHouseSkipTimeReceived,
+			schema.ClickHouseSkipMainOnlyColumns,
+			schema.ClickHouseSkipAliasedColumns), ",\n "),
+	})
+}
+
+// populatedColumnMaxRows is the maximum number of rows read to check if a
+// column is populated.
+const populatedColumnMaxRows = 10_000_000
+
+// columnIsPopulated tells if a column of a table contains a value other than
+// the default one. Columns without any row in the active parts are not
+// scanned. Otherwise, the scan stops at the first populated row or after
+// reading populatedColumnMaxRows rows. In the latter case, the column is
+// assumed to be populated.
+func (c *Component) columnIsPopulated(ctx context.Context, tableName, columnName string) (bool, error) {
+	var rows uint64
+	if err := c.d.ClickHouse.QueryRow(ctx, `
+SELECT sum(rows)
+FROM system.parts_columns
+WHERE database = $1 AND table = $2 AND column = $3 AND active
+`, c.d.ClickHouse.DatabaseName(), tableName, columnName).Scan(&rows); err != nil {
+		return false, fmt.Errorf("cannot check if column %s from %s has rows: %w",
+			columnName, tableName, err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	var populated []uint8
+	if err := c.d.ClickHouse.Select(ctx, &populated, fmt.Sprintf(`
+SELECT 1
+FROM %s
+WHERE %s != defaultValueOfArgumentType(%s)
+LIMIT 1
+SETTINGS max_rows_to_read = %d, read_overflow_mode = 'break'
+`, tableName, columnName, columnName, populatedColumnMaxRows)); err != nil {
+		return false, fmt.Errorf("cannot check if column %s from %s is populated: %w",
+			columnName, tableName, err)
+	}
+	return len(populated) > 0 || rows > populatedColumnMaxRows, nil
+}
+
 // createDistributedTable creates the distributed version of an existing table.
 // If the table already exists and does not match the definition, it is
 // replaced.
@@ -753,7 +1203,7 @@ ENGINE = Distributed('{{ .Cluster }}', '{{ .Database}}', '{{ .Source }}', rand()
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createOrReplaceQuery); err != nil {
+	if err := c.migrationExec(ctx, createOrReplaceQuery); err != nil {
 		return fmt.Errorf("cannot create %s: %w", c.distributedTable(source), err)
 	}
 	return nil
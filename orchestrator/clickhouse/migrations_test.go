@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"akvorado/common/clickhousedb"
+	"akvorado/common/clickhousedb/mocks"
 	"akvorado/common/daemon"
 	"akvorado/common/helpers"
 	"akvorado/common/httpserver"
@@ -29,6 +30,7 @@ import (
 	"akvorado/orchestrator/geoip"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.uber.org/mock/gomock"
 )
 
 type tableWithSchema struct {
@@ -156,6 +158,8 @@ func isOldTable(schema *schema.Component, table string) bool {
 	oldSuffixes := []string{
 		"_raw",
 		"_raw_consumer",
+		"_raw_local",
+		"_raw_local_consumer",
 		"_raw_errors", "_raw_errors_local",
 		"_raw_errors_consumer",
 	}
@@ -290,6 +294,8 @@ WHERE database=currentDatabase() AND table NOT LIKE '.%'`)
 			expected := []string{
 				schema.DictionaryASNs,
 				"exporters",
+				"exporters_accounting",
+				"exporters_accounting_local",
 				"exporters_consumer",
 				// No exporters_local, because exporters is always local
 				"flows",
@@ -304,8 +310,14 @@ WHERE database=currentDatabase() AND table NOT LIKE '.%'`)
 				"flows_5m0s_local",
 				fmt.Sprintf("flows_%s_raw", hash),
 				fmt.Sprintf("flows_%s_raw_consumer", hash),
+				fmt.Sprintf("flows_%s_raw_local", hash),
+				fmt.Sprintf("flows_%s_raw_local_consumer", hash),
 				"flows_local",
+				"flows_quarantine",
+				"flows_quarantine_local",
 				schema.DictionaryICMP,
+				"interfaces_counters",
+				"interfaces_counters_local",
 				schema.DictionaryNetworks,
 				schema.DictionaryProtocols,
 				schema.DictionaryTCP,
@@ -314,7 +326,7 @@ WHERE database=currentDatabase() AND table NOT LIKE '.%'`)
 			if !cluster {
 				filteredExpected := []string{}
 				for _, item := range expected {
-					if !strings.HasSuffix(item, "_local") {
+					if !strings.HasSuffix(item, "_local") && !strings.HasSuffix(item, "_local_consumer") {
 						filteredExpected = append(filteredExpected, item)
 					}
 				}
@@ -571,6 +583,254 @@ AND name LIKE $3`, "flows", ch.d.ClickHouse.DatabaseName(), "%DimensionAttribute
 	})
 }
 
+func TestDisabledColumnMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+
+	start := func(t *testing.T, enabled, dropPopulated bool) *Component {
+		t.Helper()
+		r := reporter.NewMock(t)
+		schConfig := schema.DefaultConfiguration()
+		if enabled {
+			schConfig.Enabled = []schema.ColumnKey{schema.ColumnSrcVlan, schema.ColumnDstVlan}
+		}
+		sch, err := schema.New(schConfig)
+		if err != nil {
+			t.Fatalf("schema.New() error:\n%+v", err)
+		}
+		configuration := DefaultConfiguration()
+		configuration.OrchestratorURL = "http://127.0.0.1:0"
+		configuration.DropPopulatedColumns = dropPopulated
+		ch, err := New(r, configuration, Dependencies{
+			Daemon:     daemon.NewMock(t),
+			HTTP:       httpserver.NewMock(t, r),
+			Schema:     sch,
+			ClickHouse: chComponent,
+			GeoIP:      geoip.NewMock(t, r, true),
+		})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, ch)
+		waitMigrations(t, ch)
+		return ch
+	}
+	check := func(t *testing.T, ch *Component, expected []string) {
+		t.Helper()
+		for _, table := range []string{"flows", "flows_1m0s"} {
+			var got []string
+			if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name
+FROM system.columns
+WHERE table = $1
+AND database = $2
+AND name LIKE $3
+ORDER BY name`, table, ch.d.ClickHouse.DatabaseName(), "%Vlan"); err != nil {
+				t.Fatalf("Select() error:\n%+v", err)
+			}
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Fatalf("Unexpected columns in %s (-got, +want):\n%s", table, diff)
+			}
+		}
+	}
+
+	_ = t.Run("enable", func(t *testing.T) {
+		ch := start(t, true, false)
+		check(t, ch, []string{"DstVlan", "SrcVlan"})
+	}) && t.Run("disable empty", func(t *testing.T) {
+		ch := start(t, false, false)
+		check(t, ch, nil)
+	}) && t.Run("disable populated", func(t *testing.T) {
+		ch := start(t, true, false)
+		if err := ch.d.ClickHouse.Exec(context.Background(),
+			"INSERT INTO flows (TimeReceived, SrcVlan) VALUES (now(), 100)"); err != nil {
+			t.Fatalf("Exec() error:\n%+v", err)
+		}
+		ch = start(t, false, false)
+		check(t, ch, []string{"SrcVlan"})
+	}) && t.Run("drop populated", func(t *testing.T) {
+		ch := start(t, false, true)
+		check(t, ch, nil)
+	})
+}
+
+func TestSkipColumnsMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+
+	start := func(t *testing.T, skipColumns []schema.ColumnKey) *Component {
+		t.Helper()
+		r := reporter.NewMock(t)
+		configuration := DefaultConfiguration()
+		configuration.OrchestratorURL = "http://127.0.0.1:0"
+		configuration.Resolutions[1].SkipColumns = skipColumns
+		ch, err := New(r, configuration, Dependencies{
+			Daemon:     daemon.NewMock(t),
+			HTTP:       httpserver.NewMock(t, r),
+			Schema:     schema.NewMock(t),
+			ClickHouse: chComponent,
+			GeoIP:      geoip.NewMock(t, r, true),
+		})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, ch)
+		waitMigrations(t, ch)
+		return ch
+	}
+	check := func(t *testing.T, ch *Component, expectedColumns []string, expectedBytes uint64) {
+		t.Helper()
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name
+FROM system.columns
+WHERE table = $1
+AND database = $2
+AND name LIKE $3
+AND is_in_sorting_key
+ORDER BY name`, "flows_1m0s", ch.d.ClickHouse.DatabaseName(), "%Country"); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, expectedColumns); diff != "" {
+			t.Fatalf("Unexpected columns (-got, +want):\n%s", diff)
+		}
+		var bytes uint64
+		if err := ch.d.ClickHouse.QueryRow(context.Background(),
+			"SELECT sum(Bytes) FROM flows_1m0s").Scan(&bytes); err != nil {
+			t.Fatalf("QueryRow() error:\n%+v", err)
+		}
+		if bytes != expectedBytes {
+			t.Fatalf("sum(Bytes) == %d, expected %d", bytes, expectedBytes)
+		}
+	}
+
+	_ = t.Run("all columns", func(t *testing.T) {
+		ch := start(t, nil)
+		if err := ch.d.ClickHouse.Exec(context.Background(),
+			"INSERT INTO flows_1m0s (TimeReceived, SrcCountry, DstCountry, Bytes) VALUES (now(), 'FR', 'US', 1000)"); err != nil {
+			t.Fatalf("Exec() error:\n%+v", err)
+		}
+		check(t, ch, []string{"DstCountry", "SrcCountry"}, 1000)
+	}) && t.Run("skip sorting key column", func(t *testing.T) {
+		ch := start(t, []schema.ColumnKey{schema.ColumnSrcCountry})
+		check(t, ch, []string{"DstCountry"}, 1000)
+		var tables []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &tables, `
+SELECT name
+FROM system.tables
+WHERE database = $1
+AND name LIKE $2
+ORDER BY name`, ch.d.ClickHouse.DatabaseName(), "flows_1m0s%"); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(tables, []string{"flows_1m0s", "flows_1m0s_consumer"}); diff != "" {
+			t.Fatalf("Unexpected tables (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("idempotency", func(t *testing.T) {
+		ch := start(t, []schema.ColumnKey{schema.ColumnSrcCountry})
+		check(t, ch, []string{"DstCountry"}, 1000)
+	})
+}
+
+func TestCustomViewMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+
+	// start starts the component with a custom view and returns the number
+	// of applied steps.
+	start := func(t *testing.T, where string) string {
+		t.Helper()
+		r := reporter.NewMock(t)
+		configuration := DefaultConfiguration()
+		configuration.OrchestratorURL = "http://127.0.0.1:0"
+		view := DefaultCustomViewConfiguration()
+		view.Name = "customer_daily"
+		view.Select = []string{
+			"toStartOfDay(TimeReceived) AS TimeReceived",
+			"InIfProvider",
+			"SUM(Bytes*SamplingRate) AS Bytes",
+		}
+		view.Where = where
+		view.GroupBy = []string{"TimeReceived", "InIfProvider"}
+		view.OrderBy = []string{"TimeReceived", "InIfProvider"}
+		configuration.CustomViews = []CustomViewConfiguration{view}
+		ch, err := New(r, configuration, Dependencies{
+			Daemon:     daemon.NewMock(t),
+			HTTP:       httpserver.NewMock(t, r),
+			Schema:     schema.NewMock(t),
+			ClickHouse: chComponent,
+			GeoIP:      geoip.NewMock(t, r, true),
+		})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, ch)
+		waitMigrations(t, ch)
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		return gotMetrics["applied_steps_total"]
+	}
+
+	_ = t.Run("create", func(t *testing.T) {
+		if steps := start(t, ""); steps == "0" {
+			t.Fatal("No migration applied when adding a custom view")
+		}
+	}) && t.Run("unchanged", func(t *testing.T) {
+		if steps := start(t, ""); steps != "0" {
+			t.Fatalf("%s steps applied for an unchanged custom view", steps)
+		}
+	}) && t.Run("changed", func(t *testing.T) {
+		if steps := start(t, "InIfBoundary = 'external'"); steps == "0" {
+			t.Fatal("No migration applied when changing a custom view")
+		}
+	})
+}
+
+func TestColumnIsPopulated(t *testing.T) {
+	cases := []struct {
+		Pos       helpers.Pos
+		Rows      uint64
+		Populated []uint8
+		Expected  bool
+	}{
+		{helpers.Mark(), 0, nil, false},
+		{helpers.Mark(), 1000, []uint8{}, false},
+		{helpers.Mark(), 1000, []uint8{1}, true},
+		{helpers.Mark(), populatedColumnMaxRows + 1, []uint8{}, true},
+	}
+	for _, tc := range cases {
+		r := reporter.NewMock(t)
+		chComponent, mockConn := clickhousedb.NewMock(t, r)
+		c := Component{r: r, d: &Dependencies{ClickHouse: chComponent}}
+		mockRow := mocks.NewMockRow(gomock.NewController(t))
+		mockRow.EXPECT().Scan(gomock.Any()).SetArg(0, tc.Rows).Return(nil)
+		mockConn.EXPECT().
+			QueryRow(gomock.Any(), gomock.Any(), "default", "flows", "SrcVlan").
+			Return(mockRow)
+		if tc.Rows > 0 {
+			mockConn.EXPECT().
+				Select(gomock.Any(), gomock.Any(), `
+SELECT 1
+FROM flows
+WHERE SrcVlan != defaultValueOfArgumentType(SrcVlan)
+LIMIT 1
+SETTINGS max_rows_to_read = 10000000, read_overflow_mode = 'break'
+`).
+				SetArg(1, tc.Populated).
+				Return(nil)
+		}
+		got, err := c.columnIsPopulated(context.Background(), "flows", "SrcVlan")
+		if err != nil {
+			t.Fatalf("%scolumnIsPopulated() error:\n%+v", tc.Pos, err)
+		}
+		if got != tc.Expected {
+			t.Errorf("%scolumnIsPopulated() == %v, expected %v", tc.Pos, got, tc.Expected)
+		}
+	}
+}
+
 func TestQuoteString(t *testing.T) {
 	cases := []struct {
 		s        string
@@ -588,3 +848,53 @@ func TestQuoteString(t *testing.T) {
 		}
 	}
 }
+
+func TestFlowsTableTTLClause(t *testing.T) {
+	cases := []struct {
+		Pos        helpers.Pos
+		Resolution ResolutionConfiguration
+		Expected   string
+	}{
+		{
+			Pos:        helpers.Mark(),
+			Resolution: ResolutionConfiguration{Interval: time.Minute, TTL: 24 * time.Hour},
+			Expected:   "TTL TimeReceived + toIntervalSecond(86400)",
+		}, {
+			Pos: helpers.Mark(),
+			Resolution: ResolutionConfiguration{
+				Interval: time.Minute,
+				TTL:      30 * 24 * time.Hour,
+				Moves: []MoveConfiguration{
+					{After: 7 * 24 * time.Hour, Volume: "cold"},
+					{After: 24 * time.Hour, Disk: "warm"},
+				},
+			},
+			Expected: "TTL TimeReceived + toIntervalSecond(86400) TO DISK 'warm', TimeReceived + toIntervalSecond(604800) TO VOLUME 'cold', TimeReceived + toIntervalSecond(2592000)",
+		},
+	}
+	for _, tc := range cases {
+		got := flowsTableTTLClause(tc.Resolution)
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%sflowsTableTTLClause() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
+
+func TestMigrationsHealthcheck(t *testing.T) {
+	c := Component{migrationsDone: make(chan bool)}
+	got := c.migrationsHealthcheck(context.Background())
+	if diff := helpers.Diff(got, reporter.HealthcheckResult{
+		Status: reporter.HealthcheckStarting,
+		Reason: "migrations in progress",
+	}); diff != "" {
+		t.Errorf("migrationsHealthcheck() (-got, +want):\n%s", diff)
+	}
+	close(c.migrationsDone)
+	got = c.migrationsHealthcheck(context.Background())
+	if diff := helpers.Diff(got, reporter.HealthcheckResult{
+		Status: reporter.HealthcheckOK,
+		Reason: "migrations done",
+	}); diff != "" {
+		t.Errorf("migrationsHealthcheck() (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rawTable is a raw flow table as reported by ClickHouse.
+type rawTable struct {
+	Name    string    `ch:"name"`
+	Created time.Time `ch:"metadata_modification_time"`
+}
+
+// rawTableGeneration is a generation of raw tables for a previous schema.
+type rawTableGeneration struct {
+	Table         string    `json:"table"`
+	Created       time.Time `json:"created"`
+	ObsoleteSince time.Time `json:"obsolete-since"`
+	Drop          bool      `json:"drop"`
+	Statements    []string  `json:"statements,omitempty"`
+}
+
+// rawTablesGCReport is the result of a collection of raw tables.
+type rawTablesGCReport struct {
+	Checked     time.Time            `json:"checked"`
+	DryRun      bool                 `json:"dry-run"`
+	Error       string               `json:"error,omitempty"`
+	Generations []rawTableGeneration `json:"generations"`
+}
+
+// rawTablesQuery lists the raw flow tables. Local tables are not listed as
+// they share the name of the distributed one.
+const rawTablesQuery = `
+SELECT name, metadata_modification_time
+FROM system.tables
+WHERE database = currentDatabase()
+AND match(name, '^flows_[A-Z0-9]+v[0-9]+_raw$')
+ORDER BY metadata_modification_time DESC, name DESC
+`
+
+// rawTableNameRegex extracts the generation from the name of a raw table.
+var rawTableNameRegex = regexp.MustCompile(`^flows_[A-Z0-9]+v([0-9]+)_raw$`)
+
+// rawTableGenerationNumber returns the generation encoded in the name of a raw
+// table, or 0 if there is none.
+func rawTableGenerationNumber(name string) int {
+	matches := rawTableNameRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return 0
+	}
+	generation, _ := strconv.Atoi(matches[1])
+	return generation
+}
+
+// planRawTablesGC returns the generations of raw tables older than the
+// current one, from the most recent to the oldest. Tables are ordered by the
+// generation encoded in their names, then by their modification time. A generation is obsolete
+// once the next one has been created. It is dropped when it is not among the
+// ones to keep and when it is obsolete for longer than the grace period.
+// Generations more recent than the current one are never touched: they may
+// belong to a newer version during an upgrade.
+func (c *Component) planRawTablesGC(tables []rawTable, now time.Time) ([]rawTableGeneration, error) {
+	// Order by generation first as the modification time of a table may be
+	// updated after its creation. The time only orders tables of the same
+	// generation.
+	tables = slices.Clone(tables)
+	slices.SortStableFunc(tables, func(a, b rawTable) int {
+		return cmp.Or(
+			cmp.Compare(rawTableGenerationNumber(b.Name), rawTableGenerationNumber(a.Name)),
+			b.Created.Compare(a.Created))
+	})
+	current := fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash())
+	idx := slices.IndexFunc(tables, func(table rawTable) bool { return table.Name == current })
+	if idx == -1 {
+		return nil, fmt.Errorf("cannot find current raw table %s", current)
+	}
+	generations := []rawTableGeneration{}
+	obsoleteSince := tables[idx].Created
+	for _, table := range tables[idx+1:] {
+		generation := rawTableGeneration{
+			Table:         table.Name,
+			Created:       table.Created,
+			ObsoleteSince: obsoleteSince,
+		}
+		if uint(len(generations)) >= c.config.RawTablesGC.Keep &&
+			now.Sub(obsoleteSince) >= c.config.RawTablesGC.GracePeriod {
+			generation.Drop = true
+			generation.Statements = c.rawTablesDropStatements(table.Name)
+		}
+		generations = append(generations, generation)
+		obsoleteSince = table.Created
+	}
+	return generations, nil
+}
+
+// rawTablesDropStatements returns the statements to drop the provided raw
+// table, its consumer, and the local variants.
+func (c *Component) rawTablesDropStatements(table string) []string {
+	tables := []string{table}
+	if local := c.localTable(table); local != table {
+		tables = append(tables, local)
+	}
+	statements := []string{}
+	for _, table := range tables {
+		statements = append(statements,
+			fmt.Sprintf("DROP TABLE IF EXISTS %s_consumer SYNC", table),
+			fmt.Sprintf("DROP TABLE IF EXISTS %s SYNC", table))
+	}
+	return statements
+}
+
+// collectRawTables drops the raw tables of previous schemas according to the
+// configured policy and returns a report. With dry run, nothing is dropped.
+func (c *Component) collectRawTables(ctx context.Context, dryRun bool) *rawTablesGCReport {
+	c.rawTablesGCLock.Lock()
+	defer c.rawTablesGCLock.Unlock()
+
+	report := rawTablesGCReport{
+		Checked:     time.Now(),
+		DryRun:      dryRun,
+		Generations: []rawTableGeneration{},
+	}
+	err := func() error {
+		var tables []rawTable
+		if err := c.d.ClickHouse.Select(ctx, &tables, rawTablesQuery); err != nil {
+			return fmt.Errorf("cannot list raw tables: %w", err)
+		}
+		generations, err := c.planRawTablesGC(tables, report.Checked)
+		if err != nil {
+			return err
+		}
+		report.Generations = generations
+		remaining := len(generations)
+		for _, generation := range generations {
+			if !generation.Drop {
+				continue
+			}
+			if dryRun {
+				c.r.Info().Msgf("would drop %s (dry run)", generation.Table)
+				continue
+			}
+			c.r.Info().Msgf("drop %s", generation.Table)
+			for _, statement := range generation.Statements {
+				if err := c.d.ClickHouse.ExecOnCluster(ctx, statement); err != nil {
+					return fmt.Errorf("cannot drop %s: %w", generation.Table, err)
+				}
+			}
+			c.metrics.rawTablesDropped.Inc()
+			remaining--
+		}
+		c.metrics.rawTablesObsolete.Set(float64(remaining))
+		return nil
+	}()
+	if err != nil {
+		c.r.Err(err).Msg("cannot collect raw tables")
+		c.metrics.rawTablesGCErrors.Inc()
+		report.Error = err.Error()
+	}
+	return &report
+}
+
+// rawTablesCollector periodically collects raw tables once migrations are
+// done.
+func (c *Component) rawTablesCollector() error {
+	select {
+	case <-c.t.Dying():
+		return nil
+	case <-c.migrationsDone:
+	}
+	ticker := time.NewTicker(c.config.RawTablesGC.Interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(c.t.Context(nil), c.config.RawTablesGC.Interval)
+		c.collectRawTables(ctx, c.config.RawTablesGC.DryRun)
+		cancel()
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// rawTablesGCHandlerFunc reports the generations of raw tables which would
+// be dropped (GET) or runs a collection (POST).
+func (c *Component) rawTablesGCHandlerFunc(gc *gin.Context) {
+	if c.d.ClickHouse == nil {
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "ClickHouse is not available."})
+		return
+	}
+	select {
+	case <-c.migrationsDone:
+	default:
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "Migrations are not done yet."})
+		return
+	}
+	dryRun := gc.Request.Method != http.MethodPost || c.config.RawTablesGC.DryRun
+	gc.JSON(http.StatusOK, c.collectRawTables(gc.Request.Context(), dryRun))
+}
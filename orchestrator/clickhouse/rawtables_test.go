@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestPlanRawTablesGC(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, _ := clickhousedb.NewMock(t, r)
+	sch := schema.NewMock(t)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     sch,
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	current := fmt.Sprintf("flows_%s_raw", sch.ClickHouseHash())
+	now := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tables := []rawTable{
+		{"flows_NEWERv6_raw", now.Add(-time.Hour)},
+		{current, now.Add(-10 * day)},
+		{"flows_AAAv5_raw", now.Add(-20 * day)},
+		{"flows_BBBv5_raw", now.Add(-30 * day)},
+		{"flows_CCCv5_raw", now.Add(-40 * day)},
+		{"flows_DDDv5_raw", now.Add(-50 * day)},
+	}
+
+	cases := []struct {
+		Pos         helpers.Pos
+		Keep        uint
+		GracePeriod time.Duration
+		Drop        []bool
+	}{
+		{helpers.Mark(), 0, 0, []bool{true, true, true, true}},
+		{helpers.Mark(), 2, 0, []bool{false, false, true, true}},
+		{helpers.Mark(), 2, 15 * day, []bool{false, false, true, true}},
+		{helpers.Mark(), 0, 15 * day, []bool{false, true, true, true}},
+		{helpers.Mark(), 1, 25 * day, []bool{false, false, true, true}},
+		{helpers.Mark(), 1, 35 * day, []bool{false, false, false, true}},
+		{helpers.Mark(), 10, 0, []bool{false, false, false, false}},
+	}
+	for _, tc := range cases {
+		c.config.RawTablesGC.Keep = tc.Keep
+		c.config.RawTablesGC.GracePeriod = tc.GracePeriod
+		generations, err := c.planRawTablesGC(tables, now)
+		if err != nil {
+			t.Fatalf("%splanRawTablesGC() error:\n%+v", tc.Pos, err)
+		}
+		got := []bool{}
+		for _, generation := range generations {
+			got = append(got, generation.Drop)
+		}
+		if diff := helpers.Diff(got, tc.Drop); diff != "" {
+			t.Errorf("%splanRawTablesGC() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+
+	// Check a generation in details
+	c.config.RawTablesGC.Keep = 2
+	c.config.RawTablesGC.GracePeriod = 0
+	generations, _ := c.planRawTablesGC(tables, now)
+	expected := rawTableGeneration{
+		Table:         "flows_DDDv5_raw",
+		Created:       now.Add(-50 * day),
+		ObsoleteSince: now.Add(-40 * day),
+		Drop:          true,
+		Statements: []string{
+			"DROP TABLE IF EXISTS flows_DDDv5_raw_consumer SYNC",
+			"DROP TABLE IF EXISTS flows_DDDv5_raw SYNC",
+		},
+	}
+	if diff := helpers.Diff(generations[3], expected); diff != "" {
+		t.Errorf("planRawTablesGC() (-got, +want):\n%s", diff)
+	}
+
+	// Generations take precedence over modification times
+	generations, _ = c.planRawTablesGC([]rawTable{
+		{"flows_OLDv4_raw", now.Add(-time.Hour)},
+		{current, now.Add(-10 * day)},
+		{"flows_AAAv5_raw", now.Add(-20 * day)},
+	}, now)
+	got := []string{}
+	for _, generation := range generations {
+		got = append(got, generation.Table)
+	}
+	if diff := helpers.Diff(got, []string{"flows_AAAv5_raw", "flows_OLDv4_raw"}); diff != "" {
+		t.Errorf("planRawTablesGC() (-got, +want):\n%s", diff)
+	}
+
+	// Without the current table
+	if _, err := c.planRawTablesGC(tables[2:], now); err == nil {
+		t.Error("planRawTablesGC() without current table did not error")
+	}
+}
+
+func TestCollectRawTables(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	sch := schema.NewMock(t)
+	config := DefaultConfiguration()
+	config.RawTablesGC.Keep = 1
+	config.RawTablesGC.GracePeriod = 0
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     sch,
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	current := fmt.Sprintf("flows_%s_raw", sch.ClickHouseHash())
+	tables := []rawTable{
+		{current, time.Now().Add(-time.Hour)},
+		{"flows_AAAv5_raw", time.Now().Add(-2 * time.Hour)},
+		{"flows_BBBv5_raw", time.Now().Add(-3 * time.Hour)},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), rawTablesQuery).
+			SetArg(1, tables).
+			Return(nil)
+		report := c.collectRawTables(context.Background(), true)
+		if report.Error != "" || len(report.Generations) != 2 ||
+			report.Generations[0].Drop || !report.Generations[1].Drop {
+			t.Fatalf("collectRawTables() dry run:\n%+v", report)
+		}
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "raw_tables_")
+		expectedMetrics := map[string]string{
+			`raw_tables_dropped_generations_total`: "0",
+			`raw_tables_obsolete_generations`:      "2",
+			`raw_tables_gc_errors_total`:           "0",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), rawTablesQuery).
+			SetArg(1, tables).
+			Return(nil)
+		gomock.InOrder(
+			mockConn.EXPECT().
+				Exec(gomock.Any(), "DROP TABLE IF EXISTS flows_BBBv5_raw_consumer SYNC").
+				Return(nil),
+			mockConn.EXPECT().
+				Exec(gomock.Any(), "DROP TABLE IF EXISTS flows_BBBv5_raw SYNC").
+				Return(nil),
+		)
+		report := c.collectRawTables(context.Background(), false)
+		if report.Error != "" || len(report.Generations) != 2 {
+			t.Fatalf("collectRawTables():\n%+v", report)
+		}
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "raw_tables_")
+		expectedMetrics := map[string]string{
+			`raw_tables_dropped_generations_total`: "1",
+			`raw_tables_obsolete_generations`:      "1",
+			`raw_tables_gc_errors_total`:           "0",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("missing current table", func(t *testing.T) {
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), rawTablesQuery).
+			SetArg(1, tables[1:]).
+			Return(nil)
+		report := c.collectRawTables(context.Background(), false)
+		if report.Error == "" {
+			t.Fatalf("collectRawTables() did not report an error:\n%+v", report)
+		}
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "raw_tables_gc_")
+		expectedMetrics := map[string]string{
+			`raw_tables_gc_errors_total`: "1",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("endpoint", func(t *testing.T) {
+		helpers.TestHTTPEndpoints(t, c.d.HTTP.LocalAddr(), helpers.HTTPEndpointCases{
+			{
+				Description: "before migrations",
+				URL:         "/api/v0/orchestrator/clickhouse/raw-tables/gc",
+				StatusCode:  503,
+				JSONOutput:  gin.H{"message": "Migrations are not done yet."},
+			}, {
+				Description: "collection without token",
+				Method:      "POST",
+				URL:         "/api/v0/orchestrator/clickhouse/raw-tables/gc",
+				StatusCode:  401,
+				JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+			},
+		})
+	})
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+)
+
+// remoteWriteRow is a row of the query computing the ingest aggregates.
+type remoteWriteRow struct {
+	ExporterGroup string `ch:"ExporterGroup"`
+	Destination   string `ch:"Destination"`
+	Flows         uint64 `ch:"Flows"`
+	Bytes         uint64 `ch:"Bytes"`
+}
+
+// remoteWriteSeries is a time series pushed with remote-write. It has only
+// one sample.
+type remoteWriteSeries struct {
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// remoteWriteQuery returns the query computing the ingest aggregates between
+// the two provided times. Disabled columns are replaced by an empty string.
+func (c *Component) remoteWriteQuery(start, end time.Time) string {
+	column := func(key schema.ColumnKey) string {
+		if column, ok := c.d.Schema.LookupColumnByKey(key); ok && !column.Disabled {
+			return column.Name
+		}
+		return "''"
+	}
+	return fmt.Sprintf(`
+SELECT
+ %s AS ExporterGroup,
+ %s AS Destination,
+ count() AS Flows,
+ SUM(Bytes*SamplingRate) AS Bytes
+FROM %s
+WHERE TimeReceived >= toDateTime(%d) AND TimeReceived < toDateTime(%d)
+GROUP BY ExporterGroup, Destination
+`,
+		column(schema.ColumnExporterGroup),
+		column(schema.ColumnDstNetName),
+		c.distributedTable("flows"),
+		start.Unix(), end.Unix())
+}
+
+// remoteWriteSeriesFromRows turns the rows of the query into time series:
+// flows per second for each exporter group and bytes per second for each
+// destination network.
+func (c *Component) remoteWriteSeriesFromRows(rows []remoteWriteRow, end time.Time) []remoteWriteSeries {
+	seconds := c.config.RemoteWrite.Interval.Seconds()
+	flows := map[string]uint64{}
+	volumes := map[string]uint64{}
+	for _, row := range rows {
+		flows[row.ExporterGroup] += row.Flows
+		volumes[row.Destination] += row.Bytes
+	}
+	series := []remoteWriteSeries{}
+	add := func(name, label string, values map[string]uint64) {
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			labels := maps.Clone(c.config.RemoteWrite.Labels)
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels["__name__"] = name
+			labels[label] = key
+			series = append(series, remoteWriteSeries{
+				Labels:    labels,
+				Value:     float64(values[key]) / seconds,
+				Timestamp: end,
+			})
+		}
+	}
+	add("akvorado_flows_per_second", "exporter_group", flows)
+	add("akvorado_bytes_per_second", "destination", volumes)
+	return series
+}
+
+// encodeRemoteWriteRequest encodes time series as a remote-write request
+// (a snappy-compressed WriteRequest protobuf message).
+func encodeRemoteWriteRequest(series []remoteWriteSeries) []byte {
+	var request []byte
+	for _, s := range series {
+		var ts []byte
+		for _, name := range slices.Sorted(maps.Keys(s.Labels)) {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, s.Labels[name])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.Timestamp.UnixMilli()))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+	return snappy.Encode(nil, request)
+}
+
+// pushRemoteWrite computes the ingest aggregates for the last complete
+// interval before the provided time and pushes them to the remote-write
+// endpoint.
+func (c *Component) pushRemoteWrite(ctx context.Context, now time.Time) error {
+	end := now.Add(-c.config.RemoteWrite.Delay).Truncate(c.config.RemoteWrite.Interval)
+	start := end.Add(-c.config.RemoteWrite.Interval)
+	var rows []remoteWriteRow
+	if err := c.d.ClickHouse.Select(ctx, &rows, c.remoteWriteQuery(start, end)); err != nil {
+		return fmt.Errorf("cannot compute ingest aggregates: %w", err)
+	}
+	series := c.remoteWriteSeriesFromRows(rows, end)
+	if len(series) == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.RemoteWrite.URL,
+		bytes.NewReader(encodeRemoteWriteRequest(series)))
+	if err != nil {
+		return fmt.Errorf("cannot build remote-write request: %w", err)
+	}
+	for key, value := range c.config.RemoteWrite.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", fmt.Sprintf("akvorado/%s", helpers.AkvoradoVersion))
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot push to remote-write endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote-write endpoint returned %s: %s",
+			resp.Status, bytes.TrimSpace(body))
+	}
+	c.metrics.remoteWriteSeries.Add(float64(len(series)))
+	return nil
+}
+
+// remoteWritePusher periodically pushes the ingest aggregates once
+// migrations are done.
+func (c *Component) remoteWritePusher() error {
+	select {
+	case <-c.t.Dying():
+		return nil
+	case <-c.migrationsDone:
+	}
+	ticker := time.NewTicker(c.config.RemoteWrite.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(c.t.Context(nil), c.config.RemoteWrite.Timeout)
+		if err := c.pushRemoteWrite(ctx, time.Now()); err != nil {
+			c.r.Err(err).Msg("cannot push ingest aggregates")
+			c.metrics.remoteWriteErrors.Inc()
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+// decodeRemoteWriteRequest decodes a remote-write request. It is the reverse
+// of encodeRemoteWriteRequest.
+func decodeRemoteWriteRequest(t *testing.T, input []byte) []remoteWriteSeries {
+	t.Helper()
+	input, err := snappy.Decode(nil, input)
+	if err != nil {
+		t.Fatalf("snappy.Decode() error:\n%+v", err)
+	}
+	// fields returns the length-delimited or fixed fields of a message.
+	fields := func(b []byte) map[protowire.Number][][]byte {
+		result := map[protowire.Number][][]byte{}
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("ConsumeTag() error:\n%+v", protowire.ParseError(n))
+			}
+			b = b[n:]
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				t.Fatalf("ConsumeFieldValue() error:\n%+v", protowire.ParseError(n))
+			}
+			switch typ {
+			case protowire.BytesType:
+				v, _ := protowire.ConsumeBytes(b)
+				result[num] = append(result[num], v)
+			default:
+				result[num] = append(result[num], b[:n])
+			}
+			b = b[n:]
+		}
+		return result
+	}
+	series := []remoteWriteSeries{}
+	for _, ts := range fields(input)[1] {
+		tsFields := fields(ts)
+		s := remoteWriteSeries{Labels: map[string]string{}}
+		for _, label := range tsFields[1] {
+			labelFields := fields(label)
+			s.Labels[string(labelFields[1][0])] = string(labelFields[2][0])
+		}
+		sampleFields := fields(tsFields[2][0])
+		value, _ := protowire.ConsumeFixed64(sampleFields[1][0])
+		timestamp, _ := protowire.ConsumeVarint(sampleFields[2][0])
+		s.Value = math.Float64frombits(value)
+		s.Timestamp = time.UnixMilli(int64(timestamp)).UTC()
+		series = append(series, s)
+	}
+	return series
+}
+
+func TestPushRemoteWrite(t *testing.T) {
+	var got []remoteWriteSeries
+	var gotHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("out of order sample\n"))
+			return
+		}
+		got = decodeRemoteWriteRequest(t, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	config := DefaultConfiguration()
+	config.RemoteWrite.URL = ts.URL
+	config.RemoteWrite.Headers = map[string]string{"Authorization": "Bearer secret"}
+	config.RemoteWrite.Labels = map[string]string{"site": "paris"}
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       httpserver.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	now := time.Date(2026, time.October, 16, 10, 2, 30, 0, time.UTC)
+	end := time.Date(2026, time.October, 16, 10, 1, 0, 0, time.UTC)
+	query := `
+SELECT
+ ExporterGroup AS ExporterGroup,
+ DstNetName AS Destination,
+ count() AS Flows,
+ SUM(Bytes*SamplingRate) AS Bytes
+FROM flows
+WHERE TimeReceived >= toDateTime(1792144800) AND TimeReceived < toDateTime(1792144860)
+GROUP BY ExporterGroup, Destination
+`
+	rows := []remoteWriteRow{
+		{"edge", "google", 600, 6_000_000},
+		{"edge", "netflix", 1200, 60_000_000},
+		{"core", "google", 60, 600_000},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), query).
+		SetArg(1, rows).
+		Return(nil).
+		Times(2)
+
+	if err := c.pushRemoteWrite(context.Background(), now); err != nil {
+		t.Fatalf("pushRemoteWrite() error:\n%+v", err)
+	}
+	expected := []remoteWriteSeries{
+		{
+			Labels:    map[string]string{"__name__": "akvorado_flows_per_second", "exporter_group": "core", "site": "paris"},
+			Value:     1,
+			Timestamp: end,
+		}, {
+			Labels:    map[string]string{"__name__": "akvorado_flows_per_second", "exporter_group": "edge", "site": "paris"},
+			Value:     30,
+			Timestamp: end,
+		}, {
+			Labels:    map[string]string{"__name__": "akvorado_bytes_per_second", "destination": "google", "site": "paris"},
+			Value:     110_000,
+			Timestamp: end,
+		}, {
+			Labels:    map[string]string{"__name__": "akvorado_bytes_per_second", "destination": "netflix", "site": "paris"},
+			Value:     1_000_000,
+			Timestamp: end,
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("pushRemoteWrite() (-got, +want):\n%s", diff)
+	}
+	for key, value := range map[string]string{
+		"Authorization":                     "Bearer secret",
+		"Content-Encoding":                  "snappy",
+		"Content-Type":                      "application/x-protobuf",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if gotHeaders.Get(key) != value {
+			t.Errorf("pushRemoteWrite() header %s: got %q, expected %q", key, gotHeaders.Get(key), value)
+		}
+	}
+
+	// Rejected push
+	c.config.RemoteWrite.URL = ts.URL + "/fail"
+	if err := c.pushRemoteWrite(context.Background(), now); err == nil {
+		t.Fatal("pushRemoteWrite() did not error")
+	} else if diff := helpers.Diff(err.Error(),
+		"remote-write endpoint returned 400 Bad Request: out of order sample"); diff != "" {
+		t.Fatalf("pushRemoteWrite() error (-got, +want):\n%s", diff)
+	}
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_", "remote_write_")
+	expectedMetrics := map[string]string{
+		`remote_write_series_total`: "4",
+		`remote_write_errors_total`: "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"akvorado/common/remotedatasource"
@@ -40,11 +41,25 @@ type Component struct {
 	networkSourcesFetcher *remotedatasource.Component[externalNetworkAttributes]
 	networkSources        map[string][]externalNetworkAttributes
 	networkSourcesLock    sync.RWMutex
+	asnSourcesFetcher     *remotedatasource.Component[externalASN]
+	asnSources            map[string][]externalASN
+	asnSourcesLock        sync.RWMutex
+	asnsUpdateChan        chan bool // channel to write to to request a reload
 
 	networksCSVReady      chan bool // close when networks.csv was generated once
 	networksCSVUpdateChan chan bool // channel to write to to request updates
 	networksCSVFile       *os.File
 	networksCSVLock       sync.Mutex
+
+	ttlMaterializeRunning atomic.Bool // true while TTL materialization is being started
+
+	schemaDriftLock   sync.Mutex                        // held while checking for a schema drift
+	schemaDriftReport atomic.Pointer[schemaDriftReport] // last schema drift report
+
+	rawTablesGCLock sync.Mutex // held while collecting raw tables
+
+	resolutionGapsReport atomic.Pointer[resolutionGapsReport] // last resolution gaps report
+	backfillRunning      atomic.Bool                          // true while backfilling resolution gaps
 }
 
 // Dependencies define the dependencies of the orchestrator.
@@ -67,6 +82,8 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		networkSources:        make(map[string][]externalNetworkAttributes),
 		networksCSVReady:      make(chan bool),
 		networksCSVUpdateChan: make(chan bool, 1),
+		asnSources:            make(map[string][]externalASN),
+		asnsUpdateChan:        make(chan bool, 1),
 	}
 	var err error
 	c.networkSourcesFetcher, err = remotedatasource.New[externalNetworkAttributes](
@@ -74,6 +91,11 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize remote data source fetcher component: %w", err)
 	}
+	c.asnSourcesFetcher, err = remotedatasource.New[externalASN](
+		r, c.UpdateASNSource, "asn_source", configuration.ASNSources)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize remote data source fetcher component: %w", err)
+	}
 	c.initMetrics()
 
 	if err := c.registerHTTPHandlers(); err != nil {
@@ -87,6 +109,26 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if len(c.config.Resolutions) == 0 || c.config.Resolutions[0].Interval != 0 {
 		return nil, errors.New("resolutions need to be configured, including interval: 0")
 	}
+	for _, resolution := range c.config.Resolutions {
+		if len(resolution.SkipColumns) == 0 {
+			continue
+		}
+		if resolution.Interval == 0 {
+			return nil, errors.New("columns cannot be skipped from the main table")
+		}
+		if _, err := c.d.Schema.WithMainOnlyColumns(resolution.SkipColumns); err != nil {
+			return nil, fmt.Errorf("resolution %s: %w", resolution.Interval, err)
+		}
+	}
+	if err := validateCustomViews(c.config.CustomViews); err != nil {
+		return nil, err
+	}
+	if err := validateAccessControl(c.config.AccessControl); err != nil {
+		return nil, err
+	}
+	if c.config.Backup.Enable && c.config.Backup.Disk == "" && c.config.Backup.S3URL == "" {
+		return nil, errors.New("backups need either a disk or a S3 URL")
+	}
 
 	c.d.Daemon.Track(&c.t, "orchestrator/clickhouse")
 
@@ -105,6 +147,9 @@ func (c *Component) Start() error {
 
 	// Database migration
 	if c.d.ClickHouse != nil {
+		if !c.config.SkipMigrations {
+			c.r.RegisterHealthcheck("clickhouse/migrations", c.migrationsHealthcheck)
+		}
 		migrationsOnce := false
 		c.metrics.migrationsRunning.Set(1)
 		c.t.Go(func() error {
@@ -135,11 +180,48 @@ func (c *Component) Start() error {
 		})
 	}
 
+	// Dictionaries
+	if c.d.ClickHouse != nil {
+		c.t.Go(c.dictionariesMetricsUpdater)
+		if err := c.watchCustomDictionaries(); err != nil {
+			return fmt.Errorf("unable to watch custom dictionaries: %w", err)
+		}
+	}
+
+	// Schema drift
+	if c.d.ClickHouse != nil && !c.config.SkipMigrations && c.config.SchemaDrift.Interval > 0 {
+		c.t.Go(c.schemaDriftChecker)
+	}
+
+	// Raw tables collection
+	if c.d.ClickHouse != nil && !c.config.SkipMigrations && c.config.RawTablesGC.Interval > 0 {
+		c.t.Go(c.rawTablesCollector)
+	}
+
+	// Resolution gaps detection
+	if c.d.ClickHouse != nil && !c.config.SkipMigrations {
+		c.t.Go(c.resolutionGapsChecker)
+	}
+
+	// Remote-write of ingest aggregates
+	if c.d.ClickHouse != nil && c.config.RemoteWrite.URL != "" {
+		c.t.Go(c.remoteWritePusher)
+	}
+
 	// Network sources update
 	if err := c.networkSourcesFetcher.Start(); err != nil {
 		return fmt.Errorf("unable to start network sources fetcher component: %w", err)
 	}
 
+	// ASN sources update
+	if err := c.asnSourcesFetcher.Start(); err != nil {
+		return fmt.Errorf("unable to start ASN sources fetcher component: %w", err)
+	}
+	c.t.Go(func() error {
+		c.asnsReloader()
+		return nil
+	})
+
 	// GeoIP updates
 	notifyChan := c.d.GeoIP.Notify()
 	c.t.Go(func() error {
@@ -179,5 +261,6 @@ func (c *Component) Stop() error {
 	defer c.r.Info().Msg("ClickHouse component stopped")
 	c.t.Kill(nil)
 	c.networkSourcesFetcher.Stop()
+	c.asnSourcesFetcher.Stop()
 	return c.t.Wait()
 }
@@ -15,6 +15,11 @@ type externalNetworkAttributes struct {
 	NetworkAttributes `mapstructure:",squash"`
 }
 
+// Key returns the key identifying the network for incremental updates.
+func (na externalNetworkAttributes) Key() string {
+	return na.Prefix.String()
+}
+
 // UpdateSource updates a remote network source. It returns the
 // number of networks retrieved.
 func (c *Component) UpdateSource(ctx context.Context, name string, source remotedatasource.Source) (int, error) {
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ttlMutation describes a mutation materializing the TTL of a table.
+type ttlMutation struct {
+	Table            string    `ch:"table" json:"table"`
+	MutationID       string    `ch:"mutation_id" json:"mutation-id"`
+	CreateTime       time.Time `ch:"create_time" json:"create-time"`
+	PartsToDo        int64     `ch:"parts_to_do" json:"parts-to-do"`
+	IsDone           uint8     `ch:"is_done" json:"-"`
+	LatestFailReason string    `ch:"latest_fail_reason" json:"latest-fail-reason,omitempty"`
+}
+
+// flowsTables returns the name of the local flows tables.
+func (c *Component) flowsTables() []string {
+	tables := []string{}
+	for _, resolution := range c.config.Resolutions {
+		if resolution.Interval == 0 {
+			tables = append(tables, c.localTable("flows"))
+		} else {
+			tables = append(tables, c.localTable(fmt.Sprintf("flows_%s", resolution.Interval)))
+		}
+	}
+	return tables
+}
+
+// materializeTTL starts the materialization of the TTL for all the flows
+// tables. ClickHouse executes them in the background as mutations.
+func (c *Component) materializeTTL(ctx context.Context) error {
+	for _, table := range c.flowsTables() {
+		c.r.Info().Msgf("materialize TTL for %s", table)
+		if err := c.d.ClickHouse.ExecOnCluster(ctx,
+			fmt.Sprintf("ALTER TABLE %s MATERIALIZE TTL", table)); err != nil {
+			return fmt.Errorf("cannot materialize TTL for %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ttlMutations returns the mutations materializing TTL for the flows tables.
+func (c *Component) ttlMutations(ctx context.Context) ([]ttlMutation, error) {
+	var mutations []ttlMutation
+	if err := c.d.ClickHouse.Select(ctx, &mutations, `
+SELECT table, mutation_id, create_time, parts_to_do, is_done, latest_fail_reason
+FROM system.mutations
+WHERE database = currentDatabase()
+AND has($1, table)
+AND command LIKE '%MATERIALIZE TTL%'
+ORDER BY create_time DESC, table
+`, c.flowsTables()); err != nil {
+		return nil, fmt.Errorf("cannot get TTL mutations: %w", err)
+	}
+	return mutations, nil
+}
+
+func (c *Component) ttlMaterializeHandlerFunc(gc *gin.Context) {
+	if c.d.ClickHouse == nil {
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "ClickHouse is not available."})
+		return
+	}
+	ctx := gc.Request.Context()
+	if gc.Request.Method == http.MethodPost {
+		if !c.ttlMaterializeRunning.CompareAndSwap(false, true) {
+			gc.JSON(http.StatusConflict, gin.H{"message": "TTL materialization is already being started."})
+			return
+		}
+		c.t.Go(func() error {
+			defer c.ttlMaterializeRunning.Store(false)
+			ctx := c.t.Context(context.Background())
+			if err := c.materializeTTL(ctx); err != nil {
+				c.r.Err(err).Msg("cannot materialize TTL")
+			}
+			return nil
+		})
+		gc.JSON(http.StatusAccepted, gin.H{"message": "TTL materialization started."})
+		return
+	}
+
+	mutations, err := c.ttlMutations(ctx)
+	if err != nil {
+		c.r.Err(err).Msg("cannot get TTL materialization progress")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot get TTL materialization progress."})
+		return
+	}
+	running := []ttlMutation{}
+	done := []ttlMutation{}
+	for _, mutation := range mutations {
+		if mutation.IsDone == 0 {
+			running = append(running, mutation)
+		} else {
+			done = append(done, mutation)
+		}
+	}
+	gc.JSON(http.StatusOK, gin.H{
+		"starting": c.ttlMaterializeRunning.Load(),
+		"running":  running,
+		"done":     done,
+	})
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/orchestrator/geoip"
+)
+
+func TestMaterializeTTL(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent, mockConn := clickhousedb.NewMock(t, r)
+	mockConn.EXPECT().
+		Exec(gomock.Any(), "SYSTEM RELOAD DICTIONARY default.networks").
+		Return(nil).
+		AnyTimes()
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), dictionariesStatusQuery).
+		Return(nil).
+		AnyTimes()
+	h := httpserver.NewMock(t, r)
+	config := DefaultConfiguration()
+	config.SkipMigrations = true
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		HTTP:       h,
+		Schema:     schema.NewMock(t),
+		GeoIP:      geoip.NewMock(t, r, false),
+		ClickHouse: chComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	for _, table := range []string{"flows", "flows_1m0s", "flows_5m0s", "flows_1h0m0s"} {
+		mockConn.EXPECT().
+			Exec(gomock.Any(), "ALTER TABLE "+table+" MATERIALIZE TTL").
+			Return(nil)
+	}
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "materialization without token",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/ttl/materialize",
+			StatusCode:  401,
+			JSONOutput:  map[string]any{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "start materialization",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/clickhouse/ttl/materialize",
+			Header:      httpserver.MockAdminHeader(),
+			StatusCode:  202,
+			JSONOutput:  map[string]any{"message": "TTL materialization started."},
+		},
+	})
+	for i := 0; c.ttlMaterializeRunning.Load(); i++ {
+		if i > 100 {
+			t.Fatal("materialization not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	createTime := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(),
+			[]string{"flows", "flows_1m0s", "flows_5m0s", "flows_1h0m0s"}).
+		Return(nil).
+		SetArg(1, []ttlMutation{
+			{"flows", "mutation_2.txt", createTime, 10, 0, ""},
+			{"flows_1m0s", "mutation_3.txt", createTime, 0, 1, ""},
+		})
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "get progress",
+			URL:         "/api/v0/orchestrator/clickhouse/ttl/materialize",
+			JSONOutput: map[string]any{
+				"starting": false,
+				"running": []map[string]any{{
+					"table":       "flows",
+					"mutation-id": "mutation_2.txt",
+					"create-time": "2026-10-16T10:00:00Z",
+					"parts-to-do": 10,
+				}},
+				"done": []map[string]any{{
+					"table":       "flows_1m0s",
+					"mutation-id": "mutation_3.txt",
+					"create-time": "2026-10-16T10:00:00Z",
+					"parts-to-do": 0,
+				}},
+			},
+		},
+	})
+}
@@ -4,9 +4,27 @@
 package orchestrator
 
 // Configuration describes the configuration for the orchestrator.
-type Configuration struct{}
+type Configuration struct {
+	// ConfigurationHistory defines how to keep the history of the
+	// configurations served to the other services.
+	ConfigurationHistory HistoryConfiguration
+}
+
+// HistoryConfiguration describes how to keep the history of the served
+// configurations.
+type HistoryConfiguration struct {
+	// Directory is the directory where to store the versions. When empty,
+	// versions are only kept in memory.
+	Directory string
+	// Size is the number of versions to keep.
+	Size int `validate:"min=1"`
+}
 
 // DefaultConfiguration represents the default configuration for the orchestrator.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		ConfigurationHistory: HistoryConfiguration{
+			Size: 50,
+		},
+	}
 }
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package orchestrator
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"akvorado/common/helpers/yaml"
+)
+
+// ConfigurationChange describes a difference between two configurations.
+type ConfigurationChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// normalizeConfiguration turns a configuration into a generic structure by
+// serializing it to YAML, as it would be served to the services.
+func normalizeConfiguration(configuration any) (any, error) {
+	if configuration == nil {
+		return nil, nil
+	}
+	out, err := yaml.Marshal(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize configuration: %w", err)
+	}
+	var result any
+	if err := yaml.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("cannot deserialize configuration: %w", err)
+	}
+	return result, nil
+}
+
+// diffConfigurations returns the list of changes between two configurations.
+func diffConfigurations(path string, old, new any) []ConfigurationChange {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return fmt.Sprintf("%s.%s", path, key)
+	}
+	switch oldV := old.(type) {
+	case map[string]any:
+		if newV, ok := new.(map[string]any); ok {
+			keys := []string{}
+			for k := range oldV {
+				keys = append(keys, k)
+			}
+			for k := range newV {
+				if _, ok := oldV[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+			changes := []ConfigurationChange{}
+			for _, k := range keys {
+				changes = append(changes, diffConfigurations(join(k), oldV[k], newV[k])...)
+			}
+			return changes
+		}
+	case []any:
+		if newV, ok := new.([]any); ok {
+			changes := []ConfigurationChange{}
+			for i := range max(len(oldV), len(newV)) {
+				var o, n any
+				if i < len(oldV) {
+					o = oldV[i]
+				}
+				if i < len(newV) {
+					n = newV[i]
+				}
+				changes = append(changes, diffConfigurations(join(strconv.Itoa(i)), o, n)...)
+			}
+			return changes
+		}
+	}
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []ConfigurationChange{{Path: path, Old: old, New: new}}
+}
+
+// DiffConfigurations returns the list of changes between two configurations,
+// once serialized as they would be served to the services.
+func DiffConfigurations(old, new any) ([]ConfigurationChange, error) {
+	oldN, err := normalizeConfiguration(old)
+	if err != nil {
+		return nil, err
+	}
+	newN, err := normalizeConfiguration(new)
+	if err != nil {
+		return nil, err
+	}
+	return diffConfigurations("", oldN, newN), nil
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package orchestrator
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestDiffConfigurations(t *testing.T) {
+	type nested struct {
+		Name  string
+		Ports []int
+	}
+	type configuration struct {
+		Enabled bool
+		Nested  nested
+		Extra   map[string]string
+	}
+	cases := []struct {
+		Pos      helpers.Pos
+		Old      any
+		New      any
+		Expected []ConfigurationChange
+	}{
+		{
+			Pos:      helpers.Mark(),
+			Old:      configuration{Nested: nested{Name: "foo"}},
+			New:      configuration{Nested: nested{Name: "foo"}},
+			Expected: []ConfigurationChange{},
+		}, {
+			Pos: helpers.Mark(),
+			Old: configuration{Nested: nested{Name: "foo", Ports: []int{1, 2}}},
+			New: configuration{
+				Enabled: true,
+				Nested:  nested{Name: "bar", Ports: []int{1, 3, 4}},
+				Extra:   map[string]string{"key": "value"},
+			},
+			Expected: []ConfigurationChange{
+				{Path: "root.enabled", Old: false, New: true},
+				{Path: "root.extra.key", New: "value"},
+				{Path: "root.nested.name", Old: "foo", New: "bar"},
+				{Path: "root.nested.ports.1", Old: 2, New: 3},
+				{Path: "root.nested.ports.2", New: 4},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Old: nil,
+			New: configuration{},
+			Expected: []ConfigurationChange{
+				{Path: "root", New: map[string]any{
+					"enabled": false,
+					"extra":   map[string]any{},
+					"nested":  map[string]any{"name": "", "ports": []any{}},
+				}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		old, err := normalizeConfiguration(tc.Old)
+		if err != nil {
+			t.Fatalf("%snormalizeConfiguration() error:\n%+v", tc.Pos, err)
+		}
+		new, err := normalizeConfiguration(tc.New)
+		if err != nil {
+			t.Fatalf("%snormalizeConfiguration() error:\n%+v", tc.Pos, err)
+		}
+		got := diffConfigurations("root", old, new)
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%sdiffConfigurations() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
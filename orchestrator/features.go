@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package orchestrator
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Feature is a configuration feature of a service which may not be supported
+// by all the versions of this service. During a rolling upgrade, a service
+// tells the orchestrator the features it supports when fetching its
+// configuration.
+type Feature struct {
+	// Name is the name of the feature, as advertised by the services.
+	Name string
+	// Key is the dotted path of the configuration key enabling the feature,
+	// as serialized for the services (for example, "clickhouse.aggregation").
+	Key string
+}
+
+// serviceFeatures are the features registered for a service, with the
+// default configuration telling when they are enabled.
+type serviceFeatures struct {
+	defaultConfiguration any
+	features             []Feature
+}
+
+// RegisterFeatures registers the features of a service. A feature is enabled
+// when the value of its key differs from the one in the provided default
+// configuration.
+func (c *Component) RegisterFeatures(service ServiceType, defaultConfiguration any, features []Feature) {
+	c.serviceLock.Lock()
+	c.serviceFeatures[service] = serviceFeatures{
+		defaultConfiguration: defaultConfiguration,
+		features:             features,
+	}
+	c.serviceLock.Unlock()
+}
+
+// negotiateConfiguration adapts a configuration for a service supporting only
+// the provided features. The keys of unsupported features are removed when
+// they are not enabled. Otherwise, the list of unsupported enabled features is
+// returned.
+func negotiateConfiguration(registered serviceFeatures, configuration any, supported []string) (any, []string, error) {
+	current, err := normalizeConfiguration(configuration)
+	if err != nil {
+		return nil, nil, err
+	}
+	defaults, err := normalizeConfiguration(registered.defaultConfiguration)
+	if err != nil {
+		return nil, nil, err
+	}
+	unsupported := []string{}
+	for _, feature := range registered.features {
+		if slices.Contains(supported, feature.Name) {
+			continue
+		}
+		path := strings.Split(feature.Key, ".")
+		parent, ok := lookupConfigurationKey(current, path[:len(path)-1]).(map[string]any)
+		if !ok {
+			continue
+		}
+		value, ok := parent[path[len(path)-1]]
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(value, lookupConfigurationKey(defaults, path)) {
+			unsupported = append(unsupported, feature.Name)
+			continue
+		}
+		delete(parent, path[len(path)-1])
+	}
+	if len(unsupported) > 0 {
+		return nil, unsupported, nil
+	}
+	return current, nil, nil
+}
+
+// lookupConfigurationKey returns the value at the provided path in a
+// normalized configuration, or nil if there is none.
+func lookupConfigurationKey(configuration any, path []string) any {
+	for _, key := range path {
+		m, ok := configuration.(map[string]any)
+		if !ok {
+			return nil
+		}
+		configuration = m[key]
+	}
+	return configuration
+}
+
+// parseFeatures parses the list of features advertised by a service.
+func parseFeatures(header string) []string {
+	features := []string{}
+	for feature := range strings.SplitSeq(header, ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// unsupportedFeaturesMessage returns the message sent to a service which does
+// not support some enabled features.
+func unsupportedFeaturesMessage(features []string) string {
+	return fmt.Sprintf("Configuration uses features not supported by this version: %s.",
+		strings.Join(features, ", "))
+}
@@ -4,6 +4,8 @@
 package geoip
 
 import (
+	"time"
+
 	"akvorado/common/helpers"
 )
 
@@ -15,16 +17,58 @@ type Configuration struct {
 	GeoDatabase []string
 	// Optional tells if we need to error if not present on start.
 	Optional bool
+	// PollInterval tells how often to check for modified databases, in
+	// case the file watcher misses a modification. 0 disables polling.
+	PollInterval time.Duration `validate:"min=0"`
+	// Downloads defines databases to download and refresh periodically.
+	Downloads []DownloadConfiguration `validate:"dive"`
+}
+
+// DownloadConfiguration describes a database to download. The downloaded
+// database is added to the ASN or geo databases.
+type DownloadConfiguration struct {
+	// URL is the URL to fetch the database from. It can be a MMDB file or a
+	// tar archive containing one, both optionally gzip-compressed.
+	URL string `validate:"required,url"`
+	// ChecksumURL is the URL of the SHA256 checksum of the downloaded file.
+	ChecksumURL string `validate:"omitempty,url"`
+	// Path is where to store the database.
+	Path string `validate:"required"`
+	// Type is the type of database (asn or geo).
+	Type string `validate:"oneof=asn geo"`
+	// Username and Password are used for basic authentication (account ID
+	// and license key for MaxMind).
+	Username string
+	Password string `validate:"required_with=Username"`
+	// Token is added as the token query parameter (IPinfo).
+	Token string
+	// Timeout tells the maximum time the download should take.
+	Timeout time.Duration `validate:"min=1s"`
+	// Interval tells how much time to wait before refreshing the database.
+	Interval time.Duration `validate:"min=1h"`
+}
+
+// DefaultDownloadConfiguration is the default configuration for a database
+// download.
+func DefaultDownloadConfiguration() DownloadConfiguration {
+	return DownloadConfiguration{
+		Timeout:  5 * time.Minute,
+		Interval: 24 * time.Hour,
+	}
 }
 
 // DefaultConfiguration represents the default configuration for the
 // GeoIP component. Without databases, the component won't report
 // anything.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		PollInterval: time.Minute,
+	}
 }
 
 func init() {
 	helpers.RegisterMapstructureUnmarshallerHook(
 		helpers.RenameKeyUnmarshallerHook(Configuration{}, "CountryDatabase", "GeoDatabase"))
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultDownloadConfiguration()))
 }
@@ -5,6 +5,7 @@ package geoip
 
 import (
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
 
@@ -73,6 +74,65 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 				}
 			},
 			Error: true,
+		}, {
+			Description: "downloads",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"downloads": []gin.H{
+						{
+							"url":          "https://download.maxmind.com/geoip/databases/GeoLite2-ASN/download?suffix=tar.gz",
+							"checksum-url": "https://download.maxmind.com/geoip/databases/GeoLite2-ASN/download?suffix=tar.gz.sha256",
+							"path":         "/var/lib/akvorado/asn.mmdb",
+							"type":         "asn",
+							"username":     "12345",
+							"password":     "license",
+						}, {
+							"url":      "https://ipinfo.io/data/free/country.mmdb",
+							"path":     "/var/lib/akvorado/country.mmdb",
+							"type":     "geo",
+							"token":    "token",
+							"interval": "6h",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Downloads: []DownloadConfiguration{
+					{
+						URL:         "https://download.maxmind.com/geoip/databases/GeoLite2-ASN/download?suffix=tar.gz",
+						ChecksumURL: "https://download.maxmind.com/geoip/databases/GeoLite2-ASN/download?suffix=tar.gz.sha256",
+						Path:        "/var/lib/akvorado/asn.mmdb",
+						Type:        "asn",
+						Username:    "12345",
+						Password:    "license",
+						Timeout:     5 * time.Minute,
+						Interval:    24 * time.Hour,
+					}, {
+						URL:      "https://ipinfo.io/data/free/country.mmdb",
+						Path:     "/var/lib/akvorado/country.mmdb",
+						Type:     "geo",
+						Token:    "token",
+						Timeout:  5 * time.Minute,
+						Interval: 6 * time.Hour,
+					},
+				},
+			},
+		}, {
+			Description: "download with invalid type",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"downloads": []gin.H{
+						{
+							"url":  "https://ipinfo.io/data/free/country.mmdb",
+							"path": "/var/lib/akvorado/country.mmdb",
+							"type": "city",
+						},
+					},
+				}
+			},
+			Error: true,
 		},
 	})
 }
@@ -4,9 +4,13 @@
 package geoip
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/netip"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/oschwald/maxminddb-golang/v2"
 )
@@ -23,18 +27,72 @@ type geoDatabase interface {
 	IterGeoDatabase(GeoIterFunc) error
 }
 
+// fileState is used to detect a modification of a database file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileState, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: st.ModTime(), size: st.Size()}, nil
+}
+
+// readDatabase reads the provided database in memory and verifies it. It
+// ensures the file was not modified while reading it. This way, a partially
+// written file is never used and later modifications of the file do not
+// affect the returned database.
+func readDatabase(path string) (*maxminddb.Reader, fileState, error) {
+	before, err := statFile(path)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	after, err := statFile(path)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	if before != after || int64(len(content)) != after.size {
+		return nil, fileState{}, errors.New("database modified while reading it")
+	}
+	db, err := maxminddb.OpenBytes(content)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	if err := db.Verify(); err != nil {
+		db.Close()
+		return nil, fileState{}, fmt.Errorf("invalid database: %w", err)
+	}
+	return db, after, nil
+}
+
 // openDatabase opens the provided database and closes the current
-// one. Do nothing if the path is empty.
+// one. Do nothing if the path is empty. On error, the current database is
+// kept.
 func (c *Component) openDatabase(which, path string, notifySubscribers bool) error {
 	if path == "" {
 		return nil
 	}
 	c.r.Debug().Str("database", path).Msgf("opening %s database", which)
-	db, err := maxminddb.Open(path)
+	db, state, err := readDatabase(path)
 	if err != nil {
 		c.r.Err(err).
 			Str("database", path).
 			Msgf("cannot open %s database", which)
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.metrics.databaseRefreshErrors.WithLabelValues(which).Inc()
+		}
+		// Do not retry until the file is modified again
+		state, _ = statFile(path)
+		c.db.lock.Lock()
+		c.db.state[which+":"+path] = state
+		c.db.lock.Unlock()
 		return fmt.Errorf("cannot open %s database: %w", which, err)
 	}
 	newOne, err := getGeoDatabase(db)
@@ -52,7 +110,9 @@ func (c *Component) openDatabase(which, path string, notifySubscribers bool) err
 		oldOne = c.db.geo[path]
 		c.db.geo[path] = newOne
 	}
+	c.db.state[which+":"+path] = state
 	c.metrics.databaseRefresh.WithLabelValues(which).Inc()
+	c.metrics.databaseBuildEpoch.WithLabelValues(which, path).Set(float64(db.Metadata.BuildEpoch))
 	if oldOne != nil {
 		c.r.Debug().
 			Str("database", path).
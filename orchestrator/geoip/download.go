@@ -0,0 +1,226 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package geoip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// maxDownloadSize is the maximum size of a downloaded database.
+const maxDownloadSize = 1 << 30
+
+// errNotModified is returned when the remote database was not modified.
+var errNotModified = errors.New("database not modified")
+
+// downloadLoop refreshes the provided database periodically. The first
+// refresh happens when the current file is older than the interval.
+func (c *Component) downloadLoop(d DownloadConfiguration) {
+	next := d.Interval
+	if st, err := os.Stat(d.Path); err == nil {
+		next = max(0, d.Interval-time.Since(st.ModTime()))
+	}
+	timer := time.NewTimer(next)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			return
+		case <-timer.C:
+			c.download(d)
+			timer.Reset(d.Interval)
+		}
+	}
+}
+
+// download fetches the provided database and logs the outcome.
+func (c *Component) download(d DownloadConfiguration) error {
+	ctx, cancel := context.WithTimeout(c.t.Context(context.Background()), d.Timeout)
+	defer cancel()
+	l := c.r.With().Str("database", d.Path).Logger()
+	l.Debug().Msg("downloading database")
+	err := c.fetchDatabase(ctx, d)
+	switch {
+	case errors.Is(err, errNotModified):
+		l.Debug().Msg("database not modified")
+		c.metrics.downloads.WithLabelValues(d.Path, "not-modified").Inc()
+		return nil
+	case err != nil:
+		l.Err(err).Msg("cannot download database")
+		c.metrics.downloads.WithLabelValues(d.Path, "error").Inc()
+		return err
+	}
+	l.Info().Msg("database downloaded")
+	c.metrics.downloads.WithLabelValues(d.Path, "success").Inc()
+	c.metrics.downloadLastSuccess.WithLabelValues(d.Path).SetToCurrentTime()
+	return nil
+}
+
+// fetchDatabase downloads the provided database, checks it, and atomically
+// replaces the current one. The watcher then takes care of reloading it.
+func (c *Component) fetchDatabase(ctx context.Context, d DownloadConfiguration) error {
+	var modTime time.Time
+	if st, err := os.Stat(d.Path); err == nil {
+		modTime = st.ModTime()
+	}
+	body, lastModified, err := c.fetch(ctx, d, d.URL, modTime)
+	if err != nil {
+		return err
+	}
+
+	if d.ChecksumURL != "" {
+		checksum, _, err := c.fetch(ctx, d, d.ChecksumURL, time.Time{})
+		if err != nil {
+			return fmt.Errorf("cannot fetch checksum: %w", err)
+		}
+		fields := strings.Fields(string(checksum))
+		if len(fields) == 0 {
+			return errors.New("empty checksum")
+		}
+		got := sha256.Sum256(body)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(got[:])) {
+			return fmt.Errorf("checksum mismatch: expected %s, got %s",
+				fields[0], hex.EncodeToString(got[:]))
+		}
+	}
+
+	content, err := extractDatabase(body)
+	if err != nil {
+		return err
+	}
+	db, err := maxminddb.OpenBytes(content)
+	if err != nil {
+		return fmt.Errorf("invalid database: %w", err)
+	}
+	db.Close()
+
+	// Write to a temporary file in the same directory and rename it to not
+	// expose a partially written file.
+	tmp, err := os.CreateTemp(filepath.Dir(d.Path), fmt.Sprintf(".%s-*", filepath.Base(d.Path)))
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write temporary file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("cannot change permissions of temporary file: %w", err)
+	}
+	if !lastModified.IsZero() {
+		os.Chtimes(tmp.Name(), lastModified, lastModified)
+	}
+	if err := os.Rename(tmp.Name(), d.Path); err != nil {
+		return fmt.Errorf("cannot rename temporary file: %w", err)
+	}
+	return nil
+}
+
+// fetch executes an HTTP request to the provided URL and returns the body and
+// its last modification time. When modTime is not zero, errNotModified is
+// returned if the content was not modified since then.
+func (c *Component) fetch(ctx context.Context, d DownloadConfiguration, u string, modTime time.Time) ([]byte, time.Time, error) {
+	if d.Token != "" {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("cannot parse URL: %w", err)
+		}
+		q := parsed.Query()
+		q.Set("token", d.Token)
+		parsed.RawQuery = q.Encode()
+		u = parsed.String()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("cannot build request: %w", err)
+	}
+	if d.Username != "" {
+		req.SetBasicAuth(d.Username, d.Password)
+	}
+	if !modTime.IsZero() {
+		req.Header.Set("If-Modified-Since", modTime.UTC().Format(http.TimeFormat))
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Do not leak the credentials from the URL
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, time.Time{}, fmt.Errorf("cannot fetch database: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, time.Time{}, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("cannot read body: %w", err)
+	}
+	if len(body) > maxDownloadSize {
+		return nil, time.Time{}, errors.New("database too large")
+	}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return body, lastModified, nil
+}
+
+// extractDatabase returns the MMDB file from the downloaded content. It
+// handles gzip compression and tar archives.
+func extractDatabase(content []byte) ([]byte, error) {
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress database: %w", err)
+		}
+		content, err = io.ReadAll(io.LimitReader(gz, maxDownloadSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress database: %w", err)
+		}
+		if len(content) > maxDownloadSize {
+			return nil, errors.New("database too large")
+		}
+	}
+	// A tar archive has "ustar" at offset 257
+	if len(content) < 262 || string(content[257:262]) != "ustar" {
+		return content, nil
+	}
+	tr := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no database in archive")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && strings.HasSuffix(header.Name, ".mmdb") {
+			return io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package geoip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestExtractDatabase(t *testing.T) {
+	mmdb, err := os.ReadFile(filepath.Join("testdata", "GeoLite2-ASN-Test.mmdb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error:\n%+v", err)
+	}
+	gzipped := func(content []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(content)
+		gz.Close()
+		return buf.Bytes()
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "GeoLite2-ASN_20260101/", Typeflag: tar.TypeDir, Mode: 0o755})
+	tw.WriteHeader(&tar.Header{Name: "GeoLite2-ASN_20260101/LICENSE.txt", Size: 3, Mode: 0o644})
+	tw.Write([]byte("MIT"))
+	tw.WriteHeader(&tar.Header{Name: "GeoLite2-ASN_20260101/GeoLite2-ASN.mmdb", Size: int64(len(mmdb)), Mode: 0o644})
+	tw.Write(mmdb)
+	tw.Close()
+
+	cases := []struct {
+		Description string
+		Input       []byte
+	}{
+		{"raw", mmdb},
+		{"gzip", gzipped(mmdb)},
+		{"tar", archive.Bytes()},
+		{"tar.gz", gzipped(archive.Bytes())},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			got, err := extractDatabase(tc.Input)
+			if err != nil {
+				t.Fatalf("extractDatabase() error:\n%+v", err)
+			}
+			if !bytes.Equal(got, mmdb) {
+				t.Fatal("extractDatabase() did not return the database")
+			}
+		})
+	}
+}
+
+func TestDownload(t *testing.T) {
+	mmdb, err := os.ReadFile(filepath.Join("testdata", "GeoLite2-ASN-Test.mmdb"))
+	if err != nil {
+		t.Fatalf("ReadFile() error:\n%+v", err)
+	}
+	sum := sha256.Sum256(mmdb)
+	lastModified := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/asn.mmdb", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeContent(w, r, "asn.mmdb", lastModified, bytes.NewReader(mmdb))
+	})
+	mux.HandleFunc("/asn.mmdb.sha256", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%s  asn.mmdb\n", hex.EncodeToString(sum[:]))
+	})
+	mux.HandleFunc("/bad.sha256", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%s  asn.mmdb\n", strings.Repeat("0", 64))
+	})
+	mux.HandleFunc("/invalid.mmdb", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("hello world"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dir := t.TempDir()
+	asnFile := filepath.Join(dir, "asn.mmdb")
+	config := DefaultConfiguration()
+	download := DefaultDownloadConfiguration()
+	download.URL = fmt.Sprintf("%s/asn.mmdb", server.URL)
+	download.ChecksumURL = fmt.Sprintf("%s/asn.mmdb.sha256", server.URL)
+	download.Token = "secret"
+	download.Path = asnFile
+	download.Type = "asn"
+	config.Downloads = []DownloadConfiguration{download}
+
+	r := reporter.NewMock(t)
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// The database should have been downloaded and opened on start
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_geoip_", "db_refresh_total", "downloads_total")
+	expectedMetrics := map[string]string{
+		`db_refresh_total{database="asn"}`:                                      "1",
+		fmt.Sprintf(`downloads_total{database="%s",result="success"}`, asnFile): "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+	if st, err := os.Stat(asnFile); err != nil {
+		t.Fatalf("Stat() error:\n%+v", err)
+	} else if !st.ModTime().Equal(lastModified) {
+		t.Fatalf("Stat().ModTime() == %s, expected %s", st.ModTime(), lastModified)
+	}
+
+	// Downloading again should not modify the database
+	if err := c.fetchDatabase(context.Background(), download); err != errNotModified {
+		t.Fatalf("fetchDatabase() error:\n%+v", err)
+	}
+
+	// Bad checksum, invalid database, or bad token should be rejected
+	badChecksum := download
+	badChecksum.ChecksumURL = fmt.Sprintf("%s/bad.sha256", server.URL)
+	invalid := download
+	invalid.URL = fmt.Sprintf("%s/invalid.mmdb", server.URL)
+	invalid.ChecksumURL = ""
+	badToken := download
+	badToken.Token = "wrong"
+	for _, d := range []DownloadConfiguration{badChecksum, invalid, badToken} {
+		os.Chtimes(asnFile, time.Time{}, lastModified.Add(-time.Hour))
+		if err := c.fetchDatabase(context.Background(), d); err == nil {
+			t.Fatalf("fetchDatabase(%s) did not error", d.URL)
+		}
+		if got, _ := os.ReadFile(asnFile); !bytes.Equal(got, mmdb) {
+			t.Fatalf("fetchDatabase(%s) modified the database", d.URL)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("ReadDir() returned %d entries, expected 1", len(entries))
+	}
+}
@@ -7,7 +7,10 @@ package geoip
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -26,13 +29,18 @@ type Component struct {
 	config Configuration
 
 	db struct {
-		geo  map[string]geoDatabase
-		asn  map[string]geoDatabase
-		lock sync.RWMutex
+		geo   map[string]geoDatabase
+		asn   map[string]geoDatabase
+		state map[string]fileState // last known state of each file
+		lock  sync.RWMutex
 	}
 
 	metrics struct {
-		databaseRefresh *reporter.CounterVec
+		databaseRefresh       *reporter.CounterVec
+		databaseRefreshErrors *reporter.CounterVec
+		databaseBuildEpoch    *reporter.GaugeVec
+		downloads             *reporter.CounterVec
+		downloadLastSuccess   *reporter.GaugeVec
 	}
 
 	onOpenChan        chan struct{}   // input notification channel
@@ -57,7 +65,22 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	}
 	c.db.geo = make(map[string]geoDatabase)
 	c.db.asn = make(map[string]geoDatabase)
+	c.db.state = make(map[string]fileState)
 
+	for i, d := range c.config.Downloads {
+		d.Path = filepath.Clean(d.Path)
+		c.config.Downloads[i] = d
+		switch d.Type {
+		case "asn":
+			if !slices.Contains(c.config.ASNDatabase, d.Path) {
+				c.config.ASNDatabase = append(c.config.ASNDatabase, d.Path)
+			}
+		case "geo":
+			if !slices.Contains(c.config.GeoDatabase, d.Path) {
+				c.config.GeoDatabase = append(c.config.GeoDatabase, d.Path)
+			}
+		}
+	}
 	for i, path := range c.config.GeoDatabase {
 		c.config.GeoDatabase[i] = filepath.Clean(path)
 	}
@@ -72,6 +95,34 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		},
 		[]string{"database"},
 	)
+	c.metrics.databaseRefreshErrors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "db_refresh_errors_total",
+			Help: "Failed refresh event for a GeoIP database.",
+		},
+		[]string{"database"},
+	)
+	c.metrics.databaseBuildEpoch = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "database_build_epoch_seconds",
+			Help: "Build time of the loaded GeoIP database.",
+		},
+		[]string{"database", "path"},
+	)
+	c.metrics.downloads = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "downloads_total",
+			Help: "Download attempts of a GeoIP database.",
+		},
+		[]string{"database", "result"},
+	)
+	c.metrics.downloadLastSuccess = c.r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "download_last_success_seconds",
+			Help: "Time of the last successful download of a GeoIP database.",
+		},
+		[]string{"database"},
+	)
 	return &c, nil
 }
 
@@ -106,6 +157,13 @@ func (c *Component) Start() error {
 		return nil
 	})
 
+	// Download missing databases before opening them
+	for _, d := range c.config.Downloads {
+		if _, err := os.Stat(d.Path); errors.Is(err, fs.ErrNotExist) {
+			c.download(d)
+		}
+	}
+
 	for _, path := range c.config.GeoDatabase {
 		if err := c.openDatabase("geo", path, false); err != nil && !c.config.Optional {
 			return err
@@ -136,6 +194,27 @@ func (c *Component) Start() error {
 			return fmt.Errorf("cannot watch database directory: %w", err)
 		}
 	}
+	for _, d := range c.config.Downloads {
+		c.t.Go(func() error {
+			c.downloadLoop(d)
+			return nil
+		})
+	}
+	// Poll for modifications in case the watcher misses some of them
+	if c.config.PollInterval > 0 {
+		c.t.Go(func() error {
+			ticker := time.NewTicker(c.config.PollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-c.t.Dying():
+					return nil
+				case <-ticker.C:
+					c.pollDatabases()
+				}
+			}
+		})
+	}
 	c.t.Go(func() error {
 		errLogger := c.r.Sample(reporter.BurstSampler(10*time.Second, 1))
 		defer watcher.Close()
@@ -179,6 +258,31 @@ func (c *Component) Start() error {
 	return nil
 }
 
+// pollDatabases reopens the databases whose file was modified since they were
+// last opened.
+func (c *Component) pollDatabases() {
+	check := func(which, path string) {
+		state, err := statFile(path)
+		if err != nil {
+			return
+		}
+		c.db.lock.RLock()
+		previous, ok := c.db.state[which+":"+path]
+		c.db.lock.RUnlock()
+		if ok && previous == state {
+			return
+		}
+		c.r.Debug().Str("database", path).Msgf("%s database modified", which)
+		c.openDatabase(which, path, true)
+	}
+	for _, path := range c.config.GeoDatabase {
+		check("geo", path)
+	}
+	for _, path := range c.config.ASNDatabase {
+		check("asn", path)
+	}
+}
+
 // Stop stops the GeoIP component.
 func (c *Component) Stop() error {
 	c.r.Info().Msg("stopping GeoIP component")
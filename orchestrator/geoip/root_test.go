@@ -4,7 +4,9 @@
 package geoip
 
 import (
+	"fmt"
 	"io"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"sync/atomic"
@@ -220,3 +222,110 @@ func TestStartWithMissingDatabase(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabasePartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfiguration()
+	asnFile := filepath.Join(dir, "asn.mmdb")
+	config.ASNDatabase = []string{asnFile}
+	copyFile(t, filepath.Join("testdata", "GeoLite2-ASN-Test.mmdb"), asnFile)
+	content, err := os.ReadFile(asnFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error:\n%+v", err)
+	}
+
+	r := reporter.NewMock(t)
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+	countEntries := func() int {
+		count := 0
+		if err := c.IterASNDatabases(func(netip.Prefix, ASNInfo) error {
+			count++
+			return nil
+		}); err != nil {
+			t.Fatalf("IterASNDatabases() error:\n%+v", err)
+		}
+		return count
+	}
+	entries := countEntries()
+
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_geoip_database_")
+	expectedMetrics := map[string]string{
+		fmt.Sprintf(`build_epoch_seconds{database="asn",path="%s"}`, asnFile): "1.63710205e+09",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Write a truncated database in place. It should not be used.
+	if err := os.WriteFile(asnFile, content[:len(content)/2], 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	gotMetrics = r.GetMetrics("akvorado_orchestrator_geoip_db_refresh_")
+	if gotMetrics["total{database=\"asn\"}"] != "1" {
+		t.Fatalf("Metrics: database was reloaded (%v)", gotMetrics)
+	}
+	if gotMetrics["errors_total{database=\"asn\"}"] == "" {
+		t.Fatalf("Metrics: no error on reload (%v)", gotMetrics)
+	}
+	if got := countEntries(); got != entries {
+		t.Fatalf("IterASNDatabases() returned %d entries instead of %d", got, entries)
+	}
+
+	// Complete the database in place
+	if err := os.WriteFile(asnFile, content, 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	c.pollDatabases()
+	gotMetrics = r.GetMetrics("akvorado_orchestrator_geoip_db_refresh_total")
+	if gotMetrics["{database=\"asn\"}"] == "1" {
+		t.Fatalf("Metrics: database was not reloaded (%v)", gotMetrics)
+	}
+	if got := countEntries(); got != entries {
+		t.Fatalf("IterASNDatabases() returned %d entries instead of %d", got, entries)
+	}
+}
+
+func TestDatabasePolling(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfiguration()
+	asnFile := filepath.Join(dir, "asn.mmdb")
+	config.ASNDatabase = []string{asnFile}
+	copyFile(t, filepath.Join("testdata", "GeoLite2-ASN-Test.mmdb"), asnFile)
+
+	r := reporter.NewMock(t)
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Nothing changed
+	c.pollDatabases()
+	gotMetrics := r.GetMetrics("akvorado_orchestrator_geoip_db_")
+	expectedMetrics := map[string]string{
+		`refresh_total{database="asn"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Modification time changed. The watcher ignores this event.
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(asnFile, future, future); err != nil {
+		t.Fatalf("Chtimes() error:\n%+v", err)
+	}
+	c.pollDatabases()
+	gotMetrics = r.GetMetrics("akvorado_orchestrator_geoip_db_")
+	expectedMetrics = map[string]string{
+		`refresh_total{database="asn"}`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"akvorado/common/helpers/yaml"
+)
+
+// ConfigurationVersion is a version of the configurations served to the other
+// services.
+type ConfigurationVersion struct {
+	Version        int                   `json:"version" yaml:"version"`
+	Date           time.Time             `json:"date" yaml:"date"`
+	Hash           string                `json:"hash" yaml:"hash"`
+	Origin         string                `json:"origin" yaml:"origin"`
+	Configurations map[ServiceType][]any `json:"-" yaml:"configurations"`
+}
+
+// hashConfigurations returns a hash for a set of normalized configurations.
+func hashConfigurations(configurations map[ServiceType][]any) (string, error) {
+	out, err := yaml.Marshal(configurations)
+	if err != nil {
+		return "", fmt.Errorf("cannot serialize configurations: %w", err)
+	}
+	sum := sha256.Sum256(out)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadHistory loads the history of configurations from the configured
+// directory.
+func (c *Component) loadHistory() error {
+	directory := c.config.ConfigurationHistory.Directory
+	if directory == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot read configuration history: %w", err)
+	}
+	history := []ConfigurationVersion{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(name, ".yaml")); err != nil {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			return fmt.Errorf("cannot read configuration version %s: %w", name, err)
+		}
+		var version ConfigurationVersion
+		if err := yaml.Unmarshal(content, &version); err != nil {
+			return fmt.Errorf("cannot parse configuration version %s: %w", name, err)
+		}
+		history = append(history, version)
+	}
+	slices.SortFunc(history, func(a, b ConfigurationVersion) int {
+		return a.Version - b.Version
+	})
+	c.history = history
+	return nil
+}
+
+// recordVersion records the current configurations as a new version, unless
+// they are identical to the last version. It should be called with the service
+// lock held.
+func (c *Component) recordVersion(origin string) error {
+	configurations := map[ServiceType][]any{}
+	for service, serviceConfigurations := range c.serviceConfigurations {
+		for _, configuration := range serviceConfigurations {
+			normalized, err := normalizeConfiguration(configuration)
+			if err != nil {
+				return err
+			}
+			configurations[service] = append(configurations[service], normalized)
+		}
+	}
+	hash, err := hashConfigurations(configurations)
+	if err != nil {
+		return err
+	}
+	version := ConfigurationVersion{
+		Version:        1,
+		Date:           time.Now().UTC().Truncate(time.Second),
+		Hash:           hash,
+		Origin:         origin,
+		Configurations: configurations,
+	}
+	if len(c.history) > 0 {
+		last := c.history[len(c.history)-1]
+		if last.Hash == hash {
+			c.currentVersion = last.Version
+			return nil
+		}
+		version.Version = last.Version + 1
+	}
+	c.history = append(c.history, version)
+	c.currentVersion = version.Version
+	c.r.Info().Int("version", version.Version).Str("origin", origin).Msg("new configuration version")
+
+	var removed []ConfigurationVersion
+	if len(c.history) > c.config.ConfigurationHistory.Size {
+		removed = c.history[:len(c.history)-c.config.ConfigurationHistory.Size]
+		c.history = slices.Clone(c.history[len(removed):])
+	}
+
+	directory := c.config.ConfigurationHistory.Directory
+	if directory == "" {
+		return nil
+	}
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return fmt.Errorf("cannot create configuration history directory: %w", err)
+	}
+	out, err := yaml.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("cannot serialize configuration version: %w", err)
+	}
+	if err := os.WriteFile(c.historyPath(version.Version), out, 0o600); err != nil {
+		return fmt.Errorf("cannot write configuration version: %w", err)
+	}
+	for _, version := range removed {
+		if err := os.Remove(c.historyPath(version.Version)); err != nil && !os.IsNotExist(err) {
+			c.r.Err(err).Int("version", version.Version).Msg("cannot remove old configuration version")
+		}
+	}
+	return nil
+}
+
+// historyPath returns the path to the file storing the provided version.
+func (c *Component) historyPath(version int) string {
+	return filepath.Join(c.config.ConfigurationHistory.Directory, fmt.Sprintf("%06d.yaml", version))
+}
+
+// Rollback makes the provided version the served one. This is recorded as a
+// new version. The rollback is effective until the orchestrator is restarted.
+func (c *Component) Rollback(version int) (int, error) {
+	c.serviceLock.Lock()
+	defer c.serviceLock.Unlock()
+	idx := slices.IndexFunc(c.history, func(v ConfigurationVersion) bool {
+		return v.Version == version
+	})
+	if idx == -1 {
+		return 0, ErrVersionNotFound
+	}
+	c.serviceConfigurations = map[ServiceType][]any{}
+	for service, configurations := range c.history[idx].Configurations {
+		c.serviceConfigurations[service] = slices.Clone(configurations)
+	}
+	if err := c.recordVersion(fmt.Sprintf("rollback to version %d", version)); err != nil {
+		return 0, err
+	}
+	return c.currentVersion, nil
+}
+
+// ReplaceConfigurations replaces the configurations served to each service.
+// This is recorded as a new version if they changed. It returns the current
+// version.
+func (c *Component) ReplaceConfigurations(configurations map[ServiceType][]any, origin string) (int, error) {
+	c.serviceLock.Lock()
+	defer c.serviceLock.Unlock()
+	c.serviceConfigurations = map[ServiceType][]any{}
+	for service, serviceConfigurations := range configurations {
+		c.serviceConfigurations[service] = slices.Clone(serviceConfigurations)
+	}
+	if err := c.recordVersion(origin); err != nil {
+		return 0, err
+	}
+	return c.currentVersion, nil
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package orchestrator
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+)
+
+func TestConfigurationHistory(t *testing.T) {
+	directory := t.TempDir()
+	config := DefaultConfiguration()
+	config.ConfigurationHistory.Directory = directory
+	config.ConfigurationHistory.Size = 3
+
+	start := func(hello string) (*Component, *httpserver.Component) {
+		t.Helper()
+		r := reporter.NewMock(t)
+		h := httpserver.NewMock(t, r)
+		c, err := New(r, config, Dependencies{HTTP: h})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		c.RegisterConfiguration(InletService, map[string]string{"hello": hello})
+		if err := c.Start(); err != nil {
+			t.Fatalf("Start() error:\n%+v", err)
+		}
+		return c, h
+	}
+	versions := func(c *Component) []int {
+		t.Helper()
+		result := []int{}
+		for _, version := range c.history {
+			result = append(result, version.Version)
+		}
+		return result
+	}
+
+	// Successive starts with changes
+	c, _ := start("Hello world!")
+	if diff := helpers.Diff(versions(c), []int{1}); diff != "" {
+		t.Fatalf("Start() (-got, +want):\n%s", diff)
+	}
+	c, _ = start("Hello world!")
+	if diff := helpers.Diff(versions(c), []int{1}); diff != "" {
+		t.Fatalf("Start() without change (-got, +want):\n%s", diff)
+	}
+	c, _ = start("Hello pal!")
+	if diff := helpers.Diff(versions(c), []int{1, 2}); diff != "" {
+		t.Fatalf("Start() with change (-got, +want):\n%s", diff)
+	}
+	c, h := start("Hello friend!")
+	if diff := helpers.Diff(versions(c), []int{1, 2, 3}); diff != "" {
+		t.Fatalf("Start() with change (-got, +want):\n%s", diff)
+	}
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL:         "/api/v0/orchestrator/configuration/inlet",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{"hello: Hello friend!"},
+		}, {
+			Description: "history without token",
+			URL:         "/api/v0/orchestrator/configuration/history/2",
+			StatusCode:  401,
+			JSONOutput:  map[string]any{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "rollback without token",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/configuration/history/1/rollback",
+			StatusCode:  401,
+			JSONOutput:  map[string]any{"message": "Invalid or missing admin token."},
+		}, {
+			URL:         "/api/v0/orchestrator/configuration/history/2",
+			Header:      httpserver.MockAdminHeader(),
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{"version: 2"},
+		}, {
+			URL:        "/api/v0/orchestrator/configuration/history/10",
+			Header:     httpserver.MockAdminHeader(),
+			StatusCode: 404,
+			JSONOutput: map[string]any{"message": "Configuration version not found."},
+		}, {
+			Description: "rollback to non-existent version",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/configuration/history/10/rollback",
+			Header:      httpserver.MockAdminHeader(),
+			StatusCode:  404,
+			JSONOutput:  map[string]any{"message": "Configuration version not found."},
+		}, {
+			Description: "rollback",
+			Method:      "POST",
+			URL:         "/api/v0/orchestrator/configuration/history/1/rollback",
+			Header:      httpserver.MockAdminHeader(),
+			JSONOutput:  map[string]any{"current": 4},
+		}, {
+			Description: "after rollback",
+			URL:         "/api/v0/orchestrator/configuration/inlet",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{"hello: Hello world!"},
+		},
+	})
+
+	// Check version header
+	resp, err := http.Get("http://" + h.LocalAddr().String() + "/api/v0/orchestrator/configuration/inlet")
+	if err != nil {
+		t.Fatalf("GET error:\n%+v", err)
+	}
+	resp.Body.Close()
+	if diff := helpers.Diff(resp.Header.Get("X-Akvorado-Configuration-Version"), "4"); diff != "" {
+		t.Fatalf("GET version header (-got, +want):\n%s", diff)
+	}
+
+	// Check the history
+	if diff := helpers.Diff(versions(c), []int{2, 3, 4}); diff != "" {
+		t.Fatalf("Rollback() (-got, +want):\n%s", diff)
+	}
+	if diff := helpers.Diff(c.history[2].Origin, "rollback to version 1"); diff != "" {
+		t.Fatalf("Rollback() origin (-got, +want):\n%s", diff)
+	}
+	if c.history[2].Hash == c.history[0].Hash {
+		t.Fatal("Rollback() got the same hash as version 2")
+	}
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("ReadDir() error:\n%+v", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatalf("Info() error:\n%+v", err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("%s has mode %s, expected 0600", entry.Name(), info.Mode().Perm())
+		}
+	}
+	if diff := helpers.Diff(files, []string{"000002.yaml", "000003.yaml", "000004.yaml"}); diff != "" {
+		t.Fatalf("ReadDir() (-got, +want):\n%s", diff)
+	}
+
+	// On restart, the configuration from the file is used again
+	c, _ = start("Hello friend!")
+	if diff := helpers.Diff(versions(c), []int{3, 4, 5}); diff != "" {
+		t.Fatalf("Start() after rollback (-got, +want):\n%s", diff)
+	}
+
+	// Replacing configurations
+	version, err := c.ReplaceConfigurations(map[ServiceType][]any{
+		InletService: {map[string]string{"hello": "Hello friend!"}},
+	}, "reload")
+	if err != nil {
+		t.Fatalf("ReplaceConfigurations() error:\n%+v", err)
+	}
+	if version != 5 {
+		t.Fatalf("ReplaceConfigurations() without change == %d, expected 5", version)
+	}
+	version, err = c.ReplaceConfigurations(map[ServiceType][]any{
+		InletService: {map[string]string{"hello": "Hello buddy!"}},
+	}, "reload")
+	if err != nil {
+		t.Fatalf("ReplaceConfigurations() error:\n%+v", err)
+	}
+	if version != 6 {
+		t.Fatalf("ReplaceConfigurations() with change == %d, expected 6", version)
+	}
+	if diff := helpers.Diff(c.history[2].Origin, "reload"); diff != "" {
+		t.Fatalf("ReplaceConfigurations() origin (-got, +want):\n%s", diff)
+	}
+}
@@ -4,8 +4,13 @@
 package orchestrator
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +25,8 @@ func (c *Component) configurationHandlerFunc(gc *gin.Context) {
 	}
 
 	c.serviceLock.Lock()
+	version := c.currentVersion
+	registered, hasFeatures := c.serviceFeatures[ServiceType(service)]
 	var configuration any
 	serviceConfigurations, ok := c.serviceConfigurations[ServiceType(service)]
 	if ok {
@@ -39,5 +46,137 @@ func (c *Component) configurationHandlerFunc(gc *gin.Context) {
 		gc.JSON(http.StatusNotFound, gin.H{"message": "Configuration not found."})
 		return
 	}
+
+	// Services advertising their features get a configuration without the
+	// keys they do not know about. They are refused if some are enabled.
+	if header, ok := gc.Request.Header[http.CanonicalHeaderKey("X-Akvorado-Features")]; ok && hasFeatures {
+		adapted, unsupported, err := negotiateConfiguration(registered, configuration,
+			parseFeatures(strings.Join(header, ",")))
+		if err != nil {
+			c.r.Err(err).Str("service", service).Msg("cannot negotiate configuration")
+			gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot negotiate configuration."})
+			return
+		}
+		if len(unsupported) > 0 {
+			c.r.Warn().
+				Str("service", service).
+				Str("version", gc.GetHeader("X-Akvorado-Version")).
+				Strs("features", unsupported).
+				Msg("configuration refused to service not supporting enabled features")
+			gc.JSON(http.StatusConflict, gin.H{"message": unsupportedFeaturesMessage(unsupported)})
+			return
+		}
+		configuration = adapted
+	}
+
+	if version > 0 {
+		gc.Header("X-Akvorado-Configuration-Version", strconv.Itoa(version))
+	}
 	gc.YAML(http.StatusOK, configuration)
 }
+
+func (c *Component) configurationHistoryHandlerFunc(gc *gin.Context) {
+	c.serviceLock.Lock()
+	history := slices.Clone(c.history)
+	version := c.currentVersion
+	c.serviceLock.Unlock()
+	slices.Reverse(history)
+	gc.JSON(http.StatusOK, gin.H{"current": version, "versions": history})
+}
+
+func (c *Component) configurationVersionHandlerFunc(gc *gin.Context) {
+	version, err := strconv.Atoi(gc.Param("version"))
+	if err != nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Invalid configuration version."})
+		return
+	}
+	c.serviceLock.Lock()
+	idx := slices.IndexFunc(c.history, func(v ConfigurationVersion) bool {
+		return v.Version == version
+	})
+	var result ConfigurationVersion
+	if idx >= 0 {
+		result = c.history[idx]
+	}
+	c.serviceLock.Unlock()
+	if idx == -1 {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Configuration version not found."})
+		return
+	}
+	gc.YAML(http.StatusOK, result)
+}
+
+func (c *Component) configurationRollbackHandlerFunc(gc *gin.Context) {
+	version, err := strconv.Atoi(gc.Param("version"))
+	if err != nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Invalid configuration version."})
+		return
+	}
+	newVersion, err := c.Rollback(version)
+	if errors.Is(err, ErrVersionNotFound) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Configuration version not found."})
+		return
+	} else if err != nil {
+		c.r.Err(err).Int("version", version).Msg("cannot rollback configuration")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot rollback configuration."})
+		return
+	}
+	c.r.Info().Int("version", version).Msg("configuration rolled back")
+	gc.JSON(http.StatusOK, gin.H{"current": newVersion})
+}
+
+func (c *Component) configurationValidateHandlerFunc(gc *gin.Context) {
+	c.serviceLock.Lock()
+	validator := c.validator
+	current := map[ServiceType][]any{}
+	for service, configurations := range c.serviceConfigurations {
+		current[service] = slices.Clone(configurations)
+	}
+	c.serviceLock.Unlock()
+	if validator == nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Validation not available."})
+		return
+	}
+
+	input, err := io.ReadAll(http.MaxBytesReader(gc.Writer, gc.Request.Body, 10<<20))
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Cannot read configuration."})
+		return
+	}
+	proposed, err := validator(input)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+		return
+	}
+
+	services := []ServiceType{}
+	for service := range current {
+		services = append(services, service)
+	}
+	for service := range proposed {
+		if _, ok := current[service]; !ok {
+			services = append(services, service)
+		}
+	}
+	slices.Sort(services)
+	changes := []ConfigurationChange{}
+	for _, service := range services {
+		for i := range max(len(current[service]), len(proposed[service])) {
+			var old, new any
+			if i < len(current[service]) {
+				old, err = normalizeConfiguration(current[service][i])
+			}
+			if err == nil && i < len(proposed[service]) {
+				new, err = normalizeConfiguration(proposed[service][i])
+			}
+			if err != nil {
+				c.r.Err(err).Msg("cannot compare configurations")
+				gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot compare configurations."})
+				return
+			}
+			changes = append(changes,
+				diffConfigurations(fmt.Sprintf("%s.%d", service, i), old, new)...)
+		}
+	}
+	gc.JSON(http.StatusOK, gin.H{"valid": true, "changes": changes})
+}
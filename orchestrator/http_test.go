@@ -4,9 +4,14 @@
 package orchestrator
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+
 	"akvorado/common/helpers"
+	"akvorado/common/helpers/yaml"
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
 )
@@ -65,3 +70,153 @@ func TestConfigurationEndpoint(t *testing.T) {
 		},
 	})
 }
+
+func TestConfigurationEndpointFeatures(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		HTTP: h,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	defaults := gin.H{
+		"hello": "Hello world!",
+		"fancy": gin.H{"enabled": false, "level": 1},
+		"other": "nothing",
+	}
+	c.RegisterFeatures(InletService, defaults, []Feature{
+		{Name: "fancy", Key: "fancy"},
+		{Name: "other", Key: "other"},
+		{Name: "missing", Key: "not.here"},
+	})
+	c.RegisterConfiguration(InletService, defaults)
+	c.RegisterConfiguration(InletService, gin.H{
+		"hello": "Hello pal!",
+		"fancy": gin.H{"enabled": true, "level": 1},
+		"other": "something",
+	})
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "without features",
+			URL:         "/api/v0/orchestrator/configuration/inlet/1",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines: []string{
+				`fancy:`,
+				`  enabled: true`,
+				`  level: 1`,
+				`hello: Hello pal!`,
+				`other: something`,
+			},
+		}, {
+			Description: "all features supported",
+			URL:         "/api/v0/orchestrator/configuration/inlet/1",
+			Header:      http.Header{"X-Akvorado-Features": []string{"fancy,other,missing"}},
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines: []string{
+				`fancy:`,
+				`  enabled: true`,
+				`  level: 1`,
+				`hello: Hello pal!`,
+				`other: something`,
+			},
+		}, {
+			Description: "unsupported features not enabled",
+			URL:         "/api/v0/orchestrator/configuration/inlet/0",
+			Header:      http.Header{"X-Akvorado-Features": []string{""}},
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines: []string{
+				`hello: Hello world!`,
+			},
+		}, {
+			Description: "unsupported features enabled",
+			URL:         "/api/v0/orchestrator/configuration/inlet/1",
+			Header:      http.Header{"X-Akvorado-Features": []string{"other"}},
+			StatusCode:  409,
+			JSONOutput: gin.H{
+				"message": "Configuration uses features not supported by this version: fancy.",
+			},
+		},
+	})
+}
+
+func TestConfigurationValidateEndpoint(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		HTTP: h,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	c.RegisterConfiguration(InletService, map[string]any{
+		"hello": "Hello world!",
+		"bye":   "Goodbye world!",
+		"list":  []int{1, 2, 3},
+	})
+	c.RegisterConfiguration(OutletService, map[string]string{
+		"hello": "Hello outlet!",
+	})
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "no validator",
+			URL:         "/api/v0/orchestrator/configuration/validate",
+			JSONInput:   gin.H{},
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Validation not available."},
+		},
+	})
+
+	c.RegisterValidator(func(input []byte) (map[ServiceType][]any, error) {
+		var config map[string]any
+		if err := yaml.Unmarshal(input, &config); err != nil {
+			return nil, err
+		}
+		if _, ok := config["error"]; ok {
+			return nil, errors.New("invalid key \"error\"")
+		}
+		result := map[ServiceType][]any{}
+		if inlet, ok := config["inlet"]; ok {
+			result[InletService] = []any{inlet}
+		}
+		if console, ok := config["console"]; ok {
+			result[ConsoleService] = []any{console}
+		}
+		return result, nil
+	})
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "invalid configuration",
+			URL:         "/api/v0/orchestrator/configuration/validate",
+			JSONInput:   gin.H{"error": true},
+			StatusCode:  400,
+			JSONOutput: gin.H{
+				"valid":   false,
+				"message": `invalid key "error"`,
+			},
+		}, {
+			Description: "valid configuration",
+			URL:         "/api/v0/orchestrator/configuration/validate",
+			JSONInput: gin.H{
+				"inlet": gin.H{
+					"hello": "Hello world!",
+					"bye":   "See you world!",
+					"list":  []int{1, 2},
+				},
+				"console": gin.H{"hello": "Hello console!"},
+			},
+			JSONOutput: gin.H{
+				"valid": true,
+				"changes": []gin.H{
+					{"path": "console.0", "new": gin.H{"hello": "Hello console!"}},
+					{"path": "inlet.0.bye", "old": "Goodbye world!", "new": "See you world!"},
+					{"path": "inlet.0.list.2", "old": 3},
+					{"path": "outlet.0", "old": gin.H{"hello": "Hello outlet!"}},
+				},
+			},
+		},
+	})
+}
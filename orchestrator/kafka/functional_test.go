@@ -29,7 +29,7 @@ func TestTopicCreation(t *testing.T) {
 	segmentBytes := "107374184"
 	segmentBytes2 := "10737184"
 	cleanupPolicy := "delete"
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cases := []struct {
 		Name          string
@@ -124,7 +124,7 @@ func TestTopicMorePartitions(t *testing.T) {
 	adminClient := kadm.NewClient(client)
 
 	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	configuration := DefaultConfiguration()
 	configuration.Topic = topicName
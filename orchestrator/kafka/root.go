@@ -14,7 +14,6 @@ import (
 	"github.com/twmb/franz-go/pkg/kmsg"
 
 	"akvorado/common/kafka"
-	"akvorado/common/pb"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 )
@@ -52,7 +51,7 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 		config: config,
 
 		kafkaOpts:  kafkaOpts,
-		kafkaTopic: fmt.Sprintf("%s-v%d", config.Topic, pb.Version),
+		kafkaTopic: config.FlowsTopic(),
 	}
 	return &c, nil
 }
@@ -5,6 +5,8 @@
 package orchestrator
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 
 	"akvorado/common/httpserver"
@@ -19,6 +21,10 @@ type Component struct {
 
 	serviceLock           sync.Mutex
 	serviceConfigurations map[ServiceType][]any
+	serviceFeatures       map[ServiceType]serviceFeatures
+	validator             ConfigurationValidator
+	history               []ConfigurationVersion
+	currentVersion        int
 }
 
 // Dependencies define the dependencies of the broker.
@@ -42,6 +48,13 @@ var (
 	DemoExporterService ServiceType = "demo-exporter"
 )
 
+// ErrVersionNotFound is returned when a configuration version does not exist.
+var ErrVersionNotFound = errors.New("configuration version not found")
+
+// ConfigurationValidator parses and validates a proposed configuration file.
+// It returns the configurations that would be served to each service.
+type ConfigurationValidator func(input []byte) (map[ServiceType][]any, error)
+
 // New creates a new broker component.
 func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
 	c := Component{
@@ -50,14 +63,32 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		config: configuration,
 
 		serviceConfigurations: map[ServiceType][]any{},
+		serviceFeatures:       map[ServiceType]serviceFeatures{},
 	}
 
 	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/:service", c.configurationHandlerFunc)
 	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/:service/:index", c.configurationHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/configuration/validate", c.configurationValidateHandlerFunc)
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/history", c.d.HTTP.AdminOnly, c.configurationHistoryHandlerFunc)
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/history/:version", c.d.HTTP.AdminOnly, c.configurationVersionHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/orchestrator/configuration/history/:version/rollback", c.d.HTTP.AdminOnly, c.configurationRollbackHandlerFunc)
 
 	return &c, nil
 }
 
+// Start records the registered configurations as a new version if they changed.
+func (c *Component) Start() error {
+	c.serviceLock.Lock()
+	defer c.serviceLock.Unlock()
+	if err := c.loadHistory(); err != nil {
+		return err
+	}
+	if err := c.recordVersion("startup"); err != nil {
+		return fmt.Errorf("cannot record configuration version: %w", err)
+	}
+	return nil
+}
+
 // RegisterConfiguration registers the configuration for a service.
 func (c *Component) RegisterConfiguration(service ServiceType, configuration any) {
 	c.serviceLock.Lock()
@@ -67,3 +98,11 @@ func (c *Component) RegisterConfiguration(service ServiceType, configuration any
 	c.serviceConfigurations[service] = append(c.serviceConfigurations[service], configuration)
 	c.serviceLock.Unlock()
 }
+
+// RegisterValidator registers the function to use to validate a proposed
+// configuration.
+func (c *Component) RegisterValidator(validator ConfigurationValidator) {
+	c.serviceLock.Lock()
+	c.validator = validator
+	c.serviceLock.Unlock()
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// ExporterAccounting is the ingest accounting of one exporter over an
+// interval.
+type ExporterAccounting struct {
+	ExporterAddress  netip.Addr
+	Flows            uint64
+	Bytes            uint64
+	DecodeErrors     uint64
+	EnrichmentMisses uint64
+}
+
+// SendAccounting inserts the provided ingest accounting records into the
+// exporters_accounting table. They are all timestamped with the provided time.
+func (c *realComponent) SendAccounting(ctx context.Context, t time.Time, records []ExporterAccounting) error {
+	if len(records) == 0 {
+		return nil
+	}
+	batch, err := c.d.ClickHouse.PrepareBatch(ctx, "INSERT INTO exporters_accounting")
+	if err != nil {
+		c.metrics.errors.WithLabelValues("accounting").Inc()
+		return fmt.Errorf("cannot prepare accounting batch: %w", err)
+	}
+	defer batch.Abort()
+	for _, record := range records {
+		if err := batch.Append(
+			t,
+			record.ExporterAddress,
+			record.Flows,
+			record.Bytes,
+			record.DecodeErrors,
+			record.EnrichmentMisses,
+		); err != nil {
+			c.metrics.errors.WithLabelValues("accounting").Inc()
+			return fmt.Errorf("cannot append accounting record: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		c.metrics.errors.WithLabelValues("accounting").Inc()
+		return fmt.Errorf("cannot send accounting batch: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ package clickhouse
 
 import (
 	"time"
+
+	"akvorado/common/schema"
 )
 
 // Configuration describes the configuration for the ClickHouse exporter.
@@ -13,13 +15,65 @@ type Configuration struct {
 	GracePeriod time.Duration `validate:"min=10s"`
 	// MaximumBatchSize is the maximum number of rows to send to ClickHouse in one batch.
 	MaximumBatchSize uint `validate:"min=1"`
+	// MaximumBlockSize is the maximum number of rows to encode in one block
+	// when sending a batch to ClickHouse. When 0, a batch is sent as one block.
+	MaximumBlockSize uint
 	// MaximumWaitTime is the maximum number of seconds to wait before sending the current batch.
 	MaximumWaitTime time.Duration `validate:"min=100ms"`
+	// ShardingKey tells how to select a shard to insert flows directly into
+	// its local tables. When "none", flows are inserted through the
+	// distributed table. It is only used when running on a cluster.
+	ShardingKey ShardingKey
+	// MissingTableCheckInterval is the interval between two checks for the
+	// existence of the raw table when it is missing, for example because
+	// the orchestrator did not migrate the database yet.
+	MissingTableCheckInterval time.Duration `validate:"min=1s"`
+	// FallbackToPreviousTable tells if flows should be inserted into the raw
+	// table of a previous schema while the one for the current schema is
+	// missing. Only the columns present in this table are inserted.
+	FallbackToPreviousTable bool
+	// Settings are additional ClickHouse settings for the queries inserting
+	// flows, like max_insert_threads or insert_quorum.
+	Settings map[string]string
+	// ShardSettings are additional ClickHouse settings for the queries
+	// inserting flows directly into shards. They override Settings.
+	ShardSettings map[string]string
+	// Aggregation enables the aggregation of flows before inserting them.
+	Aggregation AggregationConfiguration
 	// minimumBatchSize the mininum number of rows before declaring underloaded and using async insert
 	minimumBatchSize uint
 }
 
-const minimumBatchSizeDivider = 10
+// AggregationConfiguration describes how flows are aggregated before being
+// inserted. This reduces the number of rows when individual flows are not
+// needed.
+type AggregationConfiguration struct {
+	// Interval is the duration of an aggregation window. When 0, flows are
+	// not aggregated.
+	Interval time.Duration `validate:"omitempty,min=1s"`
+	// Columns are the columns kept in aggregated rows. Flows with the same
+	// values for these columns in a window are summed. Other columns are left
+	// empty.
+	Columns []schema.ColumnKey `validate:"required_with=Interval"`
+}
+
+const (
+	minimumBatchSizeDivider = 10
+	reducedBatchSizeDivider = 4
+)
+
+// ShardingKey selects the shard to insert flows into.
+type ShardingKey int
+
+const (
+	// ShardingKeyNone does not select a shard. Flows are inserted through the
+	// distributed table.
+	ShardingKeyNone ShardingKey = iota
+	// ShardingKeyRandom selects a random shard for each batch.
+	ShardingKeyRandom
+	// ShardingKeyExporter selects a shard from a hash of the exporter address.
+	ShardingKeyExporter
+)
 
 // DefaultConfiguration represents the default configuration for the ClickHouse exporter.
 func DefaultConfiguration() Configuration {
@@ -27,5 +81,7 @@ func DefaultConfiguration() Configuration {
 		GracePeriod:      time.Minute,
 		MaximumBatchSize: 50_000,
 		MaximumWaitTime:  5 * time.Second,
+
+		MissingTableCheckInterval: 10 * time.Second,
 	}
 }
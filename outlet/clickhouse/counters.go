@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// InterfaceCounters is a sample of the octet counters of an interface.
+type InterfaceCounters struct {
+	TimeReceived    time.Time
+	ExporterAddress netip.Addr
+	ExporterName    string
+	IfIndex         uint32
+	IfName          string
+	InOctets        uint64
+	OutOctets       uint64
+}
+
+// SendInterfaceCounters inserts the provided counter samples into the
+// interfaces_counters table.
+func (c *realComponent) SendInterfaceCounters(ctx context.Context, samples []InterfaceCounters) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	batch, err := c.d.ClickHouse.PrepareBatch(ctx, "INSERT INTO interfaces_counters")
+	if err != nil {
+		c.metrics.errors.WithLabelValues("counters").Inc()
+		return fmt.Errorf("cannot prepare counters batch: %w", err)
+	}
+	defer batch.Abort()
+	for _, sample := range samples {
+		if err := batch.Append(
+			sample.TimeReceived,
+			sample.ExporterAddress,
+			sample.ExporterName,
+			sample.IfIndex,
+			sample.IfName,
+			sample.InOctets,
+			sample.OutOctets,
+		); err != nil {
+			c.metrics.errors.WithLabelValues("counters").Inc()
+			return fmt.Errorf("cannot append counters sample: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		c.metrics.errors.WithLabelValues("counters").Inc()
+		return fmt.Errorf("cannot send counters batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// rawTableRefreshInterval tells how often the engine of the raw table is
+// checked.
+const rawTableRefreshInterval = time.Minute
+
+// rawTable describes the engine of the raw table flows are inserted into.
+type rawTable struct {
+	Engine       string   `ch:"engine"`
+	EngineFull   string   `ch:"engine_full"`
+	Dependencies []string `ch:"dependencies_table"`
+}
+
+// bufferWithoutDestinationRegex matches a Buffer engine without a destination
+// table.
+var bufferWithoutDestinationRegex = regexp.MustCompile(`^Buffer\([^,]*,\s*''\s*,`)
+
+// asyncInsert tells if async inserts can be used with this table. Buffer and
+// Kafka engines already batch rows on their own. With the Null engine, rows
+// are pushed to the consumer view and async inserts still help to avoid small
+// parts.
+func (t rawTable) asyncInsert() bool {
+	switch t.Engine {
+	case "Buffer", "Kafka":
+		return false
+	}
+	return true
+}
+
+// discards tells if the table silently discards inserted flows.
+func (t rawTable) discards() bool {
+	switch t.Engine {
+	case "Null":
+		return len(t.Dependencies) == 0
+	case "Buffer":
+		return bufferWithoutDestinationRegex.MatchString(t.EngineFull)
+	}
+	return false
+}
+
+// rawTableEngine returns the engine of the raw table. The result is cached for
+// rawTableRefreshInterval. It returns false if the engine is unknown, notably
+// when the table does not exist yet.
+func (c *realComponent) rawTableEngine(ctx context.Context) (rawTable, bool) {
+	c.rawTableLock.Lock()
+	defer c.rawTableLock.Unlock()
+	if time.Since(c.rawTableLastRefresh) < rawTableRefreshInterval {
+		return c.rawTable, c.rawTable.Engine != ""
+	}
+	c.rawTableLastRefresh = time.Now()
+
+	table := fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash())
+	var rows []rawTable
+	if err := c.d.ClickHouse.Select(ctx, &rows, `
+SELECT engine, engine_full, dependencies_table
+FROM system.tables
+WHERE database = currentDatabase() AND name = $1
+`, table); err != nil {
+		c.r.Err(err).Str("table", table).Msg("cannot detect engine of raw table")
+		c.metrics.errors.WithLabelValues("engine").Inc()
+		return c.rawTable, c.rawTable.Engine != ""
+	}
+	if len(rows) == 0 {
+		c.rawTable = rawTable{}
+		return c.rawTable, false
+	}
+	current := rows[0]
+	if current.Engine != c.rawTable.Engine {
+		c.r.Info().
+			Str("table", table).
+			Str("engine", current.Engine).
+			Bool("async", current.asyncInsert()).
+			Msg("raw table engine detected")
+		if current.Engine == "Kafka" {
+			c.r.Warn().Str("table", table).
+				Msg("raw table uses the Kafka engine, flows are sent to a Kafka topic instead of being stored")
+		}
+	}
+	if current.discards() {
+		c.r.Error().
+			Str("table", table).
+			Str("engine", current.EngineFull).
+			Msg("raw table discards inserted flows, check the database migration")
+		c.metrics.rawTableDiscards.Set(1)
+	} else {
+		c.metrics.rawTableDiscards.Set(0)
+	}
+	c.rawTable = current
+	return current, true
+}
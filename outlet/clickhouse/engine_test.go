@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func TestRawTable(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Table    rawTable
+		Async    bool
+		Discards bool
+	}{
+		{helpers.Mark(), rawTable{Engine: "Null", EngineFull: "Null", Dependencies: []string{"flows_raw_consumer"}}, true, false},
+		{helpers.Mark(), rawTable{Engine: "Null", EngineFull: "Null"}, true, true},
+		{helpers.Mark(), rawTable{Engine: "MergeTree", EngineFull: "MergeTree ORDER BY TimeReceived"}, true, false},
+		{helpers.Mark(), rawTable{Engine: "Buffer", EngineFull: "Buffer('default', 'flows_raw_dest', 1, 10, 100, 10000, 1000000, 10000000, 100000000)"}, false, false},
+		{helpers.Mark(), rawTable{Engine: "Buffer", EngineFull: "Buffer('default', '', 1, 10, 100, 10000, 1000000, 10000000, 100000000)"}, false, true},
+		{helpers.Mark(), rawTable{Engine: "Kafka", EngineFull: "Kafka('kafka:9092', 'flows', 'akvorado', 'Protobuf')"}, false, false},
+	}
+	for _, tc := range cases {
+		if got := tc.Table.asyncInsert(); got != tc.Async {
+			t.Errorf("%sasyncInsert() == %v, expected %v", tc.Pos, got, tc.Async)
+		}
+		if got := tc.Table.discards(); got != tc.Discards {
+			t.Errorf("%sdiscards() == %v, expected %v", tc.Pos, got, tc.Discards)
+		}
+	}
+}
+
+func TestRawTableEngine(t *testing.T) {
+	r := reporter.NewMock(t)
+	chdb, mockConn := clickhousedb.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		ClickHouse: chdb,
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	component := c.(*realComponent)
+
+	// The table does not exist yet
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, []rawTable{}).
+		Return(nil)
+	helpers.StartStop(t, c)
+	if _, ok := component.rawTableEngine(t.Context()); ok {
+		t.Fatal("rawTableEngine() detected an engine for a missing table")
+	}
+
+	// The table exists, without a consumer
+	expected := rawTable{Engine: "Null", EngineFull: "Null"}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, []rawTable{expected}).
+		Return(nil)
+	component.rawTableLastRefresh = component.rawTableLastRefresh.Add(-rawTableRefreshInterval)
+	got, ok := component.rawTableEngine(t.Context())
+	if !ok {
+		t.Fatal("rawTableEngine() did not detect an engine")
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("rawTableEngine() (-got, +want):\n%s", diff)
+	}
+	gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "raw_table_discards")
+	expectedMetrics := map[string]string{
+		`raw_table_discards`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// The result is cached
+	if got, _ := component.rawTableEngine(t.Context()); got.Engine != "Null" {
+		t.Fatalf("rawTableEngine() == %q, expected Null", got.Engine)
+	}
+}
@@ -6,6 +6,7 @@ package clickhouse_test
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -44,6 +45,7 @@ func TestInsert(t *testing.T) {
 	helpers.StartStop(t, chdb)
 	conf := clickhouse.DefaultConfiguration()
 	conf.MaximumBatchSize = 10
+	conf.MaximumBlockSize = 4
 	conf.MaximumWaitTime = time.Second
 	ch, err := clickhouse.New(r, conf, clickhouse.Dependencies{
 		ClickHouse: chdb,
@@ -104,13 +106,14 @@ func TestInsert(t *testing.T) {
 		if i == 15 {
 			time.Sleep(time.Second)
 		}
-		w.FinalizeAndSend(ctx)
+		ch.Finalize(bf)
+		w.Send(ctx)
 		if i == 23 {
 			w.Flush(ctx)
 		}
 
 		// Check metrics
-		gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "-insert_time", "-wait_time")
+		gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "flow_per_batch", "worker_")
 		var expectedMetrics map[string]string
 		if i < 11 {
 			expectedMetrics = map[string]string{
@@ -161,6 +164,14 @@ func TestInsert(t *testing.T) {
 			t.Errorf("Metrics, iteration %d, (-got, +want):\n%s", i, diff)
 		}
 
+		// Check the deadline to send the pending flows
+		deadline := w.Deadline()
+		if (i == 11 || i == 23) && !deadline.IsZero() {
+			t.Errorf("Deadline(), iteration %d, got %s, expected zero", i, deadline)
+		} else if i == 12 && (deadline.IsZero() || time.Until(deadline) > conf.MaximumWaitTime) {
+			t.Errorf("Deadline(), iteration %d, got %s, expected within %s", i, deadline, conf.MaximumWaitTime)
+		}
+
 		// Check if we have anything inserted in the table
 		var results []result
 		err := chdb.Select(ctx, &results,
@@ -182,6 +193,102 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestAggregation(t *testing.T) {
+	server := helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"})
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	bf := sch.NewFlowMessage()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ctx = clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+
+	// Create components
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = "test"
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	conf := clickhouse.DefaultConfiguration()
+	conf.MaximumBatchSize = 10
+	conf.Aggregation.Interval = time.Minute
+	conf.Aggregation.Columns = []schema.ColumnKey{schema.ColumnExporterName}
+	ch, err := clickhouse.New(r, conf, clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     sch,
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+
+	// Create table
+	tableName := fmt.Sprintf("flows_%s_raw", sch.ClickHouseHash())
+	err = chdb.Exec(ctx, fmt.Sprintf("CREATE OR REPLACE TABLE %s (%s) ENGINE = Memory", tableName,
+		sch.ClickHouseCreateTable(
+			schema.ClickHouseSkipGeneratedColumns,
+			schema.ClickHouseSkipAliasedColumns)))
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+	err = chdb.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s_consumer", tableName))
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	// Send 30 flows from two exporters in the same window
+	w := ch.NewWorker(1, bf)
+	for i := range 30 {
+		bf.TimeReceived = uint32(60 + 2*i)
+		bf.SamplingRate = 10
+		bf.SrcAS = uint32(65400 + i)
+		bf.AppendString(schema.ColumnExporterName, fmt.Sprintf("exporter-%d", i%2))
+		bf.AppendUint(schema.ColumnBytes, 100)
+		bf.AppendUint(schema.ColumnPackets, 1)
+		ch.Finalize(bf)
+		w.Send(ctx)
+	}
+	w.Flush(ctx)
+
+	type result struct {
+		TimeReceived time.Time
+		ExporterName string
+		SrcAS        uint32
+		Bytes        uint64
+		Packets      uint64
+		SamplingRate uint64
+	}
+	var results []result
+	if err := chdb.Select(ctx, &results, fmt.Sprintf(`
+SELECT TimeReceived, ExporterName, SrcAS, sum(Bytes) AS Bytes, sum(Packets) AS Packets, any(SamplingRate) AS SamplingRate
+FROM %s
+GROUP BY TimeReceived, ExporterName, SrcAS
+ORDER BY TimeReceived, ExporterName`, tableName)); err != nil {
+		t.Fatalf("chdb.Select() error:\n%+v", err)
+	}
+	expected := []result{
+		{time.Unix(60, 0).UTC(), "exporter-0", 0, 15000, 150, 1},
+		{time.Unix(60, 0).UTC(), "exporter-1", 0, 15000, 150, 1},
+	}
+	if diff := helpers.Diff(results, expected); diff != "" {
+		t.Fatalf("chdb.Select() (-got, +want):\n%s", diff)
+	}
+	gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "aggregated_flows_total")
+	expectedMetrics := map[string]string{
+		`aggregated_flows_total`: "30",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
 func TestMultipleServers(t *testing.T) {
 	servers := []string{
 		helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"}),
@@ -239,3 +346,345 @@ func TestMultipleServers(t *testing.T) {
 	}
 	t.Fatal("w.Flush(): cannot trigger connect error")
 }
+
+func TestSendAccounting(t *testing.T) {
+	server := helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"})
+	r := reporter.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ctx = clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = "test"
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	ch, err := clickhouse.New(r, clickhouse.DefaultConfiguration(), clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+
+	err = chdb.Exec(ctx, `CREATE OR REPLACE TABLE exporters_accounting (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ Flows UInt64, Bytes UInt64, DecodeErrors UInt64, EnrichmentMisses UInt64
+) ENGINE = Memory`)
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	expected := []clickhouse.ExporterAccounting{
+		{
+			ExporterAddress:  netip.MustParseAddr("::ffff:192.0.2.1"),
+			Flows:            100,
+			Bytes:            15000,
+			EnrichmentMisses: 3,
+		}, {
+			ExporterAddress: netip.MustParseAddr("2001:db8::1"),
+			Flows:           10,
+			Bytes:           1500,
+			DecodeErrors:    1,
+		},
+	}
+	if err := ch.SendAccounting(ctx, now, expected); err != nil {
+		t.Fatalf("SendAccounting() error:\n%+v", err)
+	}
+
+	var got []struct {
+		TimeReceived     time.Time
+		ExporterAddress  netip.Addr
+		Flows            uint64
+		Bytes            uint64
+		DecodeErrors     uint64
+		EnrichmentMisses uint64
+	}
+	if err := chdb.Select(ctx, &got, "SELECT * FROM exporters_accounting ORDER BY ExporterAddress"); err != nil {
+		t.Fatalf("chdb.Select() error:\n%+v", err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("chdb.Select() returned %d rows instead of %d", len(got), len(expected))
+	}
+	for idx := range got {
+		if !got[idx].TimeReceived.Equal(now) {
+			t.Errorf("chdb.Select() row %d: TimeReceived %s instead of %s", idx, got[idx].TimeReceived, now)
+		}
+		record := clickhouse.ExporterAccounting{
+			ExporterAddress:  got[idx].ExporterAddress,
+			Flows:            got[idx].Flows,
+			Bytes:            got[idx].Bytes,
+			DecodeErrors:     got[idx].DecodeErrors,
+			EnrichmentMisses: got[idx].EnrichmentMisses,
+		}
+		if diff := helpers.Diff(record, expected[idx]); diff != "" {
+			t.Errorf("chdb.Select() row %d (-got, +want):\n%s", idx, diff)
+		}
+	}
+}
+
+func TestSendQuarantine(t *testing.T) {
+	server := helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"})
+	r := reporter.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ctx = clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = "test"
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	ch, err := clickhouse.New(r, clickhouse.DefaultConfiguration(), clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+
+	err = chdb.Exec(ctx, `CREATE OR REPLACE TABLE flows_quarantine (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ Source LowCardinality(String),
+ InIf UInt32, OutIf UInt32, SamplingRate UInt64, Bytes UInt64, Packets UInt64
+) ENGINE = Memory`)
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	expected := []clickhouse.QuarantinedFlow{
+		{
+			TimeReceived:    now,
+			ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+			Source:          "metadata",
+			InIf:            10,
+			OutIf:           20,
+			SamplingRate:    1000,
+			Bytes:           1500,
+			Packets:         1,
+		}, {
+			TimeReceived:    now,
+			ExporterAddress: netip.MustParseAddr("2001:db8::1"),
+			Source:          "sampling-rate",
+			InIf:            11,
+			Bytes:           500,
+			Packets:         2,
+		},
+	}
+	if err := ch.SendQuarantine(ctx, expected); err != nil {
+		t.Fatalf("SendQuarantine() error:\n%+v", err)
+	}
+
+	var got []clickhouse.QuarantinedFlow
+	if err := chdb.Select(ctx, &got, "SELECT * FROM flows_quarantine ORDER BY ExporterAddress"); err != nil {
+		t.Fatalf("chdb.Select() error:\n%+v", err)
+	}
+	for idx := range got {
+		got[idx].TimeReceived = got[idx].TimeReceived.UTC()
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("chdb.Select() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestSendInterfaceCounters(t *testing.T) {
+	server := helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"})
+	r := reporter.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ctx = clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = "test"
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	ch, err := clickhouse.New(r, clickhouse.DefaultConfiguration(), clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+
+	err = chdb.Exec(ctx, `CREATE OR REPLACE TABLE interfaces_counters (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ ExporterName LowCardinality(String),
+ IfIndex UInt32,
+ IfName LowCardinality(String),
+ InOctets UInt64, OutOctets UInt64
+) ENGINE = Memory`)
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	expected := []clickhouse.InterfaceCounters{
+		{
+			TimeReceived:    now,
+			ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+			ExporterName:    "router1",
+			IfIndex:         10,
+			IfName:          "Gi0/0/10",
+			InOctets:        123456789012,
+			OutOctets:       987654321,
+		}, {
+			TimeReceived:    now,
+			ExporterAddress: netip.MustParseAddr("2001:db8::1"),
+			ExporterName:    "router2",
+			IfIndex:         11,
+			IfName:          "Gi0/0/11",
+			InOctets:        1000,
+			OutOctets:       2000,
+		},
+	}
+	if err := ch.SendInterfaceCounters(ctx, expected); err != nil {
+		t.Fatalf("SendInterfaceCounters() error:\n%+v", err)
+	}
+
+	var got []clickhouse.InterfaceCounters
+	if err := chdb.Select(ctx, &got, "SELECT * FROM interfaces_counters ORDER BY ExporterAddress"); err != nil {
+		t.Fatalf("chdb.Select() error:\n%+v", err)
+	}
+	for idx := range got {
+		got[idx].TimeReceived = got[idx].TimeReceived.UTC()
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("chdb.Select() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestMissingTable(t *testing.T) {
+	server := helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"})
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = "test"
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+
+	tableName := fmt.Sprintf("flows_%s_raw", sch.ClickHouseHash())
+	previousTableName := "flows_previous_raw"
+	for _, table := range []string{tableName, previousTableName} {
+		if err := chdb.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s SYNC", table)); err != nil {
+			t.Fatalf("chdb.Exec() error:\n%+v", err)
+		}
+	}
+	// The previous table only has a subset of the columns.
+	if err := chdb.Exec(ctx, fmt.Sprintf(
+		"CREATE TABLE %s (TimeReceived DateTime, SrcAS UInt32, DstAS UInt32) ENGINE = Memory",
+		previousTableName)); err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	type result struct {
+		TimeReceived time.Time
+		SrcAS        uint32
+		DstAS        uint32
+	}
+	send := func(fallback bool, srcAS uint32) {
+		conf := clickhouse.DefaultConfiguration()
+		conf.MissingTableCheckInterval = time.Second
+		conf.FallbackToPreviousTable = fallback
+		ch, err := clickhouse.New(r, conf, clickhouse.Dependencies{
+			ClickHouse: chdb,
+			Schema:     sch,
+		})
+		if err != nil {
+			t.Fatalf("clickhouse.New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, ch)
+		bf := sch.NewFlowMessage()
+		w := ch.NewWorker(1, bf)
+		bf.TimeReceived = 100
+		bf.SrcAS = srcAS
+		bf.DstAS = 65500
+		ch.Finalize(bf)
+		w.Flush(ctx)
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		send(true, 65401)
+		var results []result
+		if err := chdb.Select(ctx, &results,
+			fmt.Sprintf("SELECT TimeReceived, SrcAS, DstAS FROM %s", previousTableName)); err != nil {
+			t.Fatalf("chdb.Select() error:\n%+v", err)
+		}
+		expected := []result{{time.Unix(100, 0).UTC(), 65401, 65500}}
+		if diff := helpers.Diff(results, expected); diff != "" {
+			t.Fatalf("chdb.Select() (-got, +want):\n%s", diff)
+		}
+		gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "fallback_flows_total")
+		expectedMetrics := map[string]string{
+			`fallback_flows_total`: "1",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		go func() {
+			time.Sleep(1500 * time.Millisecond)
+			if err := chdb.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (%s) ENGINE = Memory", tableName,
+				sch.ClickHouseCreateTable(
+					schema.ClickHouseSkipGeneratedColumns,
+					schema.ClickHouseSkipAliasedColumns))); err != nil {
+				t.Errorf("chdb.Exec() error:\n%+v", err)
+			}
+		}()
+		send(false, 65402)
+		var results []result
+		if err := chdb.Select(ctx, &results,
+			fmt.Sprintf("SELECT TimeReceived, SrcAS, DstAS FROM %s", tableName)); err != nil {
+			t.Fatalf("chdb.Select() error:\n%+v", err)
+		}
+		expected := []result{{time.Unix(100, 0).UTC(), 65402, 65500}}
+		if diff := helpers.Diff(results, expected); diff != "" {
+			t.Fatalf("chdb.Select() (-got, +want):\n%s", diff)
+		}
+	})
+
+	if err := chdb.Exec(ctx, fmt.Sprintf("DROP TABLE %s", previousTableName)); err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+}
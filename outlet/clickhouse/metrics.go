@@ -13,6 +13,13 @@ type metrics struct {
 	underloaded reporter.Counter
 	steady      reporter.Counter
 	errors      *reporter.CounterVec
+
+	missingTable     reporter.Gauge
+	fallbackFlows    reporter.Counter
+	rawTableDiscards reporter.Gauge
+	aggregatedFlows  reporter.Counter
+	droppedFlows     reporter.Counter
+	splitBatches     reporter.Counter
 }
 
 func (c *realComponent) initMetrics() {
@@ -71,4 +78,40 @@ func (c *realComponent) initMetrics() {
 		},
 		[]string{"error"},
 	)
+	c.metrics.missingTable = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "workers_waiting_for_table",
+			Help: "Number of workers waiting for the raw table to be created",
+		},
+	)
+	c.metrics.fallbackFlows = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "fallback_flows_total",
+			Help: "Number of flows inserted into the raw table of a previous schema",
+		},
+	)
+	c.metrics.rawTableDiscards = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "raw_table_discards",
+			Help: "Whether the raw table silently discards inserted flows",
+		},
+	)
+	c.metrics.aggregatedFlows = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "aggregated_flows_total",
+			Help: "Number of flows aggregated before being inserted",
+		},
+	)
+	c.metrics.droppedFlows = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "dropped_flows_total",
+			Help: "Number of flows dropped after a non-retryable error",
+		},
+	)
+	c.metrics.splitBatches = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "split_batches_total",
+			Help: "Number of batches split after being rejected for their size",
+		},
+	)
 }
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+
+	"akvorado/common/schema"
+)
+
+var errTableStillMissing = errors.New("table still missing")
+
+// waitForTable is called when the raw table to insert flows into does not
+// exist. This happens when the orchestrator has not migrated the database yet.
+// Instead of retrying to insert flows, it checks periodically if the table
+// exists. Meanwhile, flows may be inserted into the raw table of a previous
+// schema. It returns nil once the batch has been inserted.
+func (w *realWorker) waitForTable(ctx context.Context, c *connection, bf *schema.FlowMessage, table string, settings []ch.Setting) error {
+	w.logger.Warn().Str("table", table).Msg("raw table is missing, waiting for migration")
+	w.c.metrics.missingTable.Inc()
+	defer w.c.metrics.missingTable.Dec()
+	ticker := time.NewTicker(w.c.config.MissingTableCheckInterval)
+	defer ticker.Stop()
+	for {
+		if w.c.config.FallbackToPreviousTable {
+			if err := w.insertIntoPreviousTable(ctx, c, bf, table, settings); err == nil {
+				return nil
+			} else if err != errTableStillMissing {
+				w.logger.Err(err).Str("table", table).Msg("cannot insert into previous raw table")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		exists, err := w.tableExists(ctx, c, table)
+		if err != nil {
+			return err
+		}
+		if exists {
+			w.logger.Info().Str("table", table).Msg("raw table is now available")
+			return w.send(ctx, c, bf, table, settings, nil)
+		}
+	}
+}
+
+// tableExists tells if the provided table exists.
+func (w *realWorker) tableExists(ctx context.Context, c *connection, table string) (bool, error) {
+	var result proto.ColUInt8
+	if err := c.conn.Do(ctx, ch.Query{
+		Body:   fmt.Sprintf("EXISTS TABLE %s", table),
+		Result: proto.Results{{Name: "result", Data: &result}},
+	}); err != nil {
+		return false, fmt.Errorf("cannot check if %s exists: %w", table, err)
+	}
+	return result.Rows() > 0 && result.Row(0) == 1, nil
+}
+
+// insertIntoPreviousTable inserts the batch into the most recent raw table
+// for another schema, using only the columns present in this table. It
+// returns errTableStillMissing if there is no such table.
+func (w *realWorker) insertIntoPreviousTable(ctx context.Context, c *connection, bf *schema.FlowMessage, table string, settings []ch.Setting) error {
+	pattern := strings.Replace(table, w.c.d.Schema.ClickHouseHash(), "%", 1)
+	var name proto.ColStr
+	if err := c.conn.Do(ctx, ch.Query{
+		Body: fmt.Sprintf(`
+SELECT name
+FROM system.tables
+WHERE database = currentDatabase()
+AND name LIKE '%s' AND name != '%s'
+ORDER BY metadata_modification_time DESC
+LIMIT 1`, pattern, table),
+		Result: proto.Results{{Name: "name", Data: &name}},
+	}); err != nil {
+		return fmt.Errorf("cannot find previous raw table: %w", err)
+	}
+	if name.Rows() == 0 {
+		return errTableStillMissing
+	}
+	previous := name.Row(0)
+
+	var columnNames proto.ColStr
+	if err := c.conn.Do(ctx, ch.Query{
+		Body: fmt.Sprintf(`
+SELECT name
+FROM system.columns
+WHERE database = currentDatabase()
+AND table = '%s'`, previous),
+		Result: proto.Results{{Name: "name", Data: &columnNames}},
+	}); err != nil {
+		return fmt.Errorf("cannot get columns of %s: %w", previous, err)
+	}
+	columns := map[string]bool{}
+	for idx := range columnNames.Rows() {
+		columns[columnNames.Row(idx)] = true
+	}
+
+	flows := bf.FlowCount()
+	if err := w.send(ctx, c, bf, previous, settings, columns); err != nil {
+		w.c.metrics.errors.WithLabelValues("fallback").Inc()
+		return err
+	}
+	w.c.metrics.fallbackFlows.Add(float64(flows))
+	return nil
+}
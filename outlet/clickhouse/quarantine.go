@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// QuarantinedFlow is a flow which could not be enriched and was diverted to
+// quarantine.
+type QuarantinedFlow struct {
+	TimeReceived    time.Time
+	ExporterAddress netip.Addr
+	Source          string
+	InIf            uint32
+	OutIf           uint32
+	SamplingRate    uint64
+	Bytes           uint64
+	Packets         uint64
+}
+
+// SendQuarantine inserts the provided quarantined flows into the
+// flows_quarantine table.
+func (c *realComponent) SendQuarantine(ctx context.Context, flows []QuarantinedFlow) error {
+	if len(flows) == 0 {
+		return nil
+	}
+	batch, err := c.d.ClickHouse.PrepareBatch(ctx, "INSERT INTO flows_quarantine")
+	if err != nil {
+		c.metrics.errors.WithLabelValues("quarantine").Inc()
+		return fmt.Errorf("cannot prepare quarantine batch: %w", err)
+	}
+	defer batch.Abort()
+	for _, flow := range flows {
+		if err := batch.Append(
+			flow.TimeReceived,
+			flow.ExporterAddress,
+			flow.Source,
+			flow.InIf,
+			flow.OutIf,
+			flow.SamplingRate,
+			flow.Bytes,
+			flow.Packets,
+		); err != nil {
+			c.metrics.errors.WithLabelValues("quarantine").Inc()
+			return fmt.Errorf("cannot append quarantined flow: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		c.metrics.errors.WithLabelValues("quarantine").Inc()
+		return fmt.Errorf("cannot send quarantine batch: %w", err)
+	}
+	return nil
+}
@@ -7,6 +7,14 @@
 package clickhouse
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+
 	"akvorado/common/clickhousedb"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
@@ -15,6 +23,11 @@ import (
 // Component is the interface for the ClickHouse exporter component.
 type Component interface {
 	NewWorker(int, *schema.FlowMessage) Worker
+	Finalize(*schema.FlowMessage)
+	SendAccounting(context.Context, time.Time, []ExporterAccounting) error
+	SendQuarantine(context.Context, []QuarantinedFlow) error
+	SendInterfaceCounters(context.Context, []InterfaceCounters) error
+	ReduceBatchSize(bool)
 }
 
 // realComponent implements the ClickHouse exporter
@@ -24,6 +37,19 @@ type realComponent struct {
 	config Configuration
 
 	metrics metrics
+
+	reducedBatchSize atomic.Bool
+
+	settings      []ch.Setting // settings for inserts through the distributed table
+	shardSettings []ch.Setting // settings for inserts into shards
+
+	shardsLock        sync.Mutex
+	shards            [][]string
+	shardsLastRefresh time.Time
+
+	rawTableLock        sync.Mutex
+	rawTable            rawTable
+	rawTableLastRefresh time.Time
 }
 
 // Dependencies defines the dependencies of the ClickHouse exporter
@@ -35,11 +61,60 @@ type Dependencies struct {
 // New creates a new clickhouse component.
 func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (Component, error) {
 	configuration.minimumBatchSize = configuration.MaximumBatchSize / minimumBatchSizeDivider
-	c := realComponent{
+	c := &realComponent{
 		r:      r,
 		d:      &dependencies,
 		config: configuration,
 	}
+	var err error
+	if c.settings, err = insertSettings(configuration.Settings); err != nil {
+		return nil, err
+	}
+	if c.shardSettings, err = insertSettings(configuration.Settings, configuration.ShardSettings); err != nil {
+		return nil, err
+	}
+	for _, key := range configuration.Aggregation.Columns {
+		if column, ok := dependencies.Schema.LookupColumnByKey(key); !ok || column.Disabled {
+			return nil, fmt.Errorf("cannot aggregate on disabled column %s", key)
+		}
+	}
 	c.initMetrics()
-	return &c, nil
+	return c, nil
+}
+
+// Start detects the engine of the raw table. Failing to do so is not fatal as
+// the table may not exist yet.
+func (c *realComponent) Start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, ok := c.rawTableEngine(ctx); !ok {
+		c.r.Info().Msg("raw table engine is unknown, it will be checked later")
+	}
+	return nil
+}
+
+// Finalize adds the current flow of the provided flow message to its batch.
+func (c *realComponent) Finalize(bf *schema.FlowMessage) {
+	bf.Finalize()
+}
+
+// ReduceBatchSize enables or disables the use of smaller batches. This is used
+// to lower memory usage.
+func (c *realComponent) ReduceBatchSize(reduce bool) {
+	if c.reducedBatchSize.Swap(reduce) == reduce {
+		return
+	}
+	if reduce {
+		c.r.Warn().Msg("reduce batch size")
+	} else {
+		c.r.Info().Msg("restore batch size")
+	}
+}
+
+// maximumBatchSize returns the current maximum batch size.
+func (c *realComponent) maximumBatchSize() uint {
+	if c.reducedBatchSize.Load() {
+		return max(1, c.config.MaximumBatchSize/reducedBatchSizeDivider)
+	}
+	return c.config.MaximumBatchSize
 }
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/clickhouse"
 )
@@ -42,7 +44,8 @@ func TestMock(t *testing.T) {
 				schema.ColumnExporterName: fmt.Sprintf("exporter-%d", i),
 			},
 		})
-		w.FinalizeAndSend(t.Context())
+		ch.Finalize(bf)
+		w.Send(t.Context())
 
 		// Check if we have anything inserted in the table
 		messagesMutex.Lock()
@@ -52,3 +55,18 @@ func TestMock(t *testing.T) {
 		messagesMutex.Unlock()
 	}
 }
+
+func TestAggregationColumns(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	config := clickhouse.DefaultConfiguration()
+	config.Aggregation.Interval = time.Minute
+	config.Aggregation.Columns = []schema.ColumnKey{schema.ColumnExporterAddress, schema.ColumnSrcAS}
+	if _, err := clickhouse.New(r, config, clickhouse.Dependencies{Schema: sch}); err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	config.Aggregation.Columns = append(config.Aggregation.Columns, schema.ColumnSrcVlan)
+	if _, err := clickhouse.New(r, config, clickhouse.Dependencies{Schema: sch}); err == nil {
+		t.Fatal("New() did not error with a disabled column")
+	}
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ClickHouse/ch-go"
+)
+
+// managedSettings are the settings set by the outlet itself.
+var managedSettings = []string{
+	"async_insert",
+	"wait_for_async_insert",
+	"async_insert_busy_timeout_max_ms",
+}
+
+// insertSettings merges the provided settings, the last ones taking
+// precedence, and converts them to settings for an insert query, sorted by
+// name.
+func insertSettings(settings ...map[string]string) ([]ch.Setting, error) {
+	merged := map[string]string{}
+	for _, s := range settings {
+		maps.Copy(merged, s)
+	}
+	result := make([]ch.Setting, 0, len(merged))
+	for _, key := range slices.Sorted(maps.Keys(merged)) {
+		if slices.Contains(managedSettings, key) {
+			return nil, fmt.Errorf("setting %q is managed by the outlet", key)
+		}
+		result = append(result, ch.Setting{Key: key, Value: merged[key]})
+	}
+	return result, nil
+}
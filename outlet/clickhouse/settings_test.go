@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/ch-go"
+
+	"akvorado/common/helpers"
+)
+
+func TestInsertSettings(t *testing.T) {
+	cases := []struct {
+		Pos      helpers.Pos
+		Settings []map[string]string
+		Expected []ch.Setting
+		Error    bool
+	}{
+		{
+			Pos:      helpers.Mark(),
+			Settings: []map[string]string{nil, nil},
+			Expected: []ch.Setting{},
+		}, {
+			Pos: helpers.Mark(),
+			Settings: []map[string]string{{
+				"max_insert_threads": "4",
+				"insert_quorum":      "2",
+			}},
+			Expected: []ch.Setting{
+				{Key: "insert_quorum", Value: "2"},
+				{Key: "max_insert_threads", Value: "4"},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Settings: []map[string]string{{
+				"max_insert_threads": "4",
+				"insert_quorum":      "2",
+			}, {
+				"insert_quorum":      "1",
+				"insert_deduplicate": "0",
+			}},
+			Expected: []ch.Setting{
+				{Key: "insert_deduplicate", Value: "0"},
+				{Key: "insert_quorum", Value: "1"},
+				{Key: "max_insert_threads", Value: "4"},
+			},
+		}, {
+			Pos:      helpers.Mark(),
+			Settings: []map[string]string{{"async_insert": "0"}},
+			Error:    true,
+		},
+	}
+	for _, tc := range cases {
+		got, err := insertSettings(tc.Settings...)
+		if err != nil && !tc.Error {
+			t.Errorf("%sinsertSettings() error:\n%+v", tc.Pos, err)
+			continue
+		} else if err == nil && tc.Error {
+			t.Errorf("%sinsertSettings() did not error", tc.Pos)
+			continue
+		}
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%sinsertSettings() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// shardsRefreshInterval tells how often the shards of the cluster are
+// refreshed.
+const shardsRefreshInterval = time.Minute
+
+// clusterShards returns the servers of each shard of the cluster. The result
+// is cached for shardsRefreshInterval. On error, the previous result is
+// returned along the error.
+func (c *realComponent) clusterShards(ctx context.Context) ([][]string, error) {
+	c.shardsLock.Lock()
+	defer c.shardsLock.Unlock()
+	if time.Since(c.shardsLastRefresh) < shardsRefreshInterval {
+		return c.shards, nil
+	}
+	c.shardsLastRefresh = time.Now()
+
+	var rows []struct {
+		ShardNum uint32 `ch:"shard_num"`
+		HostName string `ch:"host_name"`
+		Port     uint16 `ch:"port"`
+	}
+	if err := c.d.ClickHouse.Select(ctx, &rows, `
+SELECT shard_num, host_name, port
+FROM system.clusters
+WHERE cluster = $1
+ORDER BY shard_num, replica_num
+`, c.d.ClickHouse.ClusterName()); err != nil {
+		return c.shards, fmt.Errorf("cannot query cluster shards: %w", err)
+	}
+	shards := [][]string{}
+	for idx, row := range rows {
+		if idx == 0 || row.ShardNum != rows[idx-1].ShardNum {
+			shards = append(shards, []string{})
+		}
+		shards[len(shards)-1] = append(shards[len(shards)-1],
+			net.JoinHostPort(row.HostName, strconv.Itoa(int(row.Port))))
+	}
+	c.shards = shards
+	return shards, nil
+}
+
+// exporterShard returns the shard to use for the provided exporter.
+func exporterShard(exporter netip.Addr, shards int) int {
+	h := fnv.New32a()
+	b := exporter.As16()
+	h.Write(b[:])
+	return int(h.Sum32() % uint32(shards))
+}
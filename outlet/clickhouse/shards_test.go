@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+func TestExporterShard(t *testing.T) {
+	counts := make([]int, 3)
+	for i := range 300 {
+		exporter := netip.MustParseAddr(fmt.Sprintf("::ffff:192.0.2.%d", i%256))
+		shard := exporterShard(exporter, len(counts))
+		if shard < 0 || shard >= len(counts) {
+			t.Fatalf("exporterShard(%s) == %d, out of range", exporter, shard)
+		}
+		if again := exporterShard(exporter, len(counts)); again != shard {
+			t.Fatalf("exporterShard(%s) == %d then %d", exporter, shard, again)
+		}
+		counts[shard]++
+	}
+	for shard, count := range counts {
+		if count < 50 {
+			t.Errorf("exporterShard(): shard %d got only %d exporters out of 300", shard, count)
+		}
+	}
+}
@@ -7,7 +7,9 @@ package clickhouse
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"akvorado/common/schema"
 )
@@ -15,9 +17,15 @@ import (
 // mockComponent is a mock version of the ClickHouse exporter.
 type mockComponent struct {
 	callback func(*schema.FlowMessage)
+
+	accountingLock sync.Mutex
+	accounting     []ExporterAccounting
+	quarantine     []QuarantinedFlow
+	counters       []InterfaceCounters
 }
 
-// NewMock creates a new mock exporter that calls the provided callback function with each received flow message.
+// NewMock creates a new mock exporter that calls the provided callback function
+// with each finalized flow message.
 func NewMock(_ *testing.T, callback func(*schema.FlowMessage)) Component {
 	return &mockComponent{
 		callback: callback,
@@ -32,21 +40,84 @@ func (c *mockComponent) NewWorker(_ int, bf *schema.FlowMessage) Worker {
 	}
 }
 
+// Finalize will record the current flow for testing purpose.
+func (c *mockComponent) Finalize(bf *schema.FlowMessage) {
+	clone := *bf
+	c.callback(&clone)
+	bf.Clear() // Clear instead of finalizing
+}
+
+// SendAccounting records the ingest accounting records for testing purpose.
+func (c *mockComponent) SendAccounting(_ context.Context, _ time.Time, records []ExporterAccounting) error {
+	c.accountingLock.Lock()
+	defer c.accountingLock.Unlock()
+	c.accounting = append(c.accounting, records...)
+	return nil
+}
+
+// SendQuarantine records the quarantined flows for testing purpose.
+func (c *mockComponent) SendQuarantine(_ context.Context, flows []QuarantinedFlow) error {
+	c.accountingLock.Lock()
+	defer c.accountingLock.Unlock()
+	c.quarantine = append(c.quarantine, flows...)
+	return nil
+}
+
+// SendInterfaceCounters records the counter samples for testing purpose.
+func (c *mockComponent) SendInterfaceCounters(_ context.Context, samples []InterfaceCounters) error {
+	c.accountingLock.Lock()
+	defer c.accountingLock.Unlock()
+	c.counters = append(c.counters, samples...)
+	return nil
+}
+
+// ReduceBatchSize does nothing.
+func (c *mockComponent) ReduceBatchSize(bool) {}
+
+// Accounting returns the ingest accounting records sent so far to a mock
+// component.
+func Accounting(c Component) []ExporterAccounting {
+	mc := c.(*mockComponent)
+	mc.accountingLock.Lock()
+	defer mc.accountingLock.Unlock()
+	return append([]ExporterAccounting{}, mc.accounting...)
+}
+
+// Quarantine returns the quarantined flows sent so far to a mock component.
+func Quarantine(c Component) []QuarantinedFlow {
+	mc := c.(*mockComponent)
+	mc.accountingLock.Lock()
+	defer mc.accountingLock.Unlock()
+	return append([]QuarantinedFlow{}, mc.quarantine...)
+}
+
+// Counters returns the counter samples sent so far to a mock component.
+func Counters(c Component) []InterfaceCounters {
+	mc := c.(*mockComponent)
+	mc.accountingLock.Lock()
+	defer mc.accountingLock.Unlock()
+	return append([]InterfaceCounters{}, mc.counters...)
+}
+
 // mockWorker is a mock version of the ClickHouse worker.
 type mockWorker struct {
 	c  *mockComponent
 	bf *schema.FlowMessage
 }
 
-// FinalizeAndSend always "send" the current flows.
-func (w *mockWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
+// Send discards the current batch. Flows were already recorded on
+// finalization.
+func (w *mockWorker) Send(ctx context.Context) WorkerStatus {
 	w.Flush(ctx)
 	return WorkerStatusIdle
 }
 
-// Send will record the sent flows for testing purpose.
+// Flush discards the current batch.
 func (w *mockWorker) Flush(_ context.Context) {
-	clone := *w.bf
-	w.c.callback(&clone)
-	w.bf.Clear() // Clear instead of finalizing
+	w.bf.Clear()
+}
+
+// Deadline returns the zero time as flows are discarded on each send.
+func (w *mockWorker) Deadline() time.Time {
+	return time.Time{}
 }
@@ -6,13 +6,18 @@ package clickhouse
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/rand/v2"
+	"net/netip"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
 	"github.com/cenkalti/backoff/v4"
 
+	"akvorado/common/clickhousedb"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 )
@@ -20,8 +25,9 @@ import (
 // Worker represents a worker sending to ClickHouse. It is synchronous (no
 // goroutines) and most functions are bound to a context.
 type Worker interface {
-	FinalizeAndSend(context.Context) WorkerStatus
+	Send(context.Context) WorkerStatus
 	Flush(context.Context)
+	Deadline() time.Time
 }
 
 // WorkerStatus tells if a worker is overloaded or not.
@@ -45,10 +51,21 @@ type realWorker struct {
 	last   time.Time
 	logger reporter.Logger
 
-	conn          *ch.Client
-	servers       []string
+	main          connection
 	options       ch.Options
 	asyncSettings []ch.Setting
+	aggregator    *schema.Aggregator // only when aggregating flows
+
+	// When inserting directly into shards
+	shards       []connection
+	shardBatches []*schema.FlowMessage
+}
+
+// connection is a connection to one of a set of equivalent ClickHouse
+// servers.
+type connection struct {
+	conn    *ch.Client
+	servers []string
 }
 
 // NewWorker creates a new worker to push data to ClickHouse.
@@ -59,7 +76,7 @@ func (c *realComponent) NewWorker(i int, bf *schema.FlowMessage) Worker {
 		bf:     bf,
 		logger: c.r.With().Int("worker", i).Logger(),
 
-		servers: servers,
+		main:    connection{servers: servers},
 		options: opts,
 		asyncSettings: []ch.Setting{
 			{
@@ -78,28 +95,37 @@ func (c *realComponent) NewWorker(i int, bf *schema.FlowMessage) Worker {
 			},
 		},
 	}
+	if c.config.Aggregation.Interval > 0 {
+		w.aggregator = c.d.Schema.NewAggregator(c.config.Aggregation.Interval, c.config.Aggregation.Columns)
+	}
 	return &w
 }
 
-// FinalizeAndSend sends data to ClickHouse after finalizing if we have a full
-// batch or exceeded the maximum wait time. See
+// Send sends data to ClickHouse if we have a full batch or exceeded the maximum
+// wait time. See
 // https://clickhouse.com/docs/best-practices/selecting-an-insert-strategy for
 // tips on the insert strategy. Notably, we switch to async insert when the
 // batch size is too small.
-func (w *realWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
-	w.bf.Finalize()
+func (w *realWorker) Send(ctx context.Context) WorkerStatus {
 	now := time.Now()
 	batchSize := w.bf.FlowCount()
+	maximumBatchSize := w.c.maximumBatchSize()
 	waitTime := now.Sub(w.last)
-	if batchSize >= int(w.c.config.MaximumBatchSize) || waitTime >= w.c.config.MaximumWaitTime {
+	if batchSize >= int(maximumBatchSize) || waitTime >= w.c.config.MaximumWaitTime {
 		// Record wait time since last send
 		if !w.last.IsZero() {
 			waitTime := now.Sub(w.last)
 			w.c.metrics.waitTime.Observe(waitTime.Seconds())
 		}
-		w.Flush(ctx)
+		w.flush(ctx, false)
 		w.last = time.Now()
-		if uint(batchSize) >= w.c.config.MaximumBatchSize {
+		if uint(batchSize) >= maximumBatchSize {
+			// With reduced batches, do not ask for more workers: they
+			// would use more memory.
+			if maximumBatchSize < w.c.config.MaximumBatchSize {
+				w.c.metrics.steady.Inc()
+				return WorkerStatusSteady
+			}
 			w.c.metrics.overloaded.Inc()
 			return WorkerStatusOverloaded
 		} else if uint(batchSize) <= w.c.config.minimumBatchSize {
@@ -112,98 +138,278 @@ func (w *realWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
 	return WorkerStatusIdle
 }
 
+// Deadline returns when Send() should be called at the latest to send the
+// pending flows, even if no new flow is added to the batch. It returns the zero
+// time when there is no pending flow.
+func (w *realWorker) Deadline() time.Time {
+	if w.bf.FlowCount() == 0 && (w.aggregator == nil || w.aggregator.RowCount() == 0) {
+		return time.Time{}
+	}
+	return w.last.Add(w.c.config.MaximumWaitTime)
+}
+
 // Flush sends remaining data to ClickHouse without an additional condition. It
 // should be called before shutting down to flush remaining data. Otherwise,
-// FinalizeAndSend() should be used instead.
+// Send() should be used instead.
 func (w *realWorker) Flush(ctx context.Context) {
+	w.flush(ctx, true)
+}
+
+// flush sends the current batch to ClickHouse. When aggregating flows, the
+// batch is first merged into the aggregation windows and only the closed
+// windows are sent, unless final is true.
+func (w *realWorker) flush(ctx context.Context, final bool) {
 	var useAsync bool
+	if w.aggregator != nil {
+		w.c.metrics.aggregatedFlows.Add(float64(w.bf.FlowCount()))
+		w.aggregator.Add(w.bf)
+		w.bf.Clear()
+		// Late flows are still accepted during MaximumWaitTime.
+		var before time.Time
+		if !final {
+			before = time.Now().Add(-w.c.config.MaximumWaitTime)
+		}
+		w.aggregator.Flush(w.bf, before)
+	}
 	if w.bf.FlowCount() == 0 {
 		return
 	}
-	// Async mode if have not a big batch size
-	var settings []ch.Setting
-	if uint(w.bf.FlowCount()) <= w.c.config.minimumBatchSize {
+	// Async mode if have not a big batch size, unless the engine of the raw
+	// table already batches rows
+	settings, shardSettings := w.c.settings, w.c.shardSettings
+	table, known := w.c.rawTableEngine(ctx)
+	if uint(w.bf.FlowCount()) <= w.c.config.minimumBatchSize && (!known || table.asyncInsert()) {
 		useAsync = true
-		settings = w.asyncSettings
+		settings = append(slices.Clone(settings), w.asyncSettings...)
+		shardSettings = append(slices.Clone(shardSettings), w.asyncSettings...)
 	}
+	ctx, span := w.c.r.StartSpan(ctx, "flush batch", reporter.SpanAttributes(
+		reporter.IntAttribute("flows", w.bf.FlowCount()),
+		reporter.BoolAttribute("async", useAsync)))
+	defer span.End()
 
-	// We try to send as long as possible. The only exit condition is an
-	// expiration of the context.
+	if !w.updateShards(ctx) {
+		// Send to ClickHouse in flows_XXXXX_raw.
+		w.insert(ctx, &w.main, w.bf, fmt.Sprintf("flows_%s_raw", w.c.d.Schema.ClickHouseHash()), settings)
+		return
+	}
+
+	// Send directly to each shard in flows_XXXXX_raw_local. Flows which
+	// cannot be sent are kept in the shard batch for the next flush.
+	switch w.c.config.ShardingKey {
+	case ShardingKeyRandom:
+		w.shardBatches[rand.IntN(len(w.shardBatches))].AppendBatch(w.bf)
+	case ShardingKeyExporter:
+		w.bf.SplitBatch(w.shardBatches, func(exporter netip.Addr) int {
+			return exporterShard(exporter, len(w.shardBatches))
+		})
+	}
+	w.bf.Clear()
+	for idx := range w.shards {
+		if w.shardBatches[idx].FlowCount() > 0 {
+			w.insert(ctx, &w.shards[idx], w.shardBatches[idx],
+				fmt.Sprintf("flows_%s_raw_local", w.c.d.Schema.ClickHouseHash()), shardSettings)
+		}
+	}
+}
+
+// updateShards updates the shards to insert flows into from the current
+// cluster topology. It returns false when flows should be inserted through the
+// distributed table instead.
+func (w *realWorker) updateShards(ctx context.Context) bool {
+	if w.c.config.ShardingKey == ShardingKeyNone || w.c.d.ClickHouse.ClusterName() == "" {
+		return false
+	}
+	servers, err := w.c.clusterShards(ctx)
+	if err != nil {
+		w.logger.Err(err).Msg("cannot refresh cluster shards")
+		w.c.metrics.errors.WithLabelValues("shards").Inc()
+	}
+	if len(servers) < 2 {
+		// With only one shard, there is no local table.
+		servers = nil
+	}
+	if slices.EqualFunc(servers, w.shards, func(s []string, c connection) bool {
+		return slices.Equal(s, c.servers)
+	}) {
+		return len(w.shards) > 0
+	}
+
+	// The topology has changed. Pending flows are moved back to the main batch.
+	w.logger.Info().Int("shards", len(servers)).Msg("cluster shards changed")
+	for idx := range w.shards {
+		w.bf.AppendBatch(w.shardBatches[idx])
+		if w.shards[idx].conn != nil {
+			w.shards[idx].conn.Close()
+		}
+	}
+	w.shards = nil
+	w.shardBatches = nil
+	for _, s := range servers {
+		w.shards = append(w.shards, connection{servers: s})
+		w.shardBatches = append(w.shardBatches, w.c.d.Schema.NewFlowMessage())
+	}
+	return len(w.shards) > 0
+}
+
+// insert sends the flows batched in the provided flow message to the provided
+// table, using the provided connection. The batch is cleared on success.
+func (w *realWorker) insert(ctx context.Context, c *connection, bf *schema.FlowMessage, table string, settings []ch.Setting) {
+	// We try to send as long as possible. The exit conditions are an
+	// expiration of the context or an error which is not worth retrying, like
+	// a schema mismatch or an authentication failure. In the latter case, the
+	// batch is dropped.
 	b := backoff.NewExponentialBackOff()
 	b.MaxElapsedTime = 0
 	b.MaxInterval = 30 * time.Second
 	b.InitialInterval = 20 * time.Millisecond
 	backoff.Retry(func() error {
 		// Connect or reconnect if connection is broken.
-		if err := w.connect(ctx); err != nil {
+		err := w.connect(ctx, c)
+		if err != nil {
 			w.logger.Err(err).Msg("cannot connect to ClickHouse")
-			return err
+		} else {
+			err = w.send(ctx, c, bf, table, settings, nil)
+			if ch.IsErr(err, proto.ErrUnknownTable) {
+				// The database may not be migrated yet.
+				err = w.waitForTable(ctx, c, bf, table, settings)
+			}
+		}
+		if err != nil && clickhousedb.IsOversized(err) && bf.FlowCount() > 1 {
+			// Retrying the same batch is unlikely to succeed.
+			w.insertHalves(ctx, c, bf, table, settings)
+			if bf.FlowCount() > 0 {
+				return backoff.Permanent(ctx.Err())
+			}
+			return nil
+		}
+		if err != nil && !clickhousedb.IsRetryable(err) {
+			w.logger.Err(err).
+				Str("table", table).
+				Int("flows", bf.FlowCount()).
+				Msg("non-retryable error, dropping batch")
+			w.c.metrics.droppedFlows.Add(float64(bf.FlowCount()))
+			bf.Clear()
+			return backoff.Permanent(err)
 		}
+		return err
+	}, backoff.WithContext(b, ctx))
+}
 
-		// Ensure the context lives for at least GracePeriod.
-		chCtx, cancel := context.WithCancel(context.Background())
-		defer cancel() // needed in case the operation completes before grace period and parent context
-		go func() {
-			gracePeriodTimer := time.NewTimer(w.c.config.GracePeriod)
-			defer gracePeriodTimer.Stop()
+// insertHalves splits the flows batched in the provided flow message in two
+// halves and inserts each of them, possibly splitting them further. This is
+// used when ClickHouse rejects a batch because of its size. Flows which cannot
+// be inserted are moved back to the provided flow message.
+func (w *realWorker) insertHalves(ctx context.Context, c *connection, bf *schema.FlowMessage, table string, settings []ch.Setting) {
+	w.logger.Warn().
+		Str("table", table).
+		Int("flows", bf.FlowCount()).
+		Msg("batch too large, splitting it")
+	w.c.metrics.splitBatches.Inc()
+	first, second := w.c.d.Schema.NewFlowMessage(), w.c.d.Schema.NewFlowMessage()
+	bf.HalveBatch(first, second)
+	bf.Clear()
+	w.insert(ctx, c, first, table, settings)
+	w.insert(ctx, c, second, table, settings)
+	bf.AppendBatch(first)
+	bf.AppendBatch(second)
+}
+
+// send makes one attempt to send the flows batched in the provided flow message
+// to the provided table. When columns is not nil, only the listed columns are
+// sent. The batch is cleared on success.
+func (w *realWorker) send(ctx context.Context, c *connection, bf *schema.FlowMessage, table string, settings []ch.Setting, columns map[string]bool) error {
+	// Ensure the context lives for at least GracePeriod.
+	chCtx, cancel := context.WithCancel(context.Background())
+	defer cancel() // needed in case the operation completes before grace period and parent context
+	go func() {
+		gracePeriodTimer := time.NewTimer(w.c.config.GracePeriod)
+		defer gracePeriodTimer.Stop()
 
+		select {
+		case <-gracePeriodTimer.C:
+			// Grace period elapsed, now wait for parent or end of operation.
 			select {
-			case <-gracePeriodTimer.C:
-				// Grace period elapsed, now wait for parent or end of operation.
-				select {
-				case <-ctx.Done():
-				case <-chCtx.Done():
-				}
 			case <-ctx.Done():
-				// Parent done before grace period, wait for grace period or end of operation.
-				select {
-				case <-gracePeriodTimer.C:
-					w.logger.Info().Msg("grace period to flush batch expired")
-				case <-chCtx.Done():
-				}
 			case <-chCtx.Done():
-				// Operation done!
 			}
-			cancel()
-		}()
+		case <-ctx.Done():
+			// Parent done before grace period, wait for grace period or end of operation.
+			select {
+			case <-gracePeriodTimer.C:
+				w.logger.Info().Msg("grace period to flush batch expired")
+			case <-chCtx.Done():
+			}
+		case <-chCtx.Done():
+			// Operation done!
+		}
+		cancel()
+	}()
 
-		// Send to ClickHouse in flows_XXXXX_raw.
-		start := time.Now()
-		if err := w.conn.Do(chCtx, ch.Query{
-			Body:     w.bf.ClickHouseProtoInput().Into(fmt.Sprintf("flows_%s_raw", w.c.d.Schema.ClickHouseHash())),
-			Input:    w.bf.ClickHouseProtoInput(),
-			Settings: settings,
-		}); err != nil {
-			w.logger.Err(err).Int("flows", w.bf.FlowCount()).Bool("async", useAsync).Msg("cannot send batch to ClickHouse")
-			w.c.metrics.errors.WithLabelValues("send").Inc()
-			return err
+	// With a large batch, stream it in several blocks to avoid encoding
+	// it at once.
+	input := bf.ClickHouseProtoInput()
+	var onInput func(context.Context) error
+	if blockSize := int(w.c.config.MaximumBlockSize); blockSize > 0 && bf.FlowCount() > blockSize {
+		offset := 0
+		input = bf.ClickHouseProtoBlock(offset, blockSize)
+		onInput = func(context.Context) error {
+			offset += blockSize
+			if bf.ClickHouseProtoBlock(offset, blockSize)[0].Data.Rows() == 0 {
+				return io.EOF
+			}
+			return nil
 		}
-		pushDuration := time.Since(start)
-		w.c.metrics.insertTime.Observe(pushDuration.Seconds())
-		w.c.metrics.flows.Observe(float64(w.bf.FlowCount()))
+	}
+	if columns != nil {
+		// Blocks reuse the same columns, so filtering once is enough.
+		input = slices.DeleteFunc(slices.Clone(input), func(column proto.InputColumn) bool {
+			return !columns[column.Name]
+		})
+	}
+	start := time.Now()
+	if err := c.conn.Do(chCtx, ch.Query{
+		Body:     input.Into(table),
+		Input:    input,
+		OnInput:  onInput,
+		Settings: settings,
+	}); err != nil {
+		w.logger.Err(err).
+			Str("table", table).
+			Int("flows", bf.FlowCount()).
+			Bool("async", slices.ContainsFunc(settings, func(s ch.Setting) bool {
+				return s.Key == "async_insert"
+			})).
+			Msg("cannot send batch to ClickHouse")
+		w.c.metrics.errors.WithLabelValues("send").Inc()
+		reporter.SpanFromContext(ctx).RecordError(err)
+		return err
+	}
+	pushDuration := time.Since(start)
+	w.c.metrics.insertTime.Observe(pushDuration.Seconds())
+	w.c.metrics.flows.Observe(float64(bf.FlowCount()))
 
-		// Clear batch
-		w.bf.Clear()
-		return nil
-	}, backoff.WithContext(b, ctx))
+	// Clear batch
+	bf.Clear()
+	return nil
 }
 
-// connect establishes or reestablish the connection to ClickHouse.
-func (w *realWorker) connect(ctx context.Context) error {
+// connect establishes or reestablish the provided connection to ClickHouse.
+func (w *realWorker) connect(ctx context.Context, c *connection) error {
 	// If connection exists and is healthy, reuse it
-	if w.conn != nil {
-		if err := w.conn.Ping(ctx); err == nil {
+	if c.conn != nil {
+		if err := c.conn.Ping(ctx); err == nil {
 			return nil
 		}
 		// Connection is unhealthy, close it
-		w.conn.Close()
-		w.conn = nil
+		c.conn.Close()
+		c.conn = nil
 	}
 
 	// Try each server until one connects successfully
 	var lastErr error
-	for _, idx := range rand.Perm(len(w.servers)) {
-		w.options.Address = w.servers[idx]
+	for _, idx := range rand.Perm(len(c.servers)) {
+		w.options.Address = c.servers[idx]
 		conn, err := ch.Dial(ctx, w.options)
 		if err != nil {
 			w.logger.Err(err).Str("server", w.options.Address).Msg("failed to connect to ClickHouse server")
@@ -223,7 +429,7 @@ func (w *realWorker) connect(ctx context.Context) error {
 		}
 
 		// Success
-		w.conn = conn
+		c.conn = conn
 		w.logger.Info().Str("server", w.options.Address).Msg("connected to ClickHouse server")
 		return nil
 	}
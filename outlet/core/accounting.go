@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"akvorado/outlet/clickhouse"
+)
+
+// exporterAccounting holds the ingest counters of one exporter since the last
+// flush.
+type exporterAccounting struct {
+	flows            atomic.Uint64
+	bytes            atomic.Uint64
+	decodeErrors     atomic.Uint64
+	enrichmentMisses atomic.Uint64
+}
+
+// accounting tracks the ingest counters of each exporter. Counters are reset
+// on each flush. Exporters are never removed as they are few.
+type accounting struct {
+	lock      sync.RWMutex
+	exporters map[netip.Addr]*exporterAccounting
+}
+
+// newAccounting creates a new accounting tracker.
+func newAccounting() *accounting {
+	return &accounting{
+		exporters: map[netip.Addr]*exporterAccounting{},
+	}
+}
+
+// get returns the counters for the provided exporter, creating them if needed.
+func (a *accounting) get(exporter netip.Addr) *exporterAccounting {
+	a.lock.RLock()
+	ea, ok := a.exporters[exporter]
+	a.lock.RUnlock()
+	if ok {
+		return ea
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if ea, ok := a.exporters[exporter]; ok {
+		return ea
+	}
+	ea = &exporterAccounting{}
+	a.exporters[exporter] = ea
+	return ea
+}
+
+// reset returns the accounting records since the last call and resets the
+// counters. Exporters without any activity are omitted.
+func (a *accounting) reset() []clickhouse.ExporterAccounting {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	records := make([]clickhouse.ExporterAccounting, 0, len(a.exporters))
+	for exporter, ea := range a.exporters {
+		record := clickhouse.ExporterAccounting{
+			ExporterAddress:  exporter,
+			Flows:            ea.flows.Swap(0),
+			Bytes:            ea.bytes.Swap(0),
+			DecodeErrors:     ea.decodeErrors.Swap(0),
+			EnrichmentMisses: ea.enrichmentMisses.Swap(0),
+		}
+		if record != (clickhouse.ExporterAccounting{ExporterAddress: exporter}) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// flushAccounting sends the accounting records to ClickHouse.
+func (c *Component) flushAccounting(ctx context.Context) {
+	records := c.accounting.reset()
+	if err := c.d.ClickHouse.SendAccounting(ctx, time.Now(), records); err != nil {
+		c.r.Err(err).Int("exporters", len(records)).Msg("cannot send ingest accounting")
+	}
+}
+
+// runAccounting periodically flushes the accounting records. Records are also
+// flushed on shutdown.
+func (c *Component) runAccounting() error {
+	ticker := time.NewTicker(c.config.AccountingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c.flushAccounting(ctx)
+			return nil
+		case <-ticker.C:
+			c.flushAccounting(c.t.Context(context.Background()))
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"akvorado/common/schema"
+	"akvorado/outlet/routing/provider/bmp"
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/ast"
@@ -145,6 +146,8 @@ type interfaceClassification struct {
 	Reject       bool
 	Name         string
 	Description  string
+	VRF          bmp.RD
+	Group        string
 }
 
 // interfaceClassifierEnvironment defines the environment used by the interface classifier
@@ -237,6 +240,21 @@ func (scr *InterfaceClassifierRule) UnmarshalText(text []byte) error {
 			},
 			new(func(*interfaceClassification, string) bool),
 		),
+		expr.Function(
+			"ClassifyVRF",
+			func(params ...any) (any, error) {
+				ic := params[0].(*interfaceClassification)
+				if ic.VRF == 0 {
+					var rd bmp.RD
+					if err := rd.UnmarshalText([]byte(params[1].(string))); err != nil {
+						return false, fmt.Errorf("cannot parse VRF %q: %w", params[1].(string), err)
+					}
+					ic.VRF = rd
+				}
+				return true, nil
+			},
+			new(func(*interfaceClassification, string) (bool, error)),
+		),
 	}
 	options = addInterfaceClassifyStringFunction(options,
 		"ClassifyProvider", func(ic *interfaceClassification) *string { return &ic.Provider })
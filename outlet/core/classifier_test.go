@@ -8,6 +8,7 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/common/schema"
+	"akvorado/outlet/routing/provider/bmp"
 )
 
 func TestExporterClassifier(t *testing.T) {
@@ -181,6 +182,17 @@ func TestInterfaceClassifier(t *testing.T) {
 			ExpectedClassification: interfaceClassification{
 				Provider: "ii-gi000",
 			},
+		}, {
+			Description:   "classify VRF",
+			Program:       `Interface.Description startsWith "VPN:" && ClassifyVRF("65000:100")`,
+			InterfaceInfo: interfaceInfo{Name: "Gi0/0/0", Description: "VPN: customer"},
+			ExpectedClassification: interfaceClassification{
+				VRF: bmp.MustParseRD("65000:100"),
+			},
+		}, {
+			Description: "classify invalid VRF",
+			Program:     `ClassifyVRF("65000:customer")`,
+			ExpectedErr: true,
 		}, {
 			Description:            "reject",
 			Program:                `Reject()`,
@@ -20,6 +20,8 @@ type Configuration struct {
 	ExporterClassifiers []ExporterClassifierRule
 	// InterfaceClassifiers defines rules for interface classification
 	InterfaceClassifiers []InterfaceClassifierRule
+	// InterfaceGroups defines named groups of interfaces, like LAG members
+	InterfaceGroups []InterfaceGroup `validate:"dive"`
 	// ClassifierCacheDuration defines the default TTL for classifier cache
 	ClassifierCacheDuration time.Duration `validate:"min=1s"`
 	// DefaultSamplingRate defines the default sampling rate to use when the information is missing
@@ -30,6 +32,22 @@ type Configuration struct {
 	ASNProviders []ASNProvider `validate:"dive"`
 	// NetProviders defines the source used to get Prefix/Network Information
 	NetProviders []NetProvider `validate:"dive"`
+	// AccountingInterval defines how often the per-exporter ingest accounting
+	// is written to ClickHouse. 0 disables ingest accounting.
+	AccountingInterval time.Duration `validate:"min=0"`
+	// EnrichmentFailures defines what to do with a flow when an enrichment
+	// source fails.
+	EnrichmentFailures EnrichmentFailuresConfiguration
+}
+
+// EnrichmentFailuresConfiguration defines the policy to apply for each
+// enrichment source when it fails to enrich a flow.
+type EnrichmentFailuresConfiguration struct {
+	// Metadata is the policy when the exporter or interface metadata is
+	// missing.
+	Metadata EnrichmentFailurePolicy
+	// SamplingRate is the policy when the sampling rate is missing.
+	SamplingRate EnrichmentFailurePolicy
 }
 
 // DefaultConfiguration represents the default configuration for the core component.
@@ -37,9 +55,11 @@ func DefaultConfiguration() Configuration {
 	return Configuration{
 		ExporterClassifiers:     []ExporterClassifierRule{},
 		InterfaceClassifiers:    []InterfaceClassifierRule{},
+		InterfaceGroups:         []InterfaceGroup{},
 		ClassifierCacheDuration: 5 * time.Minute,
 		ASNProviders:            []ASNProvider{ASNProviderFlow, ASNProviderRouting, ASNProviderGeoIP},
 		NetProviders:            []NetProvider{NetProviderFlow, NetProviderRouting},
+		AccountingInterval:      time.Minute,
 	}
 }
 
@@ -48,6 +68,9 @@ type (
 	ASNProvider int
 	// NetProvider describes one network mask provider.
 	NetProvider int
+	// EnrichmentFailurePolicy describes what to do with a flow which cannot be
+	// enriched.
+	EnrichmentFailurePolicy int
 )
 
 const (
@@ -70,6 +93,17 @@ const (
 	NetProviderRouting
 )
 
+const (
+	// EnrichmentFailurePolicyDrop drops the flow.
+	EnrichmentFailurePolicyDrop EnrichmentFailurePolicy = iota
+	// EnrichmentFailurePolicyKeep keeps the flow, using default values for
+	// the missing information.
+	EnrichmentFailurePolicyKeep
+	// EnrichmentFailurePolicyQuarantine diverts the flow to the quarantine
+	// table.
+	EnrichmentFailurePolicyQuarantine
+)
+
 // ASNProviderUnmarshallerHook normalize a net provider configuration:
 //   - map bmp to routing
 func ASNProviderUnmarshallerHook() mapstructure.DecodeHookFunc {
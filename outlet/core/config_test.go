@@ -112,6 +112,36 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 				NetProviders: []NetProvider{NetProviderFlow, NetProviderRouting},
 			},
 			SkipValidation: true,
+		}, {
+			Description: "enrichment-failures",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"enrichment-failures": gin.H{
+						"metadata":      "quarantine",
+						"sampling-rate": "keep",
+					},
+				}
+			},
+			Expected: Configuration{
+				EnrichmentFailures: EnrichmentFailuresConfiguration{
+					Metadata:     EnrichmentFailurePolicyQuarantine,
+					SamplingRate: EnrichmentFailurePolicyKeep,
+				},
+			},
+			SkipValidation: true,
+		}, {
+			Description: "enrichment-failures with unknown policy",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return gin.H{
+					"enrichment-failures": gin.H{
+						"metadata": "ignore",
+					},
+				}
+			},
+			Error:          true,
+			SkipValidation: true,
 		},
 	})
 }
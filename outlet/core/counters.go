@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"akvorado/outlet/clickhouse"
+)
+
+// countersFlushInterval defines how often the interface counters polled by
+// the metadata providers are sent to ClickHouse.
+const countersFlushInterval = time.Minute
+
+// flushCounters sends the interface counters polled since the last flush to
+// ClickHouse.
+func (c *Component) flushCounters(ctx context.Context) {
+	polled := c.d.Metadata.FlushCounters()
+	samples := make([]clickhouse.InterfaceCounters, 0, len(polled))
+	for _, sample := range polled {
+		samples = append(samples, clickhouse.InterfaceCounters{
+			TimeReceived:    sample.Time,
+			ExporterAddress: sample.ExporterIP,
+			ExporterName:    sample.ExporterName,
+			IfIndex:         uint32(sample.IfIndex),
+			IfName:          sample.IfName,
+			InOctets:        sample.InOctets,
+			OutOctets:       sample.OutOctets,
+		})
+	}
+	if err := c.d.ClickHouse.SendInterfaceCounters(ctx, samples); err != nil {
+		c.r.Err(err).Int("samples", len(samples)).Msg("cannot send interface counters")
+	}
+}
+
+// runCounters periodically flushes the interface counters. They are also
+// flushed on shutdown.
+func (c *Component) runCounters() error {
+	ticker := time.NewTicker(countersFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c.flushCounters(ctx)
+			return nil
+		case <-ticker.C:
+			c.flushCounters(c.t.Context(context.Background()))
+		}
+	}
+}
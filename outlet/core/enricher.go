@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"akvorado/common/schema"
+	"akvorado/outlet/clickhouse"
 )
 
 // exporterAndInterfaceInfo aggregates both exporter info and interface info
@@ -19,22 +20,20 @@ type exporterAndInterfaceInfo struct {
 }
 
 // enrichFlow adds more data to a flow.
-func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
+func (d *decoder) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	var (
 		flowExporterName                                                       string
 		flowInIfName, flowInIfDescription, flowOutIfName, flowOutIfDescription string
 		flowInIfSpeed, flowOutIfSpeed, flowInIfIndex, flowOutIfIndex           uint32
 		flowInIfVlan, flowOutIfVlan                                            uint16
 	)
-	var skip bool
-
 	t := time.Now() // only call it once
 	expClassification := exporterClassification{}
 	inIfClassification := interfaceClassification{}
 	outIfClassification := interfaceClassification{}
 
-	flow := w.bf
-	c := w.c
+	flow := d.bf
+	c := d.c
 
 	if flow.InIf != 0 {
 		answer := c.d.Metadata.Lookup(t, exporterIP, uint(flow.InIf))
@@ -77,14 +76,25 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	}
 
 	// We need at least one of them.
+	var metadataFailed, samplingRateFailed bool
 	if flow.OutIf == 0 && flow.InIf == 0 {
 		c.metrics.flowsErrors.WithLabelValues(exporterStr, "input and output interfaces missing").Inc()
-		skip = true
+		c.noInterfaceErrLogger.Warn().
+			Str("exporter", exporterStr).
+			Msg("cannot enrich flow: input and output interfaces missing")
+		metadataFailed = true
 	} else if flowExporterName == "" {
 		c.metrics.flowsErrors.WithLabelValues(exporterStr, "metadata cache miss").Inc()
-		skip = true
+		c.metadataMissErrLogger.Info().
+			Str("exporter", exporterStr).
+			Uint32("in-if", flow.InIf).
+			Uint32("out-if", flow.OutIf).
+			Msg("cannot enrich flow: metadata cache miss")
+		metadataFailed = true
 	}
 
+	receivedSamplingRate := flow.SamplingRate
+	flow.AppendUint(schema.ColumnReceivedSamplingRate, flow.SamplingRate)
 	if samplingRate, ok := c.config.OverrideSamplingRate.Lookup(exporterIP); ok && samplingRate > 0 {
 		flow.SamplingRate = uint64(samplingRate)
 	}
@@ -93,29 +103,73 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 			flow.SamplingRate = uint64(samplingRate)
 		} else {
 			c.metrics.flowsErrors.WithLabelValues(exporterStr, "sampling rate missing").Inc()
-			skip = true
+			c.noSamplingRateErrLogger.Warn().
+				Str("exporter", exporterStr).
+				Msg("cannot enrich flow: sampling rate missing")
+			samplingRateFailed = true
 		}
 	}
 
-	if skip {
-		return true
+	if metadataFailed || samplingRateFailed {
+		c.accounting.get(exporterIP).enrichmentMisses.Add(1)
+		policy, source := c.enrichmentFailurePolicy(metadataFailed, samplingRateFailed)
+		if policy == EnrichmentFailurePolicyQuarantine && !c.quarantine.add(clickhouse.QuarantinedFlow{
+			TimeReceived:    time.Unix(int64(flow.TimeReceived), 0),
+			ExporterAddress: exporterIP,
+			Source:          source,
+			InIf:            flow.InIf,
+			OutIf:           flow.OutIf,
+			SamplingRate:    receivedSamplingRate,
+			Bytes:           flow.CurrentUint(schema.ColumnBytes),
+			Packets:         flow.CurrentUint(schema.ColumnPackets),
+		}) {
+			// Quarantine is full
+			policy = EnrichmentFailurePolicyDrop
+		}
+		if metadataFailed {
+			c.metrics.flowsEnrichment.WithLabelValues(enrichmentSourceMetadata, policy.String()).Inc()
+		}
+		if samplingRateFailed {
+			c.metrics.flowsEnrichment.WithLabelValues(enrichmentSourceSamplingRate, policy.String()).Inc()
+		}
+		if policy != EnrichmentFailurePolicyKeep {
+			return true
+		}
+		// Keep the flow with default values
+		if metadataFailed {
+			flowExporterName = exporterStr
+		}
+		if samplingRateFailed {
+			flow.SamplingRate = 1
+		}
 	}
 
 	// Classification
 	if !c.classifyExporter(t, exporterStr, flowExporterName, flow, expClassification) ||
 		!c.classifyInterface(t, exporterStr, flowExporterName, flow,
-			flowOutIfIndex, flowOutIfName, flowOutIfDescription, flowOutIfSpeed, flowOutIfVlan, outIfClassification,
+			flowOutIfIndex, flowOutIfName, flowOutIfDescription, flowOutIfSpeed, flowOutIfVlan, &outIfClassification,
 			false) ||
 		!c.classifyInterface(t, exporterStr, flowExporterName, flow,
-			flowInIfIndex, flowInIfName, flowInIfDescription, flowInIfSpeed, flowInIfVlan, inIfClassification,
+			flowInIfIndex, flowInIfName, flowInIfDescription, flowInIfSpeed, flowInIfVlan, &inIfClassification,
 			true) {
 		// Flow is rejected
 		return true
 	}
 
+	// Select the VRF for routing lookups. The source is looked up in the VRF
+	// of the input interface and the destination in the VRF of the output
+	// interface. When only one of them is in a VRF (PE router), it is used
+	// for both lookups.
+	sourceVRF, destVRF := inIfClassification.VRF, outIfClassification.VRF
+	if sourceVRF == 0 {
+		sourceVRF = destVRF
+	} else if destVRF == 0 {
+		destVRF = sourceVRF
+	}
+
 	ctx := c.t.Context(context.Background())
-	sourceRouting := c.d.Routing.Lookup(ctx, flow.SrcAddr, netip.Addr{}, flow.ExporterAddress)
-	destRouting := c.d.Routing.Lookup(ctx, flow.DstAddr, flow.NextHop, flow.ExporterAddress)
+	sourceRouting := c.d.Routing.Lookup(ctx, flow.SrcAddr, netip.Addr{}, flow.ExporterAddress, uint64(sourceVRF))
+	destRouting := c.d.Routing.Lookup(ctx, flow.DstAddr, flow.NextHop, flow.ExporterAddress, uint64(destVRF))
 
 	// set prefix len according to user config
 	flow.SrcNetMask = c.getNetMask(flow.SrcNetMask, sourceRouting.NetMask)
@@ -144,7 +198,37 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	flow.AppendUint(schema.ColumnInIfSpeed, uint64(flowInIfSpeed))
 	flow.AppendUint(schema.ColumnOutIfSpeed, uint64(flowOutIfSpeed))
 
-	return skip
+	return false
+}
+
+// Enrichment sources which may fail.
+const (
+	enrichmentSourceMetadata     = "metadata"
+	enrichmentSourceSamplingRate = "sampling-rate"
+)
+
+// enrichmentFailurePolicy returns the policy to apply to a flow when some
+// enrichment sources failed, as well as the source this policy comes from. When
+// several sources failed, the most conservative policy wins: drop, then
+// quarantine, then keep.
+func (c *Component) enrichmentFailurePolicy(metadataFailed, samplingRateFailed bool) (EnrichmentFailurePolicy, string) {
+	severity := func(policy EnrichmentFailurePolicy) int {
+		switch policy {
+		case EnrichmentFailurePolicyDrop:
+			return 2
+		case EnrichmentFailurePolicyQuarantine:
+			return 1
+		}
+		return 0
+	}
+	policy, source := EnrichmentFailurePolicyKeep, ""
+	if metadataFailed {
+		policy, source = c.config.EnrichmentFailures.Metadata, enrichmentSourceMetadata
+	}
+	if samplingRateFailed && (source == "" || severity(c.config.EnrichmentFailures.SamplingRate) > severity(policy)) {
+		policy, source = c.config.EnrichmentFailures.SamplingRate, enrichmentSourceSamplingRate
+	}
+	return policy, source
 }
 
 // getASNumber retrieves the AS number for a flow, depending on user preferences.
@@ -230,8 +314,10 @@ func (c *Component) classifyExporter(t time.Time, ip, name string, flow *schema.
 	}
 	si := exporterInfo{IP: ip, Name: name}
 	if classification, ok := c.classifierExporterCache.Get(t, si); ok {
+		c.metrics.classifierCacheHits.WithLabelValues("exporter").Inc()
 		return c.writeExporter(flow, classification)
 	}
+	c.metrics.classifierCacheMisses.WithLabelValues("exporter").Inc()
 
 	for idx, rule := range c.config.ExporterClassifiers {
 		if err := rule.exec(si, &classification); err != nil {
@@ -262,12 +348,14 @@ func (c *Component) writeInterface(flow *schema.FlowMessage, classification inte
 		flow.AppendString(schema.ColumnInIfConnectivity, classification.Connectivity)
 		flow.AppendString(schema.ColumnInIfProvider, classification.Provider)
 		flow.AppendUint(schema.ColumnInIfBoundary, uint64(classification.Boundary))
+		flow.AppendString(schema.ColumnInIfGroup, classification.Group)
 	} else {
 		flow.AppendString(schema.ColumnOutIfName, classification.Name)
 		flow.AppendString(schema.ColumnOutIfDescription, classification.Description)
 		flow.AppendString(schema.ColumnOutIfConnectivity, classification.Connectivity)
 		flow.AppendString(schema.ColumnOutIfProvider, classification.Provider)
 		flow.AppendUint(schema.ColumnOutIfBoundary, uint64(classification.Boundary))
+		flow.AppendString(schema.ColumnOutIfGroup, classification.Group)
 	}
 	return true
 }
@@ -281,19 +369,21 @@ func (c *Component) classifyInterface(
 	ifDescription string,
 	ifSpeed uint32,
 	ifVlan uint16,
-	classification interfaceClassification,
+	classification *interfaceClassification,
 	directionIn bool,
 ) bool {
 	// we already have the info provided by the metadata component
-	if (classification != interfaceClassification{}) {
+	if (*classification != interfaceClassification{}) {
 		classification.Name = ifName
 		classification.Description = ifDescription
-		return c.writeInterface(fl, classification, directionIn)
+		classification.Group = c.interfaceGroup(t, exporterName, ifName)
+		return c.writeInterface(fl, *classification, directionIn)
 	}
 	if len(c.config.InterfaceClassifiers) == 0 {
 		classification.Name = ifName
 		classification.Description = ifDescription
-		c.writeInterface(fl, classification, directionIn)
+		classification.Group = c.interfaceGroup(t, exporterName, ifName)
+		c.writeInterface(fl, *classification, directionIn)
 		return true
 	}
 	si := exporterInfo{IP: ip, Name: exporterName}
@@ -308,12 +398,15 @@ func (c *Component) classifyInterface(
 		Exporter:  si,
 		Interface: ii,
 	}
-	if classification, ok := c.classifierInterfaceCache.Get(t, key); ok {
-		return c.writeInterface(fl, classification, directionIn)
+	if cached, ok := c.classifierInterfaceCache.Get(t, key); ok {
+		c.metrics.classifierCacheHits.WithLabelValues("interface").Inc()
+		*classification = cached
+		return c.writeInterface(fl, *classification, directionIn)
 	}
+	c.metrics.classifierCacheMisses.WithLabelValues("interface").Inc()
 
 	for idx, rule := range c.config.InterfaceClassifiers {
-		err := rule.exec(si, ii, &classification)
+		err := rule.exec(si, ii, classification)
 		if err != nil {
 			c.classifierErrLogger.Err(err).
 				Str("type", "interface").
@@ -338,8 +431,9 @@ func (c *Component) classifyInterface(
 	if classification.Description == "" {
 		classification.Description = ifDescription
 	}
-	c.classifierInterfaceCache.Put(t, key, classification)
-	return c.writeInterface(fl, classification, directionIn)
+	classification.Group = c.interfaceGroup(t, exporterName, ifName)
+	c.classifierInterfaceCache.Put(t, key, *classification)
+	return c.writeInterface(fl, *classification, directionIn)
 }
 
 func isPrivateAS(as uint32) bool {
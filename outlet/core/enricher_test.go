@@ -32,11 +32,13 @@ import (
 
 func TestEnrich(t *testing.T) {
 	cases := []struct {
-		Name            string
-		Configuration   gin.H
-		InputFlow       func() *schema.FlowMessage
-		OutputFlow      *schema.FlowMessage
-		ExpectedMetrics map[string]string
+		Name               string
+		Configuration      gin.H
+		AllColumns         bool
+		InputFlow          func() *schema.FlowMessage
+		OutputFlow         *schema.FlowMessage
+		ExpectedMetrics    map[string]string
+		ExpectedQuarantine []clickhouse.QuarantinedFlow
 	}{
 		{
 			Name:          "no rule",
@@ -96,6 +98,37 @@ func TestEnrich(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "no rule, override sampling rate, keep received one",
+			Configuration: gin.H{"overridesamplingrate": gin.H{
+				"192.0.2.0/24": 100,
+			}},
+			AllColumns: true,
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    100,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:         "192_0_2_142",
+					schema.ColumnInIfName:             "Gi0/0/100",
+					schema.ColumnOutIfName:            "Gi0/0/200",
+					schema.ColumnInIfDescription:      "Interface 100",
+					schema.ColumnOutIfDescription:     "Interface 200",
+					schema.ColumnInIfSpeed:            uint32(1000),
+					schema.ColumnOutIfSpeed:           uint32(1000),
+					schema.ColumnReceivedSamplingRate: uint64(1000),
+				},
+			},
+		},
 		{
 			Name:          "no rule, no sampling rate, default is one value",
 			Configuration: gin.H{"defaultsamplingrate": 500},
@@ -289,6 +322,52 @@ func TestEnrich(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "interface groups",
+			Configuration: gin.H{
+				"interfacegroups": []gin.H{
+					{
+						"name": "transit",
+						"members": []gin.H{
+							{"exporter": "192_0_2_1.*", "interface": "Gi0/0/10[0-9]"},
+						},
+					}, {
+						"name": "peering",
+						"members": []gin.H{
+							{"exporter": "192_0_2_1.*", "interface": "Gi0/0/100"},
+							{"interface": "Gi0/0/2.*"},
+						},
+					},
+				},
+			},
+			AllColumns: true,
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:         "192_0_2_142",
+					schema.ColumnInIfName:             "Gi0/0/100",
+					schema.ColumnOutIfName:            "Gi0/0/200",
+					schema.ColumnInIfDescription:      "Interface 100",
+					schema.ColumnOutIfDescription:     "Interface 200",
+					schema.ColumnInIfSpeed:            uint32(1000),
+					schema.ColumnOutIfSpeed:           uint32(1000),
+					schema.ColumnInIfGroup:            "transit",
+					schema.ColumnOutIfGroup:           "peering",
+					schema.ColumnReceivedSamplingRate: uint64(1000),
+				},
+			},
+		},
 		{
 			Name: "interface rule with rename",
 			Configuration: gin.H{
@@ -585,6 +664,43 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				},
 			},
 		},
+		{
+			Name: "use data from routing in a VRF",
+			Configuration: gin.H{
+				"interfaceclassifiers": []string{
+					`Interface.Index == 100 && ClassifyVRF("20")`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:192.168.146.10"),
+					DstAddr:         netip.MustParseAddr("::ffff:192.168.148.10"),
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:192.168.146.10"),
+				DstAddr:         netip.MustParseAddr("::ffff:192.168.148.10"),
+				SrcAS:           4321,
+				SrcNetMask:      24,
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+				},
+			},
+		},
 		{
 			Name:          "flow with missing interfaces",
 			Configuration: gin.H{},
@@ -599,6 +715,7 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 			OutputFlow: nil,
 			ExpectedMetrics: map[string]string{
 				`flows_errors_total{error="input and output interfaces missing",exporter="192.0.2.142"}`: "1",
+				`flows_enrichment_failures_total{outcome="drop",source="metadata"}`:                      "1",
 			},
 		},
 		{
@@ -615,18 +732,126 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 			OutputFlow: nil,
 			ExpectedMetrics: map[string]string{
 				`flows_errors_total{error="metadata cache miss",exporter="192.0.2.142"}`: "1",
+				`flows_enrichment_failures_total{outcome="drop",source="metadata"}`:      "1",
+			},
+		},
+		{
+			Name: "flow with metadata cache miss, keep",
+			Configuration: gin.H{
+				"enrichmentfailures": gin.H{"metadata": "keep"},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            999,
+					OutIf:           0,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				InIf:            999,
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName: "192.0.2.142",
+				},
+			},
+			ExpectedMetrics: map[string]string{
+				`flows_errors_total{error="metadata cache miss",exporter="192.0.2.142"}`: "1",
+				`flows_enrichment_failures_total{outcome="keep",source="metadata"}`:      "1",
+			},
+		},
+		{
+			Name:          "flow with sampling rate missing",
+			Configuration: gin.H{},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: nil,
+			ExpectedMetrics: map[string]string{
+				`flows_errors_total{error="sampling rate missing",exporter="192.0.2.142"}`: "1",
+				`flows_enrichment_failures_total{outcome="drop",source="sampling-rate"}`:   "1",
+			},
+		},
+		{
+			Name: "flow with sampling rate missing, keep",
+			Configuration: gin.H{
+				"enrichmentfailures": gin.H{"samplingrate": "keep"},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+				},
+			},
+			ExpectedMetrics: map[string]string{
+				`flows_errors_total{error="sampling rate missing",exporter="192.0.2.142"}`: "1",
+				`flows_enrichment_failures_total{outcome="keep",source="sampling-rate"}`:   "1",
+			},
+		},
+		{
+			Name: "flow with metadata cache miss and sampling rate missing, quarantine",
+			Configuration: gin.H{
+				"enrichmentfailures": gin.H{
+					"metadata":     "keep",
+					"samplingrate": "quarantine",
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            999,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: nil,
+			ExpectedMetrics: map[string]string{
+				`flows_errors_total{error="sampling rate missing",exporter="192.0.2.142"}`:     "1",
+				`flows_enrichment_failures_total{outcome="quarantine",source="sampling-rate"}`: "1",
+			},
+			ExpectedQuarantine: []clickhouse.QuarantinedFlow{
+				{
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					Source:          "sampling-rate",
+					InIf:            999,
+					OutIf:           200,
+				},
 			},
 		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.Name, func(t *testing.T) {
 			r := reporter.NewMock(t)
+			sch := schema.NewMock(t)
+			if tc.AllColumns {
+				sch = sch.EnableAllColumns()
+			}
 
 			// Prepare all components.
 			daemonComponent := daemon.NewMock(t)
 			metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
 				metadata.Dependencies{Daemon: daemonComponent})
-			flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+			flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: sch})
 			if err != nil {
 				t.Fatalf("flow.New() error:\n%+v", err)
 			}
@@ -661,7 +886,7 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				ClickHouse: clickhouseComponent,
 				HTTP:       httpComponent,
 				Routing:    routingComponent,
-				Schema:     schema.NewMock(t),
+				Schema:     sch,
 			})
 			if err != nil {
 				t.Fatalf("New() error:\n%+v", err)
@@ -722,6 +947,18 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 			if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 				t.Fatalf("Metrics (-got, +want):\n%s", diff)
 			}
+
+			c.flushQuarantine(t.Context())
+			gotQuarantine := clickhouse.Quarantine(clickhouseComponent)
+			for idx := range gotQuarantine {
+				gotQuarantine[idx].TimeReceived = time.Time{}
+			}
+			if tc.ExpectedQuarantine == nil {
+				tc.ExpectedQuarantine = []clickhouse.QuarantinedFlow{}
+			}
+			if diff := helpers.Diff(gotQuarantine, tc.ExpectedQuarantine); diff != "" {
+				t.Fatalf("Quarantine() (-got, +want):\n%s", diff)
+			}
 		})
 	}
 }
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// InterfaceGroup is a named group of interfaces, like the members of a LAG or
+// parallel links to the same peer.
+type InterfaceGroup struct {
+	// Name is the name of the group.
+	Name string `validate:"required"`
+	// Members lists the patterns matching the interfaces of the group.
+	Members []InterfaceGroupMember `validate:"min=1,dive"`
+}
+
+// InterfaceGroupMember matches interfaces belonging to a group.
+type InterfaceGroupMember struct {
+	// Exporter is a regular expression matching the exporter name. When
+	// empty, any exporter matches.
+	Exporter string
+	// Interface is a regular expression matching the interface name.
+	Interface string `validate:"required"`
+}
+
+// interfaceGroupMatcher is a compiled interface group member.
+type interfaceGroupMatcher struct {
+	group    string
+	exporter *regexp.Regexp
+	iface    *regexp.Regexp
+}
+
+// interfaceGroupKey is the key for the interface group cache.
+type interfaceGroupKey struct {
+	Exporter  string
+	Interface string
+}
+
+// compileInterfaceGroups compiles the regular expressions of the interface
+// groups. They have to match the whole exporter or interface name.
+func compileInterfaceGroups(groups []InterfaceGroup) ([]interfaceGroupMatcher, error) {
+	matchers := []interfaceGroupMatcher{}
+	for _, group := range groups {
+		for _, member := range group.Members {
+			matcher := interfaceGroupMatcher{group: group.Name}
+			var err error
+			if member.Exporter != "" {
+				matcher.exporter, err = regexp.Compile(fmt.Sprintf("^(?:%s)$", member.Exporter))
+				if err != nil {
+					return nil, fmt.Errorf("invalid exporter pattern for interface group %q: %w", group.Name, err)
+				}
+			}
+			matcher.iface, err = regexp.Compile(fmt.Sprintf("^(?:%s)$", member.Interface))
+			if err != nil {
+				return nil, fmt.Errorf("invalid interface pattern for interface group %q: %w", group.Name, err)
+			}
+			matchers = append(matchers, matcher)
+		}
+	}
+	return matchers, nil
+}
+
+// interfaceGroup returns the name of the group the provided interface belongs
+// to. The first matching group wins. An empty string is returned when the
+// interface does not belong to any group.
+func (c *Component) interfaceGroup(t time.Time, exporterName, ifName string) string {
+	if len(c.interfaceGroups) == 0 || ifName == "" {
+		return ""
+	}
+	key := interfaceGroupKey{Exporter: exporterName, Interface: ifName}
+	if group, ok := c.interfaceGroupCache.Get(t, key); ok {
+		return group
+	}
+	group := ""
+	for _, matcher := range c.interfaceGroups {
+		if matcher.exporter != nil && !matcher.exporter.MatchString(exporterName) {
+			continue
+		}
+		if matcher.iface.MatchString(ifName) {
+			group = matcher.group
+			break
+		}
+	}
+	c.interfaceGroupCache.Put(t, key, group)
+	return group
+}
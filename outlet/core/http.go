@@ -4,7 +4,9 @@
 package core
 
 import (
+	"fmt"
 	"net/http"
+	"net/netip"
 	"sync/atomic"
 	"time"
 
@@ -59,3 +61,57 @@ func (c *Component) FlowsHTTPHandler(gc *gin.Context) {
 		}
 	}
 }
+
+type cacheInvalidateParameters struct {
+	Exporter string `form:"exporter" binding:"required"`
+}
+
+// CacheInvalidateHTTPHandler removes the entries of the exporters matching the
+// provided IP address or prefix from the metadata and classifier caches. They
+// are polled and classified again on the next flow.
+func (c *Component) CacheInvalidateHTTPHandler(gc *gin.Context) {
+	var params cacheInvalidateParameters
+	if err := gc.ShouldBindQuery(&params); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	prefix, err := netip.ParsePrefix(params.Exporter)
+	if err != nil {
+		addr, err := netip.ParseAddr(params.Exporter)
+		if err != nil {
+			gc.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Invalid exporter address or prefix %q.", params.Exporter),
+			})
+			return
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	prefix = helpers.PrefixTo6(prefix.Masked())
+	contains := func(ip string) bool {
+		addr, err := netip.ParseAddr(ip)
+		return err == nil && prefix.Contains(helpers.AddrTo6(addr))
+	}
+
+	metadata := c.d.Metadata.Invalidate(prefix)
+	exporters := c.classifierExporterCache.DeleteFunc(
+		func(info exporterInfo, _ exporterClassification) bool {
+			return contains(info.IP)
+		})
+	interfaces := c.classifierInterfaceCache.DeleteFunc(
+		func(info exporterAndInterfaceInfo, _ interfaceClassification) bool {
+			return contains(info.Exporter.IP)
+		})
+	c.metrics.classifierCacheInvalidated.WithLabelValues("exporter").Add(float64(exporters))
+	c.metrics.classifierCacheInvalidated.WithLabelValues("interface").Add(float64(interfaces))
+	c.r.Info().
+		Str("exporter", params.Exporter).
+		Int("metadata", metadata).
+		Int("classifier-exporter", exporters).
+		Int("classifier-interface", interfaces).
+		Msg("cache entries invalidated")
+	gc.JSON(http.StatusOK, gin.H{
+		"metadata":             metadata,
+		"classifier-exporter":  exporters,
+		"classifier-interface": interfaces,
+	})
+}
@@ -5,6 +5,7 @@ package core
 
 import (
 	"sync/atomic"
+	"time"
 
 	"akvorado/common/reporter"
 )
@@ -15,11 +16,23 @@ type metrics struct {
 	flowsReceived    *reporter.CounterVec
 	flowsForwarded   *reporter.CounterVec
 	flowsErrors      *reporter.CounterVec
+	flowsEnrichment  *reporter.CounterVec
 	flowsHTTPClients reporter.GaugeFunc
 
+	stageDuration *reporter.HistogramVec
+	stageFetch    reporter.Observer
+	stageDecode   reporter.Observer
+	stageEnrich   reporter.Observer
+	stageBatch    reporter.Observer
+	stageInsert   reporter.Observer
+
 	classifierExporterCacheSize  reporter.CounterFunc
 	classifierInterfaceCacheSize reporter.CounterFunc
 	classifierErrors             *reporter.CounterVec
+	classifierCacheHits          *reporter.CounterVec
+	classifierCacheMisses        *reporter.CounterVec
+	classifierCacheExpired       *reporter.CounterVec
+	classifierCacheInvalidated   *reporter.CounterVec
 }
 
 func (c *Component) initMetrics() {
@@ -57,6 +70,13 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"exporter", "error"},
 	)
+	c.metrics.flowsEnrichment = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "flows_enrichment_failures_total",
+			Help: "Number of flows failing enrichment, by source and outcome.",
+		},
+		[]string{"source", "outcome"},
+	)
 	c.metrics.flowsHTTPClients = c.r.GaugeFunc(
 		reporter.GaugeOpts{
 			Name: "flows_http_clients",
@@ -67,6 +87,44 @@ func (c *Component) initMetrics() {
 		},
 	)
 
+	c.metrics.stageDuration = c.r.HistogramVec(
+		reporter.HistogramOpts{
+			Name:    "pipeline_stage_duration_seconds",
+			Help:    "Time spent in each stage of the pipeline.",
+			Buckets: []float64{.000001, .000005, .00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{"stage"},
+	)
+	c.metrics.stageFetch = c.metrics.stageDuration.WithLabelValues("fetch")
+	c.metrics.stageDecode = c.metrics.stageDuration.WithLabelValues("decode")
+	c.metrics.stageEnrich = c.metrics.stageDuration.WithLabelValues("enrich")
+	c.metrics.stageBatch = c.metrics.stageDuration.WithLabelValues("batch")
+	c.metrics.stageInsert = c.metrics.stageDuration.WithLabelValues("insert")
+	for _, stage := range []string{"decode", "batch"} {
+		c.r.GaugeFunc(
+			reporter.GaugeOpts{
+				Name:        "pipeline_queue_depth",
+				Help:        "Number of items waiting in the queue of each stage of the pipeline.",
+				ConstLabels: map[string]string{"stage": stage},
+			},
+			func() float64 {
+				depth, _ := c.queueStats(stage)
+				return float64(depth)
+			},
+		)
+		c.r.GaugeFunc(
+			reporter.GaugeOpts{
+				Name:        "pipeline_queue_capacity",
+				Help:        "Capacity of the queue of each stage of the pipeline.",
+				ConstLabels: map[string]string{"stage": stage},
+			},
+			func() float64 {
+				_, capacity := c.queueStats(stage)
+				return float64(capacity)
+			},
+		)
+	}
+
 	c.metrics.classifierExporterCacheSize = c.r.CounterFunc(
 		reporter.CounterOpts{
 			Name: "classifier_exporter_cache_items_total",
@@ -91,4 +149,52 @@ func (c *Component) initMetrics() {
 			Help: "Number of errors when evaluating a classifer",
 		},
 		[]string{"type", "index"})
+	c.metrics.classifierCacheHits = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "classifier_cache_hits_total",
+			Help: "Number of classifications retrieved from cache.",
+		},
+		[]string{"cache"})
+	c.metrics.classifierCacheMisses = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "classifier_cache_misses_total",
+			Help: "Number of classifications not found in cache.",
+		},
+		[]string{"cache"})
+	c.metrics.classifierCacheExpired = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "classifier_cache_expired_entries_total",
+			Help: "Number of classifier cache entries expired.",
+		},
+		[]string{"cache"})
+	c.metrics.classifierCacheInvalidated = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "classifier_cache_invalidated_entries_total",
+			Help: "Number of classifier cache entries invalidated on request.",
+		},
+		[]string{"cache"})
+}
+
+// queueStats returns the number of items waiting in the queue of the provided
+// stage and its capacity, summed over all workers.
+func (c *Component) queueStats(stage string) (int, int) {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+	depth, capacity := 0, 0
+	for w := range c.workers {
+		switch stage {
+		case "decode":
+			depth += len(w.rawFlows)
+			capacity += cap(w.rawFlows)
+		case "batch":
+			depth += len(w.chunks)
+			capacity += cap(w.chunks)
+		}
+	}
+	return depth, capacity
+}
+
+// observeSince records the time elapsed since the provided time.
+func observeSince(observer reporter.Observer, start time.Time) {
+	observer.Observe(time.Since(start).Seconds())
 }
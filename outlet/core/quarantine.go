@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"akvorado/outlet/clickhouse"
+)
+
+const (
+	// quarantineFlushInterval defines how often quarantined flows are sent to
+	// ClickHouse.
+	quarantineFlushInterval = 10 * time.Second
+	// quarantineMaxFlows is the maximum number of quarantined flows kept
+	// between two flushes. Additional flows are dropped.
+	quarantineMaxFlows = 10_000
+)
+
+// quarantine holds the flows diverted to quarantine since the last flush.
+type quarantine struct {
+	lock  sync.Mutex
+	flows []clickhouse.QuarantinedFlow
+}
+
+// add adds a flow to the quarantine. It returns false if the quarantine is
+// full.
+func (q *quarantine) add(flow clickhouse.QuarantinedFlow) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.flows) >= quarantineMaxFlows {
+		return false
+	}
+	q.flows = append(q.flows, flow)
+	return true
+}
+
+// reset returns the quarantined flows since the last call and empties the
+// quarantine.
+func (q *quarantine) reset() []clickhouse.QuarantinedFlow {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	flows := q.flows
+	q.flows = nil
+	return flows
+}
+
+// flushQuarantine sends the quarantined flows to ClickHouse.
+func (c *Component) flushQuarantine(ctx context.Context) {
+	flows := c.quarantine.reset()
+	if err := c.d.ClickHouse.SendQuarantine(ctx, flows); err != nil {
+		c.r.Err(err).Int("flows", len(flows)).Msg("cannot send quarantined flows")
+	}
+}
+
+// runQuarantine periodically flushes the quarantined flows. They are also
+// flushed on shutdown.
+func (c *Component) runQuarantine() error {
+	ticker := time.NewTicker(quarantineFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.t.Dying():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c.flushQuarantine(ctx)
+			return nil
+		case <-ticker.C:
+			c.flushQuarantine(c.t.Context(context.Background()))
+		}
+	}
+}
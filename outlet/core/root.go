@@ -5,6 +5,8 @@
 package core
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/tomb.v2"
@@ -18,6 +20,7 @@ import (
 	"akvorado/outlet/flow"
 	"akvorado/outlet/kafka"
 	"akvorado/outlet/metadata"
+	"akvorado/outlet/reexport"
 	"akvorado/outlet/routing"
 )
 
@@ -37,6 +40,20 @@ type Component struct {
 	classifierExporterCache  *cache.Cache[exporterInfo, exporterClassification]
 	classifierInterfaceCache *cache.Cache[exporterAndInterfaceInfo, interfaceClassification]
 	classifierErrLogger      reporter.Logger
+
+	interfaceGroups     []interfaceGroupMatcher
+	interfaceGroupCache *cache.Cache[interfaceGroupKey, string]
+
+	accounting *accounting
+	quarantine quarantine
+
+	workersLock sync.Mutex
+	workers     map[*worker]struct{}
+
+	// Sampled loggers for flows rejected during enrichment
+	noInterfaceErrLogger    reporter.Logger
+	metadataMissErrLogger   reporter.Logger
+	noSamplingRateErrLogger reporter.Logger
 }
 
 // Dependencies define the dependencies of the HTTP component.
@@ -49,6 +66,7 @@ type Dependencies struct {
 	ClickHouse clickhouse.Component
 	HTTP       *httpserver.Component
 	Schema     *schema.Component
+	Reexport   *reexport.Component // optional
 }
 
 // New creates a new core component.
@@ -65,7 +83,22 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		classifierExporterCache:  cache.New[exporterInfo, exporterClassification](),
 		classifierInterfaceCache: cache.New[exporterAndInterfaceInfo, interfaceClassification](),
 		classifierErrLogger:      r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+
+		interfaceGroupCache: cache.New[interfaceGroupKey, string](),
+
+		accounting: newAccounting(),
+
+		workers: map[*worker]struct{}{},
+
+		noInterfaceErrLogger:    r.SampleEvery(10000),
+		metadataMissErrLogger:   r.SampleEvery(10000),
+		noSamplingRateErrLogger: r.SampleEvery(10000),
 	}
+	interfaceGroups, err := compileInterfaceGroups(configuration.InterfaceGroups)
+	if err != nil {
+		return nil, err
+	}
+	c.interfaceGroups = interfaceGroups
 	c.d.Daemon.Track(&c.t, "outlet/core")
 	c.initMetrics()
 	return &c, nil
@@ -74,6 +107,15 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 // Start starts the core component.
 func (c *Component) Start() error {
 	c.r.Info().Msg("starting core component")
+	c.r.RegisterDiagnostics("core", func() any {
+		return map[string]any{
+			"classifier-exporter-cache":  c.classifierExporterCache.Size(),
+			"classifier-interface-cache": c.classifierInterfaceCache.Size(),
+			"interface-group-cache":      c.interfaceGroupCache.Size(),
+			"http-flow-clients":          atomic.LoadUint32(&c.httpFlowClients),
+			"http-flow-channel":          len(c.httpFlowChannel),
+		}
+	})
 	c.d.Kafka.StartWorkers(c.newWorker)
 
 	// Classifier cache expiration
@@ -84,13 +126,32 @@ func (c *Component) Start() error {
 				return nil
 			case <-time.After(c.config.ClassifierCacheDuration):
 				before := time.Now().Add(-c.config.ClassifierCacheDuration)
-				c.classifierExporterCache.DeleteLastAccessedBefore(before)
-				c.classifierInterfaceCache.DeleteLastAccessedBefore(before)
+				c.metrics.classifierCacheExpired.WithLabelValues("exporter").Add(
+					float64(c.classifierExporterCache.DeleteLastAccessedBefore(before)))
+				c.metrics.classifierCacheExpired.WithLabelValues("interface").Add(
+					float64(c.classifierInterfaceCache.DeleteLastAccessedBefore(before)))
+				c.metrics.classifierCacheExpired.WithLabelValues("interface-group").Add(
+					float64(c.interfaceGroupCache.DeleteLastAccessedBefore(before)))
 			}
 		}
 	})
 
+	// Ingest accounting
+	if c.config.AccountingInterval > 0 {
+		c.t.Go(c.runAccounting)
+	}
+
+	// Quarantine for flows which cannot be enriched
+	if c.config.EnrichmentFailures.Metadata == EnrichmentFailurePolicyQuarantine ||
+		c.config.EnrichmentFailures.SamplingRate == EnrichmentFailurePolicyQuarantine {
+		c.t.Go(c.runQuarantine)
+	}
+
+	// Interface counters polled by metadata providers
+	c.t.Go(c.runCounters)
+
 	c.d.HTTP.GinRouter.GET("/api/v0/outlet/flows", c.FlowsHTTPHandler)
+	c.d.HTTP.GinRouter.POST("/api/v0/outlet/cache/invalidate", c.d.HTTP.AdminOnly, c.CacheInvalidateHTTPHandler)
 	return nil
 }
 
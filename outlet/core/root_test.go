@@ -6,12 +6,16 @@ package core
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/netip"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -145,7 +149,7 @@ func TestCore(t *testing.T) {
 		injectFlow(flowMessage("192.0.2.143", 437, 679))
 		time.Sleep(20 * time.Millisecond)
 
-		gotMetrics := r.GetMetrics("akvorado_outlet_core_", "-flows_processing_")
+		gotMetrics := r.GetMetrics("akvorado_outlet_core_", "-flows_processing_", "-pipeline_")
 		expectedMetrics := map[string]string{
 			`classifier_exporter_cache_items_total`:         "0",
 			`classifier_interface_cache_items_total`:        "0",
@@ -159,6 +163,22 @@ func TestCore(t *testing.T) {
 		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 			t.Fatalf("Metrics (-got, +want):\n%s", diff)
 		}
+		gotMetrics = r.GetMetrics("akvorado_outlet_core_", "pipeline_queue_", "pipeline_stage_duration_seconds_count")
+		decoders := runtime.GOMAXPROCS(0)
+		expectedMetrics = map[string]string{
+			`pipeline_queue_capacity{stage="batch"}`:                strconv.Itoa(decoders),
+			`pipeline_queue_capacity{stage="decode"}`:               strconv.Itoa(10 * decoders),
+			`pipeline_queue_depth{stage="batch"}`:                   "0",
+			`pipeline_queue_depth{stage="decode"}`:                  "0",
+			`pipeline_stage_duration_seconds_count{stage="batch"}`:  "0",
+			`pipeline_stage_duration_seconds_count{stage="decode"}`: "2",
+			`pipeline_stage_duration_seconds_count{stage="enrich"}`: "2",
+			`pipeline_stage_duration_seconds_count{stage="fetch"}`:  "2",
+			`pipeline_stage_duration_seconds_count{stage="insert"}`: "0",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
 
 		// Should have 2 more flows in clickhouseMessages now
 		clickhouseMessagesMutex.Lock()
@@ -197,12 +217,111 @@ func TestCore(t *testing.T) {
 			`classifier_exporter_cache_items_total`:                                    "0",
 			`classifier_interface_cache_items_total`:                                   "0",
 			`flows_errors_total{error="sampling rate missing",exporter="192.0.2.142"}`: "1",
+			`flows_enrichment_failures_total{outcome="drop",source="sampling-rate"}`:   "1",
 			`received_flows_total{exporter="192.0.2.142"}`:                             "3",
 			`received_flows_total{exporter="192.0.2.143"}`:                             "1",
 			`forwarded_flows_total{exporter="192.0.2.142"}`:                            "2",
 			`forwarded_flows_total{exporter="192.0.2.143"}`:                            "1",
-			`flows_http_clients`:                                                       "0",
-			`received_raw_flows_total`:                                                 "4",
+			`flows_http_clients`:       "0",
+			`received_raw_flows_total`: "4",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+
+		// Inject a message which cannot be decoded
+		data, err := proto.Marshal(&pb.RawFlow{
+			TimeReceived:  uint64(time.Now().Unix()),
+			Payload:       []byte("hello world!"),
+			SourceAddress: helpers.AddrTo6(netip.MustParseAddr("192.0.2.143")).AsSlice(),
+			Decoder:       pb.RawFlow_DECODER_GOB,
+		})
+		if err != nil {
+			t.Fatalf("proto.Marshal() error: %v", err)
+		}
+		incoming <- data
+		time.Sleep(20 * time.Millisecond)
+
+		// Check ingest accounting
+		c.flushAccounting(t.Context())
+		gotAccounting := clickhouse.Accounting(clickhouseComponent)
+		slices.SortFunc(gotAccounting, func(a, b clickhouse.ExporterAccounting) int {
+			return a.ExporterAddress.Compare(b.ExporterAddress)
+		})
+		for idx := range gotAccounting {
+			if gotAccounting[idx].Bytes == 0 {
+				t.Errorf("Accounting() for %s: no bytes", gotAccounting[idx].ExporterAddress)
+			}
+			gotAccounting[idx].Bytes = 0
+		}
+		expectedAccounting := []clickhouse.ExporterAccounting{
+			{
+				ExporterAddress:  netip.MustParseAddr("::ffff:192.0.2.142"),
+				Flows:            3,
+				EnrichmentMisses: 1,
+			}, {
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.143"),
+				Flows:           1,
+				DecodeErrors:    1,
+			},
+		}
+		if diff := helpers.Diff(gotAccounting, expectedAccounting); diff != "" {
+			t.Fatalf("Accounting() (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("schema version", func(t *testing.T) {
+		clickhouseMessagesMutex.Lock()
+		clickhouseMessages = clickhouseMessages[:0]
+		clickhouseMessagesMutex.Unlock()
+
+		var buf bytes.Buffer
+		flow := flowMessage("192.0.2.144", 434, 677)
+		if err := gob.NewEncoder(&buf).Encode(flow); err != nil {
+			t.Fatalf("gob.Encode() error: %v", err)
+		}
+		current := uint32(pb.Version)
+		for _, version := range []uint32{0, current - 1, current + 1, current + 2} {
+			data, err := proto.Marshal(&pb.RawFlow{
+				TimeReceived:  uint64(time.Now().Unix()),
+				Payload:       buf.Bytes(),
+				SourceAddress: flow.ExporterAddress.AsSlice(),
+				Decoder:       pb.RawFlow_DECODER_GOB,
+				Version:       version,
+			})
+			if err != nil {
+				t.Fatalf("proto.Marshal() error: %v", err)
+			}
+			incoming <- data
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		gotMetrics := r.GetMetrics("akvorado_outlet_core_", "raw_flows_errors_", "forwarded_flows_total{exporter=\"192.0.2.144\"}")
+		expectedMetrics := map[string]string{
+			`raw_flows_errors_total{error="cannot decode payload"}`:       "1",
+			`raw_flows_errors_total{error="incompatible schema version"}`: "1",
+			`forwarded_flows_total{exporter="192.0.2.144"}`:               "3",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid protobuf", func(t *testing.T) {
+		callback, shutdown := c.newWorker(100, make(chan kafka.ScaleRequest, 10))
+		defer shutdown()
+		if err := callback(context.Background(), []byte("\xff\xff\xff")); err != nil {
+			t.Fatalf("callback() error:\n%+v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		err := callback(context.Background(), []byte{})
+		if err == nil || !strings.Contains(err.Error(), "cannot decode raw flow") {
+			t.Fatalf("callback() error = %v, expected a decoding error", err)
+		}
+
+		gotMetrics := r.GetMetrics("akvorado_outlet_core_", "raw_flows_errors_total{error=\"cannot decode protobuf\"}")
+		expectedMetrics := map[string]string{
+			`raw_flows_errors_total{error="cannot decode protobuf"}`: "1",
 		}
 		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 			t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -356,4 +475,121 @@ func TestCore(t *testing.T) {
 			t.Fatalf("GET /api/v0/outlet/flows got less than 4 flows (%d)", count)
 		}
 	})
+
+	t.Run("interface counters", func(t *testing.T) {
+		injectFlow(flowMessage("192.0.2.144", 3010, 677))
+		time.Sleep(20 * time.Millisecond)
+
+		c.flushCounters(t.Context())
+		gotCounters := clickhouse.Counters(clickhouseComponent)
+		for idx := range gotCounters {
+			gotCounters[idx].TimeReceived = time.Time{}
+		}
+		expectedCounters := []clickhouse.InterfaceCounters{
+			{
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.144"),
+				ExporterName:    "192_0_2_144",
+				IfIndex:         3010,
+				IfName:          "Gi0/0/3010",
+				InOctets:        1000,
+				OutOctets:       2000,
+			},
+		}
+		if diff := helpers.Diff(gotCounters, expectedCounters); diff != "" {
+			t.Fatalf("Counters() (-got, +want):\n%s", diff)
+		}
+	})
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemonComponent})
+	flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("flow.New() error:\n%+v", err)
+	}
+	httpConfiguration := httpserver.DefaultConfiguration()
+	httpConfiguration.Listen = "127.0.0.1:0"
+	httpConfiguration.AdminToken = "s3cr3t"
+	httpComponent, err := httpserver.New(r, httpConfiguration, httpserver.Dependencies{Daemon: daemonComponent})
+	if err != nil {
+		t.Fatalf("httpserver.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, httpComponent)
+	kafkaComponent, _ := kafka.NewMock(t, kafka.DefaultConfiguration())
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemonComponent,
+		Flow:       flowComponent,
+		Metadata:   metadataComponent,
+		Kafka:      kafkaComponent,
+		ClickHouse: clickhouse.NewMock(t, func(*schema.FlowMessage) {}),
+		HTTP:       httpComponent,
+		Routing:    routing.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Populate caches
+	now := time.Now()
+	for _, exporter := range []string{"192.0.2.142", "192.0.2.143", "198.51.100.1"} {
+		metadataComponent.Lookup(now, helpers.AddrTo6(netip.MustParseAddr(exporter)), 100)
+		c.classifierExporterCache.Put(now, exporterInfo{IP: exporter, Name: exporter}, exporterClassification{})
+		c.classifierInterfaceCache.Put(now, exporterAndInterfaceInfo{
+			Exporter:  exporterInfo{IP: exporter, Name: exporter},
+			Interface: interfaceInfo{Index: 100},
+		}, interfaceClassification{})
+	}
+
+	authorization := http.Header{"Authorization": []string{"Bearer s3cr3t"}}
+	helpers.TestHTTPEndpoints(t, httpComponent.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "no token",
+			Method:      "POST",
+			URL:         "/api/v0/outlet/cache/invalidate?exporter=192.0.2.142",
+			StatusCode:  401,
+			JSONOutput:  gin.H{"message": "Invalid or missing admin token."},
+		}, {
+			Description: "invalid exporter",
+			Method:      "POST",
+			URL:         "/api/v0/outlet/cache/invalidate?exporter=192.0.2.1000",
+			Header:      authorization,
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": `Invalid exporter address or prefix "192.0.2.1000".`},
+		}, {
+			Description: "single exporter",
+			Method:      "POST",
+			URL:         "/api/v0/outlet/cache/invalidate?exporter=192.0.2.142",
+			Header:      authorization,
+			JSONOutput: gin.H{
+				"metadata":             1,
+				"classifier-exporter":  1,
+				"classifier-interface": 1,
+			},
+		}, {
+			Description: "prefix",
+			Method:      "POST",
+			URL:         "/api/v0/outlet/cache/invalidate?exporter=192.0.2.0/24",
+			Header:      authorization,
+			JSONOutput: gin.H{
+				"metadata":             1,
+				"classifier-exporter":  1,
+				"classifier-interface": 1,
+			},
+		},
+	})
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_", "metadata_cache_invalidated_", "core_classifier_cache_invalidated_")
+	expectedMetrics := map[string]string{
+		`metadata_cache_invalidated_entries_total`:                           "2",
+		`core_classifier_cache_invalidated_entries_total{cache="exporter"}`:  "2",
+		`core_classifier_cache_invalidated_entries_total{cache="interface"}`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
 }
@@ -7,6 +7,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,94 +18,289 @@ import (
 	"akvorado/common/schema"
 	"akvorado/outlet/clickhouse"
 	"akvorado/outlet/kafka"
+	"akvorado/outlet/reexport"
 )
 
-// worker represents a worker processing incoming flows.
+// chunkSize is the maximum number of flows a decoder accumulates before handing
+// them to the sender.
+const chunkSize = 1000
+
+// worker represents a worker processing incoming flows. Raw flows received from
+// Kafka are decoded and enriched by a pool of decoders, sized to the number of
+// usable CPUs. Decoded flows are handed in chunks to a sender, through a bounded
+// channel. The sender merges them into its batch and sends it to ClickHouse.
 type worker struct {
+	c         *Component
+	l         reporter.Logger
+	errLogger reporter.Logger
+	cw        clickhouse.Worker
+	bf        *schema.FlowMessage
+
+	rawFlows   chan []byte              // raw flows to be decoded
+	chunks     chan *schema.FlowMessage // decoded flows to be sent
+	freeChunks chan *schema.FlowMessage // chunks to be reused
+	failed     chan error               // fatal decoding errors
+	decoders   sync.WaitGroup
+	sender     sync.WaitGroup
+	cancel     context.CancelFunc
+
+	scaleRequestChan chan<- kafka.ScaleRequest
+}
+
+// decoder decodes and enriches raw flows. Each decoder runs in its own
+// goroutine.
+type decoder struct {
 	c       *Component
-	l       reporter.Logger
-	cw      clickhouse.Worker
+	w       *worker
 	bf      *schema.FlowMessage
+	rw      *reexport.Worker
 	rawFlow pb.RawFlow
-
-	scaleRequestChan chan<- kafka.ScaleRequest
 }
 
 // newWorker instantiates a new worker and returns a callback function to
 // process an incoming flow and a function to call on shutdown.
 func (c *Component) newWorker(i int, scaleRequestChan chan<- kafka.ScaleRequest) (kafka.ReceiveFunc, kafka.ShutdownFunc) {
+	decoders := runtime.GOMAXPROCS(0)
 	bf := c.d.Schema.NewFlowMessage()
-	w := worker{
+	l := c.r.With().Int("worker", i).Logger()
+	w := &worker{
 		c:                c,
-		l:                c.r.With().Int("worker", i).Logger(),
+		l:                l,
+		errLogger:        l.Sample(reporter.BurstSampler(time.Minute, 3)),
 		bf:               bf,
 		cw:               c.d.ClickHouse.NewWorker(i, bf),
+		rawFlows:         make(chan []byte, 10*decoders),
+		chunks:           make(chan *schema.FlowMessage, decoders),
+		freeChunks:       make(chan *schema.FlowMessage, 2*decoders+1),
+		failed:           make(chan error, 1),
 		scaleRequestChan: scaleRequestChan,
 	}
+	for range decoders {
+		d := &decoder{
+			c:  c,
+			w:  w,
+			bf: c.d.Schema.NewFlowMessage(),
+		}
+		if c.d.Reexport != nil {
+			d.rw = c.d.Reexport.NewWorker(d.bf)
+		}
+		w.decoders.Go(d.run)
+	}
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+	w.sender.Go(func() { w.send(ctx) })
+	c.workersLock.Lock()
+	c.workers[w] = struct{}{}
+	c.workersLock.Unlock()
 	return w.processIncomingFlow, w.shutdown
 }
 
 // shutdown shutdowns the worker, flushing any remaining data.
 func (w *worker) shutdown() {
-	w.l.Info().Msg("flush final batch to ClickHouse")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	w.cw.Flush(ctx)
+	// Let one second to send the pending flows to ClickHouse.
+	timer := time.AfterFunc(time.Second, w.cancel)
+	defer timer.Stop()
+	defer w.cancel()
+	close(w.rawFlows)
+	w.decoders.Wait()
+	close(w.chunks)
+	w.sender.Wait()
+	w.c.workersLock.Lock()
+	delete(w.c.workers, w)
+	w.c.workersLock.Unlock()
 	w.l.Info().Msg("worker stopped")
 }
 
-// processIncomingFlow processes one incoming flow from Kafka.
+// processIncomingFlow queues one incoming flow from Kafka for decoding. The time
+// spent waiting for a decoder is recorded as the fetch stage. As decoding is
+// asynchronous, a fatal decoding error is returned on a later call, stopping
+// the consumer.
 func (w *worker) processIncomingFlow(ctx context.Context, data []byte) error {
-	// Raw flow decoding: fatal
 	w.c.metrics.rawFlowsReceived.Inc()
-	w.rawFlow.ResetVT()
-	if err := w.rawFlow.UnmarshalVT(data); err != nil {
-		w.c.metrics.rawFlowsErrors.WithLabelValues("cannot decode protobuf")
-		return fmt.Errorf("cannot decode raw flow: %w", err)
+	defer observeSince(w.c.metrics.stageFetch, time.Now())
+	select {
+	case err := <-w.failed:
+		return err
+	default:
+	}
+	select {
+	case w.rawFlows <- data:
+		return nil
+	case err := <-w.failed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fail reports a fatal decoding error. Only the first one is kept.
+func (w *worker) fail(err error) {
+	select {
+	case w.failed <- err:
+	default:
+	}
+}
+
+// send merges the chunks of decoded flows into the current batch and sends it
+// to ClickHouse when needed. A timer ensures a partial batch is sent on time,
+// even when no new flow is received.
+func (w *worker) send(ctx context.Context) {
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case chunk, ok := <-w.chunks:
+			if !ok {
+				w.l.Info().Msg("flush final batch to ClickHouse")
+				w.cw.Flush(ctx)
+				return
+			}
+			start := time.Now()
+			if w.bf.FlowCount() == 0 {
+				w.bf.SwapBatch(chunk)
+			} else {
+				w.bf.AppendBatch(chunk)
+			}
+			observeSince(w.c.metrics.stageBatch, start)
+			chunk.Clear()
+			select {
+			case w.freeChunks <- chunk:
+			default:
+			}
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		status := w.cw.Send(ctx)
+		if status != clickhouse.WorkerStatusIdle {
+			observeSince(w.c.metrics.stageInsert, start)
+		}
+		if deadline := w.cw.Deadline(); !deadline.IsZero() {
+			timer.Reset(time.Until(deadline))
+		} else {
+			timer.Stop()
+		}
+
+		var request kafka.ScaleRequest
+		switch status {
+		case clickhouse.WorkerStatusOverloaded:
+			request = kafka.ScaleIncrease
+		case clickhouse.WorkerStatusUnderloaded:
+			request = kafka.ScaleDecrease
+		case clickhouse.WorkerStatusSteady:
+			request = kafka.ScaleSteady
+		default:
+			continue
+		}
+		select {
+		case w.scaleRequestChan <- request:
+		case <-ctx.Done():
+		}
 	}
+}
+
+// run decodes the raw flows until there are no more of them. Decoded flows are
+// handed to the sender when the chunk is full or when there is no more raw flow
+// to decode.
+func (d *decoder) run() {
+	for data := range d.w.rawFlows {
+		d.processIncomingFlow(data)
+		if d.bf.FlowCount() >= chunkSize || len(d.w.rawFlows) == 0 {
+			d.handoff()
+		}
+	}
+	d.handoff()
+	if d.rw != nil {
+		d.rw.Close()
+	}
+}
+
+// handoff sends the decoded flows to the sender.
+func (d *decoder) handoff() {
+	if d.bf.FlowCount() == 0 {
+		return
+	}
+	var chunk *schema.FlowMessage
+	select {
+	case chunk = <-d.w.freeChunks:
+	default:
+		chunk = d.c.d.Schema.NewFlowMessage()
+	}
+	d.bf.SwapBatch(chunk)
+	d.w.chunks <- chunk
+}
+
+// processIncomingFlow decodes and enriches one incoming flow from Kafka. The
+// time spent enriching the decoded flows is recorded separately from the time
+// spent decoding.
+func (d *decoder) processIncomingFlow(data []byte) {
+	var enrichDuration time.Duration
+	start := time.Now()
+	defer func() {
+		d.c.metrics.stageDecode.Observe((time.Since(start) - enrichDuration).Seconds())
+		if enrichDuration > 0 {
+			d.c.metrics.stageEnrich.Observe(enrichDuration.Seconds())
+		}
+	}()
+
+	// Raw flow decoding: fatal
+	d.rawFlow.ResetVT()
+	if err := d.rawFlow.UnmarshalVT(data); err != nil {
+		d.c.metrics.rawFlowsErrors.WithLabelValues("cannot decode protobuf").Inc()
+		d.w.fail(fmt.Errorf("cannot decode raw flow: %w", err))
+		return
+	}
+	// Schema version: not fatal
+	if err := d.rawFlow.CheckVersion(); err != nil {
+		d.c.metrics.rawFlowsErrors.WithLabelValues("incompatible schema version").Inc()
+		d.w.errLogger.Err(err).Msg("drop raw flow")
+		return
+	}
+	source, _ := netip.AddrFromSlice(d.rawFlow.SourceAddress)
+	sourceAccounting := d.c.accounting.get(source)
+	sourceAccounting.bytes.Add(uint64(len(d.rawFlow.Payload)))
 
 	// Process each decoded flow
 	finalize := func() {
+		enrichStart := time.Now()
+		defer func() { enrichDuration += time.Since(enrichStart) }()
+
 		// Accounting
-		exporter := w.bf.ExporterAddress.Unmap().String()
-		w.c.metrics.flowsReceived.WithLabelValues(exporter).Inc()
+		exporter := d.bf.ExporterAddress.Unmap().String()
+		d.c.metrics.flowsReceived.WithLabelValues(exporter).Inc()
+		d.c.accounting.get(d.bf.ExporterAddress).flows.Add(1)
 
-		// Enrichment: not fatal
-		ip := w.bf.ExporterAddress
-		if skip := w.enrichFlow(ip, exporter); skip {
-			w.bf.Undo()
+		// Enrichment
+		ip := d.bf.ExporterAddress
+		if skip := d.enrichFlow(ip, exporter); skip {
+			d.bf.Undo()
 			return
 		}
 
 		// If we have HTTP clients, send to them too
-		if atomic.LoadUint32(&w.c.httpFlowClients) > 0 {
-			if jsonBytes, err := json.Marshal(w.bf); err == nil {
+		if atomic.LoadUint32(&d.c.httpFlowClients) > 0 {
+			if jsonBytes, err := json.Marshal(d.bf); err == nil {
 				select {
-				case w.c.httpFlowChannel <- jsonBytes: // OK
+				case d.c.httpFlowChannel <- jsonBytes: // OK
 				default: // Overflow, best effort and ignore
 				}
 			}
 		}
 
-		// Finalize and forward to ClickHouse
-		w.c.metrics.flowsForwarded.WithLabelValues(exporter).Inc()
-		status := w.cw.FinalizeAndSend(ctx)
-		switch status {
-		case clickhouse.WorkerStatusOverloaded:
-			w.scaleRequestChan <- kafka.ScaleIncrease
-		case clickhouse.WorkerStatusUnderloaded:
-			w.scaleRequestChan <- kafka.ScaleDecrease
-		case clickhouse.WorkerStatusSteady:
-			w.scaleRequestChan <- kafka.ScaleSteady
+		// Re-export to downstream collectors
+		if d.rw != nil {
+			d.rw.Export()
 		}
-	}
 
-	// Flow decoding: not fatal
-	err := w.c.d.Flow.Decode(&w.rawFlow, w.bf, finalize)
-	if err != nil {
-		w.c.metrics.rawFlowsErrors.WithLabelValues("cannot decode payload")
-		return nil
+		// Finalize and add to the current chunk
+		d.c.metrics.flowsForwarded.WithLabelValues(exporter).Inc()
+		d.c.d.ClickHouse.Finalize(d.bf)
 	}
 
-	return nil
+	// Flow decoding
+	if err := d.c.d.Flow.Decode(&d.rawFlow, d.bf, finalize); err != nil {
+		d.c.metrics.rawFlowsErrors.WithLabelValues("cannot decode payload").Inc()
+		sourceAccounting.decodeErrors.Add(1)
+	}
 }
@@ -3,14 +3,27 @@
 
 package flow
 
+import (
+	"akvorado/common/helpers"
+	"akvorado/outlet/flow/decoder"
+)
+
 // Configuration describes the configuration for the flow component.
 type Configuration struct {
 	// StatePersistFile defines a file to store decoder state (templates, sampling
 	// rates) to survive restarts.
 	StatePersistFile string `validate:"isdefault|filepath"`
+	// Quirks defines the compatibility shims to enable for each exporter.
+	Quirks *helpers.SubnetMap[decoder.Quirks]
 }
 
 // DefaultConfiguration returns the default configuration for the flow component.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	return Configuration{
+		Quirks: helpers.MustNewSubnetMap(map[string]decoder.Quirks{}),
+	}
+}
+
+func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(helpers.SubnetMapUnmarshallerHook[decoder.Quirks]())
 }
@@ -13,7 +13,9 @@ import (
 	"akvorado/common/pb"
 	"akvorado/common/schema"
 	"akvorado/outlet/flow/decoder"
+	"akvorado/outlet/flow/decoder/goflow2"
 	"akvorado/outlet/flow/decoder/netflow"
+	"akvorado/outlet/flow/decoder/pmacct"
 	"akvorado/outlet/flow/decoder/sflow"
 )
 
@@ -41,6 +43,9 @@ func (c *Component) Decode(rawFlow *pb.RawFlow, bf *schema.FlowMessage, finalize
 	options := decoder.Option{
 		TimestampSource: rawFlow.TimestampSource,
 	}
+	if c.config.Quirks != nil {
+		options.Quirks, _ = c.config.Quirks.Lookup(sourceIP)
+	}
 
 	if err := c.decodeWithMetrics(dec, decoderInput, options, bf, func() {
 		if rawFlow.UseSourceAddress {
@@ -80,4 +85,6 @@ func (c *Component) decodeWithMetrics(dec decoder.Decoder, input decoder.RawFlow
 var availableDecoders = map[pb.RawFlow_Decoder]decoder.NewDecoderFunc{
 	pb.RawFlow_DECODER_NETFLOW: netflow.New,
 	pb.RawFlow_DECODER_SFLOW:   sflow.New,
+	pb.RawFlow_DECODER_GOFLOW2: goflow2.New,
+	pb.RawFlow_DECODER_PMACCT:  pmacct.New,
 }
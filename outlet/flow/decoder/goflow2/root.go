@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package goflow2 handles decoding of flows produced by goflow2 in its
+// protobuf format.
+package goflow2
+
+import (
+	"fmt"
+	"time"
+
+	flowpb "github.com/netsampler/goflow2/v2/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+// Decoder contains the state for the goflow2 decoder.
+type Decoder struct {
+	r         *reporter.Reporter
+	d         decoder.Dependencies
+	errLogger reporter.Logger
+}
+
+// New instantiates a new goflow2 decoder.
+func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decoder {
+	return &Decoder{
+		r:         r,
+		d:         dependencies,
+		errLogger: r.Sample(reporter.BurstSampler(30*time.Second, 3)),
+	}
+}
+
+// Name returns the name of the decoder.
+func (nd *Decoder) Name() string {
+	return "goflow2"
+}
+
+// Decode decodes a goflow2 protobuf message. goflow2 prefixes each message with
+// its length by default. Messages without this prefix are also accepted.
+func (nd *Decoder) Decode(in decoder.RawFlow, _ decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) (int, error) {
+	payload := in.Payload
+	if length, n := protowire.ConsumeVarint(payload); n > 0 && uint64(len(payload)-n) == length {
+		payload = payload[n:]
+	}
+	var msg flowpb.FlowMessage
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		nd.errLogger.Err(err).Str("exporter", in.Source.String()).Msg("error while decoding goflow2 message")
+		return 0, fmt.Errorf("error while decoding goflow2 message: %w", err)
+	}
+
+	ts := uint32(in.TimeReceived.UTC().Unix())
+	if msg.TimeReceivedNs > 0 {
+		ts = uint32(msg.TimeReceivedNs / 1_000_000_000)
+	}
+	nd.decode(&msg, bf)
+	bf.TimeReceived = ts
+	finalize()
+	return 1, nil
+}
+
+// decode maps the fields of a goflow2 message to the flow message.
+func (nd *Decoder) decode(msg *flowpb.FlowMessage, bf *schema.FlowMessage) {
+	bf.ExporterAddress = decoder.DecodeIP(msg.SamplerAddress)
+	bf.SamplingRate = msg.SamplingRate
+	if bf.SamplingRate == 0 {
+		bf.SamplingRate = 1
+	}
+	bf.InIf = msg.InIf
+	bf.OutIf = msg.OutIf
+	bf.SrcAddr = decoder.DecodeIP(msg.SrcAddr)
+	bf.DstAddr = decoder.DecodeIP(msg.DstAddr)
+	bf.NextHop = decoder.DecodeIP(msg.NextHop)
+	if len(msg.BgpNextHop) > 0 {
+		bf.NextHop = decoder.DecodeIP(msg.BgpNextHop)
+	}
+	bf.SrcNetMask = uint8(msg.SrcNet)
+	bf.DstNetMask = uint8(msg.DstNet)
+	bf.SrcAS = msg.SrcAs
+	bf.DstAS = msg.DstAs
+	bf.AppendUint(schema.ColumnBytes, msg.Bytes)
+	bf.AppendUint(schema.ColumnPackets, msg.Packets)
+	bf.AppendUint(schema.ColumnEType, uint64(msg.Etype))
+	bf.AppendUint(schema.ColumnProto, uint64(msg.Proto))
+	bf.AppendUint(schema.ColumnSrcPort, uint64(msg.SrcPort))
+	bf.AppendUint(schema.ColumnDstPort, uint64(msg.DstPort))
+	bf.AppendUint(schema.ColumnForwardingStatus, uint64(msg.ForwardingStatus))
+	if len(msg.AsPath) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstASPath, msg.AsPath)
+	}
+	if len(msg.BgpCommunities) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstCommunities, msg.BgpCommunities)
+	}
+	if len(msg.MplsLabel) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnMPLSLabels, msg.MplsLabel)
+	}
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL2) {
+		bf.SrcVlan = uint16(msg.SrcVlan)
+		bf.DstVlan = uint16(msg.DstVlan)
+		if bf.SrcVlan == 0 {
+			bf.SrcVlan = uint16(msg.VlanId)
+		}
+		bf.AppendUint(schema.ColumnSrcMAC, msg.SrcMac)
+		bf.AppendUint(schema.ColumnDstMAC, msg.DstMac)
+	}
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL3L4) {
+		bf.AppendUint(schema.ColumnIPTos, uint64(msg.IpTos))
+		bf.AppendUint(schema.ColumnIPTTL, uint64(msg.IpTtl))
+		bf.AppendUint(schema.ColumnTCPFlags, uint64(msg.TcpFlags))
+		bf.AppendUint(schema.ColumnIPv6FlowLabel, uint64(msg.Ipv6FlowLabel))
+		bf.AppendUint(schema.ColumnIPFragmentID, uint64(msg.FragmentId))
+		bf.AppendUint(schema.ColumnIPFragmentOffset, uint64(msg.FragmentOffset))
+		switch msg.Proto {
+		case 1:
+			bf.AppendUint(schema.ColumnICMPv4Type, uint64(msg.IcmpType))
+			bf.AppendUint(schema.ColumnICMPv4Code, uint64(msg.IcmpCode))
+		case 58:
+			bf.AppendUint(schema.ColumnICMPv6Type, uint64(msg.IcmpType))
+			bf.AppendUint(schema.ColumnICMPv6Code, uint64(msg.IcmpCode))
+		}
+	}
+}
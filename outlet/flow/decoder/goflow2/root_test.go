@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package goflow2
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	flowpb "github.com/netsampler/goflow2/v2/pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+func TestDecode(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t).EnableAllColumns()
+	gdecoder := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+	got := []*schema.FlowMessage{}
+	finalize := func() {
+		clone := *bf
+		got = append(got, &clone)
+		bf.Clear()
+	}
+
+	msg, err := proto.Marshal(&flowpb.FlowMessage{
+		Type:             flowpb.FlowMessage_IPFIX,
+		TimeReceivedNs:   1_700_000_000_123_456_789,
+		SamplingRate:     1000,
+		SamplerAddress:   []byte{192, 0, 2, 1},
+		Bytes:            1500,
+		Packets:          2,
+		SrcAddr:          []byte{198, 51, 100, 10},
+		DstAddr:          []byte{203, 0, 113, 20},
+		Etype:            helpers.ETypeIPv4,
+		Proto:            6,
+		SrcPort:          443,
+		DstPort:          51234,
+		InIf:             10,
+		OutIf:            20,
+		SrcMac:           0x0200_0000_0001,
+		DstMac:           0x0200_0000_0002,
+		VlanId:           100,
+		IpTos:            8,
+		IpTtl:            64,
+		TcpFlags:         0x18,
+		ForwardingStatus: 64,
+		SrcAs:            65001,
+		DstAs:            65002,
+		NextHop:          []byte{192, 0, 2, 254},
+		SrcNet:           24,
+		DstNet:           23,
+		AsPath:           []uint32{65010, 65002},
+		BgpCommunities:   []uint32{65002<<16 | 100},
+	})
+	if err != nil {
+		t.Fatalf("proto.Marshal() error:\n%+v", err)
+	}
+	expected := []*schema.FlowMessage{
+		{
+			TimeReceived:    1_700_000_000,
+			SamplingRate:    1000,
+			ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+			InIf:            10,
+			OutIf:           20,
+			SrcVlan:         100,
+			SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+			DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+			NextHop:         netip.MustParseAddr("::ffff:192.0.2.254"),
+			SrcAS:           65001,
+			DstAS:           65002,
+			SrcNetMask:      24,
+			DstNetMask:      23,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:            uint64(1500),
+				schema.ColumnPackets:          uint64(2),
+				schema.ColumnEType:            uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:            uint32(6),
+				schema.ColumnSrcPort:          uint16(443),
+				schema.ColumnDstPort:          uint16(51234),
+				schema.ColumnForwardingStatus: uint32(64),
+				schema.ColumnDstASPath:        []uint32{65010, 65002},
+				schema.ColumnDstCommunities:   []uint32{65002<<16 | 100},
+				schema.ColumnSrcMAC:           uint64(0x0200_0000_0001),
+				schema.ColumnDstMAC:           uint64(0x0200_0000_0002),
+				schema.ColumnIPTos:            uint8(8),
+				schema.ColumnIPTTL:            uint8(64),
+				schema.ColumnTCPFlags:         uint16(0x18),
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		Description string
+		Payload     []byte
+	}{
+		{"length-prefixed", append(protowire.AppendVarint(nil, uint64(len(msg))), msg...)},
+		{"plain", msg},
+	} {
+		t.Run(tc.Description, func(t *testing.T) {
+			got = got[:0]
+			n, err := gdecoder.Decode(decoder.RawFlow{
+				TimeReceived: time.Unix(1_800_000_000, 0),
+				Payload:      tc.Payload,
+				Source:       netip.IPv6Unspecified(),
+			}, decoder.Option{}, bf, finalize)
+			if err != nil {
+				t.Fatalf("Decode() error:\n%+v", err)
+			}
+			if n != 1 {
+				t.Errorf("Decode() returned %d flows instead of 1", n)
+			}
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Errorf("Decode() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		got = got[:0]
+		_, err := gdecoder.Decode(decoder.RawFlow{
+			Payload: []byte("hello world!"),
+			Source:  netip.IPv6Unspecified(),
+		}, decoder.Option{}, bf, finalize)
+		if err == nil {
+			t.Fatal("Decode() did not error")
+		}
+		if len(got) > 0 {
+			t.Fatalf("Decode() produced %d flows", len(got))
+		}
+	})
+}
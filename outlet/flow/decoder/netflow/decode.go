@@ -7,7 +7,9 @@ package netflow
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"net/netip"
+	"strings"
 
 	"akvorado/common/helpers"
 	"akvorado/common/pb"
@@ -33,6 +35,22 @@ const (
 	directionReverse
 )
 
+// Cisco ASA/FTD NSEL fields. They are only valid with NetFlow v9 as they
+// conflict with enterprise-specific fields in IPFIX.
+const (
+	nselFieldIngressACLID     = 33000
+	nselFieldEgressACLID      = 33001
+	nselFieldFwExtEvent       = 33002
+	nselFieldXlateSrcAddrIPv4 = 40001
+	nselFieldXlateDstAddrIPv4 = 40002
+	nselFieldXlateSrcPort     = 40003
+	nselFieldXlateDstPort     = 40004
+	nselFieldFwEvent          = 40005
+)
+
+// NetFlow v9 scope field types (RFC 3954, section 6.1).
+const nfv9ScopeInterface = 2
+
 func (nd *Decoder) decodeNFv5(packet *netflowlegacy.PacketNetFlowV5, ts, sysUptime uint64, options decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) {
 	for _, record := range packet.Records {
 		bf.SamplingRate = uint64(packet.SamplingInterval)
@@ -65,12 +83,15 @@ func (nd *Decoder) decodeNFv5(packet *netflowlegacy.PacketNetFlowV5, ts, sysUpti
 	}
 }
 
-func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets []any, tao *templatesAndOptions, ts, sysUptime uint64, options decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) {
-	// Look for sampling rate in option data flowsets
+func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, exporter netip.Addr, flowSets []any, tao *templatesAndOptions, ts, sysUptime uint64, options decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) {
+	// Look for sampling rate and interface names in option data flowsets
 	for _, flowSet := range flowSets {
 		switch tFlowSet := flowSet.(type) {
 		case netflow.OptionsDataFlowSet:
 			for _, record := range tFlowSet.Records {
+				if nd.d.Interfaces != nil {
+					nd.decodeInterfaceOptions(version, exporter, record)
+				}
 				var (
 					samplingRate                uint32
 					samplerID                   uint64
@@ -107,6 +128,47 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 	}
 }
 
+// decodeInterfaceOptions records the interface name and description from an
+// option data record. The interface index is either in the scope or in the
+// options.
+func (nd *Decoder) decodeInterfaceOptions(version uint16, exporter netip.Addr, record netflow.OptionsDataRecord) {
+	var (
+		ifIndex           uint64
+		found             bool
+		name, description string
+	)
+	for _, field := range record.ScopesValues {
+		v, ok := field.Value.([]byte)
+		if !ok || field.PenProvided {
+			continue
+		}
+		if (version == 9 && field.Type == nfv9ScopeInterface) ||
+			(version == 10 && (field.Type == netflow.IPFIX_FIELD_ingressInterface ||
+				field.Type == netflow.IPFIX_FIELD_egressInterface)) {
+			ifIndex, found = decodeUNumber(v), true
+		}
+	}
+	for _, field := range record.OptionsValues {
+		v, ok := field.Value.([]byte)
+		if !ok || field.PenProvided {
+			continue
+		}
+		switch field.Type {
+		case netflow.IPFIX_FIELD_ingressInterface, netflow.IPFIX_FIELD_egressInterface:
+			if !found {
+				ifIndex, found = decodeUNumber(v), true
+			}
+		case netflow.IPFIX_FIELD_interfaceName:
+			name = decodeString(v)
+		case netflow.IPFIX_FIELD_interfaceDescription:
+			description = decodeString(v)
+		}
+	}
+	if found && name != "" {
+		nd.d.Interfaces.RecordInterface(exporter, uint(ifIndex), name, description)
+	}
+}
+
 func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templatesAndOptions, fields []netflow.DataField, ts, sysUptime uint64, options decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) {
 	var reversePresent *bitset.BitSet
 	for _, dir := range []direction{directionForward, directionReverse} {
@@ -124,6 +186,7 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 			// RFC 5103 handling.
 			if field.PenProvided {
 				if field.Pen != reversePEN {
+					nd.decodeCustomColumn(bf, field.Pen, field.Type, v)
 					continue
 				}
 				if dir == directionForward {
@@ -138,6 +201,15 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 				// No reverse PEN but we saw this one and so we should use the reversed value.
 				continue
 			}
+			if v, ok = normalizeField(field.Type, v, options.Quirks); !ok && dir == directionForward {
+				nd.metrics.errors.WithLabelValues(tao.Key, "malformed field").Inc()
+				nd.errLogger.Warn().
+					Str("exporter", tao.Key).
+					Uint16("field", field.Type).
+					Int("length", len(v)).
+					Msg("malformed field")
+			}
+			nd.decodeCustomColumn(bf, 0, field.Type, v)
 
 			switch field.Type {
 			// Statistics
@@ -250,14 +322,48 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 				if !nd.d.Schema.IsDisabled(schema.ColumnGroupNAT) {
 					// NAT
 					switch field.Type {
-					case netflow.IPFIX_FIELD_postNATSourceIPv4Address:
+					case netflow.IPFIX_FIELD_postNATSourceIPv4Address, netflow.IPFIX_FIELD_postNATSourceIPv6Address:
 						bf.AppendIPv6(schema.ColumnSrcAddrNAT, decoder.DecodeIP(v))
-					case netflow.IPFIX_FIELD_postNATDestinationIPv4Address:
+					case netflow.IPFIX_FIELD_postNATDestinationIPv4Address, netflow.IPFIX_FIELD_postNATDestinationIPv6Address:
 						bf.AppendIPv6(schema.ColumnDstAddrNAT, decoder.DecodeIP(v))
 					case netflow.IPFIX_FIELD_postNAPTSourceTransportPort:
 						bf.AppendUint(schema.ColumnSrcPortNAT, decodeUNumber(v))
 					case netflow.IPFIX_FIELD_postNAPTDestinationTransportPort:
 						bf.AppendUint(schema.ColumnDstPortNAT, decodeUNumber(v))
+					case netflow.IPFIX_FIELD_natEvent:
+						bf.AppendUint(schema.ColumnNATEvent, decodeUNumber(v))
+					}
+					if version == 9 {
+						switch field.Type {
+						case nselFieldXlateSrcAddrIPv4:
+							bf.AppendIPv6(schema.ColumnSrcAddrNAT, decoder.DecodeIP(v))
+						case nselFieldXlateDstAddrIPv4:
+							bf.AppendIPv6(schema.ColumnDstAddrNAT, decoder.DecodeIP(v))
+						case nselFieldXlateSrcPort:
+							bf.AppendUint(schema.ColumnSrcPortNAT, decodeUNumber(v))
+						case nselFieldXlateDstPort:
+							bf.AppendUint(schema.ColumnDstPortNAT, decodeUNumber(v))
+						}
+					}
+				}
+
+				if !nd.d.Schema.IsDisabled(schema.ColumnGroupFirewall) {
+					// Firewall
+					switch field.Type {
+					case netflow.IPFIX_FIELD_firewallEvent:
+						bf.AppendUint(schema.ColumnFirewallEvent, decodeUNumber(v))
+					}
+					if version == 9 {
+						switch field.Type {
+						case nselFieldFwEvent:
+							bf.AppendUint(schema.ColumnFirewallEvent, decodeUNumber(v))
+						case nselFieldFwExtEvent:
+							bf.AppendUint(schema.ColumnFirewallExtEvent, decodeUNumber(v))
+						case nselFieldIngressACLID:
+							bf.AppendString(schema.ColumnInACL, decodeACLID(v))
+						case nselFieldEgressACLID:
+							bf.AppendString(schema.ColumnOutACL, decodeACLID(v))
+						}
 					}
 				}
 
@@ -286,6 +392,9 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 						bf.AppendUint(schema.ColumnIPTTL, decodeUNumber(v))
 					case netflow.IPFIX_FIELD_ipClassOfService:
 						bf.AppendUint(schema.ColumnIPTos, decodeUNumber(v))
+					case netflow.IPFIX_FIELD_ipDiffServCodePoint:
+						// Only the DSCP is known, ECN bits are left unset
+						bf.AppendUint(schema.ColumnIPTos, decodeUNumber(v)<<2)
 					case netflow.IPFIX_FIELD_flowLabelIPv6:
 						bf.AppendUint(schema.ColumnIPv6FlowLabel, decodeUNumber(v))
 					case netflow.IPFIX_FIELD_tcpControlBits:
@@ -361,6 +470,52 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 	}
 }
 
+// customColumnField identifies the field a custom column is decoded from.
+type customColumnField struct {
+	enterprise uint32
+	field      uint16
+}
+
+// decodeCustomColumn decodes the provided field into the matching custom
+// column, if any.
+func (nd *Decoder) decodeCustomColumn(bf *schema.FlowMessage, enterprise uint32, field uint16, v []byte) {
+	if len(nd.customColumns) == 0 {
+		return
+	}
+	column, ok := nd.customColumns[customColumnField{enterprise, field}]
+	if !ok {
+		return
+	}
+	switch column.ParserType {
+	case "uint":
+		bf.AppendUint(column.Key, decodeUNumber(v))
+	case "string":
+		bf.AppendString(column.Key, string(bytes.TrimRight(v, "\x00")))
+	case "ip":
+		bf.AppendIPv6(column.Key, decoder.DecodeIP(v))
+	}
+}
+
+// decodeACLID decodes an NSEL ACL identifier. It is made of the ACL ID, the ACE
+// ID, and an extended ACE ID, each of them on 4 bytes. It is formatted as
+// displayed by Cisco ASA. An empty ACL identifier is decoded as an empty
+// string.
+func decodeACLID(b []byte) string {
+	if len(b) != 12 || bytes.Equal(b, make([]byte, 12)) {
+		return ""
+	}
+	return fmt.Sprintf("0x%08x-0x%08x-0x%08x",
+		binary.BigEndian.Uint32(b[0:4]),
+		binary.BigEndian.Uint32(b[4:8]),
+		binary.BigEndian.Uint32(b[8:12]))
+}
+
+// decodeString decodes a string, removing the trailing NUL bytes and the
+// surrounding spaces.
+func decodeString(b []byte) string {
+	return strings.TrimSpace(string(bytes.TrimRight(b, "\x00")))
+}
+
 func decodeUNumber(b []byte) uint64 {
 	l := len(b)
 	switch l {
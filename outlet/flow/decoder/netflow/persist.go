@@ -39,6 +39,20 @@ func (srk *samplingRateKey) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler for sequenceKey.
+func (sk sequenceKey) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%d-%d", sk.version, sk.obsDomainID), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler for sequenceKey.
+func (sk *sequenceKey) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d-%d", &sk.version, &sk.obsDomainID)
+	if err != nil {
+		return fmt.Errorf("invalid sequence key %q: %w", string(text), err)
+	}
+	return nil
+}
+
 // MarshalJSON encodes a set of NetFlow templates.
 func (t *templates) MarshalJSON() ([]byte, error) {
 	type typedTemplate struct {
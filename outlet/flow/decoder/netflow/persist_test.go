@@ -24,6 +24,8 @@ func TestMarshalUnmarshalTemplates(t *testing.T) {
 	exporter := collection.Get("::ffff:192.168.1.1")
 	exporter.SetSamplingRate(10, 300, 10, 2048)
 	exporter.SetSamplingRate(9, 301, 11, 4096)
+	exporter.CheckSequence(10, 300, 1000, 10)
+	exporter.CheckSequence(9, 301, 20, 10)
 	exporter.AddTemplate(10, 300, 300, netflow.TemplateRecord{
 		TemplateId: 300,
 		FieldCount: 2,
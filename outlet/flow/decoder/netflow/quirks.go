@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"bytes"
+
+	"github.com/netsampler/goflow2/v2/decoders/netflow"
+
+	"akvorado/outlet/flow/decoder"
+)
+
+// fieldKind is the kind of value expected for a field.
+type fieldKind int
+
+const (
+	fieldOther fieldKind = iota
+	fieldNumber
+	fieldIPv4
+	fieldIPv6
+)
+
+// fieldKinds are the kinds of the fields whose length is checked.
+var fieldKinds = map[uint16]fieldKind{
+	netflow.IPFIX_FIELD_octetDeltaCount:               fieldNumber,
+	netflow.IPFIX_FIELD_packetDeltaCount:              fieldNumber,
+	netflow.IPFIX_FIELD_postOctetDeltaCount:           fieldNumber,
+	netflow.IPFIX_FIELD_postPacketDeltaCount:          fieldNumber,
+	netflow.IPFIX_FIELD_initiatorOctets:               fieldNumber,
+	netflow.IPFIX_FIELD_responderOctets:               fieldNumber,
+	netflow.IPFIX_FIELD_samplingInterval:              fieldNumber,
+	netflow.IPFIX_FIELD_samplerRandomInterval:         fieldNumber,
+	netflow.IPFIX_FIELD_samplerId:                     fieldNumber,
+	netflow.IPFIX_FIELD_selectorId:                    fieldNumber,
+	netflow.IPFIX_FIELD_sourceTransportPort:           fieldNumber,
+	netflow.IPFIX_FIELD_destinationTransportPort:      fieldNumber,
+	netflow.IPFIX_FIELD_protocolIdentifier:            fieldNumber,
+	netflow.IPFIX_FIELD_bgpSourceAsNumber:             fieldNumber,
+	netflow.IPFIX_FIELD_bgpDestinationAsNumber:        fieldNumber,
+	netflow.IPFIX_FIELD_ingressInterface:              fieldNumber,
+	netflow.IPFIX_FIELD_egressInterface:               fieldNumber,
+	netflow.IPFIX_FIELD_ingressPhysicalInterface:      fieldNumber,
+	netflow.IPFIX_FIELD_egressPhysicalInterface:       fieldNumber,
+	netflow.IPFIX_FIELD_sourceIPv4Address:             fieldIPv4,
+	netflow.IPFIX_FIELD_destinationIPv4Address:        fieldIPv4,
+	netflow.IPFIX_FIELD_ipNextHopIPv4Address:          fieldIPv4,
+	netflow.IPFIX_FIELD_bgpNextHopIPv4Address:         fieldIPv4,
+	netflow.IPFIX_FIELD_postNATSourceIPv4Address:      fieldIPv4,
+	netflow.IPFIX_FIELD_postNATDestinationIPv4Address: fieldIPv4,
+	netflow.IPFIX_FIELD_sourceIPv6Address:             fieldIPv6,
+	netflow.IPFIX_FIELD_destinationIPv6Address:        fieldIPv6,
+	netflow.IPFIX_FIELD_ipNextHopIPv6Address:          fieldIPv6,
+	netflow.IPFIX_FIELD_bgpNextHopIPv6Address:         fieldIPv6,
+	netflow.IPFIX_FIELD_postNATSourceIPv6Address:      fieldIPv6,
+	netflow.IPFIX_FIELD_postNATDestinationIPv6Address: fieldIPv6,
+}
+
+// ipv4MappedPrefix is the prefix of an IPv4-mapped IPv6 address.
+var ipv4MappedPrefix = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff}
+
+// normalizeField checks the length of the value of a standard field. When the
+// length is unexpected, it is fixed if the enabled quirks allow it. Otherwise,
+// the value is returned unmodified with false.
+func normalizeField(fieldType uint16, v []byte, quirks decoder.Quirks) ([]byte, bool) {
+	switch fieldKinds[fieldType] {
+	case fieldNumber:
+		if len(v) >= 1 && len(v) <= 8 {
+			return v, true
+		}
+		if quirks.HuaweiNetStream {
+			if len(v) == 0 {
+				return v, true
+			}
+			if isAllZero(v[:len(v)-8]) {
+				return v[len(v)-8:], true
+			}
+		}
+		return v, false
+	case fieldIPv4:
+		if len(v) == 4 {
+			return v, true
+		}
+		if quirks.NokiaFieldLengths && len(v) > 4 {
+			if isAllZero(v[:len(v)-4]) ||
+				len(v) == 16 && bytes.Equal(v[:12], ipv4MappedPrefix) {
+				return v[len(v)-4:], true
+			}
+		}
+		return v, false
+	case fieldIPv6:
+		if len(v) == 16 {
+			return v, true
+		}
+		if quirks.NokiaFieldLengths && len(v) == 4 {
+			// IPv4 address, decoded as an IPv4-mapped IPv6 address
+			return v, true
+		}
+		return v, false
+	}
+	return v, true
+}
+
+// isAllZero tells if the provided bytes are all zeros.
+func isAllZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
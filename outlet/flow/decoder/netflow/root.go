@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/netsampler/goflow2/v2/decoders/netflow"
@@ -29,12 +30,18 @@ type Decoder struct {
 	// Templates and sampling systems
 	collection templateAndOptionCollection
 
+	// Custom columns to decode, indexed by enterprise number and field
+	customColumns map[customColumnField]schema.Column
+
 	metrics struct {
-		errors    *reporter.CounterVec
-		packets   *reporter.CounterVec
-		records   *reporter.CounterVec
-		sets      *reporter.CounterVec
-		templates *reporter.CounterVec
+		errors        *reporter.CounterVec
+		packets       *reporter.CounterVec
+		domainPackets *reporter.CounterVec
+		gaps          *reporter.CounterVec
+		lostRecords   *reporter.CounterVec
+		records       *reporter.CounterVec
+		sets          *reporter.CounterVec
+		templates     *reporter.CounterVec
 	}
 }
 
@@ -49,6 +56,12 @@ func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decode
 		nd:         nd,
 		Collection: make(map[string]*templatesAndOptions),
 	}
+	nd.customColumns = make(map[customColumnField]schema.Column)
+	for _, column := range dependencies.Schema.Columns() {
+		if column.IPFIXField != 0 {
+			nd.customColumns[customColumnField{column.IPFIXEnterprise, column.IPFIXField}] = column
+		}
+	}
 
 	nd.metrics.errors = nd.r.CounterVec(
 		reporter.CounterOpts{
@@ -64,6 +77,27 @@ func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decode
 		},
 		[]string{"exporter", "version"},
 	)
+	nd.metrics.domainPackets = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "observation_domain_packets_total",
+			Help: "Number of NetFlow packets received per observation domain.",
+		},
+		[]string{"exporter", "version", "obs_domain_id"},
+	)
+	nd.metrics.gaps = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sequence_gaps_total",
+			Help: "Number of NetFlow sequence number gaps detected.",
+		},
+		[]string{"exporter", "version", "obs_domain_id"},
+	)
+	nd.metrics.lostRecords = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "lost_records_total",
+			Help: "Estimated number of NetFlow records lost before reaching the collector.",
+		},
+		[]string{"exporter", "version", "obs_domain_id"},
+	)
 	nd.metrics.sets = nd.r.CounterVec(
 		reporter.CounterOpts{
 			Name: "sets_total",
@@ -102,6 +136,7 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 		versionStr  string
 		flowSets    []any
 		obsDomainID uint32
+		lostRecords uint32
 	)
 	version := binary.BigEndian.Uint16(in.Payload[:2])
 	buf := bytes.NewBuffer(in.Payload[2:])
@@ -111,6 +146,10 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 			bf.TimeReceived = uint32(ts)
 		}
 		bf.ExporterAddress = in.Source
+		bf.AppendUint(schema.ColumnObservationDomainID, uint64(obsDomainID))
+		// Only the first flow after a gap is flagged
+		bf.AppendUint(schema.ColumnLostRecords, uint64(lostRecords))
+		lostRecords = 0
 		finalize()
 	}
 
@@ -130,6 +169,7 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 			ts = uint64(packetNFv5.UnixSecs)
 			sysUptime = uint64(packetNFv5.SysUptime)
 		}
+		lostRecords = nd.checkSequence(tao, version, 0, packetNFv5.FlowSequence, uint32(len(packetNFv5.Records)))
 		nd.decodeNFv5(&packetNFv5, ts, sysUptime, options, bf, finalize2)
 	case 9:
 		var packetNFv9 netflow.NFv9Packet
@@ -149,7 +189,8 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 			ts = uint64(packetNFv9.UnixSeconds)
 			sysUptime = uint64(packetNFv9.SystemUptime)
 		}
-		nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, tao, ts, sysUptime, options, bf, finalize2)
+		lostRecords = nd.checkSequence(tao, version, obsDomainID, packetNFv9.SequenceNumber, countDataRecords(flowSets))
+		nd.decodeNFv9IPFIX(version, obsDomainID, in.Source, flowSets, tao, ts, sysUptime, options, bf, finalize2)
 	case 10:
 		var packetIPFIX netflow.IPFIXPacket
 		if err := netflow.DecodeMessageIPFIX(buf, tao, &packetIPFIX); err != nil {
@@ -167,7 +208,8 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 		if options.TimestampSource == pb.RawFlow_TS_NETFLOW_PACKET {
 			ts = uint64(packetIPFIX.ExportTime)
 		}
-		nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, tao, ts, sysUptime, options, bf, finalize2)
+		lostRecords = nd.checkSequence(tao, version, obsDomainID, packetIPFIX.SequenceNumber, countDataRecords(flowSets))
+		nd.decodeNFv9IPFIX(version, obsDomainID, in.Source, flowSets, tao, ts, sysUptime, options, bf, finalize2)
 	default:
 		nd.errLogger.Warn().Str("exporter", key).Msg("unknown NetFlow version")
 		nd.metrics.packets.WithLabelValues(key, "unknown").
@@ -175,6 +217,9 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 		return 0, errors.New("unkown NetFlow version")
 	}
 	nd.metrics.packets.WithLabelValues(key, versionStr).Inc()
+	if version != 5 {
+		nd.metrics.domainPackets.WithLabelValues(key, versionStr, strconv.Itoa(int(obsDomainID))).Inc()
+	}
 
 	nb := 0
 	for _, fs := range flowSets {
@@ -211,6 +256,30 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Option, bf *schema
 	return nb, nil
 }
 
+// checkSequence checks the sequence number of a packet and updates the metrics
+// when records were lost. It returns the estimated number of lost records.
+func (nd *Decoder) checkSequence(tao *templatesAndOptions, version uint16, obsDomainID uint32, sequence uint32, records uint32) uint32 {
+	lost := tao.CheckSequence(version, obsDomainID, sequence, records)
+	if lost > 0 {
+		versionStr := strconv.Itoa(int(version))
+		obsDomainStr := strconv.Itoa(int(obsDomainID))
+		nd.metrics.gaps.WithLabelValues(tao.Key, versionStr, obsDomainStr).Inc()
+		nd.metrics.lostRecords.WithLabelValues(tao.Key, versionStr, obsDomainStr).Add(float64(lost))
+	}
+	return lost
+}
+
+// countDataRecords returns the number of data records in the provided flow sets.
+func countDataRecords(flowSets []any) uint32 {
+	count := 0
+	for _, fs := range flowSets {
+		if dfs, ok := fs.(netflow.DataFlowSet); ok {
+			count += len(dfs.Records)
+		}
+	}
+	return uint32(count)
+}
+
 // Name returns the name of the decoder.
 func (nd *Decoder) Name() string {
 	return "netflow"
@@ -4,6 +4,7 @@
 package netflow
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net/netip"
 	"path/filepath"
@@ -58,6 +59,7 @@ func TestDecode(t *testing.T) {
 	// Check metrics
 	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_netflow_")
 	expectedMetrics := map[string]string{
+		`observation_domain_packets_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                          "1",
 		`packets_total{exporter="::ffff:127.0.0.1",version="9"}`:                                                               "1",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                 "1",
 		`sets_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                    "1",
@@ -82,6 +84,7 @@ func TestDecode(t *testing.T) {
 	// Check metrics
 	gotMetrics = r.GetMetrics("akvorado_outlet_flow_decoder_netflow_")
 	expectedMetrics = map[string]string{
+		`observation_domain_packets_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                          "2",
 		`packets_total{exporter="::ffff:127.0.0.1",version="9"}`:                                                               "2",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                 "1",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsDataFlowSet",version="9"}`:                                     "4",
@@ -108,6 +111,9 @@ func TestDecode(t *testing.T) {
 	// Check metrics
 	gotMetrics = r.GetMetrics("akvorado_outlet_flow_decoder_netflow_")
 	expectedMetrics = map[string]string{
+		`observation_domain_packets_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                          "3",
+		`lost_records_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                        "128",
+		`sequence_gaps_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                       "1",
 		`packets_total{exporter="::ffff:127.0.0.1",version="9"}`:                                                               "3",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                 "1",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsDataFlowSet",version="9"}`:                                     "4",
@@ -142,6 +148,7 @@ func TestDecode(t *testing.T) {
 			SrcNetMask:      24,
 			DstNetMask:      14,
 			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnLostRecords:      uint32(60),
 				schema.ColumnBytes:            uint64(1500),
 				schema.ColumnPackets:          uint64(1),
 				schema.ColumnEType:            uint32(helpers.ETypeIPv4),
@@ -220,11 +227,17 @@ func TestDecode(t *testing.T) {
 	gotMetrics = r.GetMetrics(
 		"akvorado_outlet_flow_decoder_netflow_",
 		"packets_",
+		"observation_domain_packets_",
+		"lost_records_",
+		"sequence_gaps_",
 		"sets_",
 		"records_",
 		"templates_",
 	)
 	expectedMetrics = map[string]string{
+		`observation_domain_packets_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                          "4",
+		`lost_records_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                        "188",
+		`sequence_gaps_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                       "2",
 		`packets_total{exporter="::ffff:127.0.0.1",version="9"}`:                                                               "4",
 		`records_total{exporter="::ffff:127.0.0.1",type="DataFlowSet",version="9"}`:                                            "4",
 		`records_total{exporter="::ffff:127.0.0.1",type="OptionsDataFlowSet",version="9"}`:                                     "4",
@@ -297,12 +310,14 @@ func TestDecodeSamplingRate(t *testing.T) {
 			SrcVlan:         701,
 			NextHop:         netip.MustParseAddr("::ffff:0.0.0.0"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnPackets: uint64(1),
-				schema.ColumnBytes:   uint64(160),
-				schema.ColumnProto:   uint32(6),
-				schema.ColumnSrcPort: uint16(13245),
-				schema.ColumnDstPort: uint16(10907),
-				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
+				schema.ColumnObservationDomainID: uint32(369099009),
+				schema.ColumnLostRecords:         uint32(90),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnBytes:               uint64(160),
+				schema.ColumnProto:               uint32(6),
+				schema.ColumnSrcPort:             uint16(13245),
+				schema.ColumnDstPort:             uint16(10907),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
 			},
 		},
 	}
@@ -357,6 +372,7 @@ func TestDecodeMultipleSamplingRates(t *testing.T) {
 			InIf:            97,
 			OutIf:           6,
 			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnLostRecords:      uint32(7260),
 				schema.ColumnPackets:          uint64(18),
 				schema.ColumnBytes:            uint64(1348),
 				schema.ColumnProto:            uint32(6),
@@ -506,16 +522,17 @@ func TestDecodeDataLink(t *testing.T) {
 			InIf:            582,
 			OutIf:           0,
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnBytes:        uint64(96),
-				schema.ColumnSrcPort:      uint16(55501),
-				schema.ColumnDstPort:      uint16(11777),
-				schema.ColumnEType:        uint32(helpers.ETypeIPv4),
-				schema.ColumnPackets:      uint64(1),
-				schema.ColumnProto:        uint32(17),
-				schema.ColumnSrcMAC:       uint64(0xb402165592f4),
-				schema.ColumnDstMAC:       uint64(0x182ad36e503f),
-				schema.ColumnIPFragmentID: uint32(0x8f00),
-				schema.ColumnIPTTL:        uint8(119),
+				schema.ColumnObservationDomainID: uint32(16843264),
+				schema.ColumnBytes:               uint64(96),
+				schema.ColumnSrcPort:             uint16(55501),
+				schema.ColumnDstPort:             uint16(11777),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnProto:               uint32(17),
+				schema.ColumnSrcMAC:              uint64(0xb402165592f4),
+				schema.ColumnDstMAC:              uint64(0x182ad36e503f),
+				schema.ColumnIPFragmentID:        uint32(0x8f00),
+				schema.ColumnIPTTL:               uint8(119),
 			},
 		},
 	}
@@ -564,15 +581,16 @@ func TestDecodeMPLS(t *testing.T) {
 			SamplingRate:    10,
 			OutIf:           16,
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnBytes:            uint64(89),
-				schema.ColumnPackets:          uint64(1),
-				schema.ColumnEType:            uint32(helpers.ETypeIPv6),
-				schema.ColumnForwardingStatus: uint32(66),
-				schema.ColumnIPTTL:            uint8(255),
-				schema.ColumnProto:            uint32(17),
-				schema.ColumnSrcPort:          uint16(49153),
-				schema.ColumnDstPort:          uint16(862),
-				schema.ColumnMPLSLabels:       []uint32{20005, 524250},
+				schema.ColumnObservationDomainID: uint32(16777216),
+				schema.ColumnBytes:               uint64(89),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv6),
+				schema.ColumnForwardingStatus:    uint32(66),
+				schema.ColumnIPTTL:               uint8(255),
+				schema.ColumnProto:               uint32(17),
+				schema.ColumnSrcPort:             uint16(49153),
+				schema.ColumnDstPort:             uint16(862),
+				schema.ColumnMPLSLabels:          []uint32{20005, 524250},
 			},
 		}, {
 			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
@@ -582,15 +600,16 @@ func TestDecodeMPLS(t *testing.T) {
 			SamplingRate:    10,
 			OutIf:           17,
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnBytes:            uint64(890),
-				schema.ColumnPackets:          uint64(10),
-				schema.ColumnEType:            uint32(helpers.ETypeIPv6),
-				schema.ColumnForwardingStatus: uint32(66),
-				schema.ColumnIPTTL:            uint8(255),
-				schema.ColumnProto:            uint32(17),
-				schema.ColumnSrcPort:          uint16(49153),
-				schema.ColumnDstPort:          uint16(862),
-				schema.ColumnMPLSLabels:       []uint32{20006, 524275},
+				schema.ColumnObservationDomainID: uint32(16777216),
+				schema.ColumnBytes:               uint64(890),
+				schema.ColumnPackets:             uint64(10),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv6),
+				schema.ColumnForwardingStatus:    uint32(66),
+				schema.ColumnIPTTL:               uint8(255),
+				schema.ColumnProto:               uint32(17),
+				schema.ColumnSrcPort:             uint16(49153),
+				schema.ColumnDstPort:             uint16(862),
+				schema.ColumnMPLSLabels:          []uint32{20006, 524275},
 			},
 		},
 	}
@@ -746,14 +765,16 @@ func TestDecodeNAT(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:172.16.100.198"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.89.87.1"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcPort:    uint16(35303),
-				schema.ColumnDstPort:    uint16(53),
-				schema.ColumnSrcAddrNAT: netip.MustParseAddr("::ffff:10.143.52.29"),
-				schema.ColumnDstAddrNAT: netip.MustParseAddr("::ffff:10.89.87.1"),
-				schema.ColumnSrcPortNAT: uint16(35303),
-				schema.ColumnDstPortNAT: uint16(53),
-				schema.ColumnEType:      uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:      uint32(17),
+				schema.ColumnObservationDomainID: uint32(200),
+				schema.ColumnSrcPort:             uint16(35303),
+				schema.ColumnDstPort:             uint16(53),
+				schema.ColumnSrcAddrNAT:          netip.MustParseAddr("::ffff:10.143.52.29"),
+				schema.ColumnDstAddrNAT:          netip.MustParseAddr("::ffff:10.89.87.1"),
+				schema.ColumnSrcPortNAT:          uint16(35303),
+				schema.ColumnDstPortNAT:          uint16(53),
+				schema.ColumnNATEvent:            uint8(1),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(17),
 			},
 		},
 	}
@@ -763,6 +784,92 @@ func TestDecodeNAT(t *testing.T) {
 	}
 }
 
+func TestDecodeNSEL(t *testing.T) {
+	_, nfdecoder, bf, got, finalize := setup(t, true)
+	options := decoder.Option{TimestampSource: pb.RawFlow_TS_INPUT}
+
+	// Build a NetFlow v9 packet with a template and a record, as a Cisco ASA
+	// would send.
+	fields := []struct {
+		Type  uint16
+		Value []byte
+	}{
+		{8, []byte{192, 168, 1, 10}},    // IPV4_SRC_ADDR
+		{12, []byte{198, 51, 100, 20}},  // IPV4_DST_ADDR
+		{7, []byte{0xc3, 0x50}},         // L4_SRC_PORT
+		{11, []byte{0x01, 0xbb}},        // L4_DST_PORT
+		{4, []byte{6}},                  // PROTOCOL
+		{231, []byte{0, 0, 0x05, 0xdc}}, // NF_F_FWD_FLOW_DELTA_BYTES
+		{nselFieldFwEvent, []byte{3}},
+		{nselFieldFwExtEvent, []byte{0x07, 0xe9}},
+		{nselFieldXlateSrcAddrIPv4, []byte{203, 0, 113, 5}},
+		{nselFieldXlateDstAddrIPv4, []byte{198, 51, 100, 20}},
+		{nselFieldXlateSrcPort, []byte{0x9c, 0x40}},
+		{nselFieldXlateDstPort, []byte{0x01, 0xbb}},
+		{nselFieldIngressACLID, []byte{0x4a, 0x5b, 0x6c, 0x7d, 0x12, 0x34, 0x56, 0x78, 0, 0, 0, 0}},
+		{nselFieldEgressACLID, make([]byte, 12)},
+	}
+	template := binary.BigEndian.AppendUint16(nil, 0) // template flowset
+	template = binary.BigEndian.AppendUint16(template, uint16(8+4*len(fields)))
+	template = binary.BigEndian.AppendUint16(template, 256)
+	template = binary.BigEndian.AppendUint16(template, uint16(len(fields)))
+	record := []byte{}
+	for _, field := range fields {
+		template = binary.BigEndian.AppendUint16(template, field.Type)
+		template = binary.BigEndian.AppendUint16(template, uint16(len(field.Value)))
+		record = append(record, field.Value...)
+	}
+	for len(record)%4 != 0 {
+		record = append(record, 0)
+	}
+	data := binary.BigEndian.AppendUint16(nil, 256) // data flowset
+	data = binary.BigEndian.AppendUint16(data, uint16(4+len(record)))
+	data = append(data, record...)
+	packet := []byte{
+		0, 9, // version
+		0, 2, // count
+		0, 0, 0x10, 0, // sysUptime
+		0x68, 0x40, 0x9a, 0x80, // unix seconds
+		0, 0, 0, 1, // sequence
+		0, 0, 0, 0, // source ID
+	}
+	packet = append(packet, template...)
+	packet = append(packet, data...)
+
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: packet, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		options, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	expectedFlows := []*schema.FlowMessage{
+		{
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:192.168.1.10"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:            uint64(1500),
+				schema.ColumnSrcPort:          uint16(50000),
+				schema.ColumnDstPort:          uint16(443),
+				schema.ColumnSrcAddrNAT:       netip.MustParseAddr("::ffff:203.0.113.5"),
+				schema.ColumnDstAddrNAT:       netip.MustParseAddr("::ffff:198.51.100.20"),
+				schema.ColumnSrcPortNAT:       uint16(40000),
+				schema.ColumnDstPortNAT:       uint16(443),
+				schema.ColumnFirewallEvent:    uint8(3),
+				schema.ColumnFirewallExtEvent: uint16(2025),
+				schema.ColumnInACL:            "0x4a5b6c7d-0x12345678-0x00000000",
+				schema.ColumnEType:            uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:            uint32(6),
+			},
+		},
+	}
+
+	if diff := helpers.Diff(*got, expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+}
+
 func TestDecodePhysicalInterfaces(t *testing.T) {
 	_, nfdecoder, bf, got, finalize := setup(t, true)
 	options := decoder.Option{TimestampSource: pb.RawFlow_TS_INPUT}
@@ -834,14 +941,15 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:10.10.1.4"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.10.1.1"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcMAC:  uint64(0x00e01c3c17c2),
-				schema.ColumnDstMAC:  uint64(0x001f33d98160),
-				schema.ColumnPackets: uint64(1),
-				schema.ColumnBytes:   uint64(62),
-				schema.ColumnSrcPort: uint16(56166),
-				schema.ColumnDstPort: uint16(53),
-				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:   uint32(17),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnSrcMAC:              uint64(0x00e01c3c17c2),
+				schema.ColumnDstMAC:              uint64(0x001f33d98160),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnBytes:               uint64(62),
+				schema.ColumnSrcPort:             uint16(56166),
+				schema.ColumnDstPort:             uint16(53),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(17),
 			},
 		}, {
 			// First biflow, reverse
@@ -852,14 +960,15 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:10.10.1.1"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.10.1.4"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnDstMAC:  uint64(0x00e01c3c17c2),
-				schema.ColumnSrcMAC:  uint64(0x001f33d98160),
-				schema.ColumnPackets: uint64(1),
-				schema.ColumnBytes:   uint64(128),
-				schema.ColumnDstPort: uint16(56166),
-				schema.ColumnSrcPort: uint16(53),
-				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:   uint32(17),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnDstMAC:              uint64(0x00e01c3c17c2),
+				schema.ColumnSrcMAC:              uint64(0x001f33d98160),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnBytes:               uint64(128),
+				schema.ColumnDstPort:             uint16(56166),
+				schema.ColumnSrcPort:             uint16(53),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(17),
 			},
 		}, {
 			// Second biflow, direct, no reverse
@@ -870,14 +979,15 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:10.10.1.20"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.10.1.255"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcMAC:  uint64(0x00023fec6111),
-				schema.ColumnDstMAC:  uint64(0xffffffffffff),
-				schema.ColumnPackets: uint64(1),
-				schema.ColumnBytes:   uint64(229),
-				schema.ColumnSrcPort: uint16(138),
-				schema.ColumnDstPort: uint16(138),
-				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:   uint32(17),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnSrcMAC:              uint64(0x00023fec6111),
+				schema.ColumnDstMAC:              uint64(0xffffffffffff),
+				schema.ColumnPackets:             uint64(1),
+				schema.ColumnBytes:               uint64(229),
+				schema.ColumnSrcPort:             uint16(138),
+				schema.ColumnDstPort:             uint16(138),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(17),
 			},
 		}, {
 			// Third biflow, direct
@@ -888,15 +998,16 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:10.10.1.4"),
 			DstAddr:         netip.MustParseAddr("::ffff:74.53.140.153"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcMAC:   uint64(0x00e01c3c17c2),
-				schema.ColumnDstMAC:   uint64(0x001f33d98160),
-				schema.ColumnPackets:  uint64(28),
-				schema.ColumnBytes:    uint64(21673),
-				schema.ColumnSrcPort:  uint16(1470),
-				schema.ColumnDstPort:  uint16(25),
-				schema.ColumnEType:    uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:    uint32(6),
-				schema.ColumnTCPFlags: uint16(0x1b),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnSrcMAC:              uint64(0x00e01c3c17c2),
+				schema.ColumnDstMAC:              uint64(0x001f33d98160),
+				schema.ColumnPackets:             uint64(28),
+				schema.ColumnBytes:               uint64(21673),
+				schema.ColumnSrcPort:             uint16(1470),
+				schema.ColumnDstPort:             uint16(25),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(6),
+				schema.ColumnTCPFlags:            uint16(0x1b),
 			},
 		}, {
 			// Third biflow, reverse
@@ -907,15 +1018,16 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:74.53.140.153"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.10.1.4"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcMAC:   uint64(0x001f33d98160),
-				schema.ColumnDstMAC:   uint64(0x00e01c3c17c2),
-				schema.ColumnPackets:  uint64(25),
-				schema.ColumnBytes:    uint64(1546),
-				schema.ColumnSrcPort:  uint16(25),
-				schema.ColumnDstPort:  uint16(1470),
-				schema.ColumnEType:    uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:    uint32(6),
-				schema.ColumnTCPFlags: uint16(0x1b),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnSrcMAC:              uint64(0x001f33d98160),
+				schema.ColumnDstMAC:              uint64(0x00e01c3c17c2),
+				schema.ColumnPackets:             uint64(25),
+				schema.ColumnBytes:               uint64(1546),
+				schema.ColumnSrcPort:             uint16(25),
+				schema.ColumnDstPort:             uint16(1470),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(6),
+				schema.ColumnTCPFlags:            uint16(0x1b),
 			},
 		}, {
 			// Last biflow, direct, no reverse
@@ -926,12 +1038,13 @@ func TestDecodeRFC5103(t *testing.T) {
 			SrcAddr:         netip.MustParseAddr("::ffff:192.168.1.1"),
 			DstAddr:         netip.MustParseAddr("::ffff:10.10.1.4"),
 			OtherColumns: map[schema.ColumnKey]any{
-				schema.ColumnSrcMAC:  uint64(0x001f33d98160),
-				schema.ColumnDstMAC:  uint64(0x00e01c3c17c2),
-				schema.ColumnPackets: uint64(4),
-				schema.ColumnBytes:   uint64(2304),
-				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
-				schema.ColumnProto:   uint32(1),
+				schema.ColumnObservationDomainID: uint32(1),
+				schema.ColumnSrcMAC:              uint64(0x001f33d98160),
+				schema.ColumnDstMAC:              uint64(0x00e01c3c17c2),
+				schema.ColumnPackets:             uint64(4),
+				schema.ColumnBytes:               uint64(2304),
+				schema.ColumnEType:               uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:               uint32(1),
 			},
 		},
 	}
@@ -943,3 +1056,300 @@ func TestDecodeRFC5103(t *testing.T) {
 	}
 
 }
+
+func TestDecodeCustomColumns(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := schema.DefaultConfiguration()
+	config.CustomColumns = []schema.CustomColumn{
+		{Name: "ObservationTime", Type: "UInt64", IPFIXField: 323},
+		{Name: "NATEventType", Type: "UInt8", IPFIXField: 230},
+		{Name: "Unused", Type: "String", IPFIXEnterprise: 1234, IPFIXField: 234},
+	}
+	sch, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("schema.New() error:\n%+v", err)
+	}
+	observationTime, _ := sch.LookupColumnByName("ObservationTime")
+	natEvent, _ := sch.LookupColumnByName("NATEventType")
+	nfdecoder := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+	got := []*schema.FlowMessage{}
+	finalize := func() {
+		bf.TimeReceived = 0
+		clone := *bf
+		got = append(got, &clone)
+		bf.Clear()
+	}
+
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "nat.pcap"))
+	_, err = nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		decoder.Option{TimestampSource: pb.RawFlow_TS_INPUT}, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	expectedFlows := []*schema.FlowMessage{
+		{
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:172.16.100.198"),
+			DstAddr:         netip.MustParseAddr("::ffff:10.89.87.1"),
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnSrcPort: uint16(35303),
+				schema.ColumnDstPort: uint16(53),
+				schema.ColumnEType:   uint32(helpers.ETypeIPv4),
+				schema.ColumnProto:   uint32(17),
+				observationTime.Key:  uint64(1749049740450),
+				natEvent.Key:         uint8(1),
+			},
+		},
+	}
+	if diff := helpers.Diff(got[:1], expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestDecodeQuirks(t *testing.T) {
+	// Build a NetFlow v9 packet with a template and a record using unexpected
+	// field lengths.
+	fields := []struct {
+		Type  uint16
+		Value []byte
+	}{
+		{8, []byte{0, 0, 0, 0, 192, 168, 1, 10}},                                 // IPV4_SRC_ADDR
+		{12, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 198, 51, 100, 20}}, // IPV4_DST_ADDR
+		{4, []byte{6}}, // PROTOCOL
+		{1, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x05, 0xdc}}, // IN_BYTES
+		{2, []byte{0, 0, 0, 1}}, // IN_PKTS
+	}
+	template := binary.BigEndian.AppendUint16(nil, 0) // template flowset
+	template = binary.BigEndian.AppendUint16(template, uint16(8+4*len(fields)))
+	template = binary.BigEndian.AppendUint16(template, 256)
+	template = binary.BigEndian.AppendUint16(template, uint16(len(fields)))
+	record := []byte{}
+	for _, field := range fields {
+		template = binary.BigEndian.AppendUint16(template, field.Type)
+		template = binary.BigEndian.AppendUint16(template, uint16(len(field.Value)))
+		record = append(record, field.Value...)
+	}
+	for len(record)%4 != 0 {
+		record = append(record, 0)
+	}
+	data := binary.BigEndian.AppendUint16(nil, 256) // data flowset
+	data = binary.BigEndian.AppendUint16(data, uint16(4+len(record)))
+	data = append(data, record...)
+	packet := []byte{
+		0, 9, // version
+		0, 2, // count
+		0, 0, 0x10, 0, // sysUptime
+		0x68, 0x40, 0x9a, 0x80, // unix seconds
+		0, 0, 0, 1, // sequence
+		0, 0, 0, 0, // source ID
+	}
+	packet = append(packet, template...)
+	packet = append(packet, data...)
+
+	cases := []struct {
+		Pos             helpers.Pos
+		Quirks          decoder.Quirks
+		ExpectedFlows   []*schema.FlowMessage
+		ExpectedMetrics map[string]string
+	}{
+		{
+			Pos:    helpers.Mark(),
+			Quirks: decoder.Quirks{},
+			ExpectedFlows: []*schema.FlowMessage{
+				{
+					ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+					DstAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnPackets: uint64(1),
+						schema.ColumnEType:   uint32(helpers.ETypeIPv4),
+						schema.ColumnProto:   uint32(6),
+					},
+				},
+			},
+			ExpectedMetrics: map[string]string{
+				`errors_total{error="malformed field",exporter="::ffff:127.0.0.1"}`: "3",
+			},
+		}, {
+			Pos:    helpers.Mark(),
+			Quirks: decoder.Quirks{HuaweiNetStream: true},
+			ExpectedFlows: []*schema.FlowMessage{
+				{
+					ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+					DstAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:   uint64(1500),
+						schema.ColumnPackets: uint64(1),
+						schema.ColumnEType:   uint32(helpers.ETypeIPv4),
+						schema.ColumnProto:   uint32(6),
+					},
+				},
+			},
+			ExpectedMetrics: map[string]string{
+				`errors_total{error="malformed field",exporter="::ffff:127.0.0.1"}`: "2",
+			},
+		}, {
+			Pos:    helpers.Mark(),
+			Quirks: decoder.Quirks{HuaweiNetStream: true, NokiaFieldLengths: true},
+			ExpectedFlows: []*schema.FlowMessage{
+				{
+					ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+					SrcAddr:         netip.MustParseAddr("::ffff:192.168.1.10"),
+					DstAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:   uint64(1500),
+						schema.ColumnPackets: uint64(1),
+						schema.ColumnEType:   uint32(helpers.ETypeIPv4),
+						schema.ColumnProto:   uint32(6),
+					},
+				},
+			},
+			ExpectedMetrics: map[string]string{},
+		},
+	}
+	for _, tc := range cases {
+		r, nfdecoder, bf, got, finalize := setup(t, true)
+		options := decoder.Option{TimestampSource: pb.RawFlow_TS_INPUT, Quirks: tc.Quirks}
+		_, err := nfdecoder.Decode(
+			decoder.RawFlow{Payload: packet, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+			options, bf, finalize)
+		if err != nil {
+			t.Fatalf("%sDecode() error:\n%+v", tc.Pos, err)
+		}
+		if diff := helpers.Diff(*got, tc.ExpectedFlows); diff != "" {
+			t.Errorf("%sDecode() (-got, +want):\n%s", tc.Pos, diff)
+		}
+		gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_netflow_", "errors_total")
+		if diff := helpers.Diff(gotMetrics, tc.ExpectedMetrics); diff != "" {
+			t.Errorf("%sMetrics (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
+
+type interfaceRecord struct {
+	ExporterIP        netip.Addr
+	IfIndex           uint
+	Name, Description string
+}
+
+type interfaceRecorder []interfaceRecord
+
+func (ir *interfaceRecorder) RecordInterface(exporterIP netip.Addr, ifIndex uint, name, description string) {
+	*ir = append(*ir, interfaceRecord{exporterIP, ifIndex, name, description})
+}
+
+func TestDecodeInterfaceNames(t *testing.T) {
+	type field struct {
+		Type  uint16
+		Value []byte
+	}
+	fixed := func(s string, n int) []byte {
+		b := make([]byte, n)
+		copy(b, s)
+		return b
+	}
+	options := []field{
+		{82, fixed("Gi0/0/1", 16)},           // interfaceName
+		{83, fixed(" Transit: Cogent ", 32)}, // interfaceDescription
+	}
+	exporter := netip.MustParseAddr("::ffff:127.0.0.1")
+
+	cases := []struct {
+		Pos     helpers.Pos
+		Version uint16
+		Scopes  []field
+		Options []field
+	}{
+		{
+			Pos:     helpers.Mark(),
+			Version: 10,
+			Scopes:  []field{{10, []byte{0, 0, 0, 5}}}, // ingressInterface
+			Options: options,
+		}, {
+			Pos:     helpers.Mark(),
+			Version: 9,
+			Scopes:  []field{{2, []byte{0, 0, 0, 5}}}, // Interface
+			Options: options,
+		}, {
+			Pos:     helpers.Mark(),
+			Version: 9,
+			Scopes:  []field{{1, []byte{10, 0, 0, 1}}}, // System
+			Options: append([]field{{10, []byte{0, 5}}}, options...),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("v%d", tc.Version), func(t *testing.T) {
+			r := reporter.NewMock(t)
+			sch := schema.NewMock(t)
+			recorder := interfaceRecorder{}
+			nfdecoder := New(r, decoder.Dependencies{Schema: sch, Interfaces: &recorder})
+
+			// Build a packet with an options template and an options record.
+			var template []byte
+			if tc.Version == 10 {
+				template = binary.BigEndian.AppendUint16(nil, 3) // options template set
+				template = binary.BigEndian.AppendUint16(template, uint16(10+4*(len(tc.Scopes)+len(tc.Options))))
+				template = binary.BigEndian.AppendUint16(template, 256)
+				template = binary.BigEndian.AppendUint16(template, uint16(len(tc.Scopes)+len(tc.Options)))
+				template = binary.BigEndian.AppendUint16(template, uint16(len(tc.Scopes)))
+			} else {
+				template = binary.BigEndian.AppendUint16(nil, 1) // options template flowset
+				template = binary.BigEndian.AppendUint16(template, uint16(10+4*(len(tc.Scopes)+len(tc.Options))))
+				template = binary.BigEndian.AppendUint16(template, 256)
+				template = binary.BigEndian.AppendUint16(template, uint16(4*len(tc.Scopes)))
+				template = binary.BigEndian.AppendUint16(template, uint16(4*len(tc.Options)))
+			}
+			record := []byte{}
+			for _, field := range append(tc.Scopes, tc.Options...) {
+				template = binary.BigEndian.AppendUint16(template, field.Type)
+				template = binary.BigEndian.AppendUint16(template, uint16(len(field.Value)))
+				record = append(record, field.Value...)
+			}
+			for len(template)%4 != 0 {
+				template = append(template, 0)
+				binary.BigEndian.PutUint16(template[2:], uint16(len(template)))
+			}
+			data := binary.BigEndian.AppendUint16(nil, 256) // data flowset
+			data = binary.BigEndian.AppendUint16(data, uint16(4+len(record)))
+			data = append(data, record...)
+			var packet []byte
+			if tc.Version == 10 {
+				packet = []byte{
+					0, 10, // version
+					0, 0, // length
+					0x68, 0x40, 0x9a, 0x80, // export time
+					0, 0, 0, 1, // sequence
+					0, 0, 0, 0, // observation domain ID
+				}
+			} else {
+				packet = []byte{
+					0, 9, // version
+					0, 2, // count
+					0, 0, 0x10, 0, // sysUptime
+					0x68, 0x40, 0x9a, 0x80, // unix seconds
+					0, 0, 0, 1, // sequence
+					0, 0, 0, 0, // source ID
+				}
+			}
+			packet = append(packet, template...)
+			packet = append(packet, data...)
+			if tc.Version == 10 {
+				binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+			}
+
+			bf := sch.NewFlowMessage()
+			_, err := nfdecoder.Decode(
+				decoder.RawFlow{Payload: packet, Source: exporter},
+				decoder.Option{TimestampSource: pb.RawFlow_TS_INPUT}, bf, func() {})
+			if err != nil {
+				t.Fatalf("%sDecode() error:\n%+v", tc.Pos, err)
+			}
+			expected := interfaceRecorder{{exporter, 5, "Gi0/0/1", "Transit: Cogent"}}
+			if diff := helpers.Diff(recorder, expected); diff != "" {
+				t.Fatalf("%sRecordInterface() (-got, +want):\n%s", tc.Pos, diff)
+			}
+		})
+	}
+}
@@ -24,10 +24,12 @@ type templatesAndOptions struct {
 	nd               *Decoder
 	templateLock     sync.RWMutex
 	samplingRateLock sync.RWMutex
+	sequenceLock     sync.Mutex
 
 	Key           string
 	Templates     templates
 	SamplingRates map[samplingRateKey]uint32
+	Sequences     map[sequenceKey]uint32
 }
 
 // templates is a mapping to one of netflow.TemplateRecord,
@@ -48,6 +50,13 @@ type samplingRateKey struct {
 	samplerID   uint64
 }
 
+// sequenceKey is the key structure to access the next expected sequence
+// number.
+type sequenceKey struct {
+	version     uint16
+	obsDomainID uint32
+}
+
 var (
 	_ netflow.NetFlowTemplateSystem = &templatesAndOptions{}
 )
@@ -66,6 +75,7 @@ func (c *templateAndOptionCollection) Get(key string) *templatesAndOptions {
 		Key:           key,
 		Templates:     make(map[templateKey]any),
 		SamplingRates: make(map[samplingRateKey]uint32),
+		Sequences:     make(map[sequenceKey]uint32),
 	}
 	c.Collection[key] = t
 	return t
@@ -139,3 +149,33 @@ func (t *templatesAndOptions) SetSamplingRate(version uint16, obsDomainID uint32
 		samplerID:   samplerID,
 	}] = samplingRate
 }
+
+// CheckSequence records the sequence number of a packet containing the
+// provided number of data records and returns the estimated number of records
+// lost since the previous packet. For NetFlow v5 and IPFIX, the sequence number
+// counts records. For NetFlow v9, it counts packets and the number of lost
+// records is estimated from the size of the current packet. A sequence number
+// going backward is handled as a reset.
+func (t *templatesAndOptions) CheckSequence(version uint16, obsDomainID uint32, sequence uint32, records uint32) uint32 {
+	key := sequenceKey{version: version, obsDomainID: obsDomainID}
+	next := sequence + records
+	if version == 9 {
+		next = sequence + 1
+	}
+	t.sequenceLock.Lock()
+	if t.Sequences == nil {
+		// Not present in state persisted by older versions
+		t.Sequences = make(map[sequenceKey]uint32)
+	}
+	expected, ok := t.Sequences[key]
+	t.Sequences[key] = next
+	t.sequenceLock.Unlock()
+	gap := sequence - expected
+	if !ok || gap == 0 || gap >= 1<<31 {
+		return 0
+	}
+	if version == 9 {
+		return gap * max(records, 1)
+	}
+	return gap
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+func TestCheckSequence(t *testing.T) {
+	r := reporter.NewMock(t)
+	nfdecoder := New(r, decoder.Dependencies{Schema: schema.NewMock(t)})
+	exporter := nfdecoder.(*Decoder).collection.Get("::ffff:192.168.1.1")
+
+	cases := []struct {
+		Pos         helpers.Pos
+		Version     uint16
+		ObsDomainID uint32
+		Sequence    uint32
+		Records     uint32
+		Expected    uint32
+	}{
+		// IPFIX: sequence number counts data records
+		{helpers.Mark(), 10, 1, 1000, 10, 0},
+		{helpers.Mark(), 10, 1, 1010, 10, 0},
+		{helpers.Mark(), 10, 1, 1025, 10, 5},
+		// Another observation domain is tracked separately
+		{helpers.Mark(), 10, 2, 50, 10, 0},
+		{helpers.Mark(), 10, 2, 60, 10, 0},
+		{helpers.Mark(), 10, 1, 1035, 10, 0},
+		// Reset
+		{helpers.Mark(), 10, 1, 0, 10, 0},
+		{helpers.Mark(), 10, 1, 10, 10, 0},
+		// Wrap around
+		{helpers.Mark(), 10, 3, 0xfffffffa, 4, 0},
+		{helpers.Mark(), 10, 3, 2, 4, 4},
+		// NetFlow v9: sequence number counts packets
+		{helpers.Mark(), 9, 1, 100, 20, 0},
+		{helpers.Mark(), 9, 1, 101, 20, 0},
+		{helpers.Mark(), 9, 1, 104, 20, 40},
+		{helpers.Mark(), 9, 1, 106, 0, 1},
+		// NetFlow v5: sequence number counts flows
+		{helpers.Mark(), 5, 0, 100, 30, 0},
+		{helpers.Mark(), 5, 0, 160, 30, 30},
+	}
+	for _, tc := range cases {
+		got := exporter.CheckSequence(tc.Version, tc.ObsDomainID, tc.Sequence, tc.Records)
+		if got != tc.Expected {
+			t.Errorf("%sCheckSequence(%d, %d, %d, %d) == %d, expected %d", tc.Pos,
+				tc.Version, tc.ObsDomainID, tc.Sequence, tc.Records, got, tc.Expected)
+		}
+	}
+}
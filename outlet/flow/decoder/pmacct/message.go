@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pmacct
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// message is a flow as exported by pmacct with the JSON format. Only the
+// primitives mapped to the Akvorado schema are decoded.
+type message struct {
+	EventType string `json:"event_type"`
+	PeerIPSrc string `json:"peer_ip_src"`
+	PeerIPDst string `json:"peer_ip_dst"`
+
+	IfaceIn      number `json:"iface_in"`
+	IfaceOut     number `json:"iface_out"`
+	SamplingRate number `json:"sampling_rate"`
+	Bytes        number `json:"bytes"`
+	Packets      number `json:"packets"`
+
+	IPSrc   string   `json:"ip_src"`
+	IPDst   string   `json:"ip_dst"`
+	MaskSrc number   `json:"mask_src"`
+	MaskDst number   `json:"mask_dst"`
+	PortSrc number   `json:"port_src"`
+	PortDst number   `json:"port_dst"`
+	IPProto protocol `json:"ip_proto"`
+	EType   etype    `json:"etype"`
+	ToS     number   `json:"tos"`
+	TCP     number   `json:"tcp_flags"`
+
+	ASSrc  number `json:"as_src"`
+	ASDst  number `json:"as_dst"`
+	ASPath string `json:"as_path"`
+	Comms  string `json:"comms"`
+
+	MACSrc string `json:"mac_src"`
+	MACDst string `json:"mac_dst"`
+	VLAN   number `json:"vlan"`
+}
+
+// number is an unsigned integer. pmacct may encode it as a JSON number or as a
+// string.
+type number uint64
+
+// UnmarshalJSON decodes a number.
+func (n *number) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", data)
+	}
+	*n = number(v)
+	return nil
+}
+
+// protocols maps the protocol names used by pmacct to their numbers.
+var protocols = map[string]uint8{
+	"icmp":      1,
+	"igmp":      2,
+	"tcp":       6,
+	"udp":       17,
+	"gre":       47,
+	"esp":       50,
+	"ah":        51,
+	"ipv6-icmp": 58,
+	"icmpv6":    58,
+	"sctp":      132,
+}
+
+// protocol is an IP protocol. pmacct encodes it with its name, unless
+// configured to use numbers. Unknown names are decoded as 0.
+type protocol uint8
+
+// UnmarshalJSON decodes a protocol.
+func (p *protocol) UnmarshalJSON(data []byte) error {
+	var n number
+	if err := n.UnmarshalJSON(data); err == nil {
+		*p = protocol(n)
+		return nil
+	}
+	*p = protocol(protocols[strings.ToLower(string(bytes.Trim(data, `"`)))])
+	return nil
+}
+
+// etype is an Ethernet type. pmacct encodes it as an hexadecimal string.
+type etype uint16
+
+// UnmarshalJSON decodes an Ethernet type.
+func (e *etype) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*e = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(strings.TrimPrefix(string(data), "0x"), 16, 16)
+	if err != nil {
+		return fmt.Errorf("invalid Ethernet type %q", data)
+	}
+	*e = etype(v)
+	return nil
+}
+
+// parseMAC parses a MAC address into an integer. It returns 0 on error.
+func parseMAC(input string) uint64 {
+	hw, err := net.ParseMAC(input)
+	if err != nil || len(hw) != 6 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(append([]byte{0, 0}, hw...))
+}
+
+// parseASPath parses an AS path. ASNs are separated by spaces. AS sets are
+// flattened.
+func parseASPath(input string) []uint32 {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == '{' || r == '}' || r == ','
+	})
+	path := make([]uint32, 0, len(fields))
+	for _, field := range fields {
+		asn, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			continue
+		}
+		path = append(path, uint32(asn))
+	}
+	return path
+}
+
+// parseCommunities parses standard communities. They are separated by spaces
+// and formatted as "ASN:value".
+func parseCommunities(input string) []uint32 {
+	fields := strings.Fields(input)
+	communities := make([]uint32, 0, len(fields))
+	for _, field := range fields {
+		asn, value, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		high, err1 := strconv.ParseUint(asn, 10, 16)
+		low, err2 := strconv.ParseUint(value, 10, 16)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		communities = append(communities, uint32(high<<16|low))
+	}
+	return communities
+}
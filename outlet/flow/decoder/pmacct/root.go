@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package pmacct handles decoding of flows produced by pmacct in its JSON
+// format.
+package pmacct
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+// Decoder contains the state for the pmacct decoder.
+type Decoder struct {
+	r         *reporter.Reporter
+	d         decoder.Dependencies
+	errLogger reporter.Logger
+}
+
+// New instantiates a new pmacct decoder.
+func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decoder {
+	return &Decoder{
+		r:         r,
+		d:         dependencies,
+		errLogger: r.Sample(reporter.BurstSampler(30*time.Second, 3)),
+	}
+}
+
+// Name returns the name of the decoder.
+func (nd *Decoder) Name() string {
+	return "pmacct"
+}
+
+// Decode decodes pmacct JSON messages. A payload may contain several messages.
+// Messages whose event type is not "purge" are ignored. pmacct timestamps
+// depend on its configuration and are not used: flows are timestamped with the
+// reception time.
+func (nd *Decoder) Decode(in decoder.RawFlow, _ decoder.Option, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) (int, error) {
+	ts := uint32(in.TimeReceived.UTC().Unix())
+	jd := json.NewDecoder(bytes.NewReader(in.Payload))
+	count := 0
+	for {
+		var msg message
+		if err := jd.Decode(&msg); errors.Is(err, io.EOF) {
+			return count, nil
+		} else if err != nil {
+			nd.errLogger.Err(err).Str("exporter", in.Source.String()).Msg("error while decoding pmacct message")
+			return count, fmt.Errorf("error while decoding pmacct message: %w", err)
+		}
+		if msg.EventType != "" && msg.EventType != "purge" {
+			continue
+		}
+		nd.decode(&msg, bf)
+		bf.TimeReceived = ts
+		finalize()
+		count++
+	}
+}
+
+// decode maps the fields of a pmacct message to the flow message.
+func (nd *Decoder) decode(msg *message, bf *schema.FlowMessage) {
+	bf.ExporterAddress = parseIP(msg.PeerIPSrc)
+	bf.SamplingRate = uint64(msg.SamplingRate)
+	if bf.SamplingRate == 0 {
+		bf.SamplingRate = 1
+	}
+	bf.InIf = uint32(msg.IfaceIn)
+	bf.OutIf = uint32(msg.IfaceOut)
+	bf.SrcAddr = parseIP(msg.IPSrc)
+	bf.DstAddr = parseIP(msg.IPDst)
+	bf.NextHop = parseIP(msg.PeerIPDst)
+	bf.SrcNetMask = uint8(msg.MaskSrc)
+	bf.DstNetMask = uint8(msg.MaskDst)
+	bf.SrcAS = uint32(msg.ASSrc)
+	bf.DstAS = uint32(msg.ASDst)
+	bf.AppendUint(schema.ColumnBytes, uint64(msg.Bytes))
+	bf.AppendUint(schema.ColumnPackets, uint64(msg.Packets))
+	etype := uint64(msg.EType)
+	if etype == 0 {
+		switch {
+		case bf.SrcAddr.Is4In6():
+			etype = helpers.ETypeIPv4
+		case bf.SrcAddr.IsValid():
+			etype = helpers.ETypeIPv6
+		}
+	}
+	bf.AppendUint(schema.ColumnEType, etype)
+	bf.AppendUint(schema.ColumnProto, uint64(msg.IPProto))
+	bf.AppendUint(schema.ColumnSrcPort, uint64(msg.PortSrc))
+	bf.AppendUint(schema.ColumnDstPort, uint64(msg.PortDst))
+	if path := parseASPath(msg.ASPath); len(path) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstASPath, path)
+	}
+	if communities := parseCommunities(msg.Comms); len(communities) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstCommunities, communities)
+	}
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL2) {
+		bf.SrcVlan = uint16(msg.VLAN)
+		bf.AppendUint(schema.ColumnSrcMAC, parseMAC(msg.MACSrc))
+		bf.AppendUint(schema.ColumnDstMAC, parseMAC(msg.MACDst))
+	}
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL3L4) {
+		bf.AppendUint(schema.ColumnIPTos, uint64(msg.ToS))
+		bf.AppendUint(schema.ColumnTCPFlags, uint64(msg.TCP))
+	}
+}
+
+// parseIP parses an IP address. It returns an invalid address on error.
+func parseIP(input string) netip.Addr {
+	ip, err := netip.ParseAddr(input)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return helpers.AddrTo6(ip)
+}
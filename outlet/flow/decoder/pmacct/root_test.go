@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pmacct
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+func TestDecode(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t).EnableAllColumns()
+	pdecoder := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+	got := []*schema.FlowMessage{}
+	finalize := func() {
+		clone := *bf
+		got = append(got, &clone)
+		bf.Clear()
+	}
+
+	cases := []struct {
+		Pos      helpers.Pos
+		Payload  string
+		Expected []*schema.FlowMessage
+		Error    bool
+	}{
+		{
+			Pos: helpers.Mark(),
+			Payload: `{"event_type": "purge", "peer_ip_src": "192.0.2.1", "peer_ip_dst": "192.0.2.254",
+ "iface_in": 10, "iface_out": 20, "ip_src": "198.51.100.10", "ip_dst": "203.0.113.20",
+ "mask_src": 24, "mask_dst": 23, "port_src": 443, "port_dst": 51234, "ip_proto": "tcp",
+ "tos": 8, "tcp_flags": "24", "as_src": 65001, "as_dst": 65002,
+ "as_path": "65010 65002", "comms": "65002:100 65002:200",
+ "mac_src": "02:00:00:00:00:01", "mac_dst": "02:00:00:00:00:02", "vlan": 100,
+ "sampling_rate": 1000, "packets": 2, "bytes": 1500,
+ "timestamp_start": "2026-01-15 10:00:00.000000"}`,
+			Expected: []*schema.FlowMessage{
+				{
+					TimeReceived:    1_800_000_000,
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+					InIf:            10,
+					OutIf:           20,
+					SrcVlan:         100,
+					SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+					DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+					NextHop:         netip.MustParseAddr("::ffff:192.0.2.254"),
+					SrcAS:           65001,
+					DstAS:           65002,
+					SrcNetMask:      24,
+					DstNetMask:      23,
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:          uint64(1500),
+						schema.ColumnPackets:        uint64(2),
+						schema.ColumnEType:          uint32(helpers.ETypeIPv4),
+						schema.ColumnProto:          uint32(6),
+						schema.ColumnSrcPort:        uint16(443),
+						schema.ColumnDstPort:        uint16(51234),
+						schema.ColumnDstASPath:      []uint32{65010, 65002},
+						schema.ColumnDstCommunities: []uint32{65002<<16 | 100, 65002<<16 | 200},
+						schema.ColumnSrcMAC:         uint64(0x0200_0000_0001),
+						schema.ColumnDstMAC:         uint64(0x0200_0000_0002),
+						schema.ColumnIPTos:          uint8(8),
+						schema.ColumnTCPFlags:       uint16(24),
+					},
+				},
+			},
+		}, {
+			Pos: helpers.Mark(),
+			Payload: `{"event_type": "purge_init", "writer_pid": 1234}
+{"peer_ip_src": "2001:db8::1", "ip_src": "2001:db8:1::10", "ip_dst": "2001:db8:2::20",
+ "ip_proto": 58, "packets": 1, "bytes": 100}
+{"event_type": "purge", "peer_ip_src": "2001:db8::1", "ip_src": "2001:db8:1::10",
+ "ip_dst": "2001:db8:2::20", "ip_proto": "udp", "etype": "86dd", "packets": 1, "bytes": 200}
+{"event_type": "purge_close", "purged_entries": 2}`,
+			Expected: []*schema.FlowMessage{
+				{
+					TimeReceived:    1_800_000_000,
+					SamplingRate:    1,
+					ExporterAddress: netip.MustParseAddr("2001:db8::1"),
+					SrcAddr:         netip.MustParseAddr("2001:db8:1::10"),
+					DstAddr:         netip.MustParseAddr("2001:db8:2::20"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:   uint64(100),
+						schema.ColumnPackets: uint64(1),
+						schema.ColumnEType:   uint32(helpers.ETypeIPv6),
+						schema.ColumnProto:   uint32(58),
+					},
+				}, {
+					TimeReceived:    1_800_000_000,
+					SamplingRate:    1,
+					ExporterAddress: netip.MustParseAddr("2001:db8::1"),
+					SrcAddr:         netip.MustParseAddr("2001:db8:1::10"),
+					DstAddr:         netip.MustParseAddr("2001:db8:2::20"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:   uint64(200),
+						schema.ColumnPackets: uint64(1),
+						schema.ColumnEType:   uint32(helpers.ETypeIPv6),
+						schema.ColumnProto:   uint32(17),
+					},
+				},
+			},
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: `{"peer_ip_src": "192.0.2.1", "bytes": "lot"}`,
+			Error:   true,
+		}, {
+			Pos:     helpers.Mark(),
+			Payload: `hello world!`,
+			Error:   true,
+		},
+	}
+	for _, tc := range cases {
+		got = got[:0]
+		n, err := pdecoder.Decode(decoder.RawFlow{
+			TimeReceived: time.Unix(1_800_000_000, 0),
+			Payload:      []byte(tc.Payload),
+			Source:       netip.IPv6Unspecified(),
+		}, decoder.Option{}, bf, finalize)
+		if err != nil && !tc.Error {
+			t.Fatalf("%sDecode() error:\n%+v", tc.Pos, err)
+		} else if err == nil && tc.Error {
+			t.Fatalf("%sDecode() did not error", tc.Pos)
+		}
+		if n != len(tc.Expected) {
+			t.Errorf("%sDecode() returned %d flows instead of %d", tc.Pos, n, len(tc.Expected))
+		}
+		if diff := helpers.Diff(got, tc.Expected, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%sDecode() (-got, +want):\n%s", tc.Pos, diff)
+		}
+	}
+}
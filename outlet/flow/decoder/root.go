@@ -30,11 +30,31 @@ type Decoder interface {
 type Option struct {
 	// TimestampSource is a selector for how to set the TimeReceived.
 	TimestampSource pb.RawFlow_TimestampSource
+	// Quirks are the compatibility shims to enable for the exporter.
+	Quirks Quirks
+}
+
+// Quirks are compatibility shims for exporters not following the
+// specifications. They are enabled per exporter.
+type Quirks struct {
+	// HuaweiNetStream accepts numeric fields encoded with a variable length
+	// larger than expected, as sent by Huawei NetStream.
+	HuaweiNetStream bool
+	// NokiaFieldLengths accepts address fields with a length not matching the
+	// specification, as sent by Nokia SR OS.
+	NokiaFieldLengths bool
 }
 
 // Dependencies are the dependencies for the decoder
 type Dependencies struct {
-	Schema *schema.Component
+	Schema     *schema.Component
+	Interfaces InterfaceRecorder // optional
+}
+
+// InterfaceRecorder is the interface to record the interface names and
+// descriptions sent by exporters.
+type InterfaceRecorder interface {
+	RecordInterface(exporterIP netip.Addr, ifIndex uint, name, description string)
 }
 
 // RawFlow is an undecoded flow.
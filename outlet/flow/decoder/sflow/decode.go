@@ -59,6 +59,21 @@ func (nd *Decoder) decode(packet sflow.Packet, bf *schema.FlowMessage, finalize
 				forwardingStatus = 128
 			case interfaceFormatMultiple:
 			}
+		case sflow.DropSample:
+			if !nd.decodeDrops {
+				continue
+			}
+			// Drop notifications are not sampled. The output interface
+			// has its most significant bit set when there are multiple
+			// output interfaces.
+			records = flowSample.Records
+			bf.SamplingRate = 1
+			bf.InIf = flowSample.Input
+			if flowSample.Output>>31 == 0 {
+				bf.OutIf = flowSample.Output
+			}
+			forwardingStatus = 128
+			bf.AppendString(schema.ColumnDropReason, dropReason(flowSample.Reason))
 		}
 
 		if bf.InIf == interfaceLocal {
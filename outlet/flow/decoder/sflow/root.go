@@ -23,6 +23,9 @@ type Decoder struct {
 	d         decoder.Dependencies
 	errLogger reporter.Logger
 
+	// decodeDrops tells if drop notifications should be decoded
+	decodeDrops bool
+
 	metrics struct {
 		errors                *reporter.CounterVec
 		stats                 *reporter.CounterVec
@@ -38,6 +41,9 @@ func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decode
 		d:         dependencies,
 		errLogger: r.Sample(reporter.BurstSampler(30*time.Second, 3)),
 	}
+	if column, ok := dependencies.Schema.LookupColumnByKey(schema.ColumnDropReason); ok && !column.Disabled {
+		nd.decodeDrops = true
+	}
 
 	nd.metrics.errors = nd.r.CounterVec(
 		reporter.CounterOpts{
@@ -106,6 +112,11 @@ func (nd *Decoder) Decode(in decoder.RawFlow, _ decoder.Option, bf *schema.FlowM
 				Inc()
 			nd.metrics.sampleRecordsStatsSum.WithLabelValues(key, agent, version, "CounterSample").
 				Add(float64(len(sConv.Records)))
+		case sflow.DropSample:
+			nd.metrics.sampleStatsSum.WithLabelValues(key, agent, version, "DropSample").
+				Inc()
+			nd.metrics.sampleRecordsStatsSum.WithLabelValues(key, agent, version, "DropSample").
+				Add(float64(len(sConv.Records)))
 		}
 	}
 
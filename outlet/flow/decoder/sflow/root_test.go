@@ -551,4 +551,82 @@ func TestDecode(t *testing.T) {
 			t.Fatalf("Decode() (-got, +want):\n%s", diff)
 		}
 	})
+
+	t.Run("drop notifications", func(t *testing.T) {
+		got = got[:0]
+		data := helpers.ReadPcapL4(t, filepath.Join("testdata", "data-drops.pcap"))
+		_, err := sdecoder.Decode(
+			decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+			options, bf, finalize)
+		if err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+		expectedFlows := []*schema.FlowMessage{
+			{
+				SamplingRate:    1,
+				InIf:            10,
+				SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+				DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnBytes:            uint64(40),
+					schema.ColumnPackets:          uint64(1),
+					schema.ColumnEType:            uint32(helpers.ETypeIPv4),
+					schema.ColumnProto:            uint32(6),
+					schema.ColumnSrcPort:          uint16(51234),
+					schema.ColumnDstPort:          uint16(443),
+					schema.ColumnSrcMAC:           uint64(0x001122334455),
+					schema.ColumnDstMAC:           uint64(0x66778899aabb),
+					schema.ColumnIPTTL:            uint8(63),
+					schema.ColumnIPFragmentID:     uint32(0x1234),
+					schema.ColumnTCPFlags:         uint16(0x2),
+					schema.ColumnForwardingStatus: uint32(128),
+					schema.ColumnDropReason:       "acl",
+				},
+			}, {
+				SamplingRate:    1,
+				InIf:            11,
+				OutIf:           12,
+				SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+				DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnBytes:            uint64(40),
+					schema.ColumnPackets:          uint64(1),
+					schema.ColumnEType:            uint32(helpers.ETypeIPv4),
+					schema.ColumnProto:            uint32(6),
+					schema.ColumnSrcPort:          uint16(51234),
+					schema.ColumnDstPort:          uint16(443),
+					schema.ColumnSrcMAC:           uint64(0x001122334455),
+					schema.ColumnDstMAC:           uint64(0x66778899aabb),
+					schema.ColumnIPTTL:            uint8(63),
+					schema.ColumnIPFragmentID:     uint32(0x1234),
+					schema.ColumnTCPFlags:         uint16(0x2),
+					schema.ColumnForwardingStatus: uint32(128),
+					schema.ColumnDropReason:       "dst_net_unknown",
+				},
+			},
+		}
+
+		if diff := helpers.Diff(got, expectedFlows); diff != "" {
+			t.Fatalf("Decode() (-got, +want):\n%s", diff)
+		}
+	})
+}
+
+func TestDecodeDropsDisabled(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	sdecoder := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+	count := 0
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "data-drops.pcap"))
+	if _, err := sdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		decoder.Option{}, bf, func() { count++ }); err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Decode() returned %d flows, expected none", count)
+	}
 }
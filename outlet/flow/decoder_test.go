@@ -149,12 +149,15 @@ func TestFlowDecode(t *testing.T) {
 
 		gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_")
 		expectedMetrics := map[string]string{
-			`flows_total{name="netflow"}`:                                                                                                  "8",
+			`flows_total{name="netflow"}`: "8",
+			`netflow_lost_records_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                        "188",
+			`netflow_observation_domain_packets_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                          "5",
 			`netflow_packets_total{exporter="::ffff:127.0.0.1",version="9"}`:                                                               "5",
 			`netflow_records_total{exporter="::ffff:127.0.0.1",type="DataFlowSet",version="9"}`:                                            "8",
 			`netflow_records_total{exporter="::ffff:127.0.0.1",type="OptionsDataFlowSet",version="9"}`:                                     "4",
 			`netflow_records_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                 "1",
 			`netflow_records_total{exporter="::ffff:127.0.0.1",type="TemplateFlowSet",version="9"}`:                                        "1",
+			`netflow_sequence_gaps_total{exporter="::ffff:127.0.0.1",obs_domain_id="0",version="9"}`:                                       "2",
 			`netflow_sets_total{exporter="::ffff:127.0.0.1",type="DataFlowSet",version="9"}`:                                               "2",
 			`netflow_sets_total{exporter="::ffff:127.0.0.1",type="OptionsDataFlowSet",version="9"}`:                                        "1",
 			`netflow_sets_total{exporter="::ffff:127.0.0.1",type="OptionsTemplateFlowSet",version="9"}`:                                    "1",
@@ -148,7 +148,7 @@ func TestRestoreVersionMismatch(t *testing.T) {
 		names = append(names, d.Name())
 	}
 	slices.Sort(names)
-	if diff := helpers.Diff(names, []string{"gob", "netflow", "sflow"}); diff != "" {
+	if diff := helpers.Diff(names, []string{"gob", "goflow2", "netflow", "pmacct", "sflow"}); diff != "" {
 		t.Fatalf("RestoreState(): invalid decoders:\n%s", diff)
 	}
 }
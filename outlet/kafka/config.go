@@ -8,6 +8,7 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/common/kafka"
+	"akvorado/common/pb"
 )
 
 // Configuration describes the configuration for the Kafka exporter.
@@ -20,6 +21,15 @@ type Configuration struct {
 	// FetchMaxWaitTime is the minimum duration to wait to get at least the
 	// minimum number of bytes.
 	FetchMaxWaitTime time.Duration `validate:"min=100ms"`
+	// FetchMaxBytes is the maximum number of bytes to fetch in a single
+	// request.
+	FetchMaxBytes int32 `validate:"gtefield=FetchMinBytes"`
+	// FetchMaxPartitionBytes is the maximum number of bytes to fetch from a
+	// single partition in a single request.
+	FetchMaxPartitionBytes int32 `validate:"min=1,ltefield=FetchMaxBytes"`
+	// DecompressionWorkers is the number of goroutines used by each worker to
+	// open envelopes of the messages of a partition.
+	DecompressionWorkers int `validate:"min=1"`
 	// MinWorkers is the minimum number of workers to read messages from Kafka.
 	MinWorkers int `validate:"min=1"`
 	// MaxWorkers is the maximum number of workers to read messages from Kafka.
@@ -28,6 +38,26 @@ type Configuration struct {
 	WorkerIncreaseRateLimit time.Duration `validate:"min=10s"`
 	// WorkerDecreaseRateLimit is the duration that should elapse before decreasing the number of workers
 	WorkerDecreaseRateLimit time.Duration `validate:"min=20s,gtfield=WorkerIncreaseRateLimit"`
+	// StartTime is the time to start consuming from when the consumer group
+	// has no committed offset. When zero, the consumer starts from the latest
+	// offset.
+	StartTime time.Time
+	// StartReplay is how far back in time to start consuming from when the
+	// consumer group has no committed offset. It cannot be used with StartTime.
+	StartReplay time.Duration `validate:"min=0"`
+	// ExternalTopics is a list of additional topics to consume. They contain
+	// flows produced by other collectors.
+	ExternalTopics []ExternalTopicConfiguration `validate:"dive"`
+}
+
+// ExternalTopicConfiguration describes a topic containing flows produced by
+// another collector.
+type ExternalTopicConfiguration struct {
+	// Topic is the name of the topic.
+	Topic string `validate:"required"`
+	// Decoder is the decoder to use for the messages of the topic. Only goflow2
+	// and pmacct are supported.
+	Decoder pb.RawFlow_Decoder
 }
 
 // DefaultConfiguration represents the default configuration for the Kafka exporter.
@@ -37,6 +67,9 @@ func DefaultConfiguration() Configuration {
 		ConsumerGroup:           "akvorado-outlet",
 		FetchMinBytes:           1_000_000,
 		FetchMaxWaitTime:        time.Second,
+		FetchMaxBytes:           50 << 20,
+		FetchMaxPartitionBytes:  1 << 20,
+		DecompressionWorkers:    1,
 		MinWorkers:              1,
 		MaxWorkers:              8, // This is not good to have too many workers for a single ClickHouse table.
 		WorkerIncreaseRateLimit: time.Minute,
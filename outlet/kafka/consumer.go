@@ -6,11 +6,16 @@ package kafka
 import (
 	"context"
 	"errors"
+	"net"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/twmb/franz-go/pkg/kgo"
 
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
 	"akvorado/common/reporter"
 )
 
@@ -19,12 +24,17 @@ var ErrStopProcessing = errors.New("stop processing further flows")
 
 // Consumer is a franz-go consumer and should process flow messages.
 type Consumer struct {
-	r *reporter.Reporter
-	l zerolog.Logger
+	r         *reporter.Reporter
+	l         zerolog.Logger
+	errLogger zerolog.Logger
 
-	metrics  metrics
-	worker   int
-	callback ReceiveFunc
+	metrics        metrics
+	worker         int
+	callback       ReceiveFunc
+	externalTopics map[string]pb.RawFlow_Decoder
+	envelope       *kafka.Envelope
+
+	decompressionWorkers int
 }
 
 // ReceiveFunc is a function that will be called with each received messages.
@@ -41,13 +51,19 @@ type WorkerBuilderFunc func(int, chan<- ScaleRequest) (ReceiveFunc, ShutdownFunc
 
 // NewConsumer creates a new consumer.
 func (c *realComponent) newConsumer(worker int, callback ReceiveFunc) *Consumer {
+	l := c.r.With().Int("worker", worker).Logger()
 	return &Consumer{
-		r: c.r,
-		l: c.r.With().Int("worker", worker).Logger(),
+		r:         c.r,
+		l:         l,
+		errLogger: l.Sample(reporter.BurstSampler(time.Minute, 1)),
+
+		worker:         worker,
+		metrics:        c.metrics,
+		callback:       callback,
+		externalTopics: c.externalTopics,
+		envelope:       c.envelope,
 
-		worker:   worker,
-		metrics:  c.metrics,
-		callback: callback,
+		decompressionWorkers: c.config.DecompressionWorkers,
 	}
 }
 
@@ -77,8 +93,16 @@ func (c *Consumer) ProcessFetches(ctx context.Context, client *kgo.Client, fetch
 
 	messagesReceived := c.metrics.messagesReceived.WithLabelValues(worker)
 	bytesReceived := c.metrics.bytesReceived.WithLabelValues(worker)
+	var fetchMessages, fetchBytes int
+	fetches.EachRecord(func(record *kgo.Record) {
+		fetchMessages++
+		fetchBytes += len(record.Value)
+	})
+	c.metrics.fetchSizeMessages.Observe(float64(fetchMessages))
+	c.metrics.fetchSizeBytes.Observe(float64(fetchBytes))
 	for _, fetch := range fetches {
 		for _, topic := range fetch.Topics {
+			decoder, external := c.externalTopics[topic.Topic]
 			for _, partition := range topic.Partitions {
 				err := func() error {
 					var epoch int32
@@ -90,12 +114,29 @@ func (c *Consumer) ProcessFetches(ctx context.Context, client *kgo.Client, fetch
 							},
 						})
 					}()
-					for _, record := range partition.Records {
+					var opened []openedRecord
+					if !external {
+						opened = c.openRecords(partition.Records)
+					}
+					for idx, record := range partition.Records {
 						epoch = record.LeaderEpoch
 						offset = record.Offset + 1
 						messagesReceived.Inc()
 						bytesReceived.Add(float64(len(record.Value)))
-						if err := c.callback(ctx, record.Value); err != nil {
+						value := record.Value
+						if external {
+							value = wrapExternalRecord(record, decoder)
+						} else if v, err := opened[idx].value, opened[idx].err; err != nil {
+							c.metrics.errorsReceived.WithLabelValues(worker).Inc()
+							c.errLogger.Err(err).
+								Str("topic", record.Topic).
+								Int64("offset", record.Offset).
+								Msg("cannot open envelope")
+							continue
+						} else {
+							value = v
+						}
+						if err := c.callback(ctx, value); err != nil {
 							return err
 						}
 					}
@@ -109,3 +150,50 @@ func (c *Consumer) ProcessFetches(ctx context.Context, client *kgo.Client, fetch
 	}
 	return nil
 }
+
+// openedRecord is the result of opening the envelope of a record.
+type openedRecord struct {
+	value []byte
+	err   error
+}
+
+// openRecords opens the envelopes of the provided records. When envelopes are
+// enabled, this is spread over several goroutines. Records are still handed
+// to the callback in order by the caller. Kafka compression is handled by the
+// Kafka client.
+func (c *Consumer) openRecords(records []*kgo.Record) []openedRecord {
+	opened := make([]openedRecord, len(records))
+	open := func(start, end int) {
+		for idx := start; idx < end; idx++ {
+			opened[idx].value, opened[idx].err = c.envelope.Open(records[idx])
+		}
+	}
+	workers := min(c.decompressionWorkers, len(records))
+	if workers <= 1 || !c.envelope.Enabled() {
+		open(0, len(records))
+		return opened
+	}
+	var wg sync.WaitGroup
+	chunk := (len(records) + workers - 1) / workers
+	for start := 0; start < len(records); start += chunk {
+		wg.Go(func() {
+			open(start, min(start+chunk, len(records)))
+		})
+	}
+	wg.Wait()
+	return opened
+}
+
+// wrapExternalRecord wraps a record from an external topic into a raw flow. The
+// exporter address is extracted from the payload by the decoder.
+func wrapExternalRecord(record *kgo.Record, decoder pb.RawFlow_Decoder) []byte {
+	rawFlow := pb.RawFlow{
+		TimeReceived:  uint64(record.Timestamp.Unix()),
+		Payload:       record.Value,
+		SourceAddress: net.IPv6unspecified,
+		Decoder:       decoder,
+		Version:       uint32(pb.Version),
+	}
+	buf, _ := rawFlow.MarshalVT()
+	return buf
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand/v2"
+	"net"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,7 +30,7 @@ import (
 func TestFakeKafka(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic2-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
@@ -120,6 +122,16 @@ func TestFakeKafka(t *testing.T) {
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Errorf("Metrics (-got, +want):\n%s", diff)
 	}
+	gotMetrics = r.GetMetrics("akvorado_outlet_kafka_",
+		"fetch_size_bytes_sum", "fetch_size_messages_sum", "fetch_size_messages_count")
+	expectedMetrics = map[string]string{
+		`fetch_size_bytes_sum`:      "21",
+		`fetch_size_messages_sum`:   "3",
+		`fetch_size_messages_count`: strconv.Itoa(max(fetches, 1)),
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Errorf("Metrics (-got, +want):\n%s", diff)
+	}
 
 	if err := c.Stop(); err != nil {
 		t.Fatalf("Stop() error:\n%+v", err)
@@ -132,7 +144,7 @@ func TestFakeKafka(t *testing.T) {
 func TestStartSeveralWorkers(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic2-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
@@ -200,7 +212,7 @@ func TestStartSeveralWorkers(t *testing.T) {
 func TestWorkerStop(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic3-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
@@ -319,7 +331,7 @@ func TestWorkerStop(t *testing.T) {
 func TestWorkerScaling(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic2-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
@@ -451,7 +463,7 @@ func TestWorkerScaling(t *testing.T) {
 func TestKafkaLagMetric(t *testing.T) {
 	r := reporter.NewMock(t)
 	topicName := fmt.Sprintf("test-topic2-%d", rand.Int())
-	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.Version)
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
 
 	cluster, err := kfake.NewCluster(
 		kfake.NumBrokers(1),
@@ -625,3 +637,314 @@ func TestKafkaLagMetric(t *testing.T) {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
 	}
 }
+
+func TestExternalTopics(t *testing.T) {
+	r := reporter.NewMock(t)
+	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
+	externalTopicName := fmt.Sprintf("pmacct-%d", rand.Int())
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, expectedTopicName, externalTopicName),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer cluster.Close()
+
+	// Create a producer client
+	producerConfiguration := kafka.DefaultConfiguration()
+	producerConfiguration.Brokers = cluster.ListenAddrs()
+	producerOpts, err := kafka.NewConfig(reporter.NewMock(t), producerConfiguration)
+	if err != nil {
+		t.Fatalf("NewConfig() error:\n%+v", err)
+	}
+	producerOpts = append(producerOpts, kgo.ProducerLinger(0))
+	producer, err := kgo.NewClient(producerOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+
+	// Callback
+	got := make(chan []byte, 10)
+	callback := func(_ context.Context, message []byte) error {
+		got <- message
+		return nil
+	}
+
+	// Invalid decoder
+	configuration := DefaultConfiguration()
+	configuration.Topic = topicName
+	configuration.Brokers = cluster.ListenAddrs()
+	configuration.FetchMaxWaitTime = 100 * time.Millisecond
+	configuration.ConsumerGroup = fmt.Sprintf("outlet-%d", rand.Int())
+	configuration.ExternalTopics = []ExternalTopicConfiguration{
+		{Topic: externalTopicName, Decoder: pb.RawFlow_DECODER_NETFLOW},
+	}
+	if _, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t)}); err == nil {
+		t.Fatal("New() did not error with an unsupported decoder")
+	}
+
+	// Start the component
+	configuration.ExternalTopics[0].Decoder = pb.RawFlow_DECODER_PMACCT
+	c, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if err := c.(*realComponent).Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	c.StartWorkers(func(int, chan<- ScaleRequest) (ReceiveFunc, ShutdownFunc) {
+		return callback, func() {}
+	})
+	defer c.Stop()
+
+	// Send messages to both topics
+	time.Sleep(100 * time.Millisecond)
+	now := time.Unix(1_800_000_000, 0)
+	for _, record := range []*kgo.Record{
+		{Topic: expectedTopicName, Value: []byte("hello")},
+		{Topic: externalTopicName, Value: []byte(`{"event_type": "purge"}`), Timestamp: now},
+	} {
+		if err := producer.ProduceSync(context.Background(), record).FirstErr(); err != nil {
+			t.Fatalf("ProduceSync() error:\n%+v", err)
+		}
+	}
+
+	received := []string{}
+	for range 2 {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("Too long to get messages")
+		case message := <-got:
+			if string(message) == "hello" {
+				received = append(received, "hello")
+				continue
+			}
+			var rawFlow pb.RawFlow
+			if err := rawFlow.UnmarshalVT(message); err != nil {
+				t.Fatalf("UnmarshalVT() error:\n%+v", err)
+			}
+			if diff := helpers.Diff(&rawFlow, &pb.RawFlow{
+				TimeReceived:  uint64(now.Unix()),
+				Payload:       []byte(`{"event_type": "purge"}`),
+				SourceAddress: net.IPv6unspecified,
+				Decoder:       pb.RawFlow_DECODER_PMACCT,
+				Version:       uint32(pb.Version),
+			}); diff != "" {
+				t.Errorf("UnmarshalVT() (-got, +want):\n%s", diff)
+			}
+			received = append(received, "pmacct")
+		}
+	}
+	slices.Sort(received)
+	if diff := helpers.Diff(received, []string{"hello", "pmacct"}); diff != "" {
+		t.Errorf("Received messages (-got, +want):\n%s", diff)
+	}
+}
+
+func TestStartPosition(t *testing.T) {
+	r := reporter.NewMock(t)
+	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, expectedTopicName),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer cluster.Close()
+
+	// Produce messages in the past
+	producerConfiguration := kafka.DefaultConfiguration()
+	producerConfiguration.Brokers = cluster.ListenAddrs()
+	producerOpts, err := kafka.NewConfig(reporter.NewMock(t), producerConfiguration)
+	if err != nil {
+		t.Fatalf("NewConfig() error:\n%+v", err)
+	}
+	producerOpts = append(producerOpts, kgo.ProducerLinger(0))
+	producer, err := kgo.NewClient(producerOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+	now := time.Now()
+	for _, ago := range []time.Duration{2 * time.Hour, 30 * time.Minute, 5 * time.Minute} {
+		record := &kgo.Record{
+			Topic:     expectedTopicName,
+			Value:     []byte(ago.String()),
+			Timestamp: now.Add(-ago),
+		}
+		if err := producer.ProduceSync(context.Background(), record).FirstErr(); err != nil {
+			t.Fatalf("ProduceSync() error:\n%+v", err)
+		}
+	}
+
+	configuration := DefaultConfiguration()
+	configuration.Topic = topicName
+	configuration.Brokers = cluster.ListenAddrs()
+	configuration.FetchMaxWaitTime = 100 * time.Millisecond
+	configuration.StartTime = now.Add(-time.Hour)
+	configuration.StartReplay = time.Hour
+	if _, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t)}); err == nil {
+		t.Fatal("New() did not error with both start time and start replay")
+	}
+
+	cases := []struct {
+		Description string
+		StartTime   time.Time
+		StartReplay time.Duration
+		Expected    []string
+	}{
+		{"latest", time.Time{}, 0, []string{}},
+		{"start time", now.Add(-10 * time.Minute), 0, []string{"5m0s"}},
+		{"start replay", time.Time{}, time.Hour, []string{"30m0s", "5m0s"}},
+		{"start replay before first message", time.Time{}, 3 * time.Hour, []string{"2h0m0s", "30m0s", "5m0s"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			configuration.ConsumerGroup = fmt.Sprintf("outlet-%d", rand.Int())
+			configuration.StartTime = tc.StartTime
+			configuration.StartReplay = tc.StartReplay
+			c, err := New(reporter.NewMock(t), configuration, Dependencies{Daemon: daemon.NewMock(t)})
+			if err != nil {
+				t.Fatalf("New() error:\n%+v", err)
+			}
+			if err := c.(*realComponent).Start(); err != nil {
+				t.Fatalf("Start() error:\n%+v", err)
+			}
+			got := make(chan string, 10)
+			c.StartWorkers(func(int, chan<- ScaleRequest) (ReceiveFunc, ShutdownFunc) {
+				return func(_ context.Context, message []byte) error {
+					got <- string(message)
+					return nil
+				}, func() {}
+			})
+			defer c.Stop()
+
+			received := []string{}
+			timeout := time.After(500 * time.Millisecond)
+		outer:
+			for {
+				select {
+				case <-timeout:
+					break outer
+				case message := <-got:
+					received = append(received, message)
+				}
+			}
+			if diff := helpers.Diff(received, tc.Expected); diff != "" {
+				t.Errorf("Received messages (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	r := reporter.NewMock(t)
+	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
+	expectedTopicName := fmt.Sprintf("%s-v%d", topicName, pb.TopicVersion)
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, expectedTopicName),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer cluster.Close()
+
+	// Create a producer client
+	producerConfiguration := kafka.DefaultConfiguration()
+	producerConfiguration.Brokers = cluster.ListenAddrs()
+	producerOpts, err := kafka.NewConfig(reporter.NewMock(t), producerConfiguration)
+	if err != nil {
+		t.Fatalf("NewConfig() error:\n%+v", err)
+	}
+	producerOpts = append(producerOpts, kgo.ProducerLinger(0))
+	producer, err := kgo.NewClient(producerOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+
+	// Callback
+	got := make(chan []byte, 10)
+	callback := func(_ context.Context, message []byte) error {
+		got <- message
+		return nil
+	}
+
+	// Start the component. It knows the old and the new keys.
+	oldKey := "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+	newKey := "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+	configuration := DefaultConfiguration()
+	configuration.Topic = topicName
+	configuration.Brokers = cluster.ListenAddrs()
+	configuration.FetchMaxWaitTime = 100 * time.Millisecond
+	configuration.ConsumerGroup = fmt.Sprintf("outlet-%d", rand.Int())
+	configuration.DecompressionWorkers = 4
+	configuration.Envelope = kafka.EnvelopeConfiguration{
+		Keys: map[string]string{"old": oldKey, "new": newKey},
+	}
+	c, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if err := c.(*realComponent).Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	c.StartWorkers(func(int, chan<- ScaleRequest) (ReceiveFunc, ShutdownFunc) {
+		return callback, func() {}
+	})
+	defer c.Stop()
+
+	// Send plain and sealed messages, including one with an unknown key
+	records := []*kgo.Record{{Topic: expectedTopicName, Value: []byte("plain")}}
+	for _, envelopeConfiguration := range []kafka.EnvelopeConfiguration{
+		{Keys: map[string]string{"old": oldKey}, EncryptionKey: "old"},
+		{Keys: map[string]string{"unknown": oldKey}, EncryptionKey: "unknown"},
+		{Keys: map[string]string{"new": newKey}, EncryptionKey: "new", Compression: true},
+	} {
+		envelope, err := kafka.NewEnvelope(envelopeConfiguration)
+		if err != nil {
+			t.Fatalf("NewEnvelope() error:\n%+v", err)
+		}
+		value, headers := envelope.Seal([]byte(envelopeConfiguration.EncryptionKey))
+		records = append(records, &kgo.Record{Topic: expectedTopicName, Value: value, Headers: headers})
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, record := range records {
+		if err := producer.ProduceSync(context.Background(), record).FirstErr(); err != nil {
+			t.Fatalf("ProduceSync() error:\n%+v", err)
+		}
+	}
+
+	received := []string{}
+	for range 3 {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("Too long to get messages")
+		case message := <-got:
+			received = append(received, string(message))
+		}
+	}
+	if diff := helpers.Diff(received, []string{"plain", "old", "new"}); diff != "" {
+		t.Errorf("Received messages (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_kafka_", "received_errors_total")
+	expectedMetrics := map[string]string{
+		`received_errors_total{worker="0"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Errorf("Metrics (-got, +want):\n%s", diff)
+	}
+}
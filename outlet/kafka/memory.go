@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"akvorado/common/reporter"
+)
+
+// memoryComponent implements a consumer reading messages from an in-memory
+// queue instead of Kafka. It is used when the inlet and the outlet run in the
+// same process.
+type memoryComponent struct {
+	r      *reporter.Reporter
+	config Configuration
+	queue  <-chan []byte
+
+	ctx               context.Context
+	cancel            context.CancelFunc
+	workerMu          sync.Mutex
+	workers           []worker
+	workerBuilder     WorkerBuilderFunc
+	workerRequestChan chan<- ScaleRequest
+	metrics           struct {
+		messagesReceived *reporter.CounterVec
+		bytesReceived    *reporter.CounterVec
+		workers          reporter.GaugeFunc
+	}
+}
+
+// NewMemory creates a new consumer reading messages from the provided queue.
+// Workers are scaled like with Kafka. They stop when the queue is closed.
+func NewMemory(r *reporter.Reporter, configuration Configuration, queue <-chan []byte) Component {
+	c := memoryComponent{
+		r:      r,
+		config: configuration,
+		queue:  queue,
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.metrics.messagesReceived = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "received_messages_total",
+			Help: "Number of messages received for a given worker.",
+		},
+		[]string{"worker"},
+	)
+	c.metrics.bytesReceived = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "received_bytes_total",
+			Help: "Number of bytes received for a given worker.",
+		},
+		[]string{"worker"},
+	)
+	c.metrics.workers = c.r.GaugeFunc(
+		reporter.GaugeOpts{
+			Name: "workers",
+			Help: "Number of running workers",
+		},
+		func() float64 {
+			c.workerMu.Lock()
+			defer c.workerMu.Unlock()
+			return float64(len(c.workers))
+		},
+	)
+	return &c
+}
+
+// StartWorkers will start the initial workers. This should only be called once.
+func (c *memoryComponent) StartWorkers(workerBuilder WorkerBuilderFunc) error {
+	c.workerRequestChan = runScaler(c.ctx, scalerConfiguration{
+		minWorkers:        c.config.MinWorkers,
+		maxWorkers:        c.config.MaxWorkers,
+		increaseRateLimit: c.config.WorkerIncreaseRateLimit,
+		decreaseRateLimit: c.config.WorkerDecreaseRateLimit,
+		getWorkerCount: func() int {
+			c.workerMu.Lock()
+			defer c.workerMu.Unlock()
+			return len(c.workers)
+		},
+		increaseWorkers: func(from, to int) {
+			c.r.Info().Msgf("increase number of workers from %d to %d", from, to)
+			for range to - from {
+				c.startOneWorker()
+			}
+		},
+		decreaseWorkers: func(from, to int) {
+			c.r.Info().Msgf("decrease number of workers from %d to %d", from, to)
+			for range from - to {
+				c.stopOneWorker()
+			}
+		},
+	})
+	c.workerBuilder = workerBuilder
+	for range c.config.MinWorkers {
+		c.startOneWorker()
+	}
+	return nil
+}
+
+// startOneWorker starts a new worker.
+func (c *memoryComponent) startOneWorker() {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+
+	i := len(c.workers)
+	callback, shutdown := c.workerBuilder(i, c.workerRequestChan)
+	messagesReceived := c.metrics.messagesReceived.WithLabelValues(strconv.Itoa(i))
+	bytesReceived := c.metrics.bytesReceived.WithLabelValues(strconv.Itoa(i))
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancelCause(c.ctx)
+	go func() {
+		logger := c.r.With().Int("worker", i).Logger()
+		defer func() {
+			logger.Info().Msg("stopping worker")
+			shutdown()
+			close(done)
+		}()
+		process := func(ctx context.Context, message []byte) bool {
+			messagesReceived.Inc()
+			bytesReceived.Add(float64(len(message)))
+			if err := callback(ctx, message); err != nil {
+				if !errors.Is(err, context.Canceled) && !errors.Is(err, ErrStopProcessing) {
+					logger.Err(err).Msg("cannot process received message")
+				}
+				return false
+			}
+			return true
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				// Process the messages still in the queue before stopping.
+				for {
+					select {
+					case message, ok := <-c.queue:
+						if !ok || !process(context.Background(), message) {
+							return
+						}
+					default:
+						return
+					}
+				}
+			case message, ok := <-c.queue:
+				if !ok || !process(ctx, message) {
+					return
+				}
+			}
+		}
+	}()
+	c.workers = append(c.workers, worker{
+		stop: func() {
+			cancel(ErrStopProcessing)
+			<-done
+		},
+	})
+}
+
+// stopOneWorker stops the last worker.
+func (c *memoryComponent) stopOneWorker() {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+	i := len(c.workers) - 1
+	c.workers[i].stop()
+	c.workers = c.workers[:i]
+}
+
+// StopWorkers stops all workers. The messages still in the queue are processed
+// first.
+func (c *memoryComponent) StopWorkers() {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+	for _, worker := range c.workers {
+		worker.stop()
+	}
+	c.workers = nil
+}
+
+// Stop stops the in-memory consumer.
+func (c *memoryComponent) Stop() error {
+	c.StopWorkers()
+	c.cancel()
+	c.r.Info().Msg("in-memory consumer stopped")
+	return nil
+}
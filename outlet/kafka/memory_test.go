@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	inletkafka "akvorado/inlet/kafka"
+)
+
+func TestMemory(t *testing.T) {
+	r := reporter.NewMock(t)
+	queue := make(chan []byte, 100)
+	producer := inletkafka.NewMemory(r, queue)
+	configuration := DefaultConfiguration()
+	configuration.MinWorkers = 2
+	c := NewMemory(r, configuration, queue)
+
+	var mu sync.Mutex
+	got := map[string]bool{}
+	var shutdownCalled atomic.Int32
+	c.StartWorkers(
+		func(int, chan<- ScaleRequest) (ReceiveFunc, ShutdownFunc) {
+			return func(_ context.Context, message []byte) error {
+					mu.Lock()
+					defer mu.Unlock()
+					got[string(message)] = true
+					return nil
+				}, func() {
+					shutdownCalled.Add(1)
+				}
+		},
+	)
+
+	// Send messages, reusing the same buffer
+	expected := map[string]bool{}
+	payload := make([]byte, 10)
+	for i := range 50 {
+		n := copy(payload, fmt.Sprintf("hello%d", i))
+		finalized := false
+		producer.Send("127.0.0.1", payload[:n], func() { finalized = true })
+		if !finalized {
+			t.Fatal("Send() did not call the finalizer")
+		}
+		expected[fmt.Sprintf("hello%d", i)] = true
+	}
+
+	// Stopping should process the remaining messages
+	if err := producer.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("Received messages (-got, +want):\n%s", diff)
+	}
+	if shutdownCalled.Load() != 2 {
+		t.Errorf("Stop() triggered %d shutdown functions, expected 2", shutdownCalled.Load())
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_kafka_", "sent_messages_total")
+	expectedMetrics := map[string]string{
+		`sent_messages_total{exporter="127.0.0.1"}`: "50",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Errorf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -8,21 +8,22 @@ import (
 	"fmt"
 	"time"
 
-	"akvorado/common/pb"
 	"akvorado/common/reporter"
 )
 
 type metrics struct {
-	messagesReceived *reporter.CounterVec
-	fetchesReceived  *reporter.CounterVec
-	bytesReceived    *reporter.CounterVec
-	errorsReceived   *reporter.CounterVec
-	workers          reporter.GaugeFunc
-	maxWorkers       reporter.GaugeFunc
-	minWorkers       reporter.GaugeFunc
-	workerIncrease   reporter.Counter
-	workerDecrease   reporter.Counter
-	consumerLag      reporter.GaugeFunc
+	messagesReceived  *reporter.CounterVec
+	fetchesReceived   *reporter.CounterVec
+	bytesReceived     *reporter.CounterVec
+	errorsReceived    *reporter.CounterVec
+	fetchSizeBytes    reporter.Histogram
+	fetchSizeMessages reporter.Histogram
+	workers           reporter.GaugeFunc
+	maxWorkers        reporter.GaugeFunc
+	minWorkers        reporter.GaugeFunc
+	workerIncrease    reporter.Counter
+	workerDecrease    reporter.Counter
+	consumerLag       reporter.GaugeFunc
 }
 
 func (c *realComponent) initMetrics() {
@@ -54,6 +55,20 @@ func (c *realComponent) initMetrics() {
 		},
 		[]string{"worker"},
 	)
+	c.metrics.fetchSizeBytes = c.r.Histogram(
+		reporter.HistogramOpts{
+			Name:    "fetch_size_bytes",
+			Help:    "Size of fetches received from Kafka in bytes.",
+			Buckets: []float64{1 << 10, 1 << 14, 1 << 17, 1 << 20, 1 << 22, 1 << 24, 1 << 26},
+		},
+	)
+	c.metrics.fetchSizeMessages = c.r.Histogram(
+		reporter.HistogramOpts{
+			Name:    "fetch_size_messages",
+			Help:    "Number of messages in fetches received from Kafka.",
+			Buckets: []float64{1, 10, 100, 1_000, 10_000, 100_000},
+		},
+	)
 	c.metrics.workers = c.r.GaugeFunc(
 		reporter.GaugeOpts{
 			Name: "workers",
@@ -141,7 +156,7 @@ func (c *realComponent) computeLagMetric(ctx context.Context) (float64, error) {
 	}
 
 	// Retrieve only the current topic as there may be several
-	topic := fmt.Sprintf("%s-v%d", c.config.Topic, pb.Version)
+	topic := c.config.FlowsTopic()
 	perPartitionGroupLag, ok := perGroupLag.Lag[topic]
 	if !ok {
 		return -1, fmt.Errorf("unable to find Kafka consumer group lag for topic %q", topic)
@@ -6,6 +6,7 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -46,6 +47,9 @@ type realComponent struct {
 	workerBuilder     WorkerBuilderFunc
 	workerRequestChan chan<- ScaleRequest
 	metrics           metrics
+
+	externalTopics map[string]pb.RawFlow_Decoder
+	envelope       *kafka.Envelope
 }
 
 // Dependencies define the dependencies of the Kafka exporter.
@@ -60,23 +64,60 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if err != nil {
 		return nil, err
 	}
+	configuration.ConsumerGroup = configuration.ConsumerGroupName(configuration.ConsumerGroup)
+	envelope, err := kafka.NewEnvelope(configuration.Envelope)
+	if err != nil {
+		return nil, err
+	}
 
 	c := realComponent{
 		r:      r,
 		d:      &dependencies,
 		config: configuration,
 
-		kafkaMetrics: []*kprom.Metrics{},
+		kafkaMetrics:   []*kprom.Metrics{},
+		externalTopics: map[string]pb.RawFlow_Decoder{},
+		envelope:       envelope,
 	}
 	c.initMetrics()
 
+	topics := []string{configuration.FlowsTopic()}
+	for _, external := range configuration.ExternalTopics {
+		switch external.Decoder {
+		case pb.RawFlow_DECODER_GOFLOW2, pb.RawFlow_DECODER_PMACCT:
+		default:
+			return nil, fmt.Errorf("unsupported decoder %s for external topic %q",
+				external.Decoder, external.Topic)
+		}
+		if _, ok := c.externalTopics[external.Topic]; ok || external.Topic == topics[0] {
+			return nil, fmt.Errorf("duplicate topic %q", external.Topic)
+		}
+		c.externalTopics[external.Topic] = external.Decoder
+		topics = append(topics, external.Topic)
+	}
+
+	// Without committed offsets, start from the end or from the requested time.
+	startOffset := kgo.NewOffset().AtEnd()
+	switch {
+	case !configuration.StartTime.IsZero() && configuration.StartReplay > 0:
+		return nil, errors.New("start time and start replay cannot be used together")
+	case !configuration.StartTime.IsZero():
+		startOffset = kgo.NewOffset().AfterMilli(configuration.StartTime.UnixMilli())
+	case configuration.StartReplay > 0:
+		startOffset = kgo.NewOffset().AfterMilli(time.Now().Add(-configuration.StartReplay).UnixMilli())
+	}
+
 	kafkaOpts = append(kafkaOpts,
 		kgo.FetchMinBytes(configuration.FetchMinBytes),
 		kgo.FetchMaxWait(configuration.FetchMaxWaitTime),
+		kgo.FetchMaxBytes(configuration.FetchMaxBytes),
+		kgo.FetchMaxPartitionBytes(configuration.FetchMaxPartitionBytes),
+		// A fetch response may be larger than the requested maximum size.
+		kgo.BrokerMaxReadBytes(int32(min(1<<30, max(100<<20, 2*int64(configuration.FetchMaxBytes))))),
 		kgo.ConsumerGroup(configuration.ConsumerGroup),
-		kgo.ConsumeStartOffset(kgo.NewOffset().AtEnd()),
-		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
-		kgo.ConsumeTopics(fmt.Sprintf("%s-v%d", configuration.Topic, pb.Version)),
+		kgo.ConsumeStartOffset(startOffset),
+		kgo.ConsumeResetOffset(startOffset),
+		kgo.ConsumeTopics(topics...),
 		kgo.AutoCommitMarks(),
 		kgo.AutoCommitInterval(time.Second),
 		kgo.OnPartitionsRevoked(c.onPartitionsRevoked),
@@ -114,7 +155,7 @@ func (c *realComponent) Start() error {
 	if err != nil {
 		return fmt.Errorf("unable to get metadata for topics: %w", err)
 	}
-	topicName := fmt.Sprintf("%s-v%d", c.config.Topic, pb.Version)
+	topicName := c.config.FlowsTopic()
 	topic, ok := topics[topicName]
 	if !ok {
 		return fmt.Errorf("unable find topic %q", topicName)
@@ -68,6 +68,9 @@ func (c *realComponent) startOneWorker() error {
 		defer func() {
 			logger.Info().Msg("stopping worker")
 
+			// Flush pending data before committing offsets.
+			shutdown()
+
 			// Allow a small grace time to commit uncommited work.
 			ctx, cancelCommit := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancelCommit()
@@ -76,7 +79,6 @@ func (c *realComponent) startOneWorker() error {
 			}
 			client.CloseAllowingRebalance()
 
-			shutdown()
 			close(done)
 		}()
 
@@ -21,10 +21,11 @@ type metadataCache struct {
 	cache *cache.Cache[provider.Query, provider.Answer]
 
 	metrics struct {
-		cacheHit     reporter.Counter
-		cacheMiss    reporter.Counter
-		cacheExpired reporter.Counter
-		cacheSize    reporter.GaugeFunc
+		cacheHit         reporter.Counter
+		cacheMiss        reporter.Counter
+		cacheExpired     reporter.Counter
+		cacheInvalidated reporter.Counter
+		cacheSize        reporter.GaugeFunc
 	}
 }
 
@@ -48,6 +49,11 @@ func newMetadataCache(r *reporter.Reporter) *metadataCache {
 			Name: "cache_expired_entries_total",
 			Help: "Number of cache entries expired.",
 		})
+	sc.metrics.cacheInvalidated = r.Counter(
+		reporter.CounterOpts{
+			Name: "cache_invalidated_entries_total",
+			Help: "Number of cache entries invalidated on request.",
+		})
 	sc.metrics.cacheSize = r.GaugeFunc(
 		reporter.GaugeOpts{
 			Name: "cache_size_entries",
@@ -75,6 +81,11 @@ func (sc *metadataCache) Put(t time.Time, query provider.Query, answer provider.
 	sc.cache.Put(t, query, answer)
 }
 
+// Flush removes all entries from the cache.
+func (sc *metadataCache) Flush() int {
+	return sc.cache.Clear()
+}
+
 // Expire expire entries whose last access is before the provided time
 func (sc *metadataCache) Expire(before time.Time) int {
 	expired := sc.cache.DeleteLastAccessedBefore(before)
@@ -82,6 +93,15 @@ func (sc *metadataCache) Expire(before time.Time) int {
 	return expired
 }
 
+// Invalidate removes the entries of the exporters in the provided prefix.
+func (sc *metadataCache) Invalidate(prefix netip.Prefix) int {
+	invalidated := sc.cache.DeleteFunc(func(query provider.Query, _ provider.Answer) bool {
+		return prefix.Contains(query.ExporterIP)
+	})
+	sc.metrics.cacheInvalidated.Add(float64(invalidated))
+	return invalidated
+}
+
 // NeedUpdates returns a map of interface entries that would need to
 // be updated. It relies on last update.
 func (sc *metadataCache) NeedUpdates(before time.Time) map[netip.Addr][]uint {
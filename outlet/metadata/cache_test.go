@@ -54,10 +54,11 @@ func TestGetEmpty(t *testing.T) {
 
 	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_cache_")
 	expectedMetrics := map[string]string{
-		`expired_entries_total`: "0",
-		`hits_total`:            "0",
-		`misses_total`:          "1",
-		`size_entries`:          "0",
+		`expired_entries_total`:     "0",
+		`invalidated_entries_total`: "0",
+		`hits_total`:                "0",
+		`misses_total`:              "1",
+		`size_entries`:              "0",
 	}
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -84,10 +85,11 @@ func TestSimpleLookup(t *testing.T) {
 
 	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_cache_")
 	expectedMetrics := map[string]string{
-		`expired_entries_total`: "0",
-		`hits_total`:            "1",
-		`misses_total`:          "2",
-		`size_entries`:          "1",
+		`expired_entries_total`:     "0",
+		`invalidated_entries_total`: "0",
+		`hits_total`:                "1",
+		`misses_total`:              "2",
+		`size_entries`:              "1",
 	}
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -179,10 +181,11 @@ func TestExpire(t *testing.T) {
 
 	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_cache_")
 	expectedMetrics := map[string]string{
-		`expired_entries_total`: "3",
-		`hits_total`:            "7",
-		`misses_total`:          "6",
-		`size_entries`:          "1",
+		`expired_entries_total`:     "3",
+		`invalidated_entries_total`: "0",
+		`hits_total`:                "7",
+		`misses_total`:              "6",
+		`size_entries`:              "1",
 	}
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -242,6 +245,42 @@ func TestExpireRefresh(t *testing.T) {
 	})
 }
 
+func TestInvalidate(t *testing.T) {
+	r, sc := setupTestCache(t)
+	now := time.Now()
+	for _, exporter := range []string{"::ffff:127.0.0.1", "::ffff:127.0.0.2", "::ffff:127.0.1.1"} {
+		sc.Put(now,
+			provider.Query{
+				ExporterIP: netip.MustParseAddr(exporter),
+				IfIndex:    676,
+			},
+			provider.Answer{
+				Exporter:  provider.Exporter{Name: exporter},
+				Interface: provider.Interface{Name: "Gi0/0/0/1", Description: "Transit"},
+			})
+	}
+	if got := sc.Invalidate(netip.MustParsePrefix("::ffff:127.0.0.0/120")); got != 2 {
+		t.Fatalf("Invalidate() == %d, expected 2", got)
+	}
+	expectCacheLookup(t, sc, "127.0.0.1", 676, provider.Answer{})
+	expectCacheLookup(t, sc, "127.0.1.1", 676, provider.Answer{
+		Exporter:  provider.Exporter{Name: "::ffff:127.0.1.1"},
+		Interface: provider.Interface{Name: "Gi0/0/0/1", Description: "Transit"},
+	})
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_cache_")
+	expectedMetrics := map[string]string{
+		`expired_entries_total`:     "0",
+		`invalidated_entries_total`: "2",
+		`hits_total`:                "1",
+		`misses_total`:              "1",
+		`size_entries`:              "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
 func TestNeedUpdates(t *testing.T) {
 	_, sc := setupTestCache(t)
 	now := time.Now()
@@ -8,6 +8,7 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/outlet/metadata/provider"
+	"akvorado/outlet/metadata/provider/exporter"
 	"akvorado/outlet/metadata/provider/gnmi"
 	"akvorado/outlet/metadata/provider/snmp"
 	"akvorado/outlet/metadata/provider/static"
@@ -57,9 +58,10 @@ func (pc ProviderConfiguration) MarshalYAML() (any, error) {
 }
 
 var providers = map[string](func() provider.Configuration){
-	"snmp":   snmp.DefaultConfiguration,
-	"gnmi":   gnmi.DefaultConfiguration,
-	"static": static.DefaultConfiguration,
+	"snmp":     snmp.DefaultConfiguration,
+	"gnmi":     gnmi.DefaultConfiguration,
+	"static":   static.DefaultConfiguration,
+	"exporter": exporter.DefaultConfiguration,
 }
 
 func init() {
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadata
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+
+	"akvorado/outlet/metadata/provider"
+)
+
+// countersMaxSamples is the maximum number of counter samples kept between two
+// flushes. Additional samples are dropped.
+const countersMaxSamples = 10_000
+
+// CounterSample is a sample of the octet counters of an interface, as polled
+// by a provider.
+type CounterSample struct {
+	Time         time.Time
+	ExporterIP   netip.Addr
+	ExporterName string
+	IfIndex      uint
+	IfName       string
+	InOctets     uint64
+	OutOctets    uint64
+}
+
+// counterSamples holds the counter samples since the last flush.
+type counterSamples struct {
+	lock    sync.Mutex
+	samples []CounterSample
+}
+
+// recordCounters records the counters of the provided answer.
+func (c *Component) recordCounters(t time.Time, query provider.Query, answer provider.Answer) {
+	c.counters.lock.Lock()
+	defer c.counters.lock.Unlock()
+	if len(c.counters.samples) >= countersMaxSamples {
+		c.metrics.counterSamplesDropped.Inc()
+		return
+	}
+	c.counters.samples = append(c.counters.samples, CounterSample{
+		Time:         t,
+		ExporterIP:   query.ExporterIP,
+		ExporterName: answer.Exporter.Name,
+		IfIndex:      query.IfIndex,
+		IfName:       answer.Interface.Name,
+		InOctets:     answer.Counters.InOctets,
+		OutOctets:    answer.Counters.OutOctets,
+	})
+}
+
+// FlushCounters returns the counter samples polled by the providers since the
+// last call.
+func (c *Component) FlushCounters() []CounterSample {
+	c.counters.lock.Lock()
+	defer c.counters.lock.Unlock()
+	samples := c.counters.samples
+	c.counters.samples = nil
+	return samples
+}
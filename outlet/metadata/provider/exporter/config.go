@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package exporter
+
+import "akvorado/outlet/metadata/provider"
+
+// Configuration describes the configuration for the exporter provider.
+type Configuration struct{}
+
+// DefaultConfiguration represents the default configuration for the exporter
+// provider.
+func DefaultConfiguration() provider.Configuration {
+	return Configuration{}
+}
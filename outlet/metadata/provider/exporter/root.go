@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package exporter is a metadata provider using the interface names and
+// descriptions sent by exporters in NetFlow v9 or IPFIX options.
+package exporter
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+)
+
+// Provider represents the exporter provider.
+type Provider struct {
+	r *reporter.Reporter
+
+	interfacesLock sync.RWMutex
+	interfaces     map[netip.Addr]map[uint]provider.Interface
+
+	metrics struct {
+		interfaces reporter.Gauge
+	}
+}
+
+var (
+	_ provider.Provider      = &Provider{}
+	_ provider.Recorder      = &Provider{}
+	_ provider.Configuration = Configuration{}
+)
+
+// New creates a new exporter provider from configuration.
+func (configuration Configuration) New(_ context.Context, r *reporter.Reporter) (provider.Provider, error) {
+	p := &Provider{
+		r:          r,
+		interfaces: map[netip.Addr]map[uint]provider.Interface{},
+	}
+	p.metrics.interfaces = r.Gauge(
+		reporter.GaugeOpts{
+			Name: "interfaces",
+			Help: "Number of interfaces learnt from exporters.",
+		})
+	return p, nil
+}
+
+// Record records the name and the description of an interface sent by an
+// exporter.
+func (p *Provider) Record(exporterIP netip.Addr, ifIndex uint, name, description string) bool {
+	iface := provider.Interface{Name: name, Description: description}
+	p.interfacesLock.RLock()
+	current, ok := p.interfaces[exporterIP][ifIndex]
+	p.interfacesLock.RUnlock()
+	if ok && current == iface {
+		return false
+	}
+
+	p.interfacesLock.Lock()
+	defer p.interfacesLock.Unlock()
+	ifaces, ok := p.interfaces[exporterIP]
+	if !ok {
+		ifaces = map[uint]provider.Interface{}
+		p.interfaces[exporterIP] = ifaces
+	}
+	if _, ok := ifaces[ifIndex]; !ok {
+		p.metrics.interfaces.Inc()
+	}
+	ifaces[ifIndex] = iface
+	return true
+}
+
+// Query answers with the interfaces sent by exporters. Unknown interfaces are
+// skipped to let the next provider answer.
+func (p *Provider) Query(_ context.Context, query provider.Query) (provider.Answer, error) {
+	p.interfacesLock.RLock()
+	iface, ok := p.interfaces[query.ExporterIP][query.IfIndex]
+	p.interfacesLock.RUnlock()
+	if !ok {
+		return provider.Answer{}, provider.ErrSkipProvider
+	}
+	return provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name: query.ExporterIP.Unmap().String(),
+		},
+		Interface: iface,
+	}, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+)
+
+func TestExporterProvider(t *testing.T) {
+	r := reporter.NewMock(t)
+	p, err := DefaultConfiguration().New(context.Background(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	recorder := p.(provider.Recorder)
+	exporterIP := netip.MustParseAddr("::ffff:192.0.2.1")
+
+	if _, err := p.Query(context.Background(), provider.Query{ExporterIP: exporterIP, IfIndex: 10}); !errors.Is(err, provider.ErrSkipProvider) {
+		t.Fatalf("Query() error == %v, expected %v", err, provider.ErrSkipProvider)
+	}
+
+	for _, tc := range []struct {
+		Pos         helpers.Pos
+		IfIndex     uint
+		Name        string
+		Description string
+		Changed     bool
+	}{
+		{helpers.Mark(), 10, "Gi0/0/10", "Transit", true},
+		{helpers.Mark(), 10, "Gi0/0/10", "Transit", false},
+		{helpers.Mark(), 10, "Gi0/0/10", "Peering", true},
+		{helpers.Mark(), 11, "Gi0/0/11", "", true},
+	} {
+		if got := recorder.Record(exporterIP, tc.IfIndex, tc.Name, tc.Description); got != tc.Changed {
+			t.Errorf("%sRecord() == %v, expected %v", tc.Pos, got, tc.Changed)
+		}
+	}
+
+	got, err := p.Query(context.Background(), provider.Query{ExporterIP: exporterIP, IfIndex: 10})
+	if err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	expected := provider.Answer{
+		Found:     true,
+		Exporter:  provider.Exporter{Name: "192.0.2.1"},
+		Interface: provider.Interface{Name: "Gi0/0/10", Description: "Peering"},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Query() (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_provider_exporter_")
+	expectedMetrics := map[string]string{
+		"interfaces": "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
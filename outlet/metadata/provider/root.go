@@ -49,11 +49,20 @@ type Query struct {
 	IfIndex    uint
 }
 
+// Counters contains the octet counters of an interface.
+type Counters struct {
+	InOctets  uint64
+	OutOctets uint64
+}
+
 // Answer is the answer received from a provider.
 type Answer struct {
 	Found     bool
 	Exporter  Exporter
 	Interface Interface
+	// Counters are the octet counters of the interface, when the provider
+	// polls them.
+	Counters *Counters
 }
 
 // Provider is the interface a provider should implement.
@@ -65,6 +74,14 @@ type Provider interface {
 	Query(ctx context.Context, query Query) (Answer, error)
 }
 
+// Recorder is the interface a provider should implement to receive the
+// interface names and descriptions sent by exporters along with flows.
+type Recorder interface {
+	// Record records the name and the description of an interface of an
+	// exporter. It returns true if the recorded information changed.
+	Record(exporterIP netip.Addr, ifIndex uint, name, description string) bool
+}
+
 // Configuration defines an interface to configure a provider.
 type Configuration interface {
 	// New instantiates a new provider from its configuration. The provided
@@ -29,6 +29,9 @@ type Configuration struct {
 	Agents map[netip.Addr]netip.Addr
 	// Ports is a mapping from exporter IPs to SNMP port
 	Ports *helpers.SubnetMap[uint16]
+	// PollCounters tells if the octet counters of the interfaces should be
+	// polled too
+	PollCounters bool
 }
 
 // Credentials describes credentials for SNMP (both SNMPv2 and SNMPv3 USM security parameters).
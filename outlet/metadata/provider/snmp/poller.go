@@ -80,6 +80,12 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 		fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.18.%d", ifIndex), // ifAlias
 		fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.15.%d", ifIndex), // ifSpeed
 	}
+	if p.config.PollCounters {
+		requests = append(requests,
+			fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.6.%d", ifIndex),  // ifHCInOctets
+			fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.10.%d", ifIndex), // ifHCOutOctets
+		)
+	}
 	var results []gosnmp.SnmpPDU
 	success := false
 
@@ -155,6 +161,18 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 			return 0, false
 		}
 	}
+	processCounter := func(idx int, what string) (uint64, bool) {
+		switch results[idx].Type {
+		case gosnmp.Counter64:
+			return results[idx].Value.(uint64), true
+		case gosnmp.NoSuchInstance, gosnmp.NoSuchObject, gosnmp.Null:
+			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s missing", what)).Inc()
+			return 0, false
+		default:
+			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s unknown type", what)).Inc()
+			return 0, false
+		}
+	}
 	sysNameVal, ok := processStr(0, "sysname")
 	if !ok {
 		return provider.Answer{}, errors.New("unable to get sysName")
@@ -195,7 +213,7 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 	speed = ifSpeedVal
 	if ok {
 		p.metrics.successes.WithLabelValues(exporterStr).Inc()
-		return provider.Answer{
+		answer := provider.Answer{
 			Found: true,
 			Exporter: provider.Exporter{
 				Name: sysNameVal,
@@ -205,7 +223,19 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 				Description: description,
 				Speed:       speed,
 			},
-		}, nil
+		}
+		// Counters are optional
+		if p.config.PollCounters {
+			inOctets, okIn := processCounter(5, "ifhcinoctets")
+			outOctets, okOut := processCounter(6, "ifhcoutoctets")
+			if okIn && okOut {
+				answer.Counters = &provider.Counters{
+					InOctets:  inOctets,
+					OutOctets: outOctets,
+				}
+			}
+		}
+		return answer, nil
 	}
 	return provider.Answer{}, nil
 }
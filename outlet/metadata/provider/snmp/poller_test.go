@@ -76,6 +76,16 @@ func TestPoller(t *testing.T) {
 				},
 			},
 			ExporterIP: netip.MustParseAddr("::ffff:192.0.2.1"),
+		}, {
+			Description: "SNMPv2 with counters",
+			Config: Configuration{
+				PollerRetries: 2,
+				PollerTimeout: 100 * time.Millisecond,
+				Credentials: helpers.MustNewSubnetMap(map[string]Credentials{
+					"::/0": {Communities: []string{"private"}},
+				}),
+				PollCounters: true,
+			},
 		}, {
 			Description: "SNMPv3",
 			Config: Configuration{
@@ -246,6 +256,28 @@ func TestPoller(t *testing.T) {
 								},
 							},
 							// ifAlias.643 missing
+							{
+								OID:  "1.3.6.1.2.1.31.1.1.1.6.641",
+								Type: gosnmp.Counter64,
+								OnGet: func() (any, error) {
+									return uint64(123456789012), nil
+								},
+							},
+							{
+								OID:  "1.3.6.1.2.1.31.1.1.1.10.641",
+								Type: gosnmp.Counter64,
+								OnGet: func() (any, error) {
+									return uint64(987654321), nil
+								},
+							},
+							{
+								OID:  "1.3.6.1.2.1.31.1.1.1.6.642",
+								Type: gosnmp.Counter64,
+								OnGet: func() (any, error) {
+									return uint64(1000), nil
+								},
+							},
+							// ifHCOutOctets.642 missing
 							{
 								OID:  "1.3.6.1.2.1.31.1.1.1.18.645",
 								Type: gosnmp.OctetString,
@@ -285,34 +317,43 @@ func TestPoller(t *testing.T) {
 
 			// Collect results from all queries
 			answer, _ := p.Query(context.Background(), provider.Query{ExporterIP: tc.ExporterIP, IfIndex: 641})
-			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d",
+			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d %v",
 				answer.Found, tc.ExporterIP.Unmap().String(), answer.Exporter.Name,
-				641, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed))
+				641, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed,
+				answer.Counters))
 			answer, _ = p.Query(context.Background(), provider.Query{ExporterIP: tc.ExporterIP, IfIndex: 642})
-			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d",
+			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d %v",
 				answer.Found, tc.ExporterIP.Unmap().String(), answer.Exporter.Name,
-				642, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed))
+				642, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed,
+				answer.Counters))
 			answer, _ = p.Query(context.Background(), provider.Query{ExporterIP: tc.ExporterIP, IfIndex: 643})
-			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d",
+			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d %v",
 				answer.Found, tc.ExporterIP.Unmap().String(), answer.Exporter.Name,
-				643, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed))
+				643, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed,
+				answer.Counters))
 			answer, _ = p.Query(context.Background(), provider.Query{ExporterIP: tc.ExporterIP, IfIndex: 644})
-			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d",
+			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d %v",
 				answer.Found, tc.ExporterIP.Unmap().String(), answer.Exporter.Name,
-				644, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed))
+				644, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed,
+				answer.Counters))
 			answer, _ = p.Query(context.Background(), provider.Query{ExporterIP: tc.ExporterIP, IfIndex: 645})
-			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d",
+			got = append(got, fmt.Sprintf("%v %s %s %d %s %s %d %v",
 				answer.Found, tc.ExporterIP.Unmap().String(), answer.Exporter.Name,
-				645, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed))
+				645, answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed,
+				answer.Counters))
 
 			exporterStr := tc.ExporterIP.Unmap().String()
 			time.Sleep(50 * time.Millisecond)
+			counters := "<nil>"
+			if tc.Config.PollCounters {
+				counters = "&{123456789012 987654321}"
+			}
 			if diff := helpers.Diff(got, []string{
-				fmt.Sprintf(`true %s exporter62 641 Gi0/0/0/0 Transit 10000`, exporterStr),
-				fmt.Sprintf(`true %s exporter62 642 Gi0/0/0/1 Peering 20000`, exporterStr),
-				fmt.Sprintf(`true %s exporter62 643 Gi0/0/0/2  10000`, exporterStr), // no ifAlias
-				fmt.Sprintf(`false %s  644   0`, exporterStr),
-				fmt.Sprintf(`true %s exporter62 645 Gi0/0/0/5 Correct description 1000`, exporterStr),
+				fmt.Sprintf(`true %s exporter62 641 Gi0/0/0/0 Transit 10000 %s`, exporterStr, counters),
+				fmt.Sprintf(`true %s exporter62 642 Gi0/0/0/1 Peering 20000 <nil>`, exporterStr), // no ifHCOutOctets
+				fmt.Sprintf(`true %s exporter62 643 Gi0/0/0/2  10000 <nil>`, exporterStr),        // no ifAlias
+				fmt.Sprintf(`false %s  644   0 <nil>`, exporterStr),
+				fmt.Sprintf(`true %s exporter62 645 Gi0/0/0/5 Correct description 1000 <nil>`, exporterStr),
 			}); diff != "" {
 				t.Fatalf("Poll() (-got, +want):\n%s", diff)
 			}
@@ -325,6 +366,10 @@ func TestPoller(t *testing.T) {
 				fmt.Sprintf(`error_requests_total{error="ifspeed missing",exporter="%s"}`, exporterStr): "1", // 644
 				fmt.Sprintf(`success_requests_total{exporter="%s"}`, exporterStr):                       "4", // 641+642+643+645
 			}
+			if tc.Config.PollCounters {
+				expectedMetrics[fmt.Sprintf(`error_requests_total{error="ifhcinoctets missing",exporter="%s"}`, exporterStr)] = "2"  // 643+645
+				expectedMetrics[fmt.Sprintf(`error_requests_total{error="ifhcoutoctets missing",exporter="%s"}`, exporterStr)] = "3" // 642+643+645
+			}
 			if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 				t.Fatalf("Metrics (-got, +want):\n%s", diff)
 			}
@@ -21,6 +21,11 @@ type exporterInfo struct {
 	Interfaces []exporterInterface `validate:"omitempty"`
 }
 
+// Key returns the key identifying the exporter for incremental updates.
+func (i exporterInfo) Key() string {
+	return i.ExporterSubnet
+}
+
 type exporterInterface struct {
 	IfIndex            uint
 	provider.Interface `validate:"omitempty,dive" mapstructure:",squash"`
@@ -39,12 +39,15 @@ type Component struct {
 	providers              []provider.Provider
 	initialDeadline        time.Time
 
+	counters counterSamples
+
 	metrics struct {
 		cacheRefreshRuns         reporter.Counter
 		cacheRefresh             reporter.Counter
 		providerBreakerOpenCount *reporter.CounterVec
 		providerRequests         reporter.Counter
 		providerErrors           reporter.Counter
+		counterSamplesDropped    reporter.Counter
 	}
 }
 
@@ -113,6 +116,11 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 			Name: "provider_errors_total",
 			Help: "Number of provider errors.",
 		})
+	c.metrics.counterSamplesDropped = r.Counter(
+		reporter.CounterOpts{
+			Name: "counter_samples_dropped_total",
+			Help: "Number of interface counter samples dropped.",
+		})
 	return &c, nil
 }
 
@@ -193,6 +201,28 @@ func (c *Component) Lookup(t time.Time, exporterIP netip.Addr, ifIndex uint) pro
 	return result.(provider.Answer)
 }
 
+// RecordInterface records the name and the description of an interface sent by
+// an exporter. They are handed to the providers accepting them. When they
+// changed, the cached entries for the exporter are removed.
+func (c *Component) RecordInterface(exporterIP netip.Addr, ifIndex uint, name, description string) {
+	changed := false
+	for _, p := range c.providers {
+		if recorder, ok := p.(provider.Recorder); ok {
+			changed = recorder.Record(exporterIP, ifIndex, name, description) || changed
+		}
+	}
+	if changed {
+		c.sc.Invalidate(netip.PrefixFrom(exporterIP, exporterIP.BitLen()))
+	}
+}
+
+// Invalidate removes from the cache the entries of the exporters in the
+// provided prefix. They are polled again on the next lookup. It returns the
+// number of removed entries.
+func (c *Component) Invalidate(prefix netip.Prefix) int {
+	return c.sc.Invalidate(prefix)
+}
+
 // queryProviders queries all providers. It returns the answer for the specific
 // query and cache it.
 func (c *Component) queryProviders(query provider.Query) (provider.Answer, error) {
@@ -229,6 +259,11 @@ func (c *Component) queryProviders(query provider.Query) (provider.Answer, error
 			if err != nil {
 				return err
 			}
+			if answer.Counters != nil {
+				// Counters are not cached
+				c.recordCounters(now, query, answer)
+				answer.Counters = nil
+			}
 			c.sc.Put(now, query, answer)
 			result = answer
 			return nil
@@ -266,6 +301,13 @@ func (c *Component) refreshCacheEntry(exporterIP netip.Addr, ifIndex uint) {
 	c.queryProviders(query)
 }
 
+// FlushCache removes all entries from the cache. They are fetched again from
+// the providers on the next lookup. This is used to free memory.
+func (c *Component) FlushCache() {
+	count := c.sc.Flush()
+	c.r.Info().Int("count", count).Msg("metadata cache flushed")
+}
+
 // expireCache handles cache expiration and refresh.
 func (c *Component) expireCache() {
 	c.sc.Expire(time.Now().Add(-c.config.CacheDuration))
@@ -17,6 +17,7 @@ import (
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/metadata/provider"
+	"akvorado/outlet/metadata/provider/exporter"
 	"akvorado/outlet/metadata/provider/static"
 )
 
@@ -167,12 +168,13 @@ func TestAutoRefresh(t *testing.T) {
 		gotMetrics := r.GetMetrics("akvorado_outlet_metadata_cache_")
 		for _, runs := range []string{"29", "30", "31"} { // 63/2
 			expectedMetrics := map[string]string{
-				`expired_entries_total`: "0",
-				`misses_total`:          "1", // First lookup misses
-				`hits_total`:            "3", // Subsequent ones hits
-				`size_entries`:          "1",
-				`refresh_runs_total`:    runs,
-				`refreshes_total`:       "1", // One refresh (after 1 hour)
+				`expired_entries_total`:     "0",
+				`invalidated_entries_total`: "0",
+				`misses_total`:              "1", // First lookup misses
+				`hits_total`:                "3", // Subsequent ones hits
+				`size_entries`:              "1",
+				`refresh_runs_total`:        runs,
+				`refreshes_total`:           "1", // One refresh (after 1 hour)
 			}
 			if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" && runs == "19" {
 				t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -353,3 +355,104 @@ func TestMultipleProviders(t *testing.T) {
 		t.Fatalf("Lookup() (-got, +want):\n%s", diff)
 	}
 }
+
+func TestRecordInterface(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration()
+	configuration.Providers = []ProviderConfiguration{
+		{Config: exporter.Configuration{}},
+		{Config: static.Configuration{
+			Exporters: helpers.MustNewSubnetMap(map[string]static.ExporterConfiguration{
+				"2001:db8:1::/48": {
+					Exporter: provider.Exporter{
+						Name: "static1",
+					},
+					Default: provider.Interface{
+						Name:  "Default0",
+						Speed: 1000,
+					},
+				},
+			}),
+		}},
+	}
+	c := NewMock(t, r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+	exporterIP := netip.MustParseAddr("2001:db8:1::1")
+
+	// Answered by the static provider
+	c.Lookup(time.Now(), exporterIP, 10)
+	time.Sleep(30 * time.Millisecond)
+	got := c.Lookup(time.Now(), exporterIP, 10)
+	expected := provider.Answer{
+		Found:     true,
+		Exporter:  provider.Exporter{Name: "static1"},
+		Interface: provider.Interface{Name: "Default0", Speed: 1000},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Lookup() (-got, +want):\n%s", diff)
+	}
+
+	// Once recorded, answered by the exporter provider
+	c.RecordInterface(exporterIP, 10, "Gi0/0/10", "Transit")
+	c.Lookup(time.Now(), exporterIP, 10)
+	time.Sleep(30 * time.Millisecond)
+	got = c.Lookup(time.Now(), exporterIP, 10)
+	expected = provider.Answer{
+		Found:     true,
+		Exporter:  provider.Exporter{Name: "2001:db8:1::1"},
+		Interface: provider.Interface{Name: "Gi0/0/10", Description: "Transit"},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Lookup() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	r := reporter.NewMock(t)
+	c := NewMock(t, r, DefaultConfiguration(), Dependencies{Daemon: daemon.NewMock(t)})
+	exporterIP := helpers.AddrTo6(netip.MustParseAddr("127.0.0.1"))
+
+	// Counters are not cached
+	expectMockLookup(t, c, "127.0.0.1", 3010, provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name: "127_0_0_1",
+		},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/3010",
+			Description: "Interface 3010",
+			Speed:       1000,
+		},
+	})
+	expectMockLookup(t, c, "127.0.0.1", 765, provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name: "127_0_0_1",
+		},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/765",
+			Description: "Interface 765",
+			Speed:       1000,
+		},
+	})
+
+	got := c.FlushCounters()
+	for idx := range got {
+		got[idx].Time = time.Time{}
+	}
+	expected := []CounterSample{
+		{
+			ExporterIP:   exporterIP,
+			ExporterName: "127_0_0_1",
+			IfIndex:      3010,
+			IfName:       "Gi0/0/3010",
+			InOctets:     1000,
+			OutOctets:    2000,
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("FlushCounters() (-got, +want):\n%s", diff)
+	}
+	if got := c.FlushCounters(); len(got) != 0 {
+		t.Fatalf("FlushCounters() = %v, expected nothing", got)
+	}
+}
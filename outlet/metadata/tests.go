@@ -26,6 +26,7 @@ type mockProvider struct{}
 //   - ifIndex = 998 → transient error
 //   - ifIndex = 1010 → with metadata for exporter
 //   - ifIndex = 2010 → with metadata for exporter and interface
+//   - ifIndex = 3010 → with counters
 func (mp mockProvider) Query(_ context.Context, query provider.Query) (provider.Answer, error) {
 	ifIndex := query.IfIndex
 	if ifIndex == 999 {
@@ -65,6 +66,14 @@ func (mp mockProvider) Query(_ context.Context, query provider.Query) (provider.
 		answer.Exporter.Tenant = "metadata tenant"
 	}
 
+	// iface with counters
+	if ifIndex == 3010 {
+		answer.Counters = &provider.Counters{
+			InOctets:  1000,
+			OutOctets: 2000,
+		}
+	}
+
 	answer.Found = true
 	return answer, nil
 }
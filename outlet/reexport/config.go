@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import (
+	"net/netip"
+	"time"
+)
+
+// Configuration describes the configuration for the re-export component.
+type Configuration struct {
+	// Collectors are the downstream collectors receiving enriched flows.
+	Collectors []CollectorConfiguration `validate:"dive"`
+	// TemplateRefreshInterval defines how often templates are sent again to
+	// collectors.
+	TemplateRefreshInterval time.Duration `validate:"min=1s"`
+	// FlushInterval defines how long flows can be kept before being sent.
+	FlushInterval time.Duration `validate:"min=10ms"`
+	// MaxMessageSize defines the maximum size of an IPFIX message.
+	MaxMessageSize int `validate:"min=512,max=65507"`
+}
+
+// CollectorConfiguration describes a downstream collector.
+type CollectorConfiguration struct {
+	// Target is the IP address and port of the collector.
+	Target string `validate:"required,hostname_port"`
+	// ObservationDomainID is the observation domain ID to use for this
+	// collector.
+	ObservationDomainID uint32
+	// Filter restricts the flows sent to this collector.
+	Filter FilterConfiguration
+}
+
+// FilterConfiguration restricts the flows sent to a collector. A flow should
+// match all the non-empty criteria.
+type FilterConfiguration struct {
+	// Exporters is a list of subnets the exporter address should belong to.
+	Exporters []netip.Prefix
+	// SrcNets is a list of subnets the source address should belong to.
+	SrcNets []netip.Prefix
+	// DstNets is a list of subnets the destination address should belong to.
+	DstNets []netip.Prefix
+}
+
+// DefaultConfiguration represents the default configuration for the re-export
+// component.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Collectors:              []CollectorConfiguration{},
+		TemplateRefreshInterval: time.Minute,
+		FlushInterval:           time.Second,
+		MaxMessageSize:          1400,
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import (
+	"net/netip"
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestDefaultConfiguration(t *testing.T) {
+	if err := helpers.Validate.Struct(DefaultConfiguration()); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	exporter := netip.MustParseAddr("::ffff:203.0.113.14")
+	src := netip.MustParseAddr("::ffff:192.0.2.10")
+	dst := netip.MustParseAddr("2001:db8::1")
+	cases := []struct {
+		Pos      helpers.Pos
+		Filter   FilterConfiguration
+		Expected bool
+	}{
+		{
+			Pos:      helpers.Mark(),
+			Filter:   FilterConfiguration{},
+			Expected: true,
+		}, {
+			Pos: helpers.Mark(),
+			Filter: FilterConfiguration{
+				Exporters: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+			},
+			Expected: true,
+		}, {
+			Pos: helpers.Mark(),
+			Filter: FilterConfiguration{
+				Exporters: []netip.Prefix{netip.MustParsePrefix("::ffff:203.0.113.0/120")},
+			},
+			Expected: true,
+		}, {
+			Pos: helpers.Mark(),
+			Filter: FilterConfiguration{
+				Exporters: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+			},
+			Expected: false,
+		}, {
+			Pos: helpers.Mark(),
+			Filter: FilterConfiguration{
+				SrcNets: []netip.Prefix{
+					netip.MustParsePrefix("198.51.100.0/24"),
+					netip.MustParsePrefix("192.0.2.0/24"),
+				},
+				DstNets: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+			},
+			Expected: true,
+		}, {
+			Pos: helpers.Mark(),
+			Filter: FilterConfiguration{
+				SrcNets: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+				DstNets: []netip.Prefix{netip.MustParsePrefix("2001:db8:1::/48")},
+			},
+			Expected: false,
+		},
+	}
+	for _, tc := range cases {
+		if got := tc.Filter.match(exporter, src, dst); got != tc.Expected {
+			t.Errorf("%smatch() == %v, expected %v", tc.Pos, got, tc.Expected)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"slices"
+
+	"github.com/netsampler/goflow2/v2/decoders/netflow"
+
+	"akvorado/common/schema"
+)
+
+const (
+	ipfixVersion       = 10
+	ipfixHeaderSize    = 16
+	ipfixSetHeaderSize = 4
+	ipfixTemplateSetID = 2
+)
+
+// family is the address family of a flow. Each family has its own template.
+type family int
+
+const (
+	familyIPv4 family = iota
+	familyIPv6
+	familyCount
+)
+
+// templateIDs are the IDs of the template of each family.
+var templateIDs = [familyCount]uint16{256, 257}
+
+// informationElement maps a column to an IPFIX information element.
+type informationElement struct {
+	ID     uint16
+	Length uint16
+	Column schema.ColumnKey
+}
+
+// informationElements are the elements exported for each family, when the
+// associated column is enabled. The ingress and egress interfaces are not
+// columns and are always exported.
+var informationElements = [familyCount][]informationElement{
+	familyIPv4: {
+		{netflow.IPFIX_FIELD_sourceIPv4Address, 4, schema.ColumnSrcAddr},
+		{netflow.IPFIX_FIELD_destinationIPv4Address, 4, schema.ColumnDstAddr},
+		{netflow.IPFIX_FIELD_sourceIPv4PrefixLength, 1, schema.ColumnSrcNetMask},
+		{netflow.IPFIX_FIELD_destinationIPv4PrefixLength, 1, schema.ColumnDstNetMask},
+		{netflow.IPFIX_FIELD_ipNextHopIPv4Address, 4, schema.ColumnNextHop},
+	},
+	familyIPv6: {
+		{netflow.IPFIX_FIELD_sourceIPv6Address, 16, schema.ColumnSrcAddr},
+		{netflow.IPFIX_FIELD_destinationIPv6Address, 16, schema.ColumnDstAddr},
+		{netflow.IPFIX_FIELD_sourceIPv6PrefixLength, 1, schema.ColumnSrcNetMask},
+		{netflow.IPFIX_FIELD_destinationIPv6PrefixLength, 1, schema.ColumnDstNetMask},
+		{netflow.IPFIX_FIELD_ipNextHopIPv6Address, 16, schema.ColumnNextHop},
+	},
+}
+
+// commonInformationElements are the elements exported for both families.
+var commonInformationElements = []informationElement{
+	{netflow.IPFIX_FIELD_flowStartSeconds, 4, schema.ColumnTimeReceived},
+	{netflow.IPFIX_FIELD_flowEndSeconds, 4, schema.ColumnTimeReceived},
+	{netflow.IPFIX_FIELD_exporterIPv6Address, 16, schema.ColumnExporterAddress},
+	{netflow.IPFIX_FIELD_octetDeltaCount, 8, schema.ColumnBytes},
+	{netflow.IPFIX_FIELD_packetDeltaCount, 8, schema.ColumnPackets},
+	{netflow.IPFIX_FIELD_samplingInterval, 4, schema.ColumnSamplingRate},
+	{netflow.IPFIX_FIELD_protocolIdentifier, 1, schema.ColumnProto},
+	{netflow.IPFIX_FIELD_sourceTransportPort, 2, schema.ColumnSrcPort},
+	{netflow.IPFIX_FIELD_destinationTransportPort, 2, schema.ColumnDstPort},
+	{netflow.IPFIX_FIELD_ipClassOfService, 1, schema.ColumnIPTos},
+	{netflow.IPFIX_FIELD_tcpControlBits, 2, schema.ColumnTCPFlags},
+	{netflow.IPFIX_FIELD_forwardingStatus, 1, schema.ColumnForwardingStatus},
+	{netflow.IPFIX_FIELD_ingressInterface, 4, 0},
+	{netflow.IPFIX_FIELD_egressInterface, 4, 0},
+	{netflow.IPFIX_FIELD_bgpSourceAsNumber, 4, schema.ColumnSrcAS},
+	{netflow.IPFIX_FIELD_bgpDestinationAsNumber, 4, schema.ColumnDstAS},
+	{netflow.IPFIX_FIELD_bgpNextAdjacentAsNumber, 4, schema.ColumnDst1stAS},
+	{netflow.IPFIX_FIELD_vlanId, 2, schema.ColumnSrcVlan},
+	{netflow.IPFIX_FIELD_postVlanId, 2, schema.ColumnDstVlan},
+	{netflow.IPFIX_FIELD_sourceMacAddress, 6, schema.ColumnSrcMAC},
+	{netflow.IPFIX_FIELD_destinationMacAddress, 6, schema.ColumnDstMAC},
+}
+
+// template is an IPFIX template for one family.
+type template struct {
+	ID       uint16
+	Elements []informationElement
+	Length   int // length of a data record
+}
+
+// newTemplates builds the templates for each family from the enabled columns
+// of the schema.
+func newTemplates(sch *schema.Component) [familyCount]template {
+	var templates [familyCount]template
+	for f := range familyCount {
+		t := template{ID: templateIDs[f]}
+		for _, ie := range slices.Concat(informationElements[f], commonInformationElements) {
+			if ie.Column != 0 {
+				if column, ok := sch.LookupColumnByKey(ie.Column); !ok || column.Disabled {
+					continue
+				}
+			}
+			t.Elements = append(t.Elements, ie)
+			t.Length += int(ie.Length)
+		}
+		templates[f] = t
+	}
+	return templates
+}
+
+// appendTemplateSet appends a template set defining the provided templates.
+func appendTemplateSet(buf []byte, templates []template) []byte {
+	start := len(buf)
+	buf = binary.BigEndian.AppendUint16(buf, ipfixTemplateSetID)
+	buf = binary.BigEndian.AppendUint16(buf, 0) // length, set later
+	for _, t := range templates {
+		buf = binary.BigEndian.AppendUint16(buf, t.ID)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(t.Elements)))
+		for _, ie := range t.Elements {
+			buf = binary.BigEndian.AppendUint16(buf, ie.ID)
+			buf = binary.BigEndian.AppendUint16(buf, ie.Length)
+		}
+	}
+	binary.BigEndian.PutUint16(buf[start+2:], uint16(len(buf)-start))
+	return buf
+}
+
+// appendRecord appends a data record for the current flow using the provided
+// template.
+func (t *template) appendRecord(buf []byte, bf *schema.FlowMessage) []byte {
+	inIf, outIf := bf.CurrentInterfaces()
+	for _, ie := range t.Elements {
+		switch ie.ID {
+		case netflow.IPFIX_FIELD_ingressInterface:
+			buf = binary.BigEndian.AppendUint32(buf, inIf)
+		case netflow.IPFIX_FIELD_egressInterface:
+			buf = binary.BigEndian.AppendUint32(buf, outIf)
+		default:
+			switch ie.Column {
+			case schema.ColumnSrcAddr, schema.ColumnDstAddr, schema.ColumnNextHop, schema.ColumnExporterAddress:
+				buf = appendAddress(buf, bf.CurrentIPv6(ie.Column), ie.Length)
+			default:
+				buf = appendUint(buf, bf.CurrentUint(ie.Column), ie.Length)
+			}
+		}
+	}
+	return buf
+}
+
+// appendUint appends an unsigned integer encoded on the provided number of
+// bytes.
+func appendUint(buf []byte, value uint64, length uint16) []byte {
+	for i := int(length) - 1; i >= 0; i-- {
+		buf = append(buf, byte(value>>(8*i)))
+	}
+	return buf
+}
+
+// appendAddress appends an IPv4 address if length is 4 or an IPv6 address
+// otherwise. Invalid addresses or addresses from another family are encoded as
+// zeros.
+func appendAddress(buf []byte, addr netip.Addr, length uint16) []byte {
+	if length == 4 {
+		addr = addr.Unmap()
+		if !addr.Is4() {
+			return append(buf, 0, 0, 0, 0)
+		}
+		v := addr.As4()
+		return append(buf, v[:]...)
+	}
+	if !addr.IsValid() {
+		return append(buf, make([]byte, 16)...)
+	}
+	v := addr.As16()
+	return append(buf, v[:]...)
+}
+
+// appendHeader appends an IPFIX message header. The length is set by
+// finalizeMessage.
+func appendHeader(buf []byte, exportTime, sequence, domainID uint32) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, ipfixVersion)
+	buf = binary.BigEndian.AppendUint16(buf, 0)
+	buf = binary.BigEndian.AppendUint32(buf, exportTime)
+	buf = binary.BigEndian.AppendUint32(buf, sequence)
+	buf = binary.BigEndian.AppendUint32(buf, domainID)
+	return buf
+}
+
+// finalizeMessage sets the length of an IPFIX message.
+func finalizeMessage(buf []byte) []byte {
+	binary.BigEndian.PutUint16(buf[2:], uint16(len(buf)))
+	return buf
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import "akvorado/common/reporter"
+
+type metrics struct {
+	messages *reporter.CounterVec
+	records  *reporter.CounterVec
+	errors   *reporter.CounterVec
+	paused   reporter.Counter
+}
+
+// initMetrics initialize the metrics for the re-export component.
+func (c *Component) initMetrics() {
+	c.metrics.messages = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "messages_total",
+			Help: "Number of IPFIX messages sent.",
+		},
+		[]string{"target", "type"},
+	)
+	c.metrics.records = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "records_total",
+			Help: "Number of flow records sent.",
+		},
+		[]string{"target"},
+	)
+	c.metrics.errors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors while sending IPFIX messages.",
+		},
+		[]string{"target"},
+	)
+	c.metrics.paused = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "paused_records_total",
+			Help: "Number of flow records not sent while paused.",
+		},
+	)
+}
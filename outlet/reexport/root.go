@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package reexport sends enriched flows as IPFIX to downstream collectors. Each
+// core worker gets its own re-export worker. They are tracked by the component
+// to flush pending flows regularly.
+package reexport
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+// Component represents the re-export component.
+type Component struct {
+	r      *reporter.Reporter
+	d      *Dependencies
+	t      tomb.Tomb
+	config Configuration
+
+	templates   [familyCount]template
+	collectors  []*collector
+	workersLock sync.Mutex
+	workers     map[*Worker]struct{}
+	errLogger   reporter.Logger
+	paused      atomic.Bool
+
+	metrics metrics
+}
+
+// Dependencies define the dependencies of the re-export component.
+type Dependencies struct {
+	Daemon daemon.Component
+	Schema *schema.Component
+}
+
+// collector is a downstream collector.
+type collector struct {
+	config   CollectorConfiguration
+	conn     net.Conn
+	sequence atomic.Uint32 // number of data records sent
+}
+
+// New creates a new re-export component.
+func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	c := Component{
+		r:      r,
+		d:      &dependencies,
+		config: configuration,
+
+		templates: newTemplates(dependencies.Schema),
+		workers:   map[*Worker]struct{}{},
+		errLogger: r.Sample(reporter.BurstSampler(time.Minute, 3)),
+	}
+	for _, cc := range configuration.Collectors {
+		c.collectors = append(c.collectors, &collector{config: cc})
+	}
+	c.d.Daemon.Track(&c.t, "outlet/reexport")
+	c.initMetrics()
+	return &c, nil
+}
+
+// Start starts the re-export component.
+func (c *Component) Start() error {
+	if len(c.collectors) == 0 {
+		return nil
+	}
+	c.r.Info().Msg("starting re-export component")
+	for _, collector := range c.collectors {
+		conn, err := net.Dial("udp", collector.config.Target)
+		if err != nil {
+			return fmt.Errorf("cannot create socket to %q: %w", collector.config.Target, err)
+		}
+		collector.conn = conn
+	}
+	c.sendTemplates()
+
+	c.t.Go(func() error {
+		templateTicker := time.NewTicker(c.config.TemplateRefreshInterval)
+		defer templateTicker.Stop()
+		flushTicker := time.NewTicker(c.config.FlushInterval)
+		defer flushTicker.Stop()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-templateTicker.C:
+				c.sendTemplates()
+			case <-flushTicker.C:
+				c.workersLock.Lock()
+				for w := range c.workers {
+					w.Flush()
+				}
+				c.workersLock.Unlock()
+			}
+		}
+	})
+	return nil
+}
+
+// Stop stops the re-export component. Workers should have been closed before.
+func (c *Component) Stop() error {
+	if len(c.collectors) == 0 {
+		return nil
+	}
+	defer c.r.Info().Msg("re-export component stopped")
+	c.r.Info().Msg("stopping re-export component")
+	c.t.Kill(nil)
+	err := c.t.Wait()
+	for _, collector := range c.collectors {
+		if collector.conn != nil {
+			collector.conn.Close()
+		}
+	}
+	return err
+}
+
+// Pause stops (or resumes) the export of flows. Flows received while paused
+// are not exported. This is used to shed load.
+func (c *Component) Pause(pause bool) {
+	if c.paused.Swap(pause) == pause {
+		return
+	}
+	if pause {
+		c.r.Warn().Msg("pause re-export")
+	} else {
+		c.r.Info().Msg("resume re-export")
+	}
+}
+
+// sendTemplates sends the templates to all collectors.
+func (c *Component) sendTemplates() {
+	for _, collector := range c.collectors {
+		buf := make([]byte, 0, ipfixHeaderSize+ipfixSetHeaderSize+256)
+		buf = appendHeader(buf, uint32(time.Now().Unix()),
+			collector.sequence.Load(), collector.config.ObservationDomainID)
+		buf = appendTemplateSet(buf, c.templates[:])
+		c.send(collector, "template", finalizeMessage(buf), 0)
+	}
+}
+
+// send sends an IPFIX message to the provided collector.
+func (c *Component) send(collector *collector, kind string, message []byte, records int) {
+	target := collector.config.Target
+	if _, err := collector.conn.Write(message); err != nil {
+		c.metrics.errors.WithLabelValues(target).Inc()
+		c.errLogger.Err(err).Str("target", target).Msg("cannot send IPFIX message")
+		return
+	}
+	c.metrics.messages.WithLabelValues(target, kind).Inc()
+	c.metrics.records.WithLabelValues(target).Add(float64(records))
+}
+
+// match tells if the provided addresses match the filter.
+func (fc FilterConfiguration) match(exporter, src, dst netip.Addr) bool {
+	return matchPrefixes(fc.Exporters, exporter) &&
+		matchPrefixes(fc.SrcNets, src) &&
+		matchPrefixes(fc.DstNets, dst)
+}
+
+// matchPrefixes tells if the address belongs to one of the prefixes. An empty
+// list of prefixes matches any address.
+func matchPrefixes(prefixes []netip.Prefix, addr netip.Addr) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	unmapped := addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) || prefix.Contains(unmapped) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/netsampler/goflow2/v2/decoders/netflow"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+// listen creates a UDP socket to receive IPFIX messages.
+func listen(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("ListenUDP() error:\n%+v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receive decodes the next IPFIX message received on the provided socket. The
+// version is skipped as DecodeMessageIPFIX() expects it to be consumed.
+func receive(t *testing.T, conn *net.UDPConn, templates netflow.NetFlowTemplateSystem) netflow.IPFIXPacket {
+	t.Helper()
+	buf := make([]byte, 9000)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() error:\n%+v", err)
+	}
+	var packet netflow.IPFIXPacket
+	if err := netflow.DecodeMessageIPFIX(bytes.NewBuffer(buf[2:n]), templates, &packet); err != nil {
+		t.Fatalf("DecodeMessageIPFIX() error:\n%+v", err)
+	}
+	return packet
+}
+
+func TestReexport(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	conn1 := listen(t)
+	conn2 := listen(t)
+	config := DefaultConfiguration()
+	config.FlushInterval = time.Hour
+	config.Collectors = []CollectorConfiguration{
+		{
+			Target:              conn1.LocalAddr().String(),
+			ObservationDomainID: 10,
+		}, {
+			Target:              conn2.LocalAddr().String(),
+			ObservationDomainID: 20,
+			Filter: FilterConfiguration{
+				DstNets: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+			},
+		},
+	}
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		Schema: sch,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Templates are sent on start
+	templates1 := netflow.CreateTemplateSystem()
+	templates2 := netflow.CreateTemplateSystem()
+	packet := receive(t, conn1, templates1)
+	if packet.ObservationDomainId != 10 || len(packet.FlowSets) != 1 {
+		t.Fatalf("receive() got unexpected template message: %+v", packet)
+	}
+	receive(t, conn2, templates2)
+
+	// Export an IPv4 flow and an IPv6 flow
+	bf := sch.NewFlowMessage()
+	w := c.NewWorker(bf)
+	bf.TimeReceived = 1_700_000_000
+	bf.SamplingRate = 1000
+	bf.ExporterAddress = netip.MustParseAddr("::ffff:203.0.113.14")
+	bf.SrcAddr = netip.MustParseAddr("::ffff:192.0.2.10")
+	bf.DstAddr = netip.MustParseAddr("::ffff:198.51.100.20")
+	bf.SrcAS = 64501
+	bf.InIf = 10
+	bf.OutIf = 20
+	bf.AppendUint(schema.ColumnBytes, 1500)
+	bf.AppendUint(schema.ColumnPackets, 2)
+	bf.AppendUint(schema.ColumnProto, 6)
+	bf.AppendUint(schema.ColumnDstPort, 443)
+	w.Export()
+	bf.Finalize()
+	bf.ExporterAddress = netip.MustParseAddr("::ffff:203.0.113.14")
+	bf.SrcAddr = netip.MustParseAddr("2001:db8:1::10")
+	bf.DstAddr = netip.MustParseAddr("2001:db8:2::20")
+	bf.AppendUint(schema.ColumnBytes, 100)
+	bf.AppendUint(schema.ColumnPackets, 1)
+	w.Export()
+	bf.Finalize()
+	w.Close()
+
+	// First collector gets both flows, in two messages
+	got := map[uint16]map[uint16]any{}
+	for range 2 {
+		packet := receive(t, conn1, templates1)
+		for _, flowSet := range packet.FlowSets {
+			dataFlowSet, ok := flowSet.(netflow.DataFlowSet)
+			if !ok || len(dataFlowSet.Records) != 1 {
+				t.Fatalf("receive() got unexpected data message: %+v", packet)
+			}
+			values := map[uint16]any{}
+			for _, field := range dataFlowSet.Records[0].Values {
+				values[field.Type] = field.Value
+			}
+			got[dataFlowSet.Id] = values
+		}
+	}
+	ipv4 := got[templateIDs[familyIPv4]]
+	for ie, expected := range map[uint16][]byte{
+		netflow.IPFIX_FIELD_sourceIPv4Address:        {192, 0, 2, 10},
+		netflow.IPFIX_FIELD_destinationIPv4Address:   {198, 51, 100, 20},
+		netflow.IPFIX_FIELD_octetDeltaCount:          {0, 0, 0, 0, 0, 0, 5, 220},
+		netflow.IPFIX_FIELD_packetDeltaCount:         {0, 0, 0, 0, 0, 0, 0, 2},
+		netflow.IPFIX_FIELD_samplingInterval:         {0, 0, 3, 232},
+		netflow.IPFIX_FIELD_protocolIdentifier:       {6},
+		netflow.IPFIX_FIELD_destinationTransportPort: {1, 187},
+		netflow.IPFIX_FIELD_ingressInterface:         {0, 0, 0, 10},
+		netflow.IPFIX_FIELD_egressInterface:          {0, 0, 0, 20},
+		netflow.IPFIX_FIELD_bgpSourceAsNumber:        {0, 0, 251, 245},
+		netflow.IPFIX_FIELD_flowEndSeconds:           {101, 83, 241, 0},
+	} {
+		if diff := helpers.Diff(ipv4[ie], expected); diff != "" {
+			t.Errorf("IPv4 record, IE %s (-got, +want):\n%s", netflow.IPFIXTypeToString(ie), diff)
+		}
+	}
+	ipv6 := got[templateIDs[familyIPv6]]
+	for ie, expected := range map[uint16][]byte{
+		netflow.IPFIX_FIELD_sourceIPv6Address: netip.MustParseAddr("2001:db8:1::10").AsSlice(),
+		netflow.IPFIX_FIELD_octetDeltaCount:   {0, 0, 0, 0, 0, 0, 0, 100},
+	} {
+		if diff := helpers.Diff(ipv6[ie], expected); diff != "" {
+			t.Errorf("IPv6 record, IE %s (-got, +want):\n%s", netflow.IPFIXTypeToString(ie), diff)
+		}
+	}
+
+	// Second collector only gets the IPv6 flow
+	packet = receive(t, conn2, templates2)
+	if dataFlowSet, ok := packet.FlowSets[0].(netflow.DataFlowSet); !ok || dataFlowSet.Id != templateIDs[familyIPv6] {
+		t.Fatalf("receive() got unexpected data message: %+v", packet)
+	}
+	if packet.SequenceNumber != 0 {
+		t.Errorf("receive() sequence number == %d, expected 0", packet.SequenceNumber)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_reexport_", "records_total")
+	expectedMetrics := map[string]string{
+		`records_total{target="` + conn1.LocalAddr().String() + `"}`: "2",
+		`records_total{target="` + conn2.LocalAddr().String() + `"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Errorf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestNoCollector(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon: daemon.NewMock(t),
+		Schema: sch,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+	if w := c.NewWorker(sch.NewFlowMessage()); w != nil {
+		t.Fatal("NewWorker() should return nil without collectors")
+	}
+}
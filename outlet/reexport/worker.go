@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package reexport
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"akvorado/common/schema"
+)
+
+// Worker encodes the flows of a core decoder and sends them to the collectors.
+// Export() should only be called from the goroutine of the core decoder.
+type Worker struct {
+	c      *Component
+	bf     *schema.FlowMessage
+	record []byte
+
+	mu      sync.Mutex
+	pending [][familyCount]pendingMessage // indexed by collector
+}
+
+// pendingMessage is an IPFIX message being built with a single data set.
+type pendingMessage struct {
+	buf     []byte
+	records int
+}
+
+// NewWorker creates a new re-export worker for the provided flow message. It
+// returns nil when there is no collector.
+func (c *Component) NewWorker(bf *schema.FlowMessage) *Worker {
+	if len(c.collectors) == 0 {
+		return nil
+	}
+	w := &Worker{
+		c:       c,
+		bf:      bf,
+		pending: make([][familyCount]pendingMessage, len(c.collectors)),
+	}
+	c.workersLock.Lock()
+	c.workers[w] = struct{}{}
+	c.workersLock.Unlock()
+	return w
+}
+
+// Export queues the current flow for the collectors whose filter matches. It
+// should be called before the flow is finalized.
+func (w *Worker) Export() {
+	if w.c.paused.Load() {
+		w.c.metrics.paused.Inc()
+		return
+	}
+	exporter := w.bf.CurrentIPv6(schema.ColumnExporterAddress)
+	src := w.bf.CurrentIPv6(schema.ColumnSrcAddr)
+	dst := w.bf.CurrentIPv6(schema.ColumnDstAddr)
+	f := familyIPv6
+	if src.Unmap().Is4() {
+		f = familyIPv4
+	}
+	template := &w.c.templates[f]
+	w.record = template.appendRecord(w.record[:0], w.bf)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, collector := range w.c.collectors {
+		if !collector.config.Filter.match(exporter, src, dst) {
+			continue
+		}
+		p := &w.pending[i][f]
+		if p.records > 0 && len(p.buf)+len(w.record) > w.c.config.MaxMessageSize {
+			w.flushOne(i, f)
+		}
+		if p.records == 0 {
+			p.buf = appendHeader(p.buf[:0], 0, 0, collector.config.ObservationDomainID)
+			p.buf = binary.BigEndian.AppendUint16(p.buf, template.ID)
+			p.buf = binary.BigEndian.AppendUint16(p.buf, 0)
+		}
+		p.buf = append(p.buf, w.record...)
+		p.records++
+	}
+}
+
+// Flush sends the pending flows.
+func (w *Worker) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.pending {
+		for f := range familyCount {
+			if w.pending[i][f].records > 0 {
+				w.flushOne(i, f)
+			}
+		}
+	}
+}
+
+// Close sends the pending flows and unregisters the worker.
+func (w *Worker) Close() {
+	w.Flush()
+	w.c.workersLock.Lock()
+	delete(w.c.workers, w)
+	w.c.workersLock.Unlock()
+}
+
+// flushOne sends the pending message for the provided collector and family.
+// The lock should be held.
+func (w *Worker) flushOne(i int, f family) {
+	p := &w.pending[i][f]
+	collector := w.c.collectors[i]
+	records := uint32(p.records)
+	sequence := collector.sequence.Add(records) - records
+	binary.BigEndian.PutUint32(p.buf[4:], uint32(time.Now().Unix()))
+	binary.BigEndian.PutUint32(p.buf[8:], sequence)
+	binary.BigEndian.PutUint16(p.buf[ipfixHeaderSize+2:], uint16(len(p.buf)-ipfixHeaderSize))
+	w.c.send(collector, "data", finalizeMessage(p.buf), p.records)
+	p.records = 0
+}
@@ -203,9 +203,10 @@ func (p *Provider) Refresh(ctx context.Context) {
 }
 
 // Lookup does an lookup on one of the specified RIS Instances and returns the
-// well known bmp lookup result. NextHopIP is ignored, but maintained for
-// compatibility to the internal bmp
-func (p *Provider) Lookup(ctx context.Context, ip, _, agent netip.Addr) (provider.LookupResult, error) {
+// well known bmp lookup result. NextHopIP and the route distinguisher are
+// ignored, but maintained for compatibility to the internal bmp. The VRF is
+// selected through the configuration.
+func (p *Provider) Lookup(ctx context.Context, ip, _, agent netip.Addr, _ uint64) (provider.LookupResult, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
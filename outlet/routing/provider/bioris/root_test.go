@@ -438,7 +438,7 @@ func TestBioRIS(t *testing.T) {
 		got, err := p.Lookup(context.Background(),
 			netip.MustParseAddr("2001:db8:1::10"),
 			netip.Addr{},
-			netip.MustParseAddr("2001:db8::7"))
+			netip.MustParseAddr("2001:db8::7"), 0)
 		if err != nil {
 			t.Fatalf("Lookup() error:\n%+v", err)
 		}
@@ -14,6 +14,15 @@ import (
 type Configuration struct {
 	// Listen tells on which port the BMP server should listen to.
 	Listen string `validate:"listen"`
+	// MaxPrefixes is the maximum number of routes to accept from each peer
+	// connected to Listen. When 0, there is no limit.
+	MaxPrefixes uint
+	// Families is the list of AFI/SAFI to accept from peers connected to
+	// Listen. When empty, all families are accepted.
+	Families []Family
+	// PeerGroups is a list of additional peer groups, each with its own
+	// listening endpoint and policy.
+	PeerGroups []PeerGroupConfiguration `validate:"dive"`
 	// RDs list the RDs to keep. If none are specified, all
 	// received routes are processed. 0 match an absence of RD.
 	RDs []RD
@@ -32,6 +41,22 @@ type Configuration struct {
 	ReceiveBuffer uint
 }
 
+// PeerGroupConfiguration describes a group of BMP peers sharing a listening
+// endpoint, a route limit and a list of accepted families.
+type PeerGroupConfiguration struct {
+	// Name is the name of the peer group.
+	Name string `validate:"required,ne=default"`
+	// Listen tells on which port the BMP server should listen to for this
+	// peer group.
+	Listen string `validate:"listen"`
+	// MaxPrefixes is the maximum number of routes to accept from each peer of
+	// the group. When 0, there is no limit.
+	MaxPrefixes uint
+	// Families is the list of AFI/SAFI to accept. When empty, all families
+	// are accepted.
+	Families []Family
+}
+
 // DefaultConfiguration represents the default configuration for the BMP server
 func DefaultConfiguration() provider.Configuration {
 	return Configuration{
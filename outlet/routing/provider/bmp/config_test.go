@@ -5,8 +5,12 @@ package bmp
 
 import (
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/osrg/gobgp/v4/pkg/packet/bgp"
 )
 
 func TestDefaultConfiguration(t *testing.T) {
@@ -14,3 +18,61 @@ func TestDefaultConfiguration(t *testing.T) {
 		t.Fatalf("validate.Struct() error:\n%+v", err)
 	}
 }
+
+func TestConfigurationUnmarshallerHook(t *testing.T) {
+	helpers.TestConfigurationDecode(t, helpers.ConfigurationDecodeCases{
+		{
+			Description: "peer groups",
+			Initial:     func() any { return DefaultConfiguration() },
+			Configuration: func() any {
+				return gin.H{
+					"max-prefixes": 1000,
+					"peer-groups": []gin.H{
+						{
+							"name":         "route-servers",
+							"listen":       ":10180",
+							"max-prefixes": 2_000_000,
+							"families":     []string{"ipv4-unicast", "ipv6-unicast"},
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Listen:             ":10179",
+				MaxPrefixes:        1000,
+				CollectASNs:        true,
+				CollectASPaths:     true,
+				CollectCommunities: true,
+				Keep:               5 * time.Minute,
+				PeerGroups: []PeerGroupConfiguration{
+					{
+						Name:        "route-servers",
+						Listen:      ":10180",
+						MaxPrefixes: 2_000_000,
+						Families:    []Family{Family(bgp.RF_IPv4_UC), Family(bgp.RF_IPv6_UC)},
+					},
+				},
+			},
+		}, {
+			Description: "unknown family",
+			Initial:     func() any { return DefaultConfiguration() },
+			Configuration: func() any {
+				return gin.H{
+					"families": []string{"ipv5-unicast"},
+				}
+			},
+			Error: true,
+		}, {
+			Description: "reserved peer group name",
+			Initial:     func() any { return DefaultConfiguration() },
+			Configuration: func() any {
+				return gin.H{
+					"peer-groups": []gin.H{
+						{"name": "default", "listen": ":10180"},
+					},
+				}
+			},
+			Error: true,
+		},
+	})
+}
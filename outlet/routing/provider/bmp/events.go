@@ -30,6 +30,9 @@ type peerInfo struct {
 	reference          uint32                   // used as a reference in the RIB
 	staleUntil         time.Time                // when to remove because it is stale
 	marshallingOptions []*bgp.MarshallingOption // decoding option (add-path mostly)
+	group              *peerGroup               // peer group of the peer
+	routes             uint                     // number of routes in the RIB
+	limitReached       bool                     // route limit has been reached
 }
 
 // peerKeyFromBMPPeerHeader computes the peer key from the BMP peer header.
@@ -80,7 +83,7 @@ func (p *Provider) removeStalePeers() {
 	p.scheduleStalePeersRemoval()
 }
 
-func (p *Provider) addPeer(pkey peerKey) *peerInfo {
+func (p *Provider) addPeer(pkey peerKey, group *peerGroup) *peerInfo {
 	p.lastPeerReference++
 	if p.lastPeerReference == 0 {
 		// This is a very unlikely event, but we don't
@@ -91,6 +94,7 @@ func (p *Provider) addPeer(pkey peerKey) *peerInfo {
 	}
 	pinfo := &peerInfo{
 		reference: p.lastPeerReference,
+		group:     group,
 	}
 	p.peers[pkey] = pinfo
 	return pinfo
@@ -159,7 +163,7 @@ func (p *Provider) handleConnectionUp(exporter netip.AddrPort) {
 }
 
 // handlePeerUpNotification handles a new peer.
-func (p *Provider) handlePeerUpNotification(pkey peerKey, body *bmp.BMPPeerUpNotification) {
+func (p *Provider) handlePeerUpNotification(pkey peerKey, group *peerGroup, body *bmp.BMPPeerUpNotification) {
 	if body.ReceivedOpenMsg == nil || body.SentOpenMsg == nil {
 		return
 	}
@@ -175,7 +179,7 @@ func (p *Provider) handlePeerUpNotification(pkey peerKey, body *bmp.BMPPeerUpNot
 	} else {
 		// Peer does not exist at all
 		p.metrics.peers.WithLabelValues(exporterStr).Inc()
-		pinfo = p.addPeer(pkey)
+		pinfo = p.addPeer(pkey, group)
 	}
 
 	// Check for ADD-PATH support.
@@ -222,7 +226,7 @@ func (p *Provider) handlePeerUpNotification(pkey peerKey, body *bmp.BMPPeerUpNot
 		Msgf("new peer %s from exporter %s", peerStr, exporterStr)
 }
 
-func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitoring) {
+func (p *Provider) handleRouteMonitoring(pkey peerKey, group *peerGroup, body *bmp.BMPRouteMonitoring) {
 	// We expect to have a BGP update message
 	if body.BGPUpdate == nil || body.BGPUpdate.Body == nil {
 		return
@@ -249,7 +253,7 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 		p.r.Info().Msgf("received route monitoring from exporter %s for peer %s, but no peer up",
 			exporterStr, peerStr)
 		p.metrics.peers.WithLabelValues(exporterStr).Inc()
-		pinfo = p.addPeer(pkey)
+		pinfo = p.addPeer(pkey, group)
 	}
 
 	var nh netip.Addr
@@ -291,6 +295,48 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 	added := 0
 	removed := 0
 
+	// addPrefix adds a route to the RIB, unless the family is not accepted or
+	// the peer has reached its route limit.
+	addPrefix := func(pfx netip.Prefix, n nlri) {
+		if !pinfo.group.isAcceptedFamily(n.family) {
+			p.metrics.rejectedRoutes.WithLabelValues(exporterStr, "family").Inc()
+			return
+		}
+		rt := route{
+			peer:       pinfo.reference,
+			nlri:       p.rib.nlris.Put(n),
+			nextHop:    p.rib.nextHops.Put(nextHop(nh)),
+			attributes: p.rib.rtas.Put(rta),
+			prefixLen:  uint8(pfx.Bits()),
+		}
+		if p.rib.AddPrefix(pfx, rt) == 0 {
+			return
+		}
+		if limit := pinfo.group.maxPrefixes; limit > 0 && pinfo.routes >= limit {
+			p.rib.RemovePrefix(pfx, rt)
+			p.metrics.rejectedRoutes.WithLabelValues(exporterStr, "max-prefixes").Inc()
+			if !pinfo.limitReached {
+				pinfo.limitReached = true
+				p.r.Warn().Msgf("peer %s from exporter %s reached the limit of %d routes (group %s)",
+					peerStr, exporterStr, limit, pinfo.group.name)
+			}
+			return
+		}
+		pinfo.routes++
+		added++
+	}
+	// removePrefix removes a route from the RIB.
+	removePrefix := func(pfx netip.Prefix, n nlri) {
+		if nlriRef, ok := p.rib.nlris.Ref(n); ok {
+			count := p.rib.RemovePrefix(pfx, route{
+				peer: pinfo.reference,
+				nlri: nlriRef,
+			})
+			pinfo.routes -= uint(count)
+			removed += count
+		}
+	}
+
 	// Regular NLRI and withdrawn routes
 	if pkey.ptype == bmp.BMP_PEER_TYPE_L3VPN || p.isAcceptedRD(0) {
 		// We know we have IPv4 NLRI
@@ -299,17 +345,10 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			if !ok {
 				continue
 			}
-			pfx := helpers.PrefixTo6(v4UCPrefix.Prefix)
-			added += p.rib.AddPrefix(pfx, route{
-				peer: pinfo.reference,
-				nlri: p.rib.nlris.Put(nlri{
-					family: bgp.RF_IPv4_UC,
-					path:   path.ID,
-					rd:     pkey.distinguisher,
-				}),
-				nextHop:    p.rib.nextHops.Put(nextHop(nh)),
-				attributes: p.rib.rtas.Put(rta),
-				prefixLen:  uint8(pfx.Bits()),
+			addPrefix(helpers.PrefixTo6(v4UCPrefix.Prefix), nlri{
+				family: bgp.RF_IPv4_UC,
+				path:   path.ID,
+				rd:     pkey.distinguisher,
 			})
 		}
 		for _, path := range update.WithdrawnRoutes {
@@ -317,17 +356,11 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			if !ok {
 				continue
 			}
-			pfx := helpers.PrefixTo6(v4UCPrefix.Prefix)
-			if nlriRef, ok := p.rib.nlris.Ref(nlri{
+			removePrefix(helpers.PrefixTo6(v4UCPrefix.Prefix), nlri{
 				family: bgp.RF_IPv4_UC,
 				path:   path.ID,
 				rd:     pkey.distinguisher,
-			}); ok {
-				removed += p.rib.RemovePrefix(pfx, route{
-					peer: pinfo.reference,
-					nlri: nlriRef,
-				})
-			}
+			})
 		}
 	}
 
@@ -372,28 +405,17 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			}
 			switch attr.(type) {
 			case *bgp.PathAttributeMpReachNLRI:
-				added += p.rib.AddPrefix(pfx, route{
-					peer: pinfo.reference,
-					nlri: p.rib.nlris.Put(nlri{
-						family: family,
-						rd:     rd,
-						path:   path.ID,
-					}),
-					nextHop:    p.rib.nextHops.Put(nextHop(nh)),
-					attributes: p.rib.rtas.Put(rta),
-					prefixLen:  uint8(pfx.Bits()),
+				addPrefix(pfx, nlri{
+					family: family,
+					rd:     rd,
+					path:   path.ID,
 				})
 			case *bgp.PathAttributeMpUnreachNLRI:
-				if nlriRef, ok := p.rib.nlris.Ref(nlri{
+				removePrefix(pfx, nlri{
 					family: family,
 					rd:     rd,
 					path:   path.ID,
-				}); ok {
-					removed += p.rib.RemovePrefix(pfx, route{
-						peer: pinfo.reference,
-						nlri: nlriRef,
-					})
-				}
+				})
 			}
 		}
 	}
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bmp
+
+import "github.com/osrg/gobgp/v4/pkg/packet/bgp"
+
+// Family is an AFI/SAFI, like ipv4-unicast or l3vpn-ipv6-unicast.
+type Family bgp.Family
+
+// UnmarshalText parses an AFI/SAFI.
+func (f *Family) UnmarshalText(input []byte) error {
+	family, err := bgp.GetFamily(string(input))
+	if err != nil {
+		return err
+	}
+	*f = Family(family)
+	return nil
+}
+
+// MarshalText turns an AFI/SAFI into a textual representation.
+func (f Family) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// String turns an AFI/SAFI into a textual representation.
+func (f Family) String() string {
+	return bgp.Family(f).String()
+}
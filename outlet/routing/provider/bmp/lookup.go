@@ -20,8 +20,9 @@ var errNoRouteFound = errors.New("no route found")
 // provided next hop if provided. This is somewhat approximate because
 // we use the best route we have, while the exporter may not have this
 // best route available. The returned result should not be modified!
-// The last parameter, the agent, is ignored by this provider.
-func (p *Provider) Lookup(_ context.Context, ip, nh, _ netip.Addr) (LookupResult, error) {
+// The agent is ignored by this provider. When the route distinguisher is not 0,
+// only routes from the matching VRF are considered.
+func (p *Provider) Lookup(_ context.Context, ip, nh, _ netip.Addr, rd uint64) (LookupResult, error) {
 	if !p.config.CollectASNs && !p.config.CollectASPaths && !p.config.CollectCommunities {
 		return LookupResult{}, nil
 	}
@@ -34,7 +35,11 @@ func (p *Provider) Lookup(_ context.Context, ip, nh, _ netip.Addr) (LookupResult
 	// Find the best route, preferring exact next hop match
 	var selectedRoute route
 	routeFound := false
-	for route := range p.rib.IterateRoutes(ip) {
+	routes := p.rib.IterateRoutes(ip)
+	if rd != 0 {
+		routes = p.rib.IterateRoutesWithRD(ip, RD(rd))
+	}
+	for route := range routes {
 		if !routeFound {
 			selectedRoute = route
 			routeFound = true
@@ -12,6 +12,7 @@ type metrics struct {
 	routes            *reporter.GaugeVec
 	bufferSize        *reporter.GaugeVec
 	ignoredNlri       *reporter.CounterVec
+	rejectedRoutes    *reporter.CounterVec
 	messages          *reporter.CounterVec
 	errors            *reporter.CounterVec
 	ignored           *reporter.CounterVec
@@ -64,6 +65,13 @@ func (p *Provider) initMetrics() {
 		},
 		[]string{"exporter", "type"},
 	)
+	p.metrics.rejectedRoutes = p.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "rejected_routes_total",
+			Help: "Number of routes rejected by the peer group policy.",
+		},
+		[]string{"exporter", "reason"},
+	)
 	p.metrics.messages = p.r.CounterVec(
 		reporter.CounterOpts{
 			Name: "received_messages_total",
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bmp
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/osrg/gobgp/v4/pkg/packet/bgp"
+)
+
+// peerGroup is a group of peers connecting to the same listening endpoint and
+// sharing the same policy.
+type peerGroup struct {
+	name        string
+	listen      string
+	address     net.Addr
+	maxPrefixes uint
+	families    map[bgp.Family]struct{}
+}
+
+// newPeerGroup creates a new peer group.
+func newPeerGroup(name, listen string, maxPrefixes uint, families []Family) *peerGroup {
+	g := &peerGroup{
+		name:        name,
+		listen:      listen,
+		maxPrefixes: maxPrefixes,
+	}
+	if len(families) > 0 {
+		g.families = make(map[bgp.Family]struct{})
+		for _, family := range families {
+			g.families[bgp.Family(family)] = struct{}{}
+		}
+	}
+	return g
+}
+
+// newPeerGroups creates the peer groups from the configuration. The first one
+// is the default peer group.
+func newPeerGroups(config Configuration) ([]*peerGroup, error) {
+	groups := []*peerGroup{newPeerGroup("default", config.Listen, config.MaxPrefixes, config.Families)}
+	names := map[string]struct{}{"default": {}}
+	for _, group := range config.PeerGroups {
+		if _, ok := names[group.Name]; ok {
+			return nil, fmt.Errorf("duplicate peer group %q", group.Name)
+		}
+		names[group.Name] = struct{}{}
+		groups = append(groups, newPeerGroup(group.Name, group.Listen, group.MaxPrefixes, group.Families))
+	}
+	return groups, nil
+}
+
+// isAcceptedFamily tells if the provided family is accepted for this peer
+// group.
+func (g *peerGroup) isAcceptedFamily(family bgp.Family) bool {
+	if len(g.families) == 0 {
+		return true
+	}
+	_, ok := g.families[family]
+	return ok
+}
@@ -240,6 +240,30 @@ func (r *rib) IterateRoutes(ip netip.Addr) iter.Seq[route] {
 	}
 }
 
+// IterateRoutesWithRD will iterate on all the routes matching the provided IP
+// address and route distinguisher. The most specific prefix with at least one
+// route for this route distinguisher is selected.
+func (r *rib) IterateRoutesWithRD(ip netip.Addr, rd RD) iter.Seq[route] {
+	return func(yield func(route) bool) {
+		ip = ip.Unmap()
+		for _, prefixIdx := range r.tree.Supernets(netip.PrefixFrom(ip, ip.BitLen())) {
+			found := false
+			for route := range r.iterateRoutesForPrefixIndex(prefixIdx) {
+				if r.nlris.Get(route.nlri).rd != rd {
+					continue
+				}
+				found = true
+				if !yield(route) {
+					return
+				}
+			}
+			if found {
+				return
+			}
+		}
+	}
+}
+
 // AddPrefix add a new route to the RIB. It returns the number of routes really added.
 func (r *rib) AddPrefix(prefix netip.Prefix, newRoute route) int {
 	var prefixIdx prefixIndex
@@ -28,6 +28,7 @@ type Provider struct {
 	t           tomb.Tomb
 	config      Configuration
 	acceptedRDs map[RD]struct{}
+	groups      []*peerGroup
 	active      atomic.Bool
 
 	address net.Addr
@@ -62,6 +63,11 @@ func (configuration Configuration) New(r *reporter.Reporter, dependencies Depend
 		rib:   newRIB(),
 		peers: make(map[peerKey]*peerInfo),
 	}
+	groups, err := newPeerGroups(configuration)
+	if err != nil {
+		return nil, err
+	}
+	p.groups = groups
 	if len(p.config.RDs) > 0 {
 		p.acceptedRDs = make(map[RD]struct{})
 		for _, rd := range p.config.RDs {
@@ -78,64 +84,82 @@ func (configuration Configuration) New(r *reporter.Reporter, dependencies Depend
 // Start starts the BMP provider.
 func (p *Provider) Start() error {
 	p.r.Info().Msg("starting BMP provider")
-	listener, err := net.Listen("tcp", p.config.Listen)
-	if err != nil {
-		return fmt.Errorf("unable to listen to %v: %w", p.config.Listen, err)
+	listeners := []net.Listener{}
+	for _, group := range p.groups {
+		listener, err := net.Listen("tcp", group.listen)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return fmt.Errorf("unable to listen to %v: %w", group.listen, err)
+		}
+		group.address = listener.Addr()
+		listeners = append(listeners, listener)
 	}
-	p.address = listener.Addr()
+	p.address = p.groups[0].address
 
-	// Listener
+	// Listeners
+	for idx, listener := range listeners {
+		group := p.groups[idx]
+		p.t.Go(func() error {
+			return p.acceptConnections(listener, group)
+		})
+	}
 	p.t.Go(func() error {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				if p.t.Alive() {
-					return fmt.Errorf("cannot accept new connection: %w", err)
-				}
-				return nil
+		<-p.t.Dying()
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return nil
+	})
+	return nil
+}
+
+// acceptConnections accepts new connections for the provided peer group.
+func (p *Provider) acceptConnections(listener net.Listener, group *peerGroup) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if p.t.Alive() {
+				return fmt.Errorf("cannot accept new connection: %w", err)
 			}
-			tcpConn := conn.(*net.TCPConn)
-			remote := conn.RemoteAddr().(*net.TCPAddr)
-			exporterIP, _ := netip.AddrFromSlice(remote.IP)
-			exporter := netip.AddrPortFrom(exporterIP, uint16(remote.Port))
-			exporterStr := exporter.Addr().Unmap().String()
-			if p.config.ReceiveBuffer > 0 {
-				if err := tcpConn.SetReadBuffer(int(p.config.ReceiveBuffer)); err != nil {
-					p.r.Warn().
-						Str("error", err.Error()).
-						Str("listen", p.config.Listen).
-						Msgf("unable to set requested TCP receive buffer size (%d bytes)", p.config.ReceiveBuffer)
-				}
+			return nil
+		}
+		tcpConn := conn.(*net.TCPConn)
+		remote := conn.RemoteAddr().(*net.TCPAddr)
+		exporterIP, _ := netip.AddrFromSlice(remote.IP)
+		exporter := netip.AddrPortFrom(exporterIP, uint16(remote.Port))
+		exporterStr := exporter.Addr().Unmap().String()
+		if p.config.ReceiveBuffer > 0 {
+			if err := tcpConn.SetReadBuffer(int(p.config.ReceiveBuffer)); err != nil {
+				p.r.Warn().
+					Str("error", err.Error()).
+					Str("listen", group.listen).
+					Msgf("unable to set requested TCP receive buffer size (%d bytes)", p.config.ReceiveBuffer)
 			}
-			// Verify the buffer size was actually set correctly
-			if syscallConn, err := tcpConn.SyscallConn(); err == nil {
-				var actualSize int
-				syscallConn.Control(func(fd uintptr) {
-					if val, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF); err == nil {
-						actualSize = val
-					}
-				})
-				p.metrics.bufferSize.WithLabelValues(exporterStr).Set(float64(actualSize))
-				if p.config.ReceiveBuffer > 0 && actualSize < int(p.config.ReceiveBuffer) {
-					p.r.Warn().
-						Str("listen", p.config.Listen).
-						Int("requested", int(p.config.ReceiveBuffer)).
-						Int("actual", actualSize).
-						Msg("TCP receive buffer size was capped by system limits (check net.core.rmem_max)")
+		}
+		// Verify the buffer size was actually set correctly
+		if syscallConn, err := tcpConn.SyscallConn(); err == nil {
+			var actualSize int
+			syscallConn.Control(func(fd uintptr) {
+				if val, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF); err == nil {
+					actualSize = val
 				}
-			}
-			p.active.Store(true)
-			p.t.Go(func() error {
-				return p.serveConnection(tcpConn, exporter, exporterStr)
 			})
+			p.metrics.bufferSize.WithLabelValues(exporterStr).Set(float64(actualSize))
+			if p.config.ReceiveBuffer > 0 && actualSize < int(p.config.ReceiveBuffer) {
+				p.r.Warn().
+					Str("listen", group.listen).
+					Int("requested", int(p.config.ReceiveBuffer)).
+					Int("actual", actualSize).
+					Msg("TCP receive buffer size was capped by system limits (check net.core.rmem_max)")
+			}
 		}
-	})
-	p.t.Go(func() error {
-		<-p.t.Dying()
-		listener.Close()
-		return nil
-	})
-	return nil
+		p.active.Store(true)
+		p.t.Go(func() error {
+			return p.serveConnection(tcpConn, group, exporter, exporterStr)
+		})
+	}
 }
 
 // Stop stops the BMP provider.
@@ -428,6 +428,60 @@ func TestBMP(t *testing.T) {
 		}
 	})
 
+	t.Run("peer group with families and max prefixes", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		config := DefaultConfiguration()
+		configP := config.(Configuration)
+		configP.PeerGroups = []PeerGroupConfiguration{
+			{
+				Name:        "edge",
+				MaxPrefixes: 2,
+				Families:    []Family{Family(bgp.RF_IPv4_UC), Family(bgp.RF_IPv6_UC)},
+			},
+		}
+		p, _ := NewMock(t, r, configP)
+		helpers.StartStop(t, p)
+		conn, err := net.Dial("tcp", p.PeerGroupLocalAddr("edge").String())
+		if err != nil {
+			t.Fatalf("Dial() error:\n%+v", err)
+		}
+		defer conn.Close()
+
+		send(t, conn, "bmp-init.pcap")
+		send(t, conn, "bmp-peers-up.pcap")
+		send(t, conn, "bmp-eor.pcap")
+		send(t, conn, "bmp-reach.pcap")
+		time.Sleep(20 * time.Millisecond)
+		gotMetrics := r.GetMetrics("akvorado_outlet_routing_provider_bmp_", "routes", "rejected_routes_total")
+		expectedMetrics := map[string]string{
+			`rejected_routes_total{exporter="127.0.0.1",reason="family"}`:       "7",
+			`rejected_routes_total{exporter="127.0.0.1",reason="max-prefixes"}`: "4",
+			`routes{exporter="127.0.0.1"}`:                                      "6",
+		}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Errorf("Metrics (-got, +want):\n%s", diff)
+		}
+
+		expectedRIB := map[netip.Addr][]string{
+			netip.MustParseAddr("2001:db8::3"): {
+				"[ipv6-unicast] 2001:db8:2::/64 via 2001:db8::3 0:0/0 12322 [65013 65013 1299 1299 1299 12322] [4260691998] []",
+				"[ipv6-unicast] 2001:db8::2/127 via 2001:db8::3 0:0/0 65013 [65013] [] []",
+			},
+			netip.MustParseAddr("2001:db8::7"): {
+				"[ipv4-unicast] 192.0.2.6/31 via 192.0.2.7 0:0/0 65017 [65017] [] []",
+				"[ipv6-unicast] 2001:db8:2::/64 via 2001:db8::7 0:0/0 12322 [65017 65017 1299 1299 1299 12322] [4260954142] [{65017 400 2}]",
+			},
+			netip.MustParseAddr("192.0.2.1"): {
+				"[ipv4-unicast] 192.0.2.0/31 via 192.0.2.1 0:0/0 65011 [65011] [] []",
+				"[ipv4-unicast] 198.51.100.128/25 via 192.0.2.1 0:0/0 396919 [65011 65011 174 29447 396919] [4260560908] []",
+			},
+		}
+		gotRIB := dumpRIB(t, p)
+		if diff := helpers.Diff(gotRIB, expectedRIB); diff != "" {
+			t.Errorf("RIB (-got, +want):\n%s", diff)
+		}
+	})
+
 	t.Run("init, peers up, eor, reach, unreach", func(t *testing.T) {
 		r := reporter.NewMock(t)
 		config := DefaultConfiguration()
@@ -1036,7 +1090,7 @@ func TestBMP(t *testing.T) {
 
 		lookup, _ := p.Lookup(context.Background(),
 			netip.MustParseAddr("2001:db8:1::10"),
-			netip.MustParseAddr("2001:db8::a"), netip.Addr{})
+			netip.MustParseAddr("2001:db8::a"), netip.Addr{}, 0)
 		if lookup.ASN != 174 {
 			t.Errorf("Lookup() == %d, expected 174", lookup.ASN)
 		}
@@ -1051,13 +1105,13 @@ func TestBMP(t *testing.T) {
 
 		lookup, _ = p.Lookup(context.Background(),
 			netip.MustParseAddr("2001:db8:1::10"),
-			netip.MustParseAddr("2001:db8::a"), netip.Addr{})
+			netip.MustParseAddr("2001:db8::a"), netip.Addr{}, 0)
 		if lookup.ASN != 176 {
 			t.Errorf("Lookup() == %d, expected 176", lookup.ASN)
 		}
 		lookup, _ = p.Lookup(context.Background(),
 			netip.MustParseAddr("2001:db8:1::10"),
-			netip.MustParseAddr("2001:db8::b"), netip.Addr{})
+			netip.MustParseAddr("2001:db8::b"), netip.Addr{}, 0)
 		if lookup.ASN != 174 {
 			t.Errorf("Lookup() == %d, expected 174", lookup.ASN)
 		}
@@ -1072,13 +1126,13 @@ func TestBMP(t *testing.T) {
 
 		lookup, _ := p.Lookup(context.Background(),
 			netip.MustParseAddr("::ffff:192.0.2.2"),
-			netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{})
+			netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{}, 0)
 		if lookup.ASN != 174 {
 			t.Errorf("Lookup() == %d, expected 174", lookup.ASN)
 		}
 		lookup, _ = p.Lookup(context.Background(),
 			netip.MustParseAddr("::ffff:192.0.2.254"),
-			netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{})
+			netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{}, 0)
 		if lookup.ASN != 0 {
 			t.Errorf("Lookup() == %d, expected 0", lookup.ASN)
 		}
@@ -1096,8 +1150,44 @@ func TestBMP(t *testing.T) {
 		// proper network, all routers should know the more specific.
 		lookup, _ := p.Lookup(context.Background(),
 			netip.MustParseAddr("::ffff:192.168.145.10"),
-			netip.MustParseAddr("::ffff:203.0.113.14"), netip.Addr{})
+			netip.MustParseAddr("::ffff:203.0.113.14"), netip.Addr{}, 0)
+		expected := provider.LookupResult{
+			ASN:     1234,
+			ASPath:  []uint32{1234},
+			NetMask: 22,
+			NextHop: netip.MustParseAddr("::ffff:203.0.113.15"),
+		}
+		if diff := helpers.Diff(lookup, expected); diff != "" {
+			t.Errorf("Lookup() (-got, +want):\n%s", diff)
+		}
+	})
+
+	t.Run("VRF", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		config := DefaultConfiguration()
+		p, _ := NewMock(t, r, config)
+		helpers.StartStop(t, p)
+		p.PopulateRIB(t)
+
+		// Without a VRF, the most specific route is selected.
+		lookup, _ := p.Lookup(context.Background(),
+			netip.MustParseAddr("::ffff:192.168.146.10"),
+			netip.Addr{}, netip.Addr{}, 0)
 		expected := provider.LookupResult{
+			ASN:     4321,
+			ASPath:  []uint32{4321},
+			NetMask: 24,
+			NextHop: netip.MustParseAddr("::ffff:203.0.113.16"),
+		}
+		if diff := helpers.Diff(lookup, expected); diff != "" {
+			t.Errorf("Lookup() (-got, +want):\n%s", diff)
+		}
+
+		// With a VRF, we use the most specific route in this VRF.
+		lookup, _ = p.Lookup(context.Background(),
+			netip.MustParseAddr("::ffff:192.168.146.10"),
+			netip.Addr{}, netip.Addr{}, 10)
+		expected = provider.LookupResult{
 			ASN:     1234,
 			ASPath:  []uint32{1234},
 			NetMask: 22,
@@ -1106,6 +1196,20 @@ func TestBMP(t *testing.T) {
 		if diff := helpers.Diff(lookup, expected); diff != "" {
 			t.Errorf("Lookup() (-got, +want):\n%s", diff)
 		}
+		lookup, _ = p.Lookup(context.Background(),
+			netip.MustParseAddr("::ffff:192.168.146.10"),
+			netip.Addr{}, netip.Addr{}, 20)
+		if lookup.ASN != 4321 {
+			t.Errorf("Lookup() == %d, expected 4321", lookup.ASN)
+		}
+
+		// With an unknown VRF, there is no route.
+		_, err := p.Lookup(context.Background(),
+			netip.MustParseAddr("::ffff:192.168.146.10"),
+			netip.Addr{}, netip.Addr{}, 30)
+		if err == nil {
+			t.Error("Lookup() did not error")
+		}
 	})
 
 	t.Run("check buffer size", func(t *testing.T) {
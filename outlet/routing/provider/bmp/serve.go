@@ -15,9 +15,9 @@ import (
 )
 
 // serveConnection handle the connection from an exporter.
-func (p *Provider) serveConnection(conn *net.TCPConn, exporter netip.AddrPort, exporterStr string) error {
+func (p *Provider) serveConnection(conn *net.TCPConn, group *peerGroup, exporter netip.AddrPort, exporterStr string) error {
 	p.metrics.openedConnections.WithLabelValues(exporterStr).Inc()
-	logger := p.r.With().Str("exporter", exporterStr).Logger()
+	logger := p.r.With().Str("exporter", exporterStr).Str("group", group.name).Logger()
 	conn.SetLinger(0)
 
 	// Stop the connection when exiting this method or when dying
@@ -197,11 +197,11 @@ func (p *Provider) serveConnection(conn *net.TCPConn, exporter netip.AddrPort, e
 			logger.Info().Msg("termination message received")
 			return nil
 		case *bmp.BMPPeerUpNotification:
-			p.handlePeerUpNotification(pkey, body)
+			p.handlePeerUpNotification(pkey, group, body)
 		case *bmp.BMPPeerDownNotification:
 			p.handlePeerDownNotification(pkey)
 		case *bmp.BMPRouteMonitoring:
-			p.handleRouteMonitoring(pkey, body)
+			p.handleRouteMonitoring(pkey, group, body)
 		}
 	}
 }
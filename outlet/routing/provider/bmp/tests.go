@@ -8,6 +8,7 @@ package bmp
 import (
 	"net"
 	"net/netip"
+	"slices"
 	"testing"
 
 	"akvorado/common/daemon"
@@ -28,6 +29,10 @@ func NewMock(t *testing.T, r *reporter.Reporter, conf provider.Configuration) (*
 	mockClock := clock.NewMock()
 	confP := conf.(Configuration)
 	confP.Listen = "127.0.0.1:0"
+	confP.PeerGroups = slices.Clone(confP.PeerGroups)
+	for idx := range confP.PeerGroups {
+		confP.PeerGroups[idx].Listen = "127.0.0.1:0"
+	}
 	p, err := confP.New(r, Dependencies{
 		Daemon: daemon.NewMock(t),
 		Clock:  mockClock,
@@ -47,7 +52,7 @@ func (p *Provider) PopulateRIB(t *testing.T) {
 		ip:       netip.MustParseAddr("::ffff:203.0.113.4"),
 		ptype:    bmp.BMP_PEER_TYPE_GLOBAL,
 		asn:      64500,
-	})
+	}, p.groups[0])
 	p.rib.AddPrefix(netip.MustParsePrefix("::ffff:192.0.2.0/123"), route{
 		peer:    pinfo.reference,
 		nlri:    p.rib.nlris.Put(nlri{family: bgp.RF_IPv4_UC, path: 1}),
@@ -111,6 +116,16 @@ func (p *Provider) PopulateRIB(t *testing.T) {
 		}),
 		prefixLen: 96 + 22,
 	})
+	p.rib.AddPrefix(netip.MustParsePrefix("::ffff:192.168.146.0/120"), route{
+		peer:    pinfo.reference,
+		nlri:    p.rib.nlris.Put(nlri{rd: 20, family: bgp.RF_IPv4_UC, path: 0}),
+		nextHop: p.rib.nextHops.Put(nextHop(netip.MustParseAddr("::ffff:203.0.113.16"))),
+		attributes: p.rib.rtas.Put(routeAttributes{
+			asn:    4321,
+			asPath: []uint32{4321},
+		}),
+		prefixLen: 96 + 24,
+	})
 	p.rib.AddPrefix(netip.MustParsePrefix("::ffff:192.168.148.0/118"), route{
 		peer:    pinfo.reference,
 		nlri:    p.rib.nlris.Put(nlri{rd: 10, family: bgp.RF_IPv4_UC, path: 0}),
@@ -138,6 +153,17 @@ func (p *Provider) LocalAddr() net.Addr {
 	return p.address
 }
 
+// PeerGroupLocalAddr returns the address the BMP collector is listening to
+// for the provided peer group.
+func (p *Provider) PeerGroupLocalAddr(name string) net.Addr {
+	for _, group := range p.groups {
+		if group.name == name {
+			return group.address
+		}
+	}
+	return nil
+}
+
 // MustParseRD parse a route distinguisher and panic on error.
 func MustParseRD(input string) RD {
 	var output RD
@@ -35,8 +35,9 @@ type Dependencies struct {
 // Provider is the interface a provider should implement.
 type Provider interface {
 	// Lookup asks the provider about information for a given IP address and
-	// next-hop.
-	Lookup(ctx context.Context, ip netip.Addr, nh netip.Addr, agent netip.Addr) (LookupResult, error)
+	// next-hop. When not 0, the route distinguisher restricts the lookup to
+	// the matching VRF.
+	Lookup(ctx context.Context, ip netip.Addr, nh netip.Addr, agent netip.Addr, rd uint64) (LookupResult, error)
 }
 
 // Configuration defines an interface to configure a provider.
@@ -74,10 +74,11 @@ type stopper interface {
 	Stop() error
 }
 
-// Lookup uses the selected provider to get an answer.
-func (c *Component) Lookup(ctx context.Context, ip, nh, agent netip.Addr) provider.LookupResult {
+// Lookup uses the selected provider to get an answer. When not 0, the route
+// distinguisher restricts the lookup to the matching VRF.
+func (c *Component) Lookup(ctx context.Context, ip, nh, agent netip.Addr, rd uint64) provider.LookupResult {
 	c.metrics.routingLookups.Inc()
-	result, err := c.provider.Lookup(ctx, ip, nh, agent)
+	result, err := c.provider.Lookup(ctx, ip, nh, agent, rd)
 	if err != nil {
 		c.metrics.routingLookupsFailed.Inc()
 		c.errLogger.Err(err).Msgf("routing: error while looking up %s at %s", ip.String(), agent.String())
@@ -20,20 +20,20 @@ func TestRoutingComponent(t *testing.T) {
 
 	lookup := c.Lookup(context.Background(),
 		netip.MustParseAddr("::ffff:192.0.2.2"),
-		netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{})
+		netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{}, 0)
 	if lookup.ASN != 174 {
 		t.Errorf("Lookup() == %d, expected 174", lookup.ASN)
 	}
 	lookup = c.Lookup(context.Background(),
 		netip.MustParseAddr("::ffff:192.0.2.254"),
-		netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{})
+		netip.MustParseAddr("::ffff:198.51.100.200"), netip.Addr{}, 0)
 	if lookup.ASN != 0 {
 		t.Errorf("Lookup() == %d, expected 0", lookup.ASN)
 	}
 
 	lookup = c.Lookup(context.Background(),
 		netip.MustParseAddr("::ffff:192.168.148.1"),
-		netip.MustParseAddr("::ffff:203.0.113.14"), netip.Addr{})
+		netip.MustParseAddr("::ffff:203.0.113.14"), netip.Addr{}, 0)
 	if lookup.NetMask != 32 {
 		t.Errorf("Lookup() == NetMask %d, expected 32", lookup.NetMask)
 	}
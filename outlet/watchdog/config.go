@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package watchdog
+
+import "time"
+
+// Configuration describes the configuration for the memory watchdog.
+type Configuration struct {
+	// MemoryLimit is the memory limit in bytes. When 0, the limit set with
+	// GOMEMLIMIT is used. When none is set, the watchdog is disabled.
+	MemoryLimit uint64
+	// CheckInterval defines how often the memory usage is checked.
+	CheckInterval time.Duration `validate:"min=100ms"`
+	// ReduceBatchSizeThreshold is the fraction of the memory limit above
+	// which smaller batches are sent to ClickHouse.
+	ReduceBatchSizeThreshold float64 `validate:"gt=0,lte=1"`
+	// PauseReexportThreshold is the fraction of the memory limit above which
+	// the re-export of flows is paused.
+	PauseReexportThreshold float64 `validate:"gtefield=ReduceBatchSizeThreshold,lte=1"`
+	// FlushCachesThreshold is the fraction of the memory limit above which
+	// the enrichment caches are flushed.
+	FlushCachesThreshold float64 `validate:"gtefield=PauseReexportThreshold,lte=1"`
+	// Hysteresis is the fraction of the memory limit the memory usage should
+	// go under a threshold before reverting the associated action.
+	Hysteresis float64 `validate:"gte=0,lt=1"`
+}
+
+// DefaultConfiguration represents the default configuration for the memory
+// watchdog.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		CheckInterval:            time.Second,
+		ReduceBatchSizeThreshold: 0.75,
+		PauseReexportThreshold:   0.85,
+		FlushCachesThreshold:     0.95,
+		Hysteresis:               0.05,
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package watchdog monitors the memory usage of the outlet and progressively
+// sheds load when it gets close to the configured limit: first by sending
+// smaller batches to ClickHouse, then by pausing the re-export of flows, and
+// finally by flushing the enrichment caches.
+package watchdog
+
+import (
+	"runtime/debug"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/reporter"
+	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/metadata"
+	"akvorado/outlet/reexport"
+)
+
+// Component represents the memory watchdog.
+type Component struct {
+	r      *reporter.Reporter
+	d      *Dependencies
+	t      tomb.Tomb
+	config Configuration
+
+	limit       uint64
+	level       int
+	memoryUsage func() uint64
+
+	metrics struct {
+		usage   reporter.Gauge
+		limit   reporter.Gauge
+		level   reporter.Gauge
+		actions *reporter.CounterVec
+	}
+}
+
+// Dependencies define the dependencies of the memory watchdog.
+type Dependencies struct {
+	Daemon     daemon.Component
+	ClickHouse clickhouse.Component
+	Reexport   *reexport.Component
+	Metadata   *metadata.Component
+}
+
+// New creates a new memory watchdog.
+func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	c := Component{
+		r:           r,
+		d:           &dependencies,
+		config:      configuration,
+		limit:       configuration.MemoryLimit,
+		memoryUsage: memoryUsage,
+	}
+	if c.limit == 0 {
+		c.limit = goMemoryLimit()
+	}
+	c.d.Daemon.Track(&c.t, "outlet/watchdog")
+
+	c.metrics.usage = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "memory_usage_bytes",
+			Help: "Memory used by the process.",
+		})
+	c.metrics.limit = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "memory_limit_bytes",
+			Help: "Memory limit enforced by the watchdog.",
+		})
+	c.metrics.level = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "shedding_level",
+			Help: "Current load shedding level (0 when not shedding).",
+		})
+	c.metrics.actions = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "shedding_actions_total",
+			Help: "Number of load shedding actions.",
+		},
+		[]string{"action"})
+	c.metrics.limit.Set(float64(c.limit))
+	return &c, nil
+}
+
+// Start starts the memory watchdog.
+func (c *Component) Start() error {
+	if c.limit == 0 {
+		c.r.Debug().Msg("no memory limit, memory watchdog disabled")
+		return nil
+	}
+	c.r.Info().Uint64("limit", c.limit).Msg("starting memory watchdog")
+	c.t.Go(func() error {
+		ticker := time.NewTicker(c.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-ticker.C:
+				c.check()
+			}
+		}
+	})
+	return nil
+}
+
+// Stop stops the memory watchdog.
+func (c *Component) Stop() error {
+	if c.limit == 0 {
+		return nil
+	}
+	defer c.r.Info().Msg("memory watchdog stopped")
+	c.r.Info().Msg("stopping memory watchdog")
+	c.t.Kill(nil)
+	return c.t.Wait()
+}
+
+// check checks the memory usage and adjusts the shedding level.
+func (c *Component) check() {
+	usage := c.memoryUsage()
+	c.metrics.usage.Set(float64(usage))
+	ratio := float64(usage) / float64(c.limit)
+
+	thresholds := []float64{
+		c.config.ReduceBatchSizeThreshold,
+		c.config.PauseReexportThreshold,
+		c.config.FlushCachesThreshold,
+	}
+	level := c.level
+	for level < len(thresholds) && ratio >= thresholds[level] {
+		level++
+	}
+	for level > 0 && ratio < thresholds[level-1]-c.config.Hysteresis {
+		level--
+	}
+	if level == c.level {
+		return
+	}
+	logger := c.r.Info()
+	if level > c.level {
+		logger = c.r.Warn()
+	}
+	logger.
+		Uint64("usage", usage).
+		Uint64("limit", c.limit).
+		Int("level", level).
+		Msg("memory pressure changed")
+	for c.level < level {
+		c.level++
+		c.apply(c.level, true)
+	}
+	for c.level > level {
+		c.apply(c.level, false)
+		c.level--
+	}
+	c.metrics.level.Set(float64(c.level))
+}
+
+// apply enables or disables the action associated with the provided level.
+func (c *Component) apply(level int, enable bool) {
+	var action string
+	switch level {
+	case 1:
+		c.d.ClickHouse.ReduceBatchSize(enable)
+		action = "reduce-batch-size"
+		if !enable {
+			action = "restore-batch-size"
+		}
+	case 2:
+		c.d.Reexport.Pause(enable)
+		action = "pause-reexport"
+		if !enable {
+			action = "resume-reexport"
+		}
+	case 3:
+		if !enable {
+			return
+		}
+		c.d.Metadata.FlushCache()
+		debug.FreeOSMemory()
+		action = "flush-caches"
+	}
+	c.metrics.actions.WithLabelValues(action).Inc()
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package watchdog
+
+import (
+	"testing"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/metadata"
+	"akvorado/outlet/reexport"
+)
+
+// clickhouseRecorder records calls to ReduceBatchSize().
+type clickhouseRecorder struct {
+	clickhouse.Component
+	reduced bool
+}
+
+func (c *clickhouseRecorder) ReduceBatchSize(reduce bool) {
+	c.reduced = reduce
+}
+
+func TestWatchdog(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := &clickhouseRecorder{Component: clickhouse.NewMock(t, nil)}
+	reexportComponent, err := reexport.New(r, reexport.DefaultConfiguration(), reexport.Dependencies{
+		Daemon: daemon.NewMock(t),
+		Schema: schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("reexport.New() error:\n%+v", err)
+	}
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemon.NewMock(t)})
+
+	config := DefaultConfiguration()
+	config.MemoryLimit = 1000
+	c, err := New(r, config, Dependencies{
+		Daemon:     daemon.NewMock(t),
+		ClickHouse: chComponent,
+		Reexport:   reexportComponent,
+		Metadata:   metadataComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	var usage uint64
+	c.memoryUsage = func() uint64 { return usage }
+
+	cases := []struct {
+		Pos     helpers.Pos
+		Usage   uint64
+		Level   int
+		Reduced bool
+		Actions map[string]string
+	}{
+		{helpers.Mark(), 500, 0, false, map[string]string{}},
+		{helpers.Mark(), 760, 1, true, map[string]string{
+			`reduce-batch-size`: "1",
+		}},
+		{helpers.Mark(), 720, 1, true, map[string]string{
+			`reduce-batch-size`: "1",
+		}},
+		{helpers.Mark(), 960, 3, true, map[string]string{
+			`reduce-batch-size`: "1",
+			`pause-reexport`:    "1",
+			`flush-caches`:      "1",
+		}},
+		{helpers.Mark(), 920, 3, true, map[string]string{
+			`reduce-batch-size`: "1",
+			`pause-reexport`:    "1",
+			`flush-caches`:      "1",
+		}},
+		{helpers.Mark(), 820, 2, true, map[string]string{
+			`reduce-batch-size`: "1",
+			`pause-reexport`:    "1",
+			`flush-caches`:      "1",
+		}},
+		{helpers.Mark(), 100, 0, false, map[string]string{
+			`reduce-batch-size`:  "1",
+			`pause-reexport`:     "1",
+			`flush-caches`:       "1",
+			`resume-reexport`:    "1",
+			`restore-batch-size`: "1",
+		}},
+	}
+	for _, tc := range cases {
+		usage = tc.Usage
+		c.check()
+		if c.level != tc.Level {
+			t.Errorf("%scheck(%d) level == %d, expected %d", tc.Pos, tc.Usage, c.level, tc.Level)
+		}
+		if chComponent.reduced != tc.Reduced {
+			t.Errorf("%scheck(%d) reduced batch size == %v, expected %v",
+				tc.Pos, tc.Usage, chComponent.reduced, tc.Reduced)
+		}
+		gotMetrics := r.GetMetrics("akvorado_outlet_watchdog_shedding_actions_total")
+		got := map[string]string{}
+		for k, v := range gotMetrics {
+			got[k[len(`{action="`):len(k)-len(`"}`)]] = v
+		}
+		if diff := helpers.Diff(got, tc.Actions); diff != "" {
+			t.Errorf("%scheck(%d) actions (-got, +want):\n%s", tc.Pos, tc.Usage, diff)
+		}
+	}
+}
+
+func TestWatchdogDisabled(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	c.limit = 0
+	helpers.StartStop(t, c)
+}
+
+func TestMemoryUsage(t *testing.T) {
+	if usage := memoryUsage(); usage == 0 {
+		t.Fatal("memoryUsage() == 0")
+	}
+}